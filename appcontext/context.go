@@ -19,11 +19,17 @@ package appcontext
 import (
 	syscontext "context"
 	"errors"
+	"fmt"
+	"time"
 
 	"github.com/antoniomtz/app-functions-sdk-go/internal/common"
+	"github.com/antoniomtz/app-functions-sdk-go/pkg/secrets"
 	"github.com/edgexfoundry/go-mod-core-contracts/clients"
+	"github.com/edgexfoundry/go-mod-core-contracts/clients/command"
 	"github.com/edgexfoundry/go-mod-core-contracts/clients/coredata"
 	"github.com/edgexfoundry/go-mod-core-contracts/clients/logger"
+	"github.com/edgexfoundry/go-mod-core-contracts/clients/notifications"
+	"github.com/edgexfoundry/go-mod-core-contracts/models"
 )
 
 // Context ...
@@ -34,30 +40,316 @@ type Context struct {
 	EventChecksum string
 	// This is the ID used to track the EdgeX event through entire EdgeX framework.
 	CorrelationID string
-	// OutputData is used for specifying the data that is to be outputted. Leverage the .Complete() function to set.
+	// Name of the device which generated the EdgeX Event
+	DeviceName string
+	// Time in milliseconds at which Core Data created the EdgeX Event
+	EventCreated int64
+	// Replayed indicates the EdgeX Event is being reprocessed, i.e. during a backfill run. MarkAsPushed does nothing for replayed events.
+	Replayed bool
+	// RawPayload is the payload as received by the trigger, after decompression and before it was decoded into the EdgeX Event
+	RawPayload []byte
+	// ContentEncoding of the received payload, i.e. gzip, from the Content-Encoding header received by the HTTP trigger.
+	// Payloads without a content encoding are decompressed when they start with the gzip or zlib magic bytes.
+	ContentEncoding string
+	// Deadline is the time by which the processing of the EdgeX Event must complete, set from Writable.PipelineTimeout.
+	// Calls to the EdgeX clients made with CallWithDeadline are abandoned at the deadline. Zero when there is no deadline.
+	Deadline time.Time
+	// TraceParent is the W3C trace context of the span of the running function while tracing is enabled, i.e. to pass
+	// in the traceparent header of outgoing requests. The HTTP trigger sets it from the traceparent header received.
+	TraceParent string
+	// Ctx is cancelled when the service stops and the events being processed haven't finished within
+	// Service.ShutdownTimeout, so long-running functions such as exports can abort rather than hang the process. It is
+	// set by the triggers of the SDK. Leverage the .RequestContext() function, which is never nil, to use it.
+	Ctx syscontext.Context
+	// ReceivedTopic is the topic the EdgeX Event was received on by the message bus trigger
+	ReceivedTopic string
+	// Signature of the RawPayload, received in the X-Signature header by the HTTP trigger
+	Signature string
+	// OutputData is used for specifying the data that is to be outputted. Leverage the .SetResponseData() function to set.
 	OutputData []byte
+	// ResponseContentType is the content type of the OutputData. Leverage the .SetResponseContentType() function to set.
+	// The trigger uses application/json when not set.
+	ResponseContentType string
 	// This holds the configuration for your service. This is the preferred way to access your custom application settings that have been set in the configuration.
 	Configuration common.ConfigurationStruct
 	// This is exposed to allow logging following the preferred logging strategy within EdgeX.
 	LoggingClient logger.LoggingClient
 	EventClient   coredata.EventClient
+	// CommandClient issues commands to devices through Core Command. It is only set when the Command client is
+	// configured in the [Clients] section of the configuration.
+	CommandClient command.CommandClient
+	// NotificationsClient sends notifications through Support Notifications. It is only set when the Notifications
+	// client is configured in the [Clients] section of the configuration.
+	NotificationsClient notifications.NotificationsClient
+	// SecretProvider retrieves secrets from the secret store configured in the [SecretStore] section of the
+	// configuration. Leverage the .GetSecret() function to retrieve secrets.
+	SecretProvider secrets.SecretProvider
+	// batchedEvents are marked as pushed along with the EdgeX Event
+	batchedEvents []EventReference
+	// values holds the metadata set by pipeline functions with SetValue
+	values map[string]interface{}
 }
 
 // Complete is optional and provides a way to return the specified data.
 // In the case of an HTTP Trigger, the data will be returned as the http response.
 // In the case of the message bus trigger, the data will be placed on the specifed
 // message bus publish topic and host in the configuration.
+//
+// Deprecated: use SetResponseData, along with SetResponseContentType for data which isn't JSON.
 func (context *Context) Complete(output []byte) {
+	context.SetResponseData(output)
+}
+
+// SetResponseData is optional and provides a way to return the specified data.
+// In the case of an HTTP Trigger, the data will be returned as the http response.
+// In the case of the message bus trigger, the data will be placed on the specifed
+// message bus publish topic and host in the configuration.
+func (context *Context) SetResponseData(output []byte) {
 	context.OutputData = output
 }
 
-// MarkAsPushed ...
+// SetResponseContentType sets the content type of the data set with SetResponseData, i.e. application/xml.
+// In the case of an HTTP Trigger, it is returned as the Content-Type header of the http response.
+// In the case of the message bus trigger, it is the content type of the published message.
+// When not set, the data is assumed to be application/json.
+func (context *Context) SetResponseContentType(contentType string) {
+	context.ResponseContentType = contentType
+}
+
+// SetValue stores a value under the key for the remaining functions in the pipeline, allowing functions to pass
+// derived metadata, such as a classification or a routing hint, along with the data they return.
+// Values are kept for the processing of the current EdgeX Event only.
+func (context *Context) SetValue(key string, value interface{}) {
+	if context.values == nil {
+		context.values = map[string]interface{}{}
+	}
+	context.values[key] = value
+}
+
+// GetValue returns the value stored under the key with SetValue, and whether the key was set
+func (context *Context) GetValue(key string) (interface{}, bool) {
+	value, ok := context.values[key]
+	return value, ok
+}
+
+// GetSecret returns the secrets stored at the path in the configured secret store, or only those for the specified
+// keys, allowing functions to retrieve API keys and passwords at runtime rather than from plain text configuration
+func (context *Context) GetSecret(path string, keys ...string) (map[string]string, error) {
+	if context.SecretProvider == nil {
+		return nil, errors.New("no secret store configured")
+	}
+	return context.SecretProvider.GetSecrets(path, keys...)
+}
+
+// EventReference identifies an EdgeX Event to be marked as pushed once its data is exported
+type EventReference struct {
+	ID            string
+	Checksum      string
+	CorrelationID string
+	Created       int64
+	Replayed      bool
+}
+
+// EventReference returns the reference to the EdgeX Event being processed, which batching functions retain
+// until the batch holding its data is exported
+func (context *Context) EventReference() EventReference {
+	return EventReference{
+		ID:            context.EventID,
+		Checksum:      context.EventChecksum,
+		CorrelationID: context.CorrelationID,
+		Created:       context.EventCreated,
+		Replayed:      context.Replayed,
+	}
+}
+
+// AddBatchedEvents adds events whose data was exported along with that of the current EdgeX Event, so that
+// MarkAsPushed marks them all as pushed
+func (context *Context) AddBatchedEvents(events ...EventReference) {
+	context.batchedEvents = append(context.batchedEvents, events...)
+}
+
+// BatchedEvents returns the events added with AddBatchedEvents which have not been marked as pushed yet
+func (context *Context) BatchedEvents() []EventReference {
+	return context.batchedEvents
+}
+
+// Detached returns a copy of the context for work done on behalf of the EdgeX Event after its pipeline returned, such
+// as marking the events of a batch exported later as pushed. The copy has no Deadline, isn't cancelled along with Ctx,
+// and holds no batched events, values or response data.
+func (context *Context) Detached() *Context {
+	detached := *context
+	detached.Deadline = time.Time{}
+	detached.Ctx = nil
+	detached.OutputData = nil
+	detached.batchedEvents = nil
+	detached.values = nil
+	return &detached
+}
+
+// MarkAsPushed marks the EdgeX Event, along with any events added with AddBatchedEvents, as pushed in Core Data.
+// Replayed events, and events older than the Writable.MarkPushedMaxAge configuration setting, are not marked so
+// reprocessing them doesn't change their push state.
 func (context *Context) MarkAsPushed() error {
-	if context.EventID != "" {
-		return context.EventClient.MarkPushed(context.EventID, syscontext.WithValue(syscontext.Background(), clients.CorrelationHeader, context.CorrelationID))
-	} else if context.EventChecksum != "" {
-		return context.EventClient.MarkPushedByChecksum(context.EventChecksum, syscontext.WithValue(syscontext.Background(), clients.CorrelationHeader, context.CorrelationID))
+	batched := context.batchedEvents
+	context.batchedEvents = nil
+
+	err := context.markEventAsPushed(context.EventReference())
+	if err != nil && len(batched) == 0 {
+		return err
+	}
+
+	failed := 0
+	if err != nil {
+		failed++
+	}
+	for _, event := range batched {
+		if batchErr := context.markEventAsPushed(event); batchErr != nil {
+			failed++
+			if err == nil {
+				err = batchErr
+			}
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("failed to mark %d of %d events as pushed: %v", failed, len(batched)+1, err)
+	}
+	return nil
+}
+
+func (context *Context) markEventAsPushed(event EventReference) error {
+	if event.Replayed {
+		context.LoggingClient.Debug("Not marking replayed event as pushed", clients.CorrelationHeader, event.CorrelationID)
+		return nil
+	}
+
+	if tooOld, err := context.exceedsMarkPushedMaxAge(event.Created); err != nil {
+		return err
+	} else if tooOld {
+		context.LoggingClient.Debug("Not marking event older than MarkPushedMaxAge as pushed", clients.CorrelationHeader, event.CorrelationID)
+		return nil
+	}
+
+	if event.ID != "" {
+		return context.callWithDeadline(event.CorrelationID, func(ctx syscontext.Context) error {
+			return context.EventClient.MarkPushed(event.ID, ctx)
+		})
+	} else if event.Checksum != "" {
+		return context.callWithDeadline(event.CorrelationID, func(ctx syscontext.Context) error {
+			return context.EventClient.MarkPushedByChecksum(event.Checksum, ctx)
+		})
 	} else {
 		return errors.New("No EventID or EventChecksum Provided")
 	}
 }
+
+func (context *Context) exceedsMarkPushedMaxAge(eventCreated int64) (bool, error) {
+	if context.Configuration.Writable.MarkPushedMaxAge == "" || eventCreated <= 0 {
+		return false, nil
+	}
+
+	maxAge, err := time.ParseDuration(context.Configuration.Writable.MarkPushedMaxAge)
+	if err != nil {
+		return false, fmt.Errorf("invalid MarkPushedMaxAge '%s': %v", context.Configuration.Writable.MarkPushedMaxAge, err)
+	}
+
+	created := time.Unix(0, eventCreated*int64(time.Millisecond))
+	return maxAge > 0 && time.Since(created) > maxAge, nil
+}
+
+// PushToCoreData creates a new event in Core Data with a single reading for the specified device, allowing
+// pipelines to store derived readings such as averages. The value is stored as a string. The created event,
+// including the ID assigned by Core Data, is returned.
+func (context *Context) PushToCoreData(deviceName string, readingName string, value interface{}) (*models.Event, error) {
+	var readingValue string
+	switch typed := value.(type) {
+	case string:
+		readingValue = typed
+	case []byte:
+		readingValue = string(typed)
+	default:
+		readingValue = fmt.Sprintf("%v", typed)
+	}
+
+	now := time.Now().UnixNano() / int64(time.Millisecond)
+	event := &models.Event{
+		Device: deviceName,
+		Origin: now,
+		Readings: []models.Reading{
+			{Device: deviceName, Name: readingName, Value: readingValue, Origin: now},
+		},
+	}
+
+	var id string
+	err := context.CallWithDeadline(func(ctx syscontext.Context) error {
+		var err error
+		id, err = context.EventClient.Add(event, ctx)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	event.ID = id
+
+	return event, nil
+}
+
+// CallWithDeadline makes a call to an EdgeX client, i.e. CommandClient.Get, with a context carrying the correlation ID
+// of the EdgeX Event. When the Deadline is set the call is abandoned with an error once the deadline passes, so a
+// stuck call to a core service can't exceed the time budget of the event. The call is also abandoned when Ctx is
+// cancelled as the service stops. The EdgeX clients don't abort their requests when the context is done, so an
+// abandoned call completes in the background.
+func (context *Context) CallWithDeadline(call func(ctx syscontext.Context) error) error {
+	return context.callWithDeadline(context.CorrelationID, call)
+}
+
+func (context *Context) callWithDeadline(correlationID string, call func(ctx syscontext.Context) error) error {
+	ctx := syscontext.WithValue(context.baseContext(), clients.CorrelationHeader, correlationID)
+	if !context.Deadline.IsZero() {
+		if !time.Now().Before(context.Deadline) {
+			return errors.New("pipeline deadline exceeded before calling EdgeX client")
+		}
+		var cancel syscontext.CancelFunc
+		ctx, cancel = syscontext.WithDeadline(ctx, context.Deadline)
+		defer cancel()
+	}
+	if ctx.Done() == nil {
+		return call(ctx)
+	}
+	if ctx.Err() != nil {
+		return abandonedCallError(ctx)
+	}
+
+	result := make(chan error, 1)
+	go func() {
+		result <- call(ctx)
+	}()
+
+	select {
+	case err := <-result:
+		return err
+	case <-ctx.Done():
+		return abandonedCallError(ctx)
+	}
+}
+
+func abandonedCallError(ctx syscontext.Context) error {
+	if ctx.Err() == syscontext.DeadlineExceeded {
+		return fmt.Errorf("EdgeX client call abandoned at the pipeline deadline: %v", ctx.Err())
+	}
+	return fmt.Errorf("EdgeX client call abandoned as the service is stopping: %v", ctx.Err())
+}
+
+// RequestContext returns a context carrying the correlation ID which is cancelled when the service stops, for the
+// outgoing requests of long-running functions such as exports, i.e. with http.Request.WithContext
+func (context *Context) RequestContext() syscontext.Context {
+	return syscontext.WithValue(context.baseContext(), clients.CorrelationHeader, context.CorrelationID)
+}
+
+// baseContext returns Ctx, or the background context when it isn't set
+func (context *Context) baseContext() syscontext.Context {
+	if context.Ctx == nil {
+		return syscontext.Background()
+	}
+	return context.Ctx
+}
@@ -0,0 +1,221 @@
+//
+// Copyright (c) 2019 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package appcontext
+
+import (
+	syscontext "context"
+	"testing"
+	"time"
+
+	"github.com/antoniomtz/app-functions-sdk-go/internal/common"
+	"github.com/edgexfoundry/go-mod-core-contracts/clients"
+	"github.com/edgexfoundry/go-mod-core-contracts/clients/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var lc = logger.NewClient("app_functions_sdk_go", false, "./test.log", "DEBUG")
+
+func TestMarkAsPushedReplayed(t *testing.T) {
+	// EventClient is nil so any attempt to mark the event as pushed would panic
+	context := Context{LoggingClient: lc, EventID: "event1", Replayed: true}
+	assert.NoError(t, context.MarkAsPushed())
+}
+
+func TestMarkAsPushedMaxAge(t *testing.T) {
+	context := Context{
+		LoggingClient: lc,
+		EventID:       "event1",
+		EventCreated:  time.Now().Add(-48*time.Hour).UnixNano() / int64(time.Millisecond),
+		Configuration: common.ConfigurationStruct{Writable: common.WritableInfo{MarkPushedMaxAge: "24h"}},
+	}
+	assert.NoError(t, context.MarkAsPushed(), "Events older than MarkPushedMaxAge should not be marked as pushed")
+
+	context.Configuration.Writable.MarkPushedMaxAge = "one day"
+	assert.Error(t, context.MarkAsPushed(), "Invalid MarkPushedMaxAge should return an error")
+}
+
+func TestMarkAsPushedNoEventID(t *testing.T) {
+	context := Context{LoggingClient: lc}
+	assert.Equal(t, "No EventID or EventChecksum Provided", context.MarkAsPushed().Error())
+}
+
+func TestMarkAsPushedBatchedEvents(t *testing.T) {
+	context := Context{LoggingClient: lc, EventID: "event1", Replayed: true}
+	context.AddBatchedEvents(EventReference{ID: "event2", Replayed: true}, EventReference{ID: "event3", Replayed: true})
+	assert.Len(t, context.BatchedEvents(), 2)
+	assert.NoError(t, context.MarkAsPushed())
+	assert.Empty(t, context.BatchedEvents())
+
+	context.AddBatchedEvents(EventReference{ID: "event2", Replayed: true}, EventReference{})
+	assert.Equal(t, "failed to mark 1 of 3 events as pushed: No EventID or EventChecksum Provided", context.MarkAsPushed().Error())
+
+	assert.NoError(t, context.MarkAsPushed(), "Batched events should be cleared once marked")
+}
+
+func TestSetGetValue(t *testing.T) {
+	context := Context{}
+	_, ok := context.GetValue("classification")
+	assert.False(t, ok, "Value should not be set")
+
+	context.SetValue("classification", "critical")
+	context.SetValue("priority", 2)
+	value, ok := context.GetValue("classification")
+	assert.True(t, ok)
+	assert.Equal(t, "critical", value)
+	value, _ = context.GetValue("priority")
+	assert.Equal(t, 2, value)
+
+	context.SetValue("classification", nil)
+	value, ok = context.GetValue("classification")
+	assert.True(t, ok, "Value set to nil should still be set")
+	assert.Nil(t, value)
+}
+
+func TestSetResponseData(t *testing.T) {
+	context := Context{}
+	context.SetResponseData([]byte("<Event></Event>"))
+	context.SetResponseContentType("application/xml")
+	assert.Equal(t, []byte("<Event></Event>"), context.OutputData)
+	assert.Equal(t, "application/xml", context.ResponseContentType)
+
+	context.Complete([]byte("{}"))
+	assert.Equal(t, []byte("{}"), context.OutputData, "Complete should set the response data")
+}
+
+type testSecretProvider map[string]map[string]string
+
+func (provider testSecretProvider) GetSecrets(path string, keys ...string) (map[string]string, error) {
+	return provider[path], nil
+}
+
+func TestGetSecret(t *testing.T) {
+	context := Context{}
+	_, err := context.GetSecret("mqtt")
+	assert.EqualError(t, err, "no secret store configured")
+
+	context.SecretProvider = testSecretProvider{"mqtt": {"password": "secret"}}
+	secrets, err := context.GetSecret("mqtt", "password")
+	assert.NoError(t, err)
+	assert.Equal(t, "secret", secrets["password"])
+}
+
+func TestCallWithDeadline(t *testing.T) {
+	context := Context{LoggingClient: lc, CorrelationID: "123-234-345-456"}
+	err := context.CallWithDeadline(func(ctx syscontext.Context) error {
+		assert.Equal(t, "123-234-345-456", ctx.Value(clients.CorrelationHeader))
+		_, hasDeadline := ctx.Deadline()
+		assert.False(t, hasDeadline)
+		return nil
+	})
+	assert.NoError(t, err)
+
+	context.Deadline = time.Now().Add(time.Minute)
+	err = context.CallWithDeadline(func(ctx syscontext.Context) error {
+		deadline, hasDeadline := ctx.Deadline()
+		assert.True(t, hasDeadline)
+		assert.Equal(t, context.Deadline, deadline)
+		return nil
+	})
+	assert.NoError(t, err)
+}
+
+func TestCallWithDeadlineAbandoned(t *testing.T) {
+	stuck := make(chan struct{})
+	defer close(stuck)
+
+	context := Context{LoggingClient: lc, Deadline: time.Now().Add(20 * time.Millisecond)}
+	started := time.Now()
+	err := context.CallWithDeadline(func(ctx syscontext.Context) error {
+		<-stuck
+		return nil
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "abandoned at the pipeline deadline")
+	assert.True(t, time.Since(started) < time.Second)
+
+	called := false
+	err = context.CallWithDeadline(func(ctx syscontext.Context) error {
+		called = true
+		return nil
+	})
+	assert.Error(t, err)
+	assert.False(t, called, "Calls shouldn't be made once the deadline has passed")
+}
+
+func TestCallWithDeadlineCancelled(t *testing.T) {
+	stuck := make(chan struct{})
+	defer close(stuck)
+
+	ctx, cancel := syscontext.WithCancel(syscontext.Background())
+	context := Context{LoggingClient: lc, Ctx: ctx}
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		cancel()
+	}()
+	err := context.CallWithDeadline(func(ctx syscontext.Context) error {
+		<-stuck
+		return nil
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "abandoned as the service is stopping")
+
+	called := false
+	err = context.CallWithDeadline(func(ctx syscontext.Context) error {
+		called = true
+		return nil
+	})
+	assert.Error(t, err)
+	assert.False(t, called, "Calls shouldn't be made once the service is stopping")
+}
+
+func TestDetached(t *testing.T) {
+	ctx, cancel := syscontext.WithCancel(syscontext.Background())
+	cancel()
+	context := Context{LoggingClient: lc, CorrelationID: "123", Deadline: time.Now().Add(-time.Second), Ctx: ctx}
+	context.SetValue("key", "value")
+	context.AddBatchedEvents(EventReference{ID: "event2"})
+
+	detached := context.Detached()
+	assert.Equal(t, "123", detached.CorrelationID)
+	assert.Empty(t, detached.BatchedEvents())
+	_, ok := detached.GetValue("key")
+	assert.False(t, ok)
+
+	called := false
+	err := detached.CallWithDeadline(func(ctx syscontext.Context) error {
+		called = true
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.True(t, called, "Calls should be made once the pipeline deadline passed and its context was cancelled")
+	assert.Equal(t, 1, len(context.BatchedEvents()), "The context should be left as it is")
+}
+
+func TestRequestContext(t *testing.T) {
+	context := Context{LoggingClient: lc, CorrelationID: "123-234-345-456"}
+	ctx := context.RequestContext()
+	require.NotNil(t, ctx)
+	assert.Equal(t, "123-234-345-456", ctx.Value(clients.CorrelationHeader))
+	assert.Nil(t, ctx.Done(), "the request context shouldn't be cancellable without Ctx")
+
+	service, cancel := syscontext.WithCancel(syscontext.Background())
+	context.Ctx = service
+	ctx = context.RequestContext()
+	cancel()
+	assert.Equal(t, syscontext.Canceled, ctx.Err())
+}
@@ -0,0 +1,120 @@
+//
+// Copyright (c) 2019 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package appsdk
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/antoniomtz/app-functions-sdk-go/internal/alerts"
+	"github.com/antoniomtz/app-functions-sdk-go/internal/runtime"
+	"github.com/edgexfoundry/go-mod-core-contracts/clients/notifications"
+)
+
+const defaultExportFailureThreshold = 3
+
+// alertMonitor creates the monitor of the configured alert rules, logging and skipping the invalid rules. It returns
+// nil when no rules are configured. The runtime may be nil when replaced by a custom runtime, and the notifications
+// client is only required when notifying.
+func (sdk *AppFunctionsSDK) alertMonitor(pipelineRuntime *runtime.GolangRuntime, notificationsClient notifications.NotificationsClient) *alerts.Monitor {
+	config := sdk.config.Alerts
+	monitor := &alerts.Monitor{
+		Runtime:       pipelineRuntime,
+		Exports:       sdk.exports,
+		LoggingClient: sdk.LoggingClient,
+	}
+	for _, expression := range config.Rules {
+		rule, err := alerts.ParseRule(expression)
+		if err != nil {
+			sdk.LoggingClient.Error(err.Error())
+			continue
+		}
+		monitor.Rules = append(monitor.Rules, rule)
+	}
+	if len(monitor.Rules) == 0 {
+		return nil
+	}
+
+	if config.Notify {
+		if notificationsClient == nil {
+			sdk.LoggingClient.Error("Alert notifications require the Notifications client to be configured")
+		} else {
+			monitor.Actions = append(monitor.Actions, alerts.NotificationAction(notificationsClient, sdk.ServiceKey))
+		}
+	}
+	if config.MQTTBroker != "" {
+		monitor.Actions = append(monitor.Actions, alerts.MQTTAction(config.MQTTBroker, config.MQTTTopic, sdk.ServiceKey+"-alerts"))
+	}
+	return monitor
+}
+
+// startAlertMonitor evaluates the configured alert rules at each check interval in the background
+func (sdk *AppFunctionsSDK) startAlertMonitor(pipelineRuntime *runtime.GolangRuntime, notificationsClient notifications.NotificationsClient) {
+	monitor := sdk.alertMonitor(pipelineRuntime, notificationsClient)
+	if monitor == nil {
+		return
+	}
+
+	interval := time.Minute
+	if sdk.config.Alerts.CheckInterval != "" {
+		parsed, err := time.ParseDuration(sdk.config.Alerts.CheckInterval)
+		if err != nil || parsed <= 0 {
+			sdk.LoggingClient.Error("Invalid Alerts.CheckInterval '" + sdk.config.Alerts.CheckInterval + "', using 1m")
+		} else {
+			interval = parsed
+		}
+	}
+
+	sdk.LoggingClient.Info("Evaluating alert rules every " + interval.String())
+	go monitor.Run(interval)
+}
+
+// configureExportWebhooks reports to the configured webhooks and MQTT topic when an export function starts failing
+// and when it recovers. The status messages are delivered in the background so exports are not delayed.
+func (sdk *AppFunctionsSDK) configureExportWebhooks() {
+	config := sdk.config.ExportWebhooks
+	if sdk.exports == nil || (len(config.URLs) == 0 && config.MQTTBroker == "") {
+		return
+	}
+
+	var publishers []alerts.Publisher
+	for _, url := range config.URLs {
+		publishers = append(publishers, alerts.WebhookPublisher(url))
+	}
+	if config.MQTTBroker != "" {
+		publishers = append(publishers, alerts.MQTTPublisher(config.MQTTBroker, config.MQTTTopic, sdk.ServiceKey+"-exports"))
+	}
+
+	sdk.exports.FailureThreshold = config.FailureThreshold
+	if sdk.exports.FailureThreshold <= 0 {
+		sdk.exports.FailureThreshold = defaultExportFailureThreshold
+	}
+	sdk.exports.OnStatusChange = func(status runtime.ExportStatus) {
+		if status.Failing {
+			sdk.LoggingClient.Warn(fmt.Sprintf("Export %s is failing: %s", status.Name, status.LastError))
+		} else {
+			sdk.LoggingClient.Info(fmt.Sprintf("Export %s recovered", status.Name))
+		}
+		go func() {
+			for _, publish := range publishers {
+				if err := publish(status); err != nil {
+					sdk.LoggingClient.Error(fmt.Sprintf("Failed to report status of export %s: %s", status.Name, err.Error()))
+				}
+			}
+		}()
+	}
+}
@@ -0,0 +1,70 @@
+//
+// Copyright (c) 2019 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package appsdk
+
+import (
+	"errors"
+
+	"github.com/antoniomtz/app-functions-sdk-go/appcontext"
+)
+
+// AppFunctionContext is the context of the event being processed, passed to each AppFunction
+type AppFunctionContext = *appcontext.Context
+
+// AppFunction is a strongly typed pipeline function. It receives the data returned by the previous function, or the
+// received EdgeX Event (or TargetType) when it is the first function of the pipeline. The function returns true and
+// the data for the next function to continue the pipeline, false and nil to stop the pipeline without an error, i.e.
+// when the event is filtered out, or false and an error when processing failed, which is logged. An error returned
+// along with true also stops the pipeline, rather than being passed to the next function as data.
+type AppFunction func(ctx AppFunctionContext, data interface{}) (bool, interface{})
+
+// AsTransform adapts the function to the variadic signature of the functions passed to SetFunctionsPipeline, so
+// AppFunctions can be mixed with functions which have not been migrated yet
+func (function AppFunction) AsTransform() func(*appcontext.Context, ...interface{}) (bool, interface{}) {
+	return func(edgexcontext *appcontext.Context, params ...interface{}) (bool, interface{}) {
+		if len(params) < 1 {
+			return false, errors.New("No Data Received")
+		}
+
+		continuePipeline, result := function(edgexcontext, params[0])
+		if _, failed := result.(error); failed {
+			return false, result
+		}
+		return continuePipeline, result
+	}
+}
+
+// FromTransform adapts a function with the variadic signature of the functions passed to SetFunctionsPipeline,
+// such as the SDK's built-in functions, to an AppFunction
+func FromTransform(transform func(*appcontext.Context, ...interface{}) (bool, interface{})) AppFunction {
+	return func(ctx AppFunctionContext, data interface{}) (bool, interface{}) {
+		return transform(ctx, data)
+	}
+}
+
+// SetAppFunctionsPipeline defines the functions to execute, in order, as each event comes in like
+// SetFunctionsPipeline, using strongly typed AppFunctions
+func (sdk *AppFunctionsSDK) SetAppFunctionsPipeline(functions ...AppFunction) error {
+	transforms := make([]func(*appcontext.Context, ...interface{}) (bool, interface{}), len(functions))
+	for index, function := range functions {
+		// nil functions are left nil so SetFunctionsPipeline rejects them
+		if function != nil {
+			transforms[index] = function.AsTransform()
+		}
+	}
+	return sdk.SetFunctionsPipeline(transforms...)
+}
@@ -0,0 +1,98 @@
+//
+// Copyright (c) 2019 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package appsdk
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/antoniomtz/go-mod-messaging/pkg/types"
+)
+
+// BackgroundPublisher publishes messages to the message bus outside of a pipeline, i.e. periodic heartbeats or the
+// results of asynchronous work
+type BackgroundPublisher interface {
+	// Publish queues the payload to be published to the Binding.PublishTopic, waiting while the queue is full. It
+	// returns an error once the service is stopping.
+	Publish(payload []byte, correlationID string, contentType string) error
+}
+
+type backgroundPublisher struct {
+	messages chan<- types.MessageEnvelope
+	stopping <-chan struct{}
+}
+
+// Publish queues the payload to be published to the Binding.PublishTopic, waiting while the queue is full. It returns
+// an error once the service is stopping.
+func (publisher *backgroundPublisher) Publish(payload []byte, correlationID string, contentType string) error {
+	message := types.MessageEnvelope{CorrelationID: correlationID, Payload: payload, ContentType: contentType}
+	select {
+	case <-publisher.stopping:
+		return errors.New("background publisher stopped: the service is stopping")
+	default:
+	}
+
+	select {
+	case publisher.messages <- message:
+		return nil
+	case <-publisher.stopping:
+		return errors.New("background publisher stopped: the service is stopping")
+	}
+}
+
+// AddBackgroundPublisher returns a publisher which application goroutines can use to publish messages to the
+// Binding.PublishTopic of the message bus outside of a pipeline. Up to capacity messages wait to be published before
+// Publish waits for them, including before MakeItRun connects to the bus. The messages waiting when the service stops
+// are published before the trigger disconnects. It must be called after Initialize and before MakeItRun, and requires
+// the MessageBus trigger.
+func (sdk *AppFunctionsSDK) AddBackgroundPublisher(capacity int) (BackgroundPublisher, error) {
+	if strings.ToUpper(sdk.config.Binding.Type) != "MESSAGEBUS" {
+		return nil, fmt.Errorf("background publishing requires the MessageBus trigger, not '%s'", sdk.config.Binding.Type)
+	}
+	if sdk.config.Binding.PublishTopic == "" {
+		return nil, errors.New("background publishing requires the Binding.PublishTopic to be set")
+	}
+	if capacity < 0 {
+		return nil, fmt.Errorf("background publisher capacity must not be negative, got %d", capacity)
+	}
+
+	if sdk.backgroundStop == nil {
+		sdk.backgroundStop = make(chan struct{})
+	}
+	messages := make(chan types.MessageEnvelope, capacity)
+	sdk.background = append(sdk.background, messages)
+	return &backgroundPublisher{messages: messages, stopping: sdk.backgroundStop}, nil
+}
+
+// backgroundMessages returns the messages of the background publishers for the trigger to publish
+func (sdk *AppFunctionsSDK) backgroundMessages() []<-chan types.MessageEnvelope {
+	var background []<-chan types.MessageEnvelope
+	for _, messages := range sdk.background {
+		background = append(background, messages)
+	}
+	return background
+}
+
+// stopBackgroundPublishers rejects the messages published from now on, before the trigger stops and publishes those
+// already waiting
+func (sdk *AppFunctionsSDK) stopBackgroundPublishers() {
+	if sdk.backgroundStop != nil {
+		close(sdk.backgroundStop)
+		sdk.backgroundStop = nil
+	}
+}
@@ -0,0 +1,725 @@
+//
+// Copyright (c) 2019 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package appsdk
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/antoniomtz/app-functions-sdk-go/appcontext"
+	"github.com/antoniomtz/app-functions-sdk-go/pkg/transforms"
+	"github.com/edgexfoundry/go-mod-core-contracts/models"
+)
+
+// pipelineFunctionFactory creates a built-in function from the parameters configured for it
+type pipelineFunctionFactory func(sdk *AppFunctionsSDK, parameters pipelineParameters) (func(*appcontext.Context, ...interface{}) (bool, interface{}), error)
+
+// builtInFunctions are the functions which can be used in a pipeline defined in the configuration, by name
+var builtInFunctions = map[string]pipelineFunctionFactory{
+	"DeviceNameFilter": func(sdk *AppFunctionsSDK, parameters pipelineParameters) (func(*appcontext.Context, ...interface{}) (bool, interface{}), error) {
+		deviceNames, err := parameters.list("DeviceNames")
+		if err != nil {
+			return nil, err
+		}
+		return sdk.DeviceNameFilter(deviceNames), nil
+	},
+	"ValueDescriptorFilter": func(sdk *AppFunctionsSDK, parameters pipelineParameters) (func(*appcontext.Context, ...interface{}) (bool, interface{}), error) {
+		valueDescriptors, err := parameters.list("ValueDescriptors")
+		if err != nil {
+			return nil, err
+		}
+		return sdk.ValueDescriptorFilter(valueDescriptors), nil
+	},
+	"SampleOneIn": func(sdk *AppFunctionsSDK, parameters pipelineParameters) (func(*appcontext.Context, ...interface{}) (bool, interface{}), error) {
+		n, err := parameters.int("N")
+		if err != nil {
+			return nil, err
+		}
+		bucketSize, err := parameters.duration("BucketSize")
+		if err != nil {
+			return nil, err
+		}
+		return sdk.SampleOneIn(n, bucketSize), nil
+	},
+	"SamplePercentage": func(sdk *AppFunctionsSDK, parameters pipelineParameters) (func(*appcontext.Context, ...interface{}) (bool, interface{}), error) {
+		percentage, err := parameters.float("Percentage")
+		if err != nil {
+			return nil, err
+		}
+		bucketSize, err := parameters.duration("BucketSize")
+		if err != nil {
+			return nil, err
+		}
+		return sdk.SamplePercentage(percentage, bucketSize), nil
+	},
+	"RateLimit": func(sdk *AppFunctionsSDK, parameters pipelineParameters) (func(*appcontext.Context, ...interface{}) (bool, interface{}), error) {
+		every, err := parameters.int("Every")
+		if err != nil {
+			return nil, err
+		}
+		maxEvents, err := parameters.int("MaxEvents")
+		if err != nil {
+			return nil, err
+		}
+		window, err := parameters.duration("Window")
+		if err != nil {
+			return nil, err
+		}
+		if every <= 0 && maxEvents <= 0 {
+			return nil, errors.New("Every or MaxEvents must be greater than zero")
+		}
+		if maxEvents > 0 && window <= 0 {
+			return nil, errors.New("Window must be specified along with MaxEvents")
+		}
+		return sdk.rateLimit(every, maxEvents, window)
+	},
+	"ScaleAndOffset": func(sdk *AppFunctionsSDK, parameters pipelineParameters) (func(*appcontext.Context, ...interface{}) (bool, interface{}), error) {
+		// each parameter is the calibration of a value descriptor, i.e. Temperature = 'Scale=1.8, Offset=32'
+		readings := map[string]transforms.ScaleConfig{}
+		for valueDescriptor, value := range parameters {
+			config, err := transforms.ParseScaleConfig(value)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %v", valueDescriptor, err)
+			}
+			readings[valueDescriptor] = config
+		}
+		if len(readings) == 0 {
+			return nil, errors.New("a calibration must be specified for at least one value descriptor")
+		}
+		return sdk.ScaleAndOffset(readings), nil
+	},
+	"FilterByValue": func(sdk *AppFunctionsSDK, parameters pipelineParameters) (func(*appcontext.Context, ...interface{}) (bool, interface{}), error) {
+		// each parameter is the condition of a value descriptor, i.e. Temperature = '> 80'
+		readings := map[string]transforms.ValueCondition{}
+		for valueDescriptor, value := range parameters {
+			condition, err := transforms.ParseValueCondition(value)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %v", valueDescriptor, err)
+			}
+			readings[valueDescriptor] = condition
+		}
+		if len(readings) == 0 {
+			return nil, errors.New("a condition must be specified for at least one value descriptor")
+		}
+		return sdk.FilterByValue(readings), nil
+	},
+	"FilterByExpression": func(sdk *AppFunctionsSDK, parameters pipelineParameters) (func(*appcontext.Context, ...interface{}) (bool, interface{}), error) {
+		text, err := parameters.required("Expression")
+		if err != nil {
+			return nil, err
+		}
+		return sdk.filterByExpression(text)
+	},
+	"ComputeReadings": func(sdk *AppFunctionsSDK, parameters pipelineParameters) (func(*appcontext.Context, ...interface{}) (bool, interface{}), error) {
+		// each parameter is the expression of a value descriptor, i.e. Temperature = 'Float64(value) * 1.8 + 32'
+		readings := map[string]string{}
+		for valueDescriptor, value := range parameters {
+			readings[valueDescriptor] = value
+		}
+		return sdk.computeReadings(readings)
+	},
+	"DeltaFilter": func(sdk *AppFunctionsSDK, parameters pipelineParameters) (func(*appcontext.Context, ...interface{}) (bool, interface{}), error) {
+		delta, err := parameters.float("Delta")
+		if err != nil {
+			return nil, err
+		}
+		percentage, err := parameters.float("Percentage")
+		if err != nil {
+			return nil, err
+		}
+		if delta < 0 || percentage < 0 {
+			return nil, errors.New("Delta and Percentage must not be negative")
+		}
+		return sdk.DeltaFilter(delta, percentage), nil
+	},
+	"DeduplicateEvents": func(sdk *AppFunctionsSDK, parameters pipelineParameters) (func(*appcontext.Context, ...interface{}) (bool, interface{}), error) {
+		window, err := parameters.duration("Window")
+		if err != nil {
+			return nil, err
+		}
+		if window <= 0 {
+			return nil, errors.New("Window must be specified")
+		}
+		key := strings.ToLower(strings.TrimSpace(parameters["Key"]))
+		if key == "" {
+			key = transforms.DedupKeyID
+		}
+		if key != transforms.DedupKeyID && key != transforms.DedupKeyContent {
+			return nil, fmt.Errorf("Key must be %s or %s, got '%s'", transforms.DedupKeyID, transforms.DedupKeyContent, key)
+		}
+		return sdk.deduplicateEvents(window, key)
+	},
+	"AggregateReadings": func(sdk *AppFunctionsSDK, parameters pipelineParameters) (func(*appcontext.Context, ...interface{}) (bool, interface{}), error) {
+		functions, err := parameters.list("Functions")
+		if err != nil {
+			return nil, err
+		}
+		config := transforms.AggregationConfig{StateFile: strings.TrimSpace(parameters["StateFile"])}
+		for _, function := range functions {
+			config.Functions = append(config.Functions, strings.ToLower(function))
+		}
+		if strings.TrimSpace(parameters["Readings"]) != "" {
+			if config.Readings, err = parameters.list("Readings"); err != nil {
+				return nil, err
+			}
+		}
+		if config.Window, err = parameters.duration("Window"); err != nil {
+			return nil, err
+		}
+		if config.Slide, err = parameters.duration("Slide"); err != nil {
+			return nil, err
+		}
+		if config.Count, err = parameters.int("Count"); err != nil {
+			return nil, err
+		}
+		if config.SlideCount, err = parameters.int("SlideCount"); err != nil {
+			return nil, err
+		}
+		if err := config.Validate(); err != nil {
+			return nil, err
+		}
+		return sdk.aggregateReadings(config)
+	},
+	"JoinReadings": func(sdk *AppFunctionsSDK, parameters pipelineParameters) (func(*appcontext.Context, ...interface{}) (bool, interface{}), error) {
+		sources, err := parameters.list("Sources")
+		if err != nil {
+			return nil, err
+		}
+		config := transforms.JoinConfig{Device: strings.TrimSpace(parameters["Device"])}
+		for _, value := range sources {
+			source, err := transforms.ParseJoinSource(value)
+			if err != nil {
+				return nil, err
+			}
+			config.Sources = append(config.Sources, source)
+		}
+		if config.Window, err = parameters.duration("Window"); err != nil {
+			return nil, err
+		}
+		if config.Partial, err = parameters.bool("Partial"); err != nil {
+			return nil, err
+		}
+		if err := config.Validate(); err != nil {
+			return nil, err
+		}
+		return sdk.joinReadings(config)
+	},
+	"SmoothReadings": func(sdk *AppFunctionsSDK, parameters pipelineParameters) (func(*appcontext.Context, ...interface{}) (bool, interface{}), error) {
+		// each parameter is the moving average of a value descriptor, i.e. Temperature = 'Method=ema, Alpha=0.2'
+		readings := map[string]transforms.SmoothingConfig{}
+		for valueDescriptor, value := range parameters {
+			config, err := transforms.ParseSmoothingConfig(value)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %v", valueDescriptor, err)
+			}
+			readings[valueDescriptor] = config
+		}
+		if len(readings) == 0 {
+			return nil, errors.New("a moving average must be specified for at least one value descriptor")
+		}
+		return sdk.smoothReadings(readings)
+	},
+	"RejectOutliers": func(sdk *AppFunctionsSDK, parameters pipelineParameters) (func(*appcontext.Context, ...interface{}) (bool, interface{}), error) {
+		config := transforms.OutlierConfig{Method: strings.ToLower(strings.TrimSpace(parameters["Method"]))}
+		var err error
+		if config.Threshold, err = parameters.float("Threshold"); err != nil {
+			return nil, err
+		}
+		if config.Window, err = parameters.int("Window"); err != nil {
+			return nil, err
+		}
+		if config.MinSamples, err = parameters.int("MinSamples"); err != nil {
+			return nil, err
+		}
+		if strings.TrimSpace(parameters["Readings"]) != "" {
+			if config.Readings, err = parameters.list("Readings"); err != nil {
+				return nil, err
+			}
+		}
+		name := strings.TrimSpace(parameters["Name"])
+		if name == "" {
+			name = "RejectOutliers"
+		}
+		return sdk.rejectOutliers(name, config)
+	},
+	"InferWithModel": func(sdk *AppFunctionsSDK, parameters pipelineParameters) (func(*appcontext.Context, ...interface{}) (bool, interface{}), error) {
+		url, err := parameters.required("URL")
+		if err != nil {
+			return nil, err
+		}
+		config := transforms.InferenceConfig{
+			URL:        url,
+			Protocol:   strings.ToLower(strings.TrimSpace(parameters["Protocol"])),
+			InputName:  strings.TrimSpace(parameters["InputName"]),
+			ResultName: strings.TrimSpace(parameters["ResultName"]),
+		}
+		if strings.TrimSpace(parameters["Readings"]) != "" {
+			if config.Readings, err = parameters.list("Readings"); err != nil {
+				return nil, err
+			}
+		}
+		if config.Binary, err = parameters.bool("Binary"); err != nil {
+			return nil, err
+		}
+		if config.BatchSize, err = parameters.int("BatchSize"); err != nil {
+			return nil, err
+		}
+		if config.BatchTimeout, err = parameters.duration("BatchTimeout"); err != nil {
+			return nil, err
+		}
+		if config.Timeout, err = parameters.duration("Timeout"); err != nil {
+			return nil, err
+		}
+		name := strings.TrimSpace(parameters["Name"])
+		if name == "" {
+			name = "InferWithModel"
+		}
+		return sdk.inferWithModel(name, config)
+	},
+	"AddTags": func(sdk *AppFunctionsSDK, parameters pipelineParameters) (func(*appcontext.Context, ...interface{}) (bool, interface{}), error) {
+		// each parameter other than HostnameTag and ProcessedTag is a static tag, i.e. site = 'plant-1'
+		tags := map[string]string{}
+		for name, value := range parameters {
+			if name != "HostnameTag" && name != "ProcessedTag" {
+				tags[name] = value
+			}
+		}
+		hostnameTag := strings.TrimSpace(parameters["HostnameTag"])
+		processedTag := strings.TrimSpace(parameters["ProcessedTag"])
+		if len(tags) == 0 && hostnameTag == "" && processedTag == "" {
+			return nil, errors.New("at least one tag must be specified")
+		}
+		return sdk.addTags(tags, hostnameTag, processedTag)
+	},
+	"XMLTransform": func(sdk *AppFunctionsSDK, parameters pipelineParameters) (func(*appcontext.Context, ...interface{}) (bool, interface{}), error) {
+		return sdk.XMLTransform(), nil
+	},
+	"JSONTransform": func(sdk *AppFunctionsSDK, parameters pipelineParameters) (func(*appcontext.Context, ...interface{}) (bool, interface{}), error) {
+		return sdk.JSONTransform(), nil
+	},
+	"CSVTransform": func(sdk *AppFunctionsSDK, parameters pipelineParameters) (func(*appcontext.Context, ...interface{}) (bool, interface{}), error) {
+		config := transforms.CSVConfig{
+			Header:    strings.ToLower(strings.TrimSpace(parameters["Header"])),
+			Separator: parameters["Separator"],
+		}
+		if strings.TrimSpace(parameters["Columns"]) != "" {
+			var err error
+			if config.Columns, err = parameters.list("Columns"); err != nil {
+				return nil, err
+			}
+		}
+		return sdk.csvTransform(config)
+	},
+	"SenMLTransform": func(sdk *AppFunctionsSDK, parameters pipelineParameters) (func(*appcontext.Context, ...interface{}) (bool, interface{}), error) {
+		// each parameter other than Format and BaseName is the SenML unit of a value descriptor, i.e. Temperature = 'Cel'
+		config := transforms.SenMLConfig{
+			Format:   strings.ToLower(strings.TrimSpace(parameters["Format"])),
+			BaseName: strings.TrimSpace(parameters["BaseName"]),
+			Units:    map[string]string{},
+		}
+		for name, value := range parameters {
+			if name != "Format" && name != "BaseName" {
+				config.Units[name] = strings.TrimSpace(value)
+			}
+		}
+		return sdk.senMLTransform(config)
+	},
+	"WrapCloudEvent": func(sdk *AppFunctionsSDK, parameters pipelineParameters) (func(*appcontext.Context, ...interface{}) (bool, interface{}), error) {
+		return sdk.WrapCloudEvent(transforms.CloudEventConfig{
+			Source:          strings.TrimSpace(parameters["Source"]),
+			Type:            strings.TrimSpace(parameters["Type"]),
+			Subject:         strings.TrimSpace(parameters["Subject"]),
+			DataContentType: strings.TrimSpace(parameters["DataContentType"]),
+		}), nil
+	},
+	"TransformWithTemplate": func(sdk *AppFunctionsSDK, parameters pipelineParameters) (func(*appcontext.Context, ...interface{}) (bool, interface{}), error) {
+		text := parameters["Template"]
+		file := strings.TrimSpace(parameters["File"])
+		if (strings.TrimSpace(text) == "") == (file == "") {
+			return nil, errors.New("either Template or File must be specified")
+		}
+		if file != "" {
+			return sdk.transformWithTemplateFile(file)
+		}
+		return sdk.transformWithTemplate(text)
+	},
+	"JavaScriptTransform": func(sdk *AppFunctionsSDK, parameters pipelineParameters) (func(*appcontext.Context, ...interface{}) (bool, interface{}), error) {
+		script := parameters["Script"]
+		file := strings.TrimSpace(parameters["File"])
+		if (strings.TrimSpace(script) == "") == (file == "") {
+			return nil, errors.New("either Script or File must be specified")
+		}
+		timeout, err := parameters.duration("Timeout")
+		if err != nil {
+			return nil, err
+		}
+		if file != "" {
+			return sdk.javaScriptTransformFile(file, timeout)
+		}
+		return sdk.javaScriptTransform(script, timeout)
+	},
+	"WASMTransform": func(sdk *AppFunctionsSDK, parameters pipelineParameters) (func(*appcontext.Context, ...interface{}) (bool, interface{}), error) {
+		file, err := parameters.required("File")
+		if err != nil {
+			return nil, err
+		}
+		function := strings.TrimSpace(parameters["Function"])
+		if function == "" {
+			function = "transform"
+		}
+		return sdk.wasmTransform(file, function)
+	},
+	"ProcessTransform": func(sdk *AppFunctionsSDK, parameters pipelineParameters) (func(*appcontext.Context, ...interface{}) (bool, interface{}), error) {
+		command, err := parameters.required("Command")
+		if err != nil {
+			return nil, err
+		}
+		var args []string
+		if strings.TrimSpace(parameters["Args"]) != "" {
+			if args, err = parameters.list("Args"); err != nil {
+				return nil, err
+			}
+		}
+		timeout, err := parameters.duration("Timeout")
+		if err != nil {
+			return nil, err
+		}
+		return sdk.processTransform(command, args, timeout)
+	},
+	"GZIPTransform": func(sdk *AppFunctionsSDK, parameters pipelineParameters) (func(*appcontext.Context, ...interface{}) (bool, interface{}), error) {
+		return sdk.GZIPTransform(), nil
+	},
+	"ZLIBTransform": func(sdk *AppFunctionsSDK, parameters pipelineParameters) (func(*appcontext.Context, ...interface{}) (bool, interface{}), error) {
+		return sdk.ZLIBTransform(), nil
+	},
+	"SetResponseData": func(sdk *AppFunctionsSDK, parameters pipelineParameters) (func(*appcontext.Context, ...interface{}) (bool, interface{}), error) {
+		return sdk.SetResponseData(parameters["ContentType"]), nil
+	},
+	"HTTPPost": func(sdk *AppFunctionsSDK, parameters pipelineParameters) (func(*appcontext.Context, ...interface{}) (bool, interface{}), error) {
+		url, err := parameters.required("Url")
+		if err != nil {
+			return nil, err
+		}
+		return sdk.HTTPPost(url, parameters["MimeType"]), nil
+	},
+	"HTTPPostJSON": func(sdk *AppFunctionsSDK, parameters pipelineParameters) (func(*appcontext.Context, ...interface{}) (bool, interface{}), error) {
+		url, err := parameters.required("Url")
+		if err != nil {
+			return nil, err
+		}
+		return sdk.HTTPPostJSON(url), nil
+	},
+	"HTTPPostXML": func(sdk *AppFunctionsSDK, parameters pipelineParameters) (func(*appcontext.Context, ...interface{}) (bool, interface{}), error) {
+		url, err := parameters.required("Url")
+		if err != nil {
+			return nil, err
+		}
+		return sdk.HTTPPostXML(url), nil
+	},
+	"MQTTSend": func(sdk *AppFunctionsSDK, parameters pipelineParameters) (func(*appcontext.Context, ...interface{}) (bool, interface{}), error) {
+		address, err := parameters.required("Address")
+		if err != nil {
+			return nil, err
+		}
+		port, err := parameters.int("Port")
+		if err != nil {
+			return nil, err
+		}
+		qos, err := parameters.int("Qos")
+		if err != nil {
+			return nil, err
+		}
+		retain, err := parameters.bool("Retain")
+		if err != nil {
+			return nil, err
+		}
+		autoReconnect, err := parameters.bool("AutoReconnect")
+		if err != nil {
+			return nil, err
+		}
+		orderMatters := true
+		if strings.TrimSpace(parameters["OrderMatters"]) != "" {
+			if orderMatters, err = parameters.bool("OrderMatters"); err != nil {
+				return nil, err
+			}
+		}
+		maxReconnectInterval, err := parameters.duration("MaxReconnectInterval")
+		if err != nil {
+			return nil, err
+		}
+		messageChannelDepth, err := parameters.int("MessageChannelDepth")
+		if err != nil {
+			return nil, err
+		}
+		if messageChannelDepth < 0 {
+			return nil, fmt.Errorf("MessageChannelDepth must not be negative, got %d", messageChannelDepth)
+		}
+		persistent, err := parameters.bool("Persistent")
+		if err != nil {
+			return nil, err
+		}
+		willQos, err := parameters.int("WillQos")
+		if err != nil {
+			return nil, err
+		}
+		willRetain, err := parameters.bool("WillRetain")
+		if err != nil {
+			return nil, err
+		}
+		maxRetries, err := parameters.int("MaxRetries")
+		if err != nil {
+			return nil, err
+		}
+		retryInterval, err := parameters.duration("RetryInterval")
+		if err != nil {
+			return nil, err
+		}
+		maxRetryInterval, err := parameters.duration("MaxRetryInterval")
+		if err != nil {
+			return nil, err
+		}
+		var failoverBrokers []string
+		if strings.TrimSpace(parameters["FailoverBrokers"]) != "" {
+			if failoverBrokers, err = parameters.list("FailoverBrokers"); err != nil {
+				return nil, err
+			}
+		}
+		addressable := models.Addressable{
+			Address:   address,
+			Port:      port,
+			Protocol:  parameters["Protocol"],
+			Path:      parameters["Path"],
+			Publisher: parameters["Publisher"],
+			User:      parameters["User"],
+			Password:  parameters["Password"],
+			Topic:     parameters["Topic"],
+		}
+		if addressable.Protocol == "" {
+			addressable.Protocol = "tcp"
+		}
+		if qos < 0 || qos > 2 {
+			return nil, fmt.Errorf("Qos must be 0, 1 or 2, got %d", qos)
+		}
+		if willQos < 0 || willQos > 2 {
+			return nil, fmt.Errorf("WillQos must be 0, 1 or 2, got %d", willQos)
+		}
+		options := []transforms.MqttOption{
+			transforms.WithQos(byte(qos)),
+			transforms.WithRetain(retain),
+			transforms.WithAutoreconnect(autoReconnect),
+			transforms.WithOrderMatters(orderMatters),
+			transforms.WithMaxReconnectInterval(maxReconnectInterval),
+			transforms.WithMessageChannelDepth(uint(messageChannelDepth)),
+			transforms.WithRetries(maxRetries, retryInterval, maxRetryInterval),
+			transforms.WithPersistent(persistent),
+			transforms.WithWill(parameters["WillTopic"], []byte(parameters["WillPayload"]), byte(willQos), willRetain),
+			transforms.WithFormat(strings.ToLower(strings.TrimSpace(parameters["Format"]))),
+			transforms.WithFailoverBrokers(failoverBrokers...),
+		}
+		if secretPath := strings.TrimSpace(parameters["SecretPath"]); secretPath != "" {
+			if addressable.Password != "" {
+				return nil, errors.New("either Password or SecretPath must be specified, not both")
+			}
+			credentials, err := sdk.secretCredentials(secretPath, addressable.User, passwordSecret)
+			if err != nil {
+				return nil, err
+			}
+			options = append(options, transforms.WithCredentialsProvider(credentials))
+		}
+		config, err := transforms.NewMqttConfigWithOptions(options...)
+		if err != nil {
+			return nil, err
+		}
+		return sdk.MQTTSendWithConfig(addressable, parameters["Cert"], parameters["Key"], config), nil
+	},
+	"FileExport": func(sdk *AppFunctionsSDK, parameters pipelineParameters) (func(*appcontext.Context, ...interface{}) (bool, interface{}), error) {
+		path, err := parameters.required("Path")
+		if err != nil {
+			return nil, err
+		}
+		maxSize, err := parameters.int("MaxSize")
+		if err != nil {
+			return nil, err
+		}
+		maxAge, err := parameters.duration("MaxAge")
+		if err != nil {
+			return nil, err
+		}
+		compress, err := parameters.bool("Compress")
+		if err != nil {
+			return nil, err
+		}
+		return sdk.FileExport(path, int64(maxSize), maxAge, compress), nil
+	},
+	"PushToCoreData": func(sdk *AppFunctionsSDK, parameters pipelineParameters) (func(*appcontext.Context, ...interface{}) (bool, interface{}), error) {
+		deviceName, err := parameters.required("DeviceName")
+		if err != nil {
+			return nil, err
+		}
+		readingName, err := parameters.required("ReadingName")
+		if err != nil {
+			return nil, err
+		}
+		return sdk.PushToCoreData(deviceName, readingName), nil
+	},
+}
+
+// LoadConfigurablePipeline returns the functions pipeline defined in the [Writable.Pipeline] section of the
+// configuration, to be set with SetFunctionsPipeline. This allows a generic app service, which has no code of its
+// own, to be configured for each use. ExecutionOrder lists the names of the built-in functions in the order they
+// are called, and the parameters of each function are set in [Writable.Pipeline.Functions.<name>.Parameters].
+// The pipeline is built when this function is called, so later changes to the section take effect once the
+// service is restarted.
+func (sdk *AppFunctionsSDK) LoadConfigurablePipeline() ([]func(*appcontext.Context, ...interface{}) (bool, interface{}), error) {
+	pipeline := sdk.config.Writable.Pipeline
+	if strings.TrimSpace(pipeline.ExecutionOrder) == "" {
+		return nil, errors.New("Writable.Pipeline.ExecutionOrder has no functions")
+	}
+
+	var pipelineFunctions []func(*appcontext.Context, ...interface{}) (bool, interface{})
+	for _, name := range strings.Split(pipeline.ExecutionOrder, ",") {
+		name = strings.TrimSpace(name)
+		factory, ok := builtInFunctions[name]
+		if !ok {
+			return nil, fmt.Errorf("function '%s' in Writable.Pipeline.ExecutionOrder is not a built-in function", name)
+		}
+
+		pipelineFunction, err := factory(sdk, pipelineParameters(pipeline.Functions[name].Parameters))
+		if err != nil {
+			return nil, fmt.Errorf("invalid parameters for function '%s': %v", name, err)
+		}
+		if pipelineFunction == nil {
+			return nil, fmt.Errorf("unable to create function '%s'", name)
+		}
+		pipelineFunctions = append(pipelineFunctions, pipelineFunction)
+	}
+
+	if sdk.LoggingClient != nil {
+		sdk.LoggingClient.Info("Functions pipeline loaded from configuration: " + pipeline.ExecutionOrder)
+	}
+	return pipelineFunctions, nil
+}
+
+// Secret keys read for the credentials of export functions
+const (
+	usernameSecret = "username"
+	passwordSecret = "password"
+	tokenSecret    = "token"
+)
+
+// secretCredentials returns a provider of the password secret, or of the secret named by passwordKey, along with the
+// username secret or the user when it isn't set, at the path in the secret store. They're read each time the export
+// function authenticates so rotated secrets are picked up, and once now so missing secrets are reported when the
+// function is created.
+func (sdk *AppFunctionsSDK) secretCredentials(path string, user string, passwordKey string) (transforms.CredentialsProvider, error) {
+	credentials := transforms.CredentialsProviderFunc(func() (string, string, error) {
+		secretValues, err := sdk.GetSecret(path)
+		if err != nil {
+			return "", "", fmt.Errorf("unable to read the secrets at '%s': %v", path, err)
+		}
+		if secretValues[passwordKey] == "" {
+			return "", "", fmt.Errorf("no %s secret at path '%s'", passwordKey, path)
+		}
+		if username := secretValues[usernameSecret]; username != "" {
+			return username, secretValues[passwordKey], nil
+		}
+		return user, secretValues[passwordKey], nil
+	})
+	if _, _, err := credentials.Credentials(); err != nil {
+		return nil, err
+	}
+	return credentials, nil
+}
+
+// pipelineParameters are the parameters of a function in a pipeline defined in the configuration
+type pipelineParameters map[string]string
+
+func (parameters pipelineParameters) required(name string) (string, error) {
+	value := strings.TrimSpace(parameters[name])
+	if value == "" {
+		return "", fmt.Errorf("%s must be specified", name)
+	}
+	return value, nil
+}
+
+// list returns the comma separated values of the parameter
+func (parameters pipelineParameters) list(name string) ([]string, error) {
+	value, err := parameters.required(name)
+	if err != nil {
+		return nil, err
+	}
+	var values []string
+	for _, item := range strings.Split(value, ",") {
+		values = append(values, strings.TrimSpace(item))
+	}
+	return values, nil
+}
+
+// int returns the integer value of the parameter, or 0 if it isn't set
+func (parameters pipelineParameters) int(name string) (int, error) {
+	value := strings.TrimSpace(parameters[name])
+	if value == "" {
+		return 0, nil
+	}
+	number, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("%s must be an integer, got '%s'", name, value)
+	}
+	return number, nil
+}
+
+// float returns the numeric value of the parameter, or 0 if it isn't set
+func (parameters pipelineParameters) float(name string) (float64, error) {
+	value := strings.TrimSpace(parameters[name])
+	if value == "" {
+		return 0, nil
+	}
+	number, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%s must be a number, got '%s'", name, value)
+	}
+	return number, nil
+}
+
+// bool returns the boolean value of the parameter, or false if it isn't set
+func (parameters pipelineParameters) bool(name string) (bool, error) {
+	value := strings.TrimSpace(parameters[name])
+	if value == "" {
+		return false, nil
+	}
+	flag, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("%s must be true or false, got '%s'", name, value)
+	}
+	return flag, nil
+}
+
+// duration returns the duration value of the parameter, i.e. '1m', or 0 if it isn't set
+func (parameters pipelineParameters) duration(name string) (time.Duration, error) {
+	value := strings.TrimSpace(parameters[name])
+	if value == "" {
+		return 0, nil
+	}
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("%s must be a duration such as '1m', got '%s'", name, value)
+	}
+	return duration, nil
+}
@@ -0,0 +1,83 @@
+//
+// Copyright (c) 2019 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package appsdk
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/antoniomtz/app-functions-sdk-go/appcontext"
+	"github.com/antoniomtz/app-functions-sdk-go/internal/runtime"
+	"github.com/antoniomtz/app-functions-sdk-go/pkg/devices"
+	"github.com/antoniomtz/app-functions-sdk-go/pkg/di"
+	"github.com/antoniomtz/app-functions-sdk-go/pkg/secrets"
+	"github.com/edgexfoundry/go-mod-core-contracts/clients/command"
+	"github.com/edgexfoundry/go-mod-core-contracts/clients/coredata"
+	"github.com/edgexfoundry/go-mod-core-contracts/clients/metadata"
+	"github.com/edgexfoundry/go-mod-core-contracts/clients/notifications"
+)
+
+// startDeviceEvents polls core-metadata for device lifecycle events in the background and executes the device events
+// pipeline for each of them. It returns the watcher, or nil when no device events pipeline is set or it can't be
+// started. The runtime may be nil when replaced by a custom runtime.
+func (sdk *AppFunctionsSDK) startDeviceEvents(pipelineRuntime *runtime.GolangRuntime, get di.Get) *devices.Watcher {
+	if len(sdk.deviceEvents) == 0 {
+		return nil
+	}
+	if pipelineRuntime == nil {
+		sdk.LoggingClient.Error("The device events pipeline requires the SDK's runtime")
+		return nil
+	}
+	deviceClient, ok := get(di.DeviceClientName).(metadata.DeviceClient)
+	if !ok {
+		sdk.LoggingClient.Error("The device events pipeline requires the Metadata client to be configured")
+		return nil
+	}
+
+	var interval time.Duration
+	if pollInterval := sdk.config.DeviceEvents.PollInterval; pollInterval != "" {
+		parsed, err := time.ParseDuration(pollInterval)
+		if err != nil || parsed <= 0 {
+			sdk.LoggingClient.Error(fmt.Sprintf("Invalid DeviceEvents.PollInterval '%s', using %s", pollInterval, devices.DefaultPollInterval))
+		} else {
+			interval = parsed
+		}
+	}
+
+	eventClient, _ := get(di.EventClientName).(coredata.EventClient)
+	commandClient, _ := get(di.CommandClientName).(command.CommandClient)
+	notificationsClient, _ := get(di.NotificationsClientName).(notifications.NotificationsClient)
+	secretProvider, _ := get(di.SecretProviderName).(secrets.SecretProvider)
+
+	watcher := devices.NewWatcher(deviceClient, interval, sdk.LoggingClient)
+	go watcher.Run(func(event devices.DeviceEvent) {
+		edgexcontext := &appcontext.Context{
+			Configuration:       sdk.config,
+			LoggingClient:       sdk.LoggingClient,
+			CorrelationID:       "device-" + event.Action + "-" + event.Device.Name + "-" + strconv.FormatInt(event.Time.UnixNano(), 10),
+			EventClient:         eventClient,
+			CommandClient:       commandClient,
+			NotificationsClient: notificationsClient,
+			SecretProvider:      secretProvider,
+			Ctx:                 sdk.ctx,
+		}
+		pipelineRuntime.ProcessDeviceEvent(edgexcontext, event)
+	})
+	sdk.LoggingClient.Info("Watching core-metadata for device lifecycle events")
+	return watcher
+}
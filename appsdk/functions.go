@@ -18,10 +18,13 @@ package appsdk
 
 import (
 	"errors"
+	"fmt"
+	"time"
 
 	"github.com/edgexfoundry/go-mod-core-contracts/models"
 
 	"github.com/antoniomtz/app-functions-sdk-go/appcontext"
+	"github.com/antoniomtz/app-functions-sdk-go/internal/runtime"
 	"github.com/antoniomtz/app-functions-sdk-go/pkg/transforms"
 )
 
@@ -31,10 +34,205 @@ func (sdk *AppFunctionsSDK) SetFunctionsPipeline(transforms ...func(edgexcontext
 	if len(transforms) == 0 {
 		return errors.New("No transforms provided to pipeline")
 	}
+	if err := checkTransforms("pipeline", transforms); err != nil {
+		return err
+	}
 	sdk.transforms = transforms
 	return nil
 }
 
+// SetCandidateFunctionsPipeline loads a second functions pipeline alongside the one set by SetFunctionsPipeline so new
+// processing logic can be validated on live data before it is fully rolled out. Events from the specified devices are
+// always processed by the candidate pipeline, and the specified percentage (0-100) of the events from all other devices
+// are processed by it as well. Each event is processed by only one of the pipelines. The metrics for each pipeline are
+// available from the /api/v1/metrics/pipelines endpoint.
+func (sdk *AppFunctionsSDK) SetCandidateFunctionsPipeline(percentage int, deviceNames []string, transforms ...func(edgexcontext *appcontext.Context, params ...interface{}) (bool, interface{})) error {
+	if len(transforms) == 0 {
+		return errors.New("No transforms provided to candidate pipeline")
+	}
+	if err := checkTransforms("candidate pipeline", transforms); err != nil {
+		return err
+	}
+	if percentage < 0 || percentage > 100 {
+		return fmt.Errorf("Candidate pipeline percentage must be between 0 and 100, got %d", percentage)
+	}
+	sdk.candidate = &runtime.CandidatePipeline{
+		Transforms:  transforms,
+		Percentage:  percentage,
+		DeviceNames: deviceNames,
+	}
+	return nil
+}
+
+// AddFunctionsPipelineForTopics adds a functions pipeline which processes the events received on the specified topics
+// by the message bus trigger, so one app service can process different kinds of events differently, i.e. sending
+// temperature events to the cloud and camera events to local storage. The topics are subscribed to in addition to the
+// configured subscribe topics, which are only subscribed to when a pipeline is also set with SetFunctionsPipeline.
+// Each topic can belong to only one pipeline. The metrics for each pipeline are available under its id from the
+// /api/v1/metrics/pipelines endpoint, and the id is logged along with the errors returned by its functions.
+// Events received by other triggers are always processed by the pipeline set with SetFunctionsPipeline.
+func (sdk *AppFunctionsSDK) AddFunctionsPipelineForTopics(id string, topics []string, transforms ...func(edgexcontext *appcontext.Context, params ...interface{}) (bool, interface{})) error {
+	if len(transforms) == 0 {
+		return fmt.Errorf("No transforms provided to pipeline '%s'", id)
+	}
+	if err := checkTransforms(fmt.Sprintf("pipeline '%s'", id), transforms); err != nil {
+		return err
+	}
+	if id == "" || id == runtime.PrimaryPipelineName || id == runtime.CandidatePipelineName || id == runtime.DeviceEventsPipelineName {
+		return fmt.Errorf("Invalid pipeline id '%s'", id)
+	}
+	if len(topics) == 0 {
+		return fmt.Errorf("No topics provided for pipeline '%s'", id)
+	}
+
+	for _, pipeline := range sdk.topicPipelines {
+		if pipeline.ID == id {
+			return fmt.Errorf("Pipeline '%s' already exists", id)
+		}
+		for _, topic := range topics {
+			if containsString(pipeline.Topics, topic) {
+				return fmt.Errorf("Topic '%s' already belongs to pipeline '%s'", topic, pipeline.ID)
+			}
+		}
+	}
+
+	sdk.topicPipelines = append(sdk.topicPipelines, &runtime.TopicPipeline{
+		ID:         id,
+		Topics:     topics,
+		Transforms: transforms,
+	})
+	return nil
+}
+
+// SetDeviceEventsPipeline sets a functions pipeline which processes the lifecycle events of the devices registered in
+// core-metadata, so the app service can react when devices are added, updated or removed, i.e. provisioning a cloud
+// twin or updating a routing table. The first function is called with a devices.DeviceEvent. Core-metadata is polled
+// for changes at the DeviceEvents.PollInterval, and requires the Metadata client to be configured. The metrics for the
+// pipeline are available under "device-events" from the /api/v1/metrics/pipelines endpoint.
+func (sdk *AppFunctionsSDK) SetDeviceEventsPipeline(transforms ...func(edgexcontext *appcontext.Context, params ...interface{}) (bool, interface{})) error {
+	if len(transforms) == 0 {
+		return errors.New("No transforms provided to device events pipeline")
+	}
+	if err := checkTransforms("device events pipeline", transforms); err != nil {
+		return err
+	}
+	sdk.deviceEvents = transforms
+	return nil
+}
+
+// checkTransforms returns an error when a function of the pipeline is nil, as returned by a configuration function
+// which failed to create it, so the pipeline isn't set only to panic on each event
+func checkTransforms(pipeline string, transforms []func(*appcontext.Context, ...interface{}) (bool, interface{})) error {
+	for i, transform := range transforms {
+		if transform == nil {
+			return fmt.Errorf("Function %d of the %s is nil, see the error logged when it was created", i+1, pipeline)
+		}
+	}
+	return nil
+}
+
+// SetPipelineErrorHandler sets a function which is called whenever a pipeline function returns an error, i.e. to raise
+// a custom alert or log the failure to a side channel. The handler is called with the name of the failing function,
+// the error and the payload received by the trigger, before the pipeline stops. It is called by the goroutine which
+// processed the event, so it should return quickly, and a panic in the handler is logged.
+func (sdk *AppFunctionsSDK) SetPipelineErrorHandler(handler func(edgexcontext *appcontext.Context, functionName string, err error, payload []byte)) {
+	sdk.errorHandler = handler
+}
+
+// ForEachReading executes the specified functions against each reading of the event received from the previous
+// function, so per-measurement logic (i.e. unit conversion) doesn't need to iterate over the readings itself.
+// The first function is called with a models.Reading and each successive function with the result of the previous one.
+// A reading is removed from the event when a function stops the pipeline for it, and is replaced when the last function
+// returns a models.Reading. If a function returns an error, processing of the whole event stops.
+// This function returns an Event and stops the pipeline if no readings remain.
+// This function is a configuration function and returns a function pointer.
+func (sdk *AppFunctionsSDK) ForEachReading(transforms ...func(edgexcontext *appcontext.Context, params ...interface{}) (bool, interface{})) func(*appcontext.Context, ...interface{}) (bool, interface{}) {
+	if err := checkTransforms("ForEachReading pipeline", transforms); err != nil {
+		sdk.LoggingClient.Error("Failed to create ForEachReading: " + err.Error())
+		return nil
+	}
+	pipeline := runtime.ReadingPipeline{
+		Transforms: transforms,
+	}
+	return pipeline.ProcessReadings
+}
+
+// SplitReadings executes the specified functions against an event per reading of the event received from the previous
+// function, i.e. for exports which require a message per metric. Each event holds a single reading along with the
+// device and origin of the event. The first function is called with the models.Event of a reading and each successive
+// function with the result of the previous one. The functions stop for an event when one of them returns false and
+// if a function returns an error, the remaining readings are not processed.
+// This function returns the Event received so the pipeline can continue with the whole event.
+// This function is a configuration function and returns a function pointer.
+func (sdk *AppFunctionsSDK) SplitReadings(transforms ...func(edgexcontext *appcontext.Context, params ...interface{}) (bool, interface{})) func(*appcontext.Context, ...interface{}) (bool, interface{}) {
+	if err := checkTransforms("SplitReadings pipeline", transforms); err != nil {
+		sdk.LoggingClient.Error("Failed to create SplitReadings: " + err.Error())
+		return nil
+	}
+	pipeline := runtime.SplitPipeline{
+		Transforms: transforms,
+	}
+	return pipeline.ProcessSplit
+}
+
+// OnlyWhen runs the provided function only when the predicate is true for the data from the previous function,
+// i.e. to export only during business hours using transforms.TimeOfDayBetween or only for alarm events using
+// transforms.ReadingValueIs. Otherwise the data is passed unchanged to the next function in the pipeline.
+// This function is a configuration function and returns a function pointer.
+func (sdk *AppFunctionsSDK) OnlyWhen(predicate transforms.Predicate, transform func(*appcontext.Context, ...interface{}) (bool, interface{})) func(*appcontext.Context, ...interface{}) (bool, interface{}) {
+	if transform == nil {
+		sdk.LoggingClient.Error("Failed to create OnlyWhen: the function is nil")
+		return nil
+	}
+	conditional := transforms.Conditional{
+		Predicate: predicate,
+		Transform: transform,
+	}
+	return conditional.OnlyWhen
+}
+
+// Memoize caches the results of an expensive pure function keyed by a hash of the data it receives, so it isn't
+// executed again for identical payloads, i.e. when rendering a template or validating a schema for duplicate
+// readings. Up to capacity results are held for ttl, or until evicted when ttl is 0. The function must return the
+// same result for the same data and must not use the context, and the functions which follow must not modify its
+// results as they are shared. Errors are never cached. The hit rate is reported under the name by the
+// /api/v1/metrics/caches endpoint.
+// This function is a configuration function and returns a function pointer.
+func (sdk *AppFunctionsSDK) Memoize(name string, capacity int, ttl time.Duration, transform func(*appcontext.Context, ...interface{}) (bool, interface{})) func(*appcontext.Context, ...interface{}) (bool, interface{}) {
+	if transform == nil {
+		sdk.LoggingClient.Error("Failed to create Memoize: the function is nil")
+		return nil
+	}
+	cache := &runtime.TransformCache{
+		Name:      name,
+		Transform: transform,
+		Capacity:  capacity,
+		TTL:       ttl,
+	}
+	sdk.caches = append(sdk.caches, cache)
+	return cache.Memoize
+}
+
+// LimitConcurrency restricts the number of concurrent calls to an expensive function, i.e. an inference, independent
+// of the number of workers processing events, so it can't use up the CPU while the other functions of the pipeline
+// are idle. Calls beyond maxConcurrent wait in turn for a call in progress to finish, and stop the pipeline with an
+// error if the pipeline deadline passes first. The calls running and waiting are reported under the name by the
+// /api/v1/metrics/concurrency endpoint.
+// This function is a configuration function and returns a function pointer.
+func (sdk *AppFunctionsSDK) LimitConcurrency(name string, maxConcurrent int, transform func(*appcontext.Context, ...interface{}) (bool, interface{})) func(*appcontext.Context, ...interface{}) (bool, interface{}) {
+	if transform == nil {
+		sdk.LoggingClient.Error("Failed to create LimitConcurrency: the function is nil")
+		return nil
+	}
+	limit := &runtime.ConcurrencyLimit{
+		Name:          name,
+		Transform:     transform,
+		MaxConcurrent: maxConcurrent,
+	}
+	sdk.limits = append(sdk.limits, limit)
+	return limit.Limit
+}
+
 // DeviceNameFilter - Specify the devices of interest to filter for data coming from certain sensors.
 // The Filter by Device transform looks at the Event in the message and looks at the devices of interest list,
 // provided by this function, and filters out those messages whose Event is for devices not on the
@@ -65,6 +263,350 @@ func (sdk *AppFunctionsSDK) ValueDescriptorFilter(valueIDs []string) func(*appco
 	return transforms.FilterByValueDescriptor
 }
 
+// SampleOneIn forwards 1 in n events to the next function in the pipeline and drops the others, i.e. to send
+// representative data to expensive cloud analytics while keeping full fidelity locally. The events of a device
+// created within the same bucketSize interval are forwarded or dropped together; pass 0 to sample each event on
+// its own. The events forwarded are decided by a hash of the device name and time bucket, so are the same across
+// restarts and instances.
+// This function will return an error and stop the pipeline if a non-edgex
+// event is received or if no data is recieved.
+// This function is a configuration function and returns a function pointer.
+func (sdk *AppFunctionsSDK) SampleOneIn(n int, bucketSize time.Duration) func(*appcontext.Context, ...interface{}) (bool, interface{}) {
+	sampler := transforms.Sampler{
+		OneIn:      n,
+		BucketSize: bucketSize,
+	}
+	return sampler.Sample
+}
+
+// SamplePercentage forwards the specified percentage of events to the next function in the pipeline, from 0 to 100,
+// and drops the others. Events are sampled in the same way as SampleOneIn.
+// This function will return an error and stop the pipeline if a non-edgex
+// event is received or if no data is recieved.
+// This function is a configuration function and returns a function pointer.
+func (sdk *AppFunctionsSDK) SamplePercentage(percentage float64, bucketSize time.Duration) func(*appcontext.Context, ...interface{}) (bool, interface{}) {
+	sampler := transforms.Sampler{
+		Percentage: percentage,
+		BucketSize: bucketSize,
+	}
+	return sampler.Sample
+}
+
+// RateLimit forwards 1 of every events of each device, and at most maxEvents events of each device per window, to
+// the next function in the pipeline and drops the others, so high frequency devices don't overwhelm cloud quotas.
+// Zero disables the respective limit. Unlike SampleOneIn, the events of each device are counted exactly, by this
+// instance of the service. Nil is returned if no limit is set or the window is missing.
+// This function will return an error and stop the pipeline if a non-edgex event is received.
+// This function is a configuration function and returns a function pointer.
+func (sdk *AppFunctionsSDK) RateLimit(every int, maxEvents int, window time.Duration) func(*appcontext.Context, ...interface{}) (bool, interface{}) {
+	transform, err := sdk.rateLimit(every, maxEvents, window)
+	if err != nil {
+		sdk.LoggingClient.Error("Failed to create rate limiter: " + err.Error())
+	}
+	return transform
+}
+
+// rateLimit returns the RateLimit function, or the error when it can't be created
+func (sdk *AppFunctionsSDK) rateLimit(every int, maxEvents int, window time.Duration) (func(*appcontext.Context, ...interface{}) (bool, interface{}), error) {
+	limiter, err := transforms.NewRateLimiter(every, maxEvents, window)
+	if err != nil {
+		return nil, err
+	}
+	return limiter.RateLimit, nil
+}
+
+// VerifySignature rejects events whose signature isn't valid for any of the PEM encoded RSA or ECDSA publicKeys,
+// so tampered data is stopped at the entrance of the pipeline. The base64 encoded signature is taken from the
+// X-Signature header received by the HTTP trigger, or from the reading named signatureReading when set.
+// This function will return an error and stop the pipeline if a non-edgex
+// event is received, if no data is recieved or if the signature is missing or invalid.
+// This function is a configuration function and returns a function pointer.
+func (sdk *AppFunctionsSDK) VerifySignature(publicKeys [][]byte, signatureReading string) func(*appcontext.Context, ...interface{}) (bool, interface{}) {
+	verifier, err := transforms.NewSignatureVerifier(publicKeys, signatureReading)
+	if err != nil {
+		sdk.LoggingClient.Error("Failed to create signature verifier: " + err.Error())
+		return nil
+	}
+	return verifier.VerifySignature
+}
+
+// PseudonymizeDevices replaces the device name of each event with an opaque random identifier, enabling privacy
+// preserving analytics in the cloud. The mapping from device names to pseudonyms is persisted to mappingFile, so a
+// device always maps to the same pseudonym and the data can be re-identified locally.
+// This function will return an error and stop the pipeline if a non-edgex
+// event is received or if no data is recieved.
+// This function is a configuration function and returns a function pointer.
+func (sdk *AppFunctionsSDK) PseudonymizeDevices(mappingFile string) func(*appcontext.Context, ...interface{}) (bool, interface{}) {
+	pseudonymizer, err := transforms.NewPseudonymizer(mappingFile)
+	if err != nil {
+		sdk.LoggingClient.Error("Failed to create pseudonymizer: " + err.Error())
+		return nil
+	}
+	return pseudonymizer.Pseudonymize
+}
+
+// LocalizeReadings replaces enumerated reading values (i.e. "0"/"1") with the human-readable labels found in the
+// lookup table for the specified locale. If no table exists for a locale with a region (i.e. "fr-CA"), the
+// table for its base language (i.e. "fr") is used. Values not found in the lookup table are left unchanged.
+// This function will return an error and stop the pipeline if a non-edgex
+// event is received or if no table is found for the locale.
+// This function is a configuration function and returns a function pointer.
+func (sdk *AppFunctionsSDK) LocalizeReadings(locale string, labels map[string]transforms.LocalizationLabels) func(*appcontext.Context, ...interface{}) (bool, interface{}) {
+	transforms := transforms.Localization{
+		Locale: locale,
+		Labels: labels,
+	}
+	return transforms.LocalizeReadings
+}
+
+// ScaleAndOffset applies a calibration, y = Scale * x + Offset, to the readings of each value descriptor in
+// readings, optionally clamping the result to a Min and Max and rounding it to a Precision, and renames them when the
+// calibration has a Name, i.e. to convert raw register values to engineering units. Readings of other value
+// descriptors are left unchanged.
+// This function will return an error and stop the pipeline if a non-edgex
+// event is received or if a reading to be scaled doesn't have a numeric value.
+// This function is a configuration function and returns a function pointer.
+func (sdk *AppFunctionsSDK) ScaleAndOffset(readings map[string]transforms.ScaleConfig) func(*appcontext.Context, ...interface{}) (bool, interface{}) {
+	transforms := transforms.ScaleAndOffset{
+		Readings: readings,
+	}
+	return transforms.Scale
+}
+
+// ComputeReadings replaces the value of the readings of each value descriptor in readings with the result of its
+// expression, i.e. `Round(Float64(value) * 1.8 + 32, 1)`, evaluated with the deviceName, readingName, value and origin
+// of the reading. Readings of other value descriptors are left unchanged. Nil is returned if an expression is invalid.
+// This function will return an error and stop the pipeline if a non-edgex
+// event is received or if an expression can't be evaluated.
+// This function is a configuration function and returns a function pointer.
+func (sdk *AppFunctionsSDK) ComputeReadings(readings map[string]string) func(*appcontext.Context, ...interface{}) (bool, interface{}) {
+	transform, err := sdk.computeReadings(readings)
+	if err != nil {
+		sdk.LoggingClient.Error("Failed to create reading computer: " + err.Error())
+	}
+	return transform
+}
+
+// computeReadings returns the ComputeReadings function, or the error when it can't be created
+func (sdk *AppFunctionsSDK) computeReadings(readings map[string]string) (func(*appcontext.Context, ...interface{}) (bool, interface{}), error) {
+	computer, err := transforms.NewReadingComputer(readings)
+	if err != nil {
+		return nil, err
+	}
+	return computer.ComputeReadings, nil
+}
+
+// FilterByValue removes the readings whose value doesn't satisfy the condition, a range and/or comparisons, of
+// their value descriptor in readings, i.e. only Temperature readings above 80. Readings of other value descriptors
+// are passed through unchanged, while readings to be filtered which don't have a numeric value are removed.
+// If no readings remain the pipeline execution stops.
+// This function will return an error and stop the pipeline if a non-edgex event is received.
+// This function is a configuration function and returns a function pointer.
+func (sdk *AppFunctionsSDK) FilterByValue(readings map[string]transforms.ValueCondition) func(*appcontext.Context, ...interface{}) (bool, interface{}) {
+	transforms := transforms.ValueFilter{
+		Readings: readings,
+	}
+	return transforms.FilterByValue
+}
+
+// FilterByExpression removes the readings for which the expression is false, i.e.
+// `Float64(value) > 40 && deviceName =~ "Random-.*"`, evaluated with the deviceName, readingName, value and origin of
+// each reading. Readings for which it can't be evaluated are removed as well. If no readings remain the pipeline
+// execution stops. Nil is returned if the expression is invalid.
+// This function will return an error and stop the pipeline if a non-edgex event is received.
+// This function is a configuration function and returns a function pointer.
+func (sdk *AppFunctionsSDK) FilterByExpression(expression string) func(*appcontext.Context, ...interface{}) (bool, interface{}) {
+	transform, err := sdk.filterByExpression(expression)
+	if err != nil {
+		sdk.LoggingClient.Error("Failed to create expression filter: " + err.Error())
+	}
+	return transform
+}
+
+// filterByExpression returns the FilterByExpression function, or the error when it can't be created
+func (sdk *AppFunctionsSDK) filterByExpression(expression string) (func(*appcontext.Context, ...interface{}) (bool, interface{}), error) {
+	filter, err := transforms.NewExpressionFilter(expression)
+	if err != nil {
+		return nil, err
+	}
+	return filter.FilterByExpression, nil
+}
+
+// DeltaFilter drops the readings whose value hasn't changed since the value last forwarded for the same device and
+// reading, reducing the chatter from slow changing sensors. Numeric values are forwarded once they changed by at least
+// delta, or by at least percentage of the value last forwarded. When both are zero any change is forwarded.
+// If no readings remain the pipeline execution stops.
+// This function will return an error and stop the pipeline if a non-edgex event is received.
+// This function is a configuration function and returns a function pointer.
+func (sdk *AppFunctionsSDK) DeltaFilter(delta float64, percentage float64) func(*appcontext.Context, ...interface{}) (bool, interface{}) {
+	filter := transforms.NewDeltaFilter(delta, percentage)
+	return filter.FilterUnchanged
+}
+
+// DeduplicateEvents drops the events already seen within window of their first delivery, protecting downstream
+// systems from the redeliveries caused by retries. Events are identified by key, either transforms.DedupKeyID, their
+// ID or their content when they have no ID, or transforms.DedupKeyContent, a hash of their device, origin, readings
+// and values. Nil is returned if the window or key is invalid.
+// This function will return an error and stop the pipeline if a non-edgex event is received.
+// This function is a configuration function and returns a function pointer.
+func (sdk *AppFunctionsSDK) DeduplicateEvents(window time.Duration, key string) func(*appcontext.Context, ...interface{}) (bool, interface{}) {
+	transform, err := sdk.deduplicateEvents(window, key)
+	if err != nil {
+		sdk.LoggingClient.Error("Failed to create deduplicator: " + err.Error())
+	}
+	return transform
+}
+
+// deduplicateEvents returns the DeduplicateEvents function, or the error when it can't be created
+func (sdk *AppFunctionsSDK) deduplicateEvents(window time.Duration, key string) (func(*appcontext.Context, ...interface{}) (bool, interface{}), error) {
+	deduplicator, err := transforms.NewDeduplicator(window, key)
+	if err != nil {
+		return nil, err
+	}
+	return deduplicator.Deduplicate, nil
+}
+
+// AggregateReadings aggregates the numeric readings of each device over the windows of config, by time or count,
+// tumbling or sliding, and passes a summary event with the min, max, avg, count and/or sum of each window to the next
+// function in the pipeline once windows are complete. When config.StateFile is set, partial windows are persisted
+// so they aren't lost when the service restarts. Nil is returned if the config is invalid.
+// This function will return an error and stop the pipeline if a non-edgex event is received.
+// This function is a configuration function and returns a function pointer.
+func (sdk *AppFunctionsSDK) AggregateReadings(config transforms.AggregationConfig) func(*appcontext.Context, ...interface{}) (bool, interface{}) {
+	transform, err := sdk.aggregateReadings(config)
+	if err != nil {
+		sdk.LoggingClient.Error("Failed to create aggregator: " + err.Error())
+	}
+	return transform
+}
+
+// aggregateReadings returns the AggregateReadings function, or the error when it can't be created
+func (sdk *AppFunctionsSDK) aggregateReadings(config transforms.AggregationConfig) (func(*appcontext.Context, ...interface{}) (bool, interface{}), error) {
+	aggregator, err := transforms.NewAggregator(config)
+	if err != nil {
+		return nil, err
+	}
+	return aggregator.Aggregate, nil
+}
+
+// JoinReadings correlates the readings of several devices, i.e. a temperature and a humidity sensor, and passes an
+// event holding a reading of each of config.Sources to the next function in the pipeline once they are all received
+// within config.Window. The readings received are passed on when the window expires if config.Partial is set, and
+// dropped otherwise. Nil is returned if the config is invalid.
+// This function will return an error and stop the pipeline if a non-edgex event is received.
+// This function is a configuration function and returns a function pointer.
+func (sdk *AppFunctionsSDK) JoinReadings(config transforms.JoinConfig) func(*appcontext.Context, ...interface{}) (bool, interface{}) {
+	transform, err := sdk.joinReadings(config)
+	if err != nil {
+		sdk.LoggingClient.Error("Failed to create joiner: " + err.Error())
+	}
+	return transform
+}
+
+// joinReadings returns the JoinReadings function, or the error when it can't be created
+func (sdk *AppFunctionsSDK) joinReadings(config transforms.JoinConfig) (func(*appcontext.Context, ...interface{}) (bool, interface{}), error) {
+	joiner, err := transforms.NewJoiner(config)
+	if err != nil {
+		return nil, err
+	}
+	return joiner.Join, nil
+}
+
+// SmoothReadings adds a reading with the moving average of each reading of the value descriptors in readings, either
+// the simple moving average of the last values or an exponential moving average, for noise sensitive analytics.
+// The average of each device is tracked separately. Nil is returned if a moving average is invalid.
+// This function will return an error and stop the pipeline if a non-edgex event is received.
+// This function is a configuration function and returns a function pointer.
+func (sdk *AppFunctionsSDK) SmoothReadings(readings map[string]transforms.SmoothingConfig) func(*appcontext.Context, ...interface{}) (bool, interface{}) {
+	transform, err := sdk.smoothReadings(readings)
+	if err != nil {
+		sdk.LoggingClient.Error("Failed to create smoother: " + err.Error())
+	}
+	return transform
+}
+
+// smoothReadings returns the SmoothReadings function, or the error when it can't be created
+func (sdk *AppFunctionsSDK) smoothReadings(readings map[string]transforms.SmoothingConfig) (func(*appcontext.Context, ...interface{}) (bool, interface{}), error) {
+	smoother, err := transforms.NewSmoother(readings)
+	if err != nil {
+		return nil, err
+	}
+	return smoother.Smooth, nil
+}
+
+// RejectOutliers drops the readings which deviate from the rolling baseline of the last values of the same device and
+// reading, either by more than a number of standard deviations from the mean or by more than a number of
+// interquartile ranges beyond the quartiles. The readings evaluated and rejected are reported under the name by the
+// /api/v1/metrics/outliers endpoint. Nil is returned if the config is invalid.
+// This function will return an error and stop the pipeline if a non-edgex event is received.
+// This function is a configuration function and returns a function pointer.
+func (sdk *AppFunctionsSDK) RejectOutliers(name string, config transforms.OutlierConfig) func(*appcontext.Context, ...interface{}) (bool, interface{}) {
+	transform, err := sdk.rejectOutliers(name, config)
+	if err != nil {
+		sdk.LoggingClient.Error("Failed to create outlier filter: " + err.Error())
+	}
+	return transform
+}
+
+// rejectOutliers returns the RejectOutliers function, or the error when it can't be created
+func (sdk *AppFunctionsSDK) rejectOutliers(name string, config transforms.OutlierConfig) (func(*appcontext.Context, ...interface{}) (bool, interface{}), error) {
+	filter, err := transforms.NewOutlierFilter(name, config)
+	if err != nil {
+		return nil, err
+	}
+	sdk.reportMetrics("outliers", name, func() interface{} { return filter.Metrics() })
+	return filter.RejectOutliers, nil
+}
+
+// InferWithModel sends the readings of each event, numeric features or binary data such as camera images, to the
+// model server endpoint of config, TensorFlow Serving, OpenVINO Model Server, or a server of the KServe v2 protocol
+// such as Triton, and adds a reading with the inference result to the event. Events processed concurrently are sent
+// in batches of up to config.BatchSize. The requests and their latency are reported under the name by the
+// /api/v1/metrics/inference endpoint. Nil is returned if the config is invalid.
+// This function will return an error and stop the pipeline if a non-edgex event is received or the request fails.
+// This function is a configuration function and returns a function pointer.
+func (sdk *AppFunctionsSDK) InferWithModel(name string, config transforms.InferenceConfig) func(*appcontext.Context, ...interface{}) (bool, interface{}) {
+	transform, err := sdk.inferWithModel(name, config)
+	if err != nil {
+		sdk.LoggingClient.Error("Failed to create inference client: " + err.Error())
+	}
+	return transform
+}
+
+// inferWithModel returns the InferWithModel function, or the error when it can't be created
+func (sdk *AppFunctionsSDK) inferWithModel(name string, config transforms.InferenceConfig) (func(*appcontext.Context, ...interface{}) (bool, interface{}), error) {
+	client, err := transforms.NewInferenceClient(name, config)
+	if err != nil {
+		return nil, err
+	}
+	sdk.reportMetrics("inference", name, func() interface{} { return client.Metrics() })
+	return client.Infer, nil
+}
+
+// AddTags attaches the static tags, i.e. the site, gateway ID or GPS coordinates, to each event along with the
+// hostname as the hostnameTag and the time the event is processed as the processedTag when they are set. The
+// transforms.TaggedEvent returned is accepted by the JSON and XML transforms and by the export functions which
+// encode their data as JSON, so AddTags should follow the functions which expect an Event.
+// Nil is returned if the hostname is unavailable.
+// This function will return an error and stop the pipeline if a non-edgex event is received.
+// This function is a configuration function and returns a function pointer.
+func (sdk *AppFunctionsSDK) AddTags(tags map[string]string, hostnameTag string, processedTag string) func(*appcontext.Context, ...interface{}) (bool, interface{}) {
+	transform, err := sdk.addTags(tags, hostnameTag, processedTag)
+	if err != nil {
+		sdk.LoggingClient.Error("Failed to create tagger: " + err.Error())
+	}
+	return transform
+}
+
+// addTags returns the AddTags function, or the error when it can't be created
+func (sdk *AppFunctionsSDK) addTags(tags map[string]string, hostnameTag string, processedTag string) (func(*appcontext.Context, ...interface{}) (bool, interface{}), error) {
+	tagger, err := transforms.NewTagger(tags, hostnameTag, processedTag)
+	if err != nil {
+		return nil, err
+	}
+	return tagger.AddTags, nil
+}
+
 // AESTransform encrypts either a string, []byte, or json.Marshaller type using AES encryption.
 // It will return a byte[] of the encrypted data.
 // This function is a configuration function and returns a function pointer.
@@ -76,6 +618,63 @@ func (sdk *AppFunctionsSDK) AESTransform(encryptionDetails models.EncryptionDeta
 	return transforms.AESTransform
 }
 
+// ParseKeyValues replaces each of the named readings (all readings when none are named) with a reading for each of
+// the key/value pairs in its value, i.e. "temp=21.5,humidity=40". Empty separators default to "," and "=".
+// This function is a configuration function and returns a function pointer.
+func (sdk *AppFunctionsSDK) ParseKeyValues(readingNames []string, pairSeparator string, keyValueSeparator string) func(*appcontext.Context, ...interface{}) (bool, interface{}) {
+	parser := transforms.KeyValueParser{
+		ReadingNames:      readingNames,
+		PairSeparator:     pairSeparator,
+		KeyValueSeparator: keyValueSeparator,
+	}
+	return parser.ParseKeyValues
+}
+
+// ParseNMEA replaces each of the named readings (all readings when none are named) with readings for the position
+// and fix details in its NMEA 0183 GGA or RMC sentence.
+// This function is a configuration function and returns a function pointer.
+func (sdk *AppFunctionsSDK) ParseNMEA(readingNames []string) func(*appcontext.Context, ...interface{}) (bool, interface{}) {
+	parser := transforms.NMEAParser{
+		ReadingNames: readingNames,
+	}
+	return parser.ParseNMEA
+}
+
+// ParseRegisters replaces each of the named readings (all readings when none are named) with a reading for each
+// field of the layout, decoded from the hex-encoded register block in its value.
+// This function is a configuration function and returns a function pointer.
+func (sdk *AppFunctionsSDK) ParseRegisters(readingNames []string, layout []transforms.RegisterField) func(*appcontext.Context, ...interface{}) (bool, interface{}) {
+	parser := transforms.RegisterParser{
+		ReadingNames: readingNames,
+		Layout:       layout,
+	}
+	return parser.ParseRegisters
+}
+
+// PushToCoreData creates a new event in Core Data for the specified device with a single reading whose value is
+// the data from the previous function, enabling pipelines which derive new readings such as averages.
+// This function is a configuration function and returns a function pointer.
+func (sdk *AppFunctionsSDK) PushToCoreData(deviceName string, readingName string) func(*appcontext.Context, ...interface{}) (bool, interface{}) {
+	coreData := transforms.CoreData{
+		DeviceName:  deviceName,
+		ReadingName: readingName,
+	}
+	return coreData.PushToCoreData
+}
+
+// NotifySupport sends a notification to Support Notifications for each event from the previous function, with
+// content built from the event using the configured template, so alerting pipelines don't need their own HTTP code.
+// The event is passed unchanged to the next function in the pipeline.
+// This function is a configuration function and returns a function pointer.
+func (sdk *AppFunctionsSDK) NotifySupport(config transforms.NotificationConfig) func(*appcontext.Context, ...interface{}) (bool, interface{}) {
+	notifier, err := transforms.NewNotifier(config)
+	if err != nil {
+		sdk.LoggingClient.Error("Failed to create notifier: " + err.Error())
+		return nil
+	}
+	return notifier.NotifySupport
+}
+
 // XMLTransform transforms an EdgeX event to XML.
 // It will return an error and stop the pipeline if a non-edgex
 // event is received or if no data is recieved.
@@ -94,6 +693,191 @@ func (sdk *AppFunctionsSDK) JSONTransform() func(*appcontext.Context, ...interfa
 	return transforms.TransformToJSON
 }
 
+// CSVTransform formats an EdgeX event as CSV rows with the columns of config, one row per reading when a column is
+// taken from a reading, emitting the header row as set by its header policy. Nil is returned if the config is invalid.
+// It will return an error and stop the pipeline if a non-edgex event is received or if no data is received.
+// This function returns a string, suitable for chaining to FileExport or S3Upload.
+// This function is a configuration function and returns a function pointer.
+func (sdk *AppFunctionsSDK) CSVTransform(config transforms.CSVConfig) func(*appcontext.Context, ...interface{}) (bool, interface{}) {
+	transform, err := sdk.csvTransform(config)
+	if err != nil {
+		sdk.LoggingClient.Error("Failed to create CSV transform: " + err.Error())
+	}
+	return transform
+}
+
+// csvTransform returns the CSVTransform function, or the error when it can't be created
+func (sdk *AppFunctionsSDK) csvTransform(config transforms.CSVConfig) (func(*appcontext.Context, ...interface{}) (bool, interface{}), error) {
+	formatter, err := transforms.NewCSVFormatter(config)
+	if err != nil {
+		return nil, err
+	}
+	return formatter.TransformToCSV, nil
+}
+
+// SenMLTransform converts an EdgeX event to a SenML (RFC 8428) pack with a record per reading, so SenML and LwM2M
+// backends can be fed directly. Nil is returned if the config is invalid.
+// It will return an error and stop the pipeline if a non-edgex event is received or if no data is received.
+// This function returns a string for the JSON format and a []byte for the CBOR format.
+// This function is a configuration function and returns a function pointer.
+func (sdk *AppFunctionsSDK) SenMLTransform(config transforms.SenMLConfig) func(*appcontext.Context, ...interface{}) (bool, interface{}) {
+	transform, err := sdk.senMLTransform(config)
+	if err != nil {
+		sdk.LoggingClient.Error("Failed to create SenML transform: " + err.Error())
+	}
+	return transform
+}
+
+// senMLTransform returns the SenMLTransform function, or the error when it can't be created
+func (sdk *AppFunctionsSDK) senMLTransform(config transforms.SenMLConfig) (func(*appcontext.Context, ...interface{}) (bool, interface{}), error) {
+	converter, err := transforms.NewSenMLConverter(config)
+	if err != nil {
+		return nil, err
+	}
+	return converter.TransformToSenML, nil
+}
+
+// WrapCloudEvent wraps the data from the previous function, an EdgeX event or the output of a previous function, in a
+// CloudEvents 1.0 envelope whose source, type and subject are mapped from the event as set by config, for Knative or
+// Azure Event Grid integrations.
+// It will return an error and stop the pipeline if no data is received.
+// This function returns the CloudEvent in the structured JSON format as a string.
+// This function is a configuration function and returns a function pointer.
+func (sdk *AppFunctionsSDK) WrapCloudEvent(config transforms.CloudEventConfig) func(*appcontext.Context, ...interface{}) (bool, interface{}) {
+	wrapper := transforms.NewCloudEventWrapper(config)
+	return wrapper.WrapCloudEvent
+}
+
+// TransformWithTemplate renders the data from the previous function, i.e. an EdgeX event, through a text/template for
+// custom output formats such as custom JSON shapes or CSV lines. Nil is returned if the template is invalid.
+// This function returns a string.
+// This function is a configuration function and returns a function pointer.
+func (sdk *AppFunctionsSDK) TransformWithTemplate(template string) func(*appcontext.Context, ...interface{}) (bool, interface{}) {
+	transform, err := sdk.transformWithTemplate(template)
+	if err != nil {
+		sdk.LoggingClient.Error("Failed to create template transform: " + err.Error())
+	}
+	return transform
+}
+
+// transformWithTemplate returns the TransformWithTemplate function, or the error when it can't be created
+func (sdk *AppFunctionsSDK) transformWithTemplate(template string) (func(*appcontext.Context, ...interface{}) (bool, interface{}), error) {
+	transform, err := transforms.NewTemplateTransform(template)
+	if err != nil {
+		return nil, err
+	}
+	return transform.TransformWithTemplate, nil
+}
+
+// TransformWithTemplateFile renders the data from the previous function through the text/template read from file, as
+// TransformWithTemplate does. Nil is returned if the file can't be read or the template is invalid.
+// This function is a configuration function and returns a function pointer.
+func (sdk *AppFunctionsSDK) TransformWithTemplateFile(file string) func(*appcontext.Context, ...interface{}) (bool, interface{}) {
+	transform, err := sdk.transformWithTemplateFile(file)
+	if err != nil {
+		sdk.LoggingClient.Error("Failed to create template transform: " + err.Error())
+	}
+	return transform
+}
+
+// transformWithTemplateFile returns the TransformWithTemplateFile function, or the error when it can't be created
+func (sdk *AppFunctionsSDK) transformWithTemplateFile(file string) (func(*appcontext.Context, ...interface{}) (bool, interface{}), error) {
+	transform, err := transforms.NewTemplateTransformFromFile(file)
+	if err != nil {
+		return nil, err
+	}
+	return transform.TransformWithTemplate, nil
+}
+
+// JavaScriptTransform executes the function named transform of the JavaScript script against the event from the
+// previous function, so payloads can be adjusted by editing configuration rather than recompiling the service.
+// The function returns the event passed to the next function, a string, or null to stop the pipeline.
+// Its execution is interrupted after timeout, unless it's zero. Nil is returned if the script is invalid.
+// This function will return an error and stop the pipeline if a non-edgex event is received or the script fails.
+// This function is a configuration function and returns a function pointer.
+func (sdk *AppFunctionsSDK) JavaScriptTransform(script string, timeout time.Duration) func(*appcontext.Context, ...interface{}) (bool, interface{}) {
+	transform, err := sdk.javaScriptTransform(script, timeout)
+	if err != nil {
+		sdk.LoggingClient.Error("Failed to create JavaScript transform: " + err.Error())
+	}
+	return transform
+}
+
+// javaScriptTransform returns the JavaScriptTransform function, or the error when it can't be created
+func (sdk *AppFunctionsSDK) javaScriptTransform(script string, timeout time.Duration) (func(*appcontext.Context, ...interface{}) (bool, interface{}), error) {
+	js, err := transforms.NewJavaScriptTransform(script, timeout)
+	if err != nil {
+		return nil, err
+	}
+	return js.TransformWithJavaScript, nil
+}
+
+// JavaScriptTransformFile executes the JavaScript script read from file, as JavaScriptTransform does.
+// Nil is returned if the file can't be read or the script is invalid.
+// This function is a configuration function and returns a function pointer.
+func (sdk *AppFunctionsSDK) JavaScriptTransformFile(file string, timeout time.Duration) func(*appcontext.Context, ...interface{}) (bool, interface{}) {
+	transform, err := sdk.javaScriptTransformFile(file, timeout)
+	if err != nil {
+		sdk.LoggingClient.Error("Failed to create JavaScript transform: " + err.Error())
+	}
+	return transform
+}
+
+// javaScriptTransformFile returns the JavaScriptTransformFile function, or the error when it can't be created
+func (sdk *AppFunctionsSDK) javaScriptTransformFile(file string, timeout time.Duration) (func(*appcontext.Context, ...interface{}) (bool, interface{}), error) {
+	js, err := transforms.NewJavaScriptTransformFromFile(file, timeout)
+	if err != nil {
+		return nil, err
+	}
+	return js.TransformWithJavaScript, nil
+}
+
+// WASMTransform calls the function exported by the WebAssembly module read from file with the data from the previous
+// function, an EdgeX event serialized as JSON, or a string or []byte passed as is, so third parties can ship sandboxed
+// custom logic. The module must export its memory and an alloc function, and the function returns the transformed
+// bytes, or zero to stop the pipeline. Nil is returned if the module can't be loaded.
+// This function returns a []byte.
+// This function is a configuration function and returns a function pointer.
+func (sdk *AppFunctionsSDK) WASMTransform(file string, function string) func(*appcontext.Context, ...interface{}) (bool, interface{}) {
+	transform, err := sdk.wasmTransform(file, function)
+	if err != nil {
+		sdk.LoggingClient.Error("Failed to create WebAssembly transform: " + err.Error())
+	}
+	return transform
+}
+
+// wasmTransform returns the WASMTransform function, or the error when it can't be created
+func (sdk *AppFunctionsSDK) wasmTransform(file string, function string) (func(*appcontext.Context, ...interface{}) (bool, interface{}), error) {
+	w, err := transforms.NewWASMTransformFromFile(file, function)
+	if err != nil {
+		return nil, err
+	}
+	return w.TransformWithWASM, nil
+}
+
+// ProcessTransform pipes the data from the previous function, an EdgeX event serialized as JSON, or a string or []byte
+// written as is, to the standard input of command run with args, i.e. to reuse existing Python or C analytics, and
+// passes its standard output to the next function as a []byte. The pipeline stops when the output is empty. The
+// process is killed after timeout, unless it's zero. Nil is returned if the command isn't found.
+// This function will return an error and stop the pipeline if the command fails or times out.
+// This function is a configuration function and returns a function pointer.
+func (sdk *AppFunctionsSDK) ProcessTransform(command string, args []string, timeout time.Duration) func(*appcontext.Context, ...interface{}) (bool, interface{}) {
+	transform, err := sdk.processTransform(command, args, timeout)
+	if err != nil {
+		sdk.LoggingClient.Error("Failed to create process transform: " + err.Error())
+	}
+	return transform
+}
+
+// processTransform returns the ProcessTransform function, or the error when it can't be created
+func (sdk *AppFunctionsSDK) processTransform(command string, args []string, timeout time.Duration) (func(*appcontext.Context, ...interface{}) (bool, interface{}), error) {
+	p, err := transforms.NewProcessTransform(command, args, timeout)
+	if err != nil {
+		return nil, err
+	}
+	return p.TransformWithProcess, nil
+}
+
 // HTTPPost will send data from the previous function to the specified Endpoint via http POST. If no previous function exists,
 // then the event that triggered the pipeline will be used. Passing an empty string to the mimetype
 // method will default to application/json.
@@ -103,7 +887,7 @@ func (sdk *AppFunctionsSDK) HTTPPost(url string, mimeType string) func(*appconte
 		URL:      url,
 		MimeType: mimeType,
 	}
-	return transforms.HTTPPost
+	return sdk.trackExport("HTTPPost", transforms.HTTPPost, nil)
 }
 
 // HTTPPostJSON sends data from the previous function to the specified Endpoint via http POST with a mime type of application/json.
@@ -134,6 +918,15 @@ func (sdk *AppFunctionsSDK) ZLIBTransform() func(*appcontext.Context, ...interfa
 	return transforms.ZLIBTransform
 }
 
+// SetResponseData returns the data from the previous function to the trigger, i.e. as the response of the HTTP trigger
+// or the message published by the message bus trigger, with the specified content type. An empty content type
+// defaults to application/json. The data is passed on to the next function.
+// This function is a configuration function and returns a function pointer.
+func (sdk *AppFunctionsSDK) SetResponseData(contentType string) func(*appcontext.Context, ...interface{}) (bool, interface{}) {
+	responseData := transforms.ResponseData{ContentType: contentType}
+	return responseData.SetResponseData
+}
+
 // MQTTSend sends data from the previous function to the specified MQTT broker.
 // If no previous function exists, then the event that triggered the pipeline will be used.
 // This function is a configuration function and returns a function pointer.
@@ -143,5 +936,227 @@ func (sdk *AppFunctionsSDK) MQTTSend(addr models.Addressable, cert string, key s
 	mqttconfig.SetRetain(retain)
 	mqttconfig.SetAutoreconnect(autoreconnect)
 	sender := transforms.NewMQTTSender(sdk.LoggingClient, addr, cert, key, mqttconfig)
-	return sender.MQTTSend
+	if sender == nil {
+		return nil
+	}
+	sdk.onShutdown("MQTTSend", sender.Close)
+	return sdk.trackExport("MQTTSend", sender.MQTTSend, nil)
+}
+
+// MQTTSendWithConfig sends data from the previous function to the specified MQTT broker like MQTTSend, using the
+// client options of the config, such as SetOrderMatters, SetMaxReconnectInterval and SetMessageChannelDepth to tune
+// high rate exports. The config can be built with transforms.NewMqttConfigWithOptions, which validates the options,
+// and nil is returned when the config is invalid. With SetPersistent, the connection to the broker is made right away
+// and its state is reported by the /api/v1/health endpoint.
+// This function is a configuration function and returns a function pointer.
+func (sdk *AppFunctionsSDK) MQTTSendWithConfig(addr models.Addressable, cert string, key string, config *transforms.MqttConfig) func(*appcontext.Context, ...interface{}) (bool, interface{}) {
+	sender := transforms.NewMQTTSender(sdk.LoggingClient, addr, cert, key, config)
+	if sender == nil {
+		return nil
+	}
+	sdk.onShutdown("MQTTSend", sender.Close)
+	sdk.trackMQTTConnection("MQTTSend", sender)
+	return sdk.trackExport("MQTTSend", sender.MQTTSend, nil)
+}
+
+// MQTTSendWithCredentials sends data from the previous function to the specified MQTT broker like MQTTSend, using
+// the username and password from the credentials provider each time the client connects or reconnects to the broker.
+// This allows credentials which expire, such as JWTs, to be refreshed.
+// This function is a configuration function and returns a function pointer.
+func (sdk *AppFunctionsSDK) MQTTSendWithCredentials(addr models.Addressable, cert string, key string, qos byte, retain bool, autoreconnect bool, credentials transforms.CredentialsProvider) func(*appcontext.Context, ...interface{}) (bool, interface{}) {
+	mqttconfig := transforms.NewMqttConfig()
+	mqttconfig.SetQos(qos)
+	mqttconfig.SetRetain(retain)
+	mqttconfig.SetAutoreconnect(autoreconnect)
+	mqttconfig.SetCredentialsProvider(credentials)
+	sender := transforms.NewMQTTSender(sdk.LoggingClient, addr, cert, key, mqttconfig)
+	if sender == nil {
+		return nil
+	}
+	sdk.onShutdown("MQTTSend", sender.Close)
+	return sdk.trackExport("MQTTSend", sender.MQTTSend, nil)
+}
+
+// gcpCredentialsSecret is the key of the secret holding the GCP service account JSON key
+const gcpCredentialsSecret = "credentials"
+
+// GCPPubSubSend publishes data from the previous function to the specified Google Cloud Pub/Sub topic. The contents of a
+// GCP service account JSON key, which also determines the project, are read from the credentials secret at secretPath
+// in the configured secret store. Setting batchSize greater than 1 publishes messages in batches of that size, with
+// incomplete batches published once batchTimeout has elapsed.
+// If no previous function exists, then the event that triggered the pipeline will be used.
+// This function is a configuration function and returns a function pointer.
+func (sdk *AppFunctionsSDK) GCPPubSubSend(secretPath string, topic string, batchSize int, batchTimeout time.Duration) func(*appcontext.Context, ...interface{}) (bool, interface{}) {
+	secrets, err := sdk.GetSecret(secretPath, gcpCredentialsSecret)
+	if err == nil && secrets[gcpCredentialsSecret] == "" {
+		err = fmt.Errorf("no %s secret at path %s", gcpCredentialsSecret, secretPath)
+	}
+	if err != nil {
+		sdk.LoggingClient.Error("Failed to create GCP Pub/Sub sender: unable to read credentials: " + err.Error())
+		return nil
+	}
+	sender, err := transforms.NewGCPPubSubSender([]byte(secrets[gcpCredentialsSecret]), topic, batchSize, batchTimeout)
+	if err != nil {
+		sdk.LoggingClient.Error("Failed to create GCP Pub/Sub sender: " + err.Error())
+		return nil
+	}
+	trackedExport, batchFailed := sdk.trackBatchExport("GCPPubSubSend", sender.PubSubSend, sender.PendingEvents)
+	sender.SetBatchFailureHandler(batchFailed)
+	sdk.onShutdown("GCPPubSubSend", sender.Flush)
+	return trackedExport
+}
+
+// FileExport appends data from the previous function to the local file at path, one line per event. The file is
+// rotated once it exceeds maxSize bytes or has been written to for longer than maxAge, with zero disabling the
+// respective rotation. Rotated files are renamed with a timestamp suffix and gzipped when compress is true.
+// If no previous function exists, then the event that triggered the pipeline will be used.
+// This function is a configuration function and returns a function pointer.
+func (sdk *AppFunctionsSDK) FileExport(path string, maxSize int64, maxAge time.Duration, compress bool) func(*appcontext.Context, ...interface{}) (bool, interface{}) {
+	writer := transforms.NewFileWriter(path, maxSize, maxAge, compress)
+	sdk.onShutdown("FileExport", writer.Close)
+	return sdk.trackExport("FileExport", writer.FileExport, nil)
+}
+
+// ArchiveExport keeps a copy of the data exported by the export function in rotating local files, for audits and to
+// replay exactly what was sent. Each line of the archive is a JSON record of the time, correlation ID, device and
+// base64 encoded payload of an export which succeeded. Setting config.OneIn archives a sample of the exports instead.
+// Each export function archived needs its own archive path.
+// This function is a configuration function and returns a function pointer.
+func (sdk *AppFunctionsSDK) ArchiveExport(config transforms.ArchiveConfig, export func(*appcontext.Context, ...interface{}) (bool, interface{})) func(*appcontext.Context, ...interface{}) (bool, interface{}) {
+	if export == nil {
+		sdk.LoggingClient.Error("Failed to create ArchiveExport: the function is nil")
+		return nil
+	}
+	archiver, err := transforms.NewArchiver(config)
+	if err != nil {
+		sdk.LoggingClient.Error("Failed to create archiver: " + err.Error())
+		return nil
+	}
+	return archiver.Archive(export)
+}
+
+// Keys of the secrets holding the S3 access key
+const (
+	s3AccessKeyIDSecret     = "accesskeyid"
+	s3SecretAccessKeySecret = "secretaccesskey"
+)
+
+// S3Upload uploads data from the previous function as objects to the configured S3 compatible object storage bucket.
+// The AccessKeyID and SecretAccessKey of config are read from the accesskeyid and secretaccesskey secrets at
+// secretPath in the configured secret store. Object keys are built from config.KeyTemplate, which may contain the
+// {device}, {year}, {month}, {day}, {hour}, {timestamp} and {correlation-id} placeholders. Payloads can optionally
+// be batched into a single object and gzipped.
+// If no previous function exists, then the event that triggered the pipeline will be used.
+// This function is a configuration function and returns a function pointer.
+func (sdk *AppFunctionsSDK) S3Upload(secretPath string, config transforms.S3Config) func(*appcontext.Context, ...interface{}) (bool, interface{}) {
+	secrets, err := sdk.GetSecret(secretPath, s3AccessKeyIDSecret, s3SecretAccessKeySecret)
+	if err == nil && (secrets[s3AccessKeyIDSecret] == "" || secrets[s3SecretAccessKeySecret] == "") {
+		err = fmt.Errorf("no %s and %s secrets at path %s", s3AccessKeyIDSecret, s3SecretAccessKeySecret, secretPath)
+	}
+	if err != nil {
+		sdk.LoggingClient.Error("Failed to create S3 sender: unable to read the access key: " + err.Error())
+		return nil
+	}
+	config.AccessKeyID = secrets[s3AccessKeyIDSecret]
+	config.SecretAccessKey = secrets[s3SecretAccessKeySecret]
+	sender, err := transforms.NewS3Sender(config)
+	if err != nil {
+		sdk.LoggingClient.Error("Failed to create S3 sender: " + err.Error())
+		return nil
+	}
+	trackedExport, batchFailed := sdk.trackBatchExport("S3Upload", sender.S3Upload, sender.PendingEvents)
+	sender.SetBatchFailureHandler(batchFailed)
+	sdk.onShutdown("S3Upload", sender.Flush)
+	return trackedExport
+}
+
+// ElasticsearchSend indexes the event from the previous function into Elasticsearch using the bulk API. Index names are
+// built from config.IndexTemplate, which may contain the {device}, {year}, {month} and {day} placeholders. Events can
+// optionally be batched into a single bulk request. Unless secretPath is empty, the username and password secrets are
+// read at secretPath in the configured secret store to authenticate.
+// This function is a configuration function and returns a function pointer.
+func (sdk *AppFunctionsSDK) ElasticsearchSend(secretPath string, config transforms.ElasticsearchConfig) func(*appcontext.Context, ...interface{}) (bool, interface{}) {
+	if secretPath != "" {
+		credentials, err := sdk.secretCredentials(secretPath, "", passwordSecret)
+		if err != nil {
+			sdk.LoggingClient.Error("Failed to create Elasticsearch sender: " + err.Error())
+			return nil
+		}
+		config.Credentials = credentials
+	}
+	sender, err := transforms.NewElasticsearchSender(config)
+	if err != nil {
+		sdk.LoggingClient.Error("Failed to create Elasticsearch sender: " + err.Error())
+		return nil
+	}
+	trackedExport, batchFailed := sdk.trackBatchExport("ElasticsearchSend", sender.ElasticsearchSend, sender.PendingEvents)
+	sender.SetBatchFailureHandler(batchFailed)
+	sdk.onShutdown("ElasticsearchSend", sender.Flush)
+	return trackedExport
+}
+
+// InfluxDBSend writes the data from the previous function to InfluxDB. Events are converted to a point per reading,
+// while string and []byte data is parsed as line protocol. Writes can optionally be batched and failed writes are
+// retried up to config.MaxRetries times. Unless secretPath is empty, the username and password secrets, or the token
+// secret when config.Bucket is set, are read at secretPath in the configured secret store to authenticate.
+// This function is a configuration function and returns a function pointer.
+func (sdk *AppFunctionsSDK) InfluxDBSend(secretPath string, config transforms.InfluxDBConfig) func(*appcontext.Context, ...interface{}) (bool, interface{}) {
+	if secretPath != "" {
+		passwordKey := passwordSecret
+		if config.Bucket != "" {
+			passwordKey = tokenSecret
+		}
+		credentials, err := sdk.secretCredentials(secretPath, "", passwordKey)
+		if err != nil {
+			sdk.LoggingClient.Error("Failed to create InfluxDB sender: " + err.Error())
+			return nil
+		}
+		config.Credentials = credentials
+	}
+	sender, err := transforms.NewInfluxDBSender(config)
+	if err != nil {
+		sdk.LoggingClient.Error("Failed to create InfluxDB sender: " + err.Error())
+		return nil
+	}
+	trackedExport, batchFailed := sdk.trackBatchExport("InfluxDBSend", sender.InfluxDBSend, sender.PendingEvents)
+	sender.SetBatchFailureHandler(batchFailed)
+	sdk.onShutdown("InfluxDBSend", sender.Flush)
+	return trackedExport
+}
+
+// RedisSend adds data from the previous function to the configured Redis Stream with XADD, or publishes it to the
+// configured Redis channel when no stream is set. Connections to Redis are pooled. Unless secretPath is empty, the
+// password secret is read at secretPath in the configured secret store to authenticate each connection.
+// If no previous function exists, then the event that triggered the pipeline will be used.
+// This function is a configuration function and returns a function pointer.
+func (sdk *AppFunctionsSDK) RedisSend(secretPath string, config transforms.RedisConfig) func(*appcontext.Context, ...interface{}) (bool, interface{}) {
+	if secretPath != "" {
+		credentials, err := sdk.secretCredentials(secretPath, "", passwordSecret)
+		if err != nil {
+			sdk.LoggingClient.Error("Failed to create Redis sender: " + err.Error())
+			return nil
+		}
+		config.Credentials = credentials
+	}
+	sender, err := transforms.NewRedisSender(config)
+	if err != nil {
+		sdk.LoggingClient.Error("Failed to create Redis sender: " + err.Error())
+		return nil
+	}
+	sdk.onShutdown("RedisSend", sender.Close)
+	return sdk.trackExport("RedisSend", sender.RedisSend, nil)
+}
+
+// AMQPSend publishes data from the previous function to the configured exchange of an AMQP 0-9-1 broker such as
+// RabbitMQ, optionally over TLS and with publisher confirms.
+// If no previous function exists, then the event that triggered the pipeline will be used.
+// This function is a configuration function and returns a function pointer.
+func (sdk *AppFunctionsSDK) AMQPSend(config transforms.AMQPConfig) func(*appcontext.Context, ...interface{}) (bool, interface{}) {
+	sender, err := transforms.NewAMQPSender(config)
+	if err != nil {
+		sdk.LoggingClient.Error("Failed to create AMQP sender: " + err.Error())
+		return nil
+	}
+	sdk.onShutdown("AMQPSend", sender.Close)
+	return sdk.trackExport("AMQPSend", sender.AMQPSend, nil)
 }
@@ -0,0 +1,59 @@
+//
+// Copyright (c) 2019 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package appsdk
+
+import (
+	"github.com/antoniomtz/app-functions-sdk-go/internal/trigger"
+	"github.com/antoniomtz/app-functions-sdk-go/pkg/transforms"
+)
+
+// exportConnection reports the health of the connection an export function keeps open to its destination
+type exportConnection struct {
+	name  string
+	state func() trigger.ConnectionStatus
+}
+
+// trackMQTTConnection reports the connection of a persistent MQTT sender to the broker from the /api/v1/health endpoint
+func (sdk *AppFunctionsSDK) trackMQTTConnection(name string, sender *transforms.MQTTSender) {
+	if !sender.Persistent() {
+		return
+	}
+	sdk.connections = append(sdk.connections, exportConnection{name: name, state: func() trigger.ConnectionStatus {
+		state := sender.ConnectionState()
+		return trigger.ConnectionStatus{
+			Healthy:       state.Connected,
+			Messages:      state.Published,
+			Errors:        state.Errors,
+			LastError:     state.LastError,
+			LastErrorTime: state.LastErrorTime,
+			Endpoint:      state.Broker,
+			Failovers:     state.Failovers,
+		}
+	}})
+}
+
+// exportConnectionStatus returns the health of the connections kept open by the export functions, named after the
+// export function
+func (sdk *AppFunctionsSDK) exportConnectionStatus() []trigger.ConnectionStatus {
+	statuses := make([]trigger.ConnectionStatus, 0, len(sdk.connections))
+	for _, connection := range sdk.connections {
+		status := connection.state()
+		status.Name = connection.name
+		statuses = append(statuses, status)
+	}
+	return statuses
+}
@@ -0,0 +1,197 @@
+//
+// Copyright (c) 2019 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package appsdk
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"time"
+
+	"github.com/antoniomtz/app-functions-sdk-go/appcontext"
+	"github.com/antoniomtz/app-functions-sdk-go/internal/alerts"
+	"github.com/antoniomtz/app-functions-sdk-go/internal/runtime"
+	"github.com/antoniomtz/app-functions-sdk-go/pkg/transforms"
+)
+
+// ShutdownReport summarizes what the service processed and what it still held when it stopped, so operators can
+// verify that nothing was lost across a planned restart
+type ShutdownReport struct {
+	ServiceKey string
+	Started    time.Time
+	Stopped    time.Time
+	// Reason the service stopped, i.e. the signal received
+	Reason string
+	// Pipelines holds the execution counts of each pipeline keyed by pipeline name
+	Pipelines map[string]runtime.PipelineMetrics
+	// Exports holds the outcome of the calls to each export function, along with the events it had not exported
+	Exports []runtime.ExportMetrics
+	// PendingEvents is the total number of events held by export functions, such as in incomplete batches, which
+	// were not exported
+	PendingEvents int
+}
+
+// trackExport records the outcome of each call to the export function for the shutdown report. pending returns the
+// number of events held by the export function, and may be nil.
+func (sdk *AppFunctionsSDK) trackExport(name string, export func(*appcontext.Context, ...interface{}) (bool, interface{}), pending func() int) func(*appcontext.Context, ...interface{}) (bool, interface{}) {
+	if sdk.exports == nil {
+		sdk.exports = &runtime.ExportTracker{}
+	}
+	return sdk.exports.Track(name, export, pending)
+}
+
+// trackBatchExport is trackExport for export functions which hold data in batches. It also returns the handler the
+// data of held events is given to when their batch fails to be exported, which stores it like the data of failed calls.
+func (sdk *AppFunctionsSDK) trackBatchExport(name string, export func(*appcontext.Context, ...interface{}) (bool, interface{}), pending func() int) (func(*appcontext.Context, ...interface{}) (bool, interface{}), transforms.BatchFailureHandler) {
+	if sdk.exports == nil {
+		sdk.exports = &runtime.ExportTracker{}
+	}
+	trackedExport, batchFailed := sdk.exports.TrackBatch(name, export, pending)
+	return trackedExport, batchFailed
+}
+
+// reportMetrics registers the metrics of the function created under the name, which are reported along with those of
+// the other functions of the kind by the /api/v1/metrics/<kind> endpoint. A function created again under the same
+// name replaces the previous one rather than being reported twice.
+func (sdk *AppFunctionsSDK) reportMetrics(kind string, name string, metrics func() interface{}) {
+	if sdk.metrics == nil {
+		sdk.metrics = map[string]map[string]func() interface{}{}
+	}
+	if sdk.metrics[kind] == nil {
+		sdk.metrics[kind] = map[string]func() interface{}{}
+	}
+	sdk.metrics[kind][name] = metrics
+}
+
+// metricsProviders returns the provider of the metrics of each kind of function, which lists the metrics of the
+// functions sorted by name
+func (sdk *AppFunctionsSDK) metricsProviders() map[string]func() interface{} {
+	providers := make(map[string]func() interface{}, len(sdk.metrics))
+	for kind, functions := range sdk.metrics {
+		names := make([]string, 0, len(functions))
+		for name := range functions {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		metrics := make([]func() interface{}, len(names))
+		for i, name := range names {
+			metrics[i] = functions[name]
+		}
+		providers[kind] = func() interface{} {
+			snapshot := make([]interface{}, len(metrics))
+			for i, functionMetrics := range metrics {
+				snapshot[i] = functionMetrics()
+			}
+			return snapshot
+		}
+	}
+	return providers
+}
+
+// configureExportManifest retains the configured number of exported events, so downstream systems can reconcile them
+// through the /api/v1/exports endpoints
+func (sdk *AppFunctionsSDK) configureExportManifest() {
+	if sdk.exports == nil || sdk.config.ExportManifest.Capacity <= 0 {
+		return
+	}
+	sdk.exports.Manifest = runtime.NewExportManifest(sdk.config.ExportManifest.Capacity)
+}
+
+// configureExportDeadLetters stores the data of the export functions which fail, once they give up retrying, in the
+// configured file, endpoint and MQTT topic
+func (sdk *AppFunctionsSDK) configureExportDeadLetters() {
+	config := sdk.config.ExportDeadLetters
+	if sdk.exports == nil {
+		return
+	}
+
+	if config.File != "" {
+		sdk.exports.DeadLetters = append(sdk.exports.DeadLetters, runtime.FileDeadLetter(config.File))
+	}
+	if config.URL != "" {
+		sdk.exports.DeadLetters = append(sdk.exports.DeadLetters, publishDeadLetter(alerts.WebhookPublisher(config.URL)))
+	}
+	if config.MQTTBroker != "" {
+		publisher := alerts.MQTTPublisher(config.MQTTBroker, config.MQTTTopic, sdk.ServiceKey+"-deadletters")
+		sdk.exports.DeadLetters = append(sdk.exports.DeadLetters, publishDeadLetter(publisher))
+	}
+}
+
+// publishDeadLetter adapts the publisher to deliver the dead letters
+func publishDeadLetter(publish alerts.Publisher) runtime.DeadLetter {
+	return func(message runtime.DeadLetterMessage) error {
+		return publish(message)
+	}
+}
+
+// shutdownReport creates the report of the runtime, which may be nil when replaced by a custom runtime
+func (sdk *AppFunctionsSDK) shutdownReport(runtime *runtime.GolangRuntime, started time.Time, reason string) ShutdownReport {
+	report := ShutdownReport{
+		ServiceKey: sdk.ServiceKey,
+		Started:    started,
+		Stopped:    time.Now(),
+		Reason:     reason,
+		Exports:    sdk.exports.Metrics(),
+	}
+	if runtime != nil {
+		report.Pipelines = runtime.PipelineMetrics()
+	}
+	for _, export := range report.Exports {
+		report.PendingEvents += export.PendingEvents
+	}
+	return report
+}
+
+// logShutdownReport logs a summary of the report, and writes the report to the configured Service.ShutdownReportFile
+func (sdk *AppFunctionsSDK) logShutdownReport(report ShutdownReport) {
+	names := make([]string, 0, len(report.Pipelines))
+	for name := range report.Pipelines {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		metrics := report.Pipelines[name]
+		sdk.LoggingClient.Info(fmt.Sprintf("Shutdown report: pipeline %s received %d events, %d completed, %d stopped, %d failed",
+			name, metrics.EventsReceived, metrics.EventsCompleted, metrics.EventsStopped, metrics.EventsFailed))
+	}
+	for _, export := range report.Exports {
+		message := fmt.Sprintf("Shutdown report: export %s succeeded %d times, held data %d times, failed %d times, %d events pending",
+			export.Name, export.Succeeded, export.Held, export.Failed, export.PendingEvents)
+		if export.LastError != "" {
+			message += fmt.Sprintf(", last error at %s: %s", export.LastErrorTime.Format(time.RFC3339), export.LastError)
+		}
+		sdk.LoggingClient.Info(message)
+	}
+	if report.PendingEvents > 0 {
+		sdk.LoggingClient.Warn(fmt.Sprintf("Shutdown report: %d events held by export functions were not exported", report.PendingEvents))
+	}
+
+	fileName := sdk.config.Service.ShutdownReportFile
+	if fileName == "" {
+		return
+	}
+	contents, err := json.MarshalIndent(report, "", "  ")
+	if err == nil {
+		err = ioutil.WriteFile(fileName, contents, 0644)
+	}
+	if err != nil {
+		sdk.LoggingClient.Error("Failed to write shutdown report: " + err.Error())
+		return
+	}
+	sdk.LoggingClient.Info("Shutdown report written to " + fileName)
+}
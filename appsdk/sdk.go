@@ -17,92 +17,249 @@
 package appsdk
 
 import (
+	syscontext "context"
+	"errors"
 	"flag"
 	"fmt"
 	"os"
 	"os/signal"
+	"reflect"
 	"strings"
+	"sync"
 	"syscall"
 	"time"
 
 	"github.com/antoniomtz/app-functions-sdk-go/appcontext"
 	"github.com/antoniomtz/app-functions-sdk-go/internal"
 	"github.com/antoniomtz/app-functions-sdk-go/internal/common"
+	"github.com/antoniomtz/app-functions-sdk-go/internal/logging"
 	"github.com/antoniomtz/app-functions-sdk-go/internal/runtime"
 	"github.com/antoniomtz/app-functions-sdk-go/internal/telemetry"
+	"github.com/antoniomtz/app-functions-sdk-go/internal/tracing"
 	"github.com/antoniomtz/app-functions-sdk-go/internal/trigger"
 	"github.com/antoniomtz/app-functions-sdk-go/internal/trigger/http"
 	"github.com/antoniomtz/app-functions-sdk-go/internal/trigger/messagebus"
+	"github.com/antoniomtz/app-functions-sdk-go/internal/trigger/stdio"
 	"github.com/antoniomtz/app-functions-sdk-go/internal/webserver"
+	"github.com/antoniomtz/app-functions-sdk-go/pkg/certs"
+	"github.com/antoniomtz/app-functions-sdk-go/pkg/di"
+	"github.com/antoniomtz/app-functions-sdk-go/pkg/secrets"
 	"github.com/antoniomtz/app-functions-sdk-go/pkg/startup"
+	messageTypes "github.com/antoniomtz/go-mod-messaging/pkg/types"
 	"github.com/edgexfoundry/go-mod-core-contracts/clients"
+	"github.com/edgexfoundry/go-mod-core-contracts/clients/command"
 	"github.com/edgexfoundry/go-mod-core-contracts/clients/coredata"
 	"github.com/edgexfoundry/go-mod-core-contracts/clients/logger"
+	"github.com/edgexfoundry/go-mod-core-contracts/clients/metadata"
+	"github.com/edgexfoundry/go-mod-core-contracts/clients/notifications"
 	coreTypes "github.com/edgexfoundry/go-mod-core-contracts/clients/types"
 	registryTypes "github.com/edgexfoundry/go-mod-registry/pkg/types"
 	"github.com/edgexfoundry/go-mod-registry/registry"
 )
 
+const (
+	profilePlaceholder  = "<profile>"
+	instancePlaceholder = "<instance>"
+	instanceEnv         = "EDGEX_SERVICE_INSTANCE"
+)
+
 // AppFunctionsSDK provides the necessary struct to create an instance of the Application Functions SDK. Be sure and provide a ServiceKey
 // when creating an instance of the SDK. After creating an instance, you'll first want to call .Initialize(), to start up the SDK. Secondly,
 // provide the desired transforms for your pipeline by calling .SetFunctionsPipeline(). Lastly, call .MakeItRun() to start listening for events based on
 // your configured trigger.
 type AppFunctionsSDK struct {
 	transforms     []func(edgexcontext *appcontext.Context, params ...interface{}) (bool, interface{})
+	candidate      *runtime.CandidatePipeline
+	topicPipelines []*runtime.TopicPipeline
+	deviceEvents   []func(edgexcontext *appcontext.Context, params ...interface{}) (bool, interface{})
+	exports        *runtime.ExportTracker
+	caches         []*runtime.TransformCache
+	limits         []*runtime.ConcurrencyLimit
+	// metrics are the metrics of the functions by the name of their kind, and then by the name of each function
+	metrics        map[string]map[string]func() interface{}
+	errorHandler   func(edgexcontext *appcontext.Context, functionName string, err error, payload []byte)
+	connections    []exportConnection
+	background     []chan messageTypes.MessageEnvelope
+	backgroundStop chan struct{}
 	ServiceKey     string
+	// TargetType is a pointer to the type the received payload is decoded into for the first function of the
+	// pipeline, instead of an EdgeX Event, i.e. &[]byte{} for the raw payload or &MyStruct{} for custom JSON data
+	TargetType     interface{}
 	configProfile  string
 	configDir      string
 	useRegistry    bool
+	overwrite      bool
+	instance       string
 	httpErrors     chan error
 	webserver      *webserver.WebServer
 	registryClient registry.Client
 	eventClient    coredata.EventClient
 	config         common.ConfigurationStruct
+	writable       *common.WritableStore
+	dic            *di.Container
+	stdout         *os.File
+	shutdownHooks  []shutdownHook
+	stopHooks      []shutdownHook
+	stopMutex      sync.Mutex
+	stop           chan struct{}
+	ctx            syscontext.Context
+	cancel         syscontext.CancelFunc
 	LoggingClient  logger.LoggingClient
 }
 
+// RegisterServices registers constructors for the named services, substituting the SDK's own implementations of the
+// services listed in package di, such as a mock EventClient or a custom Trigger. Services are created the first time
+// they are needed, so this can be called before or after Initialize, but must be called before MakeItRun.
+func (sdk *AppFunctionsSDK) RegisterServices(constructors di.ServiceConstructorMap) {
+	sdk.container().Update(constructors)
+}
+
+// container returns the dependency container, creating it if needed
+func (sdk *AppFunctionsSDK) container() *di.Container {
+	if sdk.dic == nil {
+		sdk.dic = di.NewContainer(nil)
+	}
+	return sdk.dic
+}
+
 // MakeItRun will initialize and start the trigger as specifed in the
 // configuration. It will also configure the webserver and start listening on
 // the specified port.
 func (sdk *AppFunctionsSDK) MakeItRun() error {
-	httpErrors := make(chan error)
-	defer close(httpErrors)
+	started := time.Now()
+	if sdk.TargetType != nil && reflect.ValueOf(sdk.TargetType).Kind() != reflect.Ptr {
+		return errors.New("TargetType must be a pointer, i.e. &[]byte{} or &MyStruct{}")
+	}
 
-	runtime := runtime.GolangRuntime{Transforms: sdk.transforms}
+	sdk.httpErrors = make(chan error, 1)
+	// the context passed to the pipeline functions is cancelled if events are still being processed at shutdown
+	sdk.ctx, sdk.cancel = syscontext.WithCancel(syscontext.Background())
+	defer sdk.cancel()
 
-	sdk.webserver = &webserver.WebServer{
-		Config:        &sdk.config,
-		LoggingClient: sdk.LoggingClient,
+	tracer := sdk.newTracer()
+	defer tracer.Close()
+	if sdk.exports != nil {
+		sdk.exports.Tracer = tracer
+	}
+	sdk.configureExportWebhooks()
+	sdk.configureExportManifest()
+	sdk.configureExportDeadLetters()
+
+	container := sdk.container()
+	container.SetDefaults(di.ServiceConstructorMap{
+		di.ConfigurationName: func(get di.Get) interface{} {
+			return &sdk.config
+		},
+		di.LoggingClientName: func(get di.Get) interface{} {
+			return sdk.LoggingClient
+		},
+		di.RuntimeName: func(get di.Get) interface{} {
+			var errorLog *runtime.ErrorLog
+			if sdk.config.ErrorLog.Capacity > 0 {
+				errorLog = runtime.NewErrorLog(sdk.config.ErrorLog.Capacity, sdk.config.ErrorLog.MaxPayloadSize, sdk.config.ErrorLog.RedactFields)
+			}
+			var poisonMessages *runtime.PoisonTracker
+			if sdk.config.PoisonMessages.MaxFailures > 0 {
+				poisonMessages = runtime.NewPoisonTracker(sdk.config.PoisonMessages.MaxFailures)
+				if sdk.config.PoisonMessages.DeadLetterFile != "" {
+					poisonMessages.DeadLetters = append(poisonMessages.DeadLetters, runtime.FileDeadLetter(sdk.config.PoisonMessages.DeadLetterFile))
+				}
+			}
+			return &runtime.GolangRuntime{Transforms: sdk.transforms, Candidate: sdk.candidate, TopicPipelines: sdk.topicPipelines, DeviceEvents: sdk.deviceEvents, Caches: sdk.caches, Limits: sdk.limits, MetricsProviders: sdk.metricsProviders(), TargetType: sdk.TargetType, ErrorLog: errorLog, ErrorHandler: sdk.errorHandler, PoisonMessages: poisonMessages, Writable: sdk.writable, Tracer: tracer}
+		},
+		di.WebServerName: func(get di.Get) interface{} {
+			webserver := &webserver.WebServer{
+				Config:        get(di.ConfigurationName).(*common.ConfigurationStruct),
+				LoggingClient: get(di.LoggingClientName).(logger.LoggingClient),
+			}
+			webserver.Runtime, _ = get(di.RuntimeName).(*runtime.GolangRuntime)
+			if sdk.exports != nil {
+				webserver.Manifest = sdk.exports.Manifest
+			}
+			if len(sdk.connections) > 0 {
+				webserver.ExportConnections = sdk.exportConnectionStatus
+			}
+			webserver.ConfigureStandardRoutes()
+			return webserver
+		},
+		// determine input type and create trigger for it
+		di.TriggerName: func(get di.Get) interface{} {
+			return sdk.setupTrigger(*get(di.ConfigurationName).(*common.ConfigurationStruct), get)
+		},
+	})
+
+	loggingClient, err := container.Resolve(di.LoggingClientName)
+	if err != nil {
+		return err
+	}
+	sdk.LoggingClient = loggingClient.(logger.LoggingClient)
+	webServer, err := container.Resolve(di.WebServerName)
+	if err != nil {
+		return err
+	}
+	sdk.webserver = webServer.(*webserver.WebServer)
+	sdk.startStoreForward(container.Get)
+	resolvedTrigger, err := container.Resolve(di.TriggerName)
+	if err != nil {
+		return err
+	}
+	appTrigger, ok := resolvedTrigger.(trigger.Trigger)
+	if !ok {
+		return fmt.Errorf("no trigger available for binding type '%s'", sdk.config.Binding.Type)
 	}
-	sdk.webserver.ConfigureStandardRoutes()
-
-	// determine input type and create trigger for it
-	trigger := sdk.setupTrigger(sdk.config, runtime)
 
 	// Initialize the trigger (i.e. start a web server, or connect to message bus)
-	err := trigger.Initialize(sdk.LoggingClient)
+	err = appTrigger.Initialize(sdk.LoggingClient)
 	if err != nil {
 		sdk.LoggingClient.Error(err.Error())
 	}
 
 	sdk.LoggingClient.Info(sdk.config.Service.StartupMsg)
 
-	signals := make(chan os.Signal)
+	pipelineRuntime, _ := container.Get(di.RuntimeName).(*runtime.GolangRuntime)
+	notificationsClient, _ := container.Get(di.NotificationsClientName).(notifications.NotificationsClient)
+	sdk.startAlertMonitor(pipelineRuntime, notificationsClient)
+	deviceWatcher := sdk.startDeviceEvents(pipelineRuntime, container.Get)
+
+	signals := make(chan os.Signal, 1)
 	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(signals)
 
+	if reporter, ok := appTrigger.(trigger.StatusReporter); ok {
+		sdk.webserver.Connections = reporter.Connections
+	}
 	sdk.webserver.StartHTTPServer(sdk.httpErrors)
+	running := make(chan struct{})
+	defer close(running)
+	sdk.notifyReady(running)
 
+	var done <-chan struct{}
+	if finisher, ok := appTrigger.(trigger.Finisher); ok {
+		done = finisher.Done()
+	}
+
+	var reason string
 	select {
 	case httpError := <-sdk.httpErrors:
 		sdk.LoggingClient.Info("Terminating: ", httpError.Error())
-		return httpError
+		err, reason = httpError, httpError.Error()
 
 	case signalReceived := <-signals:
 		sdk.LoggingClient.Info("Terminating: " + signalReceived.String())
+		err, reason = nil, signalReceived.String()
+
+	case <-done:
+		sdk.LoggingClient.Info("Terminating: trigger input ended")
+		err, reason = nil, "trigger input ended"
 
+	case <-sdk.stopChannel():
+		sdk.LoggingClient.Info("Terminating: stopped by MakeItStop")
+		err, reason = nil, "stopped by MakeItStop"
 	}
 
-	return nil
+	sdk.shutdown(appTrigger, pipelineRuntime, deviceWatcher)
+	sdk.logShutdownReport(sdk.shutdownReport(pipelineRuntime, started, reason))
+	return err
 }
 
 // ApplicationSettings returns the values specifed in the custom configuration section.
@@ -111,55 +268,107 @@ func (sdk *AppFunctionsSDK) ApplicationSettings() map[string]string {
 }
 
 // setupTrigger configures the appropriate trigger as specified by configuration.
-func (sdk *AppFunctionsSDK) setupTrigger(configuration common.ConfigurationStruct, runtime runtime.GolangRuntime) trigger.Trigger {
+// The runtime, webserver and event client used by the trigger are retrieved with get.
+func (sdk *AppFunctionsSDK) setupTrigger(configuration common.ConfigurationStruct, get di.Get) trigger.Trigger {
 	var trigger trigger.Trigger
+	runtime, _ := get(di.RuntimeName).(*runtime.GolangRuntime)
+	webserver, _ := get(di.WebServerName).(*webserver.WebServer)
+	eventClient, _ := get(di.EventClientName).(coredata.EventClient)
+	commandClient, _ := get(di.CommandClientName).(command.CommandClient)
+	notificationsClient, _ := get(di.NotificationsClientName).(notifications.NotificationsClient)
+	secretProvider, _ := get(di.SecretProviderName).(secrets.SecretProvider)
 	// Need to make dynamic, search for the binding that is input
 
 	switch strings.ToUpper(configuration.Binding.Type) {
 	case "HTTP":
 		sdk.LoggingClient.Info("HTTP trigger selected")
-		trigger = &http.Trigger{Configuration: configuration, Runtime: runtime, Webserver: sdk.webserver, EventClient: sdk.eventClient}
+		trigger = &http.Trigger{Configuration: configuration, Runtime: runtime, Webserver: webserver, Context: sdk.ctx, EventClient: eventClient, CommandClient: commandClient, NotificationsClient: notificationsClient, SecretProvider: secretProvider}
 	case "MESSAGEBUS":
 		sdk.LoggingClient.Info("MessageBus trigger selected")
-		trigger = &messagebus.Trigger{Configuration: configuration, Runtime: runtime, EventClient: sdk.eventClient}
+		configuration.Binding.SubscribeTopics = sdk.subscribeTopics(configuration.Binding)
+		var deviceClient metadata.DeviceClient
+		if strings.Contains(configuration.Binding.PublishTopic, messagebus.ProfilePlaceholder) {
+			deviceClient, _ = get(di.DeviceClientName).(metadata.DeviceClient)
+		}
+		trigger = &messagebus.Trigger{Configuration: configuration, Runtime: runtime, Context: sdk.ctx, EventClient: eventClient, CommandClient: commandClient, NotificationsClient: notificationsClient, SecretProvider: secretProvider, DeviceClient: deviceClient, Background: sdk.backgroundMessages()}
+	case "STDIO":
+		sdk.LoggingClient.Info("stdio trigger selected")
+		stdioTrigger := &stdio.Trigger{Configuration: configuration, Runtime: runtime, Context: sdk.ctx, EventClient: eventClient, CommandClient: commandClient, NotificationsClient: notificationsClient, SecretProvider: secretProvider}
+		if sdk.stdout != nil {
+			stdioTrigger.Output = sdk.stdout
+		}
+		trigger = stdioTrigger
 	}
 
 	return trigger
 }
 
-// Initialize will parse command line flags, register for interrupts,
-// initalize the logging system, and ingest configuration.
-func (sdk *AppFunctionsSDK) Initialize() error {
+// subscribeTopics returns the configured subscribe topics along with the topics of the pipelines added with
+// AddFunctionsPipelineForTopics. The configured topics are left out when only topic pipelines have been added.
+func (sdk *AppFunctionsSDK) subscribeTopics(binding common.BindingInfo) []string {
+	if len(sdk.topicPipelines) == 0 {
+		return binding.SubscribeTopics
+	}
 
-	flag.BoolVar(&sdk.useRegistry, "registry", false, "Indicates the service should use the registry.")
-	flag.BoolVar(&sdk.useRegistry, "r", false, "Indicates the service should use registry.")
+	var topics []string
+	if len(sdk.transforms) > 0 || sdk.candidate != nil {
+		topics = binding.SubscribeTopics
+		if len(topics) == 0 {
+			topics = []string{binding.SubscribeTopic}
+		}
+	}
 
-	flag.StringVar(&sdk.configProfile, "profile", "", "Specify a profile other than default.")
-	flag.StringVar(&sdk.configProfile, "p", "", "Specify a profile other than default.")
+	for _, pipeline := range sdk.topicPipelines {
+		for _, topic := range pipeline.Topics {
+			if !containsString(topics, topic) {
+				topics = append(topics, topic)
+			}
+		}
+	}
+	return topics
+}
 
-	flag.StringVar(&sdk.configDir, "confdir", "", "Specify an alternate configuration directory.")
-	flag.StringVar(&sdk.configDir, "c", "", "Specify an alternate configuration directory.")
+func containsString(values []string, value string) bool {
+	for _, item := range values {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
 
+// Initialize will parse command line flags, register for interrupts,
+// initalize the logging system, and ingest configuration.
+func (sdk *AppFunctionsSDK) Initialize() error {
+	// app services can define their own flags before calling Initialize, since the SDK's flags are added to
+	// the command line flags
+	sdk.defineFlags(flag.CommandLine)
 	flag.Parse()
+	sdk.ServiceKey = sdk.resolveServiceKey()
 
-	now := time.Now()
-	until := now.Add(time.Millisecond * time.Duration(internal.BootTimeoutDefault))
-	for now.Before(until) {
-		err := sdk.initializeConfiguration()
-		if err != nil {
-			fmt.Printf("failed to initialize Registry: %v\n", err)
-		} else {
-			//initialize logger
-			sdk.LoggingClient = logger.NewClient("AppFunctionsSDK", false, "./test.txt", sdk.config.Writable.LogLevel)
-			sdk.LoggingClient.Info("Configuration and logger successfully initialized")
-			break
-		}
-
-		time.Sleep(time.Second * time.Duration(1))
+	err := sdk.initializeConfiguration()
+	if err != nil {
+		return fmt.Errorf("failed to initialize configuration: %v", err)
 	}
 
+	if strings.EqualFold(sdk.config.Binding.Type, "stdio") && sdk.stdout == nil {
+		// keep log messages out of the pipeline output
+		sdk.stdout = stdio.ReserveStdout()
+	}
+	//initialize logger
+	sdk.container().SetDefaults(di.ServiceConstructorMap{
+		di.LoggingClientName: func(get di.Get) interface{} {
+			return sdk.newLoggingClient()
+		},
+	})
+	sdk.LoggingClient = sdk.container().Get(di.LoggingClientName).(logger.LoggingClient)
+	sdk.LoggingClient.Info("Configuration and logger successfully initialized")
+
+	sdk.writable = common.NewWritableStore(sdk.config.Writable)
 	if sdk.useRegistry {
 		go sdk.listenForConfigChanges()
+	} else {
+		go sdk.watchConfigurationFile(time.Millisecond * time.Duration(internal.FileWatchInterval))
 	}
 	//Setup eventClient
 	params := coreTypes.EndpointParams{
@@ -169,15 +378,204 @@ func (sdk *AppFunctionsSDK) Initialize() error {
 		Url:         sdk.config.Clients["CoreData"].Url() + clients.ApiEventRoute,
 		Interval:    sdk.config.Service.ClientMonitor,
 	}
-	sdk.eventClient = coredata.NewEventClient(params, startup.Endpoint{RegistryClient: &sdk.registryClient})
+	sdk.container().SetDefaults(di.ServiceConstructorMap{
+		di.EventClientName: func(get di.Get) interface{} {
+			return coredata.NewEventClient(params, startup.Endpoint{RegistryClient: &sdk.registryClient})
+		},
+	})
+	sdk.eventClient, _ = sdk.container().Get(di.EventClientName).(coredata.EventClient)
+
+	//Setup commandClient, which is optional
+	if commandInfo, ok := sdk.config.Clients["Command"]; ok {
+		commandParams := coreTypes.EndpointParams{
+			ServiceKey:  clients.CoreCommandServiceKey,
+			Path:        clients.ApiDeviceRoute,
+			UseRegistry: sdk.useRegistry,
+			Url:         commandInfo.Url() + clients.ApiDeviceRoute,
+			Interval:    sdk.config.Service.ClientMonitor,
+		}
+		sdk.container().SetDefaults(di.ServiceConstructorMap{
+			di.CommandClientName: func(get di.Get) interface{} {
+				return command.NewCommandClient(commandParams, startup.Endpoint{RegistryClient: &sdk.registryClient})
+			},
+		})
+	}
+
+	//Setup deviceClient for the device events pipeline and the profile of the publish topic, which is optional
+	if metadataInfo, ok := sdk.config.Clients["Metadata"]; ok {
+		deviceParams := coreTypes.EndpointParams{
+			ServiceKey:  clients.CoreMetaDataServiceKey,
+			Path:        clients.ApiDeviceRoute,
+			UseRegistry: sdk.useRegistry,
+			Url:         metadataInfo.Url() + clients.ApiDeviceRoute,
+			Interval:    sdk.config.Service.ClientMonitor,
+		}
+		sdk.container().SetDefaults(di.ServiceConstructorMap{
+			di.DeviceClientName: func(get di.Get) interface{} {
+				return metadata.NewDeviceClient(deviceParams, startup.Endpoint{RegistryClient: &sdk.registryClient})
+			},
+		})
+	}
+
+	//Setup notificationsClient, which is optional
+	if notificationsInfo, ok := sdk.config.Clients["Notifications"]; ok {
+		notificationsParams := coreTypes.EndpointParams{
+			ServiceKey:  clients.SupportNotificationsServiceKey,
+			Path:        clients.ApiNotificationRoute,
+			UseRegistry: sdk.useRegistry,
+			Url:         notificationsInfo.Url() + clients.ApiNotificationRoute,
+			Interval:    sdk.config.Service.ClientMonitor,
+		}
+		sdk.container().SetDefaults(di.ServiceConstructorMap{
+			di.NotificationsClientName: func(get di.Get) interface{} {
+				return notifications.NewNotificationsClient(notificationsParams, startup.Endpoint{RegistryClient: &sdk.registryClient})
+			},
+		})
+	}
+
+	sdk.container().SetDefaults(di.ServiceConstructorMap{
+		di.SecretProviderName: func(get di.Get) interface{} {
+			return sdk.newSecretProvider()
+		},
+	})
 
 	go telemetry.StartCpuUsageAverage()
 
 	return nil
 }
 
-func (sdk *AppFunctionsSDK) initializeConfiguration() error {
+// newSecretProvider creates the provider for the configured secret store, or returns nil if none is configured
+func (sdk *AppFunctionsSDK) newSecretProvider() interface{} {
+	config := sdk.config.SecretStore
+	switch strings.ToLower(config.Type) {
+	case "":
+		return nil
+	case "file":
+		return secrets.NewFileProvider(config.File)
+	case "vault":
+		url := fmt.Sprintf("%s://%s:%d", config.Protocol, config.Host, config.Port)
+		provider, err := secrets.NewVaultProvider(url, config.Path, config.TokenFile)
+		if err != nil {
+			sdk.LoggingClient.Error("Failed to create Vault secret provider: " + err.Error())
+			return nil
+		}
+		return provider
+	default:
+		sdk.LoggingClient.Error(fmt.Sprintf("Unsupported SecretStore type '%s'", config.Type))
+		return nil
+	}
+}
+
+// GetSecret returns the secrets stored at the path in the configured secret store, or only those for the
+// specified keys, so API keys and passwords don't need to be held in plain text in the configuration.
+// Secret providers other than Vault or a file can be plugged in by registering them with RegisterServices
+// under di.SecretProviderName.
+func (sdk *AppFunctionsSDK) GetSecret(path string, keys ...string) (map[string]string, error) {
+	provider, ok := sdk.container().Get(di.SecretProviderName).(secrets.SecretProvider)
+	if !ok {
+		return nil, errors.New("no secret store configured")
+	}
+	return provider.GetSecrets(path, keys...)
+}
 
+// CertificateWatcher returns a watcher for the PEM encoded certificate and key stored under the certKey and keyKey
+// secrets at the path in the configured secret store. The secrets are checked for changes every interval, so
+// short-lived certificates issued by an edge PKI are picked up without a restart when the watcher's TLS configs are
+// used by clients or servers. Use certs.NewWatcher with certs.FileSource for certificates held in files.
+func (sdk *AppFunctionsSDK) CertificateWatcher(path string, certKey string, keyKey string, interval time.Duration) (*certs.Watcher, error) {
+	provider, ok := sdk.container().Get(di.SecretProviderName).(secrets.SecretProvider)
+	if !ok {
+		return nil, errors.New("no secret store configured")
+	}
+	return certs.NewWatcher(certs.SecretSource(provider, path, certKey, keyKey), interval, sdk.LoggingClient)
+}
+
+// defineFlags defines the command line flags common to all app services in the flag set
+func (sdk *AppFunctionsSDK) defineFlags(flags *flag.FlagSet) {
+	flags.BoolVar(&sdk.useRegistry, "registry", false, "Indicates the service should use the registry.")
+	flags.BoolVar(&sdk.useRegistry, "r", false, "Indicates the service should use registry.")
+
+	flags.StringVar(&sdk.configProfile, "profile", "", "Specify a profile other than default.")
+	flags.StringVar(&sdk.configProfile, "p", "", "Specify a profile other than default.")
+
+	flags.StringVar(&sdk.configDir, "confdir", "", "Specify an alternate configuration directory.")
+	flags.StringVar(&sdk.configDir, "c", "", "Specify an alternate configuration directory.")
+
+	flags.BoolVar(&sdk.overwrite, "overwrite", false, "Overwrite the configuration in the registry with the local configuration.")
+	flags.BoolVar(&sdk.overwrite, "o", false, "Overwrite the configuration in the registry with the local configuration.")
+
+	flags.StringVar(&sdk.instance, "instance", "", "Specify the instance name added to the service key.")
+	flags.StringVar(&sdk.instance, "i", "", "Specify the instance name added to the service key.")
+}
+
+// resolveServiceKey returns the ServiceKey with the <profile> and <instance> placeholders replaced, so that the
+// same app service can run as several distinct services in the registry. The instance is set with the -i flag or
+// the EDGEX_SERVICE_INSTANCE environment variable, and is appended to a ServiceKey without the placeholder, i.e.
+// "app-export-<profile>" becomes "app-export-docker" with the "docker" profile. Empty values are removed along
+// with the dash preceding their placeholder.
+func (sdk *AppFunctionsSDK) resolveServiceKey() string {
+	instance := sdk.instance
+	if instance == "" {
+		instance = os.Getenv(instanceEnv)
+	}
+
+	serviceKey := sdk.ServiceKey
+	if instance != "" && !strings.Contains(serviceKey, instancePlaceholder) {
+		serviceKey += "-" + instancePlaceholder
+	}
+
+	return replacePlaceholder(replacePlaceholder(serviceKey, profilePlaceholder, sdk.configProfile), instancePlaceholder, instance)
+}
+
+func replacePlaceholder(serviceKey string, placeholder string, value string) string {
+	if value == "" {
+		serviceKey = strings.Replace(serviceKey, "-"+placeholder, "", -1)
+	}
+	return strings.Replace(serviceKey, placeholder, value, -1)
+}
+
+// newLoggingClient creates the logging client, wrapped with flood control when configured
+func (sdk *AppFunctionsSDK) newLoggingClient() logger.LoggingClient {
+	loggingClient := logger.NewClient("AppFunctionsSDK", false, "./test.txt", sdk.config.Writable.LogLevel)
+	if sdk.config.Logging.FloodControlInterval == "" {
+		return loggingClient
+	}
+
+	interval, err := time.ParseDuration(sdk.config.Logging.FloodControlInterval)
+	if err != nil || interval <= 0 {
+		loggingClient.Error(fmt.Sprintf("Invalid Logging.FloodControlInterval '%s', flood control disabled", sdk.config.Logging.FloodControlInterval))
+		return loggingClient
+	}
+	return logging.NewFloodControlClient(loggingClient, interval)
+}
+
+// newTracer creates the tracer exporting spans to the configured OpenTelemetry collector, or nil when tracing is
+// not configured
+func (sdk *AppFunctionsSDK) newTracer() *tracing.Tracer {
+	config := sdk.config.Tracing
+	if config.Endpoint == "" {
+		return nil
+	}
+
+	serviceName := config.ServiceName
+	if serviceName == "" {
+		serviceName = sdk.ServiceKey
+	}
+	var interval time.Duration
+	if config.FlushInterval != "" {
+		parsed, err := time.ParseDuration(config.FlushInterval)
+		if err != nil || parsed <= 0 {
+			sdk.LoggingClient.Error(fmt.Sprintf("Invalid Tracing.FlushInterval '%s', using %s", config.FlushInterval, tracing.DefaultFlushInterval))
+		} else {
+			interval = parsed
+		}
+	}
+
+	sdk.LoggingClient.Info("Exporting trace spans to " + config.Endpoint)
+	return tracing.NewTracer(config.Endpoint, serviceName, config.BatchSize, interval, sdk.LoggingClient)
+}
+
+func (sdk *AppFunctionsSDK) initializeConfiguration() error {
 	// Currently have to load configuration from filesystem first in order to obtain Registry Host/Port
 	configuration := &common.ConfigurationStruct{}
 	err := common.LoadFromFile(sdk.configProfile, sdk.configDir, configuration)
@@ -186,69 +584,81 @@ func (sdk *AppFunctionsSDK) initializeConfiguration() error {
 	}
 	sdk.config = *configuration
 
-	if sdk.useRegistry {
-		registryConfig := registryTypes.Config{
-			Host:          sdk.config.Registry.Host,
-			Port:          sdk.config.Registry.Port,
-			Type:          sdk.config.Registry.Type,
-			Stem:          internal.ConfigRegistryStem,
-			CheckInterval: "1s",
-			CheckRoute:    internal.ApiPingRoute,
-			ServiceKey:    sdk.ServiceKey,
-			ServiceHost:   sdk.config.Service.Host,
-			ServicePort:   sdk.config.Service.Port,
-		}
-
-		client, err := registry.NewRegistryClient(registryConfig)
-		if err != nil {
-			return fmt.Errorf("connection to Registry could not be made: %v", err)
+	if !sdk.useRegistry {
+		if sdk.overwrite {
+			fmt.Println("Ignoring -o/--overwrite, which only applies when using the registry")
 		}
-		//set registryClient
-		sdk.registryClient = client
+		return nil
+	}
 
-		if !sdk.registryClient.IsAlive() {
-			return fmt.Errorf("registry (%s) is not running", registryConfig.Type)
+	bootTimeout := sdk.config.Service.BootTimeout
+	if bootTimeout <= 0 {
+		bootTimeout = internal.BootTimeoutDefault
+	}
+	until := time.Now().Add(time.Millisecond * time.Duration(bootTimeout))
+	for {
+		err = sdk.initializeRegistry()
+		if err == nil {
+			return nil
 		}
+		fmt.Printf("failed to initialize Registry: %v\n", err)
 
-		// Register the service with Registry
-		err = sdk.registryClient.Register()
-		if err != nil {
-			return fmt.Errorf("could not register service with Registry: %v", err)
+		if !time.Now().Before(until) {
+			break
 		}
+		time.Sleep(time.Second * time.Duration(1))
+	}
 
-		hasConfig, err := sdk.registryClient.HasConfiguration()
-		if err != nil {
-			return fmt.Errorf("could not determine if registry has configuration: %v", err)
-		}
+	// run with the local configuration rather than not at all, without the registry for configuration updates
+	// and client endpoints
+	fmt.Println("Registry unavailable, falling back to local configuration")
+	sdk.useRegistry = false
+	sdk.config = *configuration
+	return nil
+}
 
-		if hasConfig {
-			rawConfig, err := sdk.registryClient.GetConfiguration(configuration)
-			if err != nil {
-				return fmt.Errorf("could not get configuration from Registry: %v", err)
-			}
+// initializeRegistry registers the service with the registry, along with its health check, and loads the
+// configuration from the registry
+func (sdk *AppFunctionsSDK) initializeRegistry() error {
+	checkInterval := sdk.config.Service.CheckInterval
+	if checkInterval == "" {
+		checkInterval = "1s"
+	}
+	registryConfig := registryTypes.Config{
+		Host:          sdk.config.Registry.Host,
+		Port:          sdk.config.Registry.Port,
+		Type:          sdk.config.Registry.Type,
+		Stem:          internal.ConfigRegistryStem,
+		CheckInterval: checkInterval,
+		CheckRoute:    internal.ApiPingRoute,
+		ServiceKey:    sdk.ServiceKey,
+		ServiceHost:   sdk.config.Service.Host,
+		ServicePort:   sdk.config.Service.Port,
+	}
 
-			actual, ok := rawConfig.(*common.ConfigurationStruct)
-			if !ok {
-				return fmt.Errorf("configuration from Registry failed type check")
-			}
+	client, err := registry.NewRegistryClient(registryConfig)
+	if err != nil {
+		return fmt.Errorf("connection to Registry could not be made: %v", err)
+	}
 
-			sdk.config = *actual
-			//Check that information was successfully read from Consul
-			if sdk.config.Service.Port == 0 {
-				sdk.LoggingClient.Error("Error reading from registry")
-			}
+	if !client.IsAlive() {
+		return fmt.Errorf("registry (%s) is not running", registryConfig.Type)
+	}
 
-			fmt.Println("Configuration loaded from registry")
-		} else {
-			err := sdk.registryClient.PutConfiguration(sdk.config, true)
-			if err != nil {
-				return fmt.Errorf("could not push configuration into registry: %v", err)
-			}
-			fmt.Println("Configuration pushed to registry")
-		}
+	// Register the service with Registry
+	err = client.Register()
+	if err != nil {
+		return fmt.Errorf("could not register service with Registry: %v", err)
+	}
 
+	configuration, err := common.LoadFromRegistry(client, sdk.config, sdk.overwrite)
+	if err != nil {
+		return err
 	}
 
+	//set registryClient
+	sdk.registryClient = client
+	sdk.config = *configuration
 	return nil
 }
 
@@ -279,12 +689,76 @@ func (sdk *AppFunctionsSDK) listenForConfigChanges() {
 				return
 			}
 
-			sdk.config.Writable = *actual
-
+			sdk.applyWritable(*actual)
 			sdk.LoggingClient.Info("Writeable configuration has been updated from Registry")
-			sdk.LoggingClient.SetLogLevel(sdk.config.Writable.LogLevel)
 
 			// TODO: Deal with pub/sub topics may have changed. Save copy of writeable so that we can determine what if anything changed?
 		}
 	}
 }
+
+// watchConfigurationFile checks the configuration file for changes at each interval, and applies changes to its
+// Writable section. Changes to other sections only take effect when the service is restarted.
+func (sdk *AppFunctionsSDK) watchConfigurationFile(interval time.Duration) {
+	fileName := common.ConfigFilePath(sdk.configProfile, sdk.configDir)
+	var modified time.Time
+	if info, err := os.Stat(fileName); err == nil {
+		modified = info.ModTime()
+	}
+
+	sdk.LoggingClient.Info("Watching for changes to " + fileName)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	stop := sdk.stopChannel()
+	for {
+		select {
+		case <-ticker.C:
+			modified = sdk.reloadConfigurationFile(fileName, modified)
+		case <-stop:
+			return
+		}
+	}
+}
+
+// reloadConfigurationFile applies the Writable section of the configuration file if the file was modified since
+// the time given, and returns the time the file was last modified
+func (sdk *AppFunctionsSDK) reloadConfigurationFile(fileName string, modified time.Time) time.Time {
+	info, err := os.Stat(fileName)
+	if err != nil || info.ModTime().Equal(modified) {
+		return modified
+	}
+
+	configuration := &common.ConfigurationStruct{}
+	if err := common.LoadFromFile(sdk.configProfile, sdk.configDir, configuration); err != nil {
+		sdk.LoggingClient.Error("Failed to reload configuration: " + err.Error())
+		return info.ModTime()
+	}
+	if sdk.writable != nil && reflect.DeepEqual(configuration.Writable, sdk.writable.Get()) {
+		return info.ModTime()
+	}
+
+	sdk.applyWritable(configuration.Writable)
+	sdk.LoggingClient.Info("Writeable configuration has been updated from " + fileName)
+	return info.ModTime()
+}
+
+// applyWritable replaces the Writable configuration, which is used by the pipeline from the next event. Only the
+// store is updated, as the configuration is read concurrently, i.e. by the webserver.
+func (sdk *AppFunctionsSDK) applyWritable(writable common.WritableInfo) {
+	if sdk.writable == nil {
+		sdk.writable = common.NewWritableStore(sdk.config.Writable)
+	}
+	previous := sdk.writable.Get()
+
+	if writable.LogLevel != previous.LogLevel {
+		if err := sdk.LoggingClient.SetLogLevel(writable.LogLevel); err != nil {
+			sdk.LoggingClient.Error("Failed to set log level: " + err.Error())
+		}
+	}
+
+	if !reflect.DeepEqual(writable.Pipeline, previous.Pipeline) {
+		sdk.LoggingClient.Info("Changes to Writable.Pipeline take effect when the service is restarted")
+	}
+
+	sdk.writable.Set(writable)
+}
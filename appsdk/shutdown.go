@@ -0,0 +1,146 @@
+//
+// Copyright (c) 2019 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package appsdk
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/antoniomtz/app-functions-sdk-go/internal/runtime"
+	"github.com/antoniomtz/app-functions-sdk-go/internal/trigger"
+	"github.com/antoniomtz/app-functions-sdk-go/pkg/devices"
+)
+
+// defaultShutdownTimeout is how long the service waits for the events being processed when it stops, unless
+// Service.ShutdownTimeout is configured
+const defaultShutdownTimeout = 30 * time.Second
+
+// cancelledDrainTimeout is how long the service waits for the events being processed to abort once their context
+// has been cancelled
+const cancelledDrainTimeout = 5 * time.Second
+
+// shutdownHook releases a resource held by an export function when the service stops, such as flushing an incomplete
+// batch or disconnecting from a broker
+type shutdownHook struct {
+	name string
+	hook func() error
+}
+
+// onShutdown registers the hook to be called, after the events being processed have been processed, when the service
+// stops
+func (sdk *AppFunctionsSDK) onShutdown(name string, hook func() error) {
+	sdk.shutdownHooks = append(sdk.shutdownHooks, shutdownHook{name: name, hook: hook})
+}
+
+// AddStopHook registers a function to be called when the service stops, after the events being processed have been
+// processed and the export functions have released their resources, i.e. to close a connection opened by a custom
+// function or to run the clean up of a snap stop-command. Hooks are called in the order they were registered, and an
+// error returned by a hook is logged under the name.
+func (sdk *AppFunctionsSDK) AddStopHook(name string, hook func() error) {
+	sdk.stopHooks = append(sdk.stopHooks, shutdownHook{name: name, hook: hook})
+}
+
+// MakeItStop stops the service started by MakeItRun, which returns once the events being processed have been
+// processed, incomplete export batches have been flushed and the export functions have disconnected. It can be called
+// from any goroutine, i.e. from a pipeline function, and has the same effect as the service receiving SIGTERM.
+func (sdk *AppFunctionsSDK) MakeItStop() {
+	stop := sdk.stopChannel()
+	sdk.stopMutex.Lock()
+	defer sdk.stopMutex.Unlock()
+
+	select {
+	case <-stop:
+	default:
+		close(stop)
+	}
+}
+
+// stopChannel returns the channel closed by MakeItStop, creating it if needed
+func (sdk *AppFunctionsSDK) stopChannel() chan struct{} {
+	sdk.stopMutex.Lock()
+	defer sdk.stopMutex.Unlock()
+
+	if sdk.stop == nil {
+		sdk.stop = make(chan struct{})
+	}
+	return sdk.stop
+}
+
+// shutdownTimeout returns the configured time to wait for the events being processed when the service stops
+func (sdk *AppFunctionsSDK) shutdownTimeout() time.Duration {
+	timeout := sdk.config.Service.ShutdownTimeout
+	if timeout == "" {
+		return defaultShutdownTimeout
+	}
+	parsed, err := time.ParseDuration(timeout)
+	if err != nil || parsed <= 0 {
+		sdk.LoggingClient.Error(fmt.Sprintf("Invalid Service.ShutdownTimeout '%s', using %s", timeout, defaultShutdownTimeout))
+		return defaultShutdownTimeout
+	}
+	return parsed
+}
+
+// shutdown stops the trigger and the device events, waits for the events being processed for up to the shutdown
+// timeout before cancelling their context, stops the web server and then calls the shutdown hooks of the export
+// functions followed by the stop hooks. The runtime and watcher may be nil.
+func (sdk *AppFunctionsSDK) shutdown(appTrigger trigger.Trigger, pipelineRuntime *runtime.GolangRuntime, deviceWatcher *devices.Watcher) {
+	timeout := sdk.shutdownTimeout()
+	deadline := time.Now().Add(timeout)
+	sdk.notifyStopping()
+
+	sdk.stopBackgroundPublishers()
+	if stopper, ok := appTrigger.(trigger.Stopper); ok {
+		if err := stopper.Stop(); err != nil {
+			sdk.LoggingClient.Error("Failed to stop trigger: " + err.Error())
+		}
+	}
+	if deviceWatcher != nil {
+		deviceWatcher.Stop()
+	}
+
+	if pipelineRuntime != nil {
+		sdk.LoggingClient.Info(fmt.Sprintf("Waiting up to %s for %d events being processed", timeout, pipelineRuntime.InFlight()))
+		if !pipelineRuntime.Drain(timeout) {
+			// cancel the context of the pipeline functions so exports stuck on an unreachable destination abort
+			sdk.LoggingClient.Warn(fmt.Sprintf("Cancelling %d events still being processed", pipelineRuntime.InFlight()))
+			if sdk.cancel != nil {
+				sdk.cancel()
+			}
+			if !pipelineRuntime.Drain(cancelledDrainTimeout) {
+				sdk.LoggingClient.Warn(fmt.Sprintf("Stopping with %d events still being processed", pipelineRuntime.InFlight()))
+			}
+		}
+	}
+
+	if sdk.webserver != nil {
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			remaining = time.Second
+		}
+		if err := sdk.webserver.StopHTTPServer(remaining); err != nil {
+			sdk.LoggingClient.Error("Failed to stop HTTP server: " + err.Error())
+		}
+	}
+
+	hooks := append(append([]shutdownHook{}, sdk.shutdownHooks...), sdk.stopHooks...)
+	for _, hook := range hooks {
+		if err := hook.hook(); err != nil {
+			sdk.LoggingClient.Error(fmt.Sprintf("Failed to stop %s: %s", hook.name, err.Error()))
+		}
+	}
+	sdk.LoggingClient.Info("Shutdown complete")
+}
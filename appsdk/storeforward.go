@@ -0,0 +1,205 @@
+//
+// Copyright (c) 2019 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package appsdk
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/antoniomtz/app-functions-sdk-go/appcontext"
+	"github.com/antoniomtz/app-functions-sdk-go/internal/common"
+	"github.com/antoniomtz/app-functions-sdk-go/internal/store"
+	"github.com/antoniomtz/app-functions-sdk-go/pkg/di"
+	"github.com/antoniomtz/app-functions-sdk-go/pkg/secrets"
+	"github.com/edgexfoundry/go-mod-core-contracts/clients/command"
+	"github.com/edgexfoundry/go-mod-core-contracts/clients/coredata"
+	"github.com/edgexfoundry/go-mod-core-contracts/clients/notifications"
+)
+
+const (
+	defaultStoreForwardPath          = "store-and-forward.db"
+	defaultStoreForwardRetryInterval = time.Minute
+
+	// StoreTypeBolt stores the data of failed exports in a BoltDB file
+	StoreTypeBolt = "bolt"
+	// StoreTypeRedis stores the data of failed exports in a Redis server
+	StoreTypeRedis = "redis"
+
+	// storePasswordSecret is the key of the secret holding the password of the Redis server
+	storePasswordSecret = "password"
+)
+
+// startStoreForward stores the data of the export functions which fail, and exports it again in the background every
+// StoreAndForward.RetryInterval until the service stops. Data given up on is moved to the dead letters.
+func (sdk *AppFunctionsSDK) startStoreForward(get di.Get) {
+	config := sdk.config.StoreAndForward
+	if !config.Enabled || sdk.exports == nil {
+		return
+	}
+
+	retryInterval := defaultStoreForwardRetryInterval
+	if config.RetryInterval != "" {
+		parsed, err := time.ParseDuration(config.RetryInterval)
+		if err != nil || parsed <= 0 {
+			sdk.LoggingClient.Error(fmt.Sprintf("Invalid StoreAndForward.RetryInterval '%s', using %s", config.RetryInterval, retryInterval))
+		} else {
+			retryInterval = parsed
+		}
+	}
+	var ttl time.Duration
+	if config.TTL != "" {
+		parsed, err := time.ParseDuration(config.TTL)
+		if err != nil || parsed <= 0 {
+			sdk.LoggingClient.Error(fmt.Sprintf("Invalid StoreAndForward.TTL '%s', storing data until it's exported", config.TTL))
+		} else {
+			ttl = parsed
+		}
+	}
+
+	storeConfig := store.Config{
+		MaxObjects: config.MaxObjects,
+		MaxSize:    config.MaxSize,
+		TTL:        ttl,
+		Evicted: func(object store.StoredObject, reason string) {
+			sdk.LoggingClient.Error(fmt.Sprintf("Evicted the stored data of export %s from store and forward: %s", object.Export, reason))
+			if err := sdk.exports.DeadLetterStored(object, reason); err != nil {
+				sdk.LoggingClient.Error(fmt.Sprintf("Failed to store dead letter of export %s: %s", object.Export, err.Error()))
+			}
+		},
+	}
+	secretProvider, _ := get(di.SecretProviderName).(secrets.SecretProvider)
+	storeClient, location, err := newStoreClient(config, storeConfig, secretProvider)
+	if err != nil {
+		sdk.LoggingClient.Error(fmt.Sprintf("Failed to open the store and forward %s, store and forward is disabled: %s", location, err.Error()))
+		return
+	}
+
+	eventClient, _ := get(di.EventClientName).(coredata.EventClient)
+	commandClient, _ := get(di.CommandClientName).(command.CommandClient)
+	notificationsClient, _ := get(di.NotificationsClientName).(notifications.NotificationsClient)
+
+	sdk.exports.Store = storeClient
+	if sdk.webserver != nil {
+		sdk.webserver.StoreForward = sdk.exports
+	}
+	sdk.exports.MaxRetryCount = config.MaxRetryCount
+	sdk.exports.NewContext = func(correlationID string) *appcontext.Context {
+		return &appcontext.Context{
+			Configuration:       sdk.config,
+			LoggingClient:       sdk.LoggingClient,
+			CorrelationID:       correlationID,
+			EventClient:         eventClient,
+			CommandClient:       commandClient,
+			NotificationsClient: notificationsClient,
+			SecretProvider:      secretProvider,
+			Ctx:                 sdk.ctx,
+		}
+	}
+
+	stop := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(retryInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				sdk.retryStored()
+			case <-stop:
+				return
+			}
+		}
+	}()
+	// the retries stop before the export functions release their resources, and the store is closed after them in
+	// case they fail while flushing
+	stopRetries := shutdownHook{name: "StoreAndForward", hook: func() error {
+		close(stop)
+		<-stopped
+		return nil
+	}}
+	sdk.shutdownHooks = append([]shutdownHook{stopRetries}, sdk.shutdownHooks...)
+	sdk.onShutdown("StoreAndForward", storeClient.Disconnect)
+	sdk.LoggingClient.Info(fmt.Sprintf("Storing the data of failed exports in %s, and exporting it again every %s", location, retryInterval))
+}
+
+// newStoreClient opens the store of the configured StoreAndForward.Type, returning it along with a description of
+// where the data is stored for the logs. The secret provider, which may be nil, provides the Redis password.
+func newStoreClient(config common.StoreAndForwardInfo, storeConfig store.Config, secretProvider secrets.SecretProvider) (store.StoreClient, string, error) {
+	switch strings.ToLower(config.Type) {
+	case "", StoreTypeBolt:
+		path := config.Path
+		if path == "" {
+			path = defaultStoreForwardPath
+		}
+		location := "BoltDB file " + path
+		storeClient, err := store.NewBoltStore(path, storeConfig)
+		if err != nil {
+			return nil, location, err
+		}
+		return storeClient, location, nil
+	case StoreTypeRedis:
+		location := "Redis server " + config.Address
+		password, err := storePassword(config, secretProvider)
+		if err != nil {
+			return nil, location, err
+		}
+		storeClient, err := store.NewRedisStore(store.RedisConfig{
+			Address:  config.Address,
+			Password: password,
+			Database: config.Database,
+			Key:      config.Key,
+		}, storeConfig)
+		if err != nil {
+			return nil, location, err
+		}
+		return storeClient, location, nil
+	default:
+		return nil, "store", fmt.Errorf("StoreAndForward.Type '%s' is not supported, use '%s' or '%s'", config.Type, StoreTypeBolt, StoreTypeRedis)
+	}
+}
+
+// storePassword reads the password of the Redis server from the secrets at StoreAndForward.SecretPath, or returns
+// no password when the SecretPath isn't set
+func storePassword(config common.StoreAndForwardInfo, secretProvider secrets.SecretProvider) (string, error) {
+	if config.SecretPath == "" {
+		return "", nil
+	}
+	if secretProvider == nil {
+		return "", fmt.Errorf("StoreAndForward.SecretPath '%s' requires the SecretStore to be configured", config.SecretPath)
+	}
+	secretValues, err := secretProvider.GetSecrets(config.SecretPath, storePasswordSecret)
+	if err != nil {
+		return "", fmt.Errorf("unable to read the store and forward secrets at '%s': %v", config.SecretPath, err)
+	}
+	if secretValues[storePasswordSecret] == "" {
+		return "", fmt.Errorf("store and forward secrets not found at path '%s': %s", config.SecretPath, storePasswordSecret)
+	}
+	return secretValues[storePasswordSecret], nil
+}
+
+// retryStored exports the stored data again, logging the outcome
+func (sdk *AppFunctionsSDK) retryStored() {
+	exported, err := sdk.exports.RetryStored()
+	if err != nil {
+		sdk.LoggingClient.Error("Failed to export the data stored for store and forward: " + err.Error())
+	}
+	if exported > 0 {
+		sdk.LoggingClient.Info(fmt.Sprintf("Exported %d stored failed exports", exported))
+	}
+}
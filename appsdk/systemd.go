@@ -0,0 +1,65 @@
+//
+// Copyright (c) 2019 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package appsdk
+
+import (
+	"time"
+
+	"github.com/antoniomtz/app-functions-sdk-go/internal/systemd"
+)
+
+// notifyReady tells systemd the service is ready when it was started with Type=notify, and feeds its watchdog at
+// half the WatchdogSec interval until stop is closed
+func (sdk *AppFunctionsSDK) notifyReady(stop <-chan struct{}) {
+	if _, err := systemd.Notify(systemd.Ready); err != nil {
+		sdk.LoggingClient.Error("Failed to notify systemd the service is ready: " + err.Error())
+	}
+
+	interval, err := systemd.WatchdogInterval()
+	if err != nil {
+		sdk.LoggingClient.Error("Unable to feed the systemd watchdog: " + err.Error())
+		return
+	}
+	if interval == 0 {
+		return
+	}
+
+	sdk.LoggingClient.Info("Feeding the systemd watchdog every " + (interval / 2).String())
+	go func() {
+		ticker := time.NewTicker(interval / 2)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				if _, err := systemd.Notify(systemd.Watchdog); err != nil {
+					sdk.LoggingClient.Error("Failed to feed the systemd watchdog: " + err.Error())
+				}
+			}
+		}
+	}()
+}
+
+// notifyStopping tells systemd the service is stopping, extending its stop timeout to cover the time the events
+// being processed are given to finish
+func (sdk *AppFunctionsSDK) notifyStopping() {
+	state := systemd.Stopping + "\n" + systemd.ExtendTimeout(sdk.shutdownTimeout()+cancelledDrainTimeout)
+	if _, err := systemd.Notify(state); err != nil {
+		sdk.LoggingClient.Error("Failed to notify systemd the service is stopping: " + err.Error())
+	}
+}
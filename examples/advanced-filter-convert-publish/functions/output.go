@@ -52,8 +52,8 @@ func Publish(edgexcontext *appcontext.Context, params ...interface{}) (bool, int
 	event := params[0].(models.Event)
 	payload, _ := json.Marshal(event)
 
-	// By calling Complete, the filtered and converted events will be posted back to the message bus on the new topic defined in the configuration.
-	edgexcontext.Complete(payload)
+	// By calling SetResponseData, the filtered and converted events will be posted back to the message bus on the new topic defined in the configuration.
+	edgexcontext.SetResponseData(payload)
 
 	return false, nil
 }
@@ -0,0 +1,59 @@
+//
+// Copyright (c) 2019 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/antoniomtz/app-functions-sdk-go/appsdk"
+)
+
+const (
+	serviceKey = "AppService-<profile>"
+)
+
+func main() {
+	// 1) First thing to do is to create an instance of the EdgeX SDK and initialize it. The service key
+	// includes the profile, so the same binary can be registered once for each configuration profile.
+	edgexSdk := &appsdk.AppFunctionsSDK{ServiceKey: serviceKey}
+	if err := edgexSdk.Initialize(); err != nil {
+		fmt.Printf("SDK initialization failed: %v\n", err)
+		os.Exit(-1)
+	}
+
+	// 2) The functions pipeline is defined entirely by the [Writable.Pipeline] section of the configuration.
+	transforms, err := edgexSdk.LoadConfigurablePipeline()
+	if err != nil {
+		edgexSdk.LoggingClient.Error("Failed to load functions pipeline from configuration: " + err.Error())
+		os.Exit(-1)
+	}
+	edgexSdk.SetFunctionsPipeline(transforms...)
+
+	// 3) Lastly, we'll go ahead and tell the SDK to "start" and begin listening for events
+	// to trigger the pipeline.
+	err = edgexSdk.MakeItRun()
+	if err != nil {
+		edgexSdk.LoggingClient.Error("MakeItRun returned error: ", err.Error())
+		os.Exit(-1)
+	}
+
+	// Do any required cleanup here
+
+	os.Exit(0)
+}
@@ -98,6 +98,7 @@ func printXMLToConsole(edgexcontext *appcontext.Context, params ...interface{})
 
 	// Leverage the built in logging service in EdgeX
 	edgexcontext.LoggingClient.Debug(params[0].(string))
-	edgexcontext.Complete(([]byte)(params[0].(string)))
+	edgexcontext.SetResponseData(([]byte)(params[0].(string)))
+	edgexcontext.SetResponseContentType("application/xml")
 	return true, params[0].(string)
 }
@@ -89,6 +89,7 @@ func printXMLToConsole(edgexcontext *appcontext.Context, params ...interface{})
 	// Leverage the built in logging service in EdgeX
 	edgexcontext.LoggingClient.Debug("XML printed to console")
 
-	edgexcontext.Complete([]byte(params[0].(string)))
+	edgexcontext.SetResponseData([]byte(params[0].(string)))
+	edgexcontext.SetResponseContentType("application/xml")
 	return false, nil
 }
@@ -0,0 +1,125 @@
+//
+// Copyright (c) 2019 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package alerts
+
+import (
+	"bytes"
+	syscontext "context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	MQTT "github.com/eclipse/paho.mqtt.golang"
+	"github.com/edgexfoundry/go-mod-core-contracts/clients/notifications"
+)
+
+const (
+	mqttTimeout    = 10 * time.Second
+	webhookTimeout = 10 * time.Second
+)
+
+// NotificationAction sends a notification through Support Notifications for each alert, with a critical severity
+// when the alert fires and a normal severity when it resolves
+func NotificationAction(client notifications.NotificationsClient, sender string) Action {
+	return func(alert Alert) error {
+		severity, status := notifications.CRITICAL, "firing"
+		if !alert.Firing {
+			severity, status = notifications.NORMAL, "resolved"
+		}
+
+		notification := notifications.Notification{
+			Slug:     sender + "-alert-" + strconv.FormatInt(alert.Time.UnixNano(), 10),
+			Sender:   sender,
+			Category: notifications.SW_HEALTH,
+			Severity: severity,
+			Content:  fmt.Sprintf("Alert %s: %s (%s is %g)", status, alert.Rule, alert.Metric, alert.Value),
+			Labels:   []string{"alert"},
+		}
+		return client.SendNotification(notification, syscontext.Background())
+	}
+}
+
+// Publisher delivers a status message, i.e. an Alert, as JSON to an external system
+type Publisher func(status interface{}) error
+
+// WebhookPublisher posts each status message as JSON to the URL
+func WebhookPublisher(url string) Publisher {
+	client := &http.Client{Timeout: webhookTimeout}
+	return func(status interface{}) error {
+		payload, err := json.Marshal(status)
+		if err != nil {
+			return err
+		}
+
+		response, err := client.Post(url, "application/json", bytes.NewReader(payload))
+		if err != nil {
+			return err
+		}
+		defer response.Body.Close()
+		if response.StatusCode < 200 || response.StatusCode >= 300 {
+			return fmt.Errorf("webhook %s responded with status %d", url, response.StatusCode)
+		}
+		return nil
+	}
+}
+
+// MQTTPublisher publishes each status message as JSON to the topic of the MQTT broker, i.e. tcp://localhost:1883.
+// The client connects when the first message is published and reconnects automatically.
+func MQTTPublisher(broker string, topic string, clientID string) Publisher {
+	opts := MQTT.NewClientOptions()
+	opts.AddBroker(broker)
+	opts.SetClientID(clientID)
+	opts.SetAutoReconnect(true)
+	client := MQTT.NewClient(opts)
+
+	return func(status interface{}) error {
+		if !client.IsConnected() {
+			if token := client.Connect(); !token.WaitTimeout(mqttTimeout) || token.Error() != nil {
+				return fmt.Errorf("could not connect to mqtt server: %v", token.Error())
+			}
+		}
+
+		payload, err := json.Marshal(status)
+		if err != nil {
+			return err
+		}
+		token := client.Publish(topic, 1, false, payload)
+		if !token.WaitTimeout(mqttTimeout) {
+			return fmt.Errorf("timed out publishing to mqtt server")
+		}
+		return token.Error()
+	}
+}
+
+// WebhookAction posts each alert as JSON to the URL
+func WebhookAction(url string) Action {
+	return publishAction(WebhookPublisher(url))
+}
+
+// MQTTAction publishes each alert as a JSON status message to the topic of the MQTT broker, i.e.
+// tcp://localhost:1883. The client connects when the first alert is published and reconnects automatically.
+func MQTTAction(broker string, topic string, clientID string) Action {
+	return publishAction(MQTTPublisher(broker, topic, clientID))
+}
+
+func publishAction(publish Publisher) Action {
+	return func(alert Alert) error {
+		return publish(alert)
+	}
+}
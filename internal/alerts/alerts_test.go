@@ -0,0 +1,186 @@
+//
+// Copyright (c) 2019 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package alerts
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/antoniomtz/app-functions-sdk-go/appcontext"
+	"github.com/antoniomtz/app-functions-sdk-go/internal/runtime"
+	"github.com/antoniomtz/go-mod-messaging/pkg/types"
+	"github.com/edgexfoundry/go-mod-core-contracts/clients"
+	"github.com/edgexfoundry/go-mod-core-contracts/clients/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var lc logger.LoggingClient
+
+func init() {
+	lc = logger.NewClient("alerts_tests", false, "./test.log", "DEBUG")
+}
+
+func TestParseRule(t *testing.T) {
+	rule, err := ParseRule("errors_per_min  >  10")
+	require.NoError(t, err)
+	assert.Equal(t, Rule{Expression: "errors_per_min > 10", Metric: ErrorsPerMinute, Operator: ">", Threshold: 10}, rule)
+	assert.True(t, rule.Holds(11))
+	assert.False(t, rule.Holds(10))
+
+	rule, err = ParseRule("intake_paused == 1")
+	require.NoError(t, err)
+	assert.True(t, rule.Holds(1))
+}
+
+func TestParseRuleErrors(t *testing.T) {
+	tests := []struct {
+		Name       string
+		Expression string
+		Error      string
+	}{
+		{"Missing threshold", "errors_per_min >", "expected '<metric> <operator> <threshold>'"},
+		{"Unknown metric", "queue_depth > 1000", "unknown metric 'queue_depth'"},
+		{"Unknown operator", "errors_per_min => 10", "unknown operator '=>'"},
+		{"Invalid threshold", "errors_per_min > ten", "invalid threshold 'ten'"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			_, err := ParseRule(test.Expression)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), test.Error)
+		})
+	}
+}
+
+func TestMonitorFiresAndResolves(t *testing.T) {
+	fail := true
+	pipelineRuntime := &runtime.GolangRuntime{
+		Transforms: []func(*appcontext.Context, ...interface{}) (bool, interface{}){
+			func(edgexcontext *appcontext.Context, params ...interface{}) (bool, interface{}) {
+				if fail {
+					return false, errors.New("failed")
+				}
+				return true, nil
+			},
+		},
+	}
+	process := func(count int) {
+		for i := 0; i < count; i++ {
+			pipelineRuntime.ProcessEvent(&appcontext.Context{LoggingClient: lc}, types.MessageEnvelope{
+				Payload:     []byte(`{"device":"Random-Float-Device"}`),
+				ContentType: clients.ContentTypeJSON,
+			})
+		}
+	}
+
+	errorsRule, err := ParseRule("errors_per_min > 10")
+	require.NoError(t, err)
+	percentRule, err := ParseRule("error_percent >= 50")
+	require.NoError(t, err)
+	var alerts []Alert
+	monitor := &Monitor{
+		Rules:         []Rule{errorsRule, percentRule},
+		Runtime:       pipelineRuntime,
+		LoggingClient: lc,
+		Actions: []Action{func(alert Alert) error {
+			alerts = append(alerts, alert)
+			return nil
+		}},
+	}
+
+	start := time.Now()
+	monitor.Check(start)
+	process(12)
+	monitor.Check(start.Add(time.Minute))
+	require.Equal(t, 2, len(alerts))
+	assert.Equal(t, Alert{Rule: "errors_per_min > 10", Metric: ErrorsPerMinute, Value: 12, Threshold: 10, Firing: true, Time: start.Add(time.Minute)}, alerts[0])
+	assert.Equal(t, float64(100), alerts[1].Value)
+
+	// Still firing, so no further alerts
+	process(12)
+	monitor.Check(start.Add(2 * time.Minute))
+	assert.Equal(t, 2, len(alerts))
+
+	fail = false
+	process(12)
+	monitor.Check(start.Add(3 * time.Minute))
+	require.Equal(t, 4, len(alerts))
+	assert.False(t, alerts[2].Firing)
+	assert.Equal(t, float64(0), alerts[2].Value)
+	assert.False(t, alerts[3].Firing)
+}
+
+func TestMonitorExportsAndIntake(t *testing.T) {
+	tracker := &runtime.ExportTracker{}
+	export := tracker.Track("HTTPPost", func(edgexcontext *appcontext.Context, params ...interface{}) (bool, interface{}) {
+		return false, errors.New("connection refused")
+	}, func() int { return 5 })
+	pipelineRuntime := &runtime.GolangRuntime{}
+
+	var rules []Rule
+	for _, expression := range []string{"export_errors_per_min > 1", "pending_events > 4", "intake_paused == 1"} {
+		rule, err := ParseRule(expression)
+		require.NoError(t, err)
+		rules = append(rules, rule)
+	}
+	var firing []string
+	monitor := &Monitor{
+		Rules:         rules,
+		Runtime:       pipelineRuntime,
+		Exports:       tracker,
+		LoggingClient: lc,
+		Actions: []Action{func(alert Alert) error {
+			firing = append(firing, alert.Metric)
+			return errors.New("action failures are logged")
+		}},
+	}
+
+	start := time.Now()
+	monitor.Check(start)
+	export(&appcontext.Context{LoggingClient: lc})
+	export(&appcontext.Context{LoggingClient: lc})
+	pipelineRuntime.Pause()
+	monitor.Check(start.Add(30 * time.Second))
+	assert.Equal(t, []string{ExportErrorsPerMinute, PendingEvents, IntakePaused}, firing)
+}
+
+func TestWebhookPublisher(t *testing.T) {
+	var received Alert
+	status := http.StatusOK
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		assert.Equal(t, "application/json", request.Header.Get("Content-Type"))
+		body, _ := ioutil.ReadAll(request.Body)
+		require.NoError(t, json.Unmarshal(body, &received))
+		writer.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	action := WebhookAction(server.URL)
+	require.NoError(t, action(Alert{Rule: "errors_per_min > 10", Metric: ErrorsPerMinute, Value: 12, Firing: true}))
+	assert.Equal(t, "errors_per_min > 10", received.Rule)
+	assert.True(t, received.Firing)
+
+	status = http.StatusServiceUnavailable
+	assert.Error(t, action(Alert{Rule: "errors_per_min > 10"}))
+}
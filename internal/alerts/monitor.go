@@ -0,0 +1,147 @@
+//
+// Copyright (c) 2019 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package alerts
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/antoniomtz/app-functions-sdk-go/internal/runtime"
+	"github.com/edgexfoundry/go-mod-core-contracts/clients/logger"
+)
+
+// Alert is sent to the actions when a rule starts to hold (fires) and when it stops holding (resolves)
+type Alert struct {
+	Rule      string    `json:"rule"`
+	Metric    string    `json:"metric"`
+	Value     float64   `json:"value"`
+	Threshold float64   `json:"threshold"`
+	Firing    bool      `json:"firing"`
+	Time      time.Time `json:"time"`
+}
+
+// Action is taken when an alert fires or resolves, i.e. sending a notification
+type Action func(alert Alert) error
+
+// Monitor evaluates the rules over the metrics of the runtime and export functions at each check. Rate metrics are
+// calculated over the time since the previous check.
+type Monitor struct {
+	Rules         []Rule
+	Runtime       *runtime.GolangRuntime
+	Exports       *runtime.ExportTracker
+	Actions       []Action
+	LoggingClient logger.LoggingClient
+	previous      counters
+	firing        map[string]bool
+}
+
+type counters struct {
+	time            time.Time
+	received        uint64
+	failed          uint64
+	exportFailed    uint64
+	processingNanos int64
+}
+
+// Run checks the rules at each interval, forever
+func (monitor *Monitor) Run(interval time.Duration) {
+	monitor.Check(time.Now())
+	for now := range time.Tick(interval) {
+		monitor.Check(now)
+	}
+}
+
+// Check evaluates the rules, taking the actions for the alerts which fire or resolve. The first check only records
+// the counters which rates are calculated from.
+func (monitor *Monitor) Check(now time.Time) {
+	current, values := monitor.sample(now)
+	previous := monitor.previous
+	monitor.previous = current
+	if previous.time.IsZero() {
+		return
+	}
+
+	minutes := now.Sub(previous.time).Minutes()
+	received := float64(current.received - previous.received)
+	values[EventsPerMinute] = received / minutes
+	values[ErrorsPerMinute] = float64(current.failed-previous.failed) / minutes
+	values[ExportErrorsPerMinute] = float64(current.exportFailed-previous.exportFailed) / minutes
+	if received > 0 {
+		values[ErrorPercent] = float64(current.failed-previous.failed) * 100 / received
+		values[AvgProcessingMs] = float64(current.processingNanos-previous.processingNanos) / float64(time.Millisecond) / received
+	}
+
+	if monitor.firing == nil {
+		monitor.firing = map[string]bool{}
+	}
+	for _, rule := range monitor.Rules {
+		value := values[rule.Metric]
+		holds := rule.Holds(value)
+		if holds == monitor.firing[rule.Expression] {
+			continue
+		}
+		monitor.firing[rule.Expression] = holds
+		monitor.alert(Alert{
+			Rule:      rule.Expression,
+			Metric:    rule.Metric,
+			Value:     value,
+			Threshold: rule.Threshold,
+			Firing:    holds,
+			Time:      now,
+		})
+	}
+}
+
+// sample reads the counters of the runtime and export functions, along with the metrics which aren't rates
+func (monitor *Monitor) sample(now time.Time) (counters, map[string]float64) {
+	current := counters{time: now}
+	values := map[string]float64{}
+
+	if monitor.Runtime != nil {
+		for _, metrics := range monitor.Runtime.PipelineMetrics() {
+			current.received += metrics.EventsReceived
+			current.failed += metrics.EventsFailed
+			current.processingNanos += metrics.ProcessingTimeNanos
+		}
+		if monitor.Runtime.IntakeStatus().Paused {
+			values[IntakePaused] = 1
+		}
+	}
+
+	pending := 0
+	for _, export := range monitor.Exports.Metrics() {
+		current.exportFailed += export.Failed
+		pending += export.PendingEvents
+	}
+	values[PendingEvents] = float64(pending)
+
+	return current, values
+}
+
+func (monitor *Monitor) alert(alert Alert) {
+	if alert.Firing {
+		monitor.LoggingClient.Warn(fmt.Sprintf("Alert firing: %s (%s is %g)", alert.Rule, alert.Metric, alert.Value))
+	} else {
+		monitor.LoggingClient.Info(fmt.Sprintf("Alert resolved: %s (%s is %g)", alert.Rule, alert.Metric, alert.Value))
+	}
+
+	for _, action := range monitor.Actions {
+		if err := action(alert); err != nil {
+			monitor.LoggingClient.Error(fmt.Sprintf("Failed to send alert '%s': %s", alert.Rule, err.Error()))
+		}
+	}
+}
@@ -0,0 +1,109 @@
+//
+// Copyright (c) 2019 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package alerts
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	// EventsPerMinute is the rate of events received by the pipelines
+	EventsPerMinute = "events_per_min"
+	// ErrorsPerMinute is the rate of events for which a pipeline function returned an error
+	ErrorsPerMinute = "errors_per_min"
+	// ErrorPercent is the percentage of the events received since the last check which failed
+	ErrorPercent = "error_percent"
+	// ExportErrorsPerMinute is the rate of failed calls to export functions
+	ExportErrorsPerMinute = "export_errors_per_min"
+	// PendingEvents is the number of events held by export functions which have not been exported, i.e. in
+	// incomplete batches
+	PendingEvents = "pending_events"
+	// AvgProcessingMs is the average time in milliseconds taken to process the events received since the last check
+	AvgProcessingMs = "avg_processing_ms"
+	// IntakePaused is 1 while the intake of events is paused, and 0 otherwise
+	IntakePaused = "intake_paused"
+)
+
+var knownMetrics = map[string]bool{
+	EventsPerMinute:       true,
+	ErrorsPerMinute:       true,
+	ErrorPercent:          true,
+	ExportErrorsPerMinute: true,
+	PendingEvents:         true,
+	AvgProcessingMs:       true,
+	IntakePaused:          true,
+}
+
+var operators = map[string]func(value float64, threshold float64) bool{
+	">":  func(value float64, threshold float64) bool { return value > threshold },
+	">=": func(value float64, threshold float64) bool { return value >= threshold },
+	"<":  func(value float64, threshold float64) bool { return value < threshold },
+	"<=": func(value float64, threshold float64) bool { return value <= threshold },
+	"==": func(value float64, threshold float64) bool { return value == threshold },
+	"!=": func(value float64, threshold float64) bool { return value != threshold },
+}
+
+// Rule fires an alert while a metric compares to the threshold, i.e. "errors_per_min > 10"
+type Rule struct {
+	Expression string
+	Metric     string
+	Operator   string
+	Threshold  float64
+}
+
+// ParseRule parses a rule of the form "<metric> <operator> <threshold>"
+func ParseRule(expression string) (Rule, error) {
+	fields := strings.Fields(expression)
+	if len(fields) != 3 {
+		return Rule{}, fmt.Errorf("invalid alert rule '%s', expected '<metric> <operator> <threshold>'", expression)
+	}
+
+	if !knownMetrics[fields[0]] {
+		return Rule{}, fmt.Errorf("unknown metric '%s' in alert rule '%s', expected one of %s", fields[0], expression, strings.Join(metricNames(), ", "))
+	}
+	if operators[fields[1]] == nil {
+		return Rule{}, fmt.Errorf("unknown operator '%s' in alert rule '%s'", fields[1], expression)
+	}
+	threshold, err := strconv.ParseFloat(fields[2], 64)
+	if err != nil {
+		return Rule{}, fmt.Errorf("invalid threshold '%s' in alert rule '%s'", fields[2], expression)
+	}
+
+	return Rule{
+		Expression: strings.Join(fields, " "),
+		Metric:     fields[0],
+		Operator:   fields[1],
+		Threshold:  threshold,
+	}, nil
+}
+
+// Holds reports whether the rule's condition is true for the value of its metric
+func (rule Rule) Holds(value float64) bool {
+	return operators[rule.Operator](value, rule.Threshold)
+}
+
+func metricNames() []string {
+	names := make([]string, 0, len(knownMetrics))
+	for name := range knownMetrics {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -22,9 +22,37 @@ import (
 	"github.com/antoniomtz/go-mod-messaging/pkg/types"
 )
 
-// WritableInfo ...
+// WritableInfo holds the settings which can be changed while the service is running, through the registry or by
+// editing the configuration file
 type WritableInfo struct {
 	LogLevel string
+	// MarkPushedMaxAge is the maximum age, i.e. '24h', of an event which will be marked as pushed in Core Data.
+	// Older events are assumed to be replayed. Empty disables the check.
+	MarkPushedMaxAge string
+	// PipelineSettings are custom settings read by pipeline functions for each event, such as filter parameters,
+	// through the Configuration of the context
+	PipelineSettings map[string]string
+	// Pipeline defines a functions pipeline built from the SDK's built-in functions
+	Pipeline PipelineInfo
+	// ProfileStages records the time, CPU and heap allocations of each pipeline function. It adds overhead to every
+	// function call, so is intended for finding the functions which dominate the cost of a pipeline.
+	ProfileStages bool
+	// PipelineTimeout is the time budget for processing each event, i.e. '10s'. Functions aren't called once it is
+	// exceeded, and calls to the EdgeX clients are abandoned at its deadline. Empty disables the timeout.
+	PipelineTimeout string
+}
+
+// PipelineInfo defines a functions pipeline in the configuration
+type PipelineInfo struct {
+	// ExecutionOrder is the comma separated names of the functions in the pipeline, i.e. "DeviceNameFilter, XMLTransform"
+	ExecutionOrder string
+	// Functions holds the parameters of the functions by name
+	Functions map[string]PipelineFunction
+}
+
+// PipelineFunction holds the parameters of a function in a pipeline defined in the configuration
+type PipelineFunction struct {
+	Parameters map[string]string
 }
 
 // ClientInfo provides the host and port of another service in the eco-system.
@@ -50,6 +78,16 @@ type ConfigurationStruct struct {
 	Service             ServiceInfo
 	MessageBus          types.MessageBusConfig
 	Binding             BindingInfo
+	ErrorLog            ErrorLogInfo
+	PoisonMessages      PoisonMessagesInfo
+	SecretStore         SecretStoreInfo
+	Alerts              AlertsInfo
+	ExportWebhooks      ExportWebhooksInfo
+	ExportManifest      ExportManifestInfo
+	ExportDeadLetters   ExportDeadLettersInfo
+	StoreAndForward     StoreAndForwardInfo
+	DeviceEvents        DeviceEventsInfo
+	Tracing             TracingInfo
 	ApplicationSettings map[string]string
 	Clients             map[string]ClientInfo
 }
@@ -65,6 +103,9 @@ type RegistryInfo struct {
 type LoggingInfo struct {
 	EnableRemote bool
 	File         string
+	// FloodControlInterval collapses repeated identical error and warning messages within the interval, i.e. '1m',
+	// into a summary with the count. Empty disables flood control.
+	FloodControlInterval string
 }
 
 // ServiceInfo ...
@@ -78,6 +119,17 @@ type ServiceInfo struct {
 	StartupMsg    string
 	ReadMaxLimit  int
 	Timeout       int
+	// CertFile and KeyFile are the PEM files of the certificate used when Protocol is https. They are reloaded
+	// when changed, so the certificate can be renewed without a restart.
+	CertFile string
+	KeyFile  string
+
+	// ShutdownTimeout is how long the service waits for the events being processed when it stops, i.e. '10s'.
+	// Defaults to 30 seconds.
+	ShutdownTimeout string
+	// ShutdownReportFile is the file the shutdown report is written to as JSON when the service stops. The report is
+	// always logged.
+	ShutdownReportFile string
 }
 
 // BindingInfo contains Metadata associated with each binding
@@ -86,4 +138,169 @@ type BindingInfo struct {
 	Name           string
 	SubscribeTopic string
 	PublishTopic   string
+	// SubscribeTopics lists the topics subscribed to by the message bus trigger, instead of SubscribeTopic
+	SubscribeTopics []string
+	// TopicWeights is the number of messages received from a subscribed topic in its turn, before moving on to the
+	// next topic with messages waiting. Topics default to a weight of 1.
+	TopicWeights map[string]int
+	// Workers is the number of events the message bus trigger processes in parallel. Defaults to 1, processing
+	// events one at a time in the order received.
+	Workers int
+	// PreserveDeviceOrder processes the events of a device one at a time in the order received when Workers is
+	// more than 1
+	PreserveDeviceOrder bool
+	// QueueSize is the number of events received by the message bus trigger which are buffered while the pipeline
+	// is busy. Zero disables the queue.
+	QueueSize int
+	// OverflowPolicy is what happens to events when the queue is full: 'block' (default), 'drop-oldest' or
+	// 'drop-newest'
+	OverflowPolicy string
+	// PublishQueueSize is the number of messages waiting to be published to PublishTopic by the message bus trigger
+	// before processing waits for them to be published. Defaults to 100.
+	PublishQueueSize int
+	// ReconnectInterval is the wait, i.e. '1s', before the message bus trigger subscribes again to a topic whose
+	// connection dropped, doubling after each failed attempt up to MaxReconnectInterval. Defaults to 1s and 30s.
+	ReconnectInterval    string
+	MaxReconnectInterval string
+	// MaxReconnectAttempts is the number of failed attempts in a row to subscribe again after which the subscriber
+	// is reported unhealthy, while the attempts carry on. Defaults to 5.
+	MaxReconnectAttempts int
+	// AckMode is when the message bus trigger acknowledges the messages received: 'auto' (default) as they are
+	// received, or 'pipeline' once processed successfully, so the messages which aren't are delivered again
+	AckMode string
+}
+
+// ErrorLogInfo configures the retention of pipeline errors and the payloads which caused them
+type ErrorLogInfo struct {
+	// Capacity is the number of errors retained. Zero disables the error log.
+	Capacity int
+	// MaxPayloadSize is the number of bytes of each payload retained. Zero retains the whole payload.
+	MaxPayloadSize int
+	// RedactFields lists the JSON fields whose values are redacted from the retained payloads
+	RedactFields []string
+}
+
+// PoisonMessagesInfo configures the handling of messages which fail to be processed every time they are received
+type PoisonMessagesInfo struct {
+	// MaxFailures is the number of consecutive failures to process a message, identified by its payload, after which
+	// it is moved to the dead letters instead of being processed again. Zero disables poison message handling.
+	MaxFailures int
+	// DeadLetterFile is the file the dead letters are appended to as lines of JSON
+	DeadLetterFile string
+	// DeadLetterTopic is the message bus topic the dead letters are published to by the message bus trigger
+	DeadLetterTopic string
+}
+
+// AlertsInfo configures the alert rules evaluated over the metrics of the SDK
+type AlertsInfo struct {
+	// Rules lists the alert rules, i.e. 'errors_per_min > 10'. Empty disables alerting.
+	Rules []string
+	// CheckInterval is how often the rules are evaluated, i.e. '30s'. Defaults to one minute.
+	CheckInterval string
+	// Notify sends a notification through Support Notifications when an alert fires or resolves
+	Notify bool
+	// MQTTBroker is the broker to which the alerts are published as JSON status messages, i.e. 'tcp://localhost:1883'
+	MQTTBroker string
+	MQTTTopic  string
+}
+
+// ExportWebhooksInfo configures the status messages sent when an export function starts failing and when it recovers
+type ExportWebhooksInfo struct {
+	// URLs lists the webhooks to which the status messages are posted as JSON
+	URLs []string
+	// FailureThreshold is the number of consecutive failed exports after which an export function is reported as
+	// failing. Defaults to 3.
+	FailureThreshold int
+	// MQTTBroker is the broker to which the status messages are published, i.e. 'tcp://localhost:1883'
+	MQTTBroker string
+	MQTTTopic  string
+}
+
+// ExportManifestInfo configures the retention of the exported events which downstream systems reconcile with the
+// events they received
+type ExportManifestInfo struct {
+	// Capacity is the number of exported events retained, along with their data so the events a downstream system
+	// didn't receive can be exported again. Zero disables the manifest.
+	Capacity int
+}
+
+// ExportDeadLettersInfo configures where the data of the export functions which fail is stored, once they give up
+// retrying, so it is not dropped
+type ExportDeadLettersInfo struct {
+	// File is the file the dead letters are appended to as lines of JSON
+	File string
+	// URL is the endpoint to which the dead letters are posted as JSON
+	URL string
+	// MQTTBroker is the broker to which the dead letters are published as JSON, i.e. 'tcp://localhost:1883'
+	MQTTBroker string
+	MQTTTopic  string
+}
+
+// StoreAndForwardInfo configures the storing of the data of the export functions which fail, so it's exported again
+// periodically until it succeeds, including after the service restarts
+type StoreAndForwardInfo struct {
+	Enabled bool
+	// Type is the store the data is stored in, 'bolt' for a BoltDB file or 'redis' for a Redis server. Defaults to
+	// 'bolt'.
+	Type string
+	// Path is the BoltDB file the data is stored in. Defaults to 'store-and-forward.db'.
+	Path string
+	// Address and Database locate the Redis server the data is stored in, i.e. 'localhost:6379'
+	Address  string
+	Database int
+	// SecretPath is the path of the secrets in the SecretStore holding the password of the Redis server, when it
+	// requires one
+	SecretPath string
+	// Key is the prefix of the Redis keys the data is stored under. Defaults to 'store-and-forward'.
+	Key string
+	// RetryInterval is how often the stored data is exported again, i.e. '1m'. Defaults to one minute.
+	RetryInterval string
+	// MaxRetryCount is the number of times the data is exported again before it's moved to the dead letters. Zero
+	// retries until the data is evicted.
+	MaxRetryCount int
+	// MaxObjects is the number of failed exports stored, beyond which the oldest are moved to the dead letters. Zero
+	// is unlimited.
+	MaxObjects int
+	// MaxSize is the total size in bytes of the data stored, beyond which the oldest is moved to the dead letters.
+	// Zero is unlimited.
+	MaxSize int64
+	// TTL is how long the data is stored before it's moved to the dead letters, i.e. '24h'. Empty stores it until
+	// it's exported or given up on.
+	TTL string
+}
+
+// DeviceEventsInfo configures how the device lifecycle events for the pipeline set by SetDeviceEventsPipeline are
+// detected
+type DeviceEventsInfo struct {
+	// PollInterval is how often core-metadata is polled for added, updated and removed devices, i.e. '10s'. Defaults
+	// to 30 seconds.
+	PollInterval string
+}
+
+// TracingInfo configures the export of trace spans to an OpenTelemetry collector using OTLP over HTTP
+type TracingInfo struct {
+	// Endpoint is the base URL of the collector's OTLP/HTTP receiver, i.e. 'http://localhost:4318'. Empty disables
+	// tracing.
+	Endpoint string
+	// ServiceName is reported as the service.name of the spans. Defaults to the service key.
+	ServiceName string
+	// BatchSize is the number of spans exported together. Defaults to 512.
+	BatchSize int
+	// FlushInterval is how often queued spans are exported, i.e. '5s'. Defaults to five seconds.
+	FlushInterval string
+}
+
+// SecretStoreInfo configures the secret store from which secrets are retrieved with GetSecret
+type SecretStoreInfo struct {
+	// Type is "vault" for HashiCorp Vault or "file" for a JSON file. Empty disables the secret store.
+	Type     string
+	Protocol string
+	Host     string
+	Port     int
+	// Path is prepended to the path of each secret retrieved from Vault, i.e. "secret/edgex/app-service/"
+	Path string
+	// TokenFile is the file holding the token used to authenticate with Vault
+	TokenFile string
+	// File is the JSON file holding the secrets for the file secret store
+	File string
 }
@@ -17,14 +17,18 @@
 package common
 
 import (
+	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/antoniomtz/app-functions-sdk-go/internal"
 
 	"github.com/BurntSushi/toml"
+	"github.com/edgexfoundry/go-mod-registry/registry"
+	"gopkg.in/yaml.v2"
 )
 
 const (
@@ -32,20 +36,26 @@ const (
 	configDirEnv    = "EDGEX_CONF_DIR"
 )
 
-// LoadFromFile loads .toml file for configuration
+// configFileExtensions are the supported formats of the configuration file, in order of precedence
+var configFileExtensions = []string{".toml", ".yaml", ".yml", ".json"}
+
+// LoadFromFile loads the configuration file, which is in TOML, YAML or JSON format as determined by its extension
 func LoadFromFile(profile string, configDir string, configuration interface{}) error {
-	path := determinePath(configDir)
-	fileName := path + "/" + internal.ConfigFileName //default profile
-	if len(profile) > 0 {
-		fileName = path + "/" + profile + "/" + internal.ConfigFileName
-	}
+	fileName := ConfigFilePath(profile, configDir)
 	contents, err := ioutil.ReadFile(fileName)
 	if err != nil {
 		return fmt.Errorf("could not load configuration file (%s): %v", fileName, err.Error())
 	}
 
-	// Decode the configuration from TOML
-	err = toml.Unmarshal(contents, configuration)
+	switch filepath.Ext(fileName) {
+	case ".yaml", ".yml":
+		err = unmarshalYAML(contents, configuration)
+	case ".json":
+		err = json.Unmarshal(contents, configuration)
+	default:
+		// Decode the configuration from TOML
+		err = toml.Unmarshal(contents, configuration)
+	}
 	if err != nil {
 		return fmt.Errorf("unable to parse configuration file (%s): %v", fileName, err.Error())
 	}
@@ -53,6 +63,75 @@ func LoadFromFile(profile string, configDir string, configuration interface{}) e
 	return nil
 }
 
+// ConfigFilePath returns the path of the configuration file for the profile. The file named configuration with
+// the first of the supported extensions found is used, defaulting to configuration.toml.
+func ConfigFilePath(profile string, configDir string) string {
+	path := determinePath(configDir)
+	if len(profile) > 0 {
+		path = path + "/" + profile
+	}
+
+	baseName := strings.TrimSuffix(internal.ConfigFileName, filepath.Ext(internal.ConfigFileName))
+	for _, extension := range configFileExtensions {
+		fileName := path + "/" + baseName + extension
+		if _, err := os.Stat(fileName); err == nil {
+			return fileName
+		}
+	}
+	return path + "/" + internal.ConfigFileName //default format
+}
+
+// unmarshalYAML decodes YAML by way of JSON, so the keys match the field names of the configuration the same way
+// as those of TOML and JSON configuration files, without needing yaml tags
+func unmarshalYAML(contents []byte, configuration interface{}) error {
+	var value interface{}
+	if err := yaml.Unmarshal(contents, &value); err != nil {
+		return err
+	}
+
+	value, err := yamlToJSON(value)
+	if err != nil {
+		return err
+	}
+	contents, err = json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(contents, configuration)
+}
+
+// yamlToJSON converts the maps decoded from YAML, which have interface{} keys, into maps with string keys
+func yamlToJSON(value interface{}) (interface{}, error) {
+	switch typed := value.(type) {
+	case map[interface{}]interface{}:
+		converted := make(map[string]interface{}, len(typed))
+		for key, item := range typed {
+			keyString, ok := key.(string)
+			if !ok {
+				keyString = fmt.Sprint(key)
+			}
+			convertedItem, err := yamlToJSON(item)
+			if err != nil {
+				return nil, err
+			}
+			converted[keyString] = convertedItem
+		}
+		return converted, nil
+	case []interface{}:
+		converted := make([]interface{}, len(typed))
+		for index, item := range typed {
+			convertedItem, err := yamlToJSON(item)
+			if err != nil {
+				return nil, err
+			}
+			converted[index] = convertedItem
+		}
+		return converted, nil
+	default:
+		return value, nil
+	}
+}
+
 func determinePath(configDir string) string {
 	path := configDir
 
@@ -95,3 +174,37 @@ func VerifyTomlFiles(configuration interface{}, configDir string) error {
 	}
 	return nil
 }
+
+// LoadFromRegistry returns the configuration held in the registry. If the registry doesn't hold the service's
+// configuration yet, or overwrite is set, the local configuration is pushed into the registry and returned.
+func LoadFromRegistry(client registry.Client, local ConfigurationStruct, overwrite bool) (*ConfigurationStruct, error) {
+	hasConfig, err := client.HasConfiguration()
+	if err != nil {
+		return nil, fmt.Errorf("could not determine if registry has configuration: %v", err)
+	}
+
+	if !hasConfig || overwrite {
+		if err := client.PutConfiguration(local, true); err != nil {
+			return nil, fmt.Errorf("could not push configuration into registry: %v", err)
+		}
+		fmt.Println("Configuration pushed to registry")
+		return &local, nil
+	}
+
+	rawConfig, err := client.GetConfiguration(&ConfigurationStruct{})
+	if err != nil {
+		return nil, fmt.Errorf("could not get configuration from Registry: %v", err)
+	}
+
+	actual, ok := rawConfig.(*ConfigurationStruct)
+	if !ok {
+		return nil, fmt.Errorf("configuration from Registry failed type check")
+	}
+	//Check that information was successfully read from the registry
+	if actual.Service.Port == 0 {
+		return nil, fmt.Errorf("configuration from Registry is missing the service port")
+	}
+
+	fmt.Println("Configuration loaded from registry")
+	return actual, nil
+}
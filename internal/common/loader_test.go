@@ -0,0 +1,144 @@
+//
+// Copyright (c) 2019 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package common
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/edgexfoundry/go-mod-registry/registry"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeRegistry is a registry.Client holding a single configuration
+type fakeRegistry struct {
+	registry.Client
+	config *ConfigurationStruct
+	err    error
+}
+
+func (r *fakeRegistry) HasConfiguration() (bool, error) {
+	return r.config != nil, r.err
+}
+
+func (r *fakeRegistry) PutConfiguration(configStruct interface{}, overwrite bool) error {
+	config := configStruct.(ConfigurationStruct)
+	r.config = &config
+	return nil
+}
+
+func (r *fakeRegistry) GetConfiguration(configStruct interface{}) (interface{}, error) {
+	config := *r.config
+	return &config, nil
+}
+
+func TestLoadFromRegistryPushesLocalConfiguration(t *testing.T) {
+	client := &fakeRegistry{}
+	local := ConfigurationStruct{Service: ServiceInfo{Host: "localhost", Port: 48095}}
+
+	actual, err := LoadFromRegistry(client, local, false)
+
+	require.NoError(t, err)
+	assert.Equal(t, local, *actual)
+	require.NotNil(t, client.config, "Local configuration should have been pushed to the registry")
+	assert.Equal(t, 48095, client.config.Service.Port)
+}
+
+func TestLoadFromRegistryUsesRegistryConfiguration(t *testing.T) {
+	client := &fakeRegistry{config: &ConfigurationStruct{Service: ServiceInfo{Host: "app-service", Port: 48100}}}
+	local := ConfigurationStruct{Service: ServiceInfo{Host: "localhost", Port: 48095}}
+
+	actual, err := LoadFromRegistry(client, local, false)
+
+	require.NoError(t, err)
+	assert.Equal(t, "app-service", actual.Service.Host)
+	assert.Equal(t, 48100, actual.Service.Port)
+}
+
+func TestLoadFromRegistryOverwrite(t *testing.T) {
+	client := &fakeRegistry{config: &ConfigurationStruct{Service: ServiceInfo{Host: "app-service", Port: 48100}}}
+	local := ConfigurationStruct{Service: ServiceInfo{Host: "localhost", Port: 48095}}
+
+	actual, err := LoadFromRegistry(client, local, true)
+
+	require.NoError(t, err)
+	assert.Equal(t, local, *actual)
+	assert.Equal(t, 48095, client.config.Service.Port, "Registry configuration should have been overwritten")
+}
+
+func TestLoadFromRegistryMissingPort(t *testing.T) {
+	client := &fakeRegistry{config: &ConfigurationStruct{}}
+
+	_, err := LoadFromRegistry(client, ConfigurationStruct{}, false)
+
+	assert.Error(t, err)
+}
+
+func TestLoadFromRegistryUnavailable(t *testing.T) {
+	client := &fakeRegistry{err: errors.New("connection refused")}
+
+	_, err := LoadFromRegistry(client, ConfigurationStruct{}, false)
+
+	assert.Error(t, err)
+}
+
+func TestLoadFromFileFormats(t *testing.T) {
+	tests := []struct {
+		name     string
+		fileName string
+		contents string
+	}{
+		{"TOML", "configuration.toml", "[Writable]\nLogLevel = 'DEBUG'\n[Service]\nPort = 48095\n[ApplicationSettings]\nDeviceNames = 'Random-Float-Device'\n"},
+		{"YAML", "configuration.yaml", "Writable:\n  LogLevel: DEBUG\nService:\n  Port: 48095\nApplicationSettings:\n  DeviceNames: Random-Float-Device\n"},
+		{"YML", "configuration.yml", "Writable:\n  LogLevel: DEBUG\nService:\n  Port: 48095\nApplicationSettings:\n  DeviceNames: Random-Float-Device\n"},
+		{"JSON", "configuration.json", `{"Writable": {"LogLevel": "DEBUG"}, "Service": {"Port": 48095}, "ApplicationSettings": {"DeviceNames": "Random-Float-Device"}}`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dir, err := ioutil.TempDir("", "config")
+			require.NoError(t, err)
+			defer os.RemoveAll(dir)
+			require.NoError(t, ioutil.WriteFile(filepath.Join(dir, test.fileName), []byte(test.contents), 0644))
+
+			assert.Equal(t, dir+"/"+test.fileName, ConfigFilePath("", dir))
+
+			configuration := ConfigurationStruct{}
+			require.NoError(t, LoadFromFile("", dir, &configuration))
+			assert.Equal(t, "DEBUG", configuration.Writable.LogLevel)
+			assert.Equal(t, 48095, configuration.Service.Port)
+			assert.Equal(t, "Random-Float-Device", configuration.ApplicationSettings["DeviceNames"])
+		})
+	}
+}
+
+func TestConfigFilePathPrecedence(t *testing.T) {
+	dir, err := ioutil.TempDir("", "config")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	assert.Equal(t, dir+"/configuration.toml", ConfigFilePath("", dir), "TOML should be the default")
+
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "docker"), 0755))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "docker", "configuration.json"), []byte("{}"), 0644))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "docker", "configuration.yaml"), []byte(""), 0644))
+	assert.Equal(t, dir+"/docker/configuration.yaml", ConfigFilePath("docker", dir))
+}
@@ -0,0 +1,44 @@
+//
+// Copyright (c) 2019 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package common
+
+import "sync"
+
+// WritableStore holds the Writable configuration, which can be changed while the service is running
+type WritableStore struct {
+	mutex    sync.RWMutex
+	writable WritableInfo
+}
+
+// NewWritableStore creates a store holding the initial Writable configuration
+func NewWritableStore(writable WritableInfo) *WritableStore {
+	return &WritableStore{writable: writable}
+}
+
+// Get returns the current Writable configuration
+func (store *WritableStore) Get() WritableInfo {
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+	return store.writable
+}
+
+// Set replaces the Writable configuration
+func (store *WritableStore) Set(writable WritableInfo) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	store.writable = writable
+}
@@ -22,6 +22,36 @@ const (
 	ConfigFileName       = "configuration.toml"
 	ConfigRegistryStem   = "edgex/appfunctions/1.0/"
 	WritableKey          = "/Writable"
+	FileWatchInterval    = 5000
 	ApiPingRoute         = "/api/v1/ping"
+	ApiVersionRoute      = "/api/v1/version"
+	ApiHealthRoute       = "/api/v1/health"
+	ApiPipelineMetrics   = "/api/v1/metrics/pipelines"
+	ApiStageMetrics      = "/api/v1/metrics/stages"
+	ApiFunctionMetrics   = "/api/v1/metrics/functions"
+	ApiCacheMetrics      = "/api/v1/metrics/caches"
+	ApiLimitMetrics      = "/api/v1/metrics/concurrency"
+	ApiNamedMetrics      = "/api/v1/metrics/{name}"
+	ApiErrorLogRoute     = "/api/v1/errors"
+	ApiPipelineStatus    = "/api/v1/pipeline/status"
+	ApiPipelinePause     = "/api/v1/pipeline/pause"
+	ApiPipelineResume    = "/api/v1/pipeline/resume"
+	ApiTriggerStatus     = "/api/v1/trigger/status"
+	ApiExportManifest    = "/api/v1/exports/manifest"
+	ApiExportReconcile   = "/api/v1/exports/reconcile"
+	ApiStoredExports     = "/api/v1/exports/stored"
+	ApiStoredItems       = "/api/v1/exports/stored/items"
+	ApiStoredRetry       = "/api/v1/exports/stored/retry"
 	LogDurationKey       = "duration"
+	ReplayHeader         = "X-Replay"
+	SignatureHeader      = "X-Signature"
+	TraceParentHeader    = "traceparent"
 )
+
+// SDKVersion is the version of the SDK, set when building with
+// -ldflags "-X github.com/antoniomtz/app-functions-sdk-go/internal.SDKVersion=1.0.0"
+var SDKVersion = "0.0.0"
+
+// ApplicationVersion is the version of the application service built with the SDK, set when building with
+// -ldflags "-X github.com/antoniomtz/app-functions-sdk-go/internal.ApplicationVersion=1.0.0"
+var ApplicationVersion = "0.0.0"
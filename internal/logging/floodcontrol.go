@@ -0,0 +1,107 @@
+//
+// Copyright (c) 2019 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package logging
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/clients/logger"
+)
+
+const (
+	levelError = "ERROR"
+	levelWarn  = "WARN"
+)
+
+// FloodControlClient wraps a LoggingClient so that repeated identical error and warning messages, such as those
+// logged for every event while an export destination is down, are collapsed. The first occurrence of a message is
+// logged, further occurrences within the interval are counted, and a summary with the count is logged when the
+// interval ends. Messages are compared without their arguments, which typically hold per event values such as the
+// correlation ID.
+type FloodControlClient struct {
+	logger.LoggingClient
+	interval time.Duration
+	mutex    sync.Mutex
+	entries  map[string]*floodEntry
+}
+
+type floodEntry struct {
+	level      string
+	msg        string
+	suppressed int
+}
+
+// NewFloodControlClient wraps client, collapsing repeated messages within each interval
+func NewFloodControlClient(client logger.LoggingClient, interval time.Duration) *FloodControlClient {
+	return &FloodControlClient{
+		LoggingClient: client,
+		interval:      interval,
+		entries:       map[string]*floodEntry{},
+	}
+}
+
+// Error logs a message at the ERROR severity level unless it is a repeat within the interval
+func (c *FloodControlClient) Error(msg string, args ...interface{}) {
+	if c.allow(levelError, msg) {
+		c.LoggingClient.Error(msg, args...)
+	}
+}
+
+// Warn logs a message at the WARN severity level unless it is a repeat within the interval
+func (c *FloodControlClient) Warn(msg string, args ...interface{}) {
+	if c.allow(levelWarn, msg) {
+		c.LoggingClient.Warn(msg, args...)
+	}
+}
+
+// allow reports whether the message should be logged, counting it if it is suppressed
+func (c *FloodControlClient) allow(level string, msg string) bool {
+	key := level + "|" + msg
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if entry, exists := c.entries[key]; exists {
+		entry.suppressed++
+		return false
+	}
+
+	c.entries[key] = &floodEntry{level: level, msg: msg}
+	time.AfterFunc(c.interval, func() { c.flush(key) })
+	return true
+}
+
+// flush ends the interval of a message, logging a summary if any repeats were suppressed
+func (c *FloodControlClient) flush(key string) {
+	c.mutex.Lock()
+	entry := c.entries[key]
+	delete(c.entries, key)
+	c.mutex.Unlock()
+
+	if entry == nil || entry.suppressed == 0 {
+		return
+	}
+
+	summary := fmt.Sprintf("Suppressed %d repeats in the last %s of: %s", entry.suppressed, c.interval, entry.msg)
+	if entry.level == levelError {
+		c.LoggingClient.Error(summary)
+	} else {
+		c.LoggingClient.Warn(summary)
+	}
+}
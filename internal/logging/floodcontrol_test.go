@@ -0,0 +1,78 @@
+//
+// Copyright (c) 2019 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package logging
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type recordingClient struct {
+	mutex    sync.Mutex
+	messages []string
+}
+
+func (r *recordingClient) record(level string, msg string) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.messages = append(r.messages, level+": "+msg)
+}
+
+func (r *recordingClient) recorded() []string {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return append([]string{}, r.messages...)
+}
+
+func (r *recordingClient) SetLogLevel(logLevel string) error     { return nil }
+func (r *recordingClient) Debug(msg string, args ...interface{}) { r.record("DEBUG", msg) }
+func (r *recordingClient) Error(msg string, args ...interface{}) { r.record(levelError, msg) }
+func (r *recordingClient) Info(msg string, args ...interface{})  { r.record("INFO", msg) }
+func (r *recordingClient) Trace(msg string, args ...interface{}) { r.record("TRACE", msg) }
+func (r *recordingClient) Warn(msg string, args ...interface{})  { r.record(levelWarn, msg) }
+
+func TestFloodControlCollapsesRepeats(t *testing.T) {
+	recorder := &recordingClient{}
+	client := NewFloodControlClient(recorder, 50*time.Millisecond)
+
+	for i := 0; i < 100; i++ {
+		client.Error("Could not connect to mqtt server", "correlation-id", i)
+	}
+	client.Warn("Slow response")
+	client.Info("Not subject to flood control")
+	client.Info("Not subject to flood control")
+
+	assert.Equal(t, []string{
+		"ERROR: Could not connect to mqtt server",
+		"WARN: Slow response",
+		"INFO: Not subject to flood control",
+		"INFO: Not subject to flood control",
+	}, recorder.recorded())
+
+	time.Sleep(200 * time.Millisecond)
+
+	messages := recorder.recorded()
+	require.Equal(t, 5, len(messages), "Expected a single summary for the repeated error")
+	assert.Equal(t, "ERROR: Suppressed 99 repeats in the last 50ms of: Could not connect to mqtt server", messages[4])
+
+	client.Error("Could not connect to mqtt server")
+	assert.Equal(t, 6, len(recorder.recorded()), "Message should be logged again once the interval ends")
+}
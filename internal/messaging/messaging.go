@@ -0,0 +1,215 @@
+//
+// Copyright (c) 2019 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+// Package messaging provides the MQTT and Redis Streams implementations of the EdgeX message bus, alongside the ZeroMQ
+// implementation of go-mod-messaging, selected by MessageBus.Type
+package messaging
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/antoniomtz/app-functions-sdk-go/pkg/secrets"
+	"github.com/antoniomtz/go-mod-messaging/messaging"
+	"github.com/antoniomtz/go-mod-messaging/pkg/types"
+)
+
+// Message bus types implemented by this package
+const (
+	// MQTT publishes and subscribes to the topics of an MQTT broker
+	MQTT = "mqtt"
+	// RedisStreams adds messages to and reads them from Redis Streams named by the topics
+	RedisStreams = "redisstreams"
+)
+
+// Keys of MessageBus.Optional
+const (
+	ClientIDKey       = "ClientId"
+	QosKey            = "Qos"
+	KeepAliveKey      = "KeepAlive"
+	RetainedKey       = "Retained"
+	AutoReconnectKey  = "AutoReconnect"
+	ConnectTimeoutKey = "ConnectTimeout"
+	CleanSessionKey   = "CleanSession"
+	SkipVerifyKey     = "SkipCertVerify"
+	MaxLenKey         = "MaxLen"
+	ConsumerGroupKey  = "ConsumerGroup"
+	AuthModeKey       = "AuthMode"
+	SecretPathKey     = "SecretPath"
+	UsernameKey       = "Username"
+	PasswordKey       = "Password"
+)
+
+// Authentication modes of MessageBus.Optional.AuthMode, whose credentials are read from the secrets at
+// MessageBus.Optional.SecretPath
+const (
+	// AuthModeNone uses the Username and Password of MessageBus.Optional, if any
+	AuthModeNone = "none"
+	// AuthModeUsernamePassword uses the username and password secrets
+	AuthModeUsernamePassword = "usernamepassword"
+	// AuthModeClientCert uses the clientcert and clientkey secrets, along with the cacert secret when present
+	AuthModeClientCert = "clientcert"
+	// AuthModeCACert uses the cacert secret to verify the server
+	AuthModeCACert = "cacert"
+)
+
+// defaultClientID is the MQTT client ID prefix and Redis consumer name unless MessageBus.Optional.ClientId is set
+const defaultClientID = "app-functions-sdk"
+
+// Secret keys read for the authentication modes
+const (
+	usernameSecret   = "username"
+	passwordSecret   = "password"
+	clientCertSecret = "clientcert"
+	clientKeySecret  = "clientkey"
+	caCertSecret     = "cacert"
+)
+
+// Acknowledger is implemented by the clients which deliver the next message of a topic only once the last one is
+// acknowledged, keeping the messages which aren't so that they are delivered again, even after a restart
+type Acknowledger interface {
+	// Ack acknowledges the last message delivered for the topic. The message is consumed when processed, and
+	// delivered again otherwise.
+	Ack(topic string, processed bool) error
+}
+
+// NewMessageClient creates the client of the message bus of the configured MessageBus.Type, i.e. 'mqtt',
+// 'redisstreams' or 'zero'. The secret provider, which may be nil, provides the credentials of the AuthMode.
+func NewMessageClient(config types.MessageBusConfig, secretProvider secrets.SecretProvider) (messaging.MessageClient, error) {
+	switch strings.ToLower(config.Type) {
+	case MQTT:
+		auth, err := authenticate(config, secretProvider)
+		if err != nil {
+			return nil, err
+		}
+		return newMQTTClient(config, auth)
+	case RedisStreams:
+		auth, err := authenticate(config, secretProvider)
+		if err != nil {
+			return nil, err
+		}
+		client, err := newRedisStreamsClient(config, auth)
+		if err != nil {
+			return nil, err
+		}
+		if client.group != "" {
+			return &redisStreamsGroupClient{client}, nil
+		}
+		return client, nil
+	default:
+		return messaging.NewMessageClient(config)
+	}
+}
+
+// authentication holds the credentials of the message bus
+type authentication struct {
+	username  string
+	password  string
+	tlsConfig *tls.Config
+}
+
+// authenticate returns the credentials of the configured AuthMode
+func authenticate(config types.MessageBusConfig, secretProvider secrets.SecretProvider) (authentication, error) {
+	auth := authentication{username: config.Optional[UsernameKey], password: config.Optional[PasswordKey]}
+	mode := strings.ToLower(config.Optional[AuthModeKey])
+	if mode == "" || mode == AuthModeNone {
+		return auth, nil
+	}
+
+	path := config.Optional[SecretPathKey]
+	if secretProvider == nil || path == "" {
+		return auth, fmt.Errorf("message bus AuthMode '%s' requires the SecretStore and the SecretPath to be configured", mode)
+	}
+	secretValues, err := secretProvider.GetSecrets(path)
+	if err != nil {
+		return auth, fmt.Errorf("unable to read the message bus secrets at '%s': %v", path, err)
+	}
+	required := func(keys ...string) error {
+		var missing []string
+		for _, key := range keys {
+			if secretValues[key] == "" {
+				missing = append(missing, key)
+			}
+		}
+		if len(missing) > 0 {
+			return fmt.Errorf("message bus secrets not found at path '%s': %s", path, strings.Join(missing, ", "))
+		}
+		return nil
+	}
+
+	switch mode {
+	case AuthModeUsernamePassword:
+		if err := required(usernameSecret, passwordSecret); err != nil {
+			return auth, err
+		}
+		auth.username, auth.password = secretValues[usernameSecret], secretValues[passwordSecret]
+	case AuthModeClientCert:
+		if err := required(clientCertSecret, clientKeySecret); err != nil {
+			return auth, err
+		}
+		certificate, err := tls.X509KeyPair([]byte(secretValues[clientCertSecret]), []byte(secretValues[clientKeySecret]))
+		if err != nil {
+			return auth, fmt.Errorf("invalid message bus client certificate: %v", err)
+		}
+		auth.tlsConfig = &tls.Config{Certificates: []tls.Certificate{certificate}}
+	case AuthModeCACert:
+		if err := required(caCertSecret); err != nil {
+			return auth, err
+		}
+		auth.tlsConfig = &tls.Config{}
+	default:
+		return auth, fmt.Errorf("message bus AuthMode '%s' not supported, use '%s', '%s', '%s' or '%s'", mode, AuthModeNone, AuthModeUsernamePassword, AuthModeClientCert, AuthModeCACert)
+	}
+
+	if caCert := secretValues[caCertSecret]; caCert != "" {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM([]byte(caCert)) {
+			return auth, errors.New("invalid message bus CA certificate")
+		}
+		auth.tlsConfig.RootCAs = pool
+	}
+	return auth, nil
+}
+
+// optionalInt returns the integer of MessageBus.Optional under the key, or the default when it's not set
+func optionalInt(config types.MessageBusConfig, key string, defaultValue int) (int, error) {
+	value, ok := config.Optional[key]
+	if !ok || value == "" {
+		return defaultValue, nil
+	}
+	parsed, err := strconv.Atoi(value)
+	if err != nil {
+		return defaultValue, fmt.Errorf("message bus %s must be an integer, got '%s'", key, value)
+	}
+	return parsed, nil
+}
+
+// optionalBool returns the boolean of MessageBus.Optional under the key, or the default when it's not set
+func optionalBool(config types.MessageBusConfig, key string, defaultValue bool) (bool, error) {
+	value, ok := config.Optional[key]
+	if !ok || value == "" {
+		return defaultValue, nil
+	}
+	parsed, err := strconv.ParseBool(value)
+	if err != nil {
+		return defaultValue, fmt.Errorf("message bus %s must be true or false, got '%s'", key, value)
+	}
+	return parsed, nil
+}
@@ -0,0 +1,140 @@
+//
+// Copyright (c) 2019 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package messaging
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/antoniomtz/go-mod-messaging/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeSecretProvider provides the secrets of its paths
+type fakeSecretProvider map[string]map[string]string
+
+func (provider fakeSecretProvider) GetSecrets(path string, keys ...string) (map[string]string, error) {
+	secrets, ok := provider[path]
+	if !ok {
+		return nil, fmt.Errorf("no secrets at path '%s'", path)
+	}
+	return secrets, nil
+}
+
+// newKeyPair creates a self-signed certificate along with its key, both PEM encoded
+func newKeyPair(t *testing.T) (string, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "messagebus"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})), string(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}))
+}
+
+func TestNewMessageClient(t *testing.T) {
+	host := types.HostInfo{Host: "localhost", Port: 1883, Protocol: "tcp"}
+
+	client, err := NewMessageClient(types.MessageBusConfig{Type: "MQTT", PublishHost: host, SubscribeHost: host}, nil)
+	require.NoError(t, err)
+	assert.IsType(t, &mqttClient{}, client)
+
+	client, err = NewMessageClient(types.MessageBusConfig{Type: RedisStreams, PublishHost: host, SubscribeHost: host}, nil)
+	require.NoError(t, err)
+	assert.IsType(t, &redisStreamsClient{}, client)
+
+	_, err = NewMessageClient(types.MessageBusConfig{Type: "zero", PublishHost: host, SubscribeHost: host}, nil)
+	assert.NoError(t, err)
+
+	_, err = NewMessageClient(types.MessageBusConfig{Type: "kafka", PublishHost: host, SubscribeHost: host}, nil)
+	assert.Error(t, err, "Unsupported types should fail")
+
+	_, err = NewMessageClient(types.MessageBusConfig{Type: MQTT, Optional: map[string]string{QosKey: "3"}}, nil)
+	assert.Error(t, err, "Invalid QoS should fail")
+
+	_, err = NewMessageClient(types.MessageBusConfig{Type: RedisStreams, Optional: map[string]string{MaxLenKey: "many"}}, nil)
+	assert.Error(t, err, "Invalid MaxLen should fail")
+}
+
+func TestAuthenticate(t *testing.T) {
+	certPEM, keyPEM := newKeyPair(t)
+	provider := fakeSecretProvider{
+		"bus":   {"username": "edgex", "password": "secret"},
+		"certs": {"clientcert": certPEM, "clientkey": keyPEM, "cacert": certPEM},
+		"ca":    {"cacert": certPEM},
+		"bad":   {"cacert": "not a certificate"},
+	}
+	configWith := func(optional map[string]string) types.MessageBusConfig {
+		return types.MessageBusConfig{Type: MQTT, Optional: optional}
+	}
+
+	auth, err := authenticate(configWith(map[string]string{UsernameKey: "user", PasswordKey: "pass"}), nil)
+	require.NoError(t, err)
+	assert.Equal(t, "user", auth.username)
+	assert.Equal(t, "pass", auth.password)
+	assert.Nil(t, auth.tlsConfig)
+
+	auth, err = authenticate(configWith(map[string]string{AuthModeKey: "UsernamePassword", SecretPathKey: "bus"}), provider)
+	require.NoError(t, err)
+	assert.Equal(t, "edgex", auth.username)
+	assert.Equal(t, "secret", auth.password)
+
+	auth, err = authenticate(configWith(map[string]string{AuthModeKey: AuthModeClientCert, SecretPathKey: "certs"}), provider)
+	require.NoError(t, err)
+	require.NotNil(t, auth.tlsConfig)
+	assert.Len(t, auth.tlsConfig.Certificates, 1)
+	assert.NotNil(t, auth.tlsConfig.RootCAs)
+
+	auth, err = authenticate(configWith(map[string]string{AuthModeKey: AuthModeCACert, SecretPathKey: "ca"}), provider)
+	require.NoError(t, err)
+	require.NotNil(t, auth.tlsConfig)
+	assert.Empty(t, auth.tlsConfig.Certificates)
+	assert.NotNil(t, auth.tlsConfig.RootCAs)
+
+	failures := []map[string]string{
+		{AuthModeKey: AuthModeUsernamePassword, SecretPathKey: "missing"},
+		{AuthModeKey: AuthModeUsernamePassword, SecretPathKey: "ca"},
+		{AuthModeKey: AuthModeUsernamePassword},
+		{AuthModeKey: AuthModeClientCert, SecretPathKey: "bus"},
+		{AuthModeKey: AuthModeCACert, SecretPathKey: "bad"},
+		{AuthModeKey: "token", SecretPathKey: "bus"},
+	}
+	for _, optional := range failures {
+		_, err := authenticate(configWith(optional), provider)
+		assert.Error(t, err, "%v should fail", optional)
+	}
+
+	_, err = authenticate(configWith(map[string]string{AuthModeKey: AuthModeUsernamePassword, SecretPathKey: "bus"}), nil)
+	assert.Error(t, err, "AuthMode without a secret provider should fail")
+}
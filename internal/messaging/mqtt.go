@@ -0,0 +1,306 @@
+//
+// Copyright (c) 2019 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package messaging
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/antoniomtz/go-mod-messaging/pkg/types"
+	paho "github.com/eclipse/paho.mqtt.golang"
+)
+
+const (
+	defaultMQTTKeepAlive      = 30
+	defaultMQTTConnectTimeout = 10
+	// mqttQuiesce is how long, in milliseconds, disconnecting waits for the work in progress to complete
+	mqttQuiesce = 250
+	// mqttWildcard is the topic subscribed to for an empty topic, which receives the messages of all topics as
+	// subscribing to an empty topic on ZeroMQ does
+	mqttWildcard = "#"
+)
+
+// newPahoClient creates the paho clients, and is replaced by the tests
+var newPahoClient = paho.NewClient
+
+// mqttClientCount numbers the clients, so that the clients of the same configuration connect with distinct client
+// IDs rather than taking over each other's connection
+var mqttClientCount uint32
+
+// mqttClient is the message bus client of an MQTT broker, publishing to PublishHost and subscribing to SubscribeHost
+// on separate connections, each established on first use
+type mqttClient struct {
+	config         types.MessageBusConfig
+	auth           authentication
+	clientID       string
+	qos            byte
+	retained       bool
+	keepAlive      time.Duration
+	connectTimeout time.Duration
+	autoReconnect  bool
+	cleanSession   bool
+	skipVerify     bool
+
+	// connectionMutex guards the connections to the broker
+	connectionMutex sync.Mutex
+	publisher       paho.Client
+	subscriber      paho.Client
+
+	// mutex guards the topic channels and messageErrors, which are closed on Disconnect while the handlers of paho
+	// may still be delivering messages
+	mutex         sync.RWMutex
+	topics        []types.TopicChannel
+	messageErrors chan error
+	closed        bool
+	quit          chan struct{}
+	quitOnce      sync.Once
+}
+
+func newMQTTClient(config types.MessageBusConfig, auth authentication) (*mqttClient, error) {
+	qos, err := optionalInt(config, QosKey, 0)
+	if err != nil {
+		return nil, err
+	}
+	if qos < 0 || qos > 2 {
+		return nil, fmt.Errorf("message bus %s must be 0, 1 or 2, got %d", QosKey, qos)
+	}
+	keepAlive, err := optionalInt(config, KeepAliveKey, defaultMQTTKeepAlive)
+	if err != nil {
+		return nil, err
+	}
+	connectTimeout, err := optionalInt(config, ConnectTimeoutKey, defaultMQTTConnectTimeout)
+	if err != nil {
+		return nil, err
+	}
+	retained, err := optionalBool(config, RetainedKey, false)
+	if err != nil {
+		return nil, err
+	}
+	autoReconnect, err := optionalBool(config, AutoReconnectKey, true)
+	if err != nil {
+		return nil, err
+	}
+	cleanSession, err := optionalBool(config, CleanSessionKey, true)
+	if err != nil {
+		return nil, err
+	}
+	skipVerify, err := optionalBool(config, SkipVerifyKey, false)
+	if err != nil {
+		return nil, err
+	}
+
+	clientID := config.Optional[ClientIDKey]
+	if clientID == "" {
+		clientID = defaultClientID
+	}
+	return &mqttClient{
+		config:         config,
+		auth:           auth,
+		clientID:       fmt.Sprintf("%s-%d", clientID, atomic.AddUint32(&mqttClientCount, 1)),
+		qos:            byte(qos),
+		retained:       retained,
+		keepAlive:      time.Duration(keepAlive) * time.Second,
+		connectTimeout: time.Duration(connectTimeout) * time.Second,
+		autoReconnect:  autoReconnect,
+		cleanSession:   cleanSession,
+		skipVerify:     skipVerify,
+		quit:           make(chan struct{}),
+	}, nil
+}
+
+// Connect does nothing, the connections to the broker are made on the first Publish and Subscribe respectively
+func (client *mqttClient) Connect() error {
+	return nil
+}
+
+// Publish publishes the envelope, marshaled as JSON, to the topic
+func (client *mqttClient) Publish(message types.MessageEnvelope, topic string) error {
+	payload, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+
+	client.connectionMutex.Lock()
+	select {
+	case <-client.quit:
+		client.connectionMutex.Unlock()
+		return errors.New("message bus client is disconnected")
+	default:
+	}
+	if client.publisher == nil {
+		publisher, err := client.connect(client.config.PublishHost, "pub", nil)
+		if err != nil {
+			client.connectionMutex.Unlock()
+			return err
+		}
+		client.publisher = publisher
+	}
+	publisher := client.publisher
+	client.connectionMutex.Unlock()
+
+	return client.wait(publisher.Publish(topic, client.qos, client.retained, payload), "publish to "+topic)
+}
+
+// Subscribe subscribes to the topics, delivering their messages to their channels and the errors to messageErrors.
+// The topics are subscribed again whenever the client reconnects.
+func (client *mqttClient) Subscribe(topics []types.TopicChannel, messageErrors chan error) error {
+	if len(topics) == 0 {
+		return errors.New("no topics to subscribe to")
+	}
+	client.mutex.Lock()
+	client.topics = topics
+	client.messageErrors = messageErrors
+	client.mutex.Unlock()
+
+	subscriber, err := client.connect(client.config.SubscribeHost, "sub", client.subscribe)
+	if err != nil {
+		return err
+	}
+	client.connectionMutex.Lock()
+	client.subscriber = subscriber
+	client.connectionMutex.Unlock()
+	return nil
+}
+
+// Disconnect disconnects from the broker and closes the topic channels and the error channel
+func (client *mqttClient) Disconnect() error {
+	// the handlers blocked on delivering a message return once quit is closed, releasing the lock
+	client.quitOnce.Do(func() { close(client.quit) })
+
+	client.connectionMutex.Lock()
+	for _, connection := range []paho.Client{client.publisher, client.subscriber} {
+		if connection != nil {
+			connection.Disconnect(mqttQuiesce)
+		}
+	}
+	client.publisher, client.subscriber = nil, nil
+	client.connectionMutex.Unlock()
+
+	client.mutex.Lock()
+	defer client.mutex.Unlock()
+	if client.closed {
+		return nil
+	}
+	client.closed = true
+	if client.messageErrors != nil {
+		close(client.messageErrors)
+	}
+	for _, topic := range client.topics {
+		close(topic.Messages)
+	}
+	return nil
+}
+
+// connect connects to the broker at the host, calling onConnect on each connection and reconnection
+func (client *mqttClient) connect(host types.HostInfo, role string, onConnect paho.OnConnectHandler) (paho.Client, error) {
+	if host.IsHostInfoEmpty() {
+		return nil, fmt.Errorf("message bus host to %s is not configured", role)
+	}
+	opts := paho.NewClientOptions()
+	opts.AddBroker(host.GetHostURL())
+	opts.SetClientID(client.clientID + "-" + role)
+	opts.SetUsername(client.auth.username)
+	opts.SetPassword(client.auth.password)
+	opts.SetKeepAlive(client.keepAlive)
+	opts.SetConnectTimeout(client.connectTimeout)
+	opts.SetAutoReconnect(client.autoReconnect)
+	opts.SetCleanSession(client.cleanSession)
+	if client.auth.tlsConfig != nil || client.skipVerify {
+		tlsConfig := client.auth.tlsConfig
+		if tlsConfig == nil {
+			tlsConfig = &tls.Config{}
+		}
+		tlsConfig.InsecureSkipVerify = client.skipVerify
+		opts.SetTLSConfig(tlsConfig)
+	}
+	if onConnect != nil {
+		opts.SetOnConnectHandler(onConnect)
+	}
+
+	connection := newPahoClient(opts)
+	if err := client.wait(connection.Connect(), "connect to "+host.GetHostURL()); err != nil {
+		return nil, err
+	}
+	return connection, nil
+}
+
+// subscribe subscribes to the topics on the connection
+func (client *mqttClient) subscribe(connection paho.Client) {
+	client.mutex.RLock()
+	topics := client.topics
+	client.mutex.RUnlock()
+
+	for _, topic := range topics {
+		filter := topic.Topic
+		if filter == "" {
+			filter = mqttWildcard
+		}
+		if err := client.wait(connection.Subscribe(filter, client.qos, client.handler(topic)), "subscribe to "+filter); err != nil {
+			client.reportError(err)
+		}
+	}
+}
+
+// handler returns the handler delivering the messages of the topic to its channel
+func (client *mqttClient) handler(topic types.TopicChannel) paho.MessageHandler {
+	return func(_ paho.Client, message paho.Message) {
+		var envelope types.MessageEnvelope
+		if err := json.Unmarshal(message.Payload(), &envelope); err != nil {
+			client.reportError(fmt.Errorf("unable to decode the message received on topic %s: %v", message.Topic(), err))
+			return
+		}
+
+		client.mutex.RLock()
+		defer client.mutex.RUnlock()
+		if client.closed {
+			return
+		}
+		select {
+		case topic.Messages <- envelope:
+		case <-client.quit:
+		}
+	}
+}
+
+// reportError sends the error to the error channel of the subscription, unless the client is disconnecting
+func (client *mqttClient) reportError(err error) {
+	client.mutex.RLock()
+	defer client.mutex.RUnlock()
+	if client.closed || client.messageErrors == nil {
+		return
+	}
+	select {
+	case client.messageErrors <- err:
+	case <-client.quit:
+	}
+}
+
+// wait waits for the token to complete, up to the connect timeout
+func (client *mqttClient) wait(token paho.Token, operation string) error {
+	if !token.WaitTimeout(client.connectTimeout) {
+		return fmt.Errorf("timed out waiting to %s", operation)
+	}
+	if err := token.Error(); err != nil {
+		return fmt.Errorf("unable to %s: %v", operation, err)
+	}
+	return nil
+}
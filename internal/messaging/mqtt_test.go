@@ -0,0 +1,157 @@
+//
+// Copyright (c) 2019 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package messaging
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/antoniomtz/go-mod-messaging/pkg/types"
+	paho "github.com/eclipse/paho.mqtt.golang"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeToken struct {
+	err error
+}
+
+func (token fakeToken) Wait() bool                       { return true }
+func (token fakeToken) WaitTimeout(_ time.Duration) bool { return true }
+func (token fakeToken) Error() error                     { return token.err }
+
+type fakeMessage struct {
+	paho.Message
+	topic   string
+	payload []byte
+}
+
+func (message fakeMessage) Topic() string   { return message.topic }
+func (message fakeMessage) Payload() []byte { return message.payload }
+
+// fakeBroker records the connections, publications and subscriptions of the fake paho clients
+type fakeBroker struct {
+	mutex         sync.Mutex
+	connected     []*paho.ClientOptions
+	published     []fakeMessage
+	subscriptions map[string]paho.MessageHandler
+	disconnected  int
+}
+
+func (broker *fakeBroker) newClient(options *paho.ClientOptions) paho.Client {
+	return &fakePahoClient{broker: broker, options: options}
+}
+
+func (broker *fakeBroker) deliver(filter string, message fakeMessage) {
+	broker.mutex.Lock()
+	handler := broker.subscriptions[filter]
+	broker.mutex.Unlock()
+	handler(nil, message)
+}
+
+type fakePahoClient struct {
+	paho.Client
+	broker  *fakeBroker
+	options *paho.ClientOptions
+}
+
+func (client *fakePahoClient) Connect() paho.Token {
+	client.broker.mutex.Lock()
+	client.broker.connected = append(client.broker.connected, client.options)
+	client.broker.mutex.Unlock()
+	if client.options.OnConnect != nil {
+		client.options.OnConnect(client)
+	}
+	return fakeToken{}
+}
+
+func (client *fakePahoClient) Publish(topic string, qos byte, retained bool, payload interface{}) paho.Token {
+	client.broker.mutex.Lock()
+	defer client.broker.mutex.Unlock()
+	client.broker.published = append(client.broker.published, fakeMessage{topic: topic, payload: payload.([]byte)})
+	return fakeToken{}
+}
+
+func (client *fakePahoClient) Subscribe(topic string, qos byte, callback paho.MessageHandler) paho.Token {
+	client.broker.mutex.Lock()
+	defer client.broker.mutex.Unlock()
+	client.broker.subscriptions[topic] = callback
+	return fakeToken{}
+}
+
+func (client *fakePahoClient) Disconnect(quiesce uint) {
+	client.broker.mutex.Lock()
+	defer client.broker.mutex.Unlock()
+	client.broker.disconnected++
+}
+
+func TestMQTTClient(t *testing.T) {
+	broker := &fakeBroker{subscriptions: map[string]paho.MessageHandler{}}
+	newPahoClient = broker.newClient
+	defer func() { newPahoClient = paho.NewClient }()
+
+	config := types.MessageBusConfig{
+		Type:          MQTT,
+		PublishHost:   types.HostInfo{Host: "publisher", Port: 1883, Protocol: "tcp"},
+		SubscribeHost: types.HostInfo{Host: "subscriber", Port: 1883, Protocol: "tcp"},
+		Optional:      map[string]string{ClientIDKey: "app", QosKey: "1", UsernameKey: "user", PasswordKey: "pass"},
+	}
+	client, err := NewMessageClient(config, nil)
+	require.NoError(t, err)
+	require.NoError(t, client.Connect())
+
+	topics := []types.TopicChannel{{Topic: "events", Messages: make(chan types.MessageEnvelope)}, {Topic: "", Messages: make(chan types.MessageEnvelope)}}
+	messageErrors := make(chan error)
+	require.NoError(t, client.Subscribe(topics, messageErrors))
+	require.Len(t, broker.connected, 1)
+	subscriber := broker.connected[0]
+	assert.Equal(t, "tcp://subscriber:1883", subscriber.Servers[0].String())
+	assert.Equal(t, "user", subscriber.Username)
+	assert.Contains(t, broker.subscriptions, "events")
+	assert.Contains(t, broker.subscriptions, "#", "An empty topic should subscribe to all topics")
+
+	envelope := types.MessageEnvelope{CorrelationID: "123", Payload: []byte(`{"device":"test"}`), ContentType: "application/json"}
+	require.NoError(t, client.Publish(envelope, "events"))
+	require.Len(t, broker.connected, 2)
+	publisher := broker.connected[1]
+	assert.Equal(t, "tcp://publisher:1883", publisher.Servers[0].String())
+	assert.NotEqual(t, subscriber.ClientID, publisher.ClientID, "Publisher and subscriber need their own client IDs")
+	require.Len(t, broker.published, 1)
+
+	go broker.deliver("events", broker.published[0])
+	assert.Equal(t, envelope, <-topics[0].Messages)
+
+	go broker.deliver("#", fakeMessage{topic: "other", payload: []byte("not an envelope")})
+	assert.Error(t, <-messageErrors)
+
+	// a message being delivered when disconnecting is dropped rather than sent to a closed channel
+	delivered := make(chan struct{})
+	go func() {
+		broker.deliver("events", broker.published[0])
+		close(delivered)
+	}()
+	require.NoError(t, client.Disconnect())
+	<-delivered
+	assert.Equal(t, 2, broker.disconnected)
+	_, open := <-topics[0].Messages
+	assert.False(t, open)
+	_, open = <-messageErrors
+	assert.False(t, open)
+
+	assert.Error(t, client.Publish(envelope, "events"), "Publishing after disconnecting should fail")
+}
@@ -0,0 +1,413 @@
+//
+// Copyright (c) 2019 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package messaging
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/antoniomtz/go-mod-messaging/pkg/types"
+	"github.com/gomodule/redigo/redis"
+)
+
+const (
+	// redisEnvelopeField is the field of the stream entries holding the envelope marshaled as JSON
+	redisEnvelopeField = "envelope"
+	// redisBlock is how long, in milliseconds, a read waits for new entries before checking for Disconnect
+	redisBlock = 1000
+	// redisRetryInterval is the wait before reading again after a failed read
+	redisRetryInterval = time.Second
+	// redisConnectTimeout is the timeout of the connections to Redis
+	redisConnectTimeout = 10 * time.Second
+	// redisLatestID is the ID to read the entries added after the first read from
+	redisLatestID = "$"
+	// redisPendingID is the ID to read the entries delivered to the consumer but not acknowledged from
+	redisPendingID = "0"
+	// redisNewID is the ID to read the entries never delivered to the consumer group
+	redisNewID = ">"
+)
+
+// redisStreamsClient is the message bus client of Redis Streams, adding the messages to the stream named by the topic
+// on PublishHost and reading the streams of the topics from SubscribeHost. With a consumer group, the entries are read
+// as a consumer of the group, which delivers the next entry of a stream once the last one is acknowledged.
+type redisStreamsClient struct {
+	config     types.MessageBusConfig
+	auth       authentication
+	maxLen     int
+	skipVerify bool
+	group      string
+	consumer   string
+
+	mutex      sync.Mutex
+	publisher  *redis.Pool
+	subscriber *redis.Pool
+
+	topics        []types.TopicChannel
+	messageErrors chan error
+	readers       sync.WaitGroup
+	quit          chan struct{}
+	quitOnce      sync.Once
+
+	// unacknowledged holds, for each topic, the channel receiving the acknowledgment of the entry delivered
+	unacknowledged      map[string]chan bool
+	unacknowledgedMutex sync.Mutex
+}
+
+// redisStreamsGroupClient is the client of Redis Streams reading with a consumer group, which delivers the next entry
+// of a stream once the last one is acknowledged
+type redisStreamsGroupClient struct {
+	*redisStreamsClient
+}
+
+// Ack acknowledges the last entry delivered for the topic, which is consumed with XACK when processed, and delivered
+// again otherwise, once the consumer's pending entries are read again
+func (client *redisStreamsGroupClient) Ack(topic string, processed bool) error {
+	client.unacknowledgedMutex.Lock()
+	acknowledgment, ok := client.unacknowledged[topic]
+	delete(client.unacknowledged, topic)
+	client.unacknowledgedMutex.Unlock()
+	if !ok {
+		return fmt.Errorf("no entry of stream %s waiting to be acknowledged", topic)
+	}
+	acknowledgment <- processed
+	return nil
+}
+
+func newRedisStreamsClient(config types.MessageBusConfig, auth authentication) (*redisStreamsClient, error) {
+	maxLen, err := optionalInt(config, MaxLenKey, 0)
+	if err != nil {
+		return nil, err
+	}
+	skipVerify, err := optionalBool(config, SkipVerifyKey, false)
+	if err != nil {
+		return nil, err
+	}
+	consumer := config.Optional[ClientIDKey]
+	if consumer == "" {
+		consumer = defaultClientID
+	}
+	return &redisStreamsClient{
+		config:         config,
+		auth:           auth,
+		maxLen:         maxLen,
+		skipVerify:     skipVerify,
+		group:          config.Optional[ConsumerGroupKey],
+		consumer:       consumer,
+		quit:           make(chan struct{}),
+		unacknowledged: make(map[string]chan bool),
+	}, nil
+}
+
+// Connect does nothing, the connections to Redis are made on the first Publish and Subscribe respectively
+func (client *redisStreamsClient) Connect() error {
+	return nil
+}
+
+// Publish adds the envelope, marshaled as JSON, to the stream named by the topic, trimming the stream to about
+// MaxLen entries when configured
+func (client *redisStreamsClient) Publish(message types.MessageEnvelope, topic string) error {
+	if topic == "" {
+		return errors.New("Redis Streams require a topic to publish to")
+	}
+	payload, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+	pool, err := client.pool(&client.publisher, client.config.PublishHost, "publish")
+	if err != nil {
+		return err
+	}
+
+	conn := pool.Get()
+	defer conn.Close()
+	args := redis.Args{}.Add(topic)
+	if client.maxLen > 0 {
+		args = args.Add("MAXLEN", "~", client.maxLen)
+	}
+	args = args.Add("*", redisEnvelopeField, payload)
+	if _, err := conn.Do("XADD", args...); err != nil {
+		return fmt.Errorf("unable to add the message to stream %s: %v", topic, err)
+	}
+	return nil
+}
+
+// Subscribe reads the entries added to the streams of the topics, delivering them to the channels of the topics and
+// the errors to messageErrors
+func (client *redisStreamsClient) Subscribe(topics []types.TopicChannel, messageErrors chan error) error {
+	if len(topics) == 0 {
+		return errors.New("no topics to subscribe to")
+	}
+	for _, topic := range topics {
+		if topic.Topic == "" {
+			return errors.New("Redis Streams require a topic to subscribe to")
+		}
+	}
+	pool, err := client.pool(&client.subscriber, client.config.SubscribeHost, "subscribe")
+	if err != nil {
+		return err
+	}
+	conn := pool.Get()
+	_, err = conn.Do("PING")
+	if err == nil && client.group != "" {
+		err = client.createGroups(conn, topics)
+	}
+	conn.Close()
+	if err != nil {
+		return fmt.Errorf("unable to connect to Redis at %s: %v", client.config.SubscribeHost.GetHostURL(), err)
+	}
+
+	client.mutex.Lock()
+	defer client.mutex.Unlock()
+	client.topics = topics
+	client.messageErrors = messageErrors
+	for _, topic := range topics {
+		client.readers.Add(1)
+		go client.read(pool, topic)
+	}
+	return nil
+}
+
+// Disconnect stops reading the streams, closing the topic channels and the error channel, and closes the connections
+func (client *redisStreamsClient) Disconnect() error {
+	client.quitOnce.Do(func() { close(client.quit) })
+	client.readers.Wait()
+
+	client.mutex.Lock()
+	defer client.mutex.Unlock()
+	if client.messageErrors != nil {
+		close(client.messageErrors)
+		client.messageErrors = nil
+	}
+	for _, topic := range client.topics {
+		close(topic.Messages)
+	}
+	client.topics = nil
+	for _, pool := range []*redis.Pool{client.publisher, client.subscriber} {
+		if pool != nil {
+			pool.Close()
+		}
+	}
+	client.publisher, client.subscriber = nil, nil
+	return nil
+}
+
+// createGroups creates the consumer group of the streams of the topics, along with the streams, unless it exists
+func (client *redisStreamsClient) createGroups(conn redis.Conn, topics []types.TopicChannel) error {
+	for _, topic := range topics {
+		_, err := conn.Do("XGROUP", "CREATE", topic.Topic, client.group, redisLatestID, "MKSTREAM")
+		if err != nil && !strings.HasPrefix(err.Error(), "BUSYGROUP") {
+			return fmt.Errorf("unable to create consumer group %s of stream %s: %v", client.group, topic.Topic, err)
+		}
+	}
+	return nil
+}
+
+// read reads the entries added to the stream of the topic until the client disconnects. A consumer of a group first
+// reads the entries it was delivered without acknowledging them, i.e. before a restart, and then the new entries.
+func (client *redisStreamsClient) read(pool *redis.Pool, topic types.TopicChannel) {
+	defer client.readers.Done()
+	lastID := redisLatestID
+	if client.group != "" {
+		lastID = redisPendingID
+	}
+	for {
+		select {
+		case <-client.quit:
+			return
+		default:
+		}
+
+		conn := pool.Get()
+		var reply []interface{}
+		var err error
+		if client.group != "" {
+			reply, err = redis.Values(conn.Do("XREADGROUP", "GROUP", client.group, client.consumer, "COUNT", 1, "BLOCK", redisBlock, "STREAMS", topic.Topic, lastID))
+		} else {
+			reply, err = redis.Values(conn.Do("XREAD", "BLOCK", redisBlock, "STREAMS", topic.Topic, lastID))
+		}
+		conn.Close()
+		if err == redis.ErrNil {
+			continue
+		}
+		if err != nil {
+			client.reportError(fmt.Errorf("unable to read stream %s: %v", topic.Topic, err))
+			if !client.wait(redisRetryInterval) {
+				return
+			}
+			continue
+		}
+
+		entries, err := streamEntries(reply)
+		if err != nil {
+			client.reportError(fmt.Errorf("unable to read stream %s: %v", topic.Topic, err))
+			continue
+		}
+		if len(entries) == 0 && lastID == redisPendingID {
+			// the pending entries have all been read again
+			lastID = redisNewID
+			continue
+		}
+		for _, entry := range entries {
+			if client.group == "" {
+				lastID = entry.id
+			}
+			var envelope types.MessageEnvelope
+			if err := json.Unmarshal(entry.envelope, &envelope); err != nil {
+				client.reportError(fmt.Errorf("unable to decode entry %s of stream %s: %v", entry.id, topic.Topic, err))
+				client.acknowledge(pool, topic.Topic, entry.id)
+				continue
+			}
+
+			var acknowledgment chan bool
+			if client.group != "" {
+				acknowledgment = make(chan bool, 1)
+				client.unacknowledgedMutex.Lock()
+				client.unacknowledged[topic.Topic] = acknowledgment
+				client.unacknowledgedMutex.Unlock()
+			}
+			select {
+			case topic.Messages <- envelope:
+			case <-client.quit:
+				return
+			}
+			if acknowledgment == nil {
+				continue
+			}
+
+			select {
+			case processed := <-acknowledgment:
+				if processed {
+					client.acknowledge(pool, topic.Topic, entry.id)
+				} else if lastID = redisPendingID; !client.wait(redisRetryInterval) {
+					return
+				}
+			case <-client.quit:
+				return
+			}
+		}
+	}
+}
+
+// acknowledge acknowledges the entry to the consumer group, if any, so that it isn't delivered again
+func (client *redisStreamsClient) acknowledge(pool *redis.Pool, stream string, id string) {
+	if client.group == "" {
+		return
+	}
+	conn := pool.Get()
+	defer conn.Close()
+	if _, err := conn.Do("XACK", stream, client.group, id); err != nil {
+		client.reportError(fmt.Errorf("unable to acknowledge entry %s of stream %s: %v", id, stream, err))
+	}
+}
+
+// wait waits for the duration, returning false if the client disconnects meanwhile
+func (client *redisStreamsClient) wait(duration time.Duration) bool {
+	select {
+	case <-client.quit:
+		return false
+	case <-time.After(duration):
+		return true
+	}
+}
+
+// reportError sends the error to the error channel of the subscription, unless the client is disconnecting
+func (client *redisStreamsClient) reportError(err error) {
+	select {
+	case client.messageErrors <- err:
+	case <-client.quit:
+	}
+}
+
+// pool returns the pool of the connections to the host, creating it on first use
+func (client *redisStreamsClient) pool(pool **redis.Pool, host types.HostInfo, role string) (*redis.Pool, error) {
+	client.mutex.Lock()
+	defer client.mutex.Unlock()
+	select {
+	case <-client.quit:
+		return nil, errors.New("message bus client is disconnected")
+	default:
+	}
+	if *pool != nil {
+		return *pool, nil
+	}
+	if host.IsHostInfoEmpty() {
+		return nil, fmt.Errorf("message bus host to %s is not configured", role)
+	}
+
+	address := fmt.Sprintf("%s:%d", host.Host, host.Port)
+	protocol := strings.ToLower(host.Protocol)
+	useTLS := client.auth.tlsConfig != nil || protocol == "rediss" || protocol == "tls"
+	options := []redis.DialOption{
+		redis.DialPassword(client.auth.password),
+		redis.DialUseTLS(useTLS),
+		redis.DialTLSSkipVerify(client.skipVerify),
+		redis.DialConnectTimeout(redisConnectTimeout),
+	}
+	if client.auth.tlsConfig != nil {
+		options = append(options, redis.DialTLSConfig(client.auth.tlsConfig))
+	}
+	*pool = &redis.Pool{
+		MaxIdle:     3,
+		IdleTimeout: 5 * time.Minute,
+		Dial: func() (redis.Conn, error) {
+			return redis.Dial("tcp", address, options...)
+		},
+	}
+	return *pool, nil
+}
+
+// streamEntry is an entry of a stream read with XREAD
+type streamEntry struct {
+	id       string
+	envelope []byte
+}
+
+// streamEntries returns the entries of the reply to XREAD, i.e. [[stream, [[id, [field, value, ...]], ...]], ...]
+func streamEntries(reply []interface{}) ([]streamEntry, error) {
+	var entries []streamEntry
+	for _, stream := range reply {
+		values, err := redis.Values(stream, nil)
+		if err != nil || len(values) != 2 {
+			return nil, errors.New("unexpected reply to XREAD")
+		}
+		items, err := redis.Values(values[1], nil)
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range items {
+			values, err := redis.Values(item, nil)
+			if err != nil || len(values) != 2 {
+				return nil, errors.New("unexpected stream entry")
+			}
+			id, err := redis.String(values[0], nil)
+			if err != nil {
+				return nil, err
+			}
+			// the fields of a pending entry trimmed from the stream are nil
+			fields, err := redis.StringMap(values[1], nil)
+			if err != nil && err != redis.ErrNil {
+				return nil, err
+			}
+			entries = append(entries, streamEntry{id: id, envelope: []byte(fields[redisEnvelopeField])})
+		}
+	}
+	return entries, nil
+}
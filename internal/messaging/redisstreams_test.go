@@ -0,0 +1,377 @@
+//
+// Copyright (c) 2019 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package messaging
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/antoniomtz/go-mod-messaging/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// streamsTestServer is a Redis server supporting the commands of the Redis Streams client
+type streamsTestServer struct {
+	listener net.Listener
+	mutex    sync.Mutex
+	commands [][]string
+	// streams holds the field-value pairs of the entries of each stream, whose IDs are their index plus one
+	streams map[string][][]string
+	// delivered is the number of entries of each stream delivered to the consumer group, and pending the IDs of
+	// those which weren't acknowledged
+	delivered map[string]int
+	pending   map[string][]int
+}
+
+func newStreamsTestServer(t *testing.T) *streamsTestServer {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	server := &streamsTestServer{listener: listener, streams: map[string][][]string{}, delivered: map[string]int{}, pending: map[string][]int{}}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go server.serve(conn)
+		}
+	}()
+	return server
+}
+
+func (server *streamsTestServer) host() types.HostInfo {
+	address := server.listener.Addr().(*net.TCPAddr)
+	return types.HostInfo{Host: address.IP.String(), Port: address.Port, Protocol: "redis"}
+}
+
+func (server *streamsTestServer) serve(conn net.Conn) {
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	for {
+		command, err := readRESPArray(reader)
+		if err != nil {
+			return
+		}
+
+		server.mutex.Lock()
+		server.commands = append(server.commands, command)
+		server.mutex.Unlock()
+
+		switch strings.ToUpper(command[0]) {
+		case "XADD":
+			server.mutex.Lock()
+			fields := command[len(command)-2:]
+			server.streams[command[1]] = append(server.streams[command[1]], fields)
+			id := fmt.Sprintf("%d-0", len(server.streams[command[1]]))
+			server.mutex.Unlock()
+			fmt.Fprintf(conn, "$%d\r\n%s\r\n", len(id), id)
+		case "XREAD":
+			server.xread(conn, command)
+		case "XGROUP":
+			server.mutex.Lock()
+			if _, exists := server.delivered[command[2]]; exists {
+				fmt.Fprint(conn, "-BUSYGROUP Consumer Group name already exists\r\n")
+			} else {
+				server.delivered[command[2]] = len(server.streams[command[2]])
+				fmt.Fprint(conn, "+OK\r\n")
+			}
+			server.mutex.Unlock()
+		case "XREADGROUP":
+			server.xreadgroup(conn, command)
+		case "XACK":
+			server.mutex.Lock()
+			id, _ := strconv.Atoi(strings.TrimSuffix(command[3], "-0"))
+			pending := server.pending[command[1]][:0]
+			for _, pendingID := range server.pending[command[1]] {
+				if pendingID != id {
+					pending = append(pending, pendingID)
+				}
+			}
+			server.pending[command[1]] = pending
+			server.mutex.Unlock()
+			fmt.Fprint(conn, ":1\r\n")
+		case "PING":
+			fmt.Fprint(conn, "+PONG\r\n")
+		default:
+			fmt.Fprint(conn, "+OK\r\n")
+		}
+	}
+}
+
+// xread replies to XREAD BLOCK milliseconds STREAMS stream id
+func (server *streamsTestServer) xread(conn io.Writer, command []string) {
+	block, _ := strconv.Atoi(command[2])
+	stream, id := command[4], command[5]
+	server.mutex.Lock()
+	last := len(server.streams[stream])
+	server.mutex.Unlock()
+	if id != "$" {
+		last, _ = strconv.Atoi(strings.TrimSuffix(id, "-0"))
+	}
+
+	deadline := time.Now().Add(time.Duration(block) * time.Millisecond)
+	for time.Now().Before(deadline) {
+		server.mutex.Lock()
+		var ids []int
+		for id := last + 1; id <= len(server.streams[stream]); id++ {
+			ids = append(ids, id)
+		}
+		if len(ids) > 0 {
+			server.writeEntries(conn, stream, ids)
+			server.mutex.Unlock()
+			return
+		}
+		server.mutex.Unlock()
+		time.Sleep(10 * time.Millisecond)
+	}
+	fmt.Fprint(conn, "*-1\r\n")
+}
+
+// xreadgroup replies to XREADGROUP GROUP group consumer COUNT 1 BLOCK milliseconds STREAMS stream id, with a single
+// consumer in the group
+func (server *streamsTestServer) xreadgroup(conn io.Writer, command []string) {
+	block, _ := strconv.Atoi(command[7])
+	stream, id := command[9], command[10]
+	if id == "0" {
+		server.mutex.Lock()
+		defer server.mutex.Unlock()
+		if pending := server.pending[stream]; len(pending) > 0 {
+			server.writeEntries(conn, stream, pending[:1])
+		} else {
+			server.writeEntries(conn, stream, nil)
+		}
+		return
+	}
+
+	deadline := time.Now().Add(time.Duration(block) * time.Millisecond)
+	for time.Now().Before(deadline) {
+		server.mutex.Lock()
+		if server.delivered[stream] < len(server.streams[stream]) {
+			server.delivered[stream]++
+			id := server.delivered[stream]
+			server.pending[stream] = append(server.pending[stream], id)
+			server.writeEntries(conn, stream, []int{id})
+			server.mutex.Unlock()
+			return
+		}
+		server.mutex.Unlock()
+		time.Sleep(10 * time.Millisecond)
+	}
+	fmt.Fprint(conn, "*-1\r\n")
+}
+
+// waitAcknowledged waits for the entries of the stream delivered to the consumer group to be acknowledged
+func (server *streamsTestServer) waitAcknowledged(stream string, timeout time.Duration) bool {
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		server.mutex.Lock()
+		pending := len(server.pending[stream])
+		server.mutex.Unlock()
+		if pending == 0 {
+			return true
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	return false
+}
+
+// writeEntries writes the reply to a read of the entries of the stream
+func (server *streamsTestServer) writeEntries(conn io.Writer, stream string, ids []int) {
+	fmt.Fprintf(conn, "*1\r\n*2\r\n$%d\r\n%s\r\n*%d\r\n", len(stream), stream, len(ids))
+	for _, id := range ids {
+		entryID := fmt.Sprintf("%d-0", id)
+		fields := server.streams[stream][id-1]
+		fmt.Fprintf(conn, "*2\r\n$%d\r\n%s\r\n*%d\r\n", len(entryID), entryID, len(fields))
+		for _, field := range fields {
+			fmt.Fprintf(conn, "$%d\r\n%s\r\n", len(field), field)
+		}
+	}
+}
+
+func (server *streamsTestServer) received(name string) []string {
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+	for _, command := range server.commands {
+		if strings.EqualFold(command[0], name) {
+			return command
+		}
+	}
+	return nil
+}
+
+func (server *streamsTestServer) add(stream string, fields ...string) {
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+	server.streams[stream] = append(server.streams[stream], fields)
+}
+
+func readRESPArray(reader *bufio.Reader) ([]string, error) {
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	count, _ := strconv.Atoi(strings.TrimSpace(line[1:]))
+
+	values := make([]string, count)
+	for i := range values {
+		line, err = reader.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		length, _ := strconv.Atoi(strings.TrimSpace(line[1:]))
+		value := make([]byte, length+2)
+		if _, err := io.ReadFull(reader, value); err != nil {
+			return nil, err
+		}
+		values[i] = string(value[:length])
+	}
+	return values, nil
+}
+
+func TestRedisStreamsClient(t *testing.T) {
+	server := newStreamsTestServer(t)
+	defer server.listener.Close()
+
+	config := types.MessageBusConfig{
+		Type:          RedisStreams,
+		PublishHost:   server.host(),
+		SubscribeHost: server.host(),
+		Optional:      map[string]string{PasswordKey: "secret", MaxLenKey: "100"},
+	}
+	client, err := NewMessageClient(config, nil)
+	require.NoError(t, err)
+	require.NoError(t, client.Connect())
+
+	topics := []types.TopicChannel{{Topic: "events", Messages: make(chan types.MessageEnvelope)}}
+	messageErrors := make(chan error)
+	require.NoError(t, client.Subscribe(topics, messageErrors))
+	assert.Equal(t, []string{"AUTH", "secret"}, server.received("AUTH"))
+
+	envelope := types.MessageEnvelope{CorrelationID: "123", Payload: []byte(`{"device":"test"}`), ContentType: "application/json"}
+	require.NoError(t, client.Publish(envelope, "events"))
+	xadd := server.received("XADD")
+	require.Len(t, xadd, 8)
+	assert.Equal(t, []string{"XADD", "events", "MAXLEN", "~", "100", "*", redisEnvelopeField}, xadd[:7])
+
+	select {
+	case received := <-topics[0].Messages:
+		assert.Equal(t, envelope, received)
+	case <-time.After(5 * time.Second):
+		t.Fatal("Message not received")
+	}
+
+	server.add("events", redisEnvelopeField, "not an envelope")
+	select {
+	case err := <-messageErrors:
+		assert.Error(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("Error not received")
+	}
+
+	require.NoError(t, client.Disconnect())
+	_, open := <-topics[0].Messages
+	assert.False(t, open)
+	_, open = <-messageErrors
+	assert.False(t, open)
+}
+
+func TestRedisStreamsClientTopics(t *testing.T) {
+	server := newStreamsTestServer(t)
+	defer server.listener.Close()
+
+	client, err := NewMessageClient(types.MessageBusConfig{Type: RedisStreams, PublishHost: server.host(), SubscribeHost: server.host()}, nil)
+	require.NoError(t, err)
+
+	assert.Error(t, client.Publish(types.MessageEnvelope{}, ""), "Publishing without a topic should fail")
+	err = client.Subscribe([]types.TopicChannel{{Topic: "", Messages: make(chan types.MessageEnvelope)}}, make(chan error))
+	assert.Error(t, err, "Subscribing without a topic should fail")
+
+	unreachable, err := NewMessageClient(types.MessageBusConfig{Type: RedisStreams, SubscribeHost: types.HostInfo{Host: "127.0.0.1", Port: 1}}, nil)
+	require.NoError(t, err)
+	err = unreachable.Subscribe([]types.TopicChannel{{Topic: "events", Messages: make(chan types.MessageEnvelope)}}, make(chan error))
+	assert.Error(t, err, "Subscribing to an unreachable server should fail")
+}
+
+func TestRedisStreamsClientConsumerGroup(t *testing.T) {
+	server := newStreamsTestServer(t)
+	defer server.listener.Close()
+	envelope := func(correlationID string) string {
+		payload, _ := json.Marshal(types.MessageEnvelope{CorrelationID: correlationID})
+		return string(payload)
+	}
+
+	config := types.MessageBusConfig{
+		Type:          RedisStreams,
+		PublishHost:   server.host(),
+		SubscribeHost: server.host(),
+		Optional:      map[string]string{ConsumerGroupKey: "app-service", ClientIDKey: "consumer-1"},
+	}
+	client, err := NewMessageClient(config, nil)
+	require.NoError(t, err)
+	acknowledger, ok := client.(Acknowledger)
+	require.True(t, ok, "Redis Streams with a consumer group should acknowledge messages")
+	assert.Error(t, acknowledger.Ack("events", true), "Nothing was delivered yet")
+
+	// an entry delivered but not acknowledged before a restart is delivered again first
+	server.mutex.Lock()
+	server.streams["events"] = [][]string{{redisEnvelopeField, envelope("1")}}
+	server.delivered["events"] = 1
+	server.pending["events"] = []int{1}
+	server.mutex.Unlock()
+
+	topics := []types.TopicChannel{{Topic: "events", Messages: make(chan types.MessageEnvelope)}}
+	require.NoError(t, client.Subscribe(topics, make(chan error)))
+	assert.Equal(t, []string{"XGROUP", "CREATE", "events", "app-service", "$", "MKSTREAM"}, server.received("XGROUP"))
+
+	receive := func() string {
+		select {
+		case received := <-topics[0].Messages:
+			return received.CorrelationID
+		case <-time.After(5 * time.Second):
+			t.Fatal("Message not received")
+			return ""
+		}
+	}
+	assert.Equal(t, "1", receive())
+	server.add("events", redisEnvelopeField, envelope("2"))
+	select {
+	case <-topics[0].Messages:
+		t.Fatal("The next entry shouldn't be delivered until the last one is acknowledged")
+	case <-time.After(100 * time.Millisecond):
+	}
+	require.NoError(t, acknowledger.Ack("events", true))
+	assert.Equal(t, "2", receive())
+	assert.Equal(t, []string{"XACK", "events", "app-service", "1-0"}, server.received("XACK"))
+
+	// an entry which wasn't processed is delivered again
+	require.NoError(t, acknowledger.Ack("events", false))
+	assert.Equal(t, "2", receive())
+	require.NoError(t, acknowledger.Ack("events", true))
+
+	assert.True(t, server.waitAcknowledged("events", 5*time.Second), "The entries processed should be acknowledged")
+	require.NoError(t, client.Disconnect())
+}
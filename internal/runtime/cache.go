@@ -0,0 +1,202 @@
+//
+// Copyright (c) 2019 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package runtime
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/antoniomtz/app-functions-sdk-go/appcontext"
+)
+
+// DefaultCacheCapacity is the number of results a TransformCache keeps when its Capacity isn't set
+const DefaultCacheCapacity = 1000
+
+// CacheMetrics contains the lookups made in a TransformCache
+type CacheMetrics struct {
+	Name string
+	// Hits is the number of calls answered from the cache
+	Hits uint64
+	// Misses is the number of calls which executed the function
+	Misses uint64
+	// Evictions is the number of results dropped to make room for newer ones, or because they expired
+	Evictions uint64
+	// Entries is the number of results held
+	Entries int
+	// HitRate is the share of calls answered from the cache, from 0 to 1
+	HitRate float64
+}
+
+// TransformCache memoizes the results of a pure function keyed by a hash of the data it receives, so identical
+// payloads, i.e. duplicate readings, don't pay for expensive processing such as template rendering or schema
+// validation again. The function must return the same result for the same data, and must not depend on the context
+// or change it, as calls answered from the cache don't execute it. Errors are never cached.
+type TransformCache struct {
+	Name      string
+	Transform func(*appcontext.Context, ...interface{}) (bool, interface{})
+	// Capacity is the number of results held, the least recently used being evicted first. Defaults to
+	// DefaultCacheCapacity.
+	Capacity int
+	// TTL is how long a result is held. Zero holds results until they are evicted.
+	TTL       time.Duration
+	mutex     sync.Mutex
+	entries   map[[sha256.Size]byte]*list.Element
+	order     *list.List
+	hits      uint64
+	misses    uint64
+	evictions uint64
+}
+
+type cacheEntry struct {
+	key              [sha256.Size]byte
+	continuePipeline bool
+	result           interface{}
+	expires          time.Time
+}
+
+// Memoize returns the result of the function for the data from the previous function, executing the function only
+// when the result for the same data isn't cached. Results are shared between calls, so the functions which follow
+// must not modify them.
+func (cache *TransformCache) Memoize(edgexcontext *appcontext.Context, params ...interface{}) (bool, interface{}) {
+	var data interface{}
+	if len(params) > 0 {
+		data = params[0]
+	}
+
+	key, err := payloadKey(data)
+	if err != nil {
+		edgexcontext.LoggingClient.Debug(fmt.Sprintf("Unable to hash data for %s, calling it without the cache: %v", cache.Name, err))
+		atomic.AddUint64(&cache.misses, 1)
+		return cache.Transform(edgexcontext, params...)
+	}
+
+	if entry, found := cache.lookup(key); found {
+		atomic.AddUint64(&cache.hits, 1)
+		return entry.continuePipeline, entry.result
+	}
+
+	atomic.AddUint64(&cache.misses, 1)
+	continuePipeline, result := cache.Transform(edgexcontext, params...)
+	if _, failed := result.(error); !failed {
+		cache.store(key, continuePipeline, result)
+	}
+	return continuePipeline, result
+}
+
+// Metrics returns a snapshot of the lookups made in the cache
+func (cache *TransformCache) Metrics() CacheMetrics {
+	cache.mutex.Lock()
+	entries := len(cache.entries)
+	cache.mutex.Unlock()
+
+	metrics := CacheMetrics{
+		Name:      cache.Name,
+		Hits:      atomic.LoadUint64(&cache.hits),
+		Misses:    atomic.LoadUint64(&cache.misses),
+		Evictions: atomic.LoadUint64(&cache.evictions),
+		Entries:   entries,
+	}
+	if lookups := metrics.Hits + metrics.Misses; lookups > 0 {
+		metrics.HitRate = float64(metrics.Hits) / float64(lookups)
+	}
+	return metrics
+}
+
+// lookup returns the entry for the key unless it is missing or expired, marking it as the most recently used
+func (cache *TransformCache) lookup(key [sha256.Size]byte) (*cacheEntry, bool) {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	element, found := cache.entries[key]
+	if !found {
+		return nil, false
+	}
+	entry := element.Value.(*cacheEntry)
+	if !entry.expires.IsZero() && time.Now().After(entry.expires) {
+		cache.remove(element)
+		return nil, false
+	}
+	cache.order.MoveToFront(element)
+	return entry, true
+}
+
+// store adds the result for the key, evicting the least recently used results beyond the capacity
+func (cache *TransformCache) store(key [sha256.Size]byte, continuePipeline bool, result interface{}) {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	if cache.entries == nil {
+		cache.entries = map[[sha256.Size]byte]*list.Element{}
+		cache.order = list.New()
+	}
+
+	entry := &cacheEntry{key: key, continuePipeline: continuePipeline, result: result}
+	if cache.TTL > 0 {
+		entry.expires = time.Now().Add(cache.TTL)
+	}
+	if element, found := cache.entries[key]; found {
+		element.Value = entry
+		cache.order.MoveToFront(element)
+		return
+	}
+	cache.entries[key] = cache.order.PushFront(entry)
+
+	capacity := cache.Capacity
+	if capacity <= 0 {
+		capacity = DefaultCacheCapacity
+	}
+	for cache.order.Len() > capacity {
+		cache.remove(cache.order.Back())
+	}
+}
+
+// remove drops the entry of the element, the caller holding the mutex
+func (cache *TransformCache) remove(element *list.Element) {
+	cache.order.Remove(element)
+	delete(cache.entries, element.Value.(*cacheEntry).key)
+	atomic.AddUint64(&cache.evictions, 1)
+}
+
+// payloadKey hashes the data along with its type, so i.e. a string and a []byte with the same content don't share
+// a result. Data other than a string or []byte is hashed as JSON.
+func payloadKey(data interface{}) ([sha256.Size]byte, error) {
+	var payload []byte
+	switch value := data.(type) {
+	case []byte:
+		payload = value
+	case string:
+		payload = []byte(value)
+	default:
+		encoded, err := json.Marshal(value)
+		if err != nil {
+			return [sha256.Size]byte{}, err
+		}
+		payload = encoded
+	}
+
+	hash := sha256.New()
+	hash.Write([]byte(fmt.Sprintf("%T\x00", data)))
+	hash.Write(payload)
+	var key [sha256.Size]byte
+	copy(key[:], hash.Sum(nil))
+	return key, nil
+}
@@ -0,0 +1,139 @@
+//
+// Copyright (c) 2019 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package runtime
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/antoniomtz/app-functions-sdk-go/appcontext"
+	"github.com/edgexfoundry/go-mod-core-contracts/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoize(t *testing.T) {
+	context := &appcontext.Context{LoggingClient: lc}
+	calls := 0
+	render := func(edgexcontext *appcontext.Context, params ...interface{}) (bool, interface{}) {
+		calls++
+		event := params[0].(models.Event)
+		return true, "device=" + event.Device
+	}
+	cache := TransformCache{Name: "render", Transform: render}
+
+	eventIn := models.Event{Device: devID1, Readings: []models.Reading{{Name: readingName1, Value: "1"}}}
+	for i := 0; i < 3; i++ {
+		continuePipeline, result := cache.Memoize(context, eventIn)
+		assert.True(t, continuePipeline)
+		assert.Equal(t, "device="+devID1, result)
+	}
+	assert.Equal(t, 1, calls, "identical payloads should be rendered once")
+
+	continuePipeline, result := cache.Memoize(context, models.Event{Device: devID2})
+	assert.True(t, continuePipeline)
+	assert.Equal(t, "device="+devID2, result)
+	assert.Equal(t, 2, calls)
+
+	metrics := cache.Metrics()
+	assert.Equal(t, "render", metrics.Name)
+	assert.Equal(t, uint64(2), metrics.Hits)
+	assert.Equal(t, uint64(2), metrics.Misses)
+	assert.Equal(t, 2, metrics.Entries)
+	assert.Equal(t, 0.5, metrics.HitRate)
+}
+
+func TestMemoizeKeysByType(t *testing.T) {
+	context := &appcontext.Context{LoggingClient: lc}
+	typeName := func(edgexcontext *appcontext.Context, params ...interface{}) (bool, interface{}) {
+		if _, ok := params[0].(string); ok {
+			return true, "string"
+		}
+		return true, "bytes"
+	}
+	cache := TransformCache{Transform: typeName}
+
+	_, result := cache.Memoize(context, "payload")
+	assert.Equal(t, "string", result)
+	_, result = cache.Memoize(context, []byte("payload"))
+	assert.Equal(t, "bytes", result, "a string and []byte with the same content shouldn't share a result")
+}
+
+func TestMemoizeDoesNotCacheErrors(t *testing.T) {
+	context := &appcontext.Context{LoggingClient: lc}
+	calls := 0
+	failing := func(edgexcontext *appcontext.Context, params ...interface{}) (bool, interface{}) {
+		calls++
+		return false, errors.New("invalid")
+	}
+	cache := TransformCache{Transform: failing}
+
+	for i := 0; i < 2; i++ {
+		continuePipeline, result := cache.Memoize(context, "payload")
+		assert.False(t, continuePipeline)
+		assert.Error(t, result.(error))
+	}
+	assert.Equal(t, 2, calls)
+	assert.Equal(t, 0, cache.Metrics().Entries)
+}
+
+func TestMemoizeEvictsLeastRecentlyUsed(t *testing.T) {
+	context := &appcontext.Context{LoggingClient: lc}
+	calls := map[string]int{}
+	count := func(edgexcontext *appcontext.Context, params ...interface{}) (bool, interface{}) {
+		calls[params[0].(string)]++
+		return true, params[0]
+	}
+	cache := TransformCache{Transform: count, Capacity: 2}
+
+	cache.Memoize(context, "a")
+	cache.Memoize(context, "b")
+	cache.Memoize(context, "a")
+	cache.Memoize(context, "c")
+	cache.Memoize(context, "a")
+	cache.Memoize(context, "b")
+
+	assert.Equal(t, map[string]int{"a": 1, "b": 2, "c": 1}, calls, "b should have been evicted as least recently used")
+	metrics := cache.Metrics()
+	assert.Equal(t, 2, metrics.Entries)
+	assert.Equal(t, uint64(2), metrics.Evictions)
+}
+
+func TestMemoizeExpires(t *testing.T) {
+	context := &appcontext.Context{LoggingClient: lc}
+	calls := 0
+	count := func(edgexcontext *appcontext.Context, params ...interface{}) (bool, interface{}) {
+		calls++
+		return true, params[0]
+	}
+	cache := TransformCache{Transform: count, TTL: 10 * time.Millisecond}
+
+	cache.Memoize(context, "payload")
+	cache.Memoize(context, "payload")
+	assert.Equal(t, 1, calls)
+
+	time.Sleep(20 * time.Millisecond)
+	cache.Memoize(context, "payload")
+	assert.Equal(t, 2, calls, "expired results should be computed again")
+}
+
+func TestCacheMetrics(t *testing.T) {
+	runtime := GolangRuntime{Caches: []*TransformCache{{Name: "first"}, {Name: "second"}}}
+
+	metrics := runtime.CacheMetrics()
+	assert.Equal(t, []CacheMetrics{{Name: "first"}, {Name: "second"}}, metrics)
+}
@@ -0,0 +1,69 @@
+//
+// Copyright (c) 2019 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package runtime
+
+import (
+	"fmt"
+	"mime"
+	"strings"
+
+	"github.com/antoniomtz/app-functions-sdk-go/pkg/transforms"
+	"github.com/edgexfoundry/go-mod-core-contracts/clients"
+)
+
+// mediaType returns the media type of the content type, lowercased and without parameters such as the charset, so
+// 'application/json; charset=utf-8' is decoded as JSON. An empty content type is JSON, which publishers not setting
+// the content type of their messages send.
+func mediaType(contentType string) string {
+	if strings.TrimSpace(contentType) == "" {
+		return clients.ContentTypeJSON
+	}
+	parsed, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return strings.ToLower(strings.TrimSpace(contentType))
+	}
+	return parsed
+}
+
+// unwrapPayload returns the data of a CloudEvent in the structured JSON format along with the media type of the data,
+// so events wrapped by upstream services are decoded as if they were received as is. Other payloads are returned
+// unchanged with their media type.
+func unwrapPayload(payload []byte, contentType string) ([]byte, string, error) {
+	if contentType != transforms.ContentTypeCloudEvents {
+		return payload, contentType, nil
+	}
+	data, dataContentType, err := transforms.UnwrapCloudEvent(payload)
+	if err != nil {
+		return nil, "", err
+	}
+	return data, mediaType(dataContentType), nil
+}
+
+// ValidateContentType returns an error when the payloads of the content type can't be decoded for the pipeline,
+// which decodes JSON and CBOR, along with CloudEvents wrapping them, and takes any payload when the TargetType is
+// *[]byte
+func (gr *GolangRuntime) ValidateContentType(contentType string) error {
+	if _, raw := gr.TargetType.(*[]byte); raw {
+		return nil
+	}
+	switch mediaType(contentType) {
+	case clients.ContentTypeJSON, clients.ContentTypeCBOR, transforms.ContentTypeCloudEvents:
+		return nil
+	default:
+		return fmt.Errorf("'%s' content type not supported, only '%s' and '%s' payloads can be decoded", contentType, clients.ContentTypeJSON, clients.ContentTypeCBOR)
+	}
+}
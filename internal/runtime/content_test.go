@@ -0,0 +1,101 @@
+//
+// Copyright (c) 2019 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package runtime
+
+import (
+	"encoding/base64"
+	"testing"
+
+	"github.com/antoniomtz/app-functions-sdk-go/appcontext"
+	"github.com/antoniomtz/go-mod-messaging/pkg/types"
+	"github.com/edgexfoundry/go-mod-core-contracts/clients"
+	"github.com/edgexfoundry/go-mod-core-contracts/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/ugorji/go/codec"
+)
+
+func TestMediaType(t *testing.T) {
+	assert.Equal(t, clients.ContentTypeJSON, mediaType("application/json"))
+	assert.Equal(t, clients.ContentTypeJSON, mediaType("Application/JSON; charset=utf-8"))
+	assert.Equal(t, clients.ContentTypeJSON, mediaType(""), "an unset content type should be JSON")
+	assert.Equal(t, clients.ContentTypeCBOR, mediaType(" application/cbor "))
+	assert.Equal(t, "text/plain", mediaType("text/plain"))
+}
+
+func TestValidateContentType(t *testing.T) {
+	runtime := GolangRuntime{}
+	assert.NoError(t, runtime.ValidateContentType(clients.ContentTypeJSON))
+	assert.NoError(t, runtime.ValidateContentType(clients.ContentTypeCBOR))
+	assert.NoError(t, runtime.ValidateContentType("application/json; charset=utf-8"))
+	assert.NoError(t, runtime.ValidateContentType("application/cloudevents+json; charset=utf-8"))
+	err := runtime.ValidateContentType("text/plain")
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "'text/plain' content type not supported")
+	}
+
+	runtime.TargetType = &[]byte{}
+	assert.NoError(t, runtime.ValidateContentType("text/plain"), "any payload should be taken as is for a []byte target type")
+}
+
+func TestProcessEventContentTypeParameters(t *testing.T) {
+	var received interface{}
+	transform := func(edgexcontext *appcontext.Context, params ...interface{}) (bool, interface{}) {
+		received = params[0]
+		return false, nil
+	}
+	runtime := GolangRuntime{Transforms: []func(edgexcontext *appcontext.Context, params ...interface{}) (bool, interface{}){transform}}
+
+	runtime.ProcessEvent(&appcontext.Context{LoggingClient: lc}, types.MessageEnvelope{Payload: []byte(`{"device":"thermostat"}`), ContentType: "application/json; charset=utf-8"})
+	if assert.IsType(t, models.Event{}, received) {
+		assert.Equal(t, "thermostat", received.(models.Event).Device)
+	}
+}
+
+func TestProcessEventCloudEvent(t *testing.T) {
+	var received interface{}
+	var rawPayload []byte
+	transform := func(edgexcontext *appcontext.Context, params ...interface{}) (bool, interface{}) {
+		received = params[0]
+		rawPayload = edgexcontext.RawPayload
+		return false, nil
+	}
+	runtime := GolangRuntime{Transforms: []func(edgexcontext *appcontext.Context, params ...interface{}) (bool, interface{}){transform}}
+
+	cloudEvent := []byte(`{"specversion":"1.0","id":"1","source":"edgex/thermostat","type":"org.edgexfoundry.event","data":{"device":"thermostat"}}`)
+	envelope := types.MessageEnvelope{Payload: cloudEvent, ContentType: "application/cloudevents+json"}
+	runtime.ProcessEvent(&appcontext.Context{LoggingClient: lc}, envelope)
+	if assert.IsType(t, models.Event{}, received) {
+		assert.Equal(t, "thermostat", received.(models.Event).Device)
+	}
+	assert.Equal(t, cloudEvent, rawPayload, "the raw payload should be the CloudEvent as received")
+	assert.Equal(t, "thermostat", runtime.DecodeMessage(envelope).Device())
+
+	var cborPayload []byte
+	codec.NewEncoderBytes(&cborPayload, &codec.CborHandle{}).Encode(models.Event{Device: "hygrometer"})
+	received = nil
+	runtime.ProcessEvent(&appcontext.Context{LoggingClient: lc}, types.MessageEnvelope{
+		Payload:     []byte(`{"specversion":"1.0","id":"2","source":"edgex","type":"event","datacontenttype":"application/cbor","data_base64":"` + base64.StdEncoding.EncodeToString(cborPayload) + `"}`),
+		ContentType: "application/cloudevents+json",
+	})
+	if assert.IsType(t, models.Event{}, received) {
+		assert.Equal(t, "hygrometer", received.(models.Event).Device)
+	}
+
+	received = nil
+	runtime.ProcessEvent(&appcontext.Context{LoggingClient: lc}, types.MessageEnvelope{Payload: []byte(`{"device":"thermostat"}`), ContentType: "application/cloudevents+json"})
+	assert.Nil(t, received, "Pipeline should not run when the payload isn't a CloudEvent")
+}
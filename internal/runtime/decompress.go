@@ -0,0 +1,82 @@
+//
+// Copyright (c) 2019 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package runtime
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+)
+
+// maxDecompressedSize limits the size of decompressed payloads to guard against decompression bombs
+const maxDecompressedSize = 64 * 1024 * 1024
+
+// decompressPayload returns the payload decompressed according to the content encoding. Payloads without a
+// content encoding are decompressed when they start with the gzip or zlib magic bytes, so compressed payloads
+// are handled for triggers which have no way to signal the encoding, such as the message bus.
+func decompressPayload(payload []byte, contentEncoding string) ([]byte, error) {
+	encoding := strings.ToLower(strings.TrimSpace(contentEncoding))
+	if encoding == "" || encoding == "identity" {
+		encoding = detectEncoding(payload)
+	}
+
+	var reader io.ReadCloser
+	var err error
+	switch encoding {
+	case "":
+		return payload, nil
+	case "gzip", "x-gzip":
+		reader, err = gzip.NewReader(bytes.NewReader(payload))
+	case "deflate", "zlib":
+		reader, err = zlib.NewReader(bytes.NewReader(payload))
+	default:
+		return nil, fmt.Errorf("'%s' content encoding not supported", contentEncoding)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to decompress %s payload: %v", encoding, err)
+	}
+	defer reader.Close()
+
+	decompressed, err := ioutil.ReadAll(io.LimitReader(reader, maxDecompressedSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("unable to decompress %s payload: %v", encoding, err)
+	}
+	if len(decompressed) > maxDecompressedSize {
+		return nil, fmt.Errorf("decompressed payload exceeds %d bytes", maxDecompressedSize)
+	}
+	return decompressed, nil
+}
+
+// detectEncoding returns the compression indicated by the magic bytes at the start of the payload, or an empty
+// string for an uncompressed payload. JSON and CBOR encoded Events never start with either.
+func detectEncoding(payload []byte) string {
+	if len(payload) < 2 {
+		return ""
+	}
+	if payload[0] == 0x1f && payload[1] == 0x8b {
+		return "gzip"
+	}
+	// zlib header: deflate compression method with a check value making the first two bytes a multiple of 31
+	if payload[0]&0x0f == 8 && payload[0]>>4 <= 7 && (uint16(payload[0])<<8|uint16(payload[1]))%31 == 0 {
+		return "zlib"
+	}
+	return ""
+}
@@ -0,0 +1,112 @@
+//
+// Copyright (c) 2019 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package runtime
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"encoding/json"
+	"testing"
+
+	"github.com/antoniomtz/app-functions-sdk-go/appcontext"
+	"github.com/antoniomtz/go-mod-messaging/pkg/types"
+	"github.com/edgexfoundry/go-mod-core-contracts/clients"
+	"github.com/edgexfoundry/go-mod-core-contracts/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func gzipBytes(data []byte) []byte {
+	var buffer bytes.Buffer
+	writer := gzip.NewWriter(&buffer)
+	writer.Write(data)
+	writer.Close()
+	return buffer.Bytes()
+}
+
+func zlibBytes(data []byte) []byte {
+	var buffer bytes.Buffer
+	writer := zlib.NewWriter(&buffer)
+	writer.Write(data)
+	writer.Close()
+	return buffer.Bytes()
+}
+
+func TestDecompressPayload(t *testing.T) {
+	data, _ := json.Marshal(models.Event{Device: devID1})
+
+	tests := []struct {
+		name     string
+		payload  []byte
+		encoding string
+	}{
+		{"uncompressed", data, ""},
+		{"identity", data, "identity"},
+		{"gzip detected", gzipBytes(data), ""},
+		{"gzip", gzipBytes(data), "gzip"},
+		{"zlib detected", zlibBytes(data), ""},
+		{"deflate", zlibBytes(data), "deflate"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			actual, err := decompressPayload(test.payload, test.encoding)
+			require.NoError(t, err)
+			assert.Equal(t, data, actual)
+		})
+	}
+}
+
+func TestDecompressPayloadErrors(t *testing.T) {
+	data, _ := json.Marshal(models.Event{Device: devID1})
+
+	_, err := decompressPayload(data, "br")
+	assert.Error(t, err, "Unsupported encoding should fail")
+
+	_, err = decompressPayload(data, "gzip")
+	assert.Error(t, err, "Uncompressed payload with gzip encoding should fail")
+}
+
+func TestProcessEventCompressed(t *testing.T) {
+	eventIn := models.Event{ID: "1234", Device: devID1}
+	eventInBytes, _ := json.Marshal(eventIn)
+
+	var received *models.Event
+	var rawPayload []byte
+	transform := func(edgexcontext *appcontext.Context, params ...interface{}) (bool, interface{}) {
+		event := params[0].(models.Event)
+		received = &event
+		rawPayload = edgexcontext.RawPayload
+		return false, nil
+	}
+	runtime := GolangRuntime{
+		Transforms: []func(edgexcontext *appcontext.Context, params ...interface{}) (bool, interface{}){transform},
+	}
+
+	envelope := types.MessageEnvelope{
+		CorrelationID: "123-234-345-456",
+		Payload:       gzipBytes(eventInBytes),
+		ContentType:   clients.ContentTypeJSON,
+	}
+	runtime.ProcessEvent(&appcontext.Context{LoggingClient: lc}, envelope)
+
+	require.NotNil(t, received, "Compressed event should have been processed")
+	assert.Equal(t, eventIn.ID, received.ID)
+	assert.Equal(t, devID1, received.Device)
+	assert.Equal(t, eventInBytes, rawPayload, "RawPayload should be decompressed")
+}
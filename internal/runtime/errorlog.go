@@ -0,0 +1,190 @@
+//
+// Copyright (c) 2019 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package runtime
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	goruntime "runtime"
+	"strings"
+	"sync"
+	"time"
+)
+
+const redactedValue = "***"
+
+// ErrorLogEntry records a pipeline function error along with the data the function was called with
+type ErrorLogEntry struct {
+	Timestamp     time.Time
+	CorrelationID string
+	Pipeline      string
+	FunctionIndex int
+	FunctionName  string
+	Error         string
+	// Payload is the data the failing function was called with, redacted and capped in size
+	Payload          string
+	PayloadTruncated bool
+}
+
+// ErrorLog retains the most recent pipeline errors so failures can be reproduced from the offending payload
+type ErrorLog struct {
+	capacity       int
+	maxPayloadSize int
+	redactFields   map[string]bool
+	mutex          sync.Mutex
+	entries        []ErrorLogEntry
+	next           int
+}
+
+// NewErrorLog creates an ErrorLog which retains up to capacity entries. Payloads larger than maxPayloadSize bytes
+// are truncated, and the values of the listed fields are redacted from JSON payloads.
+func NewErrorLog(capacity int, maxPayloadSize int, redactFields []string) *ErrorLog {
+	fields := make(map[string]bool, len(redactFields))
+	for _, field := range redactFields {
+		fields[strings.ToLower(field)] = true
+	}
+
+	return &ErrorLog{
+		capacity:       capacity,
+		maxPayloadSize: maxPayloadSize,
+		redactFields:   fields,
+	}
+}
+
+// Record adds an entry for the error returned by the function at functionIndex when called with payload
+func (log *ErrorLog) Record(correlationID string, pipeline string, functionIndex int, function interface{}, err error, payload interface{}) {
+	if log == nil || log.capacity <= 0 {
+		return
+	}
+
+	entry := ErrorLogEntry{
+		Timestamp:     time.Now(),
+		CorrelationID: correlationID,
+		Pipeline:      pipeline,
+		FunctionIndex: functionIndex,
+		FunctionName:  functionName(function),
+		Error:         err.Error(),
+	}
+	entry.Payload, entry.PayloadTruncated = log.formatPayload(payload)
+
+	log.mutex.Lock()
+	defer log.mutex.Unlock()
+
+	if len(log.entries) < log.capacity {
+		log.entries = append(log.entries, entry)
+	} else {
+		log.entries[log.next] = entry
+	}
+	log.next = (log.next + 1) % log.capacity
+}
+
+// Entries returns the retained entries, most recent first
+func (log *ErrorLog) Entries() []ErrorLogEntry {
+	entries := []ErrorLogEntry{}
+	if log == nil {
+		return entries
+	}
+
+	log.mutex.Lock()
+	defer log.mutex.Unlock()
+
+	for i := 1; i <= len(log.entries); i++ {
+		index := (log.next - i + len(log.entries)) % len(log.entries)
+		entries = append(entries, log.entries[index])
+	}
+	return entries
+}
+
+func (log *ErrorLog) formatPayload(payload interface{}) (string, bool) {
+	var data []byte
+	switch value := payload.(type) {
+	case nil:
+		return "", false
+	case []byte:
+		data = value
+	case string:
+		data = []byte(value)
+	default:
+		marshaled, err := json.Marshal(value)
+		if err != nil {
+			data = []byte(fmt.Sprintf("%+v", value))
+		} else {
+			data = marshaled
+		}
+	}
+
+	if len(log.redactFields) > 0 {
+		data = log.redact(data)
+	}
+
+	if log.maxPayloadSize > 0 && len(data) > log.maxPayloadSize {
+		return string(data[:log.maxPayloadSize]), true
+	}
+	return string(data), false
+}
+
+// redact replaces the values of the configured fields when data is a JSON document
+func (log *ErrorLog) redact(data []byte) []byte {
+	var document interface{}
+	if err := json.Unmarshal(data, &document); err != nil {
+		return data
+	}
+
+	redacted, err := json.Marshal(log.redactValue(document))
+	if err != nil {
+		return data
+	}
+	return redacted
+}
+
+func (log *ErrorLog) redactValue(value interface{}) interface{} {
+	switch typed := value.(type) {
+	case map[string]interface{}:
+		for key, child := range typed {
+			if log.redactFields[strings.ToLower(key)] {
+				typed[key] = redactedValue
+			} else {
+				typed[key] = log.redactValue(child)
+			}
+		}
+	case []interface{}:
+		for index, child := range typed {
+			typed[index] = log.redactValue(child)
+		}
+	}
+	return value
+}
+
+// functionName returns the name of a pipeline function, i.e. "transforms.Filter.FilterByDeviceName-fm"
+func functionName(function interface{}) string {
+	value := reflect.ValueOf(function)
+	if value.Kind() != reflect.Func {
+		return ""
+	}
+
+	details := goruntime.FuncForPC(value.Pointer())
+	if details == nil {
+		return ""
+	}
+
+	name := details.Name()
+	if index := strings.LastIndex(name, "/"); index >= 0 {
+		name = name[index+1:]
+	}
+	return name
+}
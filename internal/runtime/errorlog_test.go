@@ -0,0 +1,82 @@
+//
+// Copyright (c) 2019 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package runtime
+
+import (
+	"encoding/json"
+	"errors"
+	"strconv"
+	"testing"
+
+	"github.com/antoniomtz/app-functions-sdk-go/appcontext"
+	"github.com/antoniomtz/go-mod-messaging/pkg/types"
+	"github.com/edgexfoundry/go-mod-core-contracts/clients"
+	"github.com/edgexfoundry/go-mod-core-contracts/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestErrorLogRecordedByRuntime(t *testing.T) {
+	toCredentials := func(edgexcontext *appcontext.Context, params ...interface{}) (bool, interface{}) {
+		return true, `{"device":"id1","password":"secret"}`
+	}
+	fail := func(edgexcontext *appcontext.Context, params ...interface{}) (bool, interface{}) {
+		return false, errors.New("export failed")
+	}
+
+	runtime := GolangRuntime{
+		Transforms: []func(edgexcontext *appcontext.Context, params ...interface{}) (bool, interface{}){toCredentials, fail},
+		ErrorLog:   NewErrorLog(10, 0, []string{"Password"}),
+	}
+
+	eventInBytes, _ := json.Marshal(models.Event{Device: devID1})
+	envelope := types.MessageEnvelope{
+		CorrelationID: "123-234-345-456",
+		Payload:       eventInBytes,
+		ContentType:   clients.ContentTypeJSON,
+	}
+	runtime.ProcessEvent(&appcontext.Context{LoggingClient: lc}, envelope)
+
+	entries := runtime.ErrorLog.Entries()
+	require.Equal(t, 1, len(entries))
+	assert.Equal(t, "123-234-345-456", entries[0].CorrelationID)
+	assert.Equal(t, PrimaryPipelineName, entries[0].Pipeline)
+	assert.Equal(t, 1, entries[0].FunctionIndex)
+	assert.Contains(t, entries[0].FunctionName, "TestErrorLogRecordedByRuntime")
+	assert.Equal(t, "export failed", entries[0].Error)
+	assert.JSONEq(t, `{"device":"id1","password":"***"}`, entries[0].Payload)
+}
+
+func TestErrorLogCapacityAndTruncation(t *testing.T) {
+	log := NewErrorLog(2, 5, nil)
+	for i := 0; i < 3; i++ {
+		log.Record(strconv.Itoa(i), PrimaryPipelineName, 0, nil, errors.New("failed"), []byte("0123456789"))
+	}
+
+	entries := log.Entries()
+	require.Equal(t, 2, len(entries), "Only the most recent entries should be retained")
+	assert.Equal(t, "2", entries[0].CorrelationID, "Most recent entry should be first")
+	assert.Equal(t, "1", entries[1].CorrelationID)
+	assert.Equal(t, "01234", entries[0].Payload)
+	assert.True(t, entries[0].PayloadTruncated)
+}
+
+func TestErrorLogDisabled(t *testing.T) {
+	var log *ErrorLog
+	log.Record("1", PrimaryPipelineName, 0, nil, errors.New("failed"), "payload")
+	assert.Equal(t, 0, len(log.Entries()))
+}
@@ -0,0 +1,319 @@
+//
+// Copyright (c) 2019 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package runtime
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/antoniomtz/app-functions-sdk-go/appcontext"
+	"github.com/antoniomtz/app-functions-sdk-go/internal/store"
+	"github.com/antoniomtz/app-functions-sdk-go/internal/tracing"
+	"github.com/edgexfoundry/go-mod-core-contracts/clients"
+)
+
+// ExportMetrics contains the outcome of the calls to an export function
+type ExportMetrics struct {
+	Name string
+	// Succeeded is the number of calls which exported the data
+	Succeeded uint64
+	// Held is the number of calls which stopped the pipeline without an error, i.e. when the data was batched
+	Held uint64
+	// Failed is the number of calls which returned an error
+	Failed uint64
+	// DeadLettered is the number of failed calls whose data was stored in the dead letters
+	DeadLettered uint64
+	// PendingEvents is the number of events held by the export function which have not been exported yet
+	PendingEvents   int
+	LastSuccessTime time.Time
+	LastErrorTime   time.Time
+	LastError       string
+}
+
+// ExportStatus reports that an export function started failing, or that it recovered
+type ExportStatus struct {
+	Name    string `json:"name"`
+	Failing bool   `json:"failing"`
+	// ConsecutiveFailures is the number of calls in a row which failed when the export function started failing
+	ConsecutiveFailures int       `json:"consecutiveFailures,omitempty"`
+	LastError           string    `json:"lastError,omitempty"`
+	Time                time.Time `json:"time"`
+}
+
+// ExportTracker records the outcome of each call to the export functions it tracks
+type ExportTracker struct {
+	// Tracer emits a span for each call to the export functions. Nil disables tracing.
+	Tracer *tracing.Tracer
+	// FailureThreshold is the number of consecutive failed calls after which an export function is reported as
+	// failing to OnStatusChange. Zero disables status reports.
+	FailureThreshold int
+	// OnStatusChange is called when an export function starts failing, and when a call succeeds after it was failing
+	OnStatusChange func(status ExportStatus)
+	// Manifest records the events exported by each successful call, so downstream systems can reconcile them. Nil
+	// records nothing.
+	Manifest *ExportManifest
+	// DeadLetters store the data of the calls which failed, once the export function gave up retrying, so it is not
+	// dropped
+	DeadLetters []DeadLetter
+	// Store holds the data of the calls which failed, so RetryStored exports it again. Nil disables store and
+	// forward, and the data is stored in the dead letters right away.
+	Store store.StoreClient
+	// MaxRetryCount is the number of times stored data is exported again before it's given up on and stored in the
+	// dead letters. Zero retries until the data is evicted from the store.
+	MaxRetryCount int
+	// NewContext creates the context stored data is exported again with
+	NewContext func(correlationID string) *appcontext.Context
+	mutex      sync.Mutex
+	retrying   sync.Mutex
+	exports    []*trackedExport
+}
+
+type trackedExport struct {
+	name    string
+	metrics ExportMetrics
+	pending func() int
+	// retry exports stored data again, without storing it when it fails
+	retry func(edgexcontext *appcontext.Context, data interface{}) (bool, interface{})
+	// consecutiveFailures and failing track whether the export function is reported as failing
+	consecutiveFailures int
+	failing             bool
+}
+
+// Track returns a function which calls the export function and records its outcome under the name. Names used more
+// than once are numbered. pending returns the number of events held by the export function, and may be nil.
+func (tracker *ExportTracker) Track(name string, export func(*appcontext.Context, ...interface{}) (bool, interface{}), pending func() int) func(*appcontext.Context, ...interface{}) (bool, interface{}) {
+	trackedExportFunction, _ := tracker.track(name, export, pending)
+	return trackedExportFunction
+}
+
+// TrackBatch is Track for export functions which hold data in batches. It also returns the function the export
+// function gives the data of a held event to when exporting its batch fails after the call holding it returned. The
+// failure is recorded as a failed call, and the data is stored like the data of one, returning whether it was stored.
+func (tracker *ExportTracker) TrackBatch(name string, export func(*appcontext.Context, ...interface{}) (bool, interface{}), pending func() int) (func(*appcontext.Context, ...interface{}) (bool, interface{}), func(*appcontext.Context, interface{}, error) bool) {
+	trackedExportFunction, tracked := tracker.track(name, export, pending)
+	batchFailed := func(edgexcontext *appcontext.Context, data interface{}, err error) bool {
+		tracker.mutex.Lock()
+		status := tracked.record(false, err, tracker.FailureThreshold)
+		tracker.mutex.Unlock()
+
+		if status != nil && tracker.OnStatusChange != nil {
+			tracker.OnStatusChange(*status)
+		}
+		return tracker.failed(tracked, edgexcontext, data, err)
+	}
+	return trackedExportFunction, batchFailed
+}
+
+func (tracker *ExportTracker) track(name string, export func(*appcontext.Context, ...interface{}) (bool, interface{}), pending func() int) (func(*appcontext.Context, ...interface{}) (bool, interface{}), *trackedExport) {
+	tracker.mutex.Lock()
+	defer tracker.mutex.Unlock()
+
+	tracked := &trackedExport{name: name, metrics: ExportMetrics{Name: name}, pending: pending}
+	count := 1
+	for _, other := range tracker.exports {
+		if other.name == name {
+			count++
+		}
+	}
+	if count > 1 {
+		tracked.metrics.Name = fmt.Sprintf("%s#%d", name, count)
+	}
+
+	tracker.exports = append(tracker.exports, tracked)
+
+	var trackedExportFunction func(*appcontext.Context, ...interface{}) (bool, interface{})
+	call := func(edgexcontext *appcontext.Context, retrying bool, params ...interface{}) (bool, interface{}) {
+		parentTraceParent := edgexcontext.TraceParent
+		span := tracker.Tracer.Start("export "+tracked.metrics.Name, tracing.KindClient, parentTraceParent, edgexcontext.CorrelationID)
+		if span != nil {
+			edgexcontext.TraceParent = span.TraceParent()
+		}
+		continuePipeline, result := export(edgexcontext, params...)
+		edgexcontext.TraceParent = parentTraceParent
+
+		err, failed := result.(error)
+		if failed && !continuePipeline {
+			span.Finish(err)
+		} else {
+			span.Finish(nil)
+		}
+
+		tracker.mutex.Lock()
+		status := tracked.record(continuePipeline, result, tracker.FailureThreshold)
+		tracker.mutex.Unlock()
+
+		if status != nil && tracker.OnStatusChange != nil {
+			tracker.OnStatusChange(*status)
+		}
+		if continuePipeline && len(params) > 0 {
+			// re-exports are made through the tracked function so they are recorded in turn
+			tracker.Manifest.record(tracked.metrics.Name, trackedExportFunction, edgexcontext, params[0])
+		}
+		if failed && !continuePipeline && len(params) > 0 && !retrying {
+			tracker.failed(tracked, edgexcontext, params[0], err)
+		}
+		return continuePipeline, result
+	}
+	trackedExportFunction = func(edgexcontext *appcontext.Context, params ...interface{}) (bool, interface{}) {
+		return call(edgexcontext, false, params...)
+	}
+	tracked.retry = func(edgexcontext *appcontext.Context, data interface{}) (bool, interface{}) {
+		return call(edgexcontext, true, data)
+	}
+	return trackedExportFunction, tracked
+}
+
+// failed stores the data of a failed call for store and forward, or with every dead letter when store and forward
+// is disabled or the data can't be stored. It returns whether the data was stored.
+func (tracker *ExportTracker) failed(tracked *trackedExport, edgexcontext *appcontext.Context, data interface{}, exportErr error) bool {
+	if tracker.Store == nil && len(tracker.DeadLetters) == 0 {
+		return false
+	}
+	payload, contentType, err := exportPayload(data)
+	if err != nil {
+		edgexcontext.LoggingClient.Error(fmt.Sprintf("Failed to marshal the data of export %s to store it: %s", tracked.metrics.Name, err.Error()), clients.CorrelationHeader, edgexcontext.CorrelationID)
+		return false
+	}
+
+	if tracker.Store != nil {
+		_, err := tracker.Store.Store(store.StoredObject{
+			Export:        tracked.metrics.Name,
+			CorrelationID: edgexcontext.CorrelationID,
+			EventID:       edgexcontext.EventID,
+			EventChecksum: edgexcontext.EventChecksum,
+			ContentType:   contentType,
+			Payload:       payload,
+			LastError:     exportErr.Error(),
+			Created:       time.Now(),
+		})
+		if err == nil {
+			return true
+		}
+		edgexcontext.LoggingClient.Error(fmt.Sprintf("Failed to store the data of export %s for store and forward: %s", tracked.metrics.Name, err.Error()), clients.CorrelationHeader, edgexcontext.CorrelationID)
+	}
+
+	err = tracker.deadLetter(DeadLetterMessage{
+		CorrelationID: edgexcontext.CorrelationID,
+		Export:        tracked.metrics.Name,
+		ContentType:   contentType,
+		Payload:       payload,
+		Failures:      1,
+		LastError:     exportErr.Error(),
+		Time:          time.Now(),
+	})
+	if err != nil {
+		edgexcontext.LoggingClient.Error(fmt.Sprintf("Failed to store dead letter of export %s: %s", tracked.metrics.Name, err.Error()), clients.CorrelationHeader, edgexcontext.CorrelationID)
+		return false
+	}
+	return true
+}
+
+// deadLetter stores the message with every dead letter, returning the first error
+func (tracker *ExportTracker) deadLetter(message DeadLetterMessage) error {
+	if len(tracker.DeadLetters) == 0 {
+		return nil
+	}
+	tracker.mutex.Lock()
+	for _, tracked := range tracker.exports {
+		if tracked.metrics.Name == message.Export {
+			tracked.metrics.DeadLettered++
+		}
+	}
+	tracker.mutex.Unlock()
+
+	var firstErr error
+	for _, deadLetter := range tracker.DeadLetters {
+		if err := deadLetter(message); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// exportPayload returns the data passed to an export function as bytes to be stored, along with their content type.
+// Strings and []byte are stored as they are, and other data as JSON.
+func exportPayload(data interface{}) ([]byte, string, error) {
+	switch data := data.(type) {
+	case []byte:
+		return data, "", nil
+	case string:
+		return []byte(data), "", nil
+	default:
+		payload, err := json.Marshal(data)
+		if err != nil {
+			return nil, "", err
+		}
+		return payload, clients.ContentTypeJSON, nil
+	}
+}
+
+// record adds the outcome of a call to the metrics, returning the status to report when the export function starts
+// failing or recovers. The tracker's mutex must be held.
+func (tracked *trackedExport) record(continuePipeline bool, result interface{}, failureThreshold int) *ExportStatus {
+	now := time.Now()
+	err, failed := result.(error)
+	switch {
+	case continuePipeline:
+		tracked.metrics.Succeeded++
+		tracked.metrics.LastSuccessTime = now
+		tracked.consecutiveFailures = 0
+		if tracked.failing {
+			tracked.failing = false
+			return &ExportStatus{Name: tracked.metrics.Name, Failing: false, Time: now}
+		}
+	case failed:
+		tracked.metrics.Failed++
+		tracked.metrics.LastErrorTime = now
+		tracked.metrics.LastError = err.Error()
+		tracked.consecutiveFailures++
+		if failureThreshold > 0 && !tracked.failing && tracked.consecutiveFailures >= failureThreshold {
+			tracked.failing = true
+			return &ExportStatus{
+				Name:                tracked.metrics.Name,
+				Failing:             true,
+				ConsecutiveFailures: tracked.consecutiveFailures,
+				LastError:           tracked.metrics.LastError,
+				Time:                now,
+			}
+		}
+	default:
+		tracked.metrics.Held++
+	}
+	return nil
+}
+
+// Metrics returns a snapshot of the metrics of each tracked export function, in the order they were tracked
+func (tracker *ExportTracker) Metrics() []ExportMetrics {
+	if tracker == nil {
+		return nil
+	}
+
+	tracker.mutex.Lock()
+	defer tracker.mutex.Unlock()
+
+	metrics := make([]ExportMetrics, 0, len(tracker.exports))
+	for _, tracked := range tracker.exports {
+		snapshot := tracked.metrics
+		if tracked.pending != nil {
+			snapshot.PendingEvents = tracked.pending()
+		}
+		metrics = append(metrics, snapshot)
+	}
+	return metrics
+}
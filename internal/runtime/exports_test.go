@@ -0,0 +1,165 @@
+//
+// Copyright (c) 2019 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package runtime
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/antoniomtz/app-functions-sdk-go/appcontext"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExportTracker(t *testing.T) {
+	tracker := &ExportTracker{}
+	outcomes := []error{nil, errors.New("connection refused"), nil}
+	calls := 0
+	export := tracker.Track("HTTPPost", func(edgexcontext *appcontext.Context, params ...interface{}) (bool, interface{}) {
+		err := outcomes[calls%len(outcomes)]
+		calls++
+		if err != nil {
+			return false, err
+		}
+		return true, nil
+	}, nil)
+	batch := tracker.Track("HTTPPost", func(edgexcontext *appcontext.Context, params ...interface{}) (bool, interface{}) {
+		return false, nil
+	}, func() int { return 3 })
+
+	for i := 0; i < 4; i++ {
+		export(&appcontext.Context{LoggingClient: lc}, "data")
+	}
+	continuePipeline, result := batch(&appcontext.Context{LoggingClient: lc}, "data")
+	assert.False(t, continuePipeline)
+	assert.Nil(t, result)
+
+	metrics := tracker.Metrics()
+	require.Equal(t, 2, len(metrics))
+	assert.Equal(t, "HTTPPost", metrics[0].Name)
+	assert.Equal(t, uint64(3), metrics[0].Succeeded)
+	assert.Equal(t, uint64(1), metrics[0].Failed)
+	assert.Equal(t, "connection refused", metrics[0].LastError)
+	assert.False(t, metrics[0].LastSuccessTime.IsZero())
+	assert.Equal(t, "HTTPPost#2", metrics[1].Name, "Repeated names should be numbered")
+	assert.Equal(t, uint64(1), metrics[1].Held)
+	assert.Equal(t, 3, metrics[1].PendingEvents)
+}
+
+func TestExportTrackerStatusChange(t *testing.T) {
+	var statuses []ExportStatus
+	tracker := &ExportTracker{
+		FailureThreshold: 2,
+		OnStatusChange: func(status ExportStatus) {
+			statuses = append(statuses, status)
+		},
+	}
+	outcomes := []interface{}{errors.New("timeout"), errors.New("connection refused"), errors.New("timeout"), nil, nil}
+	calls := 0
+	export := tracker.Track("MQTTSend", func(edgexcontext *appcontext.Context, params ...interface{}) (bool, interface{}) {
+		outcome := outcomes[calls]
+		calls++
+		if outcome != nil {
+			return false, outcome
+		}
+		return true, nil
+	}, nil)
+
+	export(&appcontext.Context{LoggingClient: lc}, "data")
+	assert.Equal(t, 0, len(statuses), "a single failure is below the threshold")
+	export(&appcontext.Context{LoggingClient: lc}, "data")
+	require.Equal(t, 1, len(statuses))
+	assert.Equal(t, "MQTTSend", statuses[0].Name)
+	assert.True(t, statuses[0].Failing)
+	assert.Equal(t, 2, statuses[0].ConsecutiveFailures)
+	assert.Equal(t, "connection refused", statuses[0].LastError)
+
+	export(&appcontext.Context{LoggingClient: lc}, "data")
+	assert.Equal(t, 1, len(statuses), "failing should only be reported once")
+	export(&appcontext.Context{LoggingClient: lc}, "data")
+	require.Equal(t, 2, len(statuses))
+	assert.False(t, statuses[1].Failing)
+	export(&appcontext.Context{LoggingClient: lc}, "data")
+	assert.Equal(t, 2, len(statuses), "recovery should only be reported once")
+}
+
+func TestExportTrackerDeadLetters(t *testing.T) {
+	var messages []DeadLetterMessage
+	tracker := &ExportTracker{DeadLetters: []DeadLetter{
+		func(message DeadLetterMessage) error {
+			messages = append(messages, message)
+			return nil
+		},
+		func(message DeadLetterMessage) error {
+			return errors.New("disk full")
+		},
+	}}
+	outcomes := []interface{}{errors.New("connection refused"), nil, nil, errors.New("timeout")}
+	calls := 0
+	export := tracker.Track("MQTTSend", func(edgexcontext *appcontext.Context, params ...interface{}) (bool, interface{}) {
+		outcome := outcomes[calls]
+		calls++
+		if outcome != nil {
+			return false, outcome
+		}
+		return calls != 3, nil
+	}, nil)
+
+	export(&appcontext.Context{LoggingClient: lc, CorrelationID: "id1"}, []byte("data"))
+	export(&appcontext.Context{LoggingClient: lc, CorrelationID: "id2"}, "data")
+	export(&appcontext.Context{LoggingClient: lc, CorrelationID: "id3"}, "batched")
+	export(&appcontext.Context{LoggingClient: lc, CorrelationID: "id4"}, map[string]int{"value": 42})
+
+	require.Len(t, messages, 2, "only the data of failed calls should be stored, despite another dead letter failing")
+	assert.Equal(t, "id1", messages[0].CorrelationID)
+	assert.Equal(t, "MQTTSend", messages[0].Export)
+	assert.Equal(t, []byte("data"), messages[0].Payload)
+	assert.Equal(t, "connection refused", messages[0].LastError)
+	assert.False(t, messages[0].Time.IsZero())
+	assert.Equal(t, "id4", messages[1].CorrelationID)
+	assert.Equal(t, `{"value":42}`, string(messages[1].Payload), "other data should be stored as JSON")
+	assert.Equal(t, "application/json", messages[1].ContentType)
+	assert.Equal(t, uint64(2), tracker.Metrics()[0].DeadLettered)
+}
+
+func TestExportTrackerTrackBatch(t *testing.T) {
+	var messages []DeadLetterMessage
+	tracker := &ExportTracker{}
+	_, batchFailed := tracker.TrackBatch("S3Upload", func(edgexcontext *appcontext.Context, params ...interface{}) (bool, interface{}) {
+		return false, nil
+	}, nil)
+
+	stored := batchFailed(&appcontext.Context{LoggingClient: lc, CorrelationID: "id1"}, "held", errors.New("timeout"))
+	assert.False(t, stored, "nothing should be stored without store and forward or dead letters")
+
+	tracker.DeadLetters = []DeadLetter{func(message DeadLetterMessage) error {
+		messages = append(messages, message)
+		return nil
+	}}
+	stored = batchFailed(&appcontext.Context{LoggingClient: lc, CorrelationID: "id2"}, "held", errors.New("timeout"))
+	assert.True(t, stored)
+	require.Len(t, messages, 1)
+	assert.Equal(t, "id2", messages[0].CorrelationID)
+	assert.Equal(t, "S3Upload", messages[0].Export)
+	assert.Equal(t, uint64(2), tracker.Metrics()[0].Failed, "failed batches should be recorded as failed calls")
+	assert.Equal(t, "timeout", tracker.Metrics()[0].LastError)
+}
+
+func TestExportTrackerNil(t *testing.T) {
+	var tracker *ExportTracker
+	assert.Nil(t, tracker.Metrics())
+}
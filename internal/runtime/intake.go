@@ -0,0 +1,96 @@
+//
+// Copyright (c) 2019 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package runtime
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// drainPollInterval is how often Drain checks whether the events in flight have been processed
+const drainPollInterval = 10 * time.Millisecond
+
+// IntakeStatus reports whether the intake of new events is paused, and the depth of the ingest queue when there is one
+type IntakeStatus struct {
+	Paused bool          `json:"paused"`
+	Queue  *QueueMetrics `json:"queue,omitempty"`
+}
+
+// Pause stops the intake of new events, i.e. during maintenance of an export destination, so that they are
+// buffered upstream rather than failing. Events already being processed finish normally.
+func (gr *GolangRuntime) Pause() {
+	gr.intakeMutex.Lock()
+	defer gr.intakeMutex.Unlock()
+
+	if gr.resumed == nil {
+		gr.resumed = make(chan struct{})
+	}
+}
+
+// Resume restarts the intake of new events after Pause
+func (gr *GolangRuntime) Resume() {
+	gr.intakeMutex.Lock()
+	defer gr.intakeMutex.Unlock()
+
+	if gr.resumed != nil {
+		close(gr.resumed)
+		gr.resumed = nil
+	}
+}
+
+// IntakeStatus returns whether the intake of new events is paused, along with the metrics of the ingest queue
+func (gr *GolangRuntime) IntakeStatus() IntakeStatus {
+	gr.intakeMutex.Lock()
+	defer gr.intakeMutex.Unlock()
+
+	status := IntakeStatus{Paused: gr.resumed != nil}
+	if gr.Queue != nil {
+		metrics := gr.Queue.Metrics()
+		status.Queue = &metrics
+	}
+	return status
+}
+
+// WaitWhilePaused blocks while the intake of new events is paused. Triggers which pull events call this before
+// receiving the next event.
+func (gr *GolangRuntime) WaitWhilePaused() {
+	gr.intakeMutex.Lock()
+	resumed := gr.resumed
+	gr.intakeMutex.Unlock()
+
+	if resumed != nil {
+		<-resumed
+	}
+}
+
+// InFlight returns the number of events being processed
+func (gr *GolangRuntime) InFlight() int {
+	return int(atomic.LoadInt64(&gr.inFlight))
+}
+
+// Drain waits until the events being processed have been processed, i.e. after the trigger has been stopped, for
+// up to the timeout. It returns false when events were still being processed at the timeout.
+func (gr *GolangRuntime) Drain(timeout time.Duration) bool {
+	deadline := time.Now().Add(timeout)
+	for gr.InFlight() > 0 {
+		if time.Now().After(deadline) {
+			return false
+		}
+		time.Sleep(drainPollInterval)
+	}
+	return true
+}
@@ -0,0 +1,71 @@
+//
+// Copyright (c) 2019 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package runtime
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPauseResume(t *testing.T) {
+	runtime := GolangRuntime{}
+	assert.False(t, runtime.IntakeStatus().Paused)
+	runtime.WaitWhilePaused()
+
+	runtime.Pause()
+	runtime.Pause()
+	assert.True(t, runtime.IntakeStatus().Paused)
+
+	waited := make(chan struct{})
+	go func() {
+		runtime.WaitWhilePaused()
+		close(waited)
+	}()
+
+	select {
+	case <-waited:
+		t.Fatal("WaitWhilePaused should block while paused")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	runtime.Resume()
+	runtime.Resume()
+	assert.False(t, runtime.IntakeStatus().Paused)
+	select {
+	case <-waited:
+	case <-time.After(time.Second):
+		t.Fatal("WaitWhilePaused should return once resumed")
+	}
+}
+
+func TestDrain(t *testing.T) {
+	runtime := GolangRuntime{}
+	assert.True(t, runtime.Drain(0), "nothing is being processed")
+
+	atomic.AddInt64(&runtime.inFlight, 1)
+	assert.Equal(t, 1, runtime.InFlight())
+	assert.False(t, runtime.Drain(20*time.Millisecond), "the event is still being processed")
+
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		atomic.AddInt64(&runtime.inFlight, -1)
+	}()
+	assert.True(t, runtime.Drain(5*time.Second))
+}
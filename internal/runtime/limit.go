@@ -0,0 +1,129 @@
+//
+// Copyright (c) 2019 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package runtime
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/antoniomtz/app-functions-sdk-go/appcontext"
+)
+
+// ConcurrencyMetrics contains the calls made through a ConcurrencyLimit
+type ConcurrencyMetrics struct {
+	Name          string
+	MaxConcurrent int
+	// Running is the number of calls in progress
+	Running int
+	// Waiting is the number of calls waiting for a call in progress to finish
+	Waiting int
+	// Calls is the number of calls made to the function
+	Calls uint64
+	// Rejected is the number of calls given up on while waiting, because the pipeline deadline passed or the service
+	// stopped
+	Rejected uint64
+	// WaitTimeNanos is the total time calls spent waiting
+	WaitTimeNanos int64
+}
+
+// ConcurrencyLimit restricts the number of concurrent calls to a function, i.e. an expensive inference, so it
+// can't use up the CPU while the other functions of the pipeline are idle. Calls beyond the limit wait in turn for a
+// call in progress to finish.
+type ConcurrencyLimit struct {
+	Name          string
+	Transform     func(*appcontext.Context, ...interface{}) (bool, interface{})
+	MaxConcurrent int
+	once          sync.Once
+	slots         chan struct{}
+	waiting       int64
+	calls         uint64
+	rejected      uint64
+	waitTimeNanos int64
+}
+
+// Limit calls the function once fewer than MaxConcurrent calls are in progress. It stops the pipeline with an error
+// when the pipeline deadline passes, or the service stops, before the function can be called.
+func (limit *ConcurrencyLimit) Limit(edgexcontext *appcontext.Context, params ...interface{}) (bool, interface{}) {
+	if err := limit.acquire(edgexcontext); err != nil {
+		atomic.AddUint64(&limit.rejected, 1)
+		return false, err
+	}
+	defer func() { <-limit.slots }()
+
+	atomic.AddUint64(&limit.calls, 1)
+	return limit.Transform(edgexcontext, params...)
+}
+
+// callSlots returns the channel holding a value for each call in progress, creating it on first use
+func (limit *ConcurrencyLimit) callSlots() chan struct{} {
+	limit.once.Do(func() {
+		maxConcurrent := limit.MaxConcurrent
+		if maxConcurrent <= 0 {
+			maxConcurrent = 1
+		}
+		limit.slots = make(chan struct{}, maxConcurrent)
+	})
+	return limit.slots
+}
+
+// acquire waits for a call slot until the pipeline deadline or the context of the event is cancelled
+func (limit *ConcurrencyLimit) acquire(edgexcontext *appcontext.Context) error {
+	slots := limit.callSlots()
+	select {
+	case slots <- struct{}{}:
+		return nil
+	default:
+	}
+
+	atomic.AddInt64(&limit.waiting, 1)
+	started := time.Now()
+	defer func() {
+		atomic.AddInt64(&limit.waiting, -1)
+		atomic.AddInt64(&limit.waitTimeNanos, int64(time.Since(started)))
+	}()
+
+	var deadline <-chan time.Time
+	if !edgexcontext.Deadline.IsZero() {
+		timer := time.NewTimer(time.Until(edgexcontext.Deadline))
+		defer timer.Stop()
+		deadline = timer.C
+	}
+	select {
+	case slots <- struct{}{}:
+		return nil
+	case <-deadline:
+		return fmt.Errorf("pipeline deadline exceeded waiting to call %s", limit.Name)
+	case <-edgexcontext.RequestContext().Done():
+		return fmt.Errorf("abandoned waiting to call %s as the service is stopping", limit.Name)
+	}
+}
+
+// Metrics returns a snapshot of the calls made through the limit
+func (limit *ConcurrencyLimit) Metrics() ConcurrencyMetrics {
+	slots := limit.callSlots()
+	return ConcurrencyMetrics{
+		Name:          limit.Name,
+		MaxConcurrent: cap(slots),
+		Running:       len(slots),
+		Waiting:       int(atomic.LoadInt64(&limit.waiting)),
+		Calls:         atomic.LoadUint64(&limit.calls),
+		Rejected:      atomic.LoadUint64(&limit.rejected),
+		WaitTimeNanos: atomic.LoadInt64(&limit.waitTimeNanos),
+	}
+}
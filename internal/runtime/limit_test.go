@@ -0,0 +1,117 @@
+//
+// Copyright (c) 2019 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package runtime
+
+import (
+	syscontext "context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/antoniomtz/app-functions-sdk-go/appcontext"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLimit(t *testing.T) {
+	release := make(chan struct{})
+	var running, maxRunning int32
+	infer := func(edgexcontext *appcontext.Context, params ...interface{}) (bool, interface{}) {
+		current := atomic.AddInt32(&running, 1)
+		for {
+			highest := atomic.LoadInt32(&maxRunning)
+			if current <= highest || atomic.CompareAndSwapInt32(&maxRunning, highest, current) {
+				break
+			}
+		}
+		<-release
+		atomic.AddInt32(&running, -1)
+		return true, params[0]
+	}
+	limit := &ConcurrencyLimit{Name: "inference", Transform: infer, MaxConcurrent: 2}
+
+	var wait sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wait.Add(1)
+		go func(i int) {
+			defer wait.Done()
+			continuePipeline, result := limit.Limit(&appcontext.Context{LoggingClient: lc}, i)
+			assert.True(t, continuePipeline)
+			assert.Equal(t, i, result)
+		}(i)
+	}
+
+	for deadline := time.Now().Add(time.Second); time.Now().Before(deadline); time.Sleep(time.Millisecond) {
+		if metrics := limit.Metrics(); metrics.Running == 2 && metrics.Waiting == 3 {
+			break
+		}
+	}
+	metrics := limit.Metrics()
+	assert.Equal(t, 2, metrics.Running)
+	assert.Equal(t, 3, metrics.Waiting, "the calls beyond the limit should wait")
+
+	close(release)
+	wait.Wait()
+
+	assert.Equal(t, int32(2), atomic.LoadInt32(&maxRunning), "no more than 2 calls should run at once")
+	metrics = limit.Metrics()
+	assert.Equal(t, "inference", metrics.Name)
+	assert.Equal(t, 2, metrics.MaxConcurrent)
+	assert.Equal(t, 0, metrics.Running)
+	assert.Equal(t, 0, metrics.Waiting)
+	assert.Equal(t, uint64(5), metrics.Calls)
+	assert.Equal(t, uint64(0), metrics.Rejected)
+	assert.True(t, metrics.WaitTimeNanos > 0)
+}
+
+func TestLimitDeadline(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	called := make(chan struct{})
+	infer := func(edgexcontext *appcontext.Context, params ...interface{}) (bool, interface{}) {
+		close(called)
+		<-release
+		return true, nil
+	}
+	limit := &ConcurrencyLimit{Name: "inference", Transform: infer, MaxConcurrent: 1}
+	go limit.Limit(&appcontext.Context{LoggingClient: lc}, "busy")
+	<-called
+
+	context := &appcontext.Context{LoggingClient: lc, Deadline: time.Now().Add(10 * time.Millisecond)}
+	continuePipeline, result := limit.Limit(context, "late")
+	assert.False(t, continuePipeline)
+	require.Implements(t, (*error)(nil), result)
+	assert.Contains(t, result.(error).Error(), "pipeline deadline exceeded waiting to call inference")
+
+	service, cancel := syscontext.WithCancel(syscontext.Background())
+	cancel()
+	context = &appcontext.Context{LoggingClient: lc, Ctx: service}
+	continuePipeline, result = limit.Limit(context, "stopping")
+	assert.False(t, continuePipeline)
+	require.Implements(t, (*error)(nil), result)
+	assert.Contains(t, result.(error).Error(), "as the service is stopping")
+
+	metrics := limit.Metrics()
+	assert.Equal(t, uint64(1), metrics.Calls)
+	assert.Equal(t, uint64(2), metrics.Rejected)
+}
+
+func TestLimitDefaultsToOneCall(t *testing.T) {
+	limit := &ConcurrencyLimit{Name: "inference"}
+	assert.Equal(t, 1, limit.Metrics().MaxConcurrent)
+}
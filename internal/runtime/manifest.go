@@ -0,0 +1,216 @@
+//
+// Copyright (c) 2019 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package runtime
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/antoniomtz/app-functions-sdk-go/appcontext"
+)
+
+// ManifestEntry records an EdgeX Event whose data was exported
+type ManifestEntry struct {
+	EventID       string    `json:"eventId"`
+	CorrelationID string    `json:"correlationId,omitempty"`
+	Export        string    `json:"export"`
+	Time          time.Time `json:"time"`
+	// Batched is true for events exported in a batch along with the event which completed it. Their data isn't
+	// retained, so they can't be exported again.
+	Batched bool `json:"batched,omitempty"`
+}
+
+// ReconcileRequest lists the IDs of the EdgeX Events received by a downstream system, to be compared with the
+// events exported
+type ReconcileRequest struct {
+	// Export restricts the comparison to the events exported by the named export function. Empty compares the events
+	// exported by every export function.
+	Export   string   `json:"export"`
+	Received []string `json:"received"`
+	// Since and Until restrict the comparison to the events exported within the period, i.e. to leave out events the
+	// downstream system may not have received yet. Zero leaves the period open.
+	Since time.Time `json:"since"`
+	Until time.Time `json:"until"`
+	// Reexport exports the data of the missing events again
+	Reexport bool `json:"reexport"`
+}
+
+// ReconcileResult reports the exported EdgeX Events a downstream system did not receive
+type ReconcileResult struct {
+	// Checked is the number of exported events compared
+	Checked int             `json:"checked"`
+	Missing []ManifestEntry `json:"missing"`
+	// Reexported lists the IDs of the missing events whose data was exported again
+	Reexported []string `json:"reexported,omitempty"`
+	// Failed holds the errors, keyed by event ID, of the missing events which couldn't be exported again
+	Failed map[string]string `json:"failed,omitempty"`
+}
+
+// ExportManifest retains the IDs of the most recently exported EdgeX Events along with the data exported, so
+// downstream systems can prove they received every event and have the missing ones exported again
+type ExportManifest struct {
+	capacity int
+	mutex    sync.Mutex
+	exported []*exportedData
+	events   int
+}
+
+// exportedData is the data of an EdgeX Event passed to an export function, along with the events exported with it
+type exportedData struct {
+	entries []ManifestEntry
+	export  func(*appcontext.Context, ...interface{}) (bool, interface{})
+	context appcontext.Context
+	data    interface{}
+}
+
+// NewExportManifest creates an ExportManifest which retains up to capacity exported events
+func NewExportManifest(capacity int) *ExportManifest {
+	return &ExportManifest{capacity: capacity}
+}
+
+// record adds the EdgeX Event of the context, along with the events batched with it, as exported by the export
+// function with data, dropping the oldest events beyond the capacity
+func (manifest *ExportManifest) record(name string, export func(*appcontext.Context, ...interface{}) (bool, interface{}), edgexcontext *appcontext.Context, data interface{}) {
+	if manifest == nil || manifest.capacity <= 0 {
+		return
+	}
+
+	now := time.Now()
+	exported := &exportedData{export: export, context: *edgexcontext, data: data}
+	if event := edgexcontext.EventReference(); eventID(event) != "" {
+		exported.entries = append(exported.entries, ManifestEntry{EventID: eventID(event), CorrelationID: event.CorrelationID, Export: name, Time: now})
+	}
+	for _, event := range edgexcontext.BatchedEvents() {
+		if eventID(event) != "" {
+			exported.entries = append(exported.entries, ManifestEntry{EventID: eventID(event), CorrelationID: event.CorrelationID, Export: name, Time: now, Batched: true})
+		}
+	}
+	if len(exported.entries) == 0 {
+		return
+	}
+	// re-exports run without the deadline of the event, which has passed
+	exported.context.Deadline = time.Time{}
+	exported.context.Replayed = true
+
+	manifest.mutex.Lock()
+	defer manifest.mutex.Unlock()
+
+	manifest.exported = append(manifest.exported, exported)
+	manifest.events += len(exported.entries)
+	for manifest.events > manifest.capacity && len(manifest.exported) > 1 {
+		manifest.events -= len(manifest.exported[0].entries)
+		manifest.exported[0] = nil
+		manifest.exported = manifest.exported[1:]
+	}
+}
+
+// eventID identifies an EdgeX Event by its ID, or by its checksum for CBOR events
+func eventID(event appcontext.EventReference) string {
+	if event.ID != "" {
+		return event.ID
+	}
+	return event.Checksum
+}
+
+// Entries returns the retained events exported by the named export function since the given time, oldest first.
+// An empty name returns the events of every export function, and a zero time every retained event.
+func (manifest *ExportManifest) Entries(export string, since time.Time) []ManifestEntry {
+	entries := []ManifestEntry{}
+	if manifest == nil {
+		return entries
+	}
+
+	manifest.mutex.Lock()
+	defer manifest.mutex.Unlock()
+
+	for _, exported := range manifest.exported {
+		for _, entry := range exported.entries {
+			if (export == "" || entry.Export == export) && !entry.Time.Before(since) {
+				entries = append(entries, entry)
+			}
+		}
+	}
+	return entries
+}
+
+// Reconcile compares the retained events with those received by a downstream system, exporting the data of the
+// missing events again when requested
+func (manifest *ExportManifest) Reconcile(request ReconcileRequest) ReconcileResult {
+	result := ReconcileResult{Missing: []ManifestEntry{}}
+	if manifest == nil {
+		return result
+	}
+
+	received := make(map[string]bool, len(request.Received))
+	for _, id := range request.Received {
+		received[id] = true
+	}
+
+	var missing []*exportedData
+	manifest.mutex.Lock()
+	for _, exported := range manifest.exported {
+		missingData := false
+		for _, entry := range exported.entries {
+			if request.Export != "" && entry.Export != request.Export {
+				continue
+			}
+			if entry.Time.Before(request.Since) || (!request.Until.IsZero() && entry.Time.After(request.Until)) {
+				continue
+			}
+			result.Checked++
+			if received[entry.EventID] {
+				continue
+			}
+			result.Missing = append(result.Missing, entry)
+			if entry.Batched {
+				if request.Reexport {
+					result.failed(entry.EventID, errors.New("the event was exported in a batch and its data isn't retained"))
+				}
+			} else {
+				missingData = true
+			}
+		}
+		if missingData {
+			missing = append(missing, exported)
+		}
+	}
+	manifest.mutex.Unlock()
+
+	if !request.Reexport {
+		return result
+	}
+	// the export functions record the events exported again, so they're called without holding the mutex
+	for _, exported := range missing {
+		id := exported.entries[0].EventID
+		edgexcontext := exported.context
+		continuePipeline, output := exported.export(&edgexcontext, exported.data)
+		if err, failed := output.(error); failed && !continuePipeline {
+			result.failed(id, err)
+			continue
+		}
+		result.Reexported = append(result.Reexported, id)
+	}
+	return result
+}
+
+func (result *ReconcileResult) failed(id string, err error) {
+	if result.Failed == nil {
+		result.Failed = make(map[string]string)
+	}
+	result.Failed[id] = err.Error()
+}
@@ -0,0 +1,133 @@
+//
+// Copyright (c) 2019 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package runtime
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/antoniomtz/app-functions-sdk-go/appcontext"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExportManifest(t *testing.T) {
+	tracker := &ExportTracker{Manifest: NewExportManifest(10)}
+	var exported []interface{}
+	export := tracker.Track("HTTPPost", func(edgexcontext *appcontext.Context, params ...interface{}) (bool, interface{}) {
+		exported = append(exported, params[0])
+		return true, nil
+	}, nil)
+	failing := tracker.Track("MQTTSend", func(edgexcontext *appcontext.Context, params ...interface{}) (bool, interface{}) {
+		return false, errors.New("connection refused")
+	}, nil)
+
+	started := time.Now()
+	export(&appcontext.Context{LoggingClient: lc, EventID: "event1", CorrelationID: "correlation1"}, "data1")
+	batch := &appcontext.Context{LoggingClient: lc, EventID: "event2"}
+	batch.AddBatchedEvents(appcontext.EventReference{ID: "event3"})
+	export(batch, "data2")
+	export(&appcontext.Context{LoggingClient: lc}, "no event")
+	failing(&appcontext.Context{LoggingClient: lc, EventID: "event4"}, "data4")
+
+	entries := tracker.Manifest.Entries("", time.Time{})
+	require.Len(t, entries, 3, "only the events exported should be recorded")
+	assert.Equal(t, "event1", entries[0].EventID)
+	assert.Equal(t, "correlation1", entries[0].CorrelationID)
+	assert.Equal(t, "HTTPPost", entries[0].Export)
+	assert.False(t, entries[0].Time.Before(started))
+	assert.Equal(t, "event2", entries[1].EventID)
+	assert.False(t, entries[1].Batched)
+	assert.Equal(t, "event3", entries[2].EventID)
+	assert.True(t, entries[2].Batched)
+
+	assert.Empty(t, tracker.Manifest.Entries("MQTTSend", time.Time{}))
+	assert.Empty(t, tracker.Manifest.Entries("", time.Now().Add(time.Minute)))
+
+	result := tracker.Manifest.Reconcile(ReconcileRequest{Received: []string{"event2"}})
+	assert.Equal(t, 3, result.Checked)
+	require.Len(t, result.Missing, 2)
+	assert.Equal(t, "event1", result.Missing[0].EventID)
+	assert.Equal(t, "event3", result.Missing[1].EventID)
+	assert.Empty(t, result.Reexported, "events should only be exported again when requested")
+	assert.Len(t, exported, 3)
+
+	result = tracker.Manifest.Reconcile(ReconcileRequest{Received: []string{"event2"}, Reexport: true})
+	assert.Equal(t, []string{"event1"}, result.Reexported)
+	assert.Equal(t, map[string]string{"event3": "the event was exported in a batch and its data isn't retained"}, result.Failed)
+	require.Len(t, exported, 4)
+	assert.Equal(t, "data1", exported[3])
+	assert.Len(t, tracker.Manifest.Entries("", time.Time{}), 4, "events exported again should be recorded")
+}
+
+func TestExportManifestReconcileFilters(t *testing.T) {
+	manifest := NewExportManifest(10)
+	tracker := &ExportTracker{Manifest: manifest}
+	var contexts []*appcontext.Context
+	export := tracker.Track("HTTPPost", func(edgexcontext *appcontext.Context, params ...interface{}) (bool, interface{}) {
+		contexts = append(contexts, edgexcontext)
+		if params[0] == "fail" && edgexcontext.Replayed {
+			return false, errors.New("connection refused")
+		}
+		return true, nil
+	}, nil)
+	other := tracker.Track("MQTTSend", func(edgexcontext *appcontext.Context, params ...interface{}) (bool, interface{}) {
+		return true, nil
+	}, nil)
+
+	export(&appcontext.Context{LoggingClient: lc, EventID: "event1", Deadline: time.Now()}, "fail")
+	other(&appcontext.Context{LoggingClient: lc, EventID: "event2"}, "data")
+
+	result := manifest.Reconcile(ReconcileRequest{Export: "HTTPPost"})
+	assert.Equal(t, 1, result.Checked)
+	require.Len(t, result.Missing, 1)
+	assert.Equal(t, "event1", result.Missing[0].EventID)
+
+	result = manifest.Reconcile(ReconcileRequest{Until: time.Now().Add(-time.Minute)})
+	assert.Equal(t, 0, result.Checked)
+	assert.Empty(t, result.Missing)
+
+	result = manifest.Reconcile(ReconcileRequest{Export: "HTTPPost", Reexport: true})
+	assert.Empty(t, result.Reexported)
+	assert.Equal(t, map[string]string{"event1": "connection refused"}, result.Failed)
+	require.Len(t, contexts, 2)
+	assert.True(t, contexts[1].Deadline.IsZero(), "the deadline of the event should not apply to re-exports")
+	assert.Equal(t, "event1", contexts[1].EventID)
+}
+
+func TestExportManifestCapacity(t *testing.T) {
+	tracker := &ExportTracker{Manifest: NewExportManifest(2)}
+	export := tracker.Track("HTTPPost", func(edgexcontext *appcontext.Context, params ...interface{}) (bool, interface{}) {
+		return true, nil
+	}, nil)
+
+	for _, id := range []string{"event1", "event2", "event3"} {
+		export(&appcontext.Context{LoggingClient: lc, EventID: id}, "data")
+	}
+
+	entries := tracker.Manifest.Entries("", time.Time{})
+	require.Len(t, entries, 2, "the oldest events should be dropped")
+	assert.Equal(t, "event2", entries[0].EventID)
+	assert.Equal(t, "event3", entries[1].EventID)
+}
+
+func TestExportManifestNil(t *testing.T) {
+	var manifest *ExportManifest
+	assert.Empty(t, manifest.Entries("", time.Time{}))
+	assert.Empty(t, manifest.Reconcile(ReconcileRequest{Received: []string{"event1"}}).Missing)
+}
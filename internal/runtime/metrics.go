@@ -0,0 +1,152 @@
+//
+// Copyright (c) 2019 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package runtime
+
+import (
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/antoniomtz/app-functions-sdk-go/appcontext"
+)
+
+const (
+	// PrimaryPipelineName is the name the metrics of the pipeline set by SetFunctionsPipeline are reported under
+	PrimaryPipelineName = "primary"
+	// CandidatePipelineName is the name the metrics of the candidate pipeline are reported under
+	CandidatePipelineName = "candidate"
+	// DeviceEventsPipelineName is the name the metrics of the pipeline set by SetDeviceEventsPipeline are reported under
+	DeviceEventsPipelineName = "device-events"
+)
+
+// PipelineMetrics contains the execution counts of a functions pipeline
+type PipelineMetrics struct {
+	// EventsReceived is the number of events routed to the pipeline
+	EventsReceived uint64
+	// EventsCompleted is the number of events for which every function in the pipeline was executed
+	EventsCompleted uint64
+	// EventsStopped is the number of events for which a function stopped the pipeline without an error
+	EventsStopped uint64
+	// EventsFailed is the number of events for which a function stopped the pipeline with an error
+	EventsFailed uint64
+	// ProcessingTimeNanos is the total time spent executing the pipeline
+	ProcessingTimeNanos int64
+}
+
+func (metrics *PipelineMetrics) snapshot() PipelineMetrics {
+	return PipelineMetrics{
+		EventsReceived:      atomic.LoadUint64(&metrics.EventsReceived),
+		EventsCompleted:     atomic.LoadUint64(&metrics.EventsCompleted),
+		EventsStopped:       atomic.LoadUint64(&metrics.EventsStopped),
+		EventsFailed:        atomic.LoadUint64(&metrics.EventsFailed),
+		ProcessingTimeNanos: atomic.LoadInt64(&metrics.ProcessingTimeNanos),
+	}
+}
+
+// latencyBounds are the upper bounds of the buckets of the function latency histograms. A last bucket holds the calls
+// slower than the last bound.
+var latencyBounds = []time.Duration{
+	100 * time.Microsecond,
+	time.Millisecond,
+	10 * time.Millisecond,
+	100 * time.Millisecond,
+	time.Second,
+	10 * time.Second,
+}
+
+// FunctionMetrics contains the execution counts and latencies of a function of a pipeline
+type FunctionMetrics struct {
+	Pipeline      string
+	FunctionIndex int
+	FunctionName  string
+	Invocations   uint64
+	// Stopped is the number of calls which stopped the pipeline without an error
+	Stopped uint64
+	// Failures is the number of calls which stopped the pipeline with an error
+	Failures uint64
+	// TotalTimeNanos is the total time spent in the function
+	TotalTimeNanos int64
+	// Latency is the histogram of the time taken by each call
+	Latency []LatencyBucket
+}
+
+// LatencyBucket holds the number of calls which took less than or as long as the upper bound, i.e. "10ms", and longer
+// than the bound of the previous bucket. The upper bound of the last bucket is "+Inf".
+type LatencyBucket struct {
+	UpperBound string
+	Count      uint64
+}
+
+// functionRecorder accumulates the metrics of the pipeline functions
+type functionRecorder struct {
+	mutex     sync.Mutex
+	functions map[stageKey]*FunctionMetrics
+}
+
+// record adds a call of the function at index of the pipeline which took the duration
+func (recorder *functionRecorder) record(pipeline string, index int, function func(*appcontext.Context, ...interface{}) (bool, interface{}), duration time.Duration, continuePipeline bool, result interface{}) {
+	recorder.mutex.Lock()
+	defer recorder.mutex.Unlock()
+
+	if recorder.functions == nil {
+		recorder.functions = map[stageKey]*FunctionMetrics{}
+	}
+	key := stageKey{pipeline: pipeline, index: index}
+	metrics, exists := recorder.functions[key]
+	if !exists {
+		metrics = &FunctionMetrics{Pipeline: pipeline, FunctionIndex: index, FunctionName: functionName(function)}
+		metrics.Latency = make([]LatencyBucket, len(latencyBounds)+1)
+		for bucket, bound := range latencyBounds {
+			metrics.Latency[bucket].UpperBound = bound.String()
+		}
+		metrics.Latency[len(latencyBounds)].UpperBound = "+Inf"
+		recorder.functions[key] = metrics
+	}
+
+	metrics.Invocations++
+	if !continuePipeline {
+		if _, failed := result.(error); failed {
+			metrics.Failures++
+		} else {
+			metrics.Stopped++
+		}
+	}
+	metrics.TotalTimeNanos += int64(duration)
+	bucket := sort.Search(len(latencyBounds), func(i int) bool { return duration <= latencyBounds[i] })
+	metrics.Latency[bucket].Count++
+}
+
+// metrics returns a snapshot of the function metrics ordered by pipeline and function index
+func (recorder *functionRecorder) metrics() []FunctionMetrics {
+	recorder.mutex.Lock()
+	defer recorder.mutex.Unlock()
+
+	snapshot := make([]FunctionMetrics, 0, len(recorder.functions))
+	for _, metrics := range recorder.functions {
+		function := *metrics
+		function.Latency = append([]LatencyBucket(nil), metrics.Latency...)
+		snapshot = append(snapshot, function)
+	}
+	sort.Slice(snapshot, func(i, j int) bool {
+		if snapshot[i].Pipeline != snapshot[j].Pipeline {
+			return snapshot[i].Pipeline < snapshot[j].Pipeline
+		}
+		return snapshot[i].FunctionIndex < snapshot[j].FunctionIndex
+	})
+	return snapshot
+}
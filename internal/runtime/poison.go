@@ -0,0 +1,148 @@
+//
+// Copyright (c) 2019 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package runtime
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// maxTrackedMessages is the number of distinct failing messages whose failures are counted
+const maxTrackedMessages = 10000
+
+// DeadLetterMessage is a message given up on after failing to be processed too many times, or the data of an export
+// function which failed
+type DeadLetterMessage struct {
+	CorrelationID string    `json:"correlationId"`
+	Export        string    `json:"export,omitempty"`
+	Topic         string    `json:"topic,omitempty"`
+	ContentType   string    `json:"contentType"`
+	Payload       []byte    `json:"payload"`
+	Failures      int       `json:"failures"`
+	LastError     string    `json:"lastError"`
+	Time          time.Time `json:"time"`
+}
+
+// DeadLetter stores a message given up on, i.e. by writing it to a file or publishing it to a topic
+type DeadLetter func(message DeadLetterMessage) error
+
+// PoisonTracker counts the consecutive failures to process each message, identified by a hash of its payload, so a
+// message which always fails, i.e. because it makes a function panic, is moved to the dead letters instead of being
+// processed again each time it is redelivered. Its failures are forgotten once it's moved, so a later message with
+// the same payload is processed again.
+type PoisonTracker struct {
+	// MaxFailures is the number of consecutive failures after which a message is moved to the dead letters
+	MaxFailures int
+	// DeadLetters store the messages given up on
+	DeadLetters  []DeadLetter
+	mutex        sync.Mutex
+	failures     map[[sha256.Size]byte]*messageFailures
+	deadLettered uint64
+}
+
+type messageFailures struct {
+	count     int
+	lastError string
+}
+
+// NewPoisonTracker creates a tracker moving messages to the dead letters after maxFailures consecutive failures
+func NewPoisonTracker(maxFailures int, deadLetters ...DeadLetter) *PoisonTracker {
+	return &PoisonTracker{MaxFailures: maxFailures, DeadLetters: deadLetters}
+}
+
+// failed records a failure to process the payload, returning its consecutive failures and whether it has failed
+// MaxFailures times, in which case its failures are forgotten as it's to be moved to the dead letters
+func (tracker *PoisonTracker) failed(payload []byte, err error) (messageFailures, bool) {
+	tracker.mutex.Lock()
+	defer tracker.mutex.Unlock()
+
+	if tracker.failures == nil {
+		tracker.failures = map[[sha256.Size]byte]*messageFailures{}
+	}
+	key := sha256.Sum256(payload)
+	failures, tracked := tracker.failures[key]
+	if !tracked {
+		if len(tracker.failures) >= maxTrackedMessages {
+			// forget an arbitrary message to bound the memory used
+			for other := range tracker.failures {
+				delete(tracker.failures, other)
+				break
+			}
+		}
+		failures = &messageFailures{}
+		tracker.failures[key] = failures
+	}
+	failures.count++
+	failures.lastError = err.Error()
+	if failures.count >= tracker.MaxFailures {
+		delete(tracker.failures, key)
+		return *failures, true
+	}
+	return *failures, false
+}
+
+// succeeded forgets the failures of the payload
+func (tracker *PoisonTracker) succeeded(payload []byte) {
+	tracker.mutex.Lock()
+	defer tracker.mutex.Unlock()
+
+	delete(tracker.failures, sha256.Sum256(payload))
+}
+
+// deadLetter stores the message with every dead letter, returning the first error
+func (tracker *PoisonTracker) deadLetter(message DeadLetterMessage) error {
+	atomic.AddUint64(&tracker.deadLettered, 1)
+	var firstErr error
+	for _, deadLetter := range tracker.DeadLetters {
+		if err := deadLetter(message); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// DeadLettered returns the number of messages moved to the dead letters
+func (tracker *PoisonTracker) DeadLettered() uint64 {
+	return atomic.LoadUint64(&tracker.deadLettered)
+}
+
+// FileDeadLetter returns a DeadLetter appending each message to the file as a line of JSON
+func FileDeadLetter(path string) DeadLetter {
+	var mutex sync.Mutex
+	return func(message DeadLetterMessage) error {
+		line, err := json.Marshal(message)
+		if err != nil {
+			return err
+		}
+
+		mutex.Lock()
+		defer mutex.Unlock()
+		file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+		if err != nil {
+			return err
+		}
+		if _, err := file.Write(append(line, '\n')); err != nil {
+			file.Close()
+			return err
+		}
+		return file.Close()
+	}
+}
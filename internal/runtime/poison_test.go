@@ -0,0 +1,152 @@
+//
+// Copyright (c) 2019 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package runtime
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/antoniomtz/app-functions-sdk-go/appcontext"
+	"github.com/antoniomtz/go-mod-messaging/pkg/types"
+	"github.com/edgexfoundry/go-mod-core-contracts/clients"
+	"github.com/edgexfoundry/go-mod-core-contracts/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPoisonMessages(t *testing.T) {
+	calls := 0
+	panicOnDevice1 := func(edgexcontext *appcontext.Context, params ...interface{}) (bool, interface{}) {
+		calls++
+		if params[0].(models.Event).Device == devID1 {
+			panic("unexpected reading")
+		}
+		return true, nil
+	}
+
+	var deadLetters []DeadLetterMessage
+	runtime := GolangRuntime{
+		Transforms: []func(*appcontext.Context, ...interface{}) (bool, interface{}){panicOnDevice1},
+		PoisonMessages: NewPoisonTracker(2, func(message DeadLetterMessage) error {
+			deadLetters = append(deadLetters, message)
+			return nil
+		}),
+	}
+	poison, _ := json.Marshal(models.Event{Device: devID1})
+	healthy, _ := json.Marshal(models.Event{Device: devID2})
+	process := func(payload []byte) {
+		runtime.ProcessEvent(&appcontext.Context{LoggingClient: lc, ReceivedTopic: "events"}, types.MessageEnvelope{
+			CorrelationID: "123",
+			Payload:       payload,
+			ContentType:   clients.ContentTypeJSON,
+		})
+	}
+
+	process(poison)
+	assert.Empty(t, deadLetters, "a message should be processed again until it fails MaxFailures times")
+	process(poison)
+	require.Equal(t, 1, len(deadLetters))
+	assert.Equal(t, 2, calls)
+	assert.Equal(t, poison, deadLetters[0].Payload)
+	assert.Equal(t, 2, deadLetters[0].Failures)
+	assert.Equal(t, "events", deadLetters[0].Topic)
+	assert.Contains(t, deadLetters[0].LastError, "unexpected reading")
+
+	process(poison)
+	assert.Equal(t, 3, calls, "a message with the payload of a dead letter should be processed again")
+	assert.Equal(t, 1, len(deadLetters), "the failures of a dead letter should be forgotten")
+	process(poison)
+	assert.Equal(t, 2, len(deadLetters))
+	assert.Equal(t, uint64(2), runtime.PoisonMessages.DeadLettered())
+
+	process(healthy)
+	process(healthy)
+	assert.Equal(t, 6, calls)
+	assert.Equal(t, 2, len(deadLetters))
+}
+
+func TestPoisonTrackerConsecutiveFailures(t *testing.T) {
+	tracker := NewPoisonTracker(2)
+	payload := []byte("payload")
+
+	failures, poisoned := tracker.failed(payload, errors.New("timeout"))
+	assert.Equal(t, 1, failures.count)
+	assert.False(t, poisoned)
+	tracker.succeeded(payload)
+	failures, poisoned = tracker.failed(payload, errors.New("timeout"))
+	assert.Equal(t, 1, failures.count, "a success should reset the failures")
+	assert.False(t, poisoned)
+
+	failures, poisoned = tracker.failed(payload, errors.New("invalid"))
+	assert.True(t, poisoned)
+	assert.Equal(t, 2, failures.count)
+	assert.Equal(t, "invalid", failures.lastError)
+	failures, poisoned = tracker.failed(payload, errors.New("invalid"))
+	assert.Equal(t, 1, failures.count, "the failures should be forgotten once the payload is poisoned")
+	assert.False(t, poisoned)
+}
+
+func TestFileDeadLetter(t *testing.T) {
+	dir, err := ioutil.TempDir("", "deadletter")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "dead-letters.jsonl")
+
+	deadLetter := FileDeadLetter(path)
+	require.NoError(t, deadLetter(DeadLetterMessage{CorrelationID: "1", Payload: []byte("one"), Failures: 3}))
+	require.NoError(t, deadLetter(DeadLetterMessage{CorrelationID: "2", Payload: []byte("two"), Failures: 3}))
+
+	contents, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(contents)), "\n")
+	require.Equal(t, 2, len(lines))
+	var message DeadLetterMessage
+	require.NoError(t, json.Unmarshal([]byte(lines[1]), &message))
+	assert.Equal(t, "2", message.CorrelationID)
+	assert.Equal(t, []byte("two"), message.Payload)
+}
+
+func TestProcessMessageOutcome(t *testing.T) {
+	failing := func(edgexcontext *appcontext.Context, params ...interface{}) (bool, interface{}) {
+		return false, errors.New("export failed")
+	}
+	runtime := GolangRuntime{
+		Transforms:     []func(*appcontext.Context, ...interface{}) (bool, interface{}){failing},
+		PoisonMessages: NewPoisonTracker(2),
+	}
+	payload, _ := json.Marshal(models.Event{Device: devID1})
+	process := func() error {
+		return runtime.ProcessMessage(&appcontext.Context{LoggingClient: lc, ReceivedTopic: "events"}, types.MessageEnvelope{
+			CorrelationID: "123",
+			Payload:       payload,
+			ContentType:   clients.ContentTypeJSON,
+		})
+	}
+
+	assert.EqualError(t, process(), "export failed")
+	assert.NoError(t, process(), "a message moved to the dead letters has been processed")
+	assert.EqualError(t, process(), "export failed", "a message with the payload of a dead letter should be processed again")
+
+	runtime.PoisonMessages = nil
+	assert.Error(t, process())
+	assert.Nil(t, runtime.ProcessEvent(&appcontext.Context{LoggingClient: lc}, types.MessageEnvelope{Payload: payload, ContentType: clients.ContentTypeJSON}))
+}
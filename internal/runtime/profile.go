@@ -0,0 +1,124 @@
+//
+// Copyright (c) 2019 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package runtime
+
+import (
+	goruntime "runtime"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/antoniomtz/app-functions-sdk-go/appcontext"
+)
+
+// StageProfile contains the cost of a function of a pipeline, recorded while Writable.ProfileStages is enabled
+type StageProfile struct {
+	Pipeline      string
+	FunctionIndex int
+	FunctionName  string
+	Calls         uint64
+	// TimeNanos is the total time spent in the function
+	TimeNanos int64
+	// CPUTimeNanos is the CPU time of the thread running the function, which excludes the work of other goroutines
+	// started by the function. It is only recorded on Linux.
+	CPUTimeNanos int64
+	// AllocBytes and AllocObjects are the heap allocations made while the function ran. They include the
+	// allocations of other goroutines running at the same time, so are most accurate while events are processed
+	// one at a time.
+	AllocBytes   uint64
+	AllocObjects uint64
+}
+
+type stageKey struct {
+	pipeline string
+	index    int
+}
+
+// stageProfiler accumulates the profiles of the pipeline functions it runs
+type stageProfiler struct {
+	mutex  sync.Mutex
+	stages map[stageKey]*StageProfile
+}
+
+// stageCounters are the process counters sampled before and after a function runs
+type stageCounters struct {
+	time         time.Time
+	cpuTimeNanos int64
+	allocBytes   uint64
+	allocObjects uint64
+}
+
+func readStageCounters() stageCounters {
+	counters := stageCounters{cpuTimeNanos: threadCPUTimeNanos()}
+	counters.allocBytes, counters.allocObjects = readHeapAllocs()
+	counters.time = time.Now()
+	return counters
+}
+
+// run calls the function at index of the pipeline and records its cost. The goroutine is locked to its thread
+// while the function runs so that the CPU time of the thread is that of the function.
+func (profiler *stageProfiler) run(pipeline string, index int, function func(*appcontext.Context, ...interface{}) (bool, interface{}), edgexcontext *appcontext.Context, input interface{}) (bool, interface{}) {
+	// unlocked by a deferred call so the thread is released when the function panics
+	goruntime.LockOSThread()
+	defer goruntime.UnlockOSThread()
+	before := readStageCounters()
+	continuePipeline, result := function(edgexcontext, input)
+	after := readStageCounters()
+
+	profiler.mutex.Lock()
+	defer profiler.mutex.Unlock()
+
+	if profiler.stages == nil {
+		profiler.stages = map[stageKey]*StageProfile{}
+	}
+	key := stageKey{pipeline: pipeline, index: index}
+	stage, exists := profiler.stages[key]
+	if !exists {
+		stage = &StageProfile{Pipeline: pipeline, FunctionIndex: index, FunctionName: functionName(function)}
+		profiler.stages[key] = stage
+	}
+	stage.Calls++
+	stage.TimeNanos += int64(after.time.Sub(before.time))
+	stage.CPUTimeNanos += after.cpuTimeNanos - before.cpuTimeNanos
+	stage.AllocBytes += after.allocBytes - before.allocBytes
+	stage.AllocObjects += after.allocObjects - before.allocObjects
+
+	return continuePipeline, result
+}
+
+// profiles returns a snapshot of the stage profiles ordered by pipeline and function index
+func (profiler *stageProfiler) profiles() []StageProfile {
+	profiler.mutex.Lock()
+	defer profiler.mutex.Unlock()
+
+	profiles := make([]StageProfile, 0, len(profiler.stages))
+	for _, stage := range profiler.stages {
+		profiles = append(profiles, *stage)
+	}
+	sort.Slice(profiles, func(i, j int) bool {
+		if profiles[i].Pipeline != profiles[j].Pipeline {
+			return profiles[i].Pipeline < profiles[j].Pipeline
+		}
+		return profiles[i].FunctionIndex < profiles[j].FunctionIndex
+	})
+	return profiles
+}
+
+// StageProfiles returns the cost of each pipeline function recorded while Writable.ProfileStages is enabled
+func (gr *GolangRuntime) StageProfiles() []StageProfile {
+	return gr.stages.profiles()
+}
@@ -0,0 +1,30 @@
+//go:build !go1.16
+// +build !go1.16
+
+//
+// Copyright (c) 2019 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package runtime
+
+import goruntime "runtime"
+
+// readHeapAllocs returns the cumulative bytes and objects allocated on the heap. Without runtime/metrics this
+// stops the world, so profiling is considerably more expensive on older Go versions.
+func readHeapAllocs() (bytes uint64, objects uint64) {
+	var stats goruntime.MemStats
+	goruntime.ReadMemStats(&stats)
+	return stats.TotalAlloc, stats.Mallocs
+}
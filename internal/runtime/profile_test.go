@@ -0,0 +1,71 @@
+//
+// Copyright (c) 2019 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package runtime
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/antoniomtz/app-functions-sdk-go/appcontext"
+	"github.com/antoniomtz/app-functions-sdk-go/internal/common"
+	"github.com/antoniomtz/go-mod-messaging/pkg/types"
+	"github.com/edgexfoundry/go-mod-core-contracts/clients"
+	"github.com/edgexfoundry/go-mod-core-contracts/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var allocated []byte
+
+func TestProcessEventProfileStages(t *testing.T) {
+	allocate := func(edgexcontext *appcontext.Context, params ...interface{}) (bool, interface{}) {
+		allocated = make([]byte, 1<<20)
+		return true, params[0]
+	}
+	stop := func(edgexcontext *appcontext.Context, params ...interface{}) (bool, interface{}) {
+		return false, nil
+	}
+	runtime := GolangRuntime{
+		Transforms: []func(edgexcontext *appcontext.Context, params ...interface{}) (bool, interface{}){allocate, stop},
+	}
+
+	eventInBytes, _ := json.Marshal(models.Event{Device: devID1})
+	envelope := types.MessageEnvelope{
+		CorrelationID: "123-234-345-456",
+		Payload:       eventInBytes,
+		ContentType:   clients.ContentTypeJSON,
+	}
+
+	runtime.ProcessEvent(&appcontext.Context{LoggingClient: lc}, envelope)
+	assert.Empty(t, runtime.StageProfiles(), "Stages should only be profiled when enabled")
+
+	profiled := common.ConfigurationStruct{Writable: common.WritableInfo{ProfileStages: true}}
+	for i := 0; i < 3; i++ {
+		runtime.ProcessEvent(&appcontext.Context{LoggingClient: lc, Configuration: profiled}, envelope)
+	}
+
+	profiles := runtime.StageProfiles()
+	require.Equal(t, 2, len(profiles))
+	assert.Equal(t, PrimaryPipelineName, profiles[0].Pipeline)
+	assert.Equal(t, 0, profiles[0].FunctionIndex)
+	assert.NotEmpty(t, profiles[0].FunctionName)
+	assert.Equal(t, uint64(3), profiles[0].Calls)
+	assert.True(t, profiles[0].TimeNanos > 0)
+	assert.True(t, profiles[0].AllocBytes >= 3<<20, "Expected the allocations of the function to be recorded")
+	assert.Equal(t, 1, profiles[1].FunctionIndex)
+	assert.Equal(t, uint64(3), profiles[1].Calls)
+}
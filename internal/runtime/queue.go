@@ -0,0 +1,153 @@
+//
+// Copyright (c) 2019 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package runtime
+
+import (
+	"fmt"
+	"strings"
+	"sync/atomic"
+
+	"github.com/antoniomtz/go-mod-messaging/pkg/types"
+)
+
+const (
+	// OverflowBlock stops receiving events while the ingest queue is full, leaving them to back up upstream
+	OverflowBlock = "block"
+	// OverflowDropOldest drops the event which has been queued the longest to make room for a new event
+	OverflowDropOldest = "drop-oldest"
+	// OverflowDropNewest drops new events while the ingest queue is full
+	OverflowDropNewest = "drop-newest"
+)
+
+// QueuedEvent is an event received by a trigger waiting in the ingest queue to be processed
+type QueuedEvent struct {
+	Envelope types.MessageEnvelope
+	Topic    string
+}
+
+// QueueMetrics reports the depth of the ingest queue and the events it dropped
+type QueueMetrics struct {
+	Capacity int    `json:"capacity"`
+	Length   int    `json:"length"`
+	Policy   string `json:"policy"`
+	// Enqueued is the number of events added to the queue
+	Enqueued uint64 `json:"enqueued"`
+	// Dropped is the number of events dropped because the queue was full
+	Dropped uint64 `json:"dropped"`
+}
+
+// IngestQueue buffers the events received by a trigger until the pipeline is ready for them, so bursts are absorbed
+// and the behavior when the pipeline falls behind is chosen by its overflow policy
+type IngestQueue struct {
+	events   chan QueuedEvent
+	policy   string
+	enqueued uint64
+	dropped  uint64
+}
+
+// NewIngestQueue creates a queue holding up to capacity events with the overflow policy, which defaults to
+// OverflowBlock when empty
+func NewIngestQueue(capacity int, policy string) (*IngestQueue, error) {
+	if capacity <= 0 {
+		return nil, fmt.Errorf("ingest queue capacity must be positive, got %d", capacity)
+	}
+	policy = strings.ToLower(strings.TrimSpace(policy))
+	switch policy {
+	case "":
+		policy = OverflowBlock
+	case OverflowBlock, OverflowDropOldest, OverflowDropNewest:
+	default:
+		return nil, fmt.Errorf("invalid overflow policy '%s', expected %s, %s or %s", policy, OverflowBlock, OverflowDropOldest, OverflowDropNewest)
+	}
+	return &IngestQueue{events: make(chan QueuedEvent, capacity), policy: policy}, nil
+}
+
+// Push adds the event to the queue, applying the overflow policy when the queue is full. With OverflowBlock it waits
+// until there is room or stop is closed. It returns true when an event was dropped.
+func (queue *IngestQueue) Push(event QueuedEvent, stop <-chan struct{}) bool {
+	select {
+	case queue.events <- event:
+		atomic.AddUint64(&queue.enqueued, 1)
+		return false
+	default:
+	}
+
+	switch queue.policy {
+	case OverflowDropNewest:
+		atomic.AddUint64(&queue.dropped, 1)
+		return true
+
+	case OverflowDropOldest:
+		dropped := false
+		for {
+			select {
+			case queue.events <- event:
+				atomic.AddUint64(&queue.enqueued, 1)
+				return dropped
+			default:
+			}
+			select {
+			case <-queue.events:
+				atomic.AddUint64(&queue.dropped, 1)
+				dropped = true
+			default:
+			}
+		}
+
+	default:
+		select {
+		case queue.events <- event:
+			atomic.AddUint64(&queue.enqueued, 1)
+			return false
+		case <-stop:
+			return false
+		}
+	}
+}
+
+// Pop returns the event which has been queued the longest, waiting until an event is queued. It returns false once
+// stop is closed.
+func (queue *IngestQueue) Pop(stop <-chan struct{}) (QueuedEvent, bool) {
+	select {
+	case <-stop:
+		return QueuedEvent{}, false
+	default:
+	}
+
+	select {
+	case event := <-queue.events:
+		return event, true
+	case <-stop:
+		return QueuedEvent{}, false
+	}
+}
+
+// Len returns the number of events waiting in the queue
+func (queue *IngestQueue) Len() int {
+	return len(queue.events)
+}
+
+// Metrics returns a snapshot of the depth of the queue and the events it dropped
+func (queue *IngestQueue) Metrics() QueueMetrics {
+	return QueueMetrics{
+		Capacity: cap(queue.events),
+		Length:   len(queue.events),
+		Policy:   queue.policy,
+		Enqueued: atomic.LoadUint64(&queue.enqueued),
+		Dropped:  atomic.LoadUint64(&queue.dropped),
+	}
+}
@@ -0,0 +1,125 @@
+//
+// Copyright (c) 2019 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package runtime
+
+import (
+	"testing"
+	"time"
+
+	"github.com/antoniomtz/go-mod-messaging/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func queuedEvent(id string) QueuedEvent {
+	return QueuedEvent{Envelope: types.MessageEnvelope{CorrelationID: id}, Topic: "events"}
+}
+
+func popIDs(t *testing.T, queue *IngestQueue, count int) []string {
+	var ids []string
+	for i := 0; i < count; i++ {
+		event, ok := queue.Pop(nil)
+		require.True(t, ok)
+		ids = append(ids, event.Envelope.CorrelationID)
+	}
+	return ids
+}
+
+func TestNewIngestQueue(t *testing.T) {
+	queue, err := NewIngestQueue(10, "")
+	require.NoError(t, err)
+	assert.Equal(t, QueueMetrics{Capacity: 10, Policy: OverflowBlock}, queue.Metrics())
+
+	queue, err = NewIngestQueue(10, " Drop-Oldest ")
+	require.NoError(t, err)
+	assert.Equal(t, OverflowDropOldest, queue.Metrics().Policy)
+
+	_, err = NewIngestQueue(10, "drop-all")
+	assert.Error(t, err)
+	_, err = NewIngestQueue(0, OverflowBlock)
+	assert.Error(t, err)
+}
+
+func TestIngestQueueDropNewest(t *testing.T) {
+	queue, _ := NewIngestQueue(2, OverflowDropNewest)
+
+	assert.False(t, queue.Push(queuedEvent("1"), nil))
+	assert.False(t, queue.Push(queuedEvent("2"), nil))
+	assert.True(t, queue.Push(queuedEvent("3"), nil), "the new event should be dropped")
+
+	assert.Equal(t, []string{"1", "2"}, popIDs(t, queue, 2))
+	assert.Equal(t, QueueMetrics{Capacity: 2, Policy: OverflowDropNewest, Enqueued: 2, Dropped: 1}, queue.Metrics())
+}
+
+func TestIngestQueueDropOldest(t *testing.T) {
+	queue, _ := NewIngestQueue(2, OverflowDropOldest)
+
+	queue.Push(queuedEvent("1"), nil)
+	queue.Push(queuedEvent("2"), nil)
+	assert.True(t, queue.Push(queuedEvent("3"), nil), "the oldest event should be dropped")
+
+	assert.Equal(t, []string{"2", "3"}, popIDs(t, queue, 2))
+	assert.Equal(t, QueueMetrics{Capacity: 2, Policy: OverflowDropOldest, Enqueued: 3, Dropped: 1}, queue.Metrics())
+}
+
+func TestIngestQueueBlock(t *testing.T) {
+	queue, _ := NewIngestQueue(1, OverflowBlock)
+	queue.Push(queuedEvent("1"), nil)
+
+	pushed := make(chan bool)
+	go func() {
+		pushed <- queue.Push(queuedEvent("2"), nil)
+	}()
+	select {
+	case <-pushed:
+		t.Fatal("Push should block while the queue is full")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	assert.Equal(t, []string{"1"}, popIDs(t, queue, 1))
+	assert.False(t, <-pushed)
+	assert.Equal(t, []string{"2"}, popIDs(t, queue, 1))
+	assert.Equal(t, uint64(0), queue.Metrics().Dropped)
+
+	// a blocked push gives up once stopped
+	queue.Push(queuedEvent("3"), nil)
+	stop := make(chan struct{})
+	close(stop)
+	assert.False(t, queue.Push(queuedEvent("4"), stop))
+	assert.Equal(t, 1, queue.Len())
+}
+
+func TestIngestQueuePopStopped(t *testing.T) {
+	queue, _ := NewIngestQueue(1, OverflowBlock)
+	queue.Push(queuedEvent("1"), nil)
+
+	stop := make(chan struct{})
+	close(stop)
+	_, ok := queue.Pop(stop)
+	assert.False(t, ok, "Pop should stop even when events are queued")
+}
+
+func TestIntakeStatusQueue(t *testing.T) {
+	runtime := GolangRuntime{}
+	assert.Nil(t, runtime.IntakeStatus().Queue)
+
+	runtime.Queue, _ = NewIngestQueue(5, OverflowDropNewest)
+	runtime.Queue.Push(queuedEvent("1"), nil)
+	status := runtime.IntakeStatus()
+	require.NotNil(t, status.Queue)
+	assert.Equal(t, 1, status.Queue.Length)
+}
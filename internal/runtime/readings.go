@@ -0,0 +1,83 @@
+//
+// Copyright (c) 2019 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package runtime
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/antoniomtz/app-functions-sdk-go/appcontext"
+	"github.com/edgexfoundry/go-mod-core-contracts/models"
+)
+
+// ReadingPipeline executes a collection of functions against each reading of an event individually.
+// The first function is called with a models.Reading and each successive function with the result
+// of the previous one.
+type ReadingPipeline struct {
+	Transforms []func(*appcontext.Context, ...interface{}) (bool, interface{})
+}
+
+// ProcessReadings runs the reading functions for every reading in the event received from the previous function.
+// A reading is dropped from the event when a function stops the pipeline for it, and is replaced when the last
+// function returns a models.Reading. If a function returns an error the event is not processed any further.
+// This function returns an Event, and stops the pipeline if no readings remain.
+func (rp ReadingPipeline) ProcessReadings(edgexcontext *appcontext.Context, params ...interface{}) (bool, interface{}) {
+	if len(params) < 1 {
+		return false, errors.New("No Event Received")
+	}
+
+	event, ok := params[0].(models.Event)
+	if !ok {
+		return false, errors.New("Unexpected type received, expecting models.Event")
+	}
+
+	readings := []models.Reading{}
+	for _, reading := range event.Readings {
+		keep, result := rp.processReading(edgexcontext, reading)
+		if err, ok := result.(error); ok {
+			return false, fmt.Errorf("processing reading '%s' failed: %v", reading.Name, err)
+		}
+		if !keep {
+			continue
+		}
+		if processed, ok := result.(models.Reading); ok {
+			reading = processed
+		}
+		readings = append(readings, reading)
+	}
+
+	if len(readings) == 0 {
+		return false, nil
+	}
+
+	event.Readings = readings
+	return true, event
+}
+
+func (rp ReadingPipeline) processReading(edgexcontext *appcontext.Context, reading models.Reading) (bool, interface{}) {
+	var result interface{} = reading
+	for _, trxFunc := range rp.Transforms {
+		continuePipeline, functionResult := trxFunc(edgexcontext, result)
+		if !continuePipeline {
+			return false, functionResult
+		}
+		if functionResult != nil {
+			result = functionResult
+		}
+	}
+	return true, result
+}
@@ -0,0 +1,97 @@
+//
+// Copyright (c) 2019 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package runtime
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/antoniomtz/app-functions-sdk-go/appcontext"
+	"github.com/edgexfoundry/go-mod-core-contracts/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProcessReadings(t *testing.T) {
+	context := &appcontext.Context{LoggingClient: lc}
+
+	dropSensor2 := func(edgexcontext *appcontext.Context, params ...interface{}) (bool, interface{}) {
+		reading := params[0].(models.Reading)
+		return reading.Name != "sensor2", reading
+	}
+	convert := func(edgexcontext *appcontext.Context, params ...interface{}) (bool, interface{}) {
+		reading := params[0].(models.Reading)
+		reading.Value = reading.Value + "0"
+		return true, reading
+	}
+	passThrough := func(edgexcontext *appcontext.Context, params ...interface{}) (bool, interface{}) {
+		return true, nil
+	}
+
+	pipeline := ReadingPipeline{
+		Transforms: []func(*appcontext.Context, ...interface{}) (bool, interface{}){dropSensor2, convert, passThrough},
+	}
+	eventIn := models.Event{
+		Device: devID1,
+		Readings: []models.Reading{
+			{Name: readingName1, Value: "1"},
+			{Name: "sensor2", Value: "2"},
+			{Name: "sensor3", Value: "3"},
+		},
+	}
+
+	continuePipeline, result := pipeline.ProcessReadings(context, eventIn)
+	assert.True(t, continuePipeline)
+	eventOut := result.(models.Event)
+	assert.Equal(t, devID1, eventOut.Device)
+	assert.Equal(t, 2, len(eventOut.Readings), "sensor2 should have been dropped")
+	assert.Equal(t, "10", eventOut.Readings[0].Value)
+	assert.Equal(t, "30", eventOut.Readings[1].Value)
+}
+
+func TestProcessReadingsAllDropped(t *testing.T) {
+	context := &appcontext.Context{LoggingClient: lc}
+	drop := func(edgexcontext *appcontext.Context, params ...interface{}) (bool, interface{}) {
+		return false, nil
+	}
+
+	pipeline := ReadingPipeline{
+		Transforms: []func(*appcontext.Context, ...interface{}) (bool, interface{}){drop},
+	}
+
+	continuePipeline, result := pipeline.ProcessReadings(context, models.Event{Readings: []models.Reading{{Name: readingName1}}})
+	assert.False(t, continuePipeline)
+	assert.Nil(t, result)
+}
+
+func TestProcessReadingsError(t *testing.T) {
+	context := &appcontext.Context{LoggingClient: lc}
+	fail := func(edgexcontext *appcontext.Context, params ...interface{}) (bool, interface{}) {
+		return false, errors.New("bad value")
+	}
+
+	pipeline := ReadingPipeline{
+		Transforms: []func(*appcontext.Context, ...interface{}) (bool, interface{}){fail},
+	}
+
+	continuePipeline, result := pipeline.ProcessReadings(context, models.Event{Readings: []models.Reading{{Name: readingName1}}})
+	assert.False(t, continuePipeline)
+	assert.EqualError(t, result.(error), "processing reading 'sensor1' failed: bad value")
+
+	continuePipeline, result = pipeline.ProcessReadings(context, "not an event")
+	assert.False(t, continuePipeline)
+	assert.Error(t, result.(error))
+}
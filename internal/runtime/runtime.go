@@ -18,70 +18,504 @@ package runtime
 
 import (
 	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"math/rand"
+	"reflect"
+	"runtime/debug"
 	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/antoniomtz/app-functions-sdk-go/appcontext"
+	"github.com/antoniomtz/app-functions-sdk-go/internal/common"
+	"github.com/antoniomtz/app-functions-sdk-go/internal/tracing"
+	"github.com/antoniomtz/app-functions-sdk-go/pkg/devices"
+	"github.com/antoniomtz/go-mod-messaging/pkg/types"
 	"github.com/edgexfoundry/go-mod-core-contracts/clients"
 	"github.com/edgexfoundry/go-mod-core-contracts/models"
-	"github.com/antoniomtz/go-mod-messaging/pkg/types"
 	"github.com/ugorji/go/codec"
 )
 
 // GolangRuntime represents the golang runtime environment
 type GolangRuntime struct {
 	Transforms []func(*appcontext.Context, ...interface{}) (bool, interface{})
+	// Candidate is an optional second pipeline which processes a share of the events instead of Transforms
+	Candidate *CandidatePipeline
+	// TopicPipelines process the events received on their topics instead of Transforms and Candidate
+	TopicPipelines []*TopicPipeline
+	// DeviceEvents is an optional pipeline which processes the device lifecycle events reported by core-metadata
+	DeviceEvents        []func(*appcontext.Context, ...interface{}) (bool, interface{})
+	deviceEventsMetrics PipelineMetrics
+	// PoisonMessages moves the messages which fail to be processed too many times to the dead letters. Nil processes
+	// every message however many times it failed.
+	PoisonMessages *PoisonTracker
+	// Caches are the memoized functions of the pipelines whose lookups are reported by CacheMetrics
+	Caches []*TransformCache
+	// Limits are the functions of the pipelines with a maximum number of concurrent calls, whose calls are reported
+	// by ConcurrencyMetrics
+	Limits []*ConcurrencyLimit
+	// MetricsProviders return the metrics of the functions of the pipelines, i.e. the readings rejected by the
+	// outlier filters or the requests of the model server clients, by the name they're reported under by the /api/v1/metrics/<name> endpoint
+	MetricsProviders map[string]func() interface{}
+	// ErrorLog records the errors returned by pipeline functions along with the data they were called with
+	ErrorLog       *ErrorLog
+	primaryMetrics PipelineMetrics
+	intakeMutex    sync.Mutex
+	stages         stageProfiler
+	functions      functionRecorder
+	// ErrorHandler is called with the name of the function, the error and the payload received whenever a pipeline
+	// function returns an error. Nil calls nothing.
+	ErrorHandler func(edgexcontext *appcontext.Context, functionName string, err error, payload []byte)
+	// Queue buffers the events received by the trigger, and is set by triggers configured with an ingest queue
+	Queue *IngestQueue
+	// resumed is closed when intake is resumed, and is nil while intake is not paused
+	resumed chan struct{}
+	// inFlight is the number of events being processed
+	inFlight int64
+	// Tracer emits a span for each event, and for each function of the pipeline it is routed to. Nil disables tracing.
+	Tracer *tracing.Tracer
+	// TargetType is a pointer to the type the payload is decoded into for the first function, instead of an EdgeX
+	// Event, i.e. &[]byte{} for the raw payload or a pointer to a struct for custom JSON or CBOR data
+	TargetType interface{}
+	// Writable holds the current Writable configuration, which replaces that of the context of each event when set
+	Writable *common.WritableStore
+}
+
+// CandidatePipeline is a functions pipeline being validated alongside the primary pipeline. Events from
+// the listed devices are always routed to the candidate, other events are routed to it based on Percentage.
+type CandidatePipeline struct {
+	Transforms  []func(*appcontext.Context, ...interface{}) (bool, interface{})
+	Percentage  int
+	DeviceNames []string
+	metrics     PipelineMetrics
+}
+
+// TopicPipeline is a named functions pipeline which processes the events received on its topics by the message
+// bus trigger. Its metrics are reported under its ID.
+type TopicPipeline struct {
+	ID         string
+	Topics     []string
+	Transforms []func(*appcontext.Context, ...interface{}) (bool, interface{})
+	metrics    PipelineMetrics
 }
 
 // ProcessEvent handles processing the event
-func (gr GolangRuntime) ProcessEvent(edgexcontext *appcontext.Context, envelope types.MessageEnvelope) error {
+func (gr *GolangRuntime) ProcessEvent(edgexcontext *appcontext.Context, envelope types.MessageEnvelope) error {
+	gr.ProcessMessage(edgexcontext, envelope)
+	return nil
+}
 
-	edgexcontext.LoggingClient.Debug("Processing Event: " + strconv.Itoa(len(gr.Transforms)) + " Transforms")
-	var event models.Event
+// ProcessMessage processes the event like ProcessEvent, returning the error which stopped its processing, if any, so
+// that triggers can acknowledge the message once processed. A message moved to the dead letters has been processed.
+func (gr *GolangRuntime) ProcessMessage(edgexcontext *appcontext.Context, envelope types.MessageEnvelope) error {
+	return gr.processMessage(edgexcontext, envelope, nil)
+}
 
-	switch envelope.ContentType {
-	case clients.ContentTypeJSON:
-		if err := json.Unmarshal([]byte(envelope.Payload), &event); err != nil {
-			edgexcontext.LoggingClient.Error("Unable to JSON unmarshal EdgeX Event: "+err.Error(), clients.CorrelationHeader, envelope.CorrelationID)
-			return nil
-		}
+// ProcessDecodedMessage processes the message like ProcessMessage, without decoding its payload again
+func (gr *GolangRuntime) ProcessDecodedMessage(edgexcontext *appcontext.Context, message *DecodedMessage) error {
+	return gr.processMessage(edgexcontext, message.Envelope, message)
+}
 
-		// Needed for Marking event as handled
-		edgexcontext.EventID = event.ID
+// processMessage processes the message, decoding its payload unless it's already decoded
+func (gr *GolangRuntime) processMessage(edgexcontext *appcontext.Context, envelope types.MessageEnvelope, message *DecodedMessage) error {
+	atomic.AddInt64(&gr.inFlight, 1)
+	defer atomic.AddInt64(&gr.inFlight, -1)
+
+	kind := tracing.KindServer
+	if edgexcontext.ReceivedTopic != "" {
+		kind = tracing.KindConsumer
+	}
+	span := gr.Tracer.Start("process event", kind, edgexcontext.TraceParent, envelope.CorrelationID)
+	if span != nil {
+		edgexcontext.TraceParent = span.TraceParent()
+	}
 
+	if message == nil {
+		message = gr.decodeMessage(envelope, edgexcontext.ContentEncoding)
+	}
+	err := gr.processEvent(edgexcontext, message, span)
+	span.Finish(err)
+	if gr.trackPoison(edgexcontext, envelope, err) {
+		return nil
+	}
+	return err
+}
+
+// trackPoison records the outcome of processing the message, moving it to the dead letters once it has failed
+// PoisonMessages.MaxFailures times in a row, in which case it returns true
+func (gr *GolangRuntime) trackPoison(edgexcontext *appcontext.Context, envelope types.MessageEnvelope, err error) bool {
+	if gr.PoisonMessages == nil {
+		return false
+	}
+	if err == nil {
+		gr.PoisonMessages.succeeded(envelope.Payload)
+		return false
+	}
+	failures, poisoned := gr.PoisonMessages.failed(envelope.Payload, err)
+	if poisoned {
+		gr.deadLetter(edgexcontext, envelope, failures)
+	}
+	return poisoned
+}
+
+// deadLetter moves the message to the dead letters
+func (gr *GolangRuntime) deadLetter(edgexcontext *appcontext.Context, envelope types.MessageEnvelope, failures messageFailures) {
+	edgexcontext.LoggingClient.Error(fmt.Sprintf("Message failed to be processed %d times, moving it to the dead letters: %s", failures.count, failures.lastError), clients.CorrelationHeader, envelope.CorrelationID)
+	err := gr.PoisonMessages.deadLetter(DeadLetterMessage{
+		CorrelationID: envelope.CorrelationID,
+		Topic:         edgexcontext.ReceivedTopic,
+		ContentType:   envelope.ContentType,
+		Payload:       envelope.Payload,
+		Failures:      failures.count,
+		LastError:     failures.lastError,
+		Time:          time.Now(),
+	})
+	if err != nil {
+		edgexcontext.LoggingClient.Error("Failed to store dead letter: "+err.Error(), clients.CorrelationHeader, envelope.CorrelationID)
+	}
+}
+
+// DecodedMessage is a message whose payload is decoded, i.e. to pick the worker of its device, so that it isn't
+// decoded again when processed
+type DecodedMessage struct {
+	Envelope    types.MessageEnvelope
+	payload     []byte
+	contentType string
+	data        interface{}
+	event       models.Event
+	// failure is logged with err when the payload can't be decoded
+	failure string
+	err     error
+}
+
+// Device returns the name of the device of the EdgeX Event, or an empty string when the payload isn't decoded as an
+// Event
+func (message *DecodedMessage) Device() string {
+	if event, ok := message.data.(models.Event); ok {
+		return event.Device
+	}
+	return ""
+}
+
+// DecodeMessage decodes the payload of the message received from the message bus
+func (gr *GolangRuntime) DecodeMessage(envelope types.MessageEnvelope) *DecodedMessage {
+	return gr.decodeMessage(envelope, "")
+}
+
+// decodeMessage decompresses the payload with the content encoding and decodes it into the target type, or an EdgeX
+// Event when there is none
+func (gr *GolangRuntime) decodeMessage(envelope types.MessageEnvelope, contentEncoding string) *DecodedMessage {
+	message := &DecodedMessage{Envelope: envelope}
+	payload, err := decompressPayload(envelope.Payload, contentEncoding)
+	if err != nil {
+		message.failure, message.err = "Unable to decompress EdgeX Event", err
+		return message
+	}
+	message.payload = payload
+
+	eventPayload, contentType, err := unwrapPayload(payload, mediaType(envelope.ContentType))
+	if err != nil {
+		message.failure, message.err = "Unable to unwrap CloudEvent", err
+		return message
+	}
+	message.contentType = contentType
+
+	if gr.TargetType != nil {
+		message.data, err = decodeTarget(gr.TargetType, eventPayload, contentType)
+		if err != nil {
+			message.failure, message.err = "Unable to decode payload into target type", err
+		}
+		return message
+	}
+	switch contentType {
+	case clients.ContentTypeJSON:
+		if err := json.Unmarshal(eventPayload, &message.event); err != nil {
+			message.failure, message.err = "Unable to JSON unmarshal EdgeX Event", err
+			return message
+		}
 	case clients.ContentTypeCBOR:
 		x := codec.CborHandle{}
-		err := codec.NewDecoderBytes([]byte(envelope.Payload), &x).Decode(&event)
-		if err != nil {
-			edgexcontext.LoggingClient.Error("Unable to CBOR unmarshal EdgeX Event: "+err.Error(), clients.CorrelationHeader, envelope.CorrelationID)
-			return nil
+		if err := codec.NewDecoderBytes(eventPayload, &x).Decode(&message.event); err != nil {
+			message.failure, message.err = "Unable to CBOR unmarshal EdgeX Event", err
+			return message
 		}
+	default:
+		message.failure, message.err = "Unable to decode EdgeX Event", fmt.Errorf("'%s' content type for EdgeX Event not supported", contentType)
+		return message
+	}
+	message.data = message.event
+	return message
+}
 
+// processEvent executes the pipeline the decoded event is routed to, returning the error which stopped the
+// processing of the event, if any, for its span
+func (gr *GolangRuntime) processEvent(edgexcontext *appcontext.Context, message *DecodedMessage, span *tracing.Span) error {
+	envelope, event := message.Envelope, message.event
+	if message.err != nil {
+		edgexcontext.LoggingClient.Error(message.failure+": "+message.err.Error(), clients.CorrelationHeader, envelope.CorrelationID)
+		return message.err
+	}
+	if gr.TargetType == nil && message.contentType == clients.ContentTypeCBOR {
 		// Needed for Marking event as handled
 		edgexcontext.EventChecksum = envelope.Checksum
-
-	default:
-		edgexcontext.LoggingClient.Error("'"+envelope.ContentType+"' content type for EdgeX Event not supported: ", clients.CorrelationHeader, envelope.CorrelationID)
-		return nil
 	}
 
+	if gr.Writable != nil {
+		edgexcontext.Configuration.Writable = gr.Writable.Get()
+	}
 	edgexcontext.CorrelationID = envelope.CorrelationID
+	edgexcontext.RawPayload = message.payload
 	edgexcontext.EventID = event.ID
+	edgexcontext.DeviceName = event.Device
+	edgexcontext.EventCreated = event.Created
+	if timeout := edgexcontext.Configuration.Writable.PipelineTimeout; timeout != "" && edgexcontext.Deadline.IsZero() {
+		if duration, err := time.ParseDuration(timeout); err != nil || duration <= 0 {
+			edgexcontext.LoggingClient.Error("Invalid PipelineTimeout '"+timeout+"', processing without a deadline", clients.CorrelationHeader, envelope.CorrelationID)
+		} else {
+			edgexcontext.Deadline = time.Now().Add(duration)
+		}
+	}
+
+	transforms, metrics, name := gr.Transforms, &gr.primaryMetrics, PrimaryPipelineName
+	if pipeline := gr.topicPipeline(edgexcontext.ReceivedTopic); pipeline != nil {
+		transforms, metrics, name = pipeline.Transforms, &pipeline.metrics, pipeline.ID
+	} else if gr.Candidate != nil && gr.Candidate.accepts(event, envelope.CorrelationID) {
+		transforms, metrics, name = gr.Candidate.Transforms, &gr.Candidate.metrics, CandidatePipelineName
+	}
+
+	span.SetAttribute("device", event.Device)
+	span.SetAttribute("pipeline", name)
+	span.SetAttribute("topic", edgexcontext.ReceivedTopic)
+
+	edgexcontext.LoggingClient.Debug("Processing Event: "+strconv.Itoa(len(transforms))+" Transforms", "pipeline", name)
+	return gr.executePipeline(edgexcontext, name, transforms, metrics, message.data)
+}
+
+// ProcessDeviceEvent executes the device events pipeline for the device lifecycle event. The first function is called
+// with the devices.DeviceEvent.
+func (gr *GolangRuntime) ProcessDeviceEvent(edgexcontext *appcontext.Context, event devices.DeviceEvent) error {
+	atomic.AddInt64(&gr.inFlight, 1)
+	defer atomic.AddInt64(&gr.inFlight, -1)
+
+	span := gr.Tracer.Start("process device event", tracing.KindConsumer, edgexcontext.TraceParent, edgexcontext.CorrelationID)
+	if span != nil {
+		edgexcontext.TraceParent = span.TraceParent()
+	}
+	span.SetAttribute("device", event.Device.Name)
+	span.SetAttribute("pipeline", DeviceEventsPipelineName)
+
+	if gr.Writable != nil {
+		edgexcontext.Configuration.Writable = gr.Writable.Get()
+	}
+	edgexcontext.DeviceName = event.Device.Name
+	if payload, err := json.Marshal(event); err == nil {
+		edgexcontext.RawPayload = payload
+	}
+
+	edgexcontext.LoggingClient.Debug("Processing device "+event.Action+" event: "+strconv.Itoa(len(gr.DeviceEvents))+" Transforms", "pipeline", DeviceEventsPipelineName)
+	err := gr.executePipeline(edgexcontext, DeviceEventsPipelineName, gr.DeviceEvents, &gr.deviceEventsMetrics, event)
+	span.Finish(err)
+	return err
+}
+
+// executePipeline calls the functions of the pipeline, returning the error which stopped the pipeline, if any
+func (gr *GolangRuntime) executePipeline(edgexcontext *appcontext.Context, name string, transforms []func(*appcontext.Context, ...interface{}) (bool, interface{}), metrics *PipelineMetrics, data interface{}) error {
+	started := time.Now()
+	atomic.AddUint64(&metrics.EventsReceived, 1)
+	defer func() {
+		atomic.AddInt64(&metrics.ProcessingTimeNanos, int64(time.Since(started)))
+	}()
+
 	var result interface{}
 	var continuePipeline = true
-	for _, trxFunc := range gr.Transforms {
+	for index, trxFunc := range transforms {
+		input := data
 		if result != nil {
-			continuePipeline, result = trxFunc(edgexcontext, result)
-		} else {
-			continuePipeline, result = trxFunc(edgexcontext, event)
+			input = result
 		}
+		if !edgexcontext.Deadline.IsZero() && time.Now().After(edgexcontext.Deadline) {
+			atomic.AddUint64(&metrics.EventsFailed, 1)
+			edgexcontext.LoggingClient.Error("Pipeline deadline exceeded before function "+strconv.Itoa(index), "pipeline", name, clients.CorrelationHeader, edgexcontext.CorrelationID)
+			return fmt.Errorf("pipeline deadline exceeded before function %d", index)
+		}
+		called := time.Now()
+		continuePipeline, result = gr.executeFunction(edgexcontext, name, index, trxFunc, input)
+		gr.functions.record(name, index, trxFunc, time.Since(called), continuePipeline, result)
 		if continuePipeline != true {
 			if result != nil {
-				if result, ok := result.(error); ok {
-					edgexcontext.LoggingClient.Error((result).(error).Error())
+				if err, ok := result.(error); ok {
+					atomic.AddUint64(&metrics.EventsFailed, 1)
+					edgexcontext.LoggingClient.Error(err.Error(), "pipeline", name)
+					gr.ErrorLog.Record(edgexcontext.CorrelationID, name, index, trxFunc, err, input)
+					gr.handleError(edgexcontext, name, trxFunc, err)
+					return err
 				}
 			}
-			break
+			atomic.AddUint64(&metrics.EventsStopped, 1)
+			return nil
+		}
+	}
+	atomic.AddUint64(&metrics.EventsCompleted, 1)
+	return nil
+}
+
+// executeFunction calls the function at index of the pipeline, profiling it while Writable.ProfileStages is enabled
+// and tracing it within the span of the event while tracing is enabled
+func (gr *GolangRuntime) executeFunction(edgexcontext *appcontext.Context, name string, index int, function func(*appcontext.Context, ...interface{}) (bool, interface{}), input interface{}) (bool, interface{}) {
+	eventTraceParent := edgexcontext.TraceParent
+	span := gr.Tracer.Start(functionName(function), tracing.KindInternal, eventTraceParent, edgexcontext.CorrelationID)
+	if span != nil {
+		span.SetAttribute("pipeline", name)
+		span.SetAttribute("function.index", strconv.Itoa(index))
+		edgexcontext.TraceParent = span.TraceParent()
+		defer func() {
+			edgexcontext.TraceParent = eventTraceParent
+		}()
+	}
+
+	continuePipeline, result := gr.callFunction(edgexcontext, name, index, function, input)
+	err, _ := result.(error)
+	if continuePipeline {
+		err = nil
+	}
+	span.Finish(err)
+	return continuePipeline, result
+}
+
+// callFunction calls the function at index of the pipeline, recovering from a panic so a failing function can't stop
+// the trigger. A panic stops the pipeline with an error.
+func (gr *GolangRuntime) callFunction(edgexcontext *appcontext.Context, name string, index int, function func(*appcontext.Context, ...interface{}) (bool, interface{}), input interface{}) (continuePipeline bool, result interface{}) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err := fmt.Errorf("function %d (%s) panicked: %v", index, functionName(function), recovered)
+			edgexcontext.LoggingClient.Error(err.Error(), "pipeline", name, clients.CorrelationHeader, edgexcontext.CorrelationID)
+			edgexcontext.LoggingClient.Debug(string(debug.Stack()), clients.CorrelationHeader, edgexcontext.CorrelationID)
+			continuePipeline, result = false, err
+		}
+	}()
+
+	if edgexcontext.Configuration.Writable.ProfileStages {
+		return gr.stages.run(name, index, function, edgexcontext, input)
+	}
+	return function(edgexcontext, input)
+}
+
+// handleError calls the ErrorHandler with the error returned by the function, recovering from a panic so a failing
+// handler can't stop the trigger
+func (gr *GolangRuntime) handleError(edgexcontext *appcontext.Context, name string, function func(*appcontext.Context, ...interface{}) (bool, interface{}), err error) {
+	if gr.ErrorHandler == nil {
+		return
+	}
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			edgexcontext.LoggingClient.Error(fmt.Sprintf("Pipeline error handler panicked: %v", recovered), "pipeline", name, clients.CorrelationHeader, edgexcontext.CorrelationID)
+		}
+	}()
+	gr.ErrorHandler(edgexcontext, functionName(function), err, edgexcontext.RawPayload)
+}
+
+// FunctionMetrics returns a snapshot of the invocation counts, failures and latency histogram of each function of
+// the pipelines
+func (gr *GolangRuntime) FunctionMetrics() []FunctionMetrics {
+	return gr.functions.metrics()
+}
+
+// CacheMetrics returns a snapshot of the hits and misses of each memoized function of the pipelines
+func (gr *GolangRuntime) CacheMetrics() []CacheMetrics {
+	metrics := make([]CacheMetrics, 0, len(gr.Caches))
+	for _, cache := range gr.Caches {
+		metrics = append(metrics, cache.Metrics())
+	}
+	return metrics
+}
+
+// ConcurrencyMetrics returns a snapshot of the calls running and waiting for each function with a concurrency limit
+func (gr *GolangRuntime) ConcurrencyMetrics() []ConcurrencyMetrics {
+	metrics := make([]ConcurrencyMetrics, 0, len(gr.Limits))
+	for _, limit := range gr.Limits {
+		metrics = append(metrics, limit.Metrics())
+	}
+	return metrics
+}
+
+// decodeTarget decodes the payload into a new value of the type pointed to by targetType. A []byte target receives
+// the payload as is, whatever its content type.
+func decodeTarget(targetType interface{}, payload []byte, contentType string) (interface{}, error) {
+	targetPointer := reflect.ValueOf(targetType)
+	if targetPointer.Kind() != reflect.Ptr || targetPointer.IsNil() {
+		return nil, fmt.Errorf("target type must be a non-nil pointer, got %T", targetType)
+	}
+	if _, ok := targetType.(*[]byte); ok {
+		return payload, nil
+	}
+
+	target := reflect.New(targetPointer.Elem().Type())
+	switch contentType {
+	case clients.ContentTypeJSON:
+		if err := json.Unmarshal(payload, target.Interface()); err != nil {
+			return nil, err
+		}
+	case clients.ContentTypeCBOR:
+		if err := codec.NewDecoderBytes(payload, &codec.CborHandle{}).Decode(target.Interface()); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("'%s' content type not supported", contentType)
+	}
+	return target.Elem().Interface(), nil
+}
+
+// topicPipeline returns the pipeline for events received on the topic, or nil if there is none
+func (gr *GolangRuntime) topicPipeline(topic string) *TopicPipeline {
+	if topic == "" {
+		return nil
+	}
+	for _, pipeline := range gr.TopicPipelines {
+		for _, pipelineTopic := range pipeline.Topics {
+			if pipelineTopic == topic {
+				return pipeline
+			}
 		}
 	}
 	return nil
 }
+
+// accepts determines if the candidate pipeline should process the event
+func (candidate *CandidatePipeline) accepts(event models.Event, correlationID string) bool {
+	for _, deviceName := range candidate.DeviceNames {
+		if event.Device == deviceName {
+			return true
+		}
+	}
+
+	if candidate.Percentage <= 0 {
+		return false
+	}
+
+	// hash the correlation ID when present so the routing decision for an event is repeatable
+	bucket := rand.Intn(100)
+	if correlationID != "" {
+		hash := fnv.New32a()
+		hash.Write([]byte(correlationID))
+		bucket = int(hash.Sum32() % 100)
+	}
+
+	return bucket < candidate.Percentage
+}
+
+// PipelineMetrics returns a snapshot of the execution metrics of each pipeline keyed by pipeline name
+func (gr *GolangRuntime) PipelineMetrics() map[string]PipelineMetrics {
+	metrics := map[string]PipelineMetrics{PrimaryPipelineName: gr.primaryMetrics.snapshot()}
+	if gr.Candidate != nil {
+		metrics[CandidatePipelineName] = gr.Candidate.metrics.snapshot()
+	}
+	for _, pipeline := range gr.TopicPipelines {
+		metrics[pipeline.ID] = pipeline.metrics.snapshot()
+	}
+	if len(gr.DeviceEvents) > 0 {
+		metrics[DeviceEventsPipelineName] = gr.deviceEventsMetrics.snapshot()
+	}
+	return metrics
+}
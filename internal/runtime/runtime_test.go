@@ -1,34 +1,42 @@
-//
 // Copyright (c) 2019 Intel Corporation
 //
 // Licensed under the Apache License, Version 2.0 (the "License");
 // you may not use this file except in compliance with the License.
 // You may obtain a copy of the License at
 //
-//      http://www.apache.org/licenses/LICENSE-2.0
+//	http://www.apache.org/licenses/LICENSE-2.0
 //
 // Unless required by applicable law or agreed to in writing, software
 // distributed under the License is distributed on an "AS IS" BASIS,
 // WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
 // See the License for the specific language governing permissions and
 // limitations under the License.
-//
 package runtime
 
 import (
 	"bytes"
 	"encoding/json"
 	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"github.com/ugorji/go/codec"
 
 	"github.com/antoniomtz/app-functions-sdk-go/appcontext"
+	"github.com/antoniomtz/app-functions-sdk-go/internal/common"
+	"github.com/antoniomtz/app-functions-sdk-go/internal/tracing"
+	"github.com/antoniomtz/app-functions-sdk-go/pkg/devices"
+	"github.com/antoniomtz/go-mod-messaging/pkg/types"
 	"github.com/edgexfoundry/go-mod-core-contracts/clients"
 	"github.com/edgexfoundry/go-mod-core-contracts/clients/logger"
 	"github.com/edgexfoundry/go-mod-core-contracts/models"
-	"github.com/antoniomtz/go-mod-messaging/pkg/types"
 )
 
 var lc logger.LoggingClient
@@ -269,6 +277,14 @@ func TestProcessEventJSON(t *testing.T) {
 			t.Fatal()
 		}
 
+		if !assert.Equal(t, devID1, edgexcontext.DeviceName, "Context doesn't contain expected DeviceName") {
+			t.Fatal()
+		}
+
+		if !assert.Equal(t, eventInBytes, edgexcontext.RawPayload, "Context doesn't contain expected RawPayload") {
+			t.Fatal()
+		}
+
 		if result, ok := params[0].(*models.Event); ok {
 			if !assert.True(t, ok, "Should have received CoreData event") {
 				t.Fatal()
@@ -359,3 +375,458 @@ func TestProcessEventCBOR(t *testing.T) {
 		t.Fatal()
 	}
 }
+
+func TestProcessEventCandidatePipelineByDevice(t *testing.T) {
+	primaryCalled := 0
+	candidateCalled := 0
+	primary := func(edgexcontext *appcontext.Context, params ...interface{}) (bool, interface{}) {
+		primaryCalled++
+		return true, nil
+	}
+	candidate := func(edgexcontext *appcontext.Context, params ...interface{}) (bool, interface{}) {
+		candidateCalled++
+		return false, errors.New("candidate failed")
+	}
+
+	runtime := GolangRuntime{
+		Transforms: []func(edgexcontext *appcontext.Context, params ...interface{}) (bool, interface{}){primary},
+		Candidate: &CandidatePipeline{
+			Transforms:  []func(edgexcontext *appcontext.Context, params ...interface{}) (bool, interface{}){candidate},
+			DeviceNames: []string{devID2},
+		},
+	}
+
+	for _, device := range []string{devID1, devID2, devID1} {
+		eventInBytes, _ := json.Marshal(models.Event{Device: device})
+		envelope := types.MessageEnvelope{
+			CorrelationID: "123-234-345-456",
+			Payload:       eventInBytes,
+			ContentType:   clients.ContentTypeJSON,
+		}
+		runtime.ProcessEvent(&appcontext.Context{LoggingClient: lc}, envelope)
+	}
+
+	assert.Equal(t, 2, primaryCalled, "primary pipeline should process events from other devices")
+	assert.Equal(t, 1, candidateCalled, "candidate pipeline should process events from its devices")
+
+	metrics := runtime.PipelineMetrics()
+	assert.Equal(t, uint64(2), metrics[PrimaryPipelineName].EventsReceived)
+	assert.Equal(t, uint64(2), metrics[PrimaryPipelineName].EventsCompleted)
+	assert.Equal(t, uint64(1), metrics[CandidatePipelineName].EventsReceived)
+	assert.Equal(t, uint64(1), metrics[CandidatePipelineName].EventsFailed)
+}
+
+func TestProcessEventTopicPipelines(t *testing.T) {
+	primaryCalled := 0
+	cloudCalled := 0
+	primary := func(edgexcontext *appcontext.Context, params ...interface{}) (bool, interface{}) {
+		primaryCalled++
+		return true, nil
+	}
+	cloud := func(edgexcontext *appcontext.Context, params ...interface{}) (bool, interface{}) {
+		cloudCalled++
+		return false, nil
+	}
+
+	runtime := GolangRuntime{
+		Transforms: []func(edgexcontext *appcontext.Context, params ...interface{}) (bool, interface{}){primary},
+		TopicPipelines: []*TopicPipeline{
+			{
+				ID:         "cloud",
+				Topics:     []string{"temperature", "humidity"},
+				Transforms: []func(edgexcontext *appcontext.Context, params ...interface{}) (bool, interface{}){cloud},
+			},
+		},
+	}
+
+	for _, topic := range []string{"temperature", "camera", "humidity", ""} {
+		eventInBytes, _ := json.Marshal(models.Event{Device: devID1})
+		envelope := types.MessageEnvelope{
+			CorrelationID: "123-234-345-456",
+			Payload:       eventInBytes,
+			ContentType:   clients.ContentTypeJSON,
+		}
+		runtime.ProcessEvent(&appcontext.Context{LoggingClient: lc, ReceivedTopic: topic}, envelope)
+	}
+
+	assert.Equal(t, 2, primaryCalled, "primary pipeline should process events from other topics")
+	assert.Equal(t, 2, cloudCalled, "topic pipeline should process events from its topics")
+
+	metrics := runtime.PipelineMetrics()
+	assert.Equal(t, uint64(2), metrics[PrimaryPipelineName].EventsCompleted)
+	assert.Equal(t, uint64(2), metrics["cloud"].EventsReceived)
+	assert.Equal(t, uint64(2), metrics["cloud"].EventsStopped)
+}
+
+func TestProcessDeviceEvent(t *testing.T) {
+	var received devices.DeviceEvent
+	var deviceName string
+	provision := func(edgexcontext *appcontext.Context, params ...interface{}) (bool, interface{}) {
+		received = params[0].(devices.DeviceEvent)
+		deviceName = edgexcontext.DeviceName
+		return false, errors.New("cloud twin unavailable")
+	}
+	runtime := GolangRuntime{
+		DeviceEvents: []func(edgexcontext *appcontext.Context, params ...interface{}) (bool, interface{}){provision},
+	}
+
+	event := devices.DeviceEvent{Action: devices.DeviceAdded, Device: models.Device{Name: devID1}}
+	edgexcontext := &appcontext.Context{LoggingClient: lc}
+	err := runtime.ProcessDeviceEvent(edgexcontext, event)
+	assert.EqualError(t, err, "cloud twin unavailable")
+	assert.Equal(t, devices.DeviceAdded, received.Action)
+	assert.Equal(t, devID1, deviceName)
+	assert.Contains(t, string(edgexcontext.RawPayload), `"action":"added"`)
+
+	metrics := runtime.PipelineMetrics()
+	assert.Equal(t, uint64(1), metrics[DeviceEventsPipelineName].EventsReceived)
+	assert.Equal(t, uint64(1), metrics[DeviceEventsPipelineName].EventsFailed)
+}
+
+func TestProcessEventTargetType(t *testing.T) {
+	type customData struct {
+		Name  string `json:"name"`
+		Value int    `json:"value"`
+	}
+	payload := []byte(`{"name":"pressure","value":42}`)
+	envelope := types.MessageEnvelope{
+		CorrelationID: "123-234-345-456",
+		Payload:       payload,
+		ContentType:   clients.ContentTypeJSON,
+	}
+
+	var received interface{}
+	transform := func(edgexcontext *appcontext.Context, params ...interface{}) (bool, interface{}) {
+		received = params[0]
+		return false, nil
+	}
+
+	runtime := GolangRuntime{
+		Transforms: []func(edgexcontext *appcontext.Context, params ...interface{}) (bool, interface{}){transform},
+		TargetType: &[]byte{},
+	}
+	runtime.ProcessEvent(&appcontext.Context{LoggingClient: lc}, envelope)
+	assert.Equal(t, payload, received, "Raw payload should be passed for a []byte target type")
+
+	runtime.TargetType = &customData{}
+	runtime.ProcessEvent(&appcontext.Context{LoggingClient: lc}, envelope)
+	assert.Equal(t, customData{Name: "pressure", Value: 42}, received, "Payload should be decoded into the target type")
+
+	var cborPayload []byte
+	codec.NewEncoderBytes(&cborPayload, &codec.CborHandle{}).Encode(customData{Name: "flow", Value: 7})
+	runtime.ProcessEvent(&appcontext.Context{LoggingClient: lc}, types.MessageEnvelope{Payload: cborPayload, ContentType: clients.ContentTypeCBOR})
+	assert.Equal(t, customData{Name: "flow", Value: 7}, received)
+
+	received = nil
+	runtime.ProcessEvent(&appcontext.Context{LoggingClient: lc}, types.MessageEnvelope{Payload: []byte("not json"), ContentType: clients.ContentTypeJSON})
+	assert.Nil(t, received, "Pipeline should not run when the payload can't be decoded")
+
+	runtime.TargetType = customData{}
+	runtime.ProcessEvent(&appcontext.Context{LoggingClient: lc}, envelope)
+	assert.Nil(t, received, "Pipeline should not run when the target type isn't a pointer")
+}
+
+func TestCandidatePipelinePercentage(t *testing.T) {
+	none := CandidatePipeline{Percentage: 0}
+	all := CandidatePipeline{Percentage: 100}
+	half := CandidatePipeline{Percentage: 50}
+
+	accepted := 0
+	for i := 0; i < 1000; i++ {
+		correlationID := strconv.Itoa(i)
+		assert.False(t, none.accepts(models.Event{}, correlationID))
+		assert.True(t, all.accepts(models.Event{}, correlationID))
+		if half.accepts(models.Event{}, correlationID) {
+			accepted++
+		}
+		assert.Equal(t, half.accepts(models.Event{}, correlationID), half.accepts(models.Event{}, correlationID), "routing should be repeatable for a correlation ID")
+	}
+
+	assert.InDelta(t, 500, accepted, 100, "roughly half of the events should be routed to the candidate")
+}
+
+func TestProcessEventWritable(t *testing.T) {
+	eventInBytes, _ := json.Marshal(models.Event{Device: devID1})
+	envelope := types.MessageEnvelope{
+		CorrelationID: "123-234-345-456",
+		Payload:       eventInBytes,
+		ContentType:   clients.ContentTypeJSON,
+	}
+
+	var settings map[string]string
+	transform := func(edgexcontext *appcontext.Context, params ...interface{}) (bool, interface{}) {
+		settings = edgexcontext.Configuration.Writable.PipelineSettings
+		return false, nil
+	}
+	writable := common.NewWritableStore(common.WritableInfo{PipelineSettings: map[string]string{"Threshold": "10"}})
+	runtime := GolangRuntime{
+		Transforms: []func(edgexcontext *appcontext.Context, params ...interface{}) (bool, interface{}){transform},
+		Writable:   writable,
+	}
+
+	runtime.ProcessEvent(&appcontext.Context{LoggingClient: lc}, envelope)
+	assert.Equal(t, "10", settings["Threshold"])
+
+	writable.Set(common.WritableInfo{PipelineSettings: map[string]string{"Threshold": "20"}})
+	runtime.ProcessEvent(&appcontext.Context{LoggingClient: lc}, envelope)
+	assert.Equal(t, "20", settings["Threshold"], "Updated Writable configuration should be used for the next event")
+}
+
+func TestProcessEventFunctionMetrics(t *testing.T) {
+	calls := 0
+	slow := func(edgexcontext *appcontext.Context, params ...interface{}) (bool, interface{}) {
+		time.Sleep(2 * time.Millisecond)
+		return true, params[0]
+	}
+	failEverySecond := func(edgexcontext *appcontext.Context, params ...interface{}) (bool, interface{}) {
+		calls++
+		if calls%2 == 0 {
+			return false, errors.New("failed")
+		}
+		return false, nil
+	}
+	runtime := GolangRuntime{
+		Transforms: []func(edgexcontext *appcontext.Context, params ...interface{}) (bool, interface{}){slow, failEverySecond},
+	}
+
+	eventInBytes, _ := json.Marshal(models.Event{Device: devID1})
+	envelope := types.MessageEnvelope{
+		CorrelationID: "123-234-345-456",
+		Payload:       eventInBytes,
+		ContentType:   clients.ContentTypeJSON,
+	}
+	for i := 0; i < 4; i++ {
+		runtime.ProcessEvent(&appcontext.Context{LoggingClient: lc}, envelope)
+	}
+
+	metrics := runtime.FunctionMetrics()
+	require.Equal(t, 2, len(metrics))
+	assert.Equal(t, PrimaryPipelineName, metrics[0].Pipeline)
+	assert.Equal(t, 0, metrics[0].FunctionIndex)
+	assert.Contains(t, metrics[0].FunctionName, "TestProcessEventFunctionMetrics")
+	assert.Equal(t, uint64(4), metrics[0].Invocations)
+	assert.Equal(t, uint64(0), metrics[0].Failures)
+	assert.True(t, metrics[0].TotalTimeNanos >= int64(8*time.Millisecond))
+	require.Equal(t, len(latencyBounds)+1, len(metrics[0].Latency))
+	assert.Equal(t, LatencyBucket{UpperBound: "1ms", Count: 0}, metrics[0].Latency[1], "calls take at least 2ms")
+	var count uint64
+	for _, bucket := range metrics[0].Latency {
+		count += bucket.Count
+	}
+	assert.Equal(t, uint64(4), count)
+	assert.Equal(t, "+Inf", metrics[0].Latency[len(latencyBounds)].UpperBound)
+
+	assert.Equal(t, 1, metrics[1].FunctionIndex)
+	assert.Equal(t, uint64(4), metrics[1].Invocations)
+	assert.Equal(t, uint64(2), metrics[1].Stopped)
+	assert.Equal(t, uint64(2), metrics[1].Failures)
+}
+
+func TestProcessEventPipelineTimeout(t *testing.T) {
+	var deadline time.Time
+	slow := func(edgexcontext *appcontext.Context, params ...interface{}) (bool, interface{}) {
+		deadline = edgexcontext.Deadline
+		time.Sleep(20 * time.Millisecond)
+		return true, params[0]
+	}
+	called := false
+	next := func(edgexcontext *appcontext.Context, params ...interface{}) (bool, interface{}) {
+		called = true
+		return true, nil
+	}
+	runtime := GolangRuntime{
+		Transforms: []func(edgexcontext *appcontext.Context, params ...interface{}) (bool, interface{}){slow, next},
+	}
+
+	eventInBytes, _ := json.Marshal(models.Event{Device: devID1})
+	envelope := types.MessageEnvelope{
+		CorrelationID: "123-234-345-456",
+		Payload:       eventInBytes,
+		ContentType:   clients.ContentTypeJSON,
+	}
+	configuration := common.ConfigurationStruct{Writable: common.WritableInfo{PipelineTimeout: "10ms"}}
+	started := time.Now()
+	runtime.ProcessEvent(&appcontext.Context{LoggingClient: lc, Configuration: configuration}, envelope)
+
+	assert.False(t, deadline.Before(started.Add(10*time.Millisecond)), "Deadline should be set from PipelineTimeout")
+	assert.False(t, called, "Functions shouldn't be called once the deadline has passed")
+	assert.Equal(t, uint64(1), runtime.PipelineMetrics()[PrimaryPipelineName].EventsFailed)
+
+	configuration.Writable.PipelineTimeout = ""
+	runtime.ProcessEvent(&appcontext.Context{LoggingClient: lc, Configuration: configuration}, envelope)
+	assert.True(t, deadline.IsZero())
+	assert.True(t, called)
+}
+
+func TestProcessEventTracing(t *testing.T) {
+	type span struct {
+		TraceID      string `json:"traceId"`
+		SpanID       string `json:"spanId"`
+		ParentSpanID string `json:"parentSpanId"`
+		Name         string `json:"name"`
+		Status       struct {
+			Code int `json:"code"`
+		} `json:"status"`
+	}
+	var mutex sync.Mutex
+	spans := map[string]span{}
+	collector := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		request := struct {
+			ResourceSpans []struct {
+				ScopeSpans []struct {
+					Spans []span `json:"spans"`
+				} `json:"scopeSpans"`
+			} `json:"resourceSpans"`
+		}{}
+		require.NoError(t, json.Unmarshal(body, &request))
+		mutex.Lock()
+		defer mutex.Unlock()
+		for _, resource := range request.ResourceSpans {
+			for _, scope := range resource.ScopeSpans {
+				for _, received := range scope.Spans {
+					spans[received.Name] = received
+				}
+			}
+		}
+	}))
+	defer collector.Close()
+
+	tracer := tracing.NewTracer(collector.URL, "app-trace", 0, time.Hour, lc)
+	exports := &ExportTracker{Tracer: tracer}
+	var exportTraceParent string
+	export := exports.Track("HTTPPost", func(edgexcontext *appcontext.Context, params ...interface{}) (bool, interface{}) {
+		exportTraceParent = edgexcontext.TraceParent
+		return false, errors.New("connection refused")
+	}, nil)
+	runtime := GolangRuntime{
+		Transforms: []func(edgexcontext *appcontext.Context, params ...interface{}) (bool, interface{}){export},
+		Tracer:     tracer,
+	}
+
+	eventInBytes, _ := json.Marshal(models.Event{Device: devID1})
+	envelope := types.MessageEnvelope{
+		CorrelationID: "9f3b5c2e-8a1d-4e6f-9a0b-1c2d3e4f5a6b",
+		Payload:       eventInBytes,
+		ContentType:   clients.ContentTypeJSON,
+	}
+	runtime.ProcessEvent(&appcontext.Context{LoggingClient: lc}, envelope)
+	tracer.Close()
+
+	mutex.Lock()
+	defer mutex.Unlock()
+	require.Equal(t, 3, len(spans))
+	event, function, exported := spans["process event"], spans[functionName(export)], spans["export HTTPPost"]
+	assert.Equal(t, "9f3b5c2e8a1d4e6f9a0b1c2d3e4f5a6b", event.TraceID, "Trace ID should be the correlation ID")
+	assert.Empty(t, event.ParentSpanID)
+	assert.Equal(t, 2, event.Status.Code)
+	assert.Equal(t, event.TraceID, function.TraceID)
+	assert.Equal(t, event.SpanID, function.ParentSpanID)
+	assert.Equal(t, function.SpanID, exported.ParentSpanID)
+	assert.Equal(t, 2, exported.Status.Code)
+	assert.Equal(t, "00-"+exported.TraceID+"-"+exported.SpanID+"-01", exportTraceParent, "Export should see the trace context of its span")
+}
+
+func TestDecodeMessageDevice(t *testing.T) {
+	runtime := GolangRuntime{}
+	eventIn := models.Event{Device: devID1}
+	payload, _ := json.Marshal(eventIn)
+	assert.Equal(t, devID1, runtime.DecodeMessage(types.MessageEnvelope{Payload: payload, ContentType: clients.ContentTypeJSON}).Device())
+
+	buffer := new(bytes.Buffer)
+	codec.NewEncoder(buffer, new(codec.CborHandle)).Encode(eventIn)
+	assert.Equal(t, devID1, runtime.DecodeMessage(types.MessageEnvelope{Payload: buffer.Bytes(), ContentType: clients.ContentTypeCBOR}).Device())
+
+	assert.Empty(t, runtime.DecodeMessage(types.MessageEnvelope{Payload: []byte("not an event"), ContentType: clients.ContentTypeJSON}).Device())
+	assert.Empty(t, runtime.DecodeMessage(types.MessageEnvelope{Payload: payload, ContentType: "text/plain"}).Device())
+
+	runtime.TargetType = &models.Event{}
+	assert.Equal(t, devID1, runtime.DecodeMessage(types.MessageEnvelope{Payload: payload, ContentType: clients.ContentTypeJSON}).Device())
+	runtime.TargetType = &[]byte{}
+	assert.Empty(t, runtime.DecodeMessage(types.MessageEnvelope{Payload: payload, ContentType: clients.ContentTypeJSON}).Device())
+}
+
+func TestProcessDecodedMessage(t *testing.T) {
+	var received interface{}
+	var rawPayload []byte
+	transform := func(edgexcontext *appcontext.Context, params ...interface{}) (bool, interface{}) {
+		received, rawPayload = params[0], edgexcontext.RawPayload
+		return false, nil
+	}
+	runtime := GolangRuntime{Transforms: []func(*appcontext.Context, ...interface{}) (bool, interface{}){transform}}
+	payload, _ := json.Marshal(models.Event{Device: devID1})
+	message := runtime.DecodeMessage(types.MessageEnvelope{CorrelationID: "1", Payload: payload, ContentType: clients.ContentTypeJSON})
+	// the message is processed as decoded, not decoded again
+	message.Envelope.Payload = nil
+
+	assert.NoError(t, runtime.ProcessDecodedMessage(&appcontext.Context{LoggingClient: lc}, message))
+	if assert.IsType(t, models.Event{}, received) {
+		assert.Equal(t, devID1, received.(models.Event).Device)
+	}
+	assert.Equal(t, payload, rawPayload)
+
+	failed := runtime.DecodeMessage(types.MessageEnvelope{CorrelationID: "2", Payload: []byte("not an event"), ContentType: clients.ContentTypeJSON})
+	assert.Error(t, runtime.ProcessDecodedMessage(&appcontext.Context{LoggingClient: lc}, failed))
+}
+
+func TestProcessEventRecoversPanic(t *testing.T) {
+	var nilEvent *models.Event
+	panicking := func(edgexcontext *appcontext.Context, params ...interface{}) (bool, interface{}) {
+		return true, nilEvent.Device
+	}
+	runtime := GolangRuntime{
+		Transforms: []func(*appcontext.Context, ...interface{}) (bool, interface{}){panicking},
+		ErrorLog:   NewErrorLog(10, 0, nil),
+	}
+	eventInBytes, _ := json.Marshal(models.Event{Device: devID1})
+	envelope := types.MessageEnvelope{CorrelationID: "123-234-345-456", Payload: eventInBytes, ContentType: clients.ContentTypeJSON}
+
+	for i := 0; i < 2; i++ {
+		assert.NotPanics(t, func() {
+			runtime.ProcessEvent(&appcontext.Context{LoggingClient: lc}, envelope)
+		})
+	}
+
+	assert.Equal(t, uint64(2), runtime.PipelineMetrics()[PrimaryPipelineName].EventsFailed)
+	entries := runtime.ErrorLog.Entries()
+	require.Equal(t, 2, len(entries))
+	assert.Equal(t, "123-234-345-456", entries[0].CorrelationID)
+	assert.Contains(t, entries[0].Error, "panicked")
+}
+
+func TestProcessEventErrorHandler(t *testing.T) {
+	failing := func(edgexcontext *appcontext.Context, params ...interface{}) (bool, interface{}) {
+		return false, errors.New("connection refused")
+	}
+	stopping := func(edgexcontext *appcontext.Context, params ...interface{}) (bool, interface{}) {
+		return false, nil
+	}
+	var names []string
+	var errs []error
+	var payloads [][]byte
+	runtime := GolangRuntime{
+		Transforms: []func(*appcontext.Context, ...interface{}) (bool, interface{}){failing},
+		ErrorHandler: func(edgexcontext *appcontext.Context, functionName string, err error, payload []byte) {
+			assert.Equal(t, "123-234-345-456", edgexcontext.CorrelationID)
+			names = append(names, functionName)
+			errs = append(errs, err)
+			payloads = append(payloads, payload)
+			panic("handler failed")
+		},
+	}
+	eventInBytes, _ := json.Marshal(models.Event{Device: devID1})
+	envelope := types.MessageEnvelope{CorrelationID: "123-234-345-456", Payload: eventInBytes, ContentType: clients.ContentTypeJSON}
+
+	assert.NotPanics(t, func() {
+		runtime.ProcessEvent(&appcontext.Context{LoggingClient: lc}, envelope)
+	}, "a panicking handler should be recovered")
+	assert.Equal(t, uint64(1), runtime.PipelineMetrics()[PrimaryPipelineName].EventsFailed)
+	require.Len(t, names, 1)
+	assert.Contains(t, names[0], "TestProcessEventErrorHandler")
+	assert.EqualError(t, errs[0], "connection refused")
+	assert.Equal(t, eventInBytes, payloads[0], "the handler should receive the payload received")
+
+	runtime.Transforms = []func(*appcontext.Context, ...interface{}) (bool, interface{}){stopping}
+	runtime.ProcessEvent(&appcontext.Context{LoggingClient: lc}, envelope)
+	assert.Len(t, names, 1, "the handler should only be called for errors")
+}
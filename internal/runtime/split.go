@@ -0,0 +1,85 @@
+//
+// Copyright (c) 2019 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package runtime
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/antoniomtz/app-functions-sdk-go/appcontext"
+	"github.com/edgexfoundry/go-mod-core-contracts/models"
+)
+
+// SplitPipeline executes a collection of functions against an event per reading of the event received, for exports
+// which require a message per metric. The first function is called with the event of a reading and each successive
+// function with the result of the previous one.
+type SplitPipeline struct {
+	Transforms []func(*appcontext.Context, ...interface{}) (bool, interface{})
+}
+
+// ProcessSplit runs the functions for an event per reading of the event received from the previous function, holding
+// only that reading along with the device and origin of the event. The origin and device of the reading default to
+// those of the event. When a function stops the pipeline for an event the remaining functions are skipped for it,
+// and if a function returns an error the remaining readings are not processed. This function returns the Event
+// received unchanged.
+func (sp SplitPipeline) ProcessSplit(edgexcontext *appcontext.Context, params ...interface{}) (bool, interface{}) {
+	if len(params) < 1 {
+		return false, errors.New("No Event Received")
+	}
+
+	event, ok := params[0].(models.Event)
+	if !ok {
+		return false, errors.New("Unexpected type received, expecting models.Event")
+	}
+
+	for _, reading := range event.Readings {
+		if reading.Device == "" {
+			reading.Device = event.Device
+		}
+		if reading.Origin == 0 {
+			reading.Origin = event.Origin
+		}
+		split := models.Event{
+			Device:   event.Device,
+			Origin:   event.Origin,
+			Created:  event.Created,
+			Readings: []models.Reading{reading},
+		}
+		if err := sp.processEvent(edgexcontext, split); err != nil {
+			return false, fmt.Errorf("processing the event of reading '%s' failed: %v", reading.Name, err)
+		}
+	}
+
+	return true, event
+}
+
+func (sp SplitPipeline) processEvent(edgexcontext *appcontext.Context, event models.Event) error {
+	var result interface{} = event
+	for _, trxFunc := range sp.Transforms {
+		continuePipeline, functionResult := trxFunc(edgexcontext, result)
+		if !continuePipeline {
+			if err, ok := functionResult.(error); ok {
+				return err
+			}
+			return nil
+		}
+		if functionResult != nil {
+			result = functionResult
+		}
+	}
+	return nil
+}
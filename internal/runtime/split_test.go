@@ -0,0 +1,88 @@
+//
+// Copyright (c) 2019 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package runtime
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/antoniomtz/app-functions-sdk-go/appcontext"
+	"github.com/edgexfoundry/go-mod-core-contracts/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProcessSplit(t *testing.T) {
+	context := &appcontext.Context{LoggingClient: lc}
+
+	var exported []models.Event
+	skipSensor2 := func(edgexcontext *appcontext.Context, params ...interface{}) (bool, interface{}) {
+		event := params[0].(models.Event)
+		return event.Readings[0].Name != "sensor2", nil
+	}
+	export := func(edgexcontext *appcontext.Context, params ...interface{}) (bool, interface{}) {
+		exported = append(exported, params[0].(models.Event))
+		return true, nil
+	}
+
+	pipeline := SplitPipeline{
+		Transforms: []func(*appcontext.Context, ...interface{}) (bool, interface{}){skipSensor2, export},
+	}
+	eventIn := models.Event{
+		ID:     "event1",
+		Device: devID1,
+		Origin: 1000,
+		Readings: []models.Reading{
+			{Name: readingName1, Value: "1"},
+			{Name: "sensor2", Value: "2"},
+			{Name: "sensor3", Value: "3", Origin: 2000},
+		},
+	}
+
+	continuePipeline, result := pipeline.ProcessSplit(context, eventIn)
+	assert.True(t, continuePipeline)
+	assert.Equal(t, eventIn, result, "Event received should be returned unchanged")
+	assert.Equal(t, []models.Event{
+		{Device: devID1, Origin: 1000, Readings: []models.Reading{{Device: devID1, Name: readingName1, Value: "1", Origin: 1000}}},
+		{Device: devID1, Origin: 1000, Readings: []models.Reading{{Device: devID1, Name: "sensor3", Value: "3", Origin: 2000}}},
+	}, exported)
+}
+
+func TestProcessSplitError(t *testing.T) {
+	context := &appcontext.Context{LoggingClient: lc}
+
+	calls := 0
+	failing := func(edgexcontext *appcontext.Context, params ...interface{}) (bool, interface{}) {
+		calls++
+		return false, errors.New("unavailable")
+	}
+	pipeline := SplitPipeline{
+		Transforms: []func(*appcontext.Context, ...interface{}) (bool, interface{}){failing},
+	}
+
+	continuePipeline, result := pipeline.ProcessSplit(context, models.Event{
+		Readings: []models.Reading{{Name: readingName1, Value: "1"}, {Name: "sensor2", Value: "2"}},
+	})
+	assert.False(t, continuePipeline)
+	require.Error(t, result.(error))
+	assert.Equal(t, "processing the event of reading '"+readingName1+"' failed: unavailable", result.(error).Error())
+	assert.Equal(t, 1, calls, "Remaining readings should not be processed")
+
+	continuePipeline, result = pipeline.ProcessSplit(context, "event")
+	assert.False(t, continuePipeline)
+	assert.EqualError(t, result.(error), "Unexpected type received, expecting models.Event")
+}
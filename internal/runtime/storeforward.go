@@ -0,0 +1,215 @@
+//
+// Copyright (c) 2019 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package runtime
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/antoniomtz/app-functions-sdk-go/internal/store"
+	"github.com/edgexfoundry/go-mod-core-contracts/clients"
+)
+
+// RetryStored exports the data stored for store and forward again through the export functions which failed, and
+// removes it once exported. Data which fails again is stored in the dead letters after MaxRetryCount retries. It
+// returns the number of objects exported, and is not run concurrently.
+func (tracker *ExportTracker) RetryStored() (int, error) {
+	if tracker.Store == nil {
+		return 0, errors.New("store and forward is not enabled")
+	}
+	tracker.retrying.Lock()
+	defer tracker.retrying.Unlock()
+
+	objects, err := tracker.Store.RetrieveFromStore()
+	if err != nil {
+		return 0, err
+	}
+
+	exported := 0
+	for _, object := range objects {
+		tracked := tracker.tracked(object.Export)
+		if tracked == nil {
+			// the export function may be configured again, so the data is kept until it's evicted
+			continue
+		}
+
+		edgexcontext := tracker.NewContext(object.CorrelationID)
+		edgexcontext.EventID = object.EventID
+		edgexcontext.EventChecksum = object.EventChecksum
+		continuePipeline, result := tracked.retry(edgexcontext, object.Payload)
+		exportErr, failed := result.(error)
+		if continuePipeline || !failed {
+			// data held by the export function, i.e. in a batch, is exported by it from now on
+			if err := tracker.Store.RemoveFromStore(object.ID); err != nil && err != store.ErrNotFound {
+				return exported, err
+			}
+			exported++
+			continue
+		}
+
+		object.RetryCount++
+		object.LastError = exportErr.Error()
+		if tracker.MaxRetryCount > 0 && object.RetryCount >= tracker.MaxRetryCount {
+			if err := tracker.Store.RemoveFromStore(object.ID); err != nil && err != store.ErrNotFound {
+				return exported, err
+			}
+			edgexcontext.LoggingClient.Error(fmt.Sprintf("Giving up exporting the stored data of export %s after %d retries: %s", object.Export, object.RetryCount, object.LastError), clients.CorrelationHeader, object.CorrelationID)
+			if err := tracker.DeadLetterStored(object, "retries exhausted"); err != nil {
+				edgexcontext.LoggingClient.Error(fmt.Sprintf("Failed to store dead letter of export %s: %s", object.Export, err.Error()), clients.CorrelationHeader, object.CorrelationID)
+			}
+			continue
+		}
+		if err := tracker.Store.Update(object); err != nil && err != store.ErrNotFound {
+			return exported, err
+		}
+	}
+	return exported, nil
+}
+
+// StoredSummary is the depth of the store and forward queue
+type StoredSummary struct {
+	// Depth is the number of failed exports stored
+	Depth int `json:"depth"`
+	// Size is the total size in bytes of their data
+	Size int64 `json:"size"`
+	// Oldest is when the oldest was stored, and is nil when none are
+	Oldest *time.Time `json:"oldest,omitempty"`
+	// Exports is the number stored for each export function
+	Exports map[string]int `json:"exports"`
+}
+
+// StoredItem describes the data of a failed export stored for store and forward, without the data itself
+type StoredItem struct {
+	ID            string    `json:"id"`
+	Export        string    `json:"export"`
+	CorrelationID string    `json:"correlationId"`
+	EventID       string    `json:"eventId,omitempty"`
+	ContentType   string    `json:"contentType,omitempty"`
+	Size          int       `json:"size"`
+	RetryCount    int       `json:"retryCount"`
+	LastError     string    `json:"lastError,omitempty"`
+	Created       time.Time `json:"created"`
+}
+
+// StoredSummary returns the depth of the store and forward queue
+func (tracker *ExportTracker) StoredSummary() (StoredSummary, error) {
+	summary := StoredSummary{Exports: make(map[string]int)}
+	items, err := tracker.StoredItems("")
+	if err != nil {
+		return summary, err
+	}
+	for index, item := range items {
+		if index == 0 {
+			oldest := item.Created
+			summary.Oldest = &oldest
+		}
+		summary.Depth++
+		summary.Size += int64(item.Size)
+		summary.Exports[item.Export]++
+	}
+	return summary, nil
+}
+
+// StoredItems returns the failed exports stored for the export function with the name, or for all of them when the
+// name is empty, oldest first
+func (tracker *ExportTracker) StoredItems(export string) ([]StoredItem, error) {
+	if tracker.Store == nil {
+		return nil, errors.New("store and forward is not enabled")
+	}
+	objects, err := tracker.Store.RetrieveFromStore()
+	if err != nil {
+		return nil, err
+	}
+
+	items := []StoredItem{}
+	for _, object := range objects {
+		if export != "" && object.Export != export {
+			continue
+		}
+		items = append(items, StoredItem{
+			ID:            object.ID,
+			Export:        object.Export,
+			CorrelationID: object.CorrelationID,
+			EventID:       object.EventID,
+			ContentType:   object.ContentType,
+			Size:          len(object.Payload),
+			RetryCount:    object.RetryCount,
+			LastError:     object.LastError,
+			Created:       object.Created,
+		})
+	}
+	return items, nil
+}
+
+// PurgeStored removes the failed exports stored for the export function with the name, or for all of them when the
+// name is empty, without exporting them or storing them in the dead letters. It returns the number removed, and waits
+// for a retry in progress to finish.
+func (tracker *ExportTracker) PurgeStored(export string) (int, error) {
+	if tracker.Store == nil {
+		return 0, errors.New("store and forward is not enabled")
+	}
+	tracker.retrying.Lock()
+	defer tracker.retrying.Unlock()
+
+	objects, err := tracker.Store.RetrieveFromStore()
+	if err != nil {
+		return 0, err
+	}
+
+	purged := 0
+	for _, object := range objects {
+		if export != "" && object.Export != export {
+			continue
+		}
+		if err := tracker.Store.RemoveFromStore(object.ID); err != nil {
+			if err == store.ErrNotFound {
+				continue
+			}
+			return purged, err
+		}
+		purged++
+	}
+	return purged, nil
+}
+
+// DeadLetterStored stores the object given up on by store and forward, for the reason, with every dead letter, i.e.
+// when it's evicted from the store
+func (tracker *ExportTracker) DeadLetterStored(object store.StoredObject, reason string) error {
+	return tracker.deadLetter(DeadLetterMessage{
+		CorrelationID: object.CorrelationID,
+		Export:        object.Export,
+		ContentType:   object.ContentType,
+		Payload:       object.Payload,
+		Failures:      object.RetryCount + 1,
+		LastError:     fmt.Sprintf("%s (%s)", object.LastError, reason),
+		Time:          time.Now(),
+	})
+}
+
+// tracked returns the tracked export function with the name
+func (tracker *ExportTracker) tracked(name string) *trackedExport {
+	tracker.mutex.Lock()
+	defer tracker.mutex.Unlock()
+
+	for _, tracked := range tracker.exports {
+		if tracked.metrics.Name == name {
+			return tracked
+		}
+	}
+	return nil
+}
@@ -0,0 +1,166 @@
+//
+// Copyright (c) 2019 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package runtime
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/antoniomtz/app-functions-sdk-go/appcontext"
+	"github.com/antoniomtz/app-functions-sdk-go/internal/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExportTrackerStoreForward(t *testing.T) {
+	dir, err := ioutil.TempDir("", "storeforward")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	storeClient, err := store.NewBoltStore(filepath.Join(dir, "store.db"), store.Config{})
+	require.NoError(t, err)
+	defer storeClient.Disconnect()
+
+	var deadLetters []DeadLetterMessage
+	tracker := &ExportTracker{
+		Store:         storeClient,
+		MaxRetryCount: 2,
+		NewContext: func(correlationID string) *appcontext.Context {
+			return &appcontext.Context{LoggingClient: lc, CorrelationID: correlationID}
+		},
+		DeadLetters: []DeadLetter{func(message DeadLetterMessage) error {
+			deadLetters = append(deadLetters, message)
+			return nil
+		}},
+	}
+
+	reachable := false
+	var exported []string
+	var exportedEvents []string
+	export := tracker.Track("HTTPPost", func(edgexcontext *appcontext.Context, params ...interface{}) (bool, interface{}) {
+		if !reachable {
+			return false, errors.New("connection refused")
+		}
+		exported = append(exported, string(params[0].([]byte)))
+		exportedEvents = append(exportedEvents, edgexcontext.EventID)
+		return true, nil
+	}, nil)
+	broken := tracker.Track("MQTTSend", func(edgexcontext *appcontext.Context, params ...interface{}) (bool, interface{}) {
+		return false, errors.New("not authorized")
+	}, nil)
+
+	export(&appcontext.Context{LoggingClient: lc, CorrelationID: "id1", EventID: "event1"}, []byte("one"))
+	broken(&appcontext.Context{LoggingClient: lc, CorrelationID: "id2"}, "two")
+	assert.Empty(t, deadLetters, "failed exports should be stored instead of dead lettered")
+	objects, err := storeClient.RetrieveFromStore()
+	require.NoError(t, err)
+	require.Len(t, objects, 2)
+	assert.Equal(t, "HTTPPost", objects[0].Export)
+	assert.Equal(t, "event1", objects[0].EventID)
+	assert.Equal(t, "connection refused", objects[0].LastError)
+
+	count, err := tracker.RetryStored()
+	require.NoError(t, err)
+	assert.Equal(t, 0, count)
+	objects, err = storeClient.RetrieveFromStore()
+	require.NoError(t, err)
+	require.Len(t, objects, 2, "failed retries should not be stored again")
+	assert.Equal(t, 1, objects[0].RetryCount)
+
+	reachable = true
+	count, err = tracker.RetryStored()
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+	assert.Equal(t, []string{"one"}, exported)
+	assert.Equal(t, []string{"event1"}, exportedEvents, "the event should be exported again with its ID")
+
+	objects, err = storeClient.RetrieveFromStore()
+	require.NoError(t, err)
+	assert.Empty(t, objects, "exported data and data given up on should be removed")
+	require.Len(t, deadLetters, 1, "data should be dead lettered once the retries are exhausted")
+	assert.Equal(t, "MQTTSend", deadLetters[0].Export)
+	assert.Equal(t, "two", string(deadLetters[0].Payload))
+	assert.Equal(t, 3, deadLetters[0].Failures)
+
+	metrics := tracker.Metrics()
+	assert.Equal(t, uint64(1), metrics[0].Succeeded)
+	assert.Equal(t, uint64(2), metrics[0].Failed, "retries should be recorded")
+	assert.Equal(t, uint64(1), metrics[1].DeadLettered)
+}
+
+func TestExportTrackerStoredQueue(t *testing.T) {
+	dir, err := ioutil.TempDir("", "storeforward")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	storeClient, err := store.NewBoltStore(filepath.Join(dir, "store.db"), store.Config{})
+	require.NoError(t, err)
+	defer storeClient.Disconnect()
+	tracker := &ExportTracker{Store: storeClient}
+
+	summary, err := tracker.StoredSummary()
+	require.NoError(t, err)
+	assert.Equal(t, 0, summary.Depth)
+	assert.Nil(t, summary.Oldest)
+
+	created := time.Now().Add(-time.Hour)
+	for _, object := range []store.StoredObject{
+		{Export: "HTTPPost", CorrelationID: "id1", Payload: []byte("one"), RetryCount: 2, Created: created},
+		{Export: "MQTTSend", CorrelationID: "id2", Payload: []byte("two"), Created: time.Now()},
+		{Export: "HTTPPost", CorrelationID: "id3", Payload: []byte("three"), Created: time.Now()},
+	} {
+		_, err := storeClient.Store(object)
+		require.NoError(t, err)
+	}
+
+	summary, err = tracker.StoredSummary()
+	require.NoError(t, err)
+	assert.Equal(t, 3, summary.Depth)
+	assert.Equal(t, int64(11), summary.Size)
+	require.NotNil(t, summary.Oldest)
+	assert.True(t, created.Equal(*summary.Oldest))
+	assert.Equal(t, map[string]int{"HTTPPost": 2, "MQTTSend": 1}, summary.Exports)
+
+	items, err := tracker.StoredItems("HTTPPost")
+	require.NoError(t, err)
+	require.Len(t, items, 2)
+	assert.Equal(t, "id1", items[0].CorrelationID)
+	assert.Equal(t, 3, items[0].Size)
+	assert.Equal(t, 2, items[0].RetryCount)
+
+	purged, err := tracker.PurgeStored("MQTTSend")
+	require.NoError(t, err)
+	assert.Equal(t, 1, purged)
+	purged, err = tracker.PurgeStored("")
+	require.NoError(t, err)
+	assert.Equal(t, 2, purged)
+	items, err = tracker.StoredItems("")
+	require.NoError(t, err)
+	assert.Empty(t, items)
+}
+
+func TestExportTrackerRetryStoredDisabled(t *testing.T) {
+	tracker := &ExportTracker{}
+	_, err := tracker.RetryStored()
+	assert.Error(t, err)
+	_, err = tracker.StoredSummary()
+	assert.Error(t, err)
+	_, err = tracker.PurgeStored("")
+	assert.Error(t, err)
+}
@@ -0,0 +1,271 @@
+//
+// Copyright (c) 2019 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package store
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+var (
+	objectsBucket = []byte("objects")
+	metaBucket    = []byte("meta")
+	countKey      = []byte("count")
+	sizeKey       = []byte("size")
+)
+
+// BoltStore stores the objects in a BoltDB file, so they survive restarts of a single node without a database
+// server. The objects are keyed by a sequence number, so they're kept oldest first.
+type BoltStore struct {
+	db     *bolt.DB
+	config Config
+}
+
+// NewBoltStore opens or creates the BoltDB file at the path. The file is locked while the store is open.
+func NewBoltStore(path string, config Config) (*BoltStore, error) {
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, err
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		if _, err := tx.CreateBucketIfNotExists(objectsBucket); err != nil {
+			return err
+		}
+		_, err := tx.CreateBucketIfNotExists(metaBucket)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &BoltStore{db: db, config: config}, nil
+}
+
+// Store adds the object and returns its ID, evicting the oldest objects when a limit would be exceeded
+func (store *BoltStore) Store(object StoredObject) (string, error) {
+	var expired, full []StoredObject
+	err := store.db.Update(func(tx *bolt.Tx) error {
+		objects := tx.Bucket(objectsBucket)
+		sequence, err := objects.NextSequence()
+		if err != nil {
+			return err
+		}
+		object.ID = strconv.FormatUint(sequence, 10)
+		value, err := json.Marshal(object)
+		if err != nil {
+			return err
+		}
+
+		if store.config.MaxSize > 0 && int64(len(value)) > store.config.MaxSize {
+			return fmt.Errorf("object of %d bytes exceeds the maximum size of the store of %d bytes", len(value), store.config.MaxSize)
+		}
+
+		if expired, err = store.evictExpired(tx); err != nil {
+			return err
+		}
+		if full, err = store.evictOldest(tx, int64(len(value))); err != nil {
+			return err
+		}
+		if err := objects.Put(boltKey(sequence), value); err != nil {
+			return err
+		}
+		return addUsage(tx, 1, int64(len(value)))
+	})
+	if err != nil {
+		return "", err
+	}
+	store.config.evicted(expired, EvictedExpired)
+	store.config.evicted(full, EvictedFull)
+	return object.ID, nil
+}
+
+// RetrieveFromStore returns the stored objects, oldest first, after evicting the expired ones
+func (store *BoltStore) RetrieveFromStore() ([]StoredObject, error) {
+	var objects, expired []StoredObject
+	err := store.db.Update(func(tx *bolt.Tx) error {
+		var err error
+		if expired, err = store.evictExpired(tx); err != nil {
+			return err
+		}
+		return tx.Bucket(objectsBucket).ForEach(func(key []byte, value []byte) error {
+			var object StoredObject
+			if err := json.Unmarshal(value, &object); err != nil {
+				return err
+			}
+			objects = append(objects, object)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	store.config.evicted(expired, EvictedExpired)
+	return objects, nil
+}
+
+// Update replaces the stored object with the same ID, returning ErrNotFound when it was removed
+func (store *BoltStore) Update(object StoredObject) error {
+	key, err := parseBoltKey(object.ID)
+	if err != nil {
+		return err
+	}
+	value, err := json.Marshal(object)
+	if err != nil {
+		return err
+	}
+	return store.db.Update(func(tx *bolt.Tx) error {
+		objects := tx.Bucket(objectsBucket)
+		previous := objects.Get(key)
+		if previous == nil {
+			return ErrNotFound
+		}
+		if err := addUsage(tx, 0, int64(len(value)-len(previous))); err != nil {
+			return err
+		}
+		return objects.Put(key, value)
+	})
+}
+
+// RemoveFromStore removes the object with the ID, returning ErrNotFound when there's none
+func (store *BoltStore) RemoveFromStore(id string) error {
+	key, err := parseBoltKey(id)
+	if err != nil {
+		return err
+	}
+	return store.db.Update(func(tx *bolt.Tx) error {
+		objects := tx.Bucket(objectsBucket)
+		previous := objects.Get(key)
+		if previous == nil {
+			return ErrNotFound
+		}
+		if err := addUsage(tx, -1, -int64(len(previous))); err != nil {
+			return err
+		}
+		return objects.Delete(key)
+	})
+}
+
+// Disconnect closes the BoltDB file
+func (store *BoltStore) Disconnect() error {
+	return store.db.Close()
+}
+
+// evictExpired removes the objects held longer than the TTL, which are the oldest ones, and returns them
+func (store *BoltStore) evictExpired(tx *bolt.Tx) ([]StoredObject, error) {
+	if store.config.TTL <= 0 {
+		return nil, nil
+	}
+	now := time.Now()
+	var expired []StoredObject
+	cursor := tx.Bucket(objectsBucket).Cursor()
+	for key, value := cursor.First(); key != nil; key, value = cursor.First() {
+		var object StoredObject
+		if err := json.Unmarshal(value, &object); err != nil {
+			return nil, err
+		}
+		if !store.config.expired(object, now) {
+			break
+		}
+		if err := addUsage(tx, -1, -int64(len(value))); err != nil {
+			return nil, err
+		}
+		if err := cursor.Delete(); err != nil {
+			return nil, err
+		}
+		expired = append(expired, object)
+	}
+	return expired, nil
+}
+
+// evictOldest removes the oldest objects until an object of the size can be added within the limits, and returns
+// them
+func (store *BoltStore) evictOldest(tx *bolt.Tx, size int64) ([]StoredObject, error) {
+	var evicted []StoredObject
+	cursor := tx.Bucket(objectsBucket).Cursor()
+	for key, value := cursor.First(); key != nil; key, value = cursor.First() {
+		count, total := usage(tx)
+		countExceeded := store.config.MaxObjects > 0 && count+1 > int64(store.config.MaxObjects)
+		sizeExceeded := store.config.MaxSize > 0 && total+size > store.config.MaxSize
+		if !countExceeded && !sizeExceeded {
+			break
+		}
+		var object StoredObject
+		if err := json.Unmarshal(value, &object); err != nil {
+			return nil, err
+		}
+		if err := addUsage(tx, -1, -int64(len(value))); err != nil {
+			return nil, err
+		}
+		if err := cursor.Delete(); err != nil {
+			return nil, err
+		}
+		evicted = append(evicted, object)
+	}
+	return evicted, nil
+}
+
+// usage returns the number and the total size of the stored objects
+func usage(tx *bolt.Tx) (int64, int64) {
+	meta := tx.Bucket(metaBucket)
+	return metaValue(meta.Get(countKey)), metaValue(meta.Get(sizeKey))
+}
+
+// addUsage adds the deltas to the number and the total size of the stored objects
+func addUsage(tx *bolt.Tx, countDelta int64, sizeDelta int64) error {
+	count, size := usage(tx)
+	meta := tx.Bucket(metaBucket)
+	if err := meta.Put(countKey, metaBytes(count+countDelta)); err != nil {
+		return err
+	}
+	return meta.Put(sizeKey, metaBytes(size+sizeDelta))
+}
+
+func metaValue(value []byte) int64 {
+	if len(value) != 8 {
+		return 0
+	}
+	return int64(binary.BigEndian.Uint64(value))
+}
+
+func metaBytes(value int64) []byte {
+	if value < 0 {
+		value = 0
+	}
+	encoded := make([]byte, 8)
+	binary.BigEndian.PutUint64(encoded, uint64(value))
+	return encoded
+}
+
+// boltKey returns the key of the sequence number, which sorts in the order the objects were stored
+func boltKey(sequence uint64) []byte {
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, sequence)
+	return key
+}
+
+func parseBoltKey(id string) ([]byte, error) {
+	sequence, err := strconv.ParseUint(id, 10, 64)
+	if err != nil {
+		return nil, ErrNotFound
+	}
+	return boltKey(sequence), nil
+}
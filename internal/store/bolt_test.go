@@ -0,0 +1,153 @@
+//
+// Copyright (c) 2019 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package store
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestBoltStore(t *testing.T, config Config) (*BoltStore, string) {
+	dir, err := ioutil.TempDir("", "store")
+	require.NoError(t, err)
+	path := filepath.Join(dir, "store.db")
+	store, err := NewBoltStore(path, config)
+	require.NoError(t, err)
+	return store, dir
+}
+
+func TestBoltStore(t *testing.T) {
+	store, dir := newTestBoltStore(t, Config{})
+	defer os.RemoveAll(dir)
+
+	first, err := store.Store(StoredObject{Export: "HTTPPost", CorrelationID: "id1", Payload: []byte("one"), Created: time.Now()})
+	require.NoError(t, err)
+	second, err := store.Store(StoredObject{Export: "MQTTSend", CorrelationID: "id2", Payload: []byte("two"), Created: time.Now()})
+	require.NoError(t, err)
+	assert.NotEqual(t, first, second)
+
+	objects, err := store.RetrieveFromStore()
+	require.NoError(t, err)
+	require.Len(t, objects, 2)
+	assert.Equal(t, first, objects[0].ID, "objects should be retrieved oldest first")
+	assert.Equal(t, "HTTPPost", objects[0].Export)
+	assert.Equal(t, []byte("one"), objects[0].Payload)
+
+	objects[1].RetryCount = 1
+	objects[1].LastError = "connection refused"
+	require.NoError(t, store.Update(objects[1]))
+	require.NoError(t, store.RemoveFromStore(first))
+	assert.Equal(t, ErrNotFound, store.RemoveFromStore(first))
+	assert.Equal(t, ErrNotFound, store.Update(objects[0]))
+	assert.Equal(t, ErrNotFound, store.RemoveFromStore("unknown"))
+
+	// the objects should survive a restart
+	require.NoError(t, store.Disconnect())
+	store, err = NewBoltStore(filepath.Join(dir, "store.db"), Config{})
+	require.NoError(t, err)
+	defer store.Disconnect()
+	objects, err = store.RetrieveFromStore()
+	require.NoError(t, err)
+	require.Len(t, objects, 1)
+	assert.Equal(t, second, objects[0].ID)
+	assert.Equal(t, 1, objects[0].RetryCount)
+	assert.Equal(t, "connection refused", objects[0].LastError)
+
+	third, err := store.Store(StoredObject{Export: "HTTPPost", Created: time.Now()})
+	require.NoError(t, err)
+	assert.NotEqual(t, first, third, "IDs should not be reused")
+}
+
+func TestBoltStoreLimits(t *testing.T) {
+	var evicted []string
+	var reasons []string
+	config := Config{MaxObjects: 2, Evicted: func(object StoredObject, reason string) {
+		evicted = append(evicted, object.CorrelationID)
+		reasons = append(reasons, reason)
+	}}
+	store, dir := newTestBoltStore(t, config)
+	defer os.RemoveAll(dir)
+	defer store.Disconnect()
+
+	for _, id := range []string{"id1", "id2", "id3"} {
+		_, err := store.Store(StoredObject{CorrelationID: id, Created: time.Now()})
+		require.NoError(t, err)
+	}
+	assert.Equal(t, []string{"id1"}, evicted, "the oldest object should be evicted")
+	assert.Equal(t, []string{EvictedFull}, reasons)
+	objects, err := store.RetrieveFromStore()
+	require.NoError(t, err)
+	assert.Len(t, objects, 2)
+
+	// removed objects make room again
+	require.NoError(t, store.RemoveFromStore(objects[0].ID))
+	_, err = store.Store(StoredObject{CorrelationID: "id4", Created: time.Now()})
+	require.NoError(t, err)
+	assert.Len(t, evicted, 1)
+}
+
+func TestBoltStoreMaxSize(t *testing.T) {
+	var evicted []string
+	store, dir := newTestBoltStore(t, Config{MaxSize: 400, Evicted: func(object StoredObject, reason string) {
+		evicted = append(evicted, object.CorrelationID)
+	}})
+	defer os.RemoveAll(dir)
+	defer store.Disconnect()
+
+	payload := make([]byte, 100)
+	for _, id := range []string{"id1", "id2", "id3"} {
+		_, err := store.Store(StoredObject{CorrelationID: id, Payload: payload, Created: time.Now()})
+		require.NoError(t, err)
+	}
+	assert.Equal(t, []string{"id1", "id2"}, evicted, "the oldest objects should be evicted to stay within the size")
+
+	_, err := store.Store(StoredObject{CorrelationID: "big", Payload: make([]byte, 400), Created: time.Now()})
+	assert.Error(t, err, "an object larger than the store should be rejected")
+	objects, err := store.RetrieveFromStore()
+	require.NoError(t, err)
+	require.Len(t, objects, 1)
+	assert.Equal(t, "id3", objects[0].CorrelationID)
+}
+
+func TestBoltStoreTTL(t *testing.T) {
+	var evicted []string
+	var reasons []string
+	store, dir := newTestBoltStore(t, Config{TTL: time.Hour, Evicted: func(object StoredObject, reason string) {
+		evicted = append(evicted, object.CorrelationID)
+		reasons = append(reasons, reason)
+	}})
+	defer os.RemoveAll(dir)
+	defer store.Disconnect()
+
+	_, err := store.Store(StoredObject{CorrelationID: "old", Created: time.Now().Add(-2 * time.Hour)})
+	require.NoError(t, err)
+	_, err = store.Store(StoredObject{CorrelationID: "new", Created: time.Now()})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"old"}, evicted, "expired objects should be evicted when storing")
+	assert.Equal(t, []string{EvictedExpired}, reasons)
+
+	objects, err := store.RetrieveFromStore()
+	require.NoError(t, err)
+	require.Len(t, objects, 1)
+	assert.Equal(t, "new", objects[0].CorrelationID)
+}
@@ -0,0 +1,337 @@
+//
+// Copyright (c) 2019 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package store
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+)
+
+// DefaultRedisKey is the prefix of the Redis keys the objects are stored under when none is configured
+const DefaultRedisKey = "store-and-forward"
+
+// RedisConfig contains the parameters for connecting to Redis
+type RedisConfig struct {
+	// Address of the Redis server, i.e. redis:6379
+	Address  string
+	Password string
+	Database int
+	UseTLS   bool
+	// Key is the prefix of the keys the objects are stored under, so services sharing a Redis server each use their
+	// own. Defaults to DefaultRedisKey.
+	Key string
+}
+
+// RedisStore stores the objects in Redis, for deployments already running a Redis server. The objects are kept in a
+// hash by ID, and their IDs in a sorted set scored by a sequence number, so they're kept oldest first. The keys are
+// expected to be used by a single service at a time.
+type RedisStore struct {
+	pool   *redis.Pool
+	config Config
+	// mutex serializes the changes, so the limits are applied to a consistent count and size
+	mutex sync.Mutex
+
+	objectsKey  string
+	idsKey      string
+	sequenceKey string
+	sizeKey     string
+}
+
+// NewRedisStore connects to the Redis server, returning an error when it can't be reached. Connections are pooled.
+func NewRedisStore(redisConfig RedisConfig, config Config) (*RedisStore, error) {
+	if redisConfig.Address == "" {
+		return nil, errors.New("Redis address must be specified")
+	}
+	key := redisConfig.Key
+	if key == "" {
+		key = DefaultRedisKey
+	}
+
+	pool := &redis.Pool{
+		MaxIdle:     2,
+		IdleTimeout: 5 * time.Minute,
+		Dial: func() (redis.Conn, error) {
+			return redis.Dial("tcp", redisConfig.Address,
+				redis.DialPassword(redisConfig.Password),
+				redis.DialDatabase(redisConfig.Database),
+				redis.DialUseTLS(redisConfig.UseTLS),
+				redis.DialConnectTimeout(10*time.Second))
+		},
+		TestOnBorrow: func(conn redis.Conn, idleSince time.Time) error {
+			if time.Since(idleSince) < time.Minute {
+				return nil
+			}
+			_, err := conn.Do("PING")
+			return err
+		},
+	}
+
+	conn := pool.Get()
+	_, err := conn.Do("PING")
+	conn.Close()
+	if err != nil {
+		pool.Close()
+		return nil, fmt.Errorf("unable to connect to Redis at %s: %v", redisConfig.Address, err)
+	}
+
+	return &RedisStore{
+		pool:        pool,
+		config:      config,
+		objectsKey:  key + ":objects",
+		idsKey:      key + ":ids",
+		sequenceKey: key + ":sequence",
+		sizeKey:     key + ":size",
+	}, nil
+}
+
+// Store adds the object and returns its ID, evicting the oldest objects when a limit would be exceeded
+func (store *RedisStore) Store(object StoredObject) (string, error) {
+	store.mutex.Lock()
+	conn := store.pool.Get()
+	expired, full, id, err := store.store(conn, object)
+	conn.Close()
+	store.mutex.Unlock()
+
+	store.config.evicted(expired, EvictedExpired)
+	store.config.evicted(full, EvictedFull)
+	if err != nil {
+		return "", err
+	}
+	return id, nil
+}
+
+func (store *RedisStore) store(conn redis.Conn, object StoredObject) ([]StoredObject, []StoredObject, string, error) {
+	sequence, err := redis.Int64(conn.Do("INCR", store.sequenceKey))
+	if err != nil {
+		return nil, nil, "", err
+	}
+	object.ID = strconv.FormatInt(sequence, 10)
+	value, err := json.Marshal(object)
+	if err != nil {
+		return nil, nil, "", err
+	}
+
+	if store.config.MaxSize > 0 && int64(len(value)) > store.config.MaxSize {
+		return nil, nil, "", fmt.Errorf("object of %d bytes exceeds the maximum size of the store of %d bytes", len(value), store.config.MaxSize)
+	}
+
+	expired, err := store.evictExpired(conn)
+	if err != nil {
+		return expired, nil, "", err
+	}
+	full, err := store.evictOldest(conn, int64(len(value)))
+	if err != nil {
+		return expired, full, "", err
+	}
+
+	conn.Send("MULTI")
+	conn.Send("HSET", store.objectsKey, object.ID, value)
+	conn.Send("ZADD", store.idsKey, sequence, object.ID)
+	conn.Send("INCRBY", store.sizeKey, len(value))
+	if _, err := conn.Do("EXEC"); err != nil {
+		return expired, full, "", err
+	}
+	return expired, full, object.ID, nil
+}
+
+// RetrieveFromStore returns the stored objects, oldest first, after evicting the expired ones
+func (store *RedisStore) RetrieveFromStore() ([]StoredObject, error) {
+	store.mutex.Lock()
+	conn := store.pool.Get()
+	expired, err := store.evictExpired(conn)
+	var objects []StoredObject
+	if err == nil {
+		objects, err = store.retrieve(conn)
+	}
+	conn.Close()
+	store.mutex.Unlock()
+
+	store.config.evicted(expired, EvictedExpired)
+	if err != nil {
+		return nil, err
+	}
+	return objects, nil
+}
+
+func (store *RedisStore) retrieve(conn redis.Conn) ([]StoredObject, error) {
+	ids, err := redis.Strings(conn.Do("ZRANGE", store.idsKey, 0, -1))
+	if err != nil || len(ids) == 0 {
+		return nil, err
+	}
+	values, err := redis.ByteSlices(conn.Do("HMGET", redis.Args{}.Add(store.objectsKey).AddFlat(ids)...))
+	if err != nil {
+		return nil, err
+	}
+
+	objects := make([]StoredObject, 0, len(values))
+	for _, value := range values {
+		if value == nil {
+			continue
+		}
+		var object StoredObject
+		if err := json.Unmarshal(value, &object); err != nil {
+			return nil, err
+		}
+		objects = append(objects, object)
+	}
+	return objects, nil
+}
+
+// Update replaces the stored object with the same ID, returning ErrNotFound when it was removed
+func (store *RedisStore) Update(object StoredObject) error {
+	value, err := json.Marshal(object)
+	if err != nil {
+		return err
+	}
+
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	conn := store.pool.Get()
+	defer conn.Close()
+
+	previous, err := store.get(conn, object.ID)
+	if err != nil {
+		return err
+	}
+	conn.Send("MULTI")
+	conn.Send("HSET", store.objectsKey, object.ID, value)
+	conn.Send("INCRBY", store.sizeKey, len(value)-len(previous))
+	_, err = conn.Do("EXEC")
+	return err
+}
+
+// RemoveFromStore removes the object with the ID, returning ErrNotFound when there's none
+func (store *RedisStore) RemoveFromStore(id string) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	conn := store.pool.Get()
+	defer conn.Close()
+
+	previous, err := store.get(conn, id)
+	if err != nil {
+		return err
+	}
+	return store.remove(conn, id, previous)
+}
+
+// Disconnect closes the pooled connections to Redis
+func (store *RedisStore) Disconnect() error {
+	return store.pool.Close()
+}
+
+// get returns the stored value of the object with the ID, or ErrNotFound when there's none
+func (store *RedisStore) get(conn redis.Conn, id string) ([]byte, error) {
+	value, err := redis.Bytes(conn.Do("HGET", store.objectsKey, id))
+	if err == redis.ErrNil {
+		return nil, ErrNotFound
+	}
+	return value, err
+}
+
+// remove deletes the object with the ID, and its stored value from the total size
+func (store *RedisStore) remove(conn redis.Conn, id string, value []byte) error {
+	conn.Send("MULTI")
+	conn.Send("HDEL", store.objectsKey, id)
+	conn.Send("ZREM", store.idsKey, id)
+	conn.Send("INCRBY", store.sizeKey, -len(value))
+	_, err := conn.Do("EXEC")
+	return err
+}
+
+// oldest returns the oldest stored object and its stored value, or a nil value when there are none
+func (store *RedisStore) oldest(conn redis.Conn) (StoredObject, []byte, error) {
+	var object StoredObject
+	for {
+		ids, err := redis.Strings(conn.Do("ZRANGE", store.idsKey, 0, 0))
+		if err != nil || len(ids) == 0 {
+			return object, nil, err
+		}
+		value, err := store.get(conn, ids[0])
+		if err == ErrNotFound {
+			// The ID outlived its object, i.e. after the hash was deleted by hand
+			if _, err := conn.Do("ZREM", store.idsKey, ids[0]); err != nil {
+				return object, nil, err
+			}
+			continue
+		}
+		if err != nil {
+			return object, nil, err
+		}
+		if err := json.Unmarshal(value, &object); err != nil {
+			return object, nil, err
+		}
+		return object, value, nil
+	}
+}
+
+// evictExpired removes the objects held longer than the TTL, which are the oldest ones, and returns them
+func (store *RedisStore) evictExpired(conn redis.Conn) ([]StoredObject, error) {
+	if store.config.TTL <= 0 {
+		return nil, nil
+	}
+	now := time.Now()
+	var expired []StoredObject
+	for {
+		object, value, err := store.oldest(conn)
+		if err != nil {
+			return expired, err
+		}
+		if value == nil || !store.config.expired(object, now) {
+			return expired, nil
+		}
+		if err := store.remove(conn, object.ID, value); err != nil {
+			return expired, err
+		}
+		expired = append(expired, object)
+	}
+}
+
+// evictOldest removes the oldest objects until an object of the size can be added within the limits, and returns
+// them
+func (store *RedisStore) evictOldest(conn redis.Conn, size int64) ([]StoredObject, error) {
+	var evicted []StoredObject
+	for {
+		count, err := redis.Int64(conn.Do("HLEN", store.objectsKey))
+		if err != nil {
+			return evicted, err
+		}
+		total, err := redis.Int64(conn.Do("GET", store.sizeKey))
+		if err != nil && err != redis.ErrNil {
+			return evicted, err
+		}
+		countExceeded := store.config.MaxObjects > 0 && count+1 > int64(store.config.MaxObjects)
+		sizeExceeded := store.config.MaxSize > 0 && total+size > store.config.MaxSize
+		if !countExceeded && !sizeExceeded {
+			return evicted, nil
+		}
+
+		object, value, err := store.oldest(conn)
+		if err != nil || value == nil {
+			return evicted, err
+		}
+		if err := store.remove(conn, object.ID, value); err != nil {
+			return evicted, err
+		}
+		evicted = append(evicted, object)
+	}
+}
@@ -0,0 +1,114 @@
+//
+// Copyright (c) 2019 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package store
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedisStore(t *testing.T) {
+	server, err := miniredis.Run()
+	require.NoError(t, err)
+	defer server.Close()
+
+	store, err := NewRedisStore(RedisConfig{Address: server.Addr()}, Config{})
+	require.NoError(t, err)
+
+	first, err := store.Store(StoredObject{Export: "HTTPPost", CorrelationID: "id1", Payload: []byte("one"), Created: time.Now()})
+	require.NoError(t, err)
+	second, err := store.Store(StoredObject{Export: "MQTTSend", CorrelationID: "id2", Payload: []byte("two"), Created: time.Now()})
+	require.NoError(t, err)
+	assert.NotEqual(t, first, second)
+	assert.True(t, server.Exists(DefaultRedisKey+":objects"))
+
+	objects, err := store.RetrieveFromStore()
+	require.NoError(t, err)
+	require.Len(t, objects, 2)
+	assert.Equal(t, first, objects[0].ID, "objects should be retrieved oldest first")
+	assert.Equal(t, "HTTPPost", objects[0].Export)
+	assert.Equal(t, []byte("one"), objects[0].Payload)
+
+	objects[1].RetryCount = 1
+	objects[1].LastError = "connection refused"
+	require.NoError(t, store.Update(objects[1]))
+	require.NoError(t, store.RemoveFromStore(first))
+	assert.Equal(t, ErrNotFound, store.RemoveFromStore(first))
+	assert.Equal(t, ErrNotFound, store.Update(objects[0]))
+	assert.Equal(t, ErrNotFound, store.RemoveFromStore("unknown"))
+
+	// the objects should survive a restart of the service
+	require.NoError(t, store.Disconnect())
+	store, err = NewRedisStore(RedisConfig{Address: server.Addr()}, Config{})
+	require.NoError(t, err)
+	defer store.Disconnect()
+	objects, err = store.RetrieveFromStore()
+	require.NoError(t, err)
+	require.Len(t, objects, 1)
+	assert.Equal(t, second, objects[0].ID)
+	assert.Equal(t, 1, objects[0].RetryCount)
+	assert.Equal(t, "connection refused", objects[0].LastError)
+
+	third, err := store.Store(StoredObject{Export: "HTTPPost", Created: time.Now()})
+	require.NoError(t, err)
+	assert.NotEqual(t, first, third, "IDs should not be reused")
+}
+
+func TestRedisStoreLimits(t *testing.T) {
+	server, err := miniredis.Run()
+	require.NoError(t, err)
+	defer server.Close()
+
+	var evicted []string
+	var reasons []string
+	config := Config{MaxObjects: 2, MaxSize: 1000, TTL: time.Hour, Evicted: func(object StoredObject, reason string) {
+		evicted = append(evicted, object.CorrelationID)
+		reasons = append(reasons, reason)
+	}}
+	store, err := NewRedisStore(RedisConfig{Address: server.Addr(), Key: "service"}, config)
+	require.NoError(t, err)
+	defer store.Disconnect()
+
+	_, err = store.Store(StoredObject{CorrelationID: "old", Created: time.Now().Add(-2 * time.Hour)})
+	require.NoError(t, err)
+	for _, id := range []string{"id1", "id2", "id3"} {
+		_, err := store.Store(StoredObject{CorrelationID: id, Created: time.Now()})
+		require.NoError(t, err)
+	}
+	assert.Equal(t, []string{"old", "id1"}, evicted, "expired and then the oldest objects should be evicted")
+	assert.Equal(t, []string{EvictedExpired, EvictedFull}, reasons)
+	assert.True(t, server.Exists("service:objects"), "the objects should be stored under the configured key")
+
+	_, err = store.Store(StoredObject{CorrelationID: "big", Payload: make([]byte, 1000), Created: time.Now()})
+	assert.Error(t, err, "an object larger than the store should be rejected")
+	objects, err := store.RetrieveFromStore()
+	require.NoError(t, err)
+	require.Len(t, objects, 2)
+	assert.Equal(t, "id2", objects[0].CorrelationID)
+	assert.Equal(t, "id3", objects[1].CorrelationID)
+}
+
+func TestNewRedisStoreUnreachable(t *testing.T) {
+	_, err := NewRedisStore(RedisConfig{Address: "127.0.0.1:1"}, Config{})
+	assert.Error(t, err)
+	_, err = NewRedisStore(RedisConfig{}, Config{})
+	assert.Error(t, err)
+}
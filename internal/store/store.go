@@ -0,0 +1,95 @@
+//
+// Copyright (c) 2019 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+// Package store persists the data of failed exports, so store and forward can export it again after the service
+// restarts
+package store
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrNotFound is returned when no stored object has the ID
+var ErrNotFound = errors.New("object not found in store")
+
+// Eviction reasons passed to Config.Evicted
+const (
+	// EvictedExpired is the reason objects held longer than Config.TTL are evicted
+	EvictedExpired = "expired"
+	// EvictedFull is the reason the oldest objects are evicted to make room within Config.MaxObjects and
+	// Config.MaxSize
+	EvictedFull = "store full"
+)
+
+// StoredObject is the data of a failed call to an export function, stored until it is exported again
+type StoredObject struct {
+	// ID identifies the object, and is set by Store
+	ID string `json:"id"`
+	// Export is the name of the export function which failed, under which it is tracked
+	Export        string `json:"export"`
+	CorrelationID string `json:"correlationId"`
+	EventID       string `json:"eventId,omitempty"`
+	EventChecksum string `json:"eventChecksum,omitempty"`
+	ContentType   string `json:"contentType,omitempty"`
+	Payload       []byte `json:"payload"`
+	// RetryCount is the number of times exporting the data was retried
+	RetryCount int       `json:"retryCount"`
+	LastError  string    `json:"lastError,omitempty"`
+	Created    time.Time `json:"created"`
+}
+
+// StoreClient persists the stored objects. Implementations are safe for concurrent use.
+type StoreClient interface {
+	// Store adds the object and returns its ID, evicting the oldest objects when a limit would be exceeded
+	Store(object StoredObject) (string, error)
+	// RetrieveFromStore returns the stored objects, oldest first, after evicting the expired ones
+	RetrieveFromStore() ([]StoredObject, error)
+	// Update replaces the stored object with the same ID, returning ErrNotFound when it was removed
+	Update(object StoredObject) error
+	// RemoveFromStore removes the object with the ID, returning ErrNotFound when there's none
+	RemoveFromStore(id string) error
+	// Disconnect closes the store
+	Disconnect() error
+}
+
+// Config bounds the objects held by a store
+type Config struct {
+	// MaxObjects is the number of objects held. Zero is unlimited.
+	MaxObjects int
+	// MaxSize is the total size in bytes of the objects held. Zero is unlimited.
+	MaxSize int64
+	// TTL is how long objects are held before they're evicted. Zero holds them until they're removed.
+	TTL time.Duration
+	// Evicted is called with each object evicted, and the reason, i.e. to store it in the dead letters. It may be
+	// nil.
+	Evicted func(object StoredObject, reason string)
+}
+
+// expired returns whether the object has been held longer than the TTL
+func (config Config) expired(object StoredObject, now time.Time) bool {
+	return config.TTL > 0 && now.Sub(object.Created) > config.TTL
+}
+
+// evicted calls Evicted with each evicted object
+func (config Config) evicted(objects []StoredObject, reason string) {
+	if config.Evicted == nil {
+		return
+	}
+	for _, object := range objects {
+		config.Evicted(object, reason)
+	}
+}
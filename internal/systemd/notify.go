@@ -0,0 +1,87 @@
+//
+// Copyright (c) 2019 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+// Package systemd implements the sd_notify protocol, so a service run by systemd with Type=notify can report when it
+// is ready and feed its watchdog without depending on libsystemd.
+package systemd
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"time"
+)
+
+const (
+	// Ready tells systemd the service has started
+	Ready = "READY=1"
+	// Stopping tells systemd the service is stopping
+	Stopping = "STOPPING=1"
+	// Watchdog feeds the watchdog of the service
+	Watchdog = "WATCHDOG=1"
+)
+
+// ExtendTimeout asks systemd to wait for up to timeout more for the service to start or stop, i.e. while events are
+// processed at shutdown
+func ExtendTimeout(timeout time.Duration) string {
+	return fmt.Sprintf("EXTEND_TIMEOUT_USEC=%d", timeout/time.Microsecond)
+}
+
+// Status describes the state of the service, shown by systemctl status
+func Status(status string) string {
+	return "STATUS=" + status
+}
+
+// Notify sends the state to the socket named by the NOTIFY_SOCKET environment variable. It returns false without an
+// error when the service wasn't started by systemd with Type=notify.
+func Notify(state string) (bool, error) {
+	socket := os.Getenv("NOTIFY_SOCKET")
+	if socket == "" {
+		return false, nil
+	}
+
+	// names starting with '@' are abstract sockets, which net handles
+	connection, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: socket, Net: "unixgram"})
+	if err != nil {
+		return false, err
+	}
+	defer connection.Close()
+
+	if _, err := connection.Write([]byte(state)); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// WatchdogInterval returns the interval within which the watchdog must be fed, as set by WatchdogSec in the unit of
+// the service. It returns 0 when the watchdog isn't enabled for this process.
+func WatchdogInterval() (time.Duration, error) {
+	usec := os.Getenv("WATCHDOG_USEC")
+	if usec == "" {
+		return 0, nil
+	}
+	interval, err := strconv.ParseInt(usec, 10, 64)
+	if err != nil || interval <= 0 {
+		return 0, fmt.Errorf("invalid WATCHDOG_USEC '%s'", usec)
+	}
+
+	// the watchdog is enabled for another process, i.e. the parent of the service
+	if pid := os.Getenv("WATCHDOG_PID"); pid != "" && pid != strconv.Itoa(os.Getpid()) {
+		return 0, nil
+	}
+	return time.Duration(interval) * time.Microsecond, nil
+}
@@ -0,0 +1,134 @@
+//
+// Copyright (c) 2019 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package tracing
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SpanKind is the OTLP kind of a span
+type SpanKind int
+
+const (
+	// KindInternal is an operation within the service, i.e. a pipeline function
+	KindInternal SpanKind = 1
+	// KindServer is the handling of a request, i.e. an event received by the HTTP trigger
+	KindServer SpanKind = 2
+	// KindClient is a call to a remote service, i.e. an export
+	KindClient SpanKind = 3
+	// KindConsumer is the processing of a message, i.e. an event received from the message bus
+	KindConsumer SpanKind = 5
+)
+
+// Span is a timed operation of a trace. A nil span, returned while tracing is disabled, ignores every call.
+type Span struct {
+	TraceID    string
+	SpanID     string
+	ParentID   string
+	Name       string
+	Kind       SpanKind
+	Start      time.Time
+	End        time.Time
+	Attributes map[string]string
+	// Error is the error the operation failed with, if any
+	Error  string
+	tracer *Tracer
+	mutex  sync.Mutex
+}
+
+// TraceParent returns the W3C trace context of the span, i.e. for the traceparent header of an outgoing request.
+// It is empty for a nil span.
+func (span *Span) TraceParent() string {
+	if span == nil {
+		return ""
+	}
+	return fmt.Sprintf("00-%s-%s-01", span.TraceID, span.SpanID)
+}
+
+// SetAttribute records the value of an attribute of the span. Empty values aren't recorded.
+func (span *Span) SetAttribute(key string, value string) {
+	if span == nil || value == "" {
+		return
+	}
+	span.mutex.Lock()
+	defer span.mutex.Unlock()
+	if span.Attributes == nil {
+		span.Attributes = map[string]string{}
+	}
+	span.Attributes[key] = value
+}
+
+// Finish ends the span, recording the error the operation failed with, which may be nil, and queues it for export
+func (span *Span) Finish(err error) {
+	if span == nil {
+		return
+	}
+	span.mutex.Lock()
+	span.End = time.Now()
+	if err != nil {
+		span.Error = err.Error()
+	}
+	span.mutex.Unlock()
+	span.tracer.queue(span)
+}
+
+// ParseTraceParent returns the trace ID and parent span ID of a W3C traceparent, i.e.
+// "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", and whether it is valid
+func ParseTraceParent(traceParent string) (traceID string, spanID string, ok bool) {
+	parts := strings.Split(strings.TrimSpace(traceParent), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || parts[0] == "ff" {
+		return "", "", false
+	}
+	traceID, spanID = strings.ToLower(parts[1]), strings.ToLower(parts[2])
+	if !isHex(traceID, 32) || !isHex(spanID, 16) || strings.Trim(traceID, "0") == "" || strings.Trim(spanID, "0") == "" {
+		return "", "", false
+	}
+	return traceID, spanID, true
+}
+
+// TraceIDFromCorrelationID derives the trace ID of an event from its correlation ID, so each service handling the
+// event reports its spans under the same trace. A UUID correlation ID is used as is, other correlation IDs are hashed.
+func TraceIDFromCorrelationID(correlationID string) string {
+	if id := strings.ToLower(strings.Replace(correlationID, "-", "", -1)); isHex(id, 32) && strings.Trim(id, "0") != "" {
+		return id
+	}
+	if correlationID == "" {
+		return randomID(16)
+	}
+	hash := sha256.Sum256([]byte(correlationID))
+	return hex.EncodeToString(hash[:16])
+}
+
+func isHex(value string, length int) bool {
+	if len(value) != length {
+		return false
+	}
+	_, err := hex.DecodeString(value)
+	return err == nil
+}
+
+func randomID(bytes int) string {
+	id := make([]byte, bytes)
+	_, _ = rand.Read(id)
+	return hex.EncodeToString(id)
+}
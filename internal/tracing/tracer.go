@@ -0,0 +1,268 @@
+//
+// Copyright (c) 2019 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package tracing
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/clients/logger"
+)
+
+const (
+	// DefaultBatchSize is the number of spans which are exported together when no batch size is configured
+	DefaultBatchSize = 512
+	// DefaultFlushInterval is how often queued spans are exported when no interval is configured
+	DefaultFlushInterval = 5 * time.Second
+	// maxQueuedSpans bounds the spans held while the collector is unreachable, the oldest spans are dropped
+	maxQueuedSpans = 8192
+	scopeName      = "github.com/antoniomtz/app-functions-sdk-go"
+)
+
+// Tracer creates spans and exports them in batches to an OpenTelemetry collector using OTLP over HTTP with JSON
+// encoding. A nil tracer creates nil spans, so tracing costs nothing while it is disabled.
+type Tracer struct {
+	// Endpoint is the base URL of the collector, i.e. http://localhost:4318. Spans are posted to its /v1/traces path.
+	Endpoint      string
+	ServiceName   string
+	BatchSize     int
+	LoggingClient logger.LoggingClient
+	client        *http.Client
+	mutex         sync.Mutex
+	queued        []*Span
+	flushing      sync.Mutex
+	stop          chan struct{}
+}
+
+// NewTracer creates a tracer exporting to the endpoint and starts exporting the queued spans at each interval
+func NewTracer(endpoint string, serviceName string, batchSize int, interval time.Duration, logging logger.LoggingClient) *Tracer {
+	if batchSize <= 0 {
+		batchSize = DefaultBatchSize
+	}
+	if interval <= 0 {
+		interval = DefaultFlushInterval
+	}
+
+	tracer := &Tracer{
+		Endpoint:      strings.TrimSuffix(endpoint, "/"),
+		ServiceName:   serviceName,
+		BatchSize:     batchSize,
+		LoggingClient: logging,
+		client:        &http.Client{Timeout: 10 * time.Second},
+		stop:          make(chan struct{}),
+	}
+	go tracer.run(interval)
+	return tracer
+}
+
+// Start starts a span of the trace identified by the W3C traceparent of its parent. When the parent isn't a valid
+// traceparent, the span starts a trace whose ID is derived from the correlation ID.
+func (tracer *Tracer) Start(name string, kind SpanKind, parent string, correlationID string) *Span {
+	if tracer == nil {
+		return nil
+	}
+
+	span := &Span{Name: name, Kind: kind, SpanID: randomID(8), Start: time.Now(), tracer: tracer}
+	if traceID, parentID, ok := ParseTraceParent(parent); ok {
+		span.TraceID, span.ParentID = traceID, parentID
+	} else {
+		span.TraceID = TraceIDFromCorrelationID(correlationID)
+	}
+	span.SetAttribute("correlation.id", correlationID)
+	return span
+}
+
+// Close stops the periodic export and exports the spans still queued
+func (tracer *Tracer) Close() {
+	if tracer == nil {
+		return
+	}
+	close(tracer.stop)
+	tracer.Flush()
+}
+
+func (tracer *Tracer) run(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			tracer.Flush()
+		case <-tracer.stop:
+			return
+		}
+	}
+}
+
+func (tracer *Tracer) queue(span *Span) {
+	tracer.mutex.Lock()
+	if len(tracer.queued) >= maxQueuedSpans {
+		tracer.queued = tracer.queued[1:]
+	}
+	tracer.queued = append(tracer.queued, span)
+	full := len(tracer.queued) >= tracer.BatchSize
+	tracer.mutex.Unlock()
+
+	if full {
+		go tracer.Flush()
+	}
+}
+
+// Flush exports the queued spans in batches. Spans which fail to export are dropped, so an unreachable collector
+// doesn't hold up the pipeline.
+func (tracer *Tracer) Flush() {
+	tracer.flushing.Lock()
+	defer tracer.flushing.Unlock()
+
+	for {
+		tracer.mutex.Lock()
+		count := len(tracer.queued)
+		if count > tracer.BatchSize {
+			count = tracer.BatchSize
+		}
+		batch := tracer.queued[:count]
+		tracer.queued = tracer.queued[count:]
+		tracer.mutex.Unlock()
+
+		if len(batch) == 0 {
+			return
+		}
+		if err := tracer.export(batch); err != nil && tracer.LoggingClient != nil {
+			tracer.LoggingClient.Error(fmt.Sprintf("Failed to export %d spans: %s", len(batch), err.Error()))
+		}
+	}
+}
+
+func (tracer *Tracer) export(spans []*Span) error {
+	body, err := json.Marshal(tracer.request(spans))
+	if err != nil {
+		return err
+	}
+
+	response, err := tracer.client.Post(tracer.Endpoint+"/v1/traces", "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	_, _ = ioutil.ReadAll(response.Body)
+
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		return fmt.Errorf("collector responded with %s", response.Status)
+	}
+	return nil
+}
+
+// The OTLP JSON encoding of an export request, see https://github.com/open-telemetry/opentelemetry-proto
+type otlpRequest struct {
+	ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+}
+
+type otlpResourceSpans struct {
+	Resource   otlpResource     `json:"resource"`
+	ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+}
+
+type otlpResource struct {
+	Attributes []otlpAttribute `json:"attributes"`
+}
+
+type otlpScopeSpans struct {
+	Scope otlpScope  `json:"scope"`
+	Spans []otlpSpan `json:"spans"`
+}
+
+type otlpScope struct {
+	Name string `json:"name"`
+}
+
+type otlpSpan struct {
+	TraceID           string          `json:"traceId"`
+	SpanID            string          `json:"spanId"`
+	ParentSpanID      string          `json:"parentSpanId,omitempty"`
+	Name              string          `json:"name"`
+	Kind              SpanKind        `json:"kind"`
+	StartTimeUnixNano string          `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string          `json:"endTimeUnixNano"`
+	Attributes        []otlpAttribute `json:"attributes,omitempty"`
+	Status            otlpStatus      `json:"status"`
+}
+
+type otlpAttribute struct {
+	Key   string    `json:"key"`
+	Value otlpValue `json:"value"`
+}
+
+type otlpValue struct {
+	StringValue string `json:"stringValue"`
+}
+
+type otlpStatus struct {
+	// Code is 1 for ok and 2 for error
+	Code    int    `json:"code"`
+	Message string `json:"message,omitempty"`
+}
+
+func (tracer *Tracer) request(spans []*Span) otlpRequest {
+	encoded := make([]otlpSpan, 0, len(spans))
+	for _, span := range spans {
+		span.mutex.Lock()
+		otlp := otlpSpan{
+			TraceID:           span.TraceID,
+			SpanID:            span.SpanID,
+			ParentSpanID:      span.ParentID,
+			Name:              span.Name,
+			Kind:              span.Kind,
+			StartTimeUnixNano: strconv.FormatInt(span.Start.UnixNano(), 10),
+			EndTimeUnixNano:   strconv.FormatInt(span.End.UnixNano(), 10),
+			Attributes:        attributes(span.Attributes),
+			Status:            otlpStatus{Code: 1},
+		}
+		if span.Error != "" {
+			otlp.Status = otlpStatus{Code: 2, Message: span.Error}
+		}
+		span.mutex.Unlock()
+		encoded = append(encoded, otlp)
+	}
+
+	return otlpRequest{ResourceSpans: []otlpResourceSpans{{
+		Resource:   otlpResource{Attributes: attributes(map[string]string{"service.name": tracer.ServiceName})},
+		ScopeSpans: []otlpScopeSpans{{Scope: otlpScope{Name: scopeName}, Spans: encoded}},
+	}}}
+}
+
+// attributes encodes the attributes ordered by key
+func attributes(values map[string]string) []otlpAttribute {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	encoded := make([]otlpAttribute, 0, len(keys))
+	for _, key := range keys {
+		encoded = append(encoded, otlpAttribute{Key: key, Value: otlpValue{StringValue: values[key]}})
+	}
+	return encoded
+}
@@ -0,0 +1,153 @@
+//
+// Copyright (c) 2019 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package tracing
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/clients/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var lc = logger.NewClient("tracing_tests", false, "./test.log", "DEBUG")
+
+// collector records the export requests posted to it
+type collector struct {
+	mutex    sync.Mutex
+	requests []otlpRequest
+}
+
+func (c *collector) ServeHTTP(writer http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/v1/traces" || r.Header.Get("Content-Type") != "application/json" {
+		writer.WriteHeader(http.StatusNotFound)
+		return
+	}
+	body, _ := ioutil.ReadAll(r.Body)
+	request := otlpRequest{}
+	if err := json.Unmarshal(body, &request); err != nil {
+		writer.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	c.mutex.Lock()
+	c.requests = append(c.requests, request)
+	c.mutex.Unlock()
+}
+
+func (c *collector) spans() []otlpSpan {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	var spans []otlpSpan
+	for _, request := range c.requests {
+		for _, resource := range request.ResourceSpans {
+			for _, scope := range resource.ScopeSpans {
+				spans = append(spans, scope.Spans...)
+			}
+		}
+	}
+	return spans
+}
+
+func TestParseTraceParent(t *testing.T) {
+	traceID, spanID, ok := ParseTraceParent("00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01")
+	require.True(t, ok)
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", traceID)
+	assert.Equal(t, "00f067aa0ba902b7", spanID)
+
+	for _, invalid := range []string{
+		"",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7",
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-xxf067aa0ba902b7-01",
+	} {
+		_, _, ok := ParseTraceParent(invalid)
+		assert.False(t, ok, invalid)
+	}
+}
+
+func TestTraceIDFromCorrelationID(t *testing.T) {
+	assert.Equal(t, "9f3b5c2e8a1d4e6f9a0b1c2d3e4f5a6b", TraceIDFromCorrelationID("9f3b5c2e-8a1d-4e6f-9a0b-1c2d3e4f5a6b"))
+	assert.Equal(t, TraceIDFromCorrelationID("123-234-345-456"), TraceIDFromCorrelationID("123-234-345-456"), "Trace ID should be repeatable")
+	assert.Len(t, TraceIDFromCorrelationID("123-234-345-456"), 32)
+	assert.Len(t, TraceIDFromCorrelationID(""), 32)
+}
+
+func TestTracerExportsSpans(t *testing.T) {
+	received := &collector{}
+	server := httptest.NewServer(received)
+	defer server.Close()
+
+	tracer := NewTracer(server.URL+"/", "app-trace", 0, time.Hour, lc)
+	root := tracer.Start("process event", KindConsumer, "", "9f3b5c2e-8a1d-4e6f-9a0b-1c2d3e4f5a6b")
+	root.SetAttribute("device", "Random-Float-Device")
+	child := tracer.Start("export HTTPPost", KindClient, root.TraceParent(), "9f3b5c2e-8a1d-4e6f-9a0b-1c2d3e4f5a6b")
+	child.Finish(errors.New("connection refused"))
+	root.Finish(nil)
+	tracer.Close()
+
+	spans := received.spans()
+	require.Equal(t, 2, len(spans))
+	assert.Equal(t, "export HTTPPost", spans[0].Name)
+	assert.Equal(t, KindClient, spans[0].Kind)
+	assert.Equal(t, "9f3b5c2e8a1d4e6f9a0b1c2d3e4f5a6b", spans[0].TraceID)
+	assert.Equal(t, root.SpanID, spans[0].ParentSpanID)
+	assert.Equal(t, otlpStatus{Code: 2, Message: "connection refused"}, spans[0].Status)
+
+	assert.Equal(t, "process event", spans[1].Name)
+	assert.Empty(t, spans[1].ParentSpanID)
+	assert.Equal(t, 1, spans[1].Status.Code)
+	assert.Contains(t, spans[1].Attributes, otlpAttribute{Key: "device", Value: otlpValue{StringValue: "Random-Float-Device"}})
+
+	received.mutex.Lock()
+	defer received.mutex.Unlock()
+	assert.Equal(t, []otlpAttribute{{Key: "service.name", Value: otlpValue{StringValue: "app-trace"}}}, received.requests[0].ResourceSpans[0].Resource.Attributes)
+}
+
+func TestTracerBatches(t *testing.T) {
+	received := &collector{}
+	server := httptest.NewServer(received)
+	defer server.Close()
+
+	tracer := NewTracer(server.URL, "app-trace", 2, time.Hour, lc)
+	defer tracer.Close()
+	for i := 0; i < 2; i++ {
+		tracer.Start("process event", KindServer, "", "123").Finish(nil)
+	}
+
+	for start := time.Now(); len(received.spans()) < 2 && time.Since(start) < time.Second; {
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.Equal(t, 2, len(received.spans()), "A full batch should be exported")
+}
+
+func TestNilTracer(t *testing.T) {
+	var tracer *Tracer
+	span := tracer.Start("process event", KindServer, "", "123")
+	assert.Nil(t, span)
+	span.SetAttribute("device", "Random-Float-Device")
+	span.Finish(nil)
+	assert.Empty(t, span.TraceParent())
+	tracer.Close()
+}
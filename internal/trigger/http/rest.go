@@ -17,27 +17,40 @@
 package http
 
 import (
+	syscontext "context"
 	"io/ioutil"
 	"net/http"
+	"strings"
+	"sync/atomic"
 
 	"github.com/antoniomtz/app-functions-sdk-go/appcontext"
+	"github.com/antoniomtz/app-functions-sdk-go/internal"
 	"github.com/antoniomtz/app-functions-sdk-go/internal/common"
 	"github.com/antoniomtz/app-functions-sdk-go/internal/runtime"
 	"github.com/antoniomtz/app-functions-sdk-go/internal/webserver"
+	"github.com/antoniomtz/app-functions-sdk-go/pkg/secrets"
+	"github.com/antoniomtz/app-functions-sdk-go/pkg/transforms"
+	"github.com/antoniomtz/go-mod-messaging/pkg/types"
 	"github.com/edgexfoundry/go-mod-core-contracts/clients"
+	"github.com/edgexfoundry/go-mod-core-contracts/clients/command"
 	"github.com/edgexfoundry/go-mod-core-contracts/clients/coredata"
 	"github.com/edgexfoundry/go-mod-core-contracts/clients/logger"
-	"github.com/antoniomtz/go-mod-messaging/pkg/types"
+	"github.com/edgexfoundry/go-mod-core-contracts/clients/notifications"
 )
 
 // Trigger implements Trigger to support Triggers
 type Trigger struct {
-	Configuration common.ConfigurationStruct
-	Runtime       runtime.GolangRuntime
-	outputData    []byte
-	logging       logger.LoggingClient
-	Webserver     *webserver.WebServer
-	EventClient   coredata.EventClient
+	Configuration       common.ConfigurationStruct
+	Runtime             *runtime.GolangRuntime
+	outputData          []byte
+	logging             logger.LoggingClient
+	Webserver           *webserver.WebServer
+	EventClient         coredata.EventClient
+	CommandClient       command.CommandClient
+	NotificationsClient notifications.NotificationsClient
+	SecretProvider      secrets.SecretProvider
+	Context             syscontext.Context
+	stopped             int32
 }
 
 // Initialize initializes the Trigger for logging and REST route
@@ -50,12 +63,30 @@ func (trigger *Trigger) Initialize(logger logger.LoggingClient) error {
 	return nil
 }
 
+// Stop rejects new requests with 503 Service Unavailable. Requests being processed complete normally.
+func (trigger *Trigger) Stop() error {
+	atomic.StoreInt32(&trigger.stopped, 1)
+	return nil
+}
+
 func (trigger *Trigger) requestHandler(writer http.ResponseWriter, r *http.Request) {
 	defer r.Body.Close()
 
+	if atomic.LoadInt32(&trigger.stopped) == 1 {
+		trigger.logging.Debug("HTTP Trigger stopped, rejecting HTTP request")
+		writer.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+
+	if trigger.Runtime.IntakeStatus().Paused {
+		trigger.logging.Debug("Pipeline intake paused, rejecting HTTP request")
+		writer.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+
 	contentType := r.Header.Get(clients.ContentType)
 
-	if contentType != clients.ContentTypeJSON && contentType != clients.ContentTypeCBOR {
+	if contentType != clients.ContentTypeJSON && contentType != clients.ContentTypeCBOR && contentType != transforms.ContentTypeCloudEvents {
 		trigger.logging.Debug("HTTP content type not supported", clients.ContentType, contentType)
 		writer.WriteHeader(http.StatusBadRequest)
 		return
@@ -72,10 +103,18 @@ func (trigger *Trigger) requestHandler(writer http.ResponseWriter, r *http.Reque
 
 	correlationID := r.Header.Get("X-Correlation-ID")
 	edgexContext := &appcontext.Context{
-		Configuration: trigger.Configuration,
-		LoggingClient: trigger.logging,
-		CorrelationID: correlationID,
-		EventClient:   trigger.EventClient,
+		Configuration:       trigger.Configuration,
+		LoggingClient:       trigger.logging,
+		CorrelationID:       correlationID,
+		EventClient:         trigger.EventClient,
+		CommandClient:       trigger.CommandClient,
+		NotificationsClient: trigger.NotificationsClient,
+		SecretProvider:      trigger.SecretProvider,
+		Ctx:                 trigger.Context,
+		Replayed:            strings.EqualFold(r.Header.Get(internal.ReplayHeader), "true"),
+		Signature:           r.Header.Get(internal.SignatureHeader),
+		ContentEncoding:     r.Header.Get("Content-Encoding"),
+		TraceParent:         r.Header.Get(internal.TraceParentHeader),
 	}
 
 	trigger.logging.Trace("Received message from http", clients.CorrelationHeader, correlationID)
@@ -88,6 +127,9 @@ func (trigger *Trigger) requestHandler(writer http.ResponseWriter, r *http.Reque
 	}
 
 	trigger.Runtime.ProcessEvent(edgexContext, envelope)
+	if edgexContext.ResponseContentType != "" {
+		writer.Header().Set(clients.ContentType, edgexContext.ResponseContentType)
+	}
 	writer.Write(edgexContext.OutputData)
 
 	if edgexContext.OutputData != nil {
@@ -17,74 +17,368 @@
 package messagebus
 
 import (
+	syscontext "context"
+	"encoding/json"
 	"fmt"
+	"strings"
+	"sync"
 
 	"github.com/antoniomtz/app-functions-sdk-go/appcontext"
 	"github.com/antoniomtz/app-functions-sdk-go/internal/common"
+	bus "github.com/antoniomtz/app-functions-sdk-go/internal/messaging"
 	"github.com/antoniomtz/app-functions-sdk-go/internal/runtime"
+	triggers "github.com/antoniomtz/app-functions-sdk-go/internal/trigger"
+	"github.com/antoniomtz/app-functions-sdk-go/pkg/secrets"
+	"github.com/antoniomtz/go-mod-messaging/messaging"
+	"github.com/antoniomtz/go-mod-messaging/pkg/types"
 	"github.com/edgexfoundry/go-mod-core-contracts/clients"
+	"github.com/edgexfoundry/go-mod-core-contracts/clients/command"
 	"github.com/edgexfoundry/go-mod-core-contracts/clients/coredata"
 	"github.com/edgexfoundry/go-mod-core-contracts/clients/logger"
-	"github.com/antoniomtz/go-mod-messaging/messaging"
-	"github.com/antoniomtz/go-mod-messaging/pkg/types"
+	"github.com/edgexfoundry/go-mod-core-contracts/clients/metadata"
+	"github.com/edgexfoundry/go-mod-core-contracts/clients/notifications"
+)
+
+// Acknowledgment modes of Binding.AckMode
+const (
+	// AckModeAuto acknowledges the messages as they are received
+	AckModeAuto = "auto"
+	// AckModePipeline acknowledges the messages once processed successfully, so that those which fail, or which were
+	// being processed when the service stopped or crashed, are delivered again. Requires a message bus which keeps
+	// the messages until acknowledged, i.e. Redis Streams with a ConsumerGroup.
+	AckModePipeline = "pipeline"
 )
 
 // Trigger implements Trigger to support MessageBusData
 type Trigger struct {
-	Configuration common.ConfigurationStruct
-	Runtime       runtime.GolangRuntime
-	logging       logger.LoggingClient
-	client        messaging.MessageClient
-	topics        []types.TopicChannel
-	EventClient   coredata.EventClient
+	Configuration       common.ConfigurationStruct
+	Runtime             *runtime.GolangRuntime
+	logging             logger.LoggingClient
+	client              messaging.MessageClient
+	subscriptions       []*subscription
+	topics              []types.TopicChannel
+	stopped             chan struct{}
+	stopOnce            sync.Once
+	publisher           *publisher
+	subscriberHealth    connectionHealth
+	EventClient         coredata.EventClient
+	CommandClient       command.CommandClient
+	NotificationsClient notifications.NotificationsClient
+	SecretProvider      secrets.SecretProvider
+	DeviceClient        metadata.DeviceClient
+	Context             syscontext.Context
+	// Background holds the messages published by the application outside of the pipeline, which are published to
+	// the publish topic along with the output of the pipeline
+	Background []<-chan types.MessageEnvelope
+	background sync.WaitGroup
+	topic      *topicTemplate
+	ackMode    string
 }
 
 // Initialize ...
 func (trigger *Trigger) Initialize(logger logger.LoggingClient) error {
 	trigger.logging = logger
-	logger.Info(fmt.Sprintf("Initializing Message Bus Trigger. Subscribing to topic: %s, Publish Topic: %s", trigger.Configuration.Binding.SubscribeTopic, trigger.Configuration.Binding.PublishTopic))
+	subscribeTopics := trigger.Configuration.Binding.SubscribeTopics
+	if len(subscribeTopics) == 0 {
+		subscribeTopics = []string{trigger.Configuration.Binding.SubscribeTopic}
+	}
+	logger.Info(fmt.Sprintf("Initializing Message Bus Trigger. Subscribing to topics: %s, Publish Topic: %s", strings.Join(subscribeTopics, ", "), trigger.Configuration.Binding.PublishTopic))
 	var err error
-	trigger.client, err = messaging.NewMessageClient(trigger.Configuration.MessageBus)
+	var queue *runtime.IngestQueue
+	if trigger.Configuration.Binding.QueueSize > 0 {
+		if queue, err = runtime.NewIngestQueue(trigger.Configuration.Binding.QueueSize, trigger.Configuration.Binding.OverflowPolicy); err != nil {
+			return err
+		}
+		trigger.Runtime.Queue = queue
+	}
+	policy, err := newReconnectPolicy(trigger.Configuration.Binding)
+	if err != nil {
+		return err
+	}
+	ackMode := strings.ToLower(trigger.Configuration.Binding.AckMode)
+	switch ackMode {
+	case "", AckModeAuto:
+		ackMode = AckModeAuto
+	case AckModePipeline:
+		if queue != nil {
+			return fmt.Errorf("Binding.QueueSize can't be used with AckMode '%s'", AckModePipeline)
+		}
+		if trigger.Configuration.Binding.Workers > 1 {
+			// a topic is acknowledged for its message being processed, so its messages are processed one at a time
+			return fmt.Errorf("Binding.Workers can't be greater than 1 with AckMode '%s'", AckModePipeline)
+		}
+	default:
+		return fmt.Errorf("invalid Binding.AckMode '%s', use '%s' or '%s'", trigger.Configuration.Binding.AckMode, AckModeAuto, AckModePipeline)
+	}
+	newClient := func() (messaging.MessageClient, error) {
+		return bus.NewMessageClient(trigger.Configuration.MessageBus, trigger.SecretProvider)
+	}
+	trigger.client, err = newClient()
 
 	if err != nil {
 		return err
 	}
-	trigger.topics = []types.TopicChannel{{Topic: trigger.Configuration.Binding.SubscribeTopic, Messages: make(chan types.MessageEnvelope)}}
+	if _, ok := trigger.client.(bus.Acknowledger); !ok && ackMode == AckModePipeline {
+		return fmt.Errorf("AckMode '%s' requires a message bus which keeps the messages until acknowledged, i.e. '%s' with a ConsumerGroup", AckModePipeline, bus.RedisStreams)
+	}
+	trigger.ackMode = ackMode
+	// each subscription relays the errors of its client, so their errors are merged into messageErrors
 	messageErrors := make(chan error)
 
-	trigger.client.Subscribe(trigger.topics, messageErrors)
-	receiveMessage := true
+	trigger.topics = nil
+	trigger.subscriptions = nil
+	trigger.stopped = make(chan struct{})
+	for index, topic := range subscribeTopics {
+		topicChannel := types.TopicChannel{Topic: topic, Messages: make(chan types.MessageEnvelope)}
+		trigger.topics = append(trigger.topics, topicChannel)
+
+		// each topic needs its own client, since a client delivers the messages of all topics subscribed together
+		// to the channel of the last topic
+		client := trigger.client
+		if index > 0 {
+			if client, err = newClient(); err != nil {
+				return err
+			}
+		}
+		sub := &subscription{
+			topic:     topicChannel,
+			errors:    messageErrors,
+			stopped:   trigger.stopped,
+			newClient: newClient,
+			policy:    policy,
+			health:    &trigger.subscriberHealth,
+			logging:   logger,
+			autoAck:   ackMode == AckModeAuto,
+		}
+		messages, clientErrors, err := sub.subscribe(client)
+		if err != nil {
+			return err
+		}
+		go sub.run(messages, clientErrors)
+		trigger.subscriptions = append(trigger.subscriptions, sub)
+	}
+
+	// publish on a separate connection, so publishing the output doesn't hold up receiving
+	publisherClient, err := bus.NewMessageClient(trigger.Configuration.MessageBus, trigger.SecretProvider)
+	if err != nil {
+		return err
+	}
+	// the output is published to the topic resolved from its event, and the background messages, which have none, to
+	// the topic with the placeholders resolved as unknown
+	trigger.topic = newTopicTemplate(trigger.Configuration.Binding.PublishTopic, trigger.DeviceClient, logger)
+	if strings.Contains(trigger.topic.topic, ProfilePlaceholder) && trigger.DeviceClient == nil {
+		logger.Error(fmt.Sprintf("The %s placeholder of the publish topic requires the Metadata client to be configured, publishing to '%s' instead", ProfilePlaceholder, UnknownTopicLevel))
+	}
+	trigger.publisher = newPublisher(publisherClient, trigger.topic.resolve(nil), trigger.Configuration.Binding.PublishQueueSize, logger)
+	for _, messages := range trigger.Background {
+		trigger.background.Add(1)
+		go trigger.forwardBackground(messages)
+	}
+	if trigger.Runtime.PoisonMessages != nil && trigger.Configuration.PoisonMessages.DeadLetterTopic != "" {
+		trigger.Runtime.PoisonMessages.DeadLetters = append(trigger.Runtime.PoisonMessages.DeadLetters, trigger.publishDeadLetter)
+	}
+
+	scheduler := newTopicScheduler(trigger.topics, trigger.Configuration.Binding.TopicWeights, messageErrors)
+	scheduler.stop = trigger.stopped
+	var workers *workerPool
+	if trigger.Configuration.Binding.Workers > 1 {
+		workers = newWorkerPool(trigger.Configuration.Binding.Workers, trigger.Configuration.Binding.PreserveDeviceOrder, trigger.Runtime.DecodeMessage, trigger.processReceived)
+	}
+	receive := func() (types.MessageEnvelope, string, error) {
+		return trigger.receiveMessage(scheduler)
+	}
+	if queue != nil {
+		go trigger.enqueue(scheduler, queue)
+		receive = func() (types.MessageEnvelope, string, error) {
+			event, ok := queue.Pop(trigger.stopped)
+			if !ok {
+				return types.MessageEnvelope{}, "", errStopped
+			}
+			return event.Envelope, event.Topic, nil
+		}
+	}
 	go func() {
-		for receiveMessage {
-			select {
-			case msgErr := <-messageErrors:
-				logger.Error(fmt.Sprintf("Failed to receive ZMQ Message, %v", msgErr))
-			case msgs := <-trigger.topics[0].Messages:
-				logger.Trace("Received message from bus", "topic", trigger.Configuration.Binding.PublishTopic, clients.CorrelationHeader, msgs.CorrelationID)
-
-				edgexContext := &appcontext.Context{
-					Configuration: trigger.Configuration,
-					LoggingClient: trigger.logging,
-					CorrelationID: msgs.CorrelationID,
-					EventClient:   trigger.EventClient,
+		defer trigger.disconnect()
+		for {
+			// leave new messages on the bus while intake is paused
+			trigger.Runtime.WaitWhilePaused()
+			msgs, topic, msgErr := receive()
+			if msgErr == errStopped {
+				if workers != nil {
+					workers.stop()
 				}
-				trigger.Runtime.ProcessEvent(edgexContext, msgs)
-				if edgexContext.OutputData != nil {
-					outputEnvelope := types.MessageEnvelope{
-						CorrelationID: edgexContext.CorrelationID,
-						Payload:       edgexContext.OutputData,
-						ContentType:   clients.ContentTypeJSON,
-					}
-					err := trigger.client.Publish(outputEnvelope, trigger.Configuration.Binding.PublishTopic)
-					if err != nil {
-						logger.Error(fmt.Sprintf("Failed to publish Message to bus, %v", err))
-					}
-
-					logger.Trace("Published message to bus", "topic", trigger.Configuration.Binding.PublishTopic, clients.CorrelationHeader, msgs.CorrelationID)
+				if queue != nil && queue.Len() > 0 {
+					logger.Warn(fmt.Sprintf("Discarding %d events queued when the Message Bus Trigger stopped", queue.Len()))
 				}
+				logger.Info("Message Bus Trigger stopped")
+				return
+			}
+			if msgErr != nil {
+				continue
+			}
+			if workers != nil {
+				workers.dispatch(msgs, topic)
+			} else {
+				trigger.processMessage(msgs, topic)
 			}
 		}
 	}()
 
 	return nil
 }
+
+// receiveMessage returns the next message from the bus along with its topic, recording the health of the subscriber
+// connections
+func (trigger *Trigger) receiveMessage(scheduler *topicScheduler) (types.MessageEnvelope, string, error) {
+	msgs, topic, err := scheduler.receive()
+	switch {
+	case err == errStopped:
+	case err != nil:
+		trigger.subscriberHealth.errored(err)
+		trigger.logging.Error(fmt.Sprintf("Failed to receive Message Bus message, %v", err))
+	default:
+		trigger.subscriberHealth.succeeded()
+	}
+	return msgs, topic, err
+}
+
+// enqueue moves the messages received from the bus into the ingest queue, applying its overflow policy, until the
+// trigger stops
+func (trigger *Trigger) enqueue(scheduler *topicScheduler, queue *runtime.IngestQueue) {
+	for {
+		// leave new messages on the bus while intake is paused
+		trigger.Runtime.WaitWhilePaused()
+		msgs, topic, err := trigger.receiveMessage(scheduler)
+		if err == errStopped {
+			return
+		}
+		if err != nil {
+			continue
+		}
+		if queue.Push(runtime.QueuedEvent{Envelope: msgs, Topic: topic}, trigger.stopped) {
+			trigger.logging.Warn("Ingest queue full, dropped an event with the " + queue.Metrics().Policy + " policy")
+		}
+	}
+}
+
+// processMessage executes the pipeline for the message received on the topic, and queues its output to be published
+func (trigger *Trigger) processMessage(msgs types.MessageEnvelope, topic string) {
+	trigger.processReceived(receivedMessage{envelope: msgs, topic: topic})
+}
+
+// processReceived processes the received message like processMessage, without decoding it again when it's decoded
+func (trigger *Trigger) processReceived(message receivedMessage) {
+	err := trigger.process(message)
+	if trigger.ackMode == AckModePipeline {
+		trigger.acknowledge(message.topic, err == nil)
+	}
+}
+
+// process processes the message and publishes the output, returning the error which stopped the processing, if any.
+// Rejected messages can't be processed and aren't processed again.
+func (trigger *Trigger) process(message receivedMessage) error {
+	msgs, topic := message.envelope, message.topic
+	trigger.logging.Trace("Received message from bus", "topic", topic, clients.CorrelationHeader, msgs.CorrelationID)
+	if err := trigger.Runtime.ValidateContentType(msgs.ContentType); err != nil {
+		trigger.logging.Error(fmt.Sprintf("Rejected message received on topic %s: %s", topic, err.Error()), clients.CorrelationHeader, msgs.CorrelationID)
+		return nil
+	}
+
+	edgexContext := &appcontext.Context{
+		Configuration:       trigger.Configuration,
+		LoggingClient:       trigger.logging,
+		CorrelationID:       msgs.CorrelationID,
+		ReceivedTopic:       topic,
+		EventClient:         trigger.EventClient,
+		CommandClient:       trigger.CommandClient,
+		NotificationsClient: trigger.NotificationsClient,
+		SecretProvider:      trigger.SecretProvider,
+		Ctx:                 trigger.Context,
+	}
+	var err error
+	if message.decoded != nil {
+		err = trigger.Runtime.ProcessDecodedMessage(edgexContext, message.decoded)
+	} else {
+		err = trigger.Runtime.ProcessMessage(edgexContext, msgs)
+	}
+	if err != nil {
+		return err
+	}
+	if edgexContext.OutputData == nil {
+		return nil
+	}
+
+	contentType := edgexContext.ResponseContentType
+	if contentType == "" {
+		contentType = clients.ContentTypeJSON
+	}
+	outputEnvelope := types.MessageEnvelope{
+		CorrelationID: edgexContext.CorrelationID,
+		Payload:       edgexContext.OutputData,
+		ContentType:   contentType,
+	}
+	if trigger.topic != nil && trigger.topic.templated() {
+		trigger.publisher.publishTo(outputEnvelope, trigger.topic.resolve(edgexContext))
+		return nil
+	}
+	trigger.publisher.publish(outputEnvelope)
+	return nil
+}
+
+// acknowledge acknowledges the message received on the topic once processed
+func (trigger *Trigger) acknowledge(topic string, processed bool) {
+	for _, sub := range trigger.subscriptions {
+		if sub.topic.Topic == topic {
+			sub.ack(processed)
+			return
+		}
+	}
+}
+
+// publishDeadLetter publishes the message given up on to the dead letter topic, with the original payload and the
+// failures reported as JSON
+func (trigger *Trigger) publishDeadLetter(message runtime.DeadLetterMessage) error {
+	payload, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+	trigger.publisher.publishTo(types.MessageEnvelope{
+		CorrelationID: message.CorrelationID,
+		Payload:       payload,
+		ContentType:   clients.ContentTypeJSON,
+	}, trigger.Configuration.PoisonMessages.DeadLetterTopic)
+	return nil
+}
+
+// Stop stops receiving messages from the bus. The messages being processed, if any, are still processed and their
+// output published before the clients disconnect from the bus.
+func (trigger *Trigger) Stop() error {
+	trigger.stopOnce.Do(func() {
+		if trigger.stopped != nil {
+			close(trigger.stopped)
+		}
+	})
+	return nil
+}
+
+// Connections returns the health of the subscriber connections, which are reported together, and of the publisher
+// connection
+func (trigger *Trigger) Connections() []triggers.ConnectionStatus {
+	connections := []triggers.ConnectionStatus{trigger.subscriberHealth.snapshot("subscriber")}
+	if trigger.publisher != nil {
+		connections = append(connections, trigger.publisher.status())
+	}
+	return connections
+}
+
+// disconnect publishes the output and the background messages already queued and disconnects the clients from the
+// bus
+func (trigger *Trigger) disconnect() {
+	trigger.background.Wait()
+	if trigger.publisher != nil {
+		trigger.publisher.stop()
+	}
+	for _, sub := range trigger.subscriptions {
+		sub.disconnect()
+	}
+}
@@ -21,10 +21,10 @@ import (
 	"testing"
 	"time"
 
-	"github.com/edgexfoundry/go-mod-core-contracts/clients"
-	"github.com/edgexfoundry/go-mod-core-contracts/models"
 	"github.com/antoniomtz/go-mod-messaging/messaging"
 	"github.com/antoniomtz/go-mod-messaging/pkg/types"
+	"github.com/edgexfoundry/go-mod-core-contracts/clients"
+	"github.com/edgexfoundry/go-mod-core-contracts/models"
 
 	"github.com/antoniomtz/app-functions-sdk-go/appcontext"
 	"github.com/antoniomtz/app-functions-sdk-go/internal/common"
@@ -32,6 +32,8 @@ import (
 
 	"github.com/edgexfoundry/go-mod-core-contracts/clients/logger"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ugorji/go/codec"
 )
 
 var logClient logger.LoggingClient
@@ -63,7 +65,7 @@ func TestInitialize(t *testing.T) {
 		},
 	}
 
-	runtime := runtime.GolangRuntime{}
+	runtime := &runtime.GolangRuntime{}
 
 	trigger := Trigger{Configuration: config, Runtime: runtime}
 	trigger.Initialize(logClient)
@@ -74,6 +76,69 @@ func TestInitialize(t *testing.T) {
 
 }
 
+func TestInitializeMultipleTopics(t *testing.T) {
+
+	config := common.ConfigurationStruct{
+		Binding: common.BindingInfo{
+			Type:            "messagebus",
+			SubscribeTopics: []string{"events", "alarms"},
+			TopicWeights:    map[string]int{"alarms": 5},
+		},
+		MessageBus: types.MessageBusConfig{
+			Type: "zero",
+			SubscribeHost: types.HostInfo{
+				Host:     "localhost",
+				Port:     5570,
+				Protocol: "tcp",
+			},
+		},
+	}
+
+	trigger := Trigger{Configuration: config, Runtime: &runtime.GolangRuntime{}}
+	err := trigger.Initialize(logClient)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(trigger.topics))
+	assert.Equal(t, "events", trigger.topics[0].Topic)
+	assert.Equal(t, "alarms", trigger.topics[1].Topic)
+}
+
+func TestStopMultipleTopics(t *testing.T) {
+	config := common.ConfigurationStruct{
+		Binding: common.BindingInfo{
+			Type:            "messagebus",
+			SubscribeTopics: []string{"events", "alarms"},
+		},
+		MessageBus: types.MessageBusConfig{
+			Type: "zero",
+			SubscribeHost: types.HostInfo{
+				Host:     "localhost",
+				Port:     5571,
+				Protocol: "tcp",
+			},
+		},
+	}
+
+	trigger := Trigger{Configuration: config, Runtime: &runtime.GolangRuntime{}}
+	require.NoError(t, trigger.Initialize(logClient))
+	require.NotNil(t, trigger.publisher, "output should be published on a separate connection")
+
+	connections := trigger.Connections()
+	require.Len(t, connections, 2)
+	assert.Equal(t, "subscriber", connections[0].Name)
+	assert.True(t, connections[0].Healthy)
+	assert.Equal(t, "publisher", connections[1].Name)
+	assert.True(t, connections[1].Healthy)
+
+	// each client closes its own error channel when disconnected
+	assert.NoError(t, trigger.Stop())
+	select {
+	case <-trigger.publisher.done:
+	case <-time.After(time.Second):
+		t.Fatal("the publisher should be stopped")
+	}
+	time.Sleep(50 * time.Millisecond)
+}
+
 func TestInitializeBadConfiguration(t *testing.T) {
 
 	config := common.ConfigurationStruct{
@@ -97,13 +162,34 @@ func TestInitializeBadConfiguration(t *testing.T) {
 		},
 	}
 
-	runtime := runtime.GolangRuntime{}
+	runtime := &runtime.GolangRuntime{}
 
 	trigger := Trigger{Configuration: config, Runtime: runtime}
 	err := trigger.Initialize(logClient)
 	assert.NotNil(t, err)
 }
 
+func TestInitializeBadOverflowPolicy(t *testing.T) {
+	config := common.ConfigurationStruct{
+		Binding: common.BindingInfo{
+			Type:           "meSsaGebus",
+			SubscribeTopic: "events",
+			QueueSize:      10,
+			OverflowPolicy: "drop-all",
+		},
+		MessageBus: types.MessageBusConfig{
+			Type: "zero",
+		},
+	}
+
+	runtime := &runtime.GolangRuntime{}
+
+	trigger := Trigger{Configuration: config, Runtime: runtime}
+	err := trigger.Initialize(logClient)
+	assert.Error(t, err)
+	assert.Nil(t, runtime.Queue)
+}
+
 func TestInitializeAndProcessEventWithNoOutput(t *testing.T) {
 
 	config := common.ConfigurationStruct{
@@ -142,7 +228,7 @@ func TestInitializeAndProcessEventWithNoOutput(t *testing.T) {
 
 	}
 
-	runtime := runtime.GolangRuntime{}
+	runtime := &runtime.GolangRuntime{}
 	runtime.Transforms = []func(*appcontext.Context, ...interface{}) (bool, interface{}){transform1}
 
 	trigger := Trigger{Configuration: config, Runtime: runtime}
@@ -218,7 +304,7 @@ func TestInitializeAndProcessEventWithOutput(t *testing.T) {
 
 	}
 
-	runtime := runtime.GolangRuntime{}
+	runtime := &runtime.GolangRuntime{}
 	runtime.Transforms = []func(*appcontext.Context, ...interface{}) (bool, interface{}){transform1}
 
 	trigger := Trigger{Configuration: config, Runtime: runtime}
@@ -278,3 +364,32 @@ func TestInitializeAndProcessEventWithOutput(t *testing.T) {
 		}
 	}
 }
+
+func TestProcessMessageContentType(t *testing.T) {
+	client := &fakeClient{}
+	calls := 0
+	transform := func(edgexcontext *appcontext.Context, params ...interface{}) (bool, interface{}) {
+		calls++
+		edgexcontext.SetResponseContentType("text/csv")
+		edgexcontext.SetResponseData([]byte("thermostat,38"))
+		return false, nil
+	}
+	trigger := Trigger{
+		Runtime:   &runtime.GolangRuntime{Transforms: []func(*appcontext.Context, ...interface{}) (bool, interface{}){transform}},
+		logging:   logClient,
+		publisher: newPublisher(client, "output", 0, logClient),
+	}
+
+	trigger.processMessage(types.MessageEnvelope{CorrelationID: "1", Payload: []byte("38"), ContentType: "text/plain"}, "events")
+	assert.Equal(t, 0, calls, "a message of an unsupported content type should be rejected")
+
+	var payload []byte
+	codec.NewEncoderBytes(&payload, &codec.CborHandle{}).Encode(models.Event{Device: "thermostat"})
+	trigger.processMessage(types.MessageEnvelope{CorrelationID: "2", Payload: payload, ContentType: clients.ContentTypeCBOR}, "events")
+	trigger.processMessage(types.MessageEnvelope{CorrelationID: "3", Payload: []byte(`{"device":"thermostat"}`), ContentType: "application/json; charset=utf-8"}, "events")
+	trigger.publisher.stop()
+
+	assert.Equal(t, 2, calls)
+	assert.Equal(t, []string{"2", "3"}, client.published)
+	assert.Equal(t, []string{"text/csv", "text/csv"}, client.contentTypes, "the output should be published with the response content type")
+}
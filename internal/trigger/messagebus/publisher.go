@@ -0,0 +1,173 @@
+//
+// Copyright (c) 2019 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package messagebus
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	triggers "github.com/antoniomtz/app-functions-sdk-go/internal/trigger"
+	"github.com/antoniomtz/go-mod-messaging/messaging"
+	"github.com/antoniomtz/go-mod-messaging/pkg/types"
+	"github.com/edgexfoundry/go-mod-core-contracts/clients"
+	"github.com/edgexfoundry/go-mod-core-contracts/clients/logger"
+)
+
+// defaultPublishQueueSize is the number of messages waiting to be published unless Binding.PublishQueueSize is set
+const defaultPublishQueueSize = 100
+
+// connectionHealth tracks the outcome of the operations on a connection to the bus
+type connectionHealth struct {
+	mutex  sync.Mutex
+	status triggers.ConnectionStatus
+}
+
+func (health *connectionHealth) succeeded() {
+	health.mutex.Lock()
+	defer health.mutex.Unlock()
+
+	health.status.Messages++
+	health.status.Healthy = true
+}
+
+func (health *connectionHealth) failed(err error) {
+	health.mutex.Lock()
+	defer health.mutex.Unlock()
+
+	health.status.Errors++
+	health.status.Healthy = false
+	health.status.LastError = err.Error()
+	health.status.LastErrorTime = time.Now()
+}
+
+// errored records a failed operation which doesn't affect the health, such as an error the connection recovers from
+func (health *connectionHealth) errored(err error) {
+	health.mutex.Lock()
+	defer health.mutex.Unlock()
+
+	if health.status.Messages == 0 && health.status.Errors == 0 {
+		health.status.Healthy = true
+	}
+	health.status.Errors++
+	health.status.LastError = err.Error()
+	health.status.LastErrorTime = time.Now()
+}
+
+// snapshot returns the status of the connection under the name. A connection is healthy until an operation fails.
+func (health *connectionHealth) snapshot(name string) triggers.ConnectionStatus {
+	health.mutex.Lock()
+	defer health.mutex.Unlock()
+
+	status := health.status
+	status.Name = name
+	if status.Messages == 0 && status.Errors == 0 {
+		status.Healthy = true
+	}
+	return status
+}
+
+// publisher publishes the output of the pipeline to the bus on its own connection and goroutine, so slow publishing
+// doesn't hold up receiving messages. Publishing waits once the queue of messages to publish is full.
+type publisher struct {
+	client   messaging.MessageClient
+	topic    string
+	messages chan outgoingMessage
+	done     chan struct{}
+	logging  logger.LoggingClient
+	health   connectionHealth
+}
+
+type outgoingMessage struct {
+	envelope types.MessageEnvelope
+	topic    string
+}
+
+func newPublisher(client messaging.MessageClient, topic string, queueSize int, logging logger.LoggingClient) *publisher {
+	if queueSize <= 0 {
+		queueSize = defaultPublishQueueSize
+	}
+	publisher := &publisher{
+		client:   client,
+		topic:    topic,
+		messages: make(chan outgoingMessage, queueSize),
+		done:     make(chan struct{}),
+		logging:  logging,
+	}
+	go publisher.run()
+	return publisher
+}
+
+// publish queues the message to be published to the topic of the publisher
+func (publisher *publisher) publish(message types.MessageEnvelope) {
+	publisher.publishTo(message, publisher.topic)
+}
+
+// publishTo queues the message to be published to the topic
+func (publisher *publisher) publishTo(message types.MessageEnvelope, topic string) {
+	publisher.messages <- outgoingMessage{envelope: message, topic: topic}
+}
+
+func (publisher *publisher) run() {
+	defer close(publisher.done)
+	for message := range publisher.messages {
+		if err := publisher.client.Publish(message.envelope, message.topic); err != nil {
+			publisher.health.failed(err)
+			publisher.logging.Error(fmt.Sprintf("Failed to publish Message to bus, %v", err))
+			continue
+		}
+		publisher.health.succeeded()
+		publisher.logging.Trace("Published message to bus", "topic", message.topic, clients.CorrelationHeader, message.envelope.CorrelationID)
+	}
+}
+
+// stop publishes the messages already queued and then disconnects from the bus
+func (publisher *publisher) stop() {
+	close(publisher.messages)
+	<-publisher.done
+	if err := publisher.client.Disconnect(); err != nil {
+		publisher.logging.Error(fmt.Sprintf("Failed to disconnect publisher from message bus, %v", err))
+	}
+}
+
+// forwardBackground publishes the messages of a background publisher until the trigger stops, and then those already
+// waiting
+func (trigger *Trigger) forwardBackground(messages <-chan types.MessageEnvelope) {
+	defer trigger.background.Done()
+	for {
+		select {
+		case message := <-messages:
+			trigger.publisher.publish(message)
+		case <-trigger.stopped:
+			for {
+				select {
+				case message := <-messages:
+					trigger.publisher.publish(message)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+// status returns the health of the connection along with the number of messages waiting to be published
+func (publisher *publisher) status() triggers.ConnectionStatus {
+	status := publisher.health.snapshot("publisher")
+	status.Pending = len(publisher.messages)
+	return status
+}
@@ -0,0 +1,156 @@
+//
+// Copyright (c) 2019 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package messagebus
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/antoniomtz/app-functions-sdk-go/internal/common"
+	"github.com/antoniomtz/app-functions-sdk-go/internal/runtime"
+	"github.com/antoniomtz/go-mod-messaging/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeClient struct {
+	mutex        sync.Mutex
+	published    []string
+	topics       []string
+	contentTypes []string
+	fail         bool
+	release      chan struct{}
+	disconnected bool
+}
+
+func (client *fakeClient) Connect() error {
+	return nil
+}
+
+func (client *fakeClient) Publish(message types.MessageEnvelope, topic string) error {
+	if client.release != nil {
+		<-client.release
+	}
+	client.mutex.Lock()
+	defer client.mutex.Unlock()
+	if client.fail {
+		return errors.New("not connected")
+	}
+	client.published = append(client.published, message.CorrelationID)
+	client.topics = append(client.topics, topic)
+	client.contentTypes = append(client.contentTypes, message.ContentType)
+	return nil
+}
+
+func (client *fakeClient) Subscribe(topics []types.TopicChannel, messageErrors chan error) error {
+	return nil
+}
+
+func (client *fakeClient) Disconnect() error {
+	client.mutex.Lock()
+	defer client.mutex.Unlock()
+	client.disconnected = true
+	return nil
+}
+
+func TestPublisherDoesNotBlockProcessing(t *testing.T) {
+	client := &fakeClient{release: make(chan struct{})}
+	publisher := newPublisher(client, "output", 2, logClient)
+
+	queued := make(chan struct{})
+	go func() {
+		publisher.publish(types.MessageEnvelope{CorrelationID: "1"})
+		publisher.publish(types.MessageEnvelope{CorrelationID: "2"})
+		close(queued)
+	}()
+	select {
+	case <-queued:
+	case <-time.After(time.Second):
+		t.Fatal("publish shouldn't wait for a slow bus while the queue has room")
+	}
+	assert.True(t, publisher.status().Pending > 0)
+
+	close(client.release)
+	publisher.stop()
+	assert.Equal(t, []string{"1", "2"}, client.published, "queued messages should be published before stopping")
+	assert.Equal(t, []string{"output", "output"}, client.topics)
+	assert.True(t, client.disconnected)
+
+	status := publisher.status()
+	assert.Equal(t, "publisher", status.Name)
+	assert.True(t, status.Healthy)
+	assert.Equal(t, uint64(2), status.Messages)
+	assert.Equal(t, 0, status.Pending)
+}
+
+func TestPublisherHealth(t *testing.T) {
+	client := &fakeClient{fail: true}
+	publisher := newPublisher(client, "output", 0, logClient)
+	assert.True(t, publisher.status().Healthy, "a publisher is healthy until publishing fails")
+
+	publisher.publish(types.MessageEnvelope{CorrelationID: "1"})
+	publisher.stop()
+
+	status := publisher.status()
+	require.Equal(t, uint64(1), status.Errors)
+	assert.False(t, status.Healthy)
+	assert.Equal(t, "not connected", status.LastError)
+	assert.False(t, status.LastErrorTime.IsZero())
+
+	var health connectionHealth
+	health.failed(errors.New("timeout"))
+	assert.False(t, health.snapshot("subscriber").Healthy)
+	health.succeeded()
+	assert.True(t, health.snapshot("subscriber").Healthy, "a successful operation should restore the health")
+}
+
+func TestPublishDeadLetter(t *testing.T) {
+	client := &fakeClient{}
+	trigger := Trigger{
+		Configuration: common.ConfigurationStruct{PoisonMessages: common.PoisonMessagesInfo{DeadLetterTopic: "dead-letters"}},
+		publisher:     newPublisher(client, "output", 0, logClient),
+	}
+
+	err := trigger.publishDeadLetter(runtime.DeadLetterMessage{CorrelationID: "123", Payload: []byte("{}"), Failures: 3})
+	require.NoError(t, err)
+	trigger.publisher.stop()
+
+	assert.Equal(t, []string{"123"}, client.published)
+	assert.Equal(t, []string{"dead-letters"}, client.topics)
+}
+
+func TestForwardBackground(t *testing.T) {
+	client := &fakeClient{}
+	messages := make(chan types.MessageEnvelope, 10)
+	trigger := Trigger{
+		publisher: newPublisher(client, "output", 0, logClient),
+		stopped:   make(chan struct{}),
+	}
+	trigger.background.Add(1)
+	go trigger.forwardBackground(messages)
+
+	messages <- types.MessageEnvelope{CorrelationID: "heartbeat1"}
+	messages <- types.MessageEnvelope{CorrelationID: "heartbeat2"}
+	close(trigger.stopped)
+	trigger.disconnect()
+
+	assert.Equal(t, []string{"heartbeat1", "heartbeat2"}, client.published, "waiting messages should be published before disconnecting")
+	assert.Equal(t, []string{"output", "output"}, client.topics)
+	assert.True(t, client.disconnected)
+}
@@ -0,0 +1,97 @@
+//
+// Copyright (c) 2019 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package messagebus
+
+import (
+	"errors"
+	"reflect"
+
+	"github.com/antoniomtz/go-mod-messaging/pkg/types"
+)
+
+// errStopped is returned by receive once the scheduler is stopped
+var errStopped = errors.New("message bus trigger stopped")
+
+// topicScheduler receives messages from several topics using weighted round-robin, so that a topic with a high
+// message rate can't starve the other topics. Each topic is served in turn for up to its weight in messages, and
+// topics without pending messages are skipped.
+type topicScheduler struct {
+	topics  []types.TopicChannel
+	weights []int
+	errors  <-chan error
+	// stop is closed to stop receiving messages
+	stop    <-chan struct{}
+	current int
+	served  int
+}
+
+func newTopicScheduler(topics []types.TopicChannel, weights map[string]int, errors <-chan error) *topicScheduler {
+	scheduler := &topicScheduler{topics: topics, weights: make([]int, len(topics)), errors: errors}
+	for index, topic := range topics {
+		scheduler.weights[index] = 1
+		if weight := weights[topic.Topic]; weight > 0 {
+			scheduler.weights[index] = weight
+		}
+	}
+	return scheduler
+}
+
+// receive returns the next message along with its topic, waiting until a message or an error is received
+func (scheduler *topicScheduler) receive() (types.MessageEnvelope, string, error) {
+	select {
+	case <-scheduler.stop:
+		return types.MessageEnvelope{}, "", errStopped
+	case err := <-scheduler.errors:
+		return types.MessageEnvelope{}, "", err
+	default:
+	}
+
+	for range scheduler.topics {
+		if scheduler.served < scheduler.weights[scheduler.current] {
+			topic := scheduler.topics[scheduler.current]
+			select {
+			case message := <-topic.Messages:
+				scheduler.served++
+				return message, topic.Topic, nil
+			default:
+			}
+		}
+		scheduler.current = (scheduler.current + 1) % len(scheduler.topics)
+		scheduler.served = 0
+	}
+
+	// no messages are pending, so wait for the first topic to receive one
+	cases := []reflect.SelectCase{
+		{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(scheduler.stop)},
+		{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(scheduler.errors)},
+	}
+	for _, topic := range scheduler.topics {
+		cases = append(cases, reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(topic.Messages)})
+	}
+	chosen, value, _ := reflect.Select(cases)
+	switch chosen {
+	case 0:
+		return types.MessageEnvelope{}, "", errStopped
+	case 1:
+		err, _ := value.Interface().(error)
+		return types.MessageEnvelope{}, "", err
+	}
+
+	scheduler.current = chosen - 2
+	scheduler.served = 1
+	return value.Interface().(types.MessageEnvelope), scheduler.topics[scheduler.current].Topic, nil
+}
@@ -0,0 +1,111 @@
+//
+// Copyright (c) 2019 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package messagebus
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/antoniomtz/go-mod-messaging/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTopics(names ...string) []types.TopicChannel {
+	var topics []types.TopicChannel
+	for _, name := range names {
+		topics = append(topics, types.TopicChannel{Topic: name, Messages: make(chan types.MessageEnvelope, 100)})
+	}
+	return topics
+}
+
+func receiveTopics(t *testing.T, scheduler *topicScheduler, count int) []string {
+	var received []string
+	for i := 0; i < count; i++ {
+		_, topic, err := scheduler.receive()
+		require.NoError(t, err)
+		received = append(received, topic)
+	}
+	return received
+}
+
+func TestTopicSchedulerRoundRobin(t *testing.T) {
+	topics := newTopics("firehose", "alarms")
+	for i := 0; i < 10; i++ {
+		topics[0].Messages <- types.MessageEnvelope{}
+	}
+	topics[1].Messages <- types.MessageEnvelope{}
+	topics[1].Messages <- types.MessageEnvelope{}
+
+	scheduler := newTopicScheduler(topics, nil, make(chan error))
+
+	expected := []string{"firehose", "alarms", "firehose", "alarms", "firehose", "firehose"}
+	assert.Equal(t, expected, receiveTopics(t, scheduler, 6))
+}
+
+func TestTopicSchedulerWeighted(t *testing.T) {
+	topics := newTopics("firehose", "alarms")
+	for i := 0; i < 10; i++ {
+		topics[0].Messages <- types.MessageEnvelope{}
+		topics[1].Messages <- types.MessageEnvelope{}
+	}
+
+	scheduler := newTopicScheduler(topics, map[string]int{"alarms": 3}, make(chan error))
+
+	expected := []string{"firehose", "alarms", "alarms", "alarms", "firehose", "alarms", "alarms", "alarms"}
+	assert.Equal(t, expected, receiveTopics(t, scheduler, 8))
+}
+
+func TestTopicSchedulerWaits(t *testing.T) {
+	topics := newTopics("firehose", "alarms")
+	messageErrors := make(chan error)
+	scheduler := newTopicScheduler(topics, nil, messageErrors)
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		topics[1].Messages <- types.MessageEnvelope{CorrelationID: "123"}
+	}()
+	message, topic, err := scheduler.receive()
+	require.NoError(t, err)
+	assert.Equal(t, "alarms", topic)
+	assert.Equal(t, "123", message.CorrelationID)
+
+	go func() {
+		messageErrors <- errors.New("receive failed")
+	}()
+	_, _, err = scheduler.receive()
+	assert.EqualError(t, err, "receive failed")
+}
+
+func TestTopicSchedulerStop(t *testing.T) {
+	topics := newTopics("firehose")
+	stop := make(chan struct{})
+	scheduler := newTopicScheduler(topics, nil, make(chan error))
+	scheduler.stop = stop
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		close(stop)
+	}()
+	_, _, err := scheduler.receive()
+	assert.Equal(t, errStopped, err)
+
+	topics[0].Messages <- types.MessageEnvelope{}
+	_, _, err = scheduler.receive()
+	assert.Equal(t, errStopped, err, "messages should not be received once stopped")
+}
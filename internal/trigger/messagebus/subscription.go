@@ -0,0 +1,276 @@
+//
+// Copyright (c) 2019 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package messagebus
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/antoniomtz/app-functions-sdk-go/internal/common"
+	bus "github.com/antoniomtz/app-functions-sdk-go/internal/messaging"
+	"github.com/antoniomtz/go-mod-messaging/messaging"
+	"github.com/antoniomtz/go-mod-messaging/pkg/types"
+	"github.com/edgexfoundry/go-mod-core-contracts/clients/logger"
+)
+
+const (
+	// defaultReconnectInterval is the wait before the first attempt to subscribe again unless
+	// Binding.ReconnectInterval is set
+	defaultReconnectInterval = time.Second
+	// defaultMaxReconnectInterval caps the wait between attempts unless Binding.MaxReconnectInterval is set
+	defaultMaxReconnectInterval = 30 * time.Second
+	// defaultMaxReconnectAttempts is the number of failed attempts after which the subscriber is reported unhealthy
+	// unless Binding.MaxReconnectAttempts is set
+	defaultMaxReconnectAttempts = 5
+)
+
+// reconnectPolicy is how a subscription whose connection dropped subscribes again
+type reconnectPolicy struct {
+	interval    time.Duration
+	maxInterval time.Duration
+	maxAttempts int
+}
+
+func newReconnectPolicy(binding common.BindingInfo) (reconnectPolicy, error) {
+	policy := reconnectPolicy{
+		interval:    defaultReconnectInterval,
+		maxInterval: defaultMaxReconnectInterval,
+		maxAttempts: defaultMaxReconnectAttempts,
+	}
+	if binding.ReconnectInterval != "" {
+		interval, err := time.ParseDuration(binding.ReconnectInterval)
+		if err != nil || interval <= 0 {
+			return policy, fmt.Errorf("invalid Binding.ReconnectInterval '%s'", binding.ReconnectInterval)
+		}
+		policy.interval = interval
+	}
+	if binding.MaxReconnectInterval != "" {
+		maxInterval, err := time.ParseDuration(binding.MaxReconnectInterval)
+		if err != nil || maxInterval <= 0 {
+			return policy, fmt.Errorf("invalid Binding.MaxReconnectInterval '%s'", binding.MaxReconnectInterval)
+		}
+		policy.maxInterval = maxInterval
+	}
+	if policy.maxInterval < policy.interval {
+		policy.maxInterval = policy.interval
+	}
+	if binding.MaxReconnectAttempts < 0 {
+		return policy, fmt.Errorf("invalid Binding.MaxReconnectAttempts %d", binding.MaxReconnectAttempts)
+	}
+	if binding.MaxReconnectAttempts > 0 {
+		policy.maxAttempts = binding.MaxReconnectAttempts
+	}
+	return policy, nil
+}
+
+// backoff returns the wait before the attempt, doubling from the interval with each attempt up to the max interval
+func (policy reconnectPolicy) backoff(attempt int) time.Duration {
+	wait := policy.interval
+	for i := 1; i < attempt && wait < policy.maxInterval; i++ {
+		wait *= 2
+	}
+	if wait > policy.maxInterval {
+		wait = policy.maxInterval
+	}
+	return wait
+}
+
+// subscription relays the messages of a topic from the client subscribed to it to the channel read by the scheduler,
+// along with its errors. The connection is considered dropped when the client closes its channels or reports errors
+// without receiving a message in between, after which the topic is subscribed again with a new client.
+type subscription struct {
+	topic     types.TopicChannel
+	errors    chan<- error
+	stopped   <-chan struct{}
+	newClient func() (messaging.MessageClient, error)
+	policy    reconnectPolicy
+	health    *connectionHealth
+	logging   logger.LoggingClient
+	// autoAck acknowledges the messages as they are relayed, rather than once processed
+	autoAck bool
+
+	mutex        sync.Mutex
+	client       messaging.MessageClient
+	disconnected bool
+}
+
+// subscribe subscribes the client to the topic, returning the channels receiving its messages and errors
+func (sub *subscription) subscribe(client messaging.MessageClient) (chan types.MessageEnvelope, chan error, error) {
+	messages := make(chan types.MessageEnvelope)
+	clientErrors := make(chan error)
+	if err := client.Subscribe([]types.TopicChannel{{Topic: sub.topic.Topic, Messages: messages}}, clientErrors); err != nil {
+		return nil, nil, fmt.Errorf("unable to subscribe to topic '%s': %v", sub.topic.Topic, err)
+	}
+
+	sub.mutex.Lock()
+	defer sub.mutex.Unlock()
+	if sub.disconnected {
+		go drain(messages, clientErrors)
+		client.Disconnect()
+		return nil, nil, errStopped
+	}
+	sub.client = client
+	return messages, clientErrors, nil
+}
+
+// run relays the messages of the subscribed client, subscribing again whenever the connection drops, until the
+// trigger stops
+func (sub *subscription) run(messages chan types.MessageEnvelope, clientErrors chan error) {
+	// attempts is the number of times the topic was subscribed again without receiving a message since
+	attempts := 0
+	for {
+		dropped, received := sub.relay(messages, clientErrors)
+		if !dropped {
+			// the client closes its channels once disconnected by the trigger
+			drain(messages, clientErrors)
+			return
+		}
+		if received {
+			attempts = 0
+		}
+		sub.logging.Warn(fmt.Sprintf("Connection to the message bus for topic '%s' dropped, subscribing again", sub.topic.Topic))
+		sub.disconnectClient()
+		go drain(messages, clientErrors)
+
+		var ok bool
+		if messages, clientErrors, ok = sub.reconnect(&attempts); !ok {
+			return
+		}
+	}
+}
+
+// reconnect subscribes to the topic with a new client, waiting longer after each failed attempt, until it succeeds or
+// the trigger stops. Failing more than the maximum number of attempts in a row marks the subscriber unhealthy.
+func (sub *subscription) reconnect(attempts *int) (chan types.MessageEnvelope, chan error, bool) {
+	for {
+		*attempts++
+		select {
+		case <-time.After(sub.policy.backoff(*attempts)):
+		case <-sub.stopped:
+			return nil, nil, false
+		}
+
+		client, err := sub.newClient()
+		var messages chan types.MessageEnvelope
+		var clientErrors chan error
+		if err == nil {
+			messages, clientErrors, err = sub.subscribe(client)
+		}
+		switch {
+		case err == errStopped:
+			return nil, nil, false
+		case err == nil:
+			sub.logging.Info(fmt.Sprintf("Subscribed again to topic '%s' after %d attempt(s)", sub.topic.Topic, *attempts))
+			return messages, clientErrors, true
+		case *attempts >= sub.policy.maxAttempts:
+			sub.health.failed(fmt.Errorf("unable to subscribe again after %d attempts: %v", *attempts, err))
+			sub.logging.Error(fmt.Sprintf("Failed to subscribe again to topic '%s' after %d attempts, %v", sub.topic.Topic, *attempts, err))
+		default:
+			sub.health.errored(err)
+			sub.logging.Warn(fmt.Sprintf("Failed to subscribe again to topic '%s', %v", sub.topic.Topic, err))
+		}
+	}
+}
+
+// relay forwards the messages and errors of the client until the connection drops, returning true, or the trigger
+// stops or the client is disconnected, returning false. received reports whether any message was received.
+func (sub *subscription) relay(messages <-chan types.MessageEnvelope, clientErrors <-chan error) (dropped bool, received bool) {
+	failing := false
+	for {
+		select {
+		case message, ok := <-messages:
+			if !ok {
+				return false, received
+			}
+			failing = false
+			received = true
+			select {
+			case sub.topic.Messages <- message:
+			case <-sub.stopped:
+				return false, received
+			}
+			if sub.autoAck {
+				sub.ack(true)
+			}
+		case err, ok := <-clientErrors:
+			if !ok {
+				return false, received
+			}
+			select {
+			case sub.errors <- err:
+			case <-sub.stopped:
+				return false, received
+			}
+			if failing {
+				return true, received
+			}
+			failing = true
+		case <-sub.stopped:
+			return false, received
+		}
+	}
+}
+
+// ack acknowledges the last message relayed to the client subscribed, if it's an Acknowledger
+func (sub *subscription) ack(processed bool) {
+	sub.mutex.Lock()
+	client := sub.client
+	sub.mutex.Unlock()
+	if acknowledger, ok := client.(bus.Acknowledger); ok {
+		if err := acknowledger.Ack(sub.topic.Topic, processed); err != nil {
+			sub.logging.Error(fmt.Sprintf("Failed to acknowledge the message received on topic '%s', %v", sub.topic.Topic, err))
+		}
+	}
+}
+
+// disconnectClient disconnects the client currently subscribed
+func (sub *subscription) disconnectClient() {
+	sub.mutex.Lock()
+	client := sub.client
+	sub.client = nil
+	sub.mutex.Unlock()
+	if client != nil {
+		if err := client.Disconnect(); err != nil {
+			sub.logging.Error(fmt.Sprintf("Failed to disconnect from message bus, %v", err))
+		}
+	}
+}
+
+// disconnect disconnects the client subscribed and prevents subscribing again
+func (sub *subscription) disconnect() {
+	sub.mutex.Lock()
+	sub.disconnected = true
+	sub.mutex.Unlock()
+	sub.disconnectClient()
+}
+
+// drain discards the messages and errors of a client until it closes its channels on disconnecting
+func drain(messages <-chan types.MessageEnvelope, clientErrors <-chan error) {
+	for messages != nil || clientErrors != nil {
+		select {
+		case _, ok := <-messages:
+			if !ok {
+				messages = nil
+			}
+		case _, ok := <-clientErrors:
+			if !ok {
+				clientErrors = nil
+			}
+		}
+	}
+}
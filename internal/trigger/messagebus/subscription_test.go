@@ -0,0 +1,304 @@
+//
+// Copyright (c) 2019 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package messagebus
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/antoniomtz/app-functions-sdk-go/appcontext"
+	"github.com/antoniomtz/app-functions-sdk-go/internal/common"
+	"github.com/antoniomtz/app-functions-sdk-go/internal/runtime"
+	"github.com/antoniomtz/go-mod-messaging/messaging"
+	"github.com/antoniomtz/go-mod-messaging/pkg/types"
+	"github.com/edgexfoundry/go-mod-core-contracts/clients"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// subscribeClient is a client whose subscription is driven by the test, closing its channels when disconnected
+type subscribeClient struct {
+	fakeClient
+	messages     chan types.MessageEnvelope
+	errors       chan error
+	subscribed   chan struct{}
+	disconnected chan struct{}
+}
+
+func newSubscribeClient() *subscribeClient {
+	return &subscribeClient{subscribed: make(chan struct{}), disconnected: make(chan struct{})}
+}
+
+func (client *subscribeClient) Subscribe(topics []types.TopicChannel, messageErrors chan error) error {
+	client.messages = topics[0].Messages
+	client.errors = messageErrors
+	close(client.subscribed)
+	return nil
+}
+
+func (client *subscribeClient) Disconnect() error {
+	close(client.disconnected)
+	close(client.messages)
+	close(client.errors)
+	return nil
+}
+
+// ackClient is a subscribed client which records the acknowledgments of the messages delivered
+type ackClient struct {
+	*subscribeClient
+	mutex sync.Mutex
+	acks  []bool
+}
+
+func (client *ackClient) Ack(topic string, processed bool) error {
+	client.mutex.Lock()
+	defer client.mutex.Unlock()
+	client.acks = append(client.acks, processed)
+	return nil
+}
+
+func (client *ackClient) acknowledged() []bool {
+	client.mutex.Lock()
+	defer client.mutex.Unlock()
+	return append([]bool(nil), client.acks...)
+}
+
+func TestReconnectPolicy(t *testing.T) {
+	policy, err := newReconnectPolicy(common.BindingInfo{})
+	require.NoError(t, err)
+	assert.Equal(t, reconnectPolicy{interval: time.Second, maxInterval: 30 * time.Second, maxAttempts: 5}, policy)
+	assert.Equal(t, time.Second, policy.backoff(1))
+	assert.Equal(t, 2*time.Second, policy.backoff(2))
+	assert.Equal(t, 16*time.Second, policy.backoff(5))
+	assert.Equal(t, 30*time.Second, policy.backoff(6))
+	assert.Equal(t, 30*time.Second, policy.backoff(100))
+
+	policy, err = newReconnectPolicy(common.BindingInfo{ReconnectInterval: "100ms", MaxReconnectInterval: "250ms", MaxReconnectAttempts: 2})
+	require.NoError(t, err)
+	assert.Equal(t, 200*time.Millisecond, policy.backoff(2))
+	assert.Equal(t, 250*time.Millisecond, policy.backoff(3))
+	assert.Equal(t, 2, policy.maxAttempts)
+
+	invalid := []common.BindingInfo{
+		{ReconnectInterval: "soon"},
+		{ReconnectInterval: "-1s"},
+		{MaxReconnectInterval: "later"},
+		{MaxReconnectAttempts: -1},
+	}
+	for _, binding := range invalid {
+		_, err := newReconnectPolicy(binding)
+		assert.Error(t, err, "%+v should be invalid", binding)
+	}
+}
+
+func newTestSubscription(newClient func() (messaging.MessageClient, error), maxAttempts int) (*subscription, chan error, chan struct{}) {
+	messageErrors := make(chan error)
+	stopped := make(chan struct{})
+	return &subscription{
+		topic:     types.TopicChannel{Topic: "events", Messages: make(chan types.MessageEnvelope)},
+		errors:    messageErrors,
+		stopped:   stopped,
+		newClient: newClient,
+		policy:    reconnectPolicy{interval: time.Millisecond, maxInterval: 5 * time.Millisecond, maxAttempts: maxAttempts},
+		health:    &connectionHealth{},
+		logging:   logClient,
+	}, messageErrors, stopped
+}
+
+func TestSubscriptionReconnects(t *testing.T) {
+	first, second := newSubscribeClient(), newSubscribeClient()
+	var mutex sync.Mutex
+	clients := []*subscribeClient{second}
+	newClient := func() (messaging.MessageClient, error) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		client := clients[0]
+		clients = clients[1:]
+		return client, nil
+	}
+	sub, messageErrors, stopped := newTestSubscription(newClient, 3)
+
+	messages, clientErrors, err := sub.subscribe(first)
+	require.NoError(t, err)
+	go sub.run(messages, clientErrors)
+
+	first.messages <- types.MessageEnvelope{CorrelationID: "1"}
+	assert.Equal(t, "1", (<-sub.topic.Messages).CorrelationID)
+
+	// a single error doesn't drop the connection
+	first.errors <- errors.New("undecodable")
+	assert.Error(t, <-messageErrors)
+	first.messages <- types.MessageEnvelope{CorrelationID: "2"}
+	assert.Equal(t, "2", (<-sub.topic.Messages).CorrelationID)
+
+	// errors without a message in between do
+	first.errors <- errors.New("connection lost")
+	<-messageErrors
+	first.errors <- errors.New("connection lost")
+	<-messageErrors
+	select {
+	case <-second.subscribed:
+	case <-time.After(time.Second):
+		t.Fatal("topic should be subscribed again with a new client")
+	}
+	<-first.disconnected
+
+	second.messages <- types.MessageEnvelope{CorrelationID: "3"}
+	assert.Equal(t, "3", (<-sub.topic.Messages).CorrelationID)
+	assert.True(t, sub.health.snapshot("subscriber").Healthy)
+
+	close(stopped)
+	sub.disconnect()
+	<-second.disconnected
+}
+
+func TestSubscriptionMarksUnhealthy(t *testing.T) {
+	first := newSubscribeClient()
+	attempts := make(chan struct{}, 10)
+	newClient := func() (messaging.MessageClient, error) {
+		attempts <- struct{}{}
+		return nil, errors.New("broker unreachable")
+	}
+	sub, messageErrors, stopped := newTestSubscription(newClient, 2)
+
+	messages, clientErrors, err := sub.subscribe(first)
+	require.NoError(t, err)
+	go sub.run(messages, clientErrors)
+
+	first.errors <- errors.New("connection lost")
+	<-messageErrors
+	first.errors <- errors.New("connection lost")
+	<-messageErrors
+
+	<-attempts
+	<-attempts
+	// the second failed attempt marks the subscriber unhealthy, which is recorded before the third attempt
+	<-attempts
+	status := sub.health.snapshot("subscriber")
+	assert.False(t, status.Healthy)
+	assert.Contains(t, status.LastError, "unable to subscribe again after")
+
+	close(stopped)
+	sub.disconnect()
+}
+
+func TestSubscriptionStopsWhileReconnecting(t *testing.T) {
+	first := newSubscribeClient()
+	sub, messageErrors, stopped := newTestSubscription(func() (messaging.MessageClient, error) {
+		return nil, errors.New("broker unreachable")
+	}, 2)
+	sub.policy.interval, sub.policy.maxInterval = time.Hour, time.Hour
+
+	messages, clientErrors, err := sub.subscribe(first)
+	require.NoError(t, err)
+	done := make(chan struct{})
+	go func() {
+		sub.run(messages, clientErrors)
+		close(done)
+	}()
+
+	first.errors <- errors.New("connection lost")
+	<-messageErrors
+	first.errors <- errors.New("connection lost")
+	<-messageErrors
+	<-first.disconnected
+
+	close(stopped)
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("subscription should stop waiting to reconnect once the trigger stops")
+	}
+}
+
+func TestSubscriptionAutoAck(t *testing.T) {
+	client := &ackClient{subscribeClient: newSubscribeClient()}
+	sub, _, stopped := newTestSubscription(nil, 1)
+	sub.autoAck = true
+
+	messages, clientErrors, err := sub.subscribe(client)
+	require.NoError(t, err)
+	go sub.run(messages, clientErrors)
+
+	client.messages <- types.MessageEnvelope{CorrelationID: "1"}
+	<-sub.topic.Messages
+	client.messages <- types.MessageEnvelope{CorrelationID: "2"}
+	<-sub.topic.Messages
+	// the first message is acknowledged before the second is relayed
+	acks := client.acknowledged()
+	require.NotEmpty(t, acks, "messages should be acknowledged as they are relayed")
+	assert.True(t, acks[0])
+	close(stopped)
+	sub.disconnect()
+}
+
+func TestProcessMessageAcknowledges(t *testing.T) {
+	fail := false
+	transform := func(edgexcontext *appcontext.Context, params ...interface{}) (bool, interface{}) {
+		if fail {
+			return false, errors.New("export failed")
+		}
+		return true, nil
+	}
+	client := &ackClient{subscribeClient: newSubscribeClient()}
+	sub, _, _ := newTestSubscription(nil, 1)
+	_, _, err := sub.subscribe(client)
+	require.NoError(t, err)
+	trigger := Trigger{
+		Runtime:       &runtime.GolangRuntime{Transforms: []func(*appcontext.Context, ...interface{}) (bool, interface{}){transform}},
+		logging:       logClient,
+		ackMode:       AckModePipeline,
+		subscriptions: []*subscription{sub},
+	}
+	event := types.MessageEnvelope{CorrelationID: "1", Payload: []byte(`{"device":"thermostat"}`), ContentType: clients.ContentTypeJSON}
+
+	trigger.processMessage(event, "events")
+	fail = true
+	trigger.processMessage(event, "events")
+	trigger.processMessage(types.MessageEnvelope{CorrelationID: "2", Payload: []byte("38"), ContentType: "text/plain"}, "events")
+	assert.Equal(t, []bool{true, false, true}, client.acknowledged(), "failed messages should be delivered again, rejected ones not")
+
+	trigger.ackMode = AckModeAuto
+	trigger.processMessage(event, "events")
+	assert.Len(t, client.acknowledged(), 3, "messages are acknowledged as they are received in auto mode")
+}
+
+func TestInitializeAckMode(t *testing.T) {
+	invalid := []common.BindingInfo{
+		{Type: "messagebus", SubscribeTopic: "events", AckMode: "sometimes"},
+		{Type: "messagebus", SubscribeTopic: "events", AckMode: AckModePipeline},
+		{Type: "messagebus", SubscribeTopic: "events", AckMode: AckModePipeline, QueueSize: 10},
+	}
+	for _, binding := range invalid {
+		trigger := Trigger{
+			Configuration: common.ConfigurationStruct{Binding: binding, MessageBus: types.MessageBusConfig{Type: "zero"}},
+			Runtime:       &runtime.GolangRuntime{},
+		}
+		assert.Error(t, trigger.Initialize(logClient), "%+v should fail", binding)
+	}
+
+	trigger := Trigger{
+		Configuration: common.ConfigurationStruct{Binding: common.BindingInfo{Type: "messagebus", SubscribeTopic: "events", AckMode: AckModePipeline, Workers: 4}, MessageBus: types.MessageBusConfig{Type: "zero"}},
+		Runtime:       &runtime.GolangRuntime{},
+	}
+	err := trigger.Initialize(logClient)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "Binding.Workers")
+	}
+}
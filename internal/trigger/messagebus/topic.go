@@ -0,0 +1,112 @@
+//
+// Copyright (c) 2019 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package messagebus
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/antoniomtz/app-functions-sdk-go/appcontext"
+	"github.com/edgexfoundry/go-mod-core-contracts/clients"
+	"github.com/edgexfoundry/go-mod-core-contracts/clients/logger"
+	"github.com/edgexfoundry/go-mod-core-contracts/clients/metadata"
+)
+
+const (
+	// DeviceNamePlaceholder in Binding.PublishTopic is replaced with the name of the device of the processed event
+	DeviceNamePlaceholder = "{device-name}"
+	// ProfilePlaceholder in Binding.PublishTopic is replaced with the name of the device profile of the device of the
+	// processed event, looked up in core-metadata
+	ProfilePlaceholder = "{profile}"
+	// UnknownTopicLevel replaces a placeholder which can't be resolved, i.e. for output without an event
+	UnknownTopicLevel = "unknown"
+)
+
+// profileCacheTTL is how long the profile of a device is cached before it's looked up again
+const profileCacheTTL = 5 * time.Minute
+
+// topicTemplate resolves the placeholders of the publish topic from the processed event, so subscribers can filter
+// the output by topic
+type topicTemplate struct {
+	topic    string
+	devices  metadata.DeviceClient
+	logging  logger.LoggingClient
+	mutex    sync.Mutex
+	profiles map[string]cachedProfile
+}
+
+type cachedProfile struct {
+	name    string
+	expires time.Time
+}
+
+func newTopicTemplate(topic string, devices metadata.DeviceClient, logging logger.LoggingClient) *topicTemplate {
+	return &topicTemplate{topic: topic, devices: devices, logging: logging, profiles: make(map[string]cachedProfile)}
+}
+
+// templated returns whether the topic has placeholders
+func (template *topicTemplate) templated() bool {
+	return strings.Contains(template.topic, DeviceNamePlaceholder) || strings.Contains(template.topic, ProfilePlaceholder)
+}
+
+// resolve returns the topic with the placeholders replaced by the values of the event processed in the context, or
+// by UnknownTopicLevel when they can't be resolved. The context may be nil for output without an event.
+func (template *topicTemplate) resolve(edgexContext *appcontext.Context) string {
+	if !template.templated() {
+		return template.topic
+	}
+
+	deviceName, profile := UnknownTopicLevel, UnknownTopicLevel
+	if edgexContext != nil && edgexContext.DeviceName != "" {
+		deviceName = edgexContext.DeviceName
+		if strings.Contains(template.topic, ProfilePlaceholder) {
+			profile = template.profile(edgexContext)
+		}
+	}
+	return strings.NewReplacer(DeviceNamePlaceholder, deviceName, ProfilePlaceholder, profile).Replace(template.topic)
+}
+
+// profile returns the name of the profile of the device of the event, caching it for profileCacheTTL
+func (template *topicTemplate) profile(edgexContext *appcontext.Context) string {
+	if template.devices == nil {
+		return UnknownTopicLevel
+	}
+
+	template.mutex.Lock()
+	cached, found := template.profiles[edgexContext.DeviceName]
+	template.mutex.Unlock()
+	if found && time.Now().Before(cached.expires) {
+		return cached.name
+	}
+
+	device, err := template.devices.DeviceForName(edgexContext.DeviceName, edgexContext.RequestContext())
+	if err != nil || device.Profile.Name == "" {
+		if err == nil {
+			err = errors.New("device has no profile")
+		}
+		template.logging.Error(fmt.Sprintf("Failed to look up the profile of device %s for the publish topic: %s", edgexContext.DeviceName, err.Error()), clients.CorrelationHeader, edgexContext.CorrelationID)
+		return UnknownTopicLevel
+	}
+
+	template.mutex.Lock()
+	template.profiles[edgexContext.DeviceName] = cachedProfile{name: device.Profile.Name, expires: time.Now().Add(profileCacheTTL)}
+	template.mutex.Unlock()
+	return device.Profile.Name
+}
@@ -0,0 +1,95 @@
+//
+// Copyright (c) 2019 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package messagebus
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/antoniomtz/app-functions-sdk-go/appcontext"
+	"github.com/antoniomtz/app-functions-sdk-go/internal/common"
+	"github.com/antoniomtz/app-functions-sdk-go/internal/runtime"
+	"github.com/antoniomtz/go-mod-messaging/pkg/types"
+	"github.com/edgexfoundry/go-mod-core-contracts/clients"
+	"github.com/edgexfoundry/go-mod-core-contracts/clients/metadata"
+	"github.com/edgexfoundry/go-mod-core-contracts/models"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeDeviceClient struct {
+	metadata.DeviceClient
+	profiles map[string]string
+	lookups  int
+}
+
+func (client *fakeDeviceClient) DeviceForName(name string, ctx context.Context) (models.Device, error) {
+	client.lookups++
+	profile, found := client.profiles[name]
+	if !found {
+		return models.Device{}, errors.New("device not found")
+	}
+	return models.Device{Name: name, Profile: models.DeviceProfile{Name: profile}}, nil
+}
+
+func TestTopicTemplate(t *testing.T) {
+	devices := &fakeDeviceClient{profiles: map[string]string{"thermostat": "Honeywell-Thermostat"}}
+	edgexContext := &appcontext.Context{LoggingClient: logClient, DeviceName: "thermostat"}
+
+	template := newTopicTemplate("edgex/output", devices, logClient)
+	assert.False(t, template.templated())
+	assert.Equal(t, "edgex/output", template.resolve(edgexContext))
+
+	template = newTopicTemplate("edgex/{profile}/{device-name}", devices, logClient)
+	assert.True(t, template.templated())
+	assert.Equal(t, "edgex/Honeywell-Thermostat/thermostat", template.resolve(edgexContext))
+	assert.Equal(t, "edgex/Honeywell-Thermostat/thermostat", template.resolve(edgexContext))
+	assert.Equal(t, 1, devices.lookups, "the profile should be cached")
+
+	unknownDevice := &appcontext.Context{LoggingClient: logClient, DeviceName: "removed"}
+	assert.Equal(t, "edgex/unknown/removed", template.resolve(unknownDevice), "a profile which can't be looked up should be unknown")
+	assert.Equal(t, "edgex/unknown/unknown", template.resolve(nil))
+	assert.Equal(t, "edgex/unknown/unknown", template.resolve(&appcontext.Context{LoggingClient: logClient}), "output without an event should be published as unknown")
+
+	template = newTopicTemplate("edgex/{profile}", nil, logClient)
+	assert.Equal(t, "edgex/unknown", template.resolve(edgexContext), "the profile should be unknown without the Metadata client")
+}
+
+func TestProcessMessagePublishesToTemplatedTopic(t *testing.T) {
+	client := &fakeClient{}
+	transform := func(edgexcontext *appcontext.Context, params ...interface{}) (bool, interface{}) {
+		edgexcontext.Complete([]byte("Transformed"))
+		return false, nil
+	}
+	trigger := Trigger{
+		Configuration: common.ConfigurationStruct{Binding: common.BindingInfo{PublishTopic: "edgex/{device-name}"}},
+		Runtime:       &runtime.GolangRuntime{Transforms: []func(*appcontext.Context, ...interface{}) (bool, interface{}){transform}},
+		logging:       logClient,
+		publisher:     newPublisher(client, "edgex/unknown", 0, logClient),
+		topic:         newTopicTemplate("edgex/{device-name}", nil, logClient),
+	}
+
+	trigger.processMessage(types.MessageEnvelope{
+		CorrelationID: "123",
+		Payload:       []byte(`{"device":"thermostat","readings":[{"name":"temperature","value":"38"}]}`),
+		ContentType:   clients.ContentTypeJSON,
+	}, "events")
+	trigger.publisher.stop()
+
+	assert.Equal(t, []string{"123"}, client.published)
+	assert.Equal(t, []string{"edgex/thermostat"}, client.topics)
+}
@@ -0,0 +1,88 @@
+//
+// Copyright (c) 2019 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package messagebus
+
+import (
+	"hash/fnv"
+	"sync"
+
+	"github.com/antoniomtz/app-functions-sdk-go/internal/runtime"
+	"github.com/antoniomtz/go-mod-messaging/pkg/types"
+)
+
+// workerPool processes messages on several goroutines. When ordered, the messages of a device are always processed
+// by the same worker, so they are processed in the order received while the messages of other devices are processed
+// in parallel.
+type workerPool struct {
+	queues  []chan receivedMessage
+	ordered bool
+	// decode decodes the message to find its device, which picks its worker when ordered
+	decode func(types.MessageEnvelope) *runtime.DecodedMessage
+	wait   sync.WaitGroup
+}
+
+type receivedMessage struct {
+	envelope types.MessageEnvelope
+	topic    string
+	// decoded is the message decoded to pick its worker, so it isn't decoded again when processed, or nil
+	decoded *runtime.DecodedMessage
+}
+
+func newWorkerPool(workers int, ordered bool, decode func(types.MessageEnvelope) *runtime.DecodedMessage, process func(receivedMessage)) *workerPool {
+	pool := &workerPool{ordered: ordered, decode: decode}
+	// without ordering the workers share a single queue, so a message goes to the first idle worker
+	queues := 1
+	if ordered {
+		queues = workers
+	}
+	for index := 0; index < queues; index++ {
+		pool.queues = append(pool.queues, make(chan receivedMessage))
+	}
+
+	for index := 0; index < workers; index++ {
+		queue := pool.queues[index%queues]
+		pool.wait.Add(1)
+		go func() {
+			defer pool.wait.Done()
+			for message := range queue {
+				process(message)
+			}
+		}()
+	}
+	return pool
+}
+
+// dispatch hands the message to a worker, waiting until the worker is ready for it
+func (pool *workerPool) dispatch(envelope types.MessageEnvelope, topic string) {
+	message := receivedMessage{envelope: envelope, topic: topic}
+	queue := pool.queues[0]
+	if pool.ordered {
+		message.decoded = pool.decode(envelope)
+		hash := fnv.New32a()
+		hash.Write([]byte(message.decoded.Device()))
+		queue = pool.queues[hash.Sum32()%uint32(len(pool.queues))]
+	}
+	queue <- message
+}
+
+// stop waits for the workers to process the messages dispatched to them
+func (pool *workerPool) stop() {
+	for _, queue := range pool.queues {
+		close(queue)
+	}
+	pool.wait.Wait()
+}
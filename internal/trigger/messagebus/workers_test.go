@@ -0,0 +1,102 @@
+//
+// Copyright (c) 2019 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package messagebus
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/antoniomtz/app-functions-sdk-go/internal/runtime"
+	"github.com/antoniomtz/go-mod-messaging/pkg/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func eventOf(device string) []byte {
+	return []byte(`{"device":"` + device + `"}`)
+}
+
+func decodeUnordered(t *testing.T) func(types.MessageEnvelope) *runtime.DecodedMessage {
+	return func(types.MessageEnvelope) *runtime.DecodedMessage {
+		t.Error("messages shouldn't be decoded to pick their worker when unordered")
+		return nil
+	}
+}
+
+func TestWorkerPoolParallel(t *testing.T) {
+	release := make(chan struct{})
+	started := make(chan string, 3)
+	process := func(message receivedMessage) {
+		started <- message.envelope.CorrelationID
+		<-release
+	}
+	pool := newWorkerPool(3, false, decodeUnordered(t), process)
+
+	for _, id := range []string{"1", "2", "3"} {
+		pool.dispatch(types.MessageEnvelope{CorrelationID: id, Payload: []byte("a")}, "events")
+	}
+	for i := 0; i < 3; i++ {
+		select {
+		case <-started:
+		case <-time.After(time.Second):
+			t.Fatal("messages should be processed in parallel")
+		}
+	}
+
+	close(release)
+	pool.stop()
+}
+
+func TestWorkerPoolPreservesDeviceOrder(t *testing.T) {
+	var mutex sync.Mutex
+	processed := map[string][]string{}
+	process := func(message receivedMessage) {
+		// let later messages overtake this one if they are processed by another worker
+		time.Sleep(time.Millisecond)
+		mutex.Lock()
+		defer mutex.Unlock()
+		// the message decoded to pick the worker is handed to it
+		device := message.decoded.Device()
+		processed[device] = append(processed[device], message.envelope.CorrelationID)
+	}
+	pool := newWorkerPool(4, true, (&runtime.GolangRuntime{}).DecodeMessage, process)
+
+	expected := map[string][]string{}
+	for i := 0; i < 20; i++ {
+		for _, device := range []string{"a", "b", "c"} {
+			id := device + string(rune('A'+i))
+			pool.dispatch(types.MessageEnvelope{CorrelationID: id, Payload: eventOf(device), ContentType: "application/json"}, "events")
+			expected[device] = append(expected[device], id)
+		}
+	}
+	pool.stop()
+
+	assert.Equal(t, expected, processed)
+}
+
+func TestWorkerPoolStopWaits(t *testing.T) {
+	done := false
+	process := func(message receivedMessage) {
+		time.Sleep(20 * time.Millisecond)
+		done = true
+	}
+	pool := newWorkerPool(2, false, decodeUnordered(t), process)
+
+	pool.dispatch(types.MessageEnvelope{Payload: []byte("a")}, "events")
+	pool.stop()
+	assert.True(t, done, "stop should wait for the messages being processed")
+}
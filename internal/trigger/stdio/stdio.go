@@ -0,0 +1,165 @@
+//
+// Copyright (c) 2019 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package stdio
+
+import (
+	"bufio"
+	"bytes"
+	syscontext "context"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/antoniomtz/app-functions-sdk-go/appcontext"
+	"github.com/antoniomtz/app-functions-sdk-go/internal/common"
+	"github.com/antoniomtz/app-functions-sdk-go/internal/runtime"
+	"github.com/antoniomtz/app-functions-sdk-go/pkg/secrets"
+	"github.com/antoniomtz/go-mod-messaging/pkg/types"
+	"github.com/edgexfoundry/go-mod-core-contracts/clients"
+	"github.com/edgexfoundry/go-mod-core-contracts/clients/command"
+	"github.com/edgexfoundry/go-mod-core-contracts/clients/coredata"
+	"github.com/edgexfoundry/go-mod-core-contracts/clients/logger"
+	"github.com/edgexfoundry/go-mod-core-contracts/clients/notifications"
+)
+
+// maxLineSize is the largest event accepted on a single input line
+const maxLineSize = 16 * 1024 * 1024
+
+// Trigger implements Trigger to support reading newline delimited JSON events from stdin and writing the output
+// data of the pipeline to stdout, one line per event, so the service can be used as a step in shell pipelines.
+type Trigger struct {
+	Configuration       common.ConfigurationStruct
+	Runtime             *runtime.GolangRuntime
+	EventClient         coredata.EventClient
+	CommandClient       command.CommandClient
+	NotificationsClient notifications.NotificationsClient
+	SecretProvider      secrets.SecretProvider
+	Context             syscontext.Context
+	Input               io.Reader
+	Output              io.Writer
+	logging             logger.LoggingClient
+	done                chan struct{}
+	stopped             chan struct{}
+	stopOnce            sync.Once
+}
+
+// Initialize starts reading events from the input
+func (trigger *Trigger) Initialize(logger logger.LoggingClient) error {
+	trigger.logging = logger
+	trigger.logging.Info("Initializing stdio Trigger")
+	if trigger.Input == nil {
+		trigger.Input = os.Stdin
+	}
+	if trigger.Output == nil {
+		trigger.Output = os.Stdout
+	}
+
+	trigger.done = make(chan struct{})
+	trigger.stopped = make(chan struct{})
+	go func() {
+		defer close(trigger.done)
+		if err := trigger.readEvents(); err != nil {
+			trigger.logging.Error(fmt.Sprintf("Failed to read events from stdin: %v", err))
+		}
+		trigger.logging.Info("End of stdin reached")
+	}()
+
+	trigger.logging.Info("stdio Trigger Initialized")
+	return nil
+}
+
+// Done is closed once all input has been processed
+func (trigger *Trigger) Done() <-chan struct{} {
+	return trigger.done
+}
+
+// Stop stops reading events from the input. The event being processed, if any, is still written to the output.
+func (trigger *Trigger) Stop() error {
+	trigger.stopOnce.Do(func() {
+		close(trigger.stopped)
+	})
+	return nil
+}
+
+func (trigger *Trigger) readEvents() error {
+	scanner := bufio.NewScanner(trigger.Input)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxLineSize)
+
+	for {
+		// leave new lines unread while intake is paused
+		trigger.Runtime.WaitWhilePaused()
+		if !scanner.Scan() {
+			break
+		}
+		select {
+		case <-trigger.stopped:
+			trigger.logging.Info("stdio Trigger stopped")
+			return nil
+		default:
+		}
+
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+
+		trigger.logging.Debug("Received message from stdin", "byte count", len(line))
+
+		edgexContext := &appcontext.Context{
+			Configuration:       trigger.Configuration,
+			LoggingClient:       trigger.logging,
+			EventClient:         trigger.EventClient,
+			CommandClient:       trigger.CommandClient,
+			NotificationsClient: trigger.NotificationsClient,
+			SecretProvider:      trigger.SecretProvider,
+			Ctx:                 trigger.Context,
+		}
+		envelope := types.MessageEnvelope{
+			ContentType: clients.ContentTypeJSON,
+			// the scanner reuses its buffer for the next line
+			Payload: append([]byte{}, line...),
+		}
+
+		trigger.Runtime.ProcessEvent(edgexContext, envelope)
+		if edgexContext.OutputData != nil {
+			if err := trigger.writeOutput(edgexContext.OutputData); err != nil {
+				return err
+			}
+			trigger.logging.Trace("Wrote output to stdout", clients.CorrelationHeader, edgexContext.CorrelationID)
+		}
+	}
+
+	return scanner.Err()
+}
+
+// writeOutput writes the data as a single line
+func (trigger *Trigger) writeOutput(data []byte) error {
+	if !bytes.HasSuffix(data, []byte("\n")) {
+		data = append(data, '\n')
+	}
+	_, err := trigger.Output.Write(data)
+	return err
+}
+
+// ReserveStdout redirects os.Stdout to os.Stderr, returning the original stdout. Log messages are written to
+// os.Stdout, so this must be called before the logging client is created to keep them out of the pipeline output.
+func ReserveStdout() *os.File {
+	stdout := os.Stdout
+	os.Stdout = os.Stderr
+	return stdout
+}
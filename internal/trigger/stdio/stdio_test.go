@@ -0,0 +1,82 @@
+//
+// Copyright (c) 2019 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package stdio
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/antoniomtz/app-functions-sdk-go/appcontext"
+	"github.com/antoniomtz/app-functions-sdk-go/internal/runtime"
+	"github.com/edgexfoundry/go-mod-core-contracts/clients/logger"
+	"github.com/edgexfoundry/go-mod-core-contracts/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var logClient = logger.NewClient("app_functions_sdk_go", false, "./test.log", "DEBUG")
+
+func TestStdioTrigger(t *testing.T) {
+	var devices []string
+	transform := func(edgexcontext *appcontext.Context, params ...interface{}) (bool, interface{}) {
+		event := params[0].(models.Event)
+		devices = append(devices, event.Device)
+		if event.Device != "skipped" {
+			edgexcontext.SetResponseData([]byte(event.Device + "-out"))
+		}
+		return false, nil
+	}
+	runtime := &runtime.GolangRuntime{Transforms: []func(*appcontext.Context, ...interface{}) (bool, interface{}){transform}}
+
+	input := strings.NewReader(`{"device":"device1","readings":[{"name":"temp","value":"20"}]}
+
+{"device":"skipped"}
+not json
+{"device":"device2"}`)
+	output := &bytes.Buffer{}
+
+	trigger := Trigger{Runtime: runtime, Input: input, Output: output}
+	require.NoError(t, trigger.Initialize(logClient))
+
+	select {
+	case <-trigger.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("Trigger should finish once input ends")
+	}
+
+	assert.Equal(t, []string{"device1", "skipped", "device2"}, devices, "Blank and invalid lines should be skipped")
+	assert.Equal(t, "device1-out\ndevice2-out\n", output.String())
+}
+
+func TestStdioTriggerStop(t *testing.T) {
+	processed := 0
+	transform := func(edgexcontext *appcontext.Context, params ...interface{}) (bool, interface{}) {
+		processed++
+		return false, nil
+	}
+	runtime := &runtime.GolangRuntime{Transforms: []func(*appcontext.Context, ...interface{}) (bool, interface{}){transform}}
+
+	trigger := Trigger{Runtime: runtime, Input: strings.NewReader("{\"device\":\"device1\"}\n{\"device\":\"device2\"}\n"), Output: &bytes.Buffer{}}
+	trigger.stopped = make(chan struct{})
+	require.NoError(t, trigger.Stop())
+	require.NoError(t, trigger.Stop())
+	trigger.logging = logClient
+	require.NoError(t, trigger.readEvents())
+	assert.Equal(t, 0, processed, "no events should be read once stopped")
+}
@@ -17,6 +17,8 @@
 package trigger
 
 import (
+	"time"
+
 	"github.com/edgexfoundry/go-mod-core-contracts/clients/logger"
 )
 
@@ -25,3 +27,42 @@ type Trigger interface {
 	// Initialize performs post creation initializations
 	Initialize(logger.LoggingClient) error
 }
+
+// Finisher is implemented by triggers whose input can end, such as the stdio trigger, after which the service exits
+type Finisher interface {
+	// Done is closed once the trigger has processed all of its input
+	Done() <-chan struct{}
+}
+
+// Stopper is implemented by triggers which can stop receiving events, so the service can shut down gracefully.
+// Events received before Stop is called are still processed.
+type Stopper interface {
+	// Stop stops receiving new events
+	Stop() error
+}
+
+// ConnectionStatus reports the health of a connection of a trigger, i.e. its subscriber or publisher connection to
+// the message bus
+type ConnectionStatus struct {
+	Name string `json:"name"`
+	// Healthy is false when the last operation on the connection failed
+	Healthy bool `json:"healthy"`
+	// Messages is the number of messages received or published
+	Messages uint64 `json:"messages"`
+	// Errors is the number of operations which failed
+	Errors        uint64    `json:"errors"`
+	LastError     string    `json:"lastError,omitempty"`
+	LastErrorTime time.Time `json:"lastErrorTime,omitempty"`
+	// Pending is the number of messages waiting to be published
+	Pending int `json:"pending,omitempty"`
+	// Endpoint is the server in use, i.e. the failover broker an export function switched to
+	Endpoint string `json:"endpoint,omitempty"`
+	// Failovers is the number of times the connection switched to another server
+	Failovers uint64 `json:"failovers,omitempty"`
+}
+
+// StatusReporter is implemented by triggers which report the health of their connections
+type StatusReporter interface {
+	// Connections returns the status of each connection of the trigger
+	Connections() []ConnectionStatus
+}
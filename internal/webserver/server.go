@@ -17,13 +17,21 @@
 package webserver
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/antoniomtz/app-functions-sdk-go/internal/telemetry"
 
+	"github.com/antoniomtz/app-functions-sdk-go/internal"
 	"github.com/antoniomtz/app-functions-sdk-go/internal/common"
+	"github.com/antoniomtz/app-functions-sdk-go/internal/runtime"
+	"github.com/antoniomtz/app-functions-sdk-go/internal/trigger"
+	"github.com/antoniomtz/app-functions-sdk-go/pkg/certs"
 	"github.com/edgexfoundry/go-mod-core-contracts/clients"
 	"github.com/edgexfoundry/go-mod-core-contracts/clients/logger"
 
@@ -34,7 +42,36 @@ import (
 type WebServer struct {
 	Config        *common.ConfigurationStruct
 	LoggingClient logger.LoggingClient
+	Runtime       *runtime.GolangRuntime
 	router        *mux.Router
+	serverMutex   sync.Mutex
+	server        *http.Server
+	// Connections returns the health of the connections of the trigger, and is nil for triggers without connections
+	Connections func() []trigger.ConnectionStatus
+	// Manifest holds the exported events which downstream systems reconcile, and is nil when not configured
+	Manifest *runtime.ExportManifest
+	// ExportConnections returns the health of the connections kept open by export functions, and is nil when there
+	// are none
+	ExportConnections func() []trigger.ConnectionStatus
+	// StoreForward holds the data of the failed exports stored for store and forward, and is nil when not enabled
+	StoreForward *runtime.ExportTracker
+}
+
+// healthStatus reports the health of the connections of the trigger and of the export functions
+type healthStatus struct {
+	Healthy     bool                       `json:"healthy"`
+	Connections []trigger.ConnectionStatus `json:"connections"`
+}
+
+// storedRetry reports the outcome of exporting the data stored for store and forward again
+type storedRetry struct {
+	Exported int `json:"exported"`
+	Depth    int `json:"depth"`
+}
+
+// storedPurge reports the number of failed exports purged from store and forward
+type storedPurge struct {
+	Purged int `json:"purged"`
 }
 
 // Test if the service is working
@@ -43,8 +80,86 @@ func (webserver *WebServer) pingHandler(writer http.ResponseWriter, _ *http.Requ
 	writer.Write([]byte("pong"))
 }
 
+// healthHandler reports the health of the connections of the trigger and the export functions, responding with a 503
+// status when any of them is unhealthy
+func (webserver *WebServer) healthHandler(writer http.ResponseWriter, _ *http.Request) {
+	health := healthStatus{Healthy: true, Connections: []trigger.ConnectionStatus{}}
+	if webserver.Connections != nil {
+		health.Connections = append(health.Connections, webserver.Connections()...)
+	}
+	if webserver.ExportConnections != nil {
+		health.Connections = append(health.Connections, webserver.ExportConnections()...)
+	}
+	for _, connection := range health.Connections {
+		if !connection.Healthy {
+			health.Healthy = false
+		}
+	}
+
+	if !health.Healthy {
+		writer.Header().Add("Content-Type", "application/json")
+		writer.WriteHeader(http.StatusServiceUnavailable)
+		json.NewEncoder(writer).Encode(health)
+		return
+	}
+	webserver.encode(health, writer)
+}
+
+// configHandler responds with the configuration, along with the Writable configuration currently in use
 func (webserver *WebServer) configHandler(writer http.ResponseWriter, _ *http.Request) {
-	webserver.encode(webserver.Config, writer)
+	config := *webserver.Config
+	if webserver.Runtime != nil && webserver.Runtime.Writable != nil {
+		config.Writable = webserver.Runtime.Writable.Get()
+	}
+	config.MessageBus.Optional = redactCredentials(config.MessageBus.Optional)
+	if config.Writable.Pipeline.Functions != nil {
+		functions := make(map[string]common.PipelineFunction, len(config.Writable.Pipeline.Functions))
+		for name, function := range config.Writable.Pipeline.Functions {
+			functions[name] = common.PipelineFunction{Parameters: redactCredentials(function.Parameters)}
+		}
+		config.Writable.Pipeline.Functions = functions
+	}
+	webserver.encode(config, writer)
+}
+
+// redactedCredential replaces the value of the credentials returned by the config route
+const redactedCredential = "<redacted>"
+
+// redactCredentials returns a copy of the parameters with the value of those which hold a credential, i.e. a
+// Password, redacted, so the config route doesn't expose them
+func redactCredentials(parameters map[string]string) map[string]string {
+	if parameters == nil {
+		return nil
+	}
+	redacted := make(map[string]string, len(parameters))
+	for name, value := range parameters {
+		if value != "" && isCredential(name) {
+			value = redactedCredential
+		}
+		redacted[name] = value
+	}
+	return redacted
+}
+
+// isCredential returns whether the parameter holds a credential, going by its name
+func isCredential(name string) bool {
+	name = strings.ToLower(name)
+	for _, suffix := range []string{"password", "token", "apikey", "secret", "secretkey"} {
+		if strings.HasSuffix(name, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// VersionResponse is the response of the version route
+type VersionResponse struct {
+	Version    string `json:"version"`
+	SDKVersion string `json:"sdk_version"`
+}
+
+func (webserver *WebServer) versionHandler(writer http.ResponseWriter, _ *http.Request) {
+	webserver.encode(VersionResponse{Version: internal.ApplicationVersion, SDKVersion: internal.SDKVersion}, writer)
 }
 
 // Helper function for encoding things for returning from REST calls
@@ -69,6 +184,221 @@ func (webserver *WebServer) metricsHandler(writer http.ResponseWriter, _ *http.R
 	return
 }
 
+func (webserver *WebServer) pipelineMetricsHandler(writer http.ResponseWriter, _ *http.Request) {
+	if webserver.Runtime == nil {
+		http.Error(writer, "Functions pipeline not running", http.StatusServiceUnavailable)
+		return
+	}
+
+	webserver.encode(webserver.Runtime.PipelineMetrics(), writer)
+}
+
+func (webserver *WebServer) stageMetricsHandler(writer http.ResponseWriter, _ *http.Request) {
+	if webserver.Runtime == nil {
+		http.Error(writer, "Functions pipeline not running", http.StatusServiceUnavailable)
+		return
+	}
+
+	webserver.encode(webserver.Runtime.StageProfiles(), writer)
+}
+
+func (webserver *WebServer) functionMetricsHandler(writer http.ResponseWriter, _ *http.Request) {
+	if webserver.Runtime == nil {
+		http.Error(writer, "Functions pipeline not running", http.StatusServiceUnavailable)
+		return
+	}
+
+	webserver.encode(webserver.Runtime.FunctionMetrics(), writer)
+}
+
+func (webserver *WebServer) cacheMetricsHandler(writer http.ResponseWriter, _ *http.Request) {
+	if webserver.Runtime == nil {
+		http.Error(writer, "Functions pipeline not running", http.StatusServiceUnavailable)
+		return
+	}
+
+	webserver.encode(webserver.Runtime.CacheMetrics(), writer)
+}
+
+func (webserver *WebServer) concurrencyMetricsHandler(writer http.ResponseWriter, _ *http.Request) {
+	if webserver.Runtime == nil {
+		http.Error(writer, "Functions pipeline not running", http.StatusServiceUnavailable)
+		return
+	}
+
+	webserver.encode(webserver.Runtime.ConcurrencyMetrics(), writer)
+}
+
+// namedMetricsHandler returns the metrics of the functions of the pipelines provided under the name of the route
+func (webserver *WebServer) namedMetricsHandler(writer http.ResponseWriter, request *http.Request) {
+	if webserver.Runtime == nil {
+		http.Error(writer, "Functions pipeline not running", http.StatusServiceUnavailable)
+		return
+	}
+
+	name := mux.Vars(request)["name"]
+	provider, ok := webserver.Runtime.MetricsProviders[name]
+	if !ok {
+		http.Error(writer, fmt.Sprintf("No metrics named '%s'", name), http.StatusNotFound)
+		return
+	}
+	webserver.encode(provider(), writer)
+}
+
+func (webserver *WebServer) errorLogHandler(writer http.ResponseWriter, _ *http.Request) {
+	if webserver.Runtime == nil || webserver.Runtime.ErrorLog == nil {
+		http.Error(writer, "Error log not enabled", http.StatusNotFound)
+		return
+	}
+
+	webserver.encode(webserver.Runtime.ErrorLog.Entries(), writer)
+}
+
+func (webserver *WebServer) pipelineStatusHandler(writer http.ResponseWriter, _ *http.Request) {
+	if webserver.Runtime == nil {
+		http.Error(writer, "Functions pipeline not running", http.StatusServiceUnavailable)
+		return
+	}
+
+	webserver.encode(webserver.Runtime.IntakeStatus(), writer)
+}
+
+func (webserver *WebServer) triggerStatusHandler(writer http.ResponseWriter, _ *http.Request) {
+	if webserver.Connections == nil {
+		http.Error(writer, "Trigger has no connections", http.StatusNotFound)
+		return
+	}
+
+	webserver.encode(webserver.Connections(), writer)
+}
+
+func (webserver *WebServer) exportManifestHandler(writer http.ResponseWriter, r *http.Request) {
+	if webserver.Manifest == nil {
+		http.Error(writer, "Export manifest not enabled", http.StatusNotFound)
+		return
+	}
+
+	var since time.Time
+	if value := r.URL.Query().Get("since"); value != "" {
+		var err error
+		if since, err = time.Parse(time.RFC3339, value); err != nil {
+			http.Error(writer, "Invalid since time: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	webserver.encode(webserver.Manifest.Entries(r.URL.Query().Get("export"), since), writer)
+}
+
+func (webserver *WebServer) exportReconcileHandler(writer http.ResponseWriter, r *http.Request) {
+	if webserver.Manifest == nil {
+		http.Error(writer, "Export manifest not enabled", http.StatusNotFound)
+		return
+	}
+
+	request := runtime.ReconcileRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(writer, "Invalid reconcile request: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	result := webserver.Manifest.Reconcile(request)
+	if len(result.Missing) > 0 {
+		webserver.LoggingClient.Warn(fmt.Sprintf("Reconciliation found %d of %d exported events missing, %d exported again",
+			len(result.Missing), result.Checked, len(result.Reexported)))
+	}
+	webserver.encode(result, writer)
+}
+
+func (webserver *WebServer) pipelinePauseHandler(writer http.ResponseWriter, r *http.Request) {
+	if webserver.Runtime == nil {
+		http.Error(writer, "Functions pipeline not running", http.StatusServiceUnavailable)
+		return
+	}
+
+	webserver.Runtime.Pause()
+	webserver.LoggingClient.Info("Pipeline intake paused")
+	webserver.pipelineStatusHandler(writer, r)
+}
+
+func (webserver *WebServer) pipelineResumeHandler(writer http.ResponseWriter, r *http.Request) {
+	if webserver.Runtime == nil {
+		http.Error(writer, "Functions pipeline not running", http.StatusServiceUnavailable)
+		return
+	}
+
+	webserver.Runtime.Resume()
+	webserver.LoggingClient.Info("Pipeline intake resumed")
+	webserver.pipelineStatusHandler(writer, r)
+}
+
+func (webserver *WebServer) storedExportsHandler(writer http.ResponseWriter, _ *http.Request) {
+	if webserver.StoreForward == nil {
+		http.Error(writer, "Store and forward not enabled", http.StatusNotFound)
+		return
+	}
+
+	summary, err := webserver.StoreForward.StoredSummary()
+	if err != nil {
+		http.Error(writer, "Failed to read the store: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	webserver.encode(summary, writer)
+}
+
+func (webserver *WebServer) storedItemsHandler(writer http.ResponseWriter, r *http.Request) {
+	if webserver.StoreForward == nil {
+		http.Error(writer, "Store and forward not enabled", http.StatusNotFound)
+		return
+	}
+
+	items, err := webserver.StoreForward.StoredItems(r.URL.Query().Get("export"))
+	if err != nil {
+		http.Error(writer, "Failed to read the store: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	webserver.encode(items, writer)
+}
+
+func (webserver *WebServer) storedRetryHandler(writer http.ResponseWriter, _ *http.Request) {
+	if webserver.StoreForward == nil {
+		http.Error(writer, "Store and forward not enabled", http.StatusNotFound)
+		return
+	}
+
+	exported, err := webserver.StoreForward.RetryStored()
+	if err != nil {
+		http.Error(writer, "Failed to export the stored data: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	summary, err := webserver.StoreForward.StoredSummary()
+	if err != nil {
+		http.Error(writer, "Failed to read the store: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	webserver.LoggingClient.Info(fmt.Sprintf("Exported %d stored failed exports on request, %d remain stored", exported, summary.Depth))
+	webserver.encode(storedRetry{Exported: exported, Depth: summary.Depth}, writer)
+}
+
+func (webserver *WebServer) storedPurgeHandler(writer http.ResponseWriter, r *http.Request) {
+	if webserver.StoreForward == nil {
+		http.Error(writer, "Store and forward not enabled", http.StatusNotFound)
+		return
+	}
+
+	export := r.URL.Query().Get("export")
+	purged, err := webserver.StoreForward.PurgeStored(export)
+	if err != nil {
+		http.Error(writer, "Failed to purge the store: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if export == "" {
+		export = "all exports"
+	}
+	webserver.LoggingClient.Warn(fmt.Sprintf("Purged %d stored failed exports of %s on request", purged, export))
+	webserver.encode(storedPurge{Purged: purged}, writer)
+}
+
 // ConfigureStandardRoutes loads up some default routes
 func (webserver *WebServer) ConfigureStandardRoutes() {
 	webserver.LoggingClient.Info("Registering standard routes...")
@@ -76,12 +406,40 @@ func (webserver *WebServer) ConfigureStandardRoutes() {
 
 	// Ping Resource
 	webserver.router.HandleFunc(clients.ApiPingRoute, webserver.pingHandler).Methods(http.MethodGet)
+	webserver.router.HandleFunc(internal.ApiHealthRoute, webserver.healthHandler).Methods(http.MethodGet)
 
 	// Configuration
 	webserver.router.HandleFunc(clients.ApiConfigRoute, webserver.configHandler).Methods(http.MethodGet)
 
+	// Version
+	webserver.router.HandleFunc(internal.ApiVersionRoute, webserver.versionHandler).Methods(http.MethodGet)
+
 	// Metrics
 	webserver.router.HandleFunc(clients.ApiMetricsRoute, webserver.metricsHandler).Methods(http.MethodGet)
+	webserver.router.HandleFunc(internal.ApiPipelineMetrics, webserver.pipelineMetricsHandler).Methods(http.MethodGet)
+	webserver.router.HandleFunc(internal.ApiStageMetrics, webserver.stageMetricsHandler).Methods(http.MethodGet)
+	webserver.router.HandleFunc(internal.ApiFunctionMetrics, webserver.functionMetricsHandler).Methods(http.MethodGet)
+	webserver.router.HandleFunc(internal.ApiCacheMetrics, webserver.cacheMetricsHandler).Methods(http.MethodGet)
+	webserver.router.HandleFunc(internal.ApiLimitMetrics, webserver.concurrencyMetricsHandler).Methods(http.MethodGet)
+	webserver.router.HandleFunc(internal.ApiErrorLogRoute, webserver.errorLogHandler).Methods(http.MethodGet)
+	// registered after the other metrics routes, which take precedence over the names of the providers
+	webserver.router.HandleFunc(internal.ApiNamedMetrics, webserver.namedMetricsHandler).Methods(http.MethodGet)
+
+	// Pipeline intake
+	webserver.router.HandleFunc(internal.ApiPipelineStatus, webserver.pipelineStatusHandler).Methods(http.MethodGet)
+	webserver.router.HandleFunc(internal.ApiPipelinePause, webserver.pipelinePauseHandler).Methods(http.MethodPost)
+	webserver.router.HandleFunc(internal.ApiPipelineResume, webserver.pipelineResumeHandler).Methods(http.MethodPost)
+	webserver.router.HandleFunc(internal.ApiTriggerStatus, webserver.triggerStatusHandler).Methods(http.MethodGet)
+
+	// Export reconciliation
+	webserver.router.HandleFunc(internal.ApiExportManifest, webserver.exportManifestHandler).Methods(http.MethodGet)
+	webserver.router.HandleFunc(internal.ApiExportReconcile, webserver.exportReconcileHandler).Methods(http.MethodPost)
+
+	// Store and forward
+	webserver.router.HandleFunc(internal.ApiStoredExports, webserver.storedExportsHandler).Methods(http.MethodGet)
+	webserver.router.HandleFunc(internal.ApiStoredExports, webserver.storedPurgeHandler).Methods(http.MethodDelete)
+	webserver.router.HandleFunc(internal.ApiStoredItems, webserver.storedItemsHandler).Methods(http.MethodGet)
+	webserver.router.HandleFunc(internal.ApiStoredRetry, webserver.storedRetryHandler).Methods(http.MethodPost)
 
 }
 
@@ -94,8 +452,47 @@ func (webserver *WebServer) SetupTriggerRoute(handlerForTrigger func(http.Respon
 // StartHTTPServer starts the http server
 func (webserver *WebServer) StartHTTPServer(errChannel chan error) {
 	webserver.LoggingClient.Info(fmt.Sprintf("Starting HTTP Server on port :%d", webserver.Config.Service.Port))
+	server := &http.Server{Addr: fmt.Sprintf(":%d", webserver.Config.Service.Port), Handler: webserver.router}
+	webserver.serverMutex.Lock()
+	webserver.server = server
+	webserver.serverMutex.Unlock()
+
 	go func() {
-		p := fmt.Sprintf(":%d", webserver.Config.Service.Port)
-		errChannel <- http.ListenAndServe(p, webserver.router)
+		var err error
+		if !strings.EqualFold(webserver.Config.Service.Protocol, "https") {
+			err = server.ListenAndServe()
+		} else {
+			watcher, watchErr := certs.NewWatcher(certs.FileSource(webserver.Config.Service.CertFile, webserver.Config.Service.KeyFile), certs.DefaultReloadInterval, webserver.LoggingClient)
+			if watchErr != nil {
+				errChannel <- fmt.Errorf("unable to load HTTPS certificate: %v", watchErr)
+				return
+			}
+			server.TLSConfig = watcher.ServerTLSConfig(nil)
+			err = server.ListenAndServeTLS("", "")
+		}
+
+		// the server was stopped by StopHTTPServer, which isn't an error to report
+		if err != http.ErrServerClosed {
+			errChannel <- err
+		}
 	}()
 }
+
+// StopHTTPServer stops accepting connections and waits for the requests being handled to complete, for up to the
+// timeout, before closing the remaining connections
+func (webserver *WebServer) StopHTTPServer(timeout time.Duration) error {
+	webserver.serverMutex.Lock()
+	server := webserver.server
+	webserver.serverMutex.Unlock()
+	if server == nil {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	if err := server.Shutdown(ctx); err != nil {
+		server.Close()
+		return err
+	}
+	return nil
+}
@@ -18,9 +18,22 @@ package webserver
 
 import (
 	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/antoniomtz/app-functions-sdk-go/appcontext"
+	"github.com/antoniomtz/app-functions-sdk-go/internal"
 	"github.com/antoniomtz/app-functions-sdk-go/internal/common"
+	"github.com/antoniomtz/app-functions-sdk-go/internal/runtime"
+	"github.com/antoniomtz/app-functions-sdk-go/internal/store"
+	"github.com/antoniomtz/app-functions-sdk-go/internal/trigger"
+	"github.com/antoniomtz/app-functions-sdk-go/pkg/transforms"
+	"github.com/antoniomtz/go-mod-messaging/pkg/types"
 
 	"github.com/antoniomtz/app-functions-sdk-go/internal/telemetry"
 
@@ -31,6 +44,7 @@ import (
 	"github.com/edgexfoundry/go-mod-core-contracts/clients/logger"
 	"github.com/gorilla/mux"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 var logClient logger.LoggingClient
@@ -55,6 +69,23 @@ func TestConfigureAndPingRoute(t *testing.T) {
 
 }
 
+func TestConfigureAndVersionRoute(t *testing.T) {
+	webserver := WebServer{
+		LoggingClient: logClient,
+	}
+	webserver.ConfigureStandardRoutes()
+
+	internal.ApplicationVersion = "1.2.3"
+	defer func() { internal.ApplicationVersion = "0.0.0" }()
+
+	req, _ := http.NewRequest("GET", internal.ApiVersionRoute, nil)
+	rr := httptest.NewRecorder()
+	webserver.router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, `{"version":"1.2.3","sdk_version":"0.0.0"}`+"\n", rr.Body.String())
+}
+
 func TestConfigureAndConfigRoute(t *testing.T) {
 
 	webserver := WebServer{
@@ -67,11 +98,55 @@ func TestConfigureAndConfigRoute(t *testing.T) {
 	rr := httptest.NewRecorder()
 	webserver.router.ServeHTTP(rr, req)
 
-	expected := `{"Writable":{"LogLevel":""},"Logging":{"EnableRemote":false,"File":""},"Registry":{"Host":"","Port":0,"Type":""},"Service":{"BootTimeout":0,"CheckInterval":"","ClientMonitor":0,"Host":"","Port":0,"Protocol":"","StartupMsg":"","ReadMaxLimit":0,"Timeout":0},"MessageBus":{"PublishHost":{"Host":"","Port":0,"Protocol":""},"SubscribeHost":{"Host":"","Port":0,"Protocol":""},"Type":"","Optional":null},"Binding":{"Type":"","Name":"","SubscribeTopic":"","PublishTopic":""},"ApplicationSettings":null,"Clients":null}` + "\n"
+	expected := `{"Writable":{"LogLevel":"","MarkPushedMaxAge":"","PipelineSettings":null,"Pipeline":{"ExecutionOrder":"","Functions":null},"ProfileStages":false,"PipelineTimeout":""},"Logging":{"EnableRemote":false,"File":"","FloodControlInterval":""},"Registry":{"Host":"","Port":0,"Type":""},"Service":{"BootTimeout":0,"CheckInterval":"","ClientMonitor":0,"Host":"","Port":0,"Protocol":"","StartupMsg":"","ReadMaxLimit":0,"Timeout":0,"CertFile":"","KeyFile":"","ShutdownTimeout":"","ShutdownReportFile":""},"MessageBus":{"PublishHost":{"Host":"","Port":0,"Protocol":""},"SubscribeHost":{"Host":"","Port":0,"Protocol":""},"Type":"","Optional":null},"Binding":{"Type":"","Name":"","SubscribeTopic":"","PublishTopic":"","SubscribeTopics":null,"TopicWeights":null,"Workers":0,"PreserveDeviceOrder":false,"QueueSize":0,"OverflowPolicy":"","PublishQueueSize":0,"ReconnectInterval":"","MaxReconnectInterval":"","MaxReconnectAttempts":0,"AckMode":""},"ErrorLog":{"Capacity":0,"MaxPayloadSize":0,"RedactFields":null},"PoisonMessages":{"MaxFailures":0,"DeadLetterFile":"","DeadLetterTopic":""},"SecretStore":{"Type":"","Protocol":"","Host":"","Port":0,"Path":"","TokenFile":"","File":""},"Alerts":{"Rules":null,"CheckInterval":"","Notify":false,"MQTTBroker":"","MQTTTopic":""},"ExportWebhooks":{"URLs":null,"FailureThreshold":0,"MQTTBroker":"","MQTTTopic":""},"ExportManifest":{"Capacity":0},"ExportDeadLetters":{"File":"","URL":"","MQTTBroker":"","MQTTTopic":""},"StoreAndForward":{"Enabled":false,"Type":"","Path":"","Address":"","Database":0,"SecretPath":"","Key":"","RetryInterval":"","MaxRetryCount":0,"MaxObjects":0,"MaxSize":0,"TTL":""},"DeviceEvents":{"PollInterval":""},"Tracing":{"Endpoint":"","ServiceName":"","BatchSize":0,"FlushInterval":""},"ApplicationSettings":null,"Clients":null}` + "\n"
 	body := rr.Body.String()
 	assert.Equal(t, expected, body)
 }
 
+func TestConfigureAndConfigRouteWritable(t *testing.T) {
+	webserver := WebServer{
+		LoggingClient: logClient,
+		Config:        &common.ConfigurationStruct{Writable: common.WritableInfo{LogLevel: "INFO"}},
+		Runtime:       &runtime.GolangRuntime{Writable: common.NewWritableStore(common.WritableInfo{LogLevel: "DEBUG"})},
+	}
+	webserver.ConfigureStandardRoutes()
+
+	req, _ := http.NewRequest("GET", clients.ApiConfigRoute, nil)
+	rr := httptest.NewRecorder()
+	webserver.router.ServeHTTP(rr, req)
+
+	config := common.ConfigurationStruct{}
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &config))
+	assert.Equal(t, "DEBUG", config.Writable.LogLevel, "The Writable configuration in use should be returned")
+}
+
+func TestConfigureAndConfigRouteRedactsCredentials(t *testing.T) {
+	optional := map[string]string{"Username": "app", "Password": "secret", "AuthMode": "none"}
+	mqttParameters := map[string]string{"Address": "localhost", "User": "app", "Password": "secret", "Key": "client.key"}
+	webserver := WebServer{
+		LoggingClient: logClient,
+		Config: &common.ConfigurationStruct{
+			MessageBus: types.MessageBusConfig{Optional: optional},
+			Writable: common.WritableInfo{Pipeline: common.PipelineInfo{
+				ExecutionOrder: "MQTTSend",
+				Functions:      map[string]common.PipelineFunction{"MQTTSend": {Parameters: mqttParameters}},
+			}},
+		},
+	}
+	webserver.ConfigureStandardRoutes()
+
+	req, _ := http.NewRequest("GET", clients.ApiConfigRoute, nil)
+	rr := httptest.NewRecorder()
+	webserver.router.ServeHTTP(rr, req)
+
+	config := common.ConfigurationStruct{}
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &config))
+	assert.Equal(t, map[string]string{"Username": "app", "Password": "<redacted>", "AuthMode": "none"}, config.MessageBus.Optional)
+	assert.Equal(t, map[string]string{"Address": "localhost", "User": "app", "Password": "<redacted>", "Key": "client.key"}, config.Writable.Pipeline.Functions["MQTTSend"].Parameters)
+	assert.Equal(t, "secret", optional["Password"], "The configuration in use should not be changed")
+	assert.Equal(t, "secret", mqttParameters["Password"], "The configuration in use should not be changed")
+}
+
 func TestConfigureAndMetricsRoute(t *testing.T) {
 	webserver := WebServer{
 		LoggingClient: logClient,
@@ -121,3 +196,393 @@ func TestSetupTriggerRoute(t *testing.T) {
 	assert.False(t, handlerFunctionNotCalled, "expected handler function to be called")
 
 }
+
+func TestConfigureAndPipelineMetricsRoute(t *testing.T) {
+	webserver := WebServer{
+		LoggingClient: logClient,
+		Runtime:       &runtime.GolangRuntime{},
+	}
+	webserver.ConfigureStandardRoutes()
+
+	req, _ := http.NewRequest("GET", internal.ApiPipelineMetrics, nil)
+	rr := httptest.NewRecorder()
+	webserver.router.ServeHTTP(rr, req)
+
+	metrics := map[string]runtime.PipelineMetrics{}
+	err := json.Unmarshal(rr.Body.Bytes(), &metrics)
+	assert.NoError(t, err)
+	_, ok := metrics[runtime.PrimaryPipelineName]
+	assert.True(t, ok, "Expected metrics for the primary pipeline")
+}
+
+func TestConfigureAndPipelinePauseResumeRoutes(t *testing.T) {
+	runtime := &runtime.GolangRuntime{}
+	webserver := WebServer{
+		LoggingClient: logClient,
+		Runtime:       runtime,
+	}
+	webserver.ConfigureStandardRoutes()
+
+	req, _ := http.NewRequest(http.MethodPost, internal.ApiPipelinePause, nil)
+	rr := httptest.NewRecorder()
+	webserver.router.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, `{"paused":true}`+"\n", rr.Body.String())
+	assert.True(t, runtime.IntakeStatus().Paused)
+
+	req, _ = http.NewRequest(http.MethodGet, internal.ApiPipelineStatus, nil)
+	rr = httptest.NewRecorder()
+	webserver.router.ServeHTTP(rr, req)
+	assert.Equal(t, `{"paused":true}`+"\n", rr.Body.String())
+
+	req, _ = http.NewRequest(http.MethodPost, internal.ApiPipelineResume, nil)
+	rr = httptest.NewRecorder()
+	webserver.router.ServeHTTP(rr, req)
+	assert.Equal(t, `{"paused":false}`+"\n", rr.Body.String())
+	assert.False(t, runtime.IntakeStatus().Paused)
+}
+
+func TestStartHTTPServerInvalidCertificate(t *testing.T) {
+	webserver := WebServer{
+		Config: &common.ConfigurationStruct{
+			Service: common.ServiceInfo{Port: 48099, Protocol: "https", CertFile: "missing.pem", KeyFile: "missing.key"},
+		},
+		LoggingClient: logClient,
+	}
+	webserver.ConfigureStandardRoutes()
+
+	errs := make(chan error, 1)
+	webserver.StartHTTPServer(errs)
+	assert.Contains(t, (<-errs).Error(), "unable to load HTTPS certificate")
+}
+
+func TestStopHTTPServer(t *testing.T) {
+	webserver := WebServer{
+		Config:        &common.ConfigurationStruct{Service: common.ServiceInfo{Port: 48098}},
+		LoggingClient: logClient,
+	}
+	assert.NoError(t, webserver.StopHTTPServer(time.Second), "stopping a server which was not started should do nothing")
+	webserver.ConfigureStandardRoutes()
+
+	errs := make(chan error, 1)
+	webserver.StartHTTPServer(errs)
+	time.Sleep(50 * time.Millisecond)
+	assert.NoError(t, webserver.StopHTTPServer(time.Second))
+
+	select {
+	case err := <-errs:
+		t.Fatalf("stopping the server should not be reported as an error: %v", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestConfigureAndStageMetricsRoute(t *testing.T) {
+	webserver := WebServer{
+		LoggingClient: logClient,
+		Runtime:       &runtime.GolangRuntime{},
+	}
+	webserver.ConfigureStandardRoutes()
+
+	req, _ := http.NewRequest("GET", internal.ApiStageMetrics, nil)
+	rr := httptest.NewRecorder()
+	webserver.router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	profiles := []runtime.StageProfile{}
+	err := json.Unmarshal(rr.Body.Bytes(), &profiles)
+	assert.NoError(t, err)
+	assert.Empty(t, profiles)
+}
+
+func TestConfigureAndFunctionMetricsRoute(t *testing.T) {
+	webserver := WebServer{
+		LoggingClient: logClient,
+		Runtime:       &runtime.GolangRuntime{},
+	}
+	webserver.ConfigureStandardRoutes()
+
+	req, _ := http.NewRequest("GET", internal.ApiFunctionMetrics, nil)
+	rr := httptest.NewRecorder()
+	webserver.router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	metrics := []runtime.FunctionMetrics{}
+	err := json.Unmarshal(rr.Body.Bytes(), &metrics)
+	assert.NoError(t, err)
+	assert.Empty(t, metrics)
+}
+
+func TestConfigureAndCacheMetricsRoute(t *testing.T) {
+	cache := &runtime.TransformCache{Name: "render"}
+	webserver := WebServer{
+		LoggingClient: logClient,
+		Runtime:       &runtime.GolangRuntime{Caches: []*runtime.TransformCache{cache}},
+	}
+	webserver.ConfigureStandardRoutes()
+
+	req, _ := http.NewRequest("GET", internal.ApiCacheMetrics, nil)
+	rr := httptest.NewRecorder()
+	webserver.router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	metrics := []runtime.CacheMetrics{}
+	err := json.Unmarshal(rr.Body.Bytes(), &metrics)
+	assert.NoError(t, err)
+	assert.Equal(t, []runtime.CacheMetrics{{Name: "render"}}, metrics)
+}
+
+func TestConfigureAndConcurrencyMetricsRoute(t *testing.T) {
+	limit := &runtime.ConcurrencyLimit{Name: "inference", MaxConcurrent: 2}
+	webserver := WebServer{
+		LoggingClient: logClient,
+		Runtime:       &runtime.GolangRuntime{Limits: []*runtime.ConcurrencyLimit{limit}},
+	}
+	webserver.ConfigureStandardRoutes()
+
+	req, _ := http.NewRequest("GET", internal.ApiLimitMetrics, nil)
+	rr := httptest.NewRecorder()
+	webserver.router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	metrics := []runtime.ConcurrencyMetrics{}
+	err := json.Unmarshal(rr.Body.Bytes(), &metrics)
+	assert.NoError(t, err)
+	assert.Equal(t, []runtime.ConcurrencyMetrics{{Name: "inference", MaxConcurrent: 2}}, metrics)
+}
+
+func TestConfigureAndNamedMetricsRoute(t *testing.T) {
+	filter, err := transforms.NewOutlierFilter("temperature", transforms.OutlierConfig{})
+	require.NoError(t, err)
+	webserver := WebServer{
+		LoggingClient: logClient,
+		Runtime: &runtime.GolangRuntime{MetricsProviders: map[string]func() interface{}{
+			"outliers": func() interface{} { return []transforms.OutlierMetrics{filter.Metrics()} },
+		}},
+	}
+	webserver.ConfigureStandardRoutes()
+
+	req, _ := http.NewRequest("GET", "/api/v1/metrics/outliers", nil)
+	rr := httptest.NewRecorder()
+	webserver.router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	metrics := []transforms.OutlierMetrics{}
+	err = json.Unmarshal(rr.Body.Bytes(), &metrics)
+	assert.NoError(t, err)
+	assert.Equal(t, []transforms.OutlierMetrics{{Name: "temperature"}}, metrics)
+
+	req, _ = http.NewRequest("GET", "/api/v1/metrics/unknown", nil)
+	rr = httptest.NewRecorder()
+	webserver.router.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+
+	req, _ = http.NewRequest("GET", internal.ApiCacheMetrics, nil)
+	rr = httptest.NewRecorder()
+	webserver.router.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code, "the other metrics routes should take precedence")
+}
+
+func TestConfigureAndInferenceMetricsRoute(t *testing.T) {
+	client, err := transforms.NewInferenceClient("anomaly", transforms.InferenceConfig{URL: "http://localhost:8501/v1/models/anomaly:predict"})
+	require.NoError(t, err)
+	webserver := WebServer{
+		LoggingClient: logClient,
+		Runtime: &runtime.GolangRuntime{MetricsProviders: map[string]func() interface{}{
+			"inference": func() interface{} { return []transforms.InferenceMetrics{client.Metrics()} },
+		}},
+	}
+	webserver.ConfigureStandardRoutes()
+
+	req, _ := http.NewRequest("GET", "/api/v1/metrics/inference", nil)
+	rr := httptest.NewRecorder()
+	webserver.router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	metrics := []transforms.InferenceMetrics{}
+	err = json.Unmarshal(rr.Body.Bytes(), &metrics)
+	assert.NoError(t, err)
+	assert.Equal(t, []transforms.InferenceMetrics{{Name: "anomaly"}}, metrics)
+}
+
+func TestConfigureAndTriggerStatusRoute(t *testing.T) {
+	webserver := WebServer{
+		LoggingClient: logClient,
+		Runtime:       &runtime.GolangRuntime{},
+	}
+	webserver.ConfigureStandardRoutes()
+
+	req, _ := http.NewRequest("GET", internal.ApiTriggerStatus, nil)
+	rr := httptest.NewRecorder()
+	webserver.router.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusNotFound, rr.Code, "triggers without connections have no status")
+
+	webserver.Connections = func() []trigger.ConnectionStatus {
+		return []trigger.ConnectionStatus{{Name: "subscriber", Healthy: true, Messages: 3}, {Name: "publisher", Errors: 1, LastError: "timeout"}}
+	}
+	rr = httptest.NewRecorder()
+	webserver.router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	connections := []trigger.ConnectionStatus{}
+	err := json.Unmarshal(rr.Body.Bytes(), &connections)
+	assert.NoError(t, err)
+	assert.Equal(t, webserver.Connections(), connections)
+}
+
+func TestConfigureAndExportManifestRoutes(t *testing.T) {
+	webserver := WebServer{
+		LoggingClient: logClient,
+	}
+	webserver.ConfigureStandardRoutes()
+
+	req, _ := http.NewRequest(http.MethodGet, internal.ApiExportManifest, nil)
+	rr := httptest.NewRecorder()
+	webserver.router.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusNotFound, rr.Code, "the manifest should only be available when enabled")
+
+	tracker := &runtime.ExportTracker{Manifest: runtime.NewExportManifest(10)}
+	export := tracker.Track("HTTPPost", func(edgexcontext *appcontext.Context, params ...interface{}) (bool, interface{}) {
+		return true, nil
+	}, nil)
+	export(&appcontext.Context{LoggingClient: logClient, EventID: "event1"}, "data")
+	export(&appcontext.Context{LoggingClient: logClient, EventID: "event2"}, "data")
+	webserver.Manifest = tracker.Manifest
+
+	rr = httptest.NewRecorder()
+	webserver.router.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	entries := []runtime.ManifestEntry{}
+	err := json.Unmarshal(rr.Body.Bytes(), &entries)
+	assert.NoError(t, err)
+	assert.Len(t, entries, 2)
+
+	req, _ = http.NewRequest(http.MethodGet, internal.ApiExportManifest+"?since=yesterday", nil)
+	rr = httptest.NewRecorder()
+	webserver.router.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+	req, _ = http.NewRequest(http.MethodPost, internal.ApiExportReconcile, strings.NewReader(`{"received":["event1"],"reexport":true}`))
+	rr = httptest.NewRecorder()
+	webserver.router.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	result := runtime.ReconcileResult{}
+	err = json.Unmarshal(rr.Body.Bytes(), &result)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, result.Checked)
+	assert.Len(t, result.Missing, 1)
+	assert.Equal(t, []string{"event2"}, result.Reexported)
+
+	req, _ = http.NewRequest(http.MethodPost, internal.ApiExportReconcile, strings.NewReader(`received`))
+	rr = httptest.NewRecorder()
+	webserver.router.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
+
+func TestConfigureAndStoreForwardRoutes(t *testing.T) {
+	webserver := WebServer{
+		LoggingClient: logClient,
+	}
+	webserver.ConfigureStandardRoutes()
+
+	req, _ := http.NewRequest(http.MethodGet, internal.ApiStoredExports, nil)
+	rr := httptest.NewRecorder()
+	webserver.router.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusNotFound, rr.Code, "the store should only be available when enabled")
+
+	dir, err := ioutil.TempDir("", "storeforward")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	storeClient, err := store.NewBoltStore(filepath.Join(dir, "store.db"), store.Config{})
+	assert.NoError(t, err)
+	defer storeClient.Disconnect()
+
+	reachable := false
+	tracker := &runtime.ExportTracker{Store: storeClient, NewContext: func(correlationID string) *appcontext.Context {
+		return &appcontext.Context{LoggingClient: logClient, CorrelationID: correlationID}
+	}}
+	export := tracker.Track("HTTPPost", func(edgexcontext *appcontext.Context, params ...interface{}) (bool, interface{}) {
+		if !reachable {
+			return false, errors.New("connection refused")
+		}
+		return true, nil
+	}, nil)
+	failing := tracker.Track("MQTTSend", func(edgexcontext *appcontext.Context, params ...interface{}) (bool, interface{}) {
+		return false, errors.New("not authorized")
+	}, nil)
+	export(&appcontext.Context{LoggingClient: logClient, CorrelationID: "id1"}, "data")
+	export(&appcontext.Context{LoggingClient: logClient, CorrelationID: "id2"}, "data")
+	failing(&appcontext.Context{LoggingClient: logClient, CorrelationID: "id3"}, "data")
+	webserver.StoreForward = tracker
+
+	rr = httptest.NewRecorder()
+	webserver.router.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	summary := runtime.StoredSummary{}
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &summary))
+	assert.Equal(t, 3, summary.Depth)
+	assert.Equal(t, map[string]int{"HTTPPost": 2, "MQTTSend": 1}, summary.Exports)
+
+	req, _ = http.NewRequest(http.MethodGet, internal.ApiStoredItems+"?export=HTTPPost", nil)
+	rr = httptest.NewRecorder()
+	webserver.router.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.NotContains(t, rr.Body.String(), "payload", "the stored data should not be listed")
+	items := []runtime.StoredItem{}
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &items))
+	assert.Len(t, items, 2)
+
+	reachable = true
+	req, _ = http.NewRequest(http.MethodPost, internal.ApiStoredRetry, nil)
+	rr = httptest.NewRecorder()
+	webserver.router.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.JSONEq(t, `{"exported":2,"depth":1}`, rr.Body.String())
+
+	req, _ = http.NewRequest(http.MethodDelete, internal.ApiStoredExports, nil)
+	rr = httptest.NewRecorder()
+	webserver.router.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.JSONEq(t, `{"purged":1}`, rr.Body.String())
+	objects, err := storeClient.RetrieveFromStore()
+	assert.NoError(t, err)
+	assert.Empty(t, objects)
+}
+
+func TestConfigureAndHealthRoute(t *testing.T) {
+	webserver := WebServer{
+		LoggingClient: logClient,
+	}
+	webserver.ConfigureStandardRoutes()
+
+	req, _ := http.NewRequest(http.MethodGet, internal.ApiHealthRoute, nil)
+	rr := httptest.NewRecorder()
+	webserver.router.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, `{"healthy":true,"connections":[]}`+"\n", rr.Body.String())
+
+	webserver.Connections = func() []trigger.ConnectionStatus {
+		return []trigger.ConnectionStatus{{Name: "subscriber", Healthy: true, Messages: 3}}
+	}
+	webserver.ExportConnections = func() []trigger.ConnectionStatus {
+		return []trigger.ConnectionStatus{{Name: "MQTTSend", Healthy: true, Messages: 2}}
+	}
+	rr = httptest.NewRecorder()
+	webserver.router.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	health := healthStatus{}
+	err := json.Unmarshal(rr.Body.Bytes(), &health)
+	assert.NoError(t, err)
+	assert.True(t, health.Healthy)
+	assert.Len(t, health.Connections, 2)
+
+	webserver.ExportConnections = func() []trigger.ConnectionStatus {
+		return []trigger.ConnectionStatus{{Name: "MQTTSend", Errors: 1, LastError: "connection refused"}}
+	}
+	rr = httptest.NewRecorder()
+	webserver.router.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusServiceUnavailable, rr.Code, "an unhealthy connection should fail the health check")
+	err = json.Unmarshal(rr.Body.Bytes(), &health)
+	assert.NoError(t, err)
+	assert.False(t, health.Healthy)
+	assert.Equal(t, "connection refused", health.Connections[1].LastError)
+}
@@ -0,0 +1,171 @@
+//
+// Copyright (c) 2019 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package certs
+
+import (
+	"bytes"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"sync"
+	"time"
+
+	"github.com/antoniomtz/app-functions-sdk-go/pkg/secrets"
+	"github.com/edgexfoundry/go-mod-core-contracts/clients/logger"
+)
+
+// DefaultReloadInterval is how often the certificate and key are checked for changes when no interval is given
+const DefaultReloadInterval = time.Minute
+
+// KeyPairSource returns the PEM encoded certificate and key
+type KeyPairSource func() (certPEM []byte, keyPEM []byte, err error)
+
+// FileSource reads the certificate and key from PEM files
+func FileSource(certFile string, keyFile string) KeyPairSource {
+	return func() ([]byte, []byte, error) {
+		certPEM, err := ioutil.ReadFile(certFile)
+		if err != nil {
+			return nil, nil, fmt.Errorf("unable to read certificate: %v", err)
+		}
+		keyPEM, err := ioutil.ReadFile(keyFile)
+		if err != nil {
+			return nil, nil, fmt.Errorf("unable to read key: %v", err)
+		}
+		return certPEM, keyPEM, nil
+	}
+}
+
+// SecretSource reads the certificate and key from the secrets stored at the path in the secret store, under the
+// certKey and keyKey keys
+func SecretSource(provider secrets.SecretProvider, path string, certKey string, keyKey string) KeyPairSource {
+	return func() ([]byte, []byte, error) {
+		if provider == nil {
+			return nil, nil, errors.New("no secret store configured")
+		}
+		values, err := provider.GetSecrets(path, certKey, keyKey)
+		if err != nil {
+			return nil, nil, err
+		}
+		return []byte(values[certKey]), []byte(values[keyKey]), nil
+	}
+}
+
+// Watcher holds a certificate and key which are reloaded when they change, so that short-lived certificates can be
+// renewed without restarting the service. The source is checked at most once per interval, when the certificate is
+// needed for a TLS handshake, and the certificate is only parsed again when the source returns different data.
+// When reloading fails, the error is logged if a logging client is given, and the previous certificate is used until
+// the next check.
+type Watcher struct {
+	source   KeyPairSource
+	interval time.Duration
+	logging  logger.LoggingClient
+	mutex    sync.Mutex
+	cert     *tls.Certificate
+	certPEM  []byte
+	keyPEM   []byte
+	checked  time.Time
+}
+
+// NewWatcher creates a watcher for the certificate and key from the source, which must be valid initially. An
+// interval of zero uses DefaultReloadInterval.
+func NewWatcher(source KeyPairSource, interval time.Duration, logging logger.LoggingClient) (*Watcher, error) {
+	if interval <= 0 {
+		interval = DefaultReloadInterval
+	}
+
+	watcher := &Watcher{source: source, interval: interval, logging: logging}
+	if err := watcher.reload(time.Now()); err != nil {
+		return nil, err
+	}
+	return watcher, nil
+}
+
+// Certificate returns the current certificate, reloading it first if the interval has elapsed since the last check
+func (watcher *Watcher) Certificate() *tls.Certificate {
+	watcher.mutex.Lock()
+	defer watcher.mutex.Unlock()
+
+	now := time.Now()
+	if now.Sub(watcher.checked) >= watcher.interval {
+		if err := watcher.reload(now); err != nil && watcher.logging != nil {
+			watcher.logging.Error("Failed to reload TLS certificate, using the previous certificate: " + err.Error())
+		}
+	}
+	return watcher.cert
+}
+
+// GetCertificate returns the current certificate for servers, and is intended for tls.Config.GetCertificate
+func (watcher *Watcher) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	return watcher.Certificate(), nil
+}
+
+// GetClientCertificate returns the current certificate for clients, and is intended for
+// tls.Config.GetClientCertificate
+func (watcher *Watcher) GetClientCertificate(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+	return watcher.Certificate(), nil
+}
+
+// ClientTLSConfig returns a copy of the config which presents the current certificate to servers
+func (watcher *Watcher) ClientTLSConfig(config *tls.Config) *tls.Config {
+	config = cloneTLSConfig(config)
+	config.GetClientCertificate = watcher.GetClientCertificate
+	return config
+}
+
+// ServerTLSConfig returns a copy of the config which presents the current certificate to clients
+func (watcher *Watcher) ServerTLSConfig(config *tls.Config) *tls.Config {
+	config = cloneTLSConfig(config)
+	config.GetCertificate = watcher.GetCertificate
+	return config
+}
+
+func cloneTLSConfig(config *tls.Config) *tls.Config {
+	if config == nil {
+		return &tls.Config{}
+	}
+	config = config.Clone()
+	config.Certificates = nil
+	return config
+}
+
+// reload reads the source and parses the certificate if it changed. The caller must hold the mutex, except when
+// creating the watcher.
+func (watcher *Watcher) reload(now time.Time) error {
+	watcher.checked = now
+
+	certPEM, keyPEM, err := watcher.source()
+	if err != nil {
+		return err
+	}
+	if watcher.cert != nil && bytes.Equal(certPEM, watcher.certPEM) && bytes.Equal(keyPEM, watcher.keyPEM) {
+		return nil
+	}
+
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		return fmt.Errorf("unable to parse certificate and key: %v", err)
+	}
+
+	if watcher.cert != nil && watcher.logging != nil {
+		watcher.logging.Info("Reloaded TLS certificate")
+	}
+	watcher.cert = &cert
+	watcher.certPEM = certPEM
+	watcher.keyPEM = keyPEM
+	return nil
+}
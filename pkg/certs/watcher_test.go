@@ -0,0 +1,142 @@
+//
+// Copyright (c) 2019 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package certs
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/antoniomtz/app-functions-sdk-go/pkg/secrets"
+	"github.com/edgexfoundry/go-mod-core-contracts/clients/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var lc logger.LoggingClient
+
+func init() {
+	lc = logger.NewClient("app_functions_sdk_go", false, "./test.log", "DEBUG")
+}
+
+// newKeyPair creates a self-signed certificate for the common name along with its key, both PEM encoded
+func newKeyPair(t *testing.T, commonName string) ([]byte, []byte) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+}
+
+func commonName(t *testing.T, watcher *Watcher) string {
+	cert, err := x509.ParseCertificate(watcher.Certificate().Certificate[0])
+	require.NoError(t, err)
+	return cert.Subject.CommonName
+}
+
+func TestWatcherReloadsFiles(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "certs")
+	defer os.RemoveAll(dir)
+	certFile := filepath.Join(dir, "cert.pem")
+	keyFile := filepath.Join(dir, "key.pem")
+
+	certPEM, keyPEM := newKeyPair(t, "first")
+	ioutil.WriteFile(certFile, certPEM, 0600)
+	ioutil.WriteFile(keyFile, keyPEM, 0600)
+
+	watcher, err := NewWatcher(FileSource(certFile, keyFile), time.Nanosecond, lc)
+	require.NoError(t, err)
+	assert.Equal(t, "first", commonName(t, watcher))
+
+	certPEM, keyPEM = newKeyPair(t, "second")
+	ioutil.WriteFile(certFile, certPEM, 0600)
+	ioutil.WriteFile(keyFile, keyPEM, 0600)
+	assert.Equal(t, "second", commonName(t, watcher), "Changed certificate should be reloaded")
+
+	ioutil.WriteFile(keyFile, []byte("not a key"), 0600)
+	assert.Equal(t, "second", commonName(t, watcher), "Previous certificate should be used when reloading fails")
+
+	config := watcher.ServerTLSConfig(nil)
+	cert, err := config.GetCertificate(nil)
+	require.NoError(t, err)
+	assert.Equal(t, watcher.Certificate(), cert)
+	config = watcher.ClientTLSConfig(nil)
+	cert, err = config.GetClientCertificate(nil)
+	require.NoError(t, err)
+	assert.Equal(t, watcher.Certificate(), cert)
+}
+
+func TestWatcherInterval(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "certs")
+	defer os.RemoveAll(dir)
+	certFile := filepath.Join(dir, "cert.pem")
+	keyFile := filepath.Join(dir, "key.pem")
+
+	certPEM, keyPEM := newKeyPair(t, "first")
+	ioutil.WriteFile(certFile, certPEM, 0600)
+	ioutil.WriteFile(keyFile, keyPEM, 0600)
+
+	watcher, err := NewWatcher(FileSource(certFile, keyFile), 0, lc)
+	require.NoError(t, err)
+
+	certPEM, keyPEM = newKeyPair(t, "second")
+	ioutil.WriteFile(certFile, certPEM, 0600)
+	ioutil.WriteFile(keyFile, keyPEM, 0600)
+	assert.Equal(t, "first", commonName(t, watcher), "Certificate should not be reloaded before the interval elapses")
+}
+
+func TestWatcherSecretSource(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "certs")
+	defer os.RemoveAll(dir)
+	secretsFile := filepath.Join(dir, "secrets.json")
+
+	certPEM, keyPEM := newKeyPair(t, "secret")
+	contents, _ := json.Marshal(map[string]map[string]string{"mqtt-tls": {"cert": string(certPEM), "key": string(keyPEM)}})
+	ioutil.WriteFile(secretsFile, contents, 0600)
+
+	watcher, err := NewWatcher(SecretSource(secrets.NewFileProvider(secretsFile), "mqtt-tls", "cert", "key"), 0, lc)
+	require.NoError(t, err)
+	assert.Equal(t, "secret", commonName(t, watcher))
+}
+
+func TestNewWatcherInvalid(t *testing.T) {
+	_, err := NewWatcher(FileSource("missing.pem", "missing.key"), 0, lc)
+	assert.Error(t, err)
+
+	_, err = NewWatcher(SecretSource(nil, "mqtt-tls", "cert", "key"), 0, lc)
+	assert.Error(t, err)
+}
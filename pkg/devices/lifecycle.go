@@ -0,0 +1,140 @@
+//
+// Copyright (c) 2019 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package devices
+
+import (
+	syscontext "context"
+	"sync"
+	"time"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/clients/logger"
+	"github.com/edgexfoundry/go-mod-core-contracts/clients/metadata"
+	"github.com/edgexfoundry/go-mod-core-contracts/models"
+)
+
+// DefaultPollInterval is how often core-metadata is polled for device changes when no interval is given
+const DefaultPollInterval = 30 * time.Second
+
+// Actions reported by a DeviceEvent
+const (
+	DeviceAdded   = "added"
+	DeviceUpdated = "updated"
+	DeviceRemoved = "removed"
+)
+
+// DeviceEvent reports that a device was added to, updated in or removed from core-metadata. For a removed device,
+// Device holds the device as it was last seen.
+type DeviceEvent struct {
+	Action string        `json:"action"`
+	Device models.Device `json:"device"`
+	Time   time.Time     `json:"time"`
+}
+
+// Watcher polls core-metadata for the devices and reports the devices added, updated and removed since the previous
+// poll. The devices found by the first poll are the baseline and are not reported. A device is reported as updated
+// when its Modified timestamp changes.
+type Watcher struct {
+	client   metadata.DeviceClient
+	interval time.Duration
+	logging  logger.LoggingClient
+	devices  map[string]models.Device
+	polled   bool
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+// NewWatcher creates a watcher of the devices of core-metadata. An interval of zero uses DefaultPollInterval.
+func NewWatcher(client metadata.DeviceClient, interval time.Duration, logging logger.LoggingClient) *Watcher {
+	if interval <= 0 {
+		interval = DefaultPollInterval
+	}
+	return &Watcher{
+		client:   client,
+		interval: interval,
+		logging:  logging,
+		devices:  map[string]models.Device{},
+		stop:     make(chan struct{}),
+	}
+}
+
+// Poll retrieves the devices from core-metadata and returns the changes since the previous poll. When retrieving the
+// devices fails, the error is returned and the changes are reported by the next successful poll.
+func (watcher *Watcher) Poll() ([]DeviceEvent, error) {
+	current, err := watcher.client.Devices(syscontext.Background())
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	var events []DeviceEvent
+	devices := make(map[string]models.Device, len(current))
+	for _, device := range current {
+		devices[device.Id] = device
+		if !watcher.polled {
+			continue
+		}
+		previous, known := watcher.devices[device.Id]
+		switch {
+		case !known:
+			events = append(events, DeviceEvent{Action: DeviceAdded, Device: device, Time: now})
+		case previous.Modified != device.Modified:
+			events = append(events, DeviceEvent{Action: DeviceUpdated, Device: device, Time: now})
+		}
+	}
+	if watcher.polled {
+		for _, device := range current {
+			delete(watcher.devices, device.Id)
+		}
+		for _, device := range watcher.devices {
+			events = append(events, DeviceEvent{Action: DeviceRemoved, Device: device, Time: now})
+		}
+	}
+
+	watcher.devices = devices
+	watcher.polled = true
+	return events, nil
+}
+
+// Run polls core-metadata at each interval and calls the handler with each change, until Stop is called. Polling
+// errors are logged.
+func (watcher *Watcher) Run(handler func(DeviceEvent)) {
+	ticker := time.NewTicker(watcher.interval)
+	defer ticker.Stop()
+
+	for {
+		events, err := watcher.Poll()
+		if err != nil {
+			watcher.logging.Error("Unable to retrieve devices from core-metadata: " + err.Error())
+		}
+		for _, event := range events {
+			handler(event)
+		}
+
+		select {
+		case <-watcher.stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Stop ends Run
+func (watcher *Watcher) Stop() {
+	watcher.stopOnce.Do(func() {
+		close(watcher.stop)
+	})
+}
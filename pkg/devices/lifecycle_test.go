@@ -0,0 +1,120 @@
+//
+// Copyright (c) 2019 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package devices
+
+import (
+	syscontext "context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/clients/logger"
+	"github.com/edgexfoundry/go-mod-core-contracts/clients/metadata"
+	"github.com/edgexfoundry/go-mod-core-contracts/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var lc = logger.NewClient("app_functions_sdk_go", false, "./test.log", "DEBUG")
+
+type fakeDeviceClient struct {
+	metadata.DeviceClient
+	mutex   sync.Mutex
+	devices []models.Device
+	err     error
+}
+
+func (client *fakeDeviceClient) Devices(ctx syscontext.Context) ([]models.Device, error) {
+	client.mutex.Lock()
+	defer client.mutex.Unlock()
+	return client.devices, client.err
+}
+
+func device(id string, name string, modified int64) models.Device {
+	device := models.Device{Id: id, Name: name}
+	device.Modified = modified
+	return device
+}
+
+func TestWatcherPoll(t *testing.T) {
+	client := &fakeDeviceClient{devices: []models.Device{device("1", "thermostat", 1), device("2", "camera", 1)}}
+	watcher := NewWatcher(client, 0, lc)
+
+	events, err := watcher.Poll()
+	require.NoError(t, err)
+	assert.Equal(t, 0, len(events), "the devices of the first poll are the baseline")
+
+	client.devices = []models.Device{device("1", "thermostat", 2), device("3", "valve", 1)}
+	events, err = watcher.Poll()
+	require.NoError(t, err)
+	require.Equal(t, 3, len(events))
+	assert.Equal(t, DeviceUpdated, events[0].Action)
+	assert.Equal(t, "thermostat", events[0].Device.Name)
+	assert.Equal(t, DeviceAdded, events[1].Action)
+	assert.Equal(t, "valve", events[1].Device.Name)
+	assert.Equal(t, DeviceRemoved, events[2].Action)
+	assert.Equal(t, "camera", events[2].Device.Name)
+
+	client.err = errors.New("connection refused")
+	_, err = watcher.Poll()
+	assert.Error(t, err)
+
+	client.err = nil
+	client.devices = []models.Device{device("3", "valve", 1)}
+	events, err = watcher.Poll()
+	require.NoError(t, err)
+	require.Equal(t, 1, len(events), "changes should be reported after a failed poll")
+	assert.Equal(t, DeviceRemoved, events[0].Action)
+	assert.Equal(t, "thermostat", events[0].Device.Name)
+}
+
+func TestWatcherRun(t *testing.T) {
+	client := &fakeDeviceClient{}
+	watcher := NewWatcher(client, 10*time.Millisecond, lc)
+	assert.Equal(t, 10*time.Millisecond, watcher.interval)
+
+	received := make(chan DeviceEvent, 1)
+	done := make(chan struct{})
+	go func() {
+		watcher.Run(func(event DeviceEvent) {
+			received <- event
+		})
+		close(done)
+	}()
+
+	time.Sleep(30 * time.Millisecond)
+	client.mutex.Lock()
+	client.devices = []models.Device{device("1", "thermostat", 1)}
+	client.mutex.Unlock()
+
+	select {
+	case event := <-received:
+		assert.Equal(t, DeviceAdded, event.Action)
+		assert.Equal(t, "thermostat", event.Device.Name)
+	case <-time.After(5 * time.Second):
+		t.Fatal("the added device was not reported")
+	}
+
+	watcher.Stop()
+	watcher.Stop()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run did not return after Stop")
+	}
+}
@@ -0,0 +1,146 @@
+//
+// Copyright (c) 2019 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package di
+
+import (
+	"fmt"
+	"sync"
+)
+
+// Names of the services registered by the SDK. Registering a constructor under one of these names before calling
+// MakeItRun substitutes the SDK's implementation, i.e. a mock EventClient in tests or a custom Trigger.
+const (
+	ConfigurationName       = "Configuration"
+	LoggingClientName       = "LoggingClient"
+	EventClientName         = "EventClient"
+	CommandClientName       = "CommandClient"
+	NotificationsClientName = "NotificationsClient"
+	DeviceClientName        = "DeviceClient"
+	SecretProviderName      = "SecretProvider"
+	RuntimeName             = "Runtime"
+	WebServerName           = "WebServer"
+	TriggerName             = "Trigger"
+)
+
+// Get returns the instance of the named service, or nil if no such service is registered
+type Get func(serviceName string) interface{}
+
+// ServiceConstructor creates a service instance, retrieving the services it depends on using get
+type ServiceConstructor func(get Get) interface{}
+
+// ServiceConstructorMap maps service names to their constructors
+type ServiceConstructorMap map[string]ServiceConstructor
+
+type service struct {
+	constructor ServiceConstructor
+	instance    interface{}
+	created     bool
+}
+
+// Container holds services by name. Each service is created by its constructor the first time it is retrieved, so
+// services can be registered in any order as long as their dependencies are registered before they are used.
+type Container struct {
+	serviceMap   map[string]*service
+	constructing map[string]bool
+	// cycle is the circular dependency found while resolving a service
+	cycle error
+	mutex sync.Mutex
+}
+
+// NewContainer creates a container with the specified constructors registered
+func NewContainer(constructors ServiceConstructorMap) *Container {
+	container := &Container{
+		serviceMap:   map[string]*service{},
+		constructing: map[string]bool{},
+	}
+	container.Update(constructors)
+	return container
+}
+
+// Update registers the specified constructors, replacing any services already registered with the same names
+func (container *Container) Update(constructors ServiceConstructorMap) {
+	container.mutex.Lock()
+	defer container.mutex.Unlock()
+
+	for name, constructor := range constructors {
+		container.serviceMap[name] = &service{constructor: constructor}
+	}
+}
+
+// SetDefaults registers the specified constructors for names which do not yet have a service registered
+func (container *Container) SetDefaults(constructors ServiceConstructorMap) {
+	container.mutex.Lock()
+	defer container.mutex.Unlock()
+
+	for name, constructor := range constructors {
+		if _, exists := container.serviceMap[name]; !exists {
+			container.serviceMap[name] = &service{constructor: constructor}
+		}
+	}
+}
+
+// Get returns the instance of the named service, creating it if this is the first time it is retrieved. Nil is also
+// returned when the service depends on itself, which Resolve reports as an error.
+func (container *Container) Get(serviceName string) interface{} {
+	instance, _ := container.Resolve(serviceName)
+	return instance
+}
+
+// Resolve returns the instance of the named service like Get, or an error when its constructor depends on the
+// service itself, directly or through other services
+func (container *Container) Resolve(serviceName string) (interface{}, error) {
+	container.mutex.Lock()
+	defer container.mutex.Unlock()
+
+	container.cycle = nil
+	instance := container.get(serviceName)
+	if container.cycle != nil {
+		return nil, container.cycle
+	}
+	return instance, nil
+}
+
+// get resolves a service while the mutex is held, which is also the Get passed to constructors. A service which is
+// already being constructed is a circular dependency, for which nil is returned and the cycle recorded.
+func (container *Container) get(serviceName string) interface{} {
+	service, exists := container.serviceMap[serviceName]
+	if !exists {
+		return nil
+	}
+
+	if !service.created {
+		if container.constructing[serviceName] {
+			if container.cycle == nil {
+				container.cycle = fmt.Errorf("circular dependency detected while constructing service '%s'", serviceName)
+			}
+			return nil
+		}
+
+		container.constructing[serviceName] = true
+		defer delete(container.constructing, serviceName)
+		instance := service.constructor(container.get)
+		if container.cycle != nil {
+			// the service is constructed again the next time it's retrieved, rather than kept without a dependency
+			return nil
+		}
+
+		service.instance = instance
+		service.created = true
+	}
+
+	return service.instance
+}
@@ -0,0 +1,101 @@
+//
+// Copyright (c) 2019 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package di
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetResolvesDependenciesInAnyOrder(t *testing.T) {
+	created := 0
+	container := NewContainer(ServiceConstructorMap{
+		"consumer": func(get Get) interface{} {
+			return "consumer of " + get("dependency").(string)
+		},
+	})
+	container.Update(ServiceConstructorMap{
+		"dependency": func(get Get) interface{} {
+			created++
+			return "dependency"
+		},
+	})
+
+	assert.Equal(t, "consumer of dependency", container.Get("consumer"))
+	assert.Equal(t, "dependency", container.Get("dependency"))
+	assert.Equal(t, 1, created, "Service should only be constructed once")
+}
+
+func TestGetUnregistered(t *testing.T) {
+	container := NewContainer(nil)
+	assert.Nil(t, container.Get("missing"))
+}
+
+func TestUpdateReplacesService(t *testing.T) {
+	container := NewContainer(ServiceConstructorMap{
+		"service": func(get Get) interface{} { return "original" },
+	})
+	assert.Equal(t, "original", container.Get("service"))
+
+	container.Update(ServiceConstructorMap{
+		"service": func(get Get) interface{} { return "replacement" },
+	})
+	assert.Equal(t, "replacement", container.Get("service"))
+}
+
+func TestSetDefaultsKeepsRegisteredService(t *testing.T) {
+	container := NewContainer(ServiceConstructorMap{
+		TriggerName: func(get Get) interface{} { return "custom" },
+	})
+	container.SetDefaults(ServiceConstructorMap{
+		TriggerName: func(get Get) interface{} { return "default" },
+		RuntimeName: func(get Get) interface{} { return "runtime" },
+	})
+
+	assert.Equal(t, "custom", container.Get(TriggerName))
+	assert.Equal(t, "runtime", container.Get(RuntimeName))
+}
+
+func TestGetCircularDependency(t *testing.T) {
+	container := NewContainer(ServiceConstructorMap{
+		"a": func(get Get) interface{} { return get("b") },
+		"b": func(get Get) interface{} { return get("a") },
+	})
+
+	_, err := container.Resolve("a")
+	assert.EqualError(t, err, "circular dependency detected while constructing service 'a'")
+	assert.Nil(t, container.Get("b"))
+}
+
+func TestGetAfterConstructorPanics(t *testing.T) {
+	fail := true
+	container := NewContainer(ServiceConstructorMap{
+		"a": func(get Get) interface{} {
+			if fail {
+				panic("unavailable")
+			}
+			return "a"
+		},
+	})
+
+	assert.Panics(t, func() { container.Get("a") })
+	fail = false
+	instance, err := container.Resolve("a")
+	assert.NoError(t, err, "a constructor which panicked shouldn't be reported as a circular dependency")
+	assert.Equal(t, "a", instance)
+}
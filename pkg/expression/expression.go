@@ -0,0 +1,389 @@
+//
+// Copyright (c) 2019 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+// Package expression evaluates expressions such as `Float64(value) > 40 && deviceName =~ "Random-.*"` against named
+// parameters. Expressions have number, string and boolean values and support the following, by increasing
+// precedence:
+//   - the conditional operator `cond ? a : b`
+//   - the logical operators `||` and `&&`
+//   - the comparison operators `==`, `!=`, `<`, `<=`, `>`, `>=`, and `=~` and `!~` which match a string against a
+//     regular expression
+//   - the arithmetic operators `+`, `-`, `*`, `/` and `%`, `+` concatenating strings
+//   - the unary operators `!` and `-`
+//
+// Parameters are referenced by name, or between brackets for names which aren't identifiers, i.e. `[Random-Float]`.
+// The functions Float64, String, Abs, Round, Min and Max are available.
+package expression
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Expression is a parsed expression, which can be evaluated concurrently
+type Expression struct {
+	text string
+	root node
+}
+
+// node is a node of the syntax tree of an expression
+type node interface {
+	evaluate(parameters map[string]interface{}) (interface{}, error)
+}
+
+// Parse parses the text of an expression
+func Parse(text string) (*Expression, error) {
+	tokens, err := tokenize(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid expression '%s': %v", text, err)
+	}
+	p := &parser{tokens: tokens}
+	root, err := p.parseConditional()
+	if err == nil && p.peek().kind != tokenEnd {
+		err = fmt.Errorf("unexpected %s at position %d", p.peek(), p.peek().position)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid expression '%s': %v", text, err)
+	}
+	return &Expression{text: text, root: root}, nil
+}
+
+// String returns the text of the expression
+func (e *Expression) String() string {
+	return e.text
+}
+
+// Evaluate evaluates the expression with the parameters, returning a float64, string or bool. Parameters of other
+// numeric types are converted to float64.
+func (e *Expression) Evaluate(parameters map[string]interface{}) (interface{}, error) {
+	return e.root.evaluate(parameters)
+}
+
+// EvaluateBool evaluates the expression with the parameters, returning an error if the result isn't a boolean
+func (e *Expression) EvaluateBool(parameters map[string]interface{}) (bool, error) {
+	result, err := e.Evaluate(parameters)
+	if err != nil {
+		return false, err
+	}
+	b, ok := result.(bool)
+	if !ok {
+		return false, fmt.Errorf("expression '%s' returned %s, expecting a boolean", e.text, FormatValue(result))
+	}
+	return b, nil
+}
+
+// FormatValue formats a value returned by an expression, numbers with the fewest digits needed
+func FormatValue(value interface{}) string {
+	switch v := value.(type) {
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case string:
+		return v
+	case bool:
+		return strconv.FormatBool(v)
+	}
+	return fmt.Sprint(value)
+}
+
+// normalize converts the numeric types of parameters to float64
+func normalize(value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case float64, string, bool:
+		return v, nil
+	case float32:
+		return float64(v), nil
+	case int:
+		return float64(v), nil
+	case int8:
+		return float64(v), nil
+	case int16:
+		return float64(v), nil
+	case int32:
+		return float64(v), nil
+	case int64:
+		return float64(v), nil
+	case uint:
+		return float64(v), nil
+	case uint8:
+		return float64(v), nil
+	case uint16:
+		return float64(v), nil
+	case uint32:
+		return float64(v), nil
+	case uint64:
+		return float64(v), nil
+	}
+	return nil, fmt.Errorf("unsupported value type %T", value)
+}
+
+func toNumber(value interface{}) (float64, error) {
+	switch v := value.(type) {
+	case float64:
+		return v, nil
+	case bool:
+		if v {
+			return 1, nil
+		}
+		return 0, nil
+	case string:
+		number, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		if err != nil {
+			return 0, fmt.Errorf("'%s' is not a number", v)
+		}
+		return number, nil
+	}
+	return 0, fmt.Errorf("unsupported value type %T", value)
+}
+
+type literal struct {
+	value interface{}
+}
+
+func (n literal) evaluate(parameters map[string]interface{}) (interface{}, error) {
+	return n.value, nil
+}
+
+type parameter struct {
+	name string
+}
+
+func (n parameter) evaluate(parameters map[string]interface{}) (interface{}, error) {
+	value, ok := parameters[n.name]
+	if !ok {
+		return nil, fmt.Errorf("unknown parameter '%s'", n.name)
+	}
+	return normalize(value)
+}
+
+type conditional struct {
+	condition, whenTrue, whenFalse node
+}
+
+func (n conditional) evaluate(parameters map[string]interface{}) (interface{}, error) {
+	condition, err := evaluateBool(n.condition, parameters, "?")
+	if err != nil {
+		return nil, err
+	}
+	if condition {
+		return n.whenTrue.evaluate(parameters)
+	}
+	return n.whenFalse.evaluate(parameters)
+}
+
+type unary struct {
+	operator string
+	operand  node
+}
+
+func (n unary) evaluate(parameters map[string]interface{}) (interface{}, error) {
+	if n.operator == "!" {
+		value, err := evaluateBool(n.operand, parameters, "!")
+		return !value, err
+	}
+	value, err := n.operand.evaluate(parameters)
+	if err != nil {
+		return nil, err
+	}
+	number, ok := value.(float64)
+	if !ok {
+		return nil, fmt.Errorf("operator - expects a number, got %s", describe(value))
+	}
+	return -number, nil
+}
+
+type logical struct {
+	operator    string
+	left, right node
+}
+
+func (n logical) evaluate(parameters map[string]interface{}) (interface{}, error) {
+	left, err := evaluateBool(n.left, parameters, n.operator)
+	if err != nil {
+		return nil, err
+	}
+	if (n.operator == "&&") != left {
+		return left, nil
+	}
+	return evaluateBool(n.right, parameters, n.operator)
+}
+
+func evaluateBool(n node, parameters map[string]interface{}, operator string) (bool, error) {
+	value, err := n.evaluate(parameters)
+	if err != nil {
+		return false, err
+	}
+	b, ok := value.(bool)
+	if !ok {
+		return false, fmt.Errorf("operator %s expects a boolean, got %s", operator, describe(value))
+	}
+	return b, nil
+}
+
+type match struct {
+	negate  bool
+	left    node
+	right   node
+	pattern *regexp.Regexp
+}
+
+func (n match) evaluate(parameters map[string]interface{}) (interface{}, error) {
+	operator := "=~"
+	if n.negate {
+		operator = "!~"
+	}
+	value, err := n.left.evaluate(parameters)
+	if err != nil {
+		return nil, err
+	}
+	s, ok := value.(string)
+	if !ok {
+		return nil, fmt.Errorf("operator %s expects a string, got %s", operator, describe(value))
+	}
+
+	pattern := n.pattern
+	if pattern == nil {
+		value, err := n.right.evaluate(parameters)
+		if err != nil {
+			return nil, err
+		}
+		expr, ok := value.(string)
+		if !ok {
+			return nil, fmt.Errorf("operator %s expects a regular expression, got %s", operator, describe(value))
+		}
+		if pattern, err = regexp.Compile(expr); err != nil {
+			return nil, fmt.Errorf("invalid regular expression '%s': %v", expr, err)
+		}
+	}
+	return pattern.MatchString(s) != n.negate, nil
+}
+
+type binary struct {
+	operator    string
+	left, right node
+}
+
+func (n binary) evaluate(parameters map[string]interface{}) (interface{}, error) {
+	left, err := n.left.evaluate(parameters)
+	if err != nil {
+		return nil, err
+	}
+	right, err := n.right.evaluate(parameters)
+	if err != nil {
+		return nil, err
+	}
+
+	switch n.operator {
+	case "==":
+		return left == right, nil
+	case "!=":
+		return left != right, nil
+	case "+":
+		_, leftString := left.(string)
+		_, rightString := right.(string)
+		if leftString || rightString {
+			return FormatValue(left) + FormatValue(right), nil
+		}
+	case "<", "<=", ">", ">=":
+		if l, ok := left.(string); ok {
+			if r, ok := right.(string); ok {
+				return compare(n.operator, strings.Compare(l, r)), nil
+			}
+		}
+	}
+
+	l, leftOk := left.(float64)
+	r, rightOk := right.(float64)
+	if !leftOk || !rightOk {
+		return nil, fmt.Errorf("operator %s expects numbers, got %s and %s", n.operator, describe(left), describe(right))
+	}
+	switch n.operator {
+	case "<", "<=", ">", ">=":
+		switch {
+		case l < r:
+			return compare(n.operator, -1), nil
+		case l > r:
+			return compare(n.operator, 1), nil
+		}
+		return compare(n.operator, 0), nil
+	case "+":
+		return l + r, nil
+	case "-":
+		return l - r, nil
+	case "*":
+		return l * r, nil
+	case "/":
+		if r == 0 {
+			return nil, errors.New("division by zero")
+		}
+		return l / r, nil
+	case "%":
+		if r == 0 {
+			return nil, errors.New("division by zero")
+		}
+		return math.Mod(l, r), nil
+	}
+	return nil, fmt.Errorf("unknown operator %s", n.operator)
+}
+
+// compare returns the result of a comparison operator given the sign of the difference of its operands
+func compare(operator string, sign int) bool {
+	switch operator {
+	case "<":
+		return sign < 0
+	case "<=":
+		return sign <= 0
+	case ">":
+		return sign > 0
+	}
+	return sign >= 0
+}
+
+func describe(value interface{}) string {
+	switch value.(type) {
+	case float64:
+		return "number " + FormatValue(value)
+	case string:
+		return strconv.Quote(value.(string))
+	case bool:
+		return "boolean " + FormatValue(value)
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+type call struct {
+	function  function
+	arguments []node
+}
+
+func (n call) evaluate(parameters map[string]interface{}) (interface{}, error) {
+	arguments := make([]interface{}, len(n.arguments))
+	for i, argument := range n.arguments {
+		value, err := argument.evaluate(parameters)
+		if err != nil {
+			return nil, err
+		}
+		arguments[i] = value
+	}
+	result, err := n.function.call(arguments)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", n.function.name, err)
+	}
+	return result, nil
+}
@@ -0,0 +1,134 @@
+//
+// Copyright (c) 2019 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package expression
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEvaluate(t *testing.T) {
+	parameters := map[string]interface{}{
+		"value":        "42.5",
+		"deviceName":   "Random-Float-Device",
+		"origin":       int64(1559390400000),
+		"count":        3,
+		"enabled":      true,
+		"Random-Float": 1.5,
+	}
+
+	tests := []struct {
+		Expression string
+		Expected   interface{}
+	}{
+		{`Float64(value) > 40 && deviceName =~ "Random-*"`, true},
+		{`Float64(value) > 40 && deviceName !~ "^Random"`, false},
+		{`1 + 2 * 3 - 4 / 2`, 5.0},
+		{`(1 + 2) * 3 % 4`, 1.0},
+		{`-count + 1`, -2.0},
+		{`!enabled || count >= 3`, true},
+		{`count == 3 && count != 4 && count <= 3 && count < 4`, true},
+		{`deviceName + "/" + count`, "Random-Float-Device/3"},
+		{`"abc" < "abd"`, true},
+		{`value == "42.5"`, true},
+		{`value == 42.5`, false},
+		{`Float64(value) > 50 ? "high" : "low"`, "low"},
+		{`[Random-Float] * 2`, 3.0},
+		{`Abs(-2.5) + Min(3, 1, 2) + Max(count, 4)`, 7.5},
+		{`Round(Float64(value) * 1.8 + 32, 1)`, 108.5},
+		{`String(origin)`, "1559390400000"},
+		{`'single \'quoted\''`, "single 'quoted'"},
+		{`false && Float64(deviceName) > 0`, false},
+	}
+	for _, test := range tests {
+		t.Run(test.Expression, func(t *testing.T) {
+			expression, err := Parse(test.Expression)
+			require.NoError(t, err)
+
+			result, err := expression.Evaluate(parameters)
+			require.NoError(t, err)
+			assert.Equal(t, test.Expected, result)
+		})
+	}
+}
+
+func TestEvaluateErrors(t *testing.T) {
+	parameters := map[string]interface{}{"value": "abc", "count": 3}
+
+	tests := []struct {
+		Expression string
+		Error      string
+	}{
+		{`missing > 1`, "unknown parameter 'missing'"},
+		{`Float64(value) > 1`, "Float64: 'abc' is not a number"},
+		{`value > 1`, `operator > expects numbers, got "abc" and number 1`},
+		{`count && true`, "operator && expects a boolean, got number 3"},
+		{`count / 0`, "division by zero"},
+		{`count =~ "3"`, "operator =~ expects a string, got number 3"},
+		{`value =~ ("[" + value)`, "invalid regular expression '[abc': error parsing regexp: missing closing ]: `[abc`"},
+	}
+	for _, test := range tests {
+		t.Run(test.Expression, func(t *testing.T) {
+			expression, err := Parse(test.Expression)
+			require.NoError(t, err)
+
+			_, err = expression.Evaluate(parameters)
+			assert.EqualError(t, err, test.Error)
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		Expression string
+		Error      string
+	}{
+		{``, "invalid expression '': unexpected end of expression"},
+		{`1 +`, "invalid expression '1 +': unexpected end of expression"},
+		{`(1 + 2`, "invalid expression '(1 + 2': expected ')' at position 6, got end of expression"},
+		{`1 2`, "invalid expression '1 2': unexpected '2' at position 2"},
+		{`value = 1`, "invalid expression 'value = 1': unexpected character '=' at position 6"},
+		{`"abc`, "invalid expression '\"abc': unterminated string at position 0"},
+		{`Sqrt(2)`, "invalid expression 'Sqrt(2)': unknown function 'Sqrt' at position 0"},
+		{`Abs(1, 2)`, "invalid expression 'Abs(1, 2)': Abs expects 1 argument(s), got 2"},
+		{`Max()`, "invalid expression 'Max()': Max expects at least one argument"},
+		{`name =~ "["`, "invalid expression 'name =~ \"[\"': invalid regular expression '[': error parsing regexp: missing closing ]: `[`"},
+		{`true ? 1`, "invalid expression 'true ? 1': expected ':' at position 8, got end of expression"},
+	}
+	for _, test := range tests {
+		t.Run(test.Expression, func(t *testing.T) {
+			_, err := Parse(test.Expression)
+			assert.EqualError(t, err, test.Error)
+		})
+	}
+}
+
+func TestEvaluateBool(t *testing.T) {
+	expression, err := Parse("count > 1")
+	require.NoError(t, err)
+	result, err := expression.EvaluateBool(map[string]interface{}{"count": 2})
+	require.NoError(t, err)
+	assert.True(t, result)
+
+	expression, err = Parse("count + 1")
+	require.NoError(t, err)
+	_, err = expression.EvaluateBool(map[string]interface{}{"count": 2})
+	assert.EqualError(t, err, "expression 'count + 1' returned 3, expecting a boolean")
+	assert.Equal(t, "count + 1", expression.String())
+}
@@ -0,0 +1,93 @@
+//
+// Copyright (c) 2019 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package expression
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// function is a function which can be called by expressions
+type function struct {
+	name string
+	// arity is the number of arguments, or -1 for one or more
+	arity int
+	call  func(arguments []interface{}) (interface{}, error)
+}
+
+var functions = map[string]function{}
+
+func init() {
+	for _, f := range []function{
+		{"Float64", 1, func(arguments []interface{}) (interface{}, error) {
+			return toNumber(arguments[0])
+		}},
+		{"String", 1, func(arguments []interface{}) (interface{}, error) {
+			return FormatValue(arguments[0]), nil
+		}},
+		{"Abs", 1, func(arguments []interface{}) (interface{}, error) {
+			number, err := toNumber(arguments[0])
+			return math.Abs(number), err
+		}},
+		{"Round", 2, func(arguments []interface{}) (interface{}, error) {
+			number, err := toNumber(arguments[0])
+			if err != nil {
+				return nil, err
+			}
+			places, err := toNumber(arguments[1])
+			if err != nil {
+				return nil, err
+			}
+			return strconv.ParseFloat(strconv.FormatFloat(number, 'f', int(places), 64), 64)
+		}},
+		{"Min", -1, func(arguments []interface{}) (interface{}, error) {
+			return reduce(arguments, math.Min)
+		}},
+		{"Max", -1, func(arguments []interface{}) (interface{}, error) {
+			return reduce(arguments, math.Max)
+		}},
+	} {
+		functions[f.name] = f
+	}
+}
+
+func reduce(arguments []interface{}, combine func(float64, float64) float64) (interface{}, error) {
+	var result float64
+	for i, argument := range arguments {
+		number, err := toNumber(argument)
+		if err != nil {
+			return nil, err
+		}
+		if i == 0 {
+			result = number
+		} else {
+			result = combine(result, number)
+		}
+	}
+	return result, nil
+}
+
+func (f function) checkArity(count int) error {
+	if f.arity < 0 && count == 0 {
+		return fmt.Errorf("%s expects at least one argument", f.name)
+	}
+	if f.arity >= 0 && count != f.arity {
+		return fmt.Errorf("%s expects %d argument(s), got %d", f.name, f.arity, count)
+	}
+	return nil
+}
@@ -0,0 +1,297 @@
+//
+// Copyright (c) 2019 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package expression
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+type tokenKind int
+
+const (
+	tokenEnd tokenKind = iota
+	tokenNumber
+	tokenString
+	tokenIdentifier
+	tokenOperator
+)
+
+type token struct {
+	kind     tokenKind
+	text     string
+	value    interface{}
+	position int
+}
+
+func (t token) String() string {
+	if t.kind == tokenEnd {
+		return "end of expression"
+	}
+	return "'" + t.text + "'"
+}
+
+// operators are sorted so that the longest operators match first
+var operators = []string{"||", "&&", "==", "!=", "<=", ">=", "=~", "!~", "<", ">", "+", "-", "*", "/", "%", "!", "?", ":", "(", ")", ","}
+
+func tokenize(text string) ([]token, error) {
+	var tokens []token
+	runes := []rune(text)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+
+		case unicode.IsDigit(r) || (r == '.' && i+1 < len(runes) && unicode.IsDigit(runes[i+1])):
+			start := i
+			for i < len(runes) && (unicode.IsDigit(runes[i]) || runes[i] == '.') {
+				i++
+			}
+			number, err := strconv.ParseFloat(string(runes[start:i]), 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid number '%s' at position %d", string(runes[start:i]), start)
+			}
+			tokens = append(tokens, token{kind: tokenNumber, text: string(runes[start:i]), value: number, position: start})
+
+		case r == '"' || r == '\'':
+			start := i
+			var value strings.Builder
+			for i++; i < len(runes) && runes[i] != r; i++ {
+				if runes[i] == '\\' && i+1 < len(runes) {
+					i++
+				}
+				value.WriteRune(runes[i])
+			}
+			if i == len(runes) {
+				return nil, fmt.Errorf("unterminated string at position %d", start)
+			}
+			i++
+			tokens = append(tokens, token{kind: tokenString, text: string(runes[start:i]), value: value.String(), position: start})
+
+		case r == '[':
+			start := i
+			end := strings.IndexRune(string(runes[i:]), ']')
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated parameter name at position %d", start)
+			}
+			name := string(runes[i:])[1:end]
+			i += len([]rune(name)) + 2
+			tokens = append(tokens, token{kind: tokenIdentifier, text: name, position: start})
+
+		case unicode.IsLetter(r) || r == '_':
+			start := i
+			for i < len(runes) && (unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i]) || runes[i] == '_' || runes[i] == '.') {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokenIdentifier, text: string(runes[start:i]), position: start})
+
+		default:
+			matched := false
+			for _, operator := range operators {
+				if strings.HasPrefix(string(runes[i:]), operator) {
+					tokens = append(tokens, token{kind: tokenOperator, text: operator, position: i})
+					i += len(operator)
+					matched = true
+					break
+				}
+			}
+			if !matched {
+				return nil, fmt.Errorf("unexpected character '%c' at position %d", r, i)
+			}
+		}
+	}
+	return append(tokens, token{kind: tokenEnd, position: len(runes)}), nil
+}
+
+// parser is a recursive descent parser of the tokens of an expression
+type parser struct {
+	tokens []token
+	next   int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.next]
+}
+
+// accept consumes the next token if it's one of the operators
+func (p *parser) accept(operators ...string) (string, bool) {
+	t := p.peek()
+	if t.kind != tokenOperator {
+		return "", false
+	}
+	for _, operator := range operators {
+		if t.text == operator {
+			p.next++
+			return operator, true
+		}
+	}
+	return "", false
+}
+
+func (p *parser) expect(operator string) error {
+	if _, ok := p.accept(operator); !ok {
+		return fmt.Errorf("expected '%s' at position %d, got %s", operator, p.peek().position, p.peek())
+	}
+	return nil
+}
+
+func (p *parser) parseConditional() (node, error) {
+	condition, err := p.parseBinary(0)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := p.accept("?"); !ok {
+		return condition, nil
+	}
+	whenTrue, err := p.parseConditional()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.expect(":"); err != nil {
+		return nil, err
+	}
+	whenFalse, err := p.parseConditional()
+	if err != nil {
+		return nil, err
+	}
+	return conditional{condition: condition, whenTrue: whenTrue, whenFalse: whenFalse}, nil
+}
+
+// precedence lists the binary operators by increasing precedence
+var precedence = [][]string{
+	{"||"},
+	{"&&"},
+	{"==", "!=", "<", "<=", ">", ">=", "=~", "!~"},
+	{"+", "-"},
+	{"*", "/", "%"},
+}
+
+func (p *parser) parseBinary(level int) (node, error) {
+	if level == len(precedence) {
+		return p.parseUnary()
+	}
+	left, err := p.parseBinary(level + 1)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		operator, ok := p.accept(precedence[level]...)
+		if !ok {
+			return left, nil
+		}
+		right, err := p.parseBinary(level + 1)
+		if err != nil {
+			return nil, err
+		}
+		switch operator {
+		case "||", "&&":
+			left = logical{operator: operator, left: left, right: right}
+		case "=~", "!~":
+			m := match{negate: operator == "!~", left: left, right: right}
+			if pattern, ok := right.(literal); ok {
+				expr, ok := pattern.value.(string)
+				if !ok {
+					return nil, fmt.Errorf("operator %s expects a regular expression", operator)
+				}
+				if m.pattern, err = regexp.Compile(expr); err != nil {
+					return nil, fmt.Errorf("invalid regular expression '%s': %v", expr, err)
+				}
+			}
+			left = m
+		default:
+			left = binary{operator: operator, left: left, right: right}
+		}
+	}
+}
+
+func (p *parser) parseUnary() (node, error) {
+	if operator, ok := p.accept("!", "-"); ok {
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return unary{operator: operator, operand: operand}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *parser) parsePrimary() (node, error) {
+	t := p.peek()
+	switch t.kind {
+	case tokenNumber, tokenString:
+		p.next++
+		return literal{value: t.value}, nil
+
+	case tokenIdentifier:
+		p.next++
+		switch t.text {
+		case "true":
+			return literal{value: true}, nil
+		case "false":
+			return literal{value: false}, nil
+		}
+		if _, ok := p.accept("("); !ok {
+			return parameter{name: t.text}, nil
+		}
+		f, ok := functions[t.text]
+		if !ok {
+			return nil, fmt.Errorf("unknown function '%s' at position %d", t.text, t.position)
+		}
+		var arguments []node
+		if _, ok := p.accept(")"); !ok {
+			for {
+				argument, err := p.parseConditional()
+				if err != nil {
+					return nil, err
+				}
+				arguments = append(arguments, argument)
+				if _, ok := p.accept(","); !ok {
+					break
+				}
+			}
+			if err := p.expect(")"); err != nil {
+				return nil, err
+			}
+		}
+		if err := f.checkArity(len(arguments)); err != nil {
+			return nil, err
+		}
+		return call{function: f, arguments: arguments}, nil
+
+	case tokenOperator:
+		if t.text == "(" {
+			p.next++
+			inner, err := p.parseConditional()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect(")"); err != nil {
+				return nil, err
+			}
+			return inner, nil
+		}
+
+	case tokenEnd:
+		return nil, errors.New("unexpected end of expression")
+	}
+	return nil, fmt.Errorf("unexpected %s at position %d", t, t.position)
+}
@@ -0,0 +1,54 @@
+//
+// Copyright (c) 2019 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package secrets
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+)
+
+// FileProvider reads secrets from a JSON file holding an object of secrets for each path, i.e.
+// {"mqtt": {"username": "edgex", "password": "secret"}}. It is intended for development, where running a secret
+// store isn't worth the effort. The file is read on every call so changes are picked up without a restart.
+type FileProvider struct {
+	Path string
+}
+
+// NewFileProvider creates a provider reading secrets from the JSON file at path
+func NewFileProvider(path string) *FileProvider {
+	return &FileProvider{Path: path}
+}
+
+// GetSecrets returns the secrets stored at the path in the file
+func (provider *FileProvider) GetSecrets(path string, keys ...string) (map[string]string, error) {
+	contents, err := ioutil.ReadFile(provider.Path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read secrets file: %v", err)
+	}
+
+	all := map[string]map[string]string{}
+	if err := json.Unmarshal(contents, &all); err != nil {
+		return nil, fmt.Errorf("unable to parse secrets file '%s': %v", provider.Path, err)
+	}
+
+	secrets, ok := all[path]
+	if !ok {
+		return nil, fmt.Errorf("no secrets found at path '%s'", path)
+	}
+	return selectKeys(path, secrets, keys)
+}
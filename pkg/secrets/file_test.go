@@ -0,0 +1,51 @@
+//
+// Copyright (c) 2019 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package secrets
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileProvider(t *testing.T) {
+	dir, err := ioutil.TempDir("", "secrets")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "secrets.json")
+	require.NoError(t, ioutil.WriteFile(file, []byte(`{"mqtt": {"username": "edgex", "password": "secret"}}`), 0600))
+	provider := NewFileProvider(file)
+
+	secrets, err := provider.GetSecrets("mqtt")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"username": "edgex", "password": "secret"}, secrets)
+
+	secrets, err = provider.GetSecrets("mqtt", "password")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"password": "secret"}, secrets)
+
+	_, err = provider.GetSecrets("mqtt", "password", "apikey")
+	assert.EqualError(t, err, "secrets not found at path 'mqtt': apikey")
+
+	_, err = provider.GetSecrets("influxdb")
+	assert.EqualError(t, err, "no secrets found at path 'influxdb'")
+}
@@ -0,0 +1,53 @@
+//
+// Copyright (c) 2019 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package secrets
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SecretProvider retrieves secrets, such as the API keys used by export functions, from a secret store so they
+// don't need to be held in plain text in the configuration
+type SecretProvider interface {
+	// GetSecrets returns the secrets stored at the path. When keys are specified only those secrets are returned,
+	// and an error is returned if any of them doesn't exist.
+	GetSecrets(path string, keys ...string) (map[string]string, error)
+}
+
+// selectKeys returns the secrets for the specified keys, or all secrets when no keys are specified
+func selectKeys(path string, secrets map[string]string, keys []string) (map[string]string, error) {
+	if len(keys) == 0 {
+		return secrets, nil
+	}
+
+	selected := make(map[string]string, len(keys))
+	var missing []string
+	for _, key := range keys {
+		value, ok := secrets[key]
+		if !ok {
+			missing = append(missing, key)
+			continue
+		}
+		selected[key] = value
+	}
+
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("secrets not found at path '%s': %s", path, strings.Join(missing, ", "))
+	}
+	return selected, nil
+}
@@ -0,0 +1,112 @@
+//
+// Copyright (c) 2019 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package secrets
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// vaultTokenHeader is the header holding the Vault authentication token
+const vaultTokenHeader = "X-Vault-Token"
+
+// VaultProvider reads secrets from a HashiCorp Vault key/value secrets engine. Both version 1 and version 2 of
+// the engine are supported.
+type VaultProvider struct {
+	// URL is the address of Vault, i.e. https://vault:8200
+	URL string
+	// BasePath is prepended to the path of each secret, i.e. "secret/edgex/app-service/"
+	BasePath string
+	// Token authenticates the requests to Vault
+	Token      string
+	httpClient *http.Client
+}
+
+type vaultResponse struct {
+	Data   map[string]interface{} `json:"data"`
+	Errors []string               `json:"errors"`
+}
+
+// NewVaultProvider creates a provider reading secrets from Vault, authenticating with the token held in tokenFile
+func NewVaultProvider(url string, basePath string, tokenFile string) (*VaultProvider, error) {
+	if url == "" {
+		return nil, errors.New("Vault URL must be specified")
+	}
+
+	token, err := ioutil.ReadFile(tokenFile)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read Vault token file: %v", err)
+	}
+
+	return &VaultProvider{
+		URL:        strings.TrimRight(url, "/"),
+		BasePath:   basePath,
+		Token:      strings.TrimSpace(string(token)),
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+	}, nil
+}
+
+// GetSecrets returns the secrets stored at the path, relative to the base path
+func (provider *VaultProvider) GetSecrets(path string, keys ...string) (map[string]string, error) {
+	secretPath := strings.Trim(provider.BasePath+path, "/")
+	request, err := http.NewRequest(http.MethodGet, provider.URL+"/v1/"+secretPath, nil)
+	if err != nil {
+		return nil, err
+	}
+	request.Header.Set(vaultTokenHeader, provider.Token)
+
+	response, err := provider.httpClient.Do(request)
+	if err != nil {
+		return nil, fmt.Errorf("unable to reach Vault: %v", err)
+	}
+	defer response.Body.Close()
+
+	result := vaultResponse{}
+	if err := json.NewDecoder(response.Body).Decode(&result); err != nil && response.StatusCode == http.StatusOK {
+		return nil, fmt.Errorf("unable to parse Vault response: %v", err)
+	}
+	if response.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("no secrets found at path '%s'", path)
+	}
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Vault request failed with status %s: %s", response.Status, strings.Join(result.Errors, "; "))
+	}
+
+	data := result.Data
+	// version 2 of the key/value engine nests the secrets along with their metadata
+	if nested, ok := data["data"].(map[string]interface{}); ok {
+		if _, versioned := data["metadata"]; versioned {
+			data = nested
+		}
+	}
+
+	secrets := make(map[string]string, len(data))
+	for key, value := range data {
+		if text, ok := value.(string); ok {
+			secrets[key] = text
+		} else {
+			encoded, _ := json.Marshal(value)
+			secrets[key] = string(encoded)
+		}
+	}
+	return selectKeys(path, secrets, keys)
+}
@@ -0,0 +1,70 @@
+//
+// Copyright (c) 2019 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package secrets
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVaultProvider(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "test-token", r.Header.Get(vaultTokenHeader))
+		switch r.URL.Path {
+		case "/v1/secret/edgex/mqtt":
+			w.Write([]byte(`{"data": {"username": "edgex", "password": "secret"}}`))
+		case "/v1/secret/edgex/influxdb":
+			w.Write([]byte(`{"data": {"data": {"token": "influx-token"}, "metadata": {"version": 3}}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"errors": []}`))
+		}
+	}))
+	defer server.Close()
+
+	dir, err := ioutil.TempDir("", "secrets")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	tokenFile := filepath.Join(dir, "token")
+	require.NoError(t, ioutil.WriteFile(tokenFile, []byte("test-token\n"), 0600))
+
+	provider, err := NewVaultProvider(server.URL, "secret/edgex/", tokenFile)
+	require.NoError(t, err)
+
+	secrets, err := provider.GetSecrets("mqtt", "username", "password")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"username": "edgex", "password": "secret"}, secrets)
+
+	secrets, err = provider.GetSecrets("influxdb")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"token": "influx-token"}, secrets, "Expected secrets of a versioned key/value engine")
+
+	_, err = provider.GetSecrets("missing")
+	assert.EqualError(t, err, "no secrets found at path 'missing'")
+}
+
+func TestNewVaultProviderMissingTokenFile(t *testing.T) {
+	_, err := NewVaultProvider("http://localhost:8200", "", "/nonexistent/token")
+	assert.Error(t, err)
+}
@@ -0,0 +1,359 @@
+//
+// Copyright (c) 2019 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/antoniomtz/app-functions-sdk-go/appcontext"
+	"github.com/edgexfoundry/go-mod-core-contracts/models"
+)
+
+// The aggregate functions which can be computed over a window
+const (
+	AggregateMin   = "min"
+	AggregateMax   = "max"
+	AggregateAvg   = "avg"
+	AggregateCount = "count"
+	AggregateSum   = "sum"
+)
+
+// AggregationConfig defines the windows the readings of each device are aggregated over
+type AggregationConfig struct {
+	// Functions are the aggregates computed for each window, min, max, avg, count and/or sum
+	Functions []string
+	// Readings are the names of the readings aggregated. All numeric readings are aggregated when empty.
+	Readings []string
+	// Window is the duration of time windows. Either Window or Count must be set.
+	Window time.Duration
+	// Slide is the interval at which time windows are emitted. Windows are tumbling when it's zero or Window,
+	// otherwise they overlap.
+	Slide time.Duration
+	// Count is the number of readings in count windows
+	Count int
+	// SlideCount is the number of readings after which count windows are emitted. Windows are tumbling when it's
+	// zero or Count, otherwise they overlap.
+	SlideCount int
+	// StateFile persists the partial windows when set, so they aren't lost when the service restarts
+	StateFile string
+}
+
+// Aggregator aggregates the numeric readings of each device over windows and emits summary events
+type Aggregator struct {
+	config   AggregationConfig
+	readings map[string]bool
+	mutex    sync.Mutex
+	windows  map[aggregationKey]*aggregationWindow
+	now      func() time.Time
+}
+
+// aggregationKey identifies the readings of a device aggregated together
+type aggregationKey struct {
+	device string
+	name   string
+}
+
+// aggregationWindow is the state of the window of a device's reading, persisted as JSON
+type aggregationWindow struct {
+	Device  string
+	Reading string
+	Samples []aggregationSample
+	// Boundary is the end of the last time window emitted, or the start of the first
+	Boundary time.Time `json:",omitempty"`
+	// Pending is the number of readings since the last count window emitted
+	Pending int `json:",omitempty"`
+}
+
+type aggregationSample struct {
+	Value float64
+	Time  time.Time `json:",omitempty"`
+}
+
+// Validate checks the functions and windows of the config
+func (config AggregationConfig) Validate() error {
+	if len(config.Functions) == 0 {
+		return errors.New("at least one aggregate function must be specified")
+	}
+	for _, function := range config.Functions {
+		switch function {
+		case AggregateMin, AggregateMax, AggregateAvg, AggregateCount, AggregateSum:
+		default:
+			return fmt.Errorf("unknown aggregate function '%s', expected min, max, avg, count or sum", function)
+		}
+	}
+
+	if (config.Window > 0) == (config.Count > 0) {
+		return errors.New("either a window duration or a count must be specified")
+	}
+	if config.Slide < 0 || config.SlideCount < 0 {
+		return errors.New("window slide must not be negative")
+	}
+	if config.Window > 0 && (config.SlideCount > 0 || config.Slide > config.Window) {
+		return errors.New("time windows must slide by a duration no greater than the window")
+	}
+	if config.Count > 0 && (config.Slide > 0 || config.SlideCount > config.Count) {
+		return errors.New("count windows must slide by a count no greater than the window")
+	}
+	return nil
+}
+
+// NewAggregator creates an Aggregator for the config, loading the partial windows from its StateFile if any
+func NewAggregator(config AggregationConfig) (*Aggregator, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	if config.Slide == 0 {
+		config.Slide = config.Window
+	}
+	if config.SlideCount == 0 {
+		config.SlideCount = config.Count
+	}
+
+	aggregator := &Aggregator{
+		config:   config,
+		readings: map[string]bool{},
+		windows:  map[aggregationKey]*aggregationWindow{},
+		now:      time.Now,
+	}
+	for _, name := range config.Readings {
+		aggregator.readings[name] = true
+	}
+
+	if config.StateFile != "" {
+		contents, err := ioutil.ReadFile(config.StateFile)
+		if err != nil && !os.IsNotExist(err) {
+			return nil, fmt.Errorf("unable to read aggregation state file: %v", err)
+		}
+		if len(contents) > 0 {
+			var windows []*aggregationWindow
+			if err := json.Unmarshal(contents, &windows); err != nil {
+				return nil, fmt.Errorf("unable to parse aggregation state file '%s': %v", config.StateFile, err)
+			}
+			for _, window := range windows {
+				aggregator.windows[aggregationKey{device: window.Device, name: window.Reading}] = window
+			}
+		}
+	}
+
+	return aggregator, nil
+}
+
+// Aggregate adds the numeric readings of the event from the previous function to the windows of its device. When
+// windows are complete, a summary event of the device is returned with a reading per window and aggregate function,
+// named <reading>_<function>, i.e. Temperature_avg, whose origin is the end of the window. Otherwise the pipeline
+// execution stops. Time windows are aligned to multiples of their slide and are complete once a reading arrives
+// after they end.
+func (aggregator *Aggregator) Aggregate(edgexcontext *appcontext.Context, params ...interface{}) (bool, interface{}) {
+	if len(params) < 1 {
+		return false, errors.New("No Event Received")
+	}
+
+	event, ok := params[0].(models.Event)
+	if !ok {
+		return false, errors.New("Unexpected type received, expecting models.Event")
+	}
+
+	now := aggregator.now()
+
+	aggregator.mutex.Lock()
+	defer aggregator.mutex.Unlock()
+
+	var summaries []models.Reading
+	changed := false
+	for _, reading := range event.Readings {
+		if len(aggregator.readings) > 0 && !aggregator.readings[reading.Name] {
+			continue
+		}
+		value, err := strconv.ParseFloat(strings.TrimSpace(reading.Value), 64)
+		if err != nil {
+			continue
+		}
+
+		key := aggregationKey{device: event.Device, name: reading.Name}
+		window, ok := aggregator.windows[key]
+		if !ok {
+			window = &aggregationWindow{Device: event.Device, Reading: reading.Name}
+			aggregator.windows[key] = window
+		}
+
+		changed = true
+		if aggregator.config.Count > 0 {
+			if values := aggregator.addToCountWindow(window, value); len(values) > 0 {
+				summaries = append(summaries, aggregator.summarize(event.Device, reading.Name, values, now)...)
+			}
+			continue
+		}
+		values, ends := aggregator.addToTimeWindow(window, value, now)
+		for index := range values {
+			summaries = append(summaries, aggregator.summarize(event.Device, reading.Name, values[index], ends[index])...)
+		}
+	}
+
+	if changed && aggregator.config.StateFile != "" {
+		if err := aggregator.save(); err != nil {
+			edgexcontext.LoggingClient.Error("Unable to persist aggregation state: " + err.Error())
+		}
+	}
+
+	if len(summaries) == 0 {
+		return false, nil
+	}
+
+	summary := models.Event{
+		Device:   event.Device,
+		Origin:   now.UnixNano() / int64(time.Millisecond),
+		Readings: summaries,
+	}
+	edgexcontext.LoggingClient.Debug("Aggregation windows complete", "device", event.Device, "readings", strconv.Itoa(len(summaries)))
+
+	return true, summary
+}
+
+// addToCountWindow adds the value to the window, returning the values of the window when it's complete
+func (aggregator *Aggregator) addToCountWindow(window *aggregationWindow, value float64) []float64 {
+	window.Samples = append(window.Samples, aggregationSample{Value: value})
+	if len(window.Samples) > aggregator.config.Count {
+		window.Samples = window.Samples[len(window.Samples)-aggregator.config.Count:]
+	}
+	window.Pending++
+
+	if len(window.Samples) < aggregator.config.Count || window.Pending < aggregator.config.SlideCount {
+		return nil
+	}
+	window.Pending = 0
+
+	values := make([]float64, len(window.Samples))
+	for index, sample := range window.Samples {
+		values[index] = sample.Value
+	}
+	return values
+}
+
+// addToTimeWindow adds the value received at now to the window, returning the values of the windows which ended
+// before now and weren't emitted yet, keyed by the end of the window
+func (aggregator *Aggregator) addToTimeWindow(window *aggregationWindow, value float64, now time.Time) ([][]float64, []time.Time) {
+	boundary := now.Truncate(aggregator.config.Slide)
+
+	var values [][]float64
+	var ends []time.Time
+	if window.Boundary.IsZero() {
+		window.Boundary = boundary
+	} else if boundary.After(window.Boundary) {
+		// the samples were received since the last boundary, so only the windows ending within Window of it hold them
+		last := window.Boundary.Add(aggregator.config.Window)
+		for end := window.Boundary.Add(aggregator.config.Slide); !end.After(boundary) && !end.After(last); end = end.Add(aggregator.config.Slide) {
+			start := end.Add(-aggregator.config.Window)
+			var windowValues []float64
+			for _, sample := range window.Samples {
+				if !sample.Time.Before(start) && sample.Time.Before(end) {
+					windowValues = append(windowValues, sample.Value)
+				}
+			}
+			if len(windowValues) > 0 {
+				values = append(values, windowValues)
+				ends = append(ends, end)
+			}
+		}
+
+		// samples before the start of the next window are no longer needed
+		keepFrom := boundary.Add(aggregator.config.Slide - aggregator.config.Window)
+		var kept []aggregationSample
+		for _, sample := range window.Samples {
+			if !sample.Time.Before(keepFrom) {
+				kept = append(kept, sample)
+			}
+		}
+		window.Samples = kept
+		window.Boundary = boundary
+	}
+
+	window.Samples = append(window.Samples, aggregationSample{Value: value, Time: now})
+	return values, ends
+}
+
+// summarize returns a reading for each aggregate function of the values of the window which ended at end
+func (aggregator *Aggregator) summarize(device string, name string, values []float64, end time.Time) []models.Reading {
+	sum, min, max := 0.0, math.Inf(1), math.Inf(-1)
+	for _, value := range values {
+		sum += value
+		min = math.Min(min, value)
+		max = math.Max(max, value)
+	}
+
+	readings := make([]models.Reading, len(aggregator.config.Functions))
+	for index, function := range aggregator.config.Functions {
+		var result float64
+		switch function {
+		case AggregateMin:
+			result = min
+		case AggregateMax:
+			result = max
+		case AggregateAvg:
+			result = sum / float64(len(values))
+		case AggregateCount:
+			result = float64(len(values))
+		case AggregateSum:
+			result = sum
+		}
+		readings[index] = models.Reading{
+			Device: device,
+			Name:   name + "_" + function,
+			Value:  strconv.FormatFloat(result, 'f', -1, 64),
+			Origin: end.UnixNano() / int64(time.Millisecond),
+		}
+	}
+	return readings
+}
+
+// save writes the windows to a temporary file which replaces the state file, so an interrupted write doesn't lose
+// the existing state. The caller must hold the mutex.
+func (aggregator *Aggregator) save() error {
+	windows := make([]*aggregationWindow, 0, len(aggregator.windows))
+	for _, window := range aggregator.windows {
+		windows = append(windows, window)
+	}
+	contents, err := json.Marshal(windows)
+	if err != nil {
+		return err
+	}
+
+	stateFile := aggregator.config.StateFile
+	temp, err := ioutil.TempFile(filepath.Dir(stateFile), filepath.Base(stateFile)+".tmp")
+	if err != nil {
+		return err
+	}
+	if _, err := temp.Write(contents); err != nil {
+		temp.Close()
+		os.Remove(temp.Name())
+		return err
+	}
+	if err := temp.Close(); err != nil {
+		os.Remove(temp.Name())
+		return err
+	}
+	return os.Rename(temp.Name(), stateFile)
+}
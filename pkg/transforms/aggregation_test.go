@@ -0,0 +1,181 @@
+//
+// Copyright (c) 2019 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func aggregationEvent(values ...string) models.Event {
+	event := models.Event{Device: devID1}
+	for _, value := range values {
+		event.Readings = append(event.Readings, models.Reading{Name: "Temperature", Value: value})
+	}
+	return event
+}
+
+// aggregate passes the event to the aggregator, returning the values of the summary readings by name, or nil if no
+// summary was emitted
+func aggregate(t *testing.T, aggregator *Aggregator, event models.Event) map[string]string {
+	continuePipeline, result := aggregator.Aggregate(context, event)
+	if !continuePipeline {
+		assert.Nil(t, result)
+		return nil
+	}
+	summary, ok := result.(models.Event)
+	require.True(t, ok, "Result should be models.Event")
+	values := map[string]string{}
+	for _, reading := range summary.Readings {
+		assert.Equal(t, summary.Device, reading.Device)
+		values[reading.Name] = reading.Value
+	}
+	return values
+}
+
+func TestAggregateCountWindows(t *testing.T) {
+	aggregator, err := NewAggregator(AggregationConfig{
+		Functions: []string{AggregateMin, AggregateMax, AggregateAvg, AggregateCount, AggregateSum},
+		Count:     3,
+	})
+	require.NoError(t, err)
+
+	assert.Nil(t, aggregate(t, aggregator, aggregationEvent("1", "warm")))
+	assert.Nil(t, aggregate(t, aggregator, models.Event{Device: devID2, Readings: []models.Reading{{Name: "Temperature", Value: "10"}}}))
+	assert.Equal(t, map[string]string{
+		"Temperature_min":   "1",
+		"Temperature_max":   "5",
+		"Temperature_avg":   "3",
+		"Temperature_count": "3",
+		"Temperature_sum":   "9",
+	}, aggregate(t, aggregator, aggregationEvent("5", "3")))
+
+	assert.Nil(t, aggregate(t, aggregator, aggregationEvent("10", "20")))
+	assert.Equal(t, "20", aggregate(t, aggregator, aggregationEvent("30"))["Temperature_avg"])
+}
+
+func TestAggregateSlidingCountWindows(t *testing.T) {
+	aggregator, err := NewAggregator(AggregationConfig{Functions: []string{AggregateSum}, Count: 3, SlideCount: 1})
+	require.NoError(t, err)
+
+	var sums []string
+	for _, value := range []string{"1", "2", "3", "4", "5"} {
+		if values := aggregate(t, aggregator, aggregationEvent(value)); values != nil {
+			sums = append(sums, values["Temperature_sum"])
+		}
+	}
+	assert.Equal(t, []string{"6", "9", "12"}, sums)
+}
+
+func TestAggregateTimeWindows(t *testing.T) {
+	aggregator, err := NewAggregator(AggregationConfig{
+		Functions: []string{AggregateCount, AggregateMax},
+		Readings:  []string{"Temperature"},
+		Window:    time.Minute,
+	})
+	require.NoError(t, err)
+
+	start := time.Date(2019, 8, 1, 12, 0, 0, 0, time.UTC)
+	now := start.Add(10 * time.Second)
+	aggregator.now = func() time.Time { return now }
+
+	event := aggregationEvent("21")
+	event.Readings = append(event.Readings, models.Reading{Name: "Humidity", Value: "40"})
+	assert.Nil(t, aggregate(t, aggregator, event))
+	now = start.Add(50 * time.Second)
+	assert.Nil(t, aggregate(t, aggregator, aggregationEvent("25")))
+
+	now = start.Add(70 * time.Second)
+	assert.Equal(t, map[string]string{"Temperature_count": "2", "Temperature_max": "25"},
+		aggregate(t, aggregator, aggregationEvent("30")), "Humidity should not be aggregated")
+
+	now = start.Add(5 * time.Minute)
+	continuePipeline, result := aggregator.Aggregate(context, aggregationEvent("18"))
+	require.True(t, continuePipeline, "Window should be emitted once readings resume")
+	summary := result.(models.Event)
+	require.Len(t, summary.Readings, 2)
+	assert.Equal(t, "Temperature_count", summary.Readings[0].Name)
+	assert.Equal(t, "1", summary.Readings[0].Value)
+	assert.Equal(t, start.Add(2*time.Minute).UnixNano()/int64(time.Millisecond), summary.Readings[0].Origin,
+		"Origin should be the end of the window")
+}
+
+func TestAggregateSlidingTimeWindows(t *testing.T) {
+	aggregator, err := NewAggregator(AggregationConfig{Functions: []string{AggregateSum}, Window: time.Minute, Slide: 30 * time.Second})
+	require.NoError(t, err)
+
+	start := time.Date(2019, 8, 1, 12, 0, 0, 0, time.UTC)
+	now := start
+	aggregator.now = func() time.Time { return now }
+
+	var sums []string
+	for index, value := range []string{"1", "2", "4", "8"} {
+		now = start.Add(time.Duration(index) * 30 * time.Second)
+		if values := aggregate(t, aggregator, aggregationEvent(value)); values != nil {
+			sums = append(sums, values["Temperature_sum"])
+		}
+	}
+	assert.Equal(t, []string{"1", "3", "6"}, sums)
+}
+
+func TestAggregateStateFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "aggregation")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	config := AggregationConfig{Functions: []string{AggregateSum}, Count: 3, StateFile: filepath.Join(dir, "state.json")}
+	aggregator, err := NewAggregator(config)
+	require.NoError(t, err)
+	assert.Nil(t, aggregate(t, aggregator, aggregationEvent("1", "2")))
+
+	restarted, err := NewAggregator(config)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"Temperature_sum": "6"}, aggregate(t, restarted, aggregationEvent("3")),
+		"Partial window should be restored")
+
+	require.NoError(t, ioutil.WriteFile(config.StateFile, []byte("{"), 0644))
+	_, err = NewAggregator(config)
+	assert.Error(t, err, "Invalid state file should be reported")
+}
+
+func TestAggregationConfigValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   AggregationConfig
+		expected string
+	}{
+		{"no functions", AggregationConfig{Count: 3}, "at least one aggregate function must be specified"},
+		{"unknown function", AggregationConfig{Functions: []string{"median"}, Count: 3}, "unknown aggregate function 'median', expected min, max, avg, count or sum"},
+		{"no window", AggregationConfig{Functions: []string{AggregateSum}}, "either a window duration or a count must be specified"},
+		{"both windows", AggregationConfig{Functions: []string{AggregateSum}, Count: 3, Window: time.Minute}, "either a window duration or a count must be specified"},
+		{"time slide", AggregationConfig{Functions: []string{AggregateSum}, Window: time.Minute, Slide: time.Hour}, "time windows must slide by a duration no greater than the window"},
+		{"count slide", AggregationConfig{Functions: []string{AggregateSum}, Count: 3, SlideCount: 4}, "count windows must slide by a count no greater than the window"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.EqualError(t, test.config.Validate(), test.expected)
+		})
+	}
+}
@@ -0,0 +1,111 @@
+//
+// Copyright (c) 2019 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	"encoding/json"
+	"errors"
+	"sync/atomic"
+	"time"
+
+	"github.com/antoniomtz/app-functions-sdk-go/appcontext"
+)
+
+// ArchiveConfig contains the parameters for archiving exported data to local files
+type ArchiveConfig struct {
+	// Path of the archive file, which is rotated once it exceeds MaxSize bytes or has been open for longer than
+	// MaxAge. Zero values disable the respective rotation. Rotated files are gzipped when Compress is true.
+	Path     string
+	MaxSize  int64
+	MaxAge   time.Duration
+	Compress bool
+	// OneIn archives one in every OneIn successful exports. Values less than 2 archive every export.
+	OneIn int
+}
+
+// ArchiveRecord is a line of the archive, holding the data passed to an export function which succeeded
+type ArchiveRecord struct {
+	Timestamp     time.Time `json:"timestamp"`
+	CorrelationID string    `json:"correlationId"`
+	Device        string    `json:"device,omitempty"`
+	// Payload is base64 encoded in the archive, so that it is the exact data exported
+	Payload []byte `json:"payload"`
+}
+
+// Archiver keeps a copy of exported data in local files for audits and replay
+type Archiver struct {
+	config ArchiveConfig
+	file   *rotatingFile
+	count  uint64
+}
+
+// NewArchiver creates an Archiver for the specified configuration
+func NewArchiver(config ArchiveConfig) (*Archiver, error) {
+	if config.Path == "" {
+		return nil, errors.New("archive path must be specified")
+	}
+
+	return &Archiver{
+		config: config,
+		file: &rotatingFile{
+			path:     config.Path,
+			maxSize:  config.MaxSize,
+			maxAge:   config.MaxAge,
+			compress: config.Compress,
+		},
+	}, nil
+}
+
+// Archive returns a function which calls the export function, and archives the data passed to it once the export
+// function succeeds, i.e. continues the pipeline. Failing to archive the data is logged without failing the export.
+// Export functions which batch data only succeed for the data completing a batch, so only that data is archived.
+func (archiver *Archiver) Archive(export func(*appcontext.Context, ...interface{}) (bool, interface{})) func(*appcontext.Context, ...interface{}) (bool, interface{}) {
+	return func(edgexcontext *appcontext.Context, params ...interface{}) (bool, interface{}) {
+		continuePipeline, result := export(edgexcontext, params...)
+		if !continuePipeline || len(params) < 1 {
+			return continuePipeline, result
+		}
+
+		if archiver.config.OneIn > 1 && atomic.AddUint64(&archiver.count, 1)%uint64(archiver.config.OneIn) != 1 {
+			return continuePipeline, result
+		}
+
+		if err := archiver.write(edgexcontext, params[0]); err != nil {
+			edgexcontext.LoggingClient.Error("Failed to archive exported data: " + err.Error())
+		}
+		return continuePipeline, result
+	}
+}
+
+func (archiver *Archiver) write(edgexcontext *appcontext.Context, data interface{}) error {
+	payload, err := coerceToBytes(data)
+	if err != nil {
+		return err
+	}
+
+	record, err := json.Marshal(ArchiveRecord{
+		Timestamp:     time.Now().UTC(),
+		CorrelationID: edgexcontext.CorrelationID,
+		Device:        edgexcontext.DeviceName,
+		Payload:       payload,
+	})
+	if err != nil {
+		return err
+	}
+
+	return archiver.file.WriteLine(record)
+}
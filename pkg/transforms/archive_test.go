@@ -0,0 +1,101 @@
+//
+// Copyright (c) 2019 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/antoniomtz/app-functions-sdk-go/appcontext"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func readArchive(t *testing.T, path string) []ArchiveRecord {
+	contents, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+
+	var records []ArchiveRecord
+	for _, line := range strings.Split(strings.TrimSpace(string(contents)), "\n") {
+		record := ArchiveRecord{}
+		require.NoError(t, json.Unmarshal([]byte(line), &record))
+		records = append(records, record)
+	}
+	return records
+}
+
+func TestArchive(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "archive")
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "archive.log")
+
+	archiver, err := NewArchiver(ArchiveConfig{Path: path})
+	require.NoError(t, err)
+	export := archiver.Archive(func(edgexcontext *appcontext.Context, params ...interface{}) (bool, interface{}) {
+		if params[0] == "fail" {
+			return false, errors.New("export failed")
+		}
+		return true, nil
+	})
+
+	edgexcontext := &appcontext.Context{LoggingClient: context.LoggingClient, CorrelationID: "123-234", DeviceName: devID1}
+	continuePipeline, result := export(edgexcontext, "<Event/>")
+	assert.True(t, continuePipeline)
+	assert.Nil(t, result)
+	continuePipeline, result = export(edgexcontext, "fail")
+	assert.False(t, continuePipeline)
+	assert.EqualError(t, result.(error), "export failed")
+	archiver.file.Close()
+
+	records := readArchive(t, path)
+	require.Equal(t, 1, len(records), "Only successful exports should be archived")
+	assert.Equal(t, "123-234", records[0].CorrelationID)
+	assert.Equal(t, devID1, records[0].Device)
+	assert.Equal(t, []byte("<Event/>"), records[0].Payload)
+	assert.False(t, records[0].Timestamp.IsZero())
+}
+
+func TestArchiveOneIn(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "archive")
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "archive.log")
+
+	archiver, _ := NewArchiver(ArchiveConfig{Path: path, OneIn: 3})
+	export := archiver.Archive(func(edgexcontext *appcontext.Context, params ...interface{}) (bool, interface{}) {
+		return true, nil
+	})
+	for _, data := range []string{"1", "2", "3", "4", "5", "6", "7"} {
+		export(context, data)
+	}
+	archiver.file.Close()
+
+	records := readArchive(t, path)
+	require.Equal(t, 3, len(records))
+	assert.Equal(t, []byte("1"), records[0].Payload)
+	assert.Equal(t, []byte("4"), records[1].Payload)
+	assert.Equal(t, []byte("7"), records[2].Payload)
+}
+
+func TestNewArchiverNoPath(t *testing.T) {
+	_, err := NewArchiver(ArchiveConfig{})
+	assert.Error(t, err)
+}
@@ -0,0 +1,203 @@
+//
+// Copyright (c) 2019 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"strings"
+	"time"
+
+	"github.com/antoniomtz/app-functions-sdk-go/appcontext"
+	"github.com/edgexfoundry/go-mod-core-contracts/clients"
+	"github.com/edgexfoundry/go-mod-core-contracts/models"
+)
+
+// ContentTypeCloudEvents is the content type of CloudEvents in the structured JSON format
+const ContentTypeCloudEvents = "application/cloudevents+json"
+
+// cloudEventsSpecVersion is the version of the CloudEvents specification of the envelopes
+const cloudEventsSpecVersion = "1.0"
+
+// CloudEvent is a CloudEvents 1.0 envelope in the structured JSON format
+type CloudEvent struct {
+	SpecVersion     string          `json:"specversion"`
+	ID              string          `json:"id"`
+	Source          string          `json:"source"`
+	Type            string          `json:"type"`
+	Subject         string          `json:"subject,omitempty"`
+	Time            string          `json:"time,omitempty"`
+	DataContentType string          `json:"datacontenttype,omitempty"`
+	Data            json.RawMessage `json:"data,omitempty"`
+	DataBase64      string          `json:"data_base64,omitempty"`
+}
+
+// CloudEventConfig contains the parameters for wrapping data in CloudEvents. The {device} placeholder of the
+// attributes is replaced with the device name of the event, {reading} with the name of its first reading and
+// {correlation-id} with the correlation ID.
+type CloudEventConfig struct {
+	// Source is the source attribute, edgex/{device} by default
+	Source string
+	// Type is the type attribute, org.edgexfoundry.event by default
+	Type string
+	// Subject is the subject attribute, which is left out when empty
+	Subject string
+	// DataContentType is the content type of string or []byte data which isn't JSON, application/octet-stream by
+	// default. Such data is base64 encoded in the envelope.
+	DataContentType string
+}
+
+// CloudEventWrapper wraps the data of the pipeline in CloudEvents
+type CloudEventWrapper struct {
+	config CloudEventConfig
+}
+
+// NewCloudEventWrapper creates a CloudEventWrapper, setting the defaults of the config
+func NewCloudEventWrapper(config CloudEventConfig) *CloudEventWrapper {
+	if config.Source == "" {
+		config.Source = "edgex/{device}"
+	}
+	if config.Type == "" {
+		config.Type = "org.edgexfoundry.event"
+	}
+	if config.DataContentType == "" {
+		config.DataContentType = "application/octet-stream"
+	}
+	return &CloudEventWrapper{config: config}
+}
+
+// WrapCloudEvent wraps the data from the previous function, an Event or the string or []byte output of a previous
+// function, in a CloudEvent. Events and JSON data are embedded as JSON, other data is base64 encoded. The ID of the
+// CloudEvent is the ID of the event, or the correlation ID when it has none, and its time the origin of the event.
+// This function returns the CloudEvent in the structured JSON format as a string.
+func (wrapper *CloudEventWrapper) WrapCloudEvent(edgexcontext *appcontext.Context, params ...interface{}) (bool, interface{}) {
+	if len(params) < 1 {
+		return false, errors.New("No Data Received")
+	}
+
+	device, reading, id := edgexcontext.DeviceName, "", edgexcontext.EventID
+	eventTime := time.Now()
+	switch data := params[0].(type) {
+	case models.Event:
+		device, reading, id, eventTime = eventAttributes(data, id, eventTime)
+	case TaggedEvent:
+		device, reading, id, eventTime = eventAttributes(data.Event, id, eventTime)
+	}
+	if id == "" {
+		id = edgexcontext.CorrelationID
+	}
+	if id == "" {
+		var err error
+		if id, err = newUUID(); err != nil {
+			return false, fmt.Errorf("unable to generate CloudEvent ID: %v", err)
+		}
+	}
+
+	data, err := coerceToBytes(params[0])
+	if err != nil {
+		return false, err
+	}
+
+	replacer := strings.NewReplacer("{device}", device, "{reading}", reading, "{correlation-id}", edgexcontext.CorrelationID)
+	cloudEvent := CloudEvent{
+		SpecVersion: cloudEventsSpecVersion,
+		ID:          id,
+		Source:      replacer.Replace(wrapper.config.Source),
+		Type:        replacer.Replace(wrapper.config.Type),
+		Subject:     replacer.Replace(wrapper.config.Subject),
+		Time:        eventTime.UTC().Format(time.RFC3339Nano),
+	}
+	if json.Valid(data) {
+		cloudEvent.DataContentType = clients.ContentTypeJSON
+		cloudEvent.Data = data
+	} else {
+		cloudEvent.DataContentType = wrapper.config.DataContentType
+		cloudEvent.DataBase64 = base64.StdEncoding.EncodeToString(data)
+	}
+
+	encoded, err := json.Marshal(cloudEvent)
+	if err != nil {
+		return false, fmt.Errorf("unable to encode CloudEvent: %v", err)
+	}
+
+	edgexcontext.LoggingClient.Debug("Wrapped data in CloudEvent", "id", id)
+	return true, string(encoded)
+}
+
+// eventAttributes returns the device, first reading, ID and time of the event, or the defaults for those it hasn't
+func eventAttributes(event models.Event, id string, eventTime time.Time) (string, string, string, time.Time) {
+	reading := ""
+	if len(event.Readings) > 0 {
+		reading = event.Readings[0].Name
+	}
+	if event.ID != "" {
+		id = event.ID
+	}
+	if event.Origin != 0 {
+		eventTime = time.Unix(0, event.Origin*int64(time.Millisecond))
+	}
+	return event.Device, reading, id, eventTime
+}
+
+// UnwrapCloudEvent returns the data of the CloudEvent in the structured JSON format and its content type, JSON when
+// the CloudEvent doesn't specify one
+func UnwrapCloudEvent(payload []byte) ([]byte, string, error) {
+	var cloudEvent CloudEvent
+	if err := json.Unmarshal(payload, &cloudEvent); err != nil {
+		return nil, "", fmt.Errorf("invalid CloudEvent: %v", err)
+	}
+	if cloudEvent.SpecVersion == "" {
+		return nil, "", errors.New("invalid CloudEvent: specversion is missing")
+	}
+
+	contentType := cloudEvent.DataContentType
+	if contentType == "" {
+		contentType = clients.ContentTypeJSON
+	}
+
+	switch {
+	case cloudEvent.DataBase64 != "":
+		data, err := base64.StdEncoding.DecodeString(cloudEvent.DataBase64)
+		if err != nil {
+			return nil, "", fmt.Errorf("invalid CloudEvent data_base64: %v", err)
+		}
+		return data, contentType, nil
+	case len(cloudEvent.Data) == 0:
+		return nil, "", errors.New("CloudEvent has no data")
+	case !isJSONContentType(contentType) && cloudEvent.Data[0] == '"':
+		// data of other content types, i.e. XML, is held in a JSON string
+		var data string
+		if err := json.Unmarshal(cloudEvent.Data, &data); err != nil {
+			return nil, "", fmt.Errorf("invalid CloudEvent data: %v", err)
+		}
+		return []byte(data), contentType, nil
+	default:
+		return cloudEvent.Data, contentType, nil
+	}
+}
+
+// isJSONContentType returns whether the content type is JSON, application/json or a media type with a +json suffix
+func isJSONContentType(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return mediaType == clients.ContentTypeJSON || mediaType == "text/json" || strings.HasSuffix(mediaType, "+json")
+}
@@ -0,0 +1,115 @@
+//
+// Copyright (c) 2019 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/antoniomtz/app-functions-sdk-go/appcontext"
+	"github.com/edgexfoundry/go-mod-core-contracts/clients"
+	"github.com/edgexfoundry/go-mod-core-contracts/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWrapCloudEventEvent(t *testing.T) {
+	wrapper := NewCloudEventWrapper(CloudEventConfig{Subject: "{reading}"})
+	event := models.Event{
+		ID:       "event1",
+		Device:   devID1,
+		Origin:   1559390400000,
+		Readings: []models.Reading{{Name: readingName1, Value: readingValue1}},
+	}
+
+	continuePipeline, result := wrapper.WrapCloudEvent(context, event)
+	require.True(t, continuePipeline, "%v", result)
+
+	cloudEvent := CloudEvent{}
+	require.NoError(t, json.Unmarshal([]byte(result.(string)), &cloudEvent))
+	assert.Equal(t, "1.0", cloudEvent.SpecVersion)
+	assert.Equal(t, "event1", cloudEvent.ID)
+	assert.Equal(t, "edgex/"+devID1, cloudEvent.Source)
+	assert.Equal(t, "org.edgexfoundry.event", cloudEvent.Type)
+	assert.Equal(t, readingName1, cloudEvent.Subject)
+	assert.Equal(t, "2019-06-01T12:00:00Z", cloudEvent.Time)
+	assert.Equal(t, clients.ContentTypeJSON, cloudEvent.DataContentType)
+
+	unwrapped := models.Event{}
+	require.NoError(t, json.Unmarshal(cloudEvent.Data, &unwrapped))
+	assert.Equal(t, devID1, unwrapped.Device)
+}
+
+func TestWrapCloudEventOutput(t *testing.T) {
+	wrapper := NewCloudEventWrapper(CloudEventConfig{Source: "urn:edgex:{device}", Type: "com.example.csv", DataContentType: "text/csv"})
+	edgexcontext := &appcontext.Context{LoggingClient: context.LoggingClient, CorrelationID: "correlation1", DeviceName: devID2}
+
+	continuePipeline, result := wrapper.WrapCloudEvent(edgexcontext, "id2,21.5")
+	require.True(t, continuePipeline, "%v", result)
+
+	cloudEvent := CloudEvent{}
+	require.NoError(t, json.Unmarshal([]byte(result.(string)), &cloudEvent))
+	assert.Equal(t, "correlation1", cloudEvent.ID)
+	assert.Equal(t, "urn:edgex:"+devID2, cloudEvent.Source)
+	assert.Equal(t, "com.example.csv", cloudEvent.Type)
+	assert.Empty(t, cloudEvent.Subject)
+	assert.Equal(t, "text/csv", cloudEvent.DataContentType)
+	assert.Empty(t, cloudEvent.Data)
+
+	data, contentType, err := UnwrapCloudEvent([]byte(result.(string)))
+	require.NoError(t, err)
+	assert.Equal(t, "text/csv", contentType)
+	assert.Equal(t, []byte("id2,21.5"), data)
+}
+
+func TestWrapCloudEventNoData(t *testing.T) {
+	wrapper := NewCloudEventWrapper(CloudEventConfig{})
+
+	continuePipeline, result := wrapper.WrapCloudEvent(context)
+	assert.False(t, continuePipeline)
+	assert.EqualError(t, result.(error), "No Data Received")
+}
+
+func TestUnwrapCloudEvent(t *testing.T) {
+	tests := []struct {
+		Name        string
+		CloudEvent  string
+		Data        string
+		ContentType string
+		Error       string
+	}{
+		{"JSON", `{"specversion":"1.0","id":"1","source":"s","type":"t","data":{"device":"id1"}}`, `{"device":"id1"}`, clients.ContentTypeJSON, ""},
+		{"JSON suffix", `{"specversion":"1.0","id":"1","source":"s","type":"t","datacontenttype":"application/vnd.edgex+json","data":"id1"}`, `"id1"`, "application/vnd.edgex+json", ""},
+		{"String", `{"specversion":"1.0","id":"1","source":"s","type":"t","datacontenttype":"text/xml","data":"<event/>"}`, `<event/>`, "text/xml", ""},
+		{"Base64", `{"specversion":"1.0","id":"1","source":"s","type":"t","datacontenttype":"application/cbor","data_base64":"oA=="}`, "\xa0", clients.ContentTypeCBOR, ""},
+		{"Not JSON", `not json`, "", "", "invalid CloudEvent: invalid character 'o' in literal null (expecting 'u')"},
+		{"No spec version", `{"id":"1","data":{}}`, "", "", "invalid CloudEvent: specversion is missing"},
+		{"No data", `{"specversion":"1.0","id":"1","source":"s","type":"t"}`, "", "", "CloudEvent has no data"},
+	}
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			data, contentType, err := UnwrapCloudEvent([]byte(test.CloudEvent))
+			if test.Error != "" {
+				assert.EqualError(t, err, test.Error)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.Data, string(data))
+			assert.Equal(t, test.ContentType, contentType)
+		})
+	}
+}
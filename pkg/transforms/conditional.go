@@ -0,0 +1,103 @@
+//
+// Copyright (c) 2019 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/antoniomtz/app-functions-sdk-go/appcontext"
+	"github.com/edgexfoundry/go-mod-core-contracts/models"
+)
+
+const timeOfDayFormat = "15:04"
+
+// Predicate decides whether a conditional function runs for the data received from the previous function
+type Predicate func(edgexcontext *appcontext.Context, data interface{}) bool
+
+// Conditional houses a function which only runs when its predicate is true
+type Conditional struct {
+	Predicate Predicate
+	Transform func(edgexcontext *appcontext.Context, params ...interface{}) (bool, interface{})
+}
+
+// OnlyWhen runs the transform when the predicate is true for the data from the previous function. Otherwise the
+// transform is skipped and the data is passed unchanged to the next function.
+func (c Conditional) OnlyWhen(edgexcontext *appcontext.Context, params ...interface{}) (bool, interface{}) {
+	var data interface{}
+	if len(params) > 0 {
+		data = params[0]
+	}
+
+	if !c.Predicate(edgexcontext, data) {
+		edgexcontext.LoggingClient.Debug("Predicate is false, skipping conditional function")
+		return true, data
+	}
+
+	return c.Transform(edgexcontext, params...)
+}
+
+// TimeOfDayBetween returns a predicate which is true from start until end local time, both in the "15:04" format.
+// An end before start spans midnight, i.e. "22:00" to "06:00".
+func TimeOfDayBetween(start string, end string) (Predicate, error) {
+	startTime, err := time.Parse(timeOfDayFormat, start)
+	if err != nil {
+		return nil, fmt.Errorf("invalid start time '%s', expected format HH:MM", start)
+	}
+	endTime, err := time.Parse(timeOfDayFormat, end)
+	if err != nil {
+		return nil, fmt.Errorf("invalid end time '%s', expected format HH:MM", end)
+	}
+
+	startMinute := startTime.Hour()*60 + startTime.Minute()
+	endMinute := endTime.Hour()*60 + endTime.Minute()
+
+	return func(edgexcontext *appcontext.Context, data interface{}) bool {
+		return minuteOfDayBetween(time.Now(), startMinute, endMinute)
+	}, nil
+}
+
+func minuteOfDayBetween(now time.Time, startMinute int, endMinute int) bool {
+	minute := now.Hour()*60 + now.Minute()
+	if startMinute <= endMinute {
+		return minute >= startMinute && minute < endMinute
+	}
+	return minute >= startMinute || minute < endMinute
+}
+
+// ReadingValueIs returns a predicate which is true when the data is an Event with a reading of the given name
+// having one of the given values, i.e. ReadingValueIs("Alarm", "true")
+func ReadingValueIs(readingName string, values ...string) Predicate {
+	return func(edgexcontext *appcontext.Context, data interface{}) bool {
+		event, ok := data.(models.Event)
+		if !ok {
+			return false
+		}
+
+		for _, reading := range event.Readings {
+			if reading.Name != readingName {
+				continue
+			}
+			for _, value := range values {
+				if reading.Value == value {
+					return true
+				}
+			}
+		}
+		return false
+	}
+}
@@ -0,0 +1,69 @@
+//
+// Copyright (c) 2019 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	"testing"
+	"time"
+
+	"github.com/antoniomtz/app-functions-sdk-go/appcontext"
+	"github.com/edgexfoundry/go-mod-core-contracts/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOnlyWhen(t *testing.T) {
+	called := false
+	transform := func(edgexcontext *appcontext.Context, params ...interface{}) (bool, interface{}) {
+		called = true
+		return false, "transformed"
+	}
+
+	alarm := models.Event{Device: devID1, Readings: []models.Reading{{Name: "Alarm", Value: "true"}}}
+	normal := models.Event{Device: devID1, Readings: []models.Reading{{Name: "Alarm", Value: "false"}}}
+	conditional := Conditional{Predicate: ReadingValueIs("Alarm", "true"), Transform: transform}
+
+	continuePipeline, result := conditional.OnlyWhen(context, normal)
+	assert.True(t, continuePipeline, "Pipeline should continue when the predicate is false")
+	assert.Equal(t, normal, result, "Data should be passed through unchanged")
+	assert.False(t, called, "Transform should not run when the predicate is false")
+
+	continuePipeline, result = conditional.OnlyWhen(context, alarm)
+	assert.True(t, called, "Transform should run when the predicate is true")
+	assert.False(t, continuePipeline)
+	assert.Equal(t, "transformed", result)
+}
+
+func TestTimeOfDayBetween(t *testing.T) {
+	at := func(clock string) time.Time {
+		now, _ := time.Parse(timeOfDayFormat, clock)
+		return now
+	}
+
+	assert.True(t, minuteOfDayBetween(at("09:00"), 9*60, 17*60))
+	assert.False(t, minuteOfDayBetween(at("17:00"), 9*60, 17*60), "End time should be exclusive")
+	assert.True(t, minuteOfDayBetween(at("23:30"), 22*60, 6*60), "Window should span midnight")
+	assert.True(t, minuteOfDayBetween(at("05:59"), 22*60, 6*60), "Window should span midnight")
+	assert.False(t, minuteOfDayBetween(at("12:00"), 22*60, 6*60))
+
+	predicate, err := TimeOfDayBetween("00:00", "23:59")
+	require.NoError(t, err)
+	assert.NotNil(t, predicate)
+
+	_, err = TimeOfDayBetween("9am", "17:00")
+	assert.Error(t, err)
+}
@@ -35,7 +35,7 @@ func (f Conversion) TransformToXML(edgexcontext *appcontext.Context, params ...i
 		return false, errors.New("No Event Received")
 	}
 	edgexcontext.LoggingClient.Debug("Transforming to XML")
-	if result, ok := params[0].(models.Event); ok {
+	if result, ok := eventOrTaggedEvent(params[0]); ok {
 		b, err := xml.Marshal(result)
 		if err != nil {
 			// LoggingClient.Error(fmt.Sprintf("Error parsing XML. Error: %s", err.Error()))
@@ -54,7 +54,7 @@ func (f Conversion) TransformToJSON(edgexcontext *appcontext.Context, params ...
 		return false, errors.New("No Event Received")
 	}
 	edgexcontext.LoggingClient.Debug("Transforming to JSON")
-	if result, ok := params[0].(models.Event); ok {
+	if result, ok := eventOrTaggedEvent(params[0]); ok {
 		b, err := json.Marshal(result)
 		if err != nil {
 			// LoggingClient.Error(fmt.Sprintf("Error parsing JSON. Error: %s", err.Error()))
@@ -66,3 +66,12 @@ func (f Conversion) TransformToJSON(edgexcontext *appcontext.Context, params ...
 	}
 	return false, errors.New("Unexpected type received")
 }
+
+// eventOrTaggedEvent returns the data if it's an Event or a TaggedEvent, which are converted alike
+func eventOrTaggedEvent(data interface{}) (interface{}, bool) {
+	switch data.(type) {
+	case models.Event, TaggedEvent:
+		return data, true
+	}
+	return nil, false
+}
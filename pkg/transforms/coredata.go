@@ -0,0 +1,49 @@
+//
+// Copyright (c) 2019 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/antoniomtz/app-functions-sdk-go/appcontext"
+)
+
+// CoreData houses the device and reading names used for events pushed to Core Data
+type CoreData struct {
+	DeviceName  string
+	ReadingName string
+}
+
+// PushToCoreData creates a new event in Core Data with the data from the previous function as the value of a single
+// reading. The data may be a string, []byte or a numeric or boolean value.
+// This function returns the created Event
+func (cd CoreData) PushToCoreData(edgexcontext *appcontext.Context, params ...interface{}) (bool, interface{}) {
+	if len(params) < 1 {
+		// We didn't receive a result
+		return false, errors.New("No Data Received")
+	}
+
+	edgexcontext.LoggingClient.Debug("Pushing reading to Core Data", "device", cd.DeviceName, "reading", cd.ReadingName)
+
+	event, err := edgexcontext.PushToCoreData(cd.DeviceName, cd.ReadingName, params[0])
+	if err != nil {
+		return false, fmt.Errorf("unable to push reading to Core Data: %v", err)
+	}
+
+	return true, *event
+}
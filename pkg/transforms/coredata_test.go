@@ -0,0 +1,87 @@
+//
+// Copyright (c) 2019 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/antoniomtz/app-functions-sdk-go/appcontext"
+	"github.com/antoniomtz/app-functions-sdk-go/pkg/startup"
+	"github.com/edgexfoundry/go-mod-core-contracts/clients"
+	"github.com/edgexfoundry/go-mod-core-contracts/clients/coredata"
+	"github.com/edgexfoundry/go-mod-core-contracts/clients/types"
+	"github.com/edgexfoundry/go-mod-core-contracts/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newCoreDataContext(url string) *appcontext.Context {
+	params := types.EndpointParams{
+		ServiceKey:  clients.CoreDataServiceKey,
+		Path:        clients.ApiEventRoute,
+		UseRegistry: false,
+		Url:         url + clients.ApiEventRoute,
+		Interval:    1000,
+	}
+	return &appcontext.Context{
+		LoggingClient: context.LoggingClient,
+		EventClient:   coredata.NewEventClient(params, startup.Endpoint{RegistryClient: nil}),
+	}
+}
+
+func TestPushToCoreData(t *testing.T) {
+	var received models.Event
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, clients.ApiEventRoute, r.URL.Path)
+		json.NewDecoder(r.Body).Decode(&received)
+		w.Write([]byte("newEventID"))
+	}))
+	defer server.Close()
+
+	cd := CoreData{DeviceName: "derived-device", ReadingName: "average"}
+	continuePipeline, result := cd.PushToCoreData(newCoreDataContext(server.URL), 21.5)
+	require.True(t, continuePipeline, "Unexpected error: %v", result)
+
+	event := result.(models.Event)
+	assert.Equal(t, "newEventID", event.ID)
+	assert.Equal(t, "derived-device", received.Device)
+	require.Equal(t, 1, len(received.Readings))
+	assert.Equal(t, "average", received.Readings[0].Name)
+	assert.Equal(t, "21.5", received.Readings[0].Value)
+}
+
+func TestPushToCoreDataFailure(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	cd := CoreData{DeviceName: "derived-device", ReadingName: "average"}
+	continuePipeline, result := cd.PushToCoreData(newCoreDataContext(server.URL), "21.5")
+	assert.False(t, continuePipeline)
+	assert.Error(t, result.(error))
+}
+
+func TestPushToCoreDataNoData(t *testing.T) {
+	continuePipeline, result := CoreData{}.PushToCoreData(context)
+	assert.False(t, continuePipeline)
+	assert.Equal(t, "No Data Received", result.(error).Error())
+}
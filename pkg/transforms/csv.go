@@ -0,0 +1,229 @@
+//
+// Copyright (c) 2019 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/antoniomtz/app-functions-sdk-go/appcontext"
+	"github.com/edgexfoundry/go-mod-core-contracts/models"
+)
+
+// The policies for emitting the header row of the CSV output
+const (
+	// CSVHeaderOnce emits the header before the rows of the first output only, i.e. when appending to a file
+	CSVHeaderOnce = "once"
+	// CSVHeaderAlways emits the header before the rows of each output, i.e. when each output is a separate object
+	CSVHeaderAlways = "always"
+	// CSVHeaderNever never emits the header
+	CSVHeaderNever = "never"
+)
+
+// The prefixes of the columns taking the value of a tag or of a named reading of the event
+const (
+	csvTagPrefix     = "tag:"
+	csvReadingPrefix = "reading:"
+)
+
+// csvEventColumns are the columns taken from the event, and csvReadingColumns the columns taken from each reading
+var (
+	csvEventColumns   = map[string]bool{"id": true, "device": true, "origin": true, "created": true}
+	csvReadingColumns = map[string]bool{"name": true, "value": true, "binaryValue": true}
+)
+
+// CSVConfig contains the parameters for formatting events as CSV
+type CSVConfig struct {
+	// Columns are the columns of the rows, in order, device, origin, name and value by default. The id, device,
+	// origin and created columns are taken from the event, name, value and binaryValue from a reading, tag:<key>
+	// from a tag of the event and reading:<name> from the value of the named reading of the event.
+	Columns []string
+	// Header is the policy for emitting the header row, once, the default, always or never
+	Header string
+	// Separator is the field separator, a comma by default
+	Separator string
+}
+
+// CSVFormatter formats events as CSV rows
+type CSVFormatter struct {
+	columns    []string
+	perReading bool
+	header     string
+	separator  rune
+	mutex      sync.Mutex
+	headerSent bool
+}
+
+// NewCSVFormatter creates a CSVFormatter, setting the defaults of the config
+func NewCSVFormatter(config CSVConfig) (*CSVFormatter, error) {
+	formatter := &CSVFormatter{
+		columns:   config.Columns,
+		header:    config.Header,
+		separator: ',',
+	}
+	if len(formatter.columns) == 0 {
+		formatter.columns = []string{"device", "origin", "name", "value"}
+	}
+	for _, column := range formatter.columns {
+		switch {
+		case csvReadingColumns[column]:
+			formatter.perReading = true
+		case csvEventColumns[column]:
+		case strings.HasPrefix(column, csvTagPrefix) && len(column) > len(csvTagPrefix):
+		case strings.HasPrefix(column, csvReadingPrefix) && len(column) > len(csvReadingPrefix):
+		default:
+			return nil, fmt.Errorf("unknown CSV column '%s'", column)
+		}
+	}
+
+	switch formatter.header {
+	case "":
+		formatter.header = CSVHeaderOnce
+	case CSVHeaderOnce, CSVHeaderAlways, CSVHeaderNever:
+	default:
+		return nil, fmt.Errorf("CSV header must be once, always or never, got '%s'", config.Header)
+	}
+
+	if config.Separator != "" {
+		separator, size := utf8.DecodeRuneInString(config.Separator)
+		if size != len(config.Separator) || separator == '"' || separator == '\r' || separator == '\n' {
+			return nil, fmt.Errorf("invalid CSV separator '%s'", config.Separator)
+		}
+		formatter.separator = separator
+	}
+
+	return formatter, nil
+}
+
+// TransformToCSV formats the Event from the previous function as CSV. An event is formatted as one row per reading
+// when a column is taken from a reading, or as a single row otherwise. The header row is emitted before the rows as
+// set by the header policy. The rows are terminated with a newline, except for the last.
+// This function returns a string, or stops the pipeline when the event has no readings to format.
+func (formatter *CSVFormatter) TransformToCSV(edgexcontext *appcontext.Context, params ...interface{}) (bool, interface{}) {
+	if len(params) < 1 {
+		return false, errors.New("No Event Received")
+	}
+
+	var event models.Event
+	var tags map[string]string
+	switch data := params[0].(type) {
+	case models.Event:
+		event = data
+	case TaggedEvent:
+		event = data.Event
+		tags = data.Tags
+	default:
+		return false, errors.New("Unexpected type received, expecting models.Event")
+	}
+
+	edgexcontext.LoggingClient.Debug("Transforming to CSV")
+
+	var rows [][]string
+	if formatter.perReading {
+		for _, reading := range event.Readings {
+			rows = append(rows, formatter.row(event, tags, reading))
+		}
+	} else {
+		rows = append(rows, formatter.row(event, tags, models.Reading{}))
+	}
+	if len(rows) == 0 {
+		edgexcontext.LoggingClient.Debug("Event has no readings to format as CSV")
+		return false, nil
+	}
+
+	if formatter.emitHeader() {
+		rows = append([][]string{formatter.columns}, rows...)
+	}
+
+	var buffer bytes.Buffer
+	writer := csv.NewWriter(&buffer)
+	writer.Comma = formatter.separator
+	if err := writer.WriteAll(rows); err != nil {
+		return false, fmt.Errorf("unable to format CSV: %v", err)
+	}
+
+	return true, strings.TrimSuffix(buffer.String(), "\n")
+}
+
+// emitHeader returns whether the header is emitted before the rows of the current output
+func (formatter *CSVFormatter) emitHeader() bool {
+	switch formatter.header {
+	case CSVHeaderAlways:
+		return true
+	case CSVHeaderOnce:
+		formatter.mutex.Lock()
+		defer formatter.mutex.Unlock()
+		if !formatter.headerSent {
+			formatter.headerSent = true
+			return true
+		}
+	}
+	return false
+}
+
+// row returns the fields of the columns for the reading of the event. The device and origin of the reading, when
+// set, take precedence over those of the event.
+func (formatter *CSVFormatter) row(event models.Event, tags map[string]string, reading models.Reading) []string {
+	fields := make([]string, len(formatter.columns))
+	for i, column := range formatter.columns {
+		switch column {
+		case "id":
+			fields[i] = event.ID
+		case "device":
+			fields[i] = event.Device
+			if reading.Device != "" {
+				fields[i] = reading.Device
+			}
+		case "origin":
+			origin := event.Origin
+			if reading.Origin != 0 {
+				origin = reading.Origin
+			}
+			fields[i] = strconv.FormatInt(origin, 10)
+		case "created":
+			fields[i] = strconv.FormatInt(event.Created, 10)
+		case "name":
+			fields[i] = reading.Name
+		case "value":
+			fields[i] = reading.Value
+		case "binaryValue":
+			if len(reading.BinaryValue) > 0 {
+				fields[i] = base64.StdEncoding.EncodeToString(reading.BinaryValue)
+			}
+		default:
+			if strings.HasPrefix(column, csvTagPrefix) {
+				fields[i] = tags[strings.TrimPrefix(column, csvTagPrefix)]
+				break
+			}
+			name := strings.TrimPrefix(column, csvReadingPrefix)
+			for _, named := range event.Readings {
+				if named.Name == name {
+					fields[i] = named.Value
+					break
+				}
+			}
+		}
+	}
+	return fields
+}
@@ -0,0 +1,120 @@
+//
+// Copyright (c) 2019 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	"testing"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransformToCSV(t *testing.T) {
+	event := models.Event{
+		ID:     "event1",
+		Device: devID1,
+		Origin: 1559390400000,
+		Readings: []models.Reading{
+			{Name: "Temperature", Value: "21.5"},
+			{Name: "Note", Value: "door \"A\", open", Device: devID2, Origin: 1559390401000},
+		},
+	}
+
+	tests := []struct {
+		Name     string
+		Config   CSVConfig
+		Data     interface{}
+		Expected string
+	}{
+		{"Default", CSVConfig{}, event, "device,origin,name,value\nid1,1559390400000,Temperature,21.5\nid2,1559390401000,Note,\"door \"\"A\"\", open\""},
+		{"Never", CSVConfig{Columns: []string{"id", "name"}, Header: CSVHeaderNever}, event, "event1,Temperature\nevent1,Note"},
+		{"Per event", CSVConfig{Columns: []string{"device", "reading:Temperature", "reading:Humidity"}, Header: CSVHeaderNever}, event, "id1,21.5,"},
+		{"Separator", CSVConfig{Columns: []string{"name", "value"}, Separator: ";"}, event, "name;value\nTemperature;21.5\nNote;\"door \"\"A\"\", open\""},
+		{"Tags", CSVConfig{Columns: []string{"tag:site", "name", "binaryValue"}, Header: CSVHeaderNever},
+			TaggedEvent{Event: models.Event{Readings: []models.Reading{{Name: "Image", BinaryValue: []byte("png")}}}, Tags: map[string]string{"site": "plant-1"}}, "plant-1,Image,cG5n"},
+	}
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			formatter, err := NewCSVFormatter(test.Config)
+			require.NoError(t, err)
+
+			continuePipeline, result := formatter.TransformToCSV(context, test.Data)
+			require.True(t, continuePipeline, "%v", result)
+			assert.Equal(t, test.Expected, result)
+		})
+	}
+}
+
+func TestTransformToCSVHeader(t *testing.T) {
+	event := models.Event{Device: devID1, Readings: []models.Reading{{Name: readingName1, Value: readingValue1}}}
+
+	once, err := NewCSVFormatter(CSVConfig{Columns: []string{"name", "value"}})
+	require.NoError(t, err)
+	_, first := once.TransformToCSV(context, event)
+	_, second := once.TransformToCSV(context, event)
+	assert.Equal(t, "name,value\n"+readingName1+","+readingValue1, first)
+	assert.Equal(t, readingName1+","+readingValue1, second)
+
+	always, err := NewCSVFormatter(CSVConfig{Columns: []string{"name", "value"}, Header: CSVHeaderAlways})
+	require.NoError(t, err)
+	_, first = always.TransformToCSV(context, event)
+	_, second = always.TransformToCSV(context, event)
+	assert.Equal(t, first, second)
+	assert.Equal(t, "name,value\n"+readingName1+","+readingValue1, second)
+}
+
+func TestTransformToCSVNoReadings(t *testing.T) {
+	formatter, err := NewCSVFormatter(CSVConfig{})
+	require.NoError(t, err)
+
+	continuePipeline, result := formatter.TransformToCSV(context, models.Event{Device: devID1})
+	assert.False(t, continuePipeline)
+	assert.Nil(t, result)
+}
+
+func TestTransformToCSVErrors(t *testing.T) {
+	formatter, err := NewCSVFormatter(CSVConfig{})
+	require.NoError(t, err)
+
+	continuePipeline, result := formatter.TransformToCSV(context)
+	assert.False(t, continuePipeline)
+	assert.EqualError(t, result.(error), "No Event Received")
+
+	continuePipeline, result = formatter.TransformToCSV(context, "not an event")
+	assert.False(t, continuePipeline)
+	assert.EqualError(t, result.(error), "Unexpected type received, expecting models.Event")
+}
+
+func TestNewCSVFormatterInvalid(t *testing.T) {
+	tests := []struct {
+		Name   string
+		Config CSVConfig
+		Error  string
+	}{
+		{"Column", CSVConfig{Columns: []string{"device", "unit"}}, "unknown CSV column 'unit'"},
+		{"Empty tag", CSVConfig{Columns: []string{"tag:"}}, "unknown CSV column 'tag:'"},
+		{"Header", CSVConfig{Header: "first"}, "CSV header must be once, always or never, got 'first'"},
+		{"Separator", CSVConfig{Separator: ",,"}, "invalid CSV separator ',,'"},
+	}
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			_, err := NewCSVFormatter(test.Config)
+			assert.EqualError(t, err, test.Error)
+		})
+	}
+}
@@ -0,0 +1,126 @@
+//
+// Copyright (c) 2019 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/antoniomtz/app-functions-sdk-go/appcontext"
+	"github.com/edgexfoundry/go-mod-core-contracts/models"
+)
+
+const (
+	// DedupKeyID identifies duplicate events by their ID, or by their content when they have no ID
+	DedupKeyID = "id"
+	// DedupKeyContent identifies duplicate events by a hash of their device, origin, readings and values
+	DedupKeyContent = "content"
+)
+
+// Deduplicator drops the events already seen within a time window, protecting downstream systems from the
+// redeliveries caused by retries
+type Deduplicator struct {
+	window time.Duration
+	key    string
+	mutex  sync.Mutex
+	seen   map[string]time.Time
+	pruned time.Time
+}
+
+// NewDeduplicator creates a Deduplicator dropping the events seen within window of their first delivery, identified
+// by key, either DedupKeyID or DedupKeyContent
+func NewDeduplicator(window time.Duration, key string) (*Deduplicator, error) {
+	if window <= 0 {
+		return nil, errors.New("deduplication window must be greater than zero")
+	}
+	if key != DedupKeyID && key != DedupKeyContent {
+		return nil, fmt.Errorf("deduplication key must be %s or %s, got '%s'", DedupKeyID, DedupKeyContent, key)
+	}
+
+	return &Deduplicator{
+		window: window,
+		key:    key,
+		seen:   map[string]time.Time{},
+		pruned: time.Now(),
+	}, nil
+}
+
+// Deduplicate stops the pipeline execution for an event from the previous function which was already seen within
+// the window, otherwise the Event is returned
+func (d *Deduplicator) Deduplicate(edgexcontext *appcontext.Context, params ...interface{}) (bool, interface{}) {
+	if len(params) < 1 {
+		return false, errors.New("No Event Received")
+	}
+
+	event, ok := params[0].(models.Event)
+	if !ok {
+		return false, errors.New("Unexpected type received, expecting models.Event")
+	}
+
+	key := d.eventKey(event)
+	now := time.Now()
+
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	if now.Sub(d.pruned) >= d.window {
+		d.prune(now)
+	}
+
+	if first, ok := d.seen[key]; ok && now.Sub(first) < d.window {
+		edgexcontext.LoggingClient.Debug("Duplicate event dropped", "device", event.Device, "id", event.ID)
+		return false, nil
+	}
+	d.seen[key] = now
+
+	return true, event
+}
+
+// eventKey returns the key identifying the event and its duplicates
+func (d *Deduplicator) eventKey(event models.Event) string {
+	if d.key == DedupKeyID && event.ID != "" {
+		return "id:" + event.ID
+	}
+
+	hash := fnv.New64a()
+	write := func(value string) {
+		hash.Write([]byte(value))
+		hash.Write([]byte{0})
+	}
+	write(event.Device)
+	write(strconv.FormatInt(event.Origin, 10))
+	for _, reading := range event.Readings {
+		write(reading.Name)
+		write(reading.Value)
+		write(strconv.FormatInt(reading.Origin, 10))
+	}
+	return "content:" + strconv.FormatUint(hash.Sum64(), 16)
+}
+
+// prune forgets the events seen before the window
+func (d *Deduplicator) prune(now time.Time) {
+	for key, first := range d.seen {
+		if now.Sub(first) >= d.window {
+			delete(d.seen, key)
+		}
+	}
+	d.pruned = now
+}
@@ -0,0 +1,110 @@
+//
+// Copyright (c) 2019 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	"testing"
+	"time"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeduplicateByID(t *testing.T) {
+	dedup, err := NewDeduplicator(time.Minute, DedupKeyID)
+	require.NoError(t, err)
+
+	event := models.Event{ID: "event1", Device: devID1, Readings: []models.Reading{{Name: readingName1, Value: "1"}}}
+	continuePipeline, result := dedup.Deduplicate(context, event)
+	assert.True(t, continuePipeline, "First delivery should be forwarded")
+	assert.Equal(t, event, result)
+
+	redelivery := event
+	redelivery.Readings = []models.Reading{{Name: readingName1, Value: "2"}}
+	continuePipeline, result = dedup.Deduplicate(context, redelivery)
+	assert.False(t, continuePipeline, "Event with the same ID should be dropped")
+	assert.Nil(t, result)
+
+	other := event
+	other.ID = "event2"
+	continuePipeline, _ = dedup.Deduplicate(context, other)
+	assert.True(t, continuePipeline, "Event with another ID should be forwarded")
+
+	noID := models.Event{Device: devID1, Origin: 1, Readings: []models.Reading{{Name: readingName1, Value: "1"}}}
+	continuePipeline, _ = dedup.Deduplicate(context, noID)
+	assert.True(t, continuePipeline)
+	continuePipeline, _ = dedup.Deduplicate(context, noID)
+	assert.False(t, continuePipeline, "Event without an ID should be identified by its content")
+}
+
+func TestDeduplicateByContent(t *testing.T) {
+	dedup, err := NewDeduplicator(time.Minute, DedupKeyContent)
+	require.NoError(t, err)
+
+	event := models.Event{ID: "event1", Device: devID1, Origin: 1, Readings: []models.Reading{{Name: readingName1, Value: "1"}}}
+	continuePipeline, _ := dedup.Deduplicate(context, event)
+	assert.True(t, continuePipeline)
+
+	redelivery := event
+	redelivery.ID = "event2"
+	continuePipeline, _ = dedup.Deduplicate(context, redelivery)
+	assert.False(t, continuePipeline, "Event with the same content should be dropped")
+
+	changes := []func(event *models.Event){
+		func(event *models.Event) { event.Device = devID2 },
+		func(event *models.Event) { event.Origin = 2 },
+		func(event *models.Event) { event.Readings = []models.Reading{{Name: "Humidity", Value: "1"}} },
+		func(event *models.Event) { event.Readings = []models.Reading{{Name: readingName1, Value: "2"}} },
+	}
+	for _, change := range changes {
+		other := event
+		change(&other)
+		continuePipeline, _ = dedup.Deduplicate(context, other)
+		assert.True(t, continuePipeline, "Event with other content should be forwarded")
+	}
+}
+
+func TestDeduplicateWindow(t *testing.T) {
+	dedup, err := NewDeduplicator(50*time.Millisecond, DedupKeyID)
+	require.NoError(t, err)
+
+	event := models.Event{ID: "event1"}
+	continuePipeline, _ := dedup.Deduplicate(context, event)
+	assert.True(t, continuePipeline)
+
+	time.Sleep(60 * time.Millisecond)
+	continuePipeline, _ = dedup.Deduplicate(context, event)
+	assert.True(t, continuePipeline, "Event should be forwarded again after the window")
+	assert.Len(t, dedup.seen, 1, "Events seen before the window should be forgotten")
+}
+
+func TestNewDeduplicatorErrors(t *testing.T) {
+	_, err := NewDeduplicator(0, DedupKeyID)
+	assert.EqualError(t, err, "deduplication window must be greater than zero")
+	_, err = NewDeduplicator(time.Minute, "hash")
+	assert.EqualError(t, err, "deduplication key must be id or content, got 'hash'")
+}
+
+func TestDeduplicateNoParameters(t *testing.T) {
+	dedup, err := NewDeduplicator(time.Minute, DedupKeyID)
+	require.NoError(t, err)
+	continuePipeline, result := dedup.Deduplicate(context)
+
+	assert.False(t, continuePipeline, "Pipeline should stop")
+	assert.EqualError(t, result.(error), "No Event Received")
+}
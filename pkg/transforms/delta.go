@@ -0,0 +1,118 @@
+//
+// Copyright (c) 2019 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/antoniomtz/app-functions-sdk-go/appcontext"
+	"github.com/edgexfoundry/go-mod-core-contracts/models"
+)
+
+// DeltaFilter drops the readings whose value hasn't changed significantly since the value last forwarded for the
+// same device and reading, reducing the chatter from slow changing sensors
+type DeltaFilter struct {
+	// Delta is the minimum absolute change of a numeric value to be forwarded
+	Delta float64
+	// Percentage is the minimum change of a numeric value, relative to the value last forwarded, to be forwarded
+	Percentage float64
+	mutex      sync.Mutex
+	last       map[deltaKey]string
+}
+
+// deltaKey identifies the readings of a device whose changes are tracked together
+type deltaKey struct {
+	device string
+	name   string
+}
+
+// NewDeltaFilter creates a DeltaFilter forwarding numeric values which changed by at least delta, or by at least
+// percentage of the value last forwarded. When both are zero any change is forwarded.
+func NewDeltaFilter(delta float64, percentage float64) *DeltaFilter {
+	return &DeltaFilter{
+		Delta:      delta,
+		Percentage: percentage,
+		last:       map[deltaKey]string{},
+	}
+}
+
+// FilterUnchanged removes the readings of the event from the previous function whose value hasn't changed
+// significantly since the value last forwarded for the same device and reading. Comparing with the value last
+// forwarded, rather than the previous value, means a slow drift is still forwarded once it adds up. The first
+// value of each reading is always forwarded, and non-numeric values are forwarded whenever they differ.
+// If no readings remain the pipeline execution will stop, otherwise the filtered Event is returned.
+func (f *DeltaFilter) FilterUnchanged(edgexcontext *appcontext.Context, params ...interface{}) (bool, interface{}) {
+	if len(params) < 1 {
+		return false, errors.New("No Event Received")
+	}
+
+	event, ok := params[0].(models.Event)
+	if !ok {
+		return false, errors.New("Unexpected type received, expecting models.Event")
+	}
+
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if f.last == nil {
+		f.last = map[deltaKey]string{}
+	}
+
+	readings := []models.Reading{}
+	for _, reading := range event.Readings {
+		key := deltaKey{device: event.Device, name: reading.Name}
+		last, seen := f.last[key]
+		if seen && !f.changed(last, reading.Value) {
+			continue
+		}
+		f.last[key] = reading.Value
+		readings = append(readings, reading)
+	}
+
+	if len(readings) == 0 {
+		edgexcontext.LoggingClient.Debug("Event dropped, no reading values changed")
+		return false, nil
+	}
+	event.Readings = readings
+
+	return true, event
+}
+
+// changed reports whether value differs significantly from the value last forwarded
+func (f *DeltaFilter) changed(last string, value string) bool {
+	lastNumber, lastErr := strconv.ParseFloat(strings.TrimSpace(last), 64)
+	number, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+	if lastErr != nil || err != nil {
+		return last != value
+	}
+
+	change := math.Abs(number - lastNumber)
+	if change == 0 {
+		return false
+	}
+	if f.Delta <= 0 && f.Percentage <= 0 {
+		return true
+	}
+	if f.Delta > 0 && change >= f.Delta {
+		return true
+	}
+	return f.Percentage > 0 && change >= math.Abs(lastNumber)*f.Percentage/100
+}
@@ -0,0 +1,98 @@
+//
+// Copyright (c) 2019 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	"testing"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func deltaEvent(device string, values ...string) models.Event {
+	event := models.Event{Device: device}
+	for _, value := range values {
+		event.Readings = append(event.Readings, models.Reading{Name: "Temperature", Value: value})
+	}
+	return event
+}
+
+func TestFilterUnchanged(t *testing.T) {
+	filter := NewDeltaFilter(0.5, 0)
+
+	tests := []struct {
+		name     string
+		event    models.Event
+		expected []string
+	}{
+		{"first value", deltaEvent(devID1, "20"), []string{"20"}},
+		{"unchanged", deltaEvent(devID1, "20.0"), nil},
+		{"below delta", deltaEvent(devID1, "20.3"), nil},
+		{"drift adds up", deltaEvent(devID1, "20.5"), []string{"20.5"}},
+		{"other device", deltaEvent(devID2, "20.5"), []string{"20.5"}},
+		{"within event", deltaEvent(devID1, "20.6", "21", "21.2"), []string{"21"}},
+		{"decrease", deltaEvent(devID1, "19"), []string{"19"}},
+		{"not numeric", deltaEvent(devID1, "warm"), []string{"warm"}},
+		{"same text", deltaEvent(devID1, "warm"), nil},
+	}
+
+	for _, test := range tests {
+		continuePipeline, result := filter.FilterUnchanged(context, test.event)
+		if test.expected == nil {
+			assert.False(t, continuePipeline, test.name)
+			assert.Nil(t, result, test.name)
+			continue
+		}
+
+		require.True(t, continuePipeline, test.name)
+		var values []string
+		for _, reading := range result.(models.Event).Readings {
+			values = append(values, reading.Value)
+		}
+		assert.Equal(t, test.expected, values, test.name)
+	}
+}
+
+func TestFilterUnchangedPercentage(t *testing.T) {
+	filter := NewDeltaFilter(0, 10)
+
+	continuePipeline, _ := filter.FilterUnchanged(context, deltaEvent(devID1, "200"))
+	assert.True(t, continuePipeline, "First value should be forwarded")
+	continuePipeline, _ = filter.FilterUnchanged(context, deltaEvent(devID1, "215"))
+	assert.False(t, continuePipeline, "Change below 10 percent should be dropped")
+	continuePipeline, _ = filter.FilterUnchanged(context, deltaEvent(devID1, "180"))
+	assert.True(t, continuePipeline, "Change of 10 percent should be forwarded")
+}
+
+func TestFilterUnchangedAnyChange(t *testing.T) {
+	filter := &DeltaFilter{}
+
+	continuePipeline, _ := filter.FilterUnchanged(context, deltaEvent(devID1, "1"))
+	assert.True(t, continuePipeline)
+	continuePipeline, _ = filter.FilterUnchanged(context, deltaEvent(devID1, "1"))
+	assert.False(t, continuePipeline, "Unchanged value should be dropped")
+	continuePipeline, _ = filter.FilterUnchanged(context, deltaEvent(devID1, "1.001"))
+	assert.True(t, continuePipeline, "Any change should be forwarded without a delta or percentage")
+}
+
+func TestFilterUnchangedNoParameters(t *testing.T) {
+	continuePipeline, result := NewDeltaFilter(0, 0).FilterUnchanged(context)
+
+	assert.False(t, continuePipeline, "Pipeline should stop")
+	assert.EqualError(t, result.(error), "No Event Received")
+}
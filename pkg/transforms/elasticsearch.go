@@ -0,0 +1,283 @@
+//
+// Copyright (c) 2019 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/antoniomtz/app-functions-sdk-go/appcontext"
+	"github.com/edgexfoundry/go-mod-core-contracts/clients"
+	"github.com/edgexfoundry/go-mod-core-contracts/models"
+)
+
+const defaultElasticsearchIndex = "edgex-{year}.{month}.{day}"
+
+// ElasticsearchConfig contains the parameters for exporting events to Elasticsearch
+type ElasticsearchConfig struct {
+	// URL is the base URL of the Elasticsearch cluster, i.e. http://elasticsearch:9200
+	URL string
+	// IndexTemplate is the template for index names. The placeholders {device}, {year}, {month} and {day} are
+	// replaced using the event's device and creation date. Defaults to edgex-{year}.{month}.{day}
+	IndexTemplate string
+	// Credentials provides the username and password used for basic authentication. They are read when the sender is
+	// created and again once Elasticsearch rejects them. Requests are not authenticated without a provider.
+	Credentials CredentialsProvider
+	// BatchSize is the number of events sent in each bulk request. Values less than 2 disable batching.
+	BatchSize int
+	// BatchTimeout is the maximum time an event waits in an incomplete batch before the batch is sent.
+	BatchTimeout time.Duration
+}
+
+// ElasticsearchSender indexes events into Elasticsearch using the bulk API
+type ElasticsearchSender struct {
+	config     ElasticsearchConfig
+	httpClient *http.Client
+	batch      exportBatch
+
+	mutex sync.Mutex
+	// username and password are those read from the provider, until Elasticsearch rejects them
+	username string
+	password string
+	read     bool
+}
+
+type elasticsearchBulkResponse struct {
+	Errors bool `json:"errors"`
+	Items  []map[string]struct {
+		Status int `json:"status"`
+		Error  struct {
+			Type   string `json:"type"`
+			Reason string `json:"reason"`
+		} `json:"error"`
+	} `json:"items"`
+}
+
+// NewElasticsearchSender creates a sender for the specified Elasticsearch configuration
+func NewElasticsearchSender(config ElasticsearchConfig) (*ElasticsearchSender, error) {
+	if config.URL == "" {
+		return nil, errors.New("Elasticsearch URL must be specified")
+	}
+	if config.IndexTemplate == "" {
+		config.IndexTemplate = defaultElasticsearchIndex
+	}
+
+	sender := &ElasticsearchSender{
+		config:     config,
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+	}
+	if config.Credentials != nil {
+		if _, _, err := sender.credentials(); err != nil {
+			return nil, err
+		}
+	}
+	sender.batch = exportBatch{name: "Elasticsearch", size: config.BatchSize, timeout: config.BatchTimeout, export: sender.sendBatch}
+	return sender, nil
+}
+
+// SetBatchFailureHandler sets the handler given the events held in a batch which failed to be sent after the calls
+// holding them returned, i.e. to store them for store and forward. Without a handler the events are kept and sent
+// with the next batch.
+func (sender *ElasticsearchSender) SetBatchFailureHandler(handler BatchFailureHandler) {
+	sender.batch.failed = handler
+}
+
+// ElasticsearchSend converts the event from the previous function into a bulk API document and indexes it. The
+// document is the event with an added @timestamp field, and the event ID is used as the document ID when set.
+// When batching is enabled the pipeline stops for the events held in an incomplete batch.
+func (sender *ElasticsearchSender) ElasticsearchSend(edgexcontext *appcontext.Context, params ...interface{}) (bool, interface{}) {
+	if len(params) < 1 {
+		// We didn't receive a result
+		return false, errors.New("No Event Received")
+	}
+
+	var event models.Event
+	switch value := params[0].(type) {
+	case models.Event:
+		event = value
+	case *models.Event:
+		event = *value
+	default:
+		return false, errors.New("Unexpected type received, expecting models.Event")
+	}
+
+	lines, err := sender.bulkLines(event, time.Now().UTC())
+	if err != nil {
+		return false, err
+	}
+
+	exported, err := sender.batch.add(edgexcontext, params[0], lines)
+	if err != nil {
+		return false, err
+	}
+	if !exported {
+		return false, nil
+	}
+
+	edgexcontext.LoggingClient.Info("Sent data to Elasticsearch")
+	edgexcontext.LoggingClient.Trace("Data exported", "Transport", "Elasticsearch", clients.CorrelationHeader, edgexcontext.CorrelationID)
+
+	return true, nil
+}
+
+// bulkLines returns the action and document lines for indexing the event
+func (sender *ElasticsearchSender) bulkLines(event models.Event, now time.Time) ([]byte, error) {
+	timestamp := now
+	if event.Created > 0 {
+		timestamp = time.Unix(0, event.Created*int64(time.Millisecond)).UTC()
+	}
+
+	eventJSON, err := json.Marshal(event)
+	if err != nil {
+		return nil, fmt.Errorf("unable to marshal event to JSON: %v", err)
+	}
+	document := map[string]interface{}{}
+	if err := json.Unmarshal(eventJSON, &document); err != nil {
+		return nil, fmt.Errorf("unable to marshal event to JSON: %v", err)
+	}
+	document["@timestamp"] = timestamp.Format(time.RFC3339Nano)
+
+	action := map[string]string{"_index": sender.indexName(event.Device, timestamp)}
+	if event.ID != "" {
+		action["_id"] = event.ID
+	}
+
+	var lines bytes.Buffer
+	encoder := json.NewEncoder(&lines)
+	if err := encoder.Encode(map[string]interface{}{"index": action}); err != nil {
+		return nil, err
+	}
+	if err := encoder.Encode(document); err != nil {
+		return nil, err
+	}
+	return lines.Bytes(), nil
+}
+
+// indexName applies the index template. Elasticsearch index names must be lowercase.
+func (sender *ElasticsearchSender) indexName(device string, timestamp time.Time) string {
+	replacer := strings.NewReplacer(
+		"{device}", device,
+		"{year}", timestamp.Format("2006"),
+		"{month}", timestamp.Format("01"),
+		"{day}", timestamp.Format("02"),
+	)
+	return strings.ToLower(replacer.Replace(sender.config.IndexTemplate))
+}
+
+// PendingEvents returns the number of events held in an incomplete batch, which have not been exported yet
+func (sender *ElasticsearchSender) PendingEvents() int {
+	return sender.batch.pending()
+}
+
+// Flush sends the events held in an incomplete batch to Elasticsearch, i.e. before the service stops
+func (sender *ElasticsearchSender) Flush() error {
+	return sender.batch.flush()
+}
+
+// sendBatch sends the documents of the entries in a single bulk request, which is retried as a whole when any of the
+// documents fail to be indexed
+func (sender *ElasticsearchSender) sendBatch(entries []batchEntry) (int, error) {
+	if err := sender.bulk(entries); err != nil {
+		return 0, err
+	}
+	return len(entries), nil
+}
+
+func (sender *ElasticsearchSender) bulk(entries []batchEntry) error {
+	var body []byte
+	for _, entry := range entries {
+		body = append(body, entry.payload.([]byte)...)
+	}
+
+	request, err := http.NewRequest(http.MethodPost, strings.TrimRight(sender.config.URL, "/")+"/_bulk", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	request.Header.Set(clients.ContentType, "application/x-ndjson")
+	if sender.config.Credentials != nil {
+		username, password, err := sender.credentials()
+		if err != nil {
+			return err
+		}
+		request.SetBasicAuth(username, password)
+	}
+
+	response, err := sender.httpClient.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	responseBody, _ := ioutil.ReadAll(response.Body)
+	if response.StatusCode == http.StatusUnauthorized {
+		sender.rejectCredentials()
+	}
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		return fmt.Errorf("Elasticsearch bulk request failed with status %s: %s", response.Status, string(responseBody))
+	}
+
+	result := elasticsearchBulkResponse{}
+	if err := json.Unmarshal(responseBody, &result); err != nil {
+		return fmt.Errorf("unable to parse Elasticsearch bulk response: %v", err)
+	}
+	if result.Errors {
+		failed := 0
+		reason := ""
+		for _, item := range result.Items {
+			for _, status := range item {
+				if status.Status >= 300 {
+					failed++
+					if reason == "" {
+						reason = status.Error.Type + ": " + status.Error.Reason
+					}
+				}
+			}
+		}
+		return fmt.Errorf("Elasticsearch failed to index %d of %d documents: %s", failed, len(result.Items), reason)
+	}
+
+	return nil
+}
+
+// credentials returns the username and password, reading them from the provider when none were read or Elasticsearch
+// rejected them
+func (sender *ElasticsearchSender) credentials() (string, string, error) {
+	sender.mutex.Lock()
+	defer sender.mutex.Unlock()
+	if !sender.read {
+		username, password, err := sender.config.Credentials.Credentials()
+		if err != nil {
+			return "", "", fmt.Errorf("unable to get Elasticsearch credentials: %v", err)
+		}
+		sender.username, sender.password, sender.read = username, password, true
+	}
+	return sender.username, sender.password, nil
+}
+
+// rejectCredentials forgets the credentials Elasticsearch rejected, so the next request reads them again
+func (sender *ElasticsearchSender) rejectCredentials() {
+	sender.mutex.Lock()
+	defer sender.mutex.Unlock()
+	sender.read = false
+}
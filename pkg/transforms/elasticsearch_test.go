@@ -0,0 +1,183 @@
+//
+// Copyright (c) 2019 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/antoniomtz/app-functions-sdk-go/appcontext"
+	"github.com/edgexfoundry/go-mod-core-contracts/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type elasticsearchTestServer struct {
+	*httptest.Server
+	mutex    sync.Mutex
+	requests [][]string
+}
+
+func newElasticsearchTestServer(t *testing.T, response string) *elasticsearchTestServer {
+	server := &elasticsearchTestServer{}
+	server.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/_bulk", r.URL.Path)
+		assert.Equal(t, "application/x-ndjson", r.Header.Get("Content-Type"))
+		body, _ := ioutil.ReadAll(r.Body)
+		server.mutex.Lock()
+		server.requests = append(server.requests, strings.Split(strings.TrimSpace(string(body)), "\n"))
+		server.mutex.Unlock()
+		w.Write([]byte(response))
+	}))
+	return server
+}
+
+func TestElasticsearchSend(t *testing.T) {
+	server := newElasticsearchTestServer(t, `{"errors":false,"items":[{"index":{"status":201}}]}`)
+	defer server.Close()
+
+	sender, err := NewElasticsearchSender(ElasticsearchConfig{
+		URL:           server.URL,
+		IndexTemplate: "EdgeX-{device}-{year}.{month}.{day}",
+	})
+	require.NoError(t, err)
+
+	// 2019-06-01T12:00:00Z
+	event := models.Event{ID: "event1", Device: devID1, Created: 1559390400000,
+		Readings: []models.Reading{{Name: readingName1, Value: readingValue1}}}
+	continuePipeline, result := sender.ElasticsearchSend(context, event)
+	assert.True(t, continuePipeline, "Pipeline should continue")
+	assert.Nil(t, result)
+
+	require.Equal(t, 1, len(server.requests))
+	require.Equal(t, 2, len(server.requests[0]), "Expected an action and a document line")
+
+	action := map[string]map[string]string{}
+	require.NoError(t, json.Unmarshal([]byte(server.requests[0][0]), &action))
+	assert.Equal(t, "edgex-id1-2019.06.01", action["index"]["_index"])
+	assert.Equal(t, "event1", action["index"]["_id"])
+
+	document := map[string]interface{}{}
+	require.NoError(t, json.Unmarshal([]byte(server.requests[0][1]), &document))
+	assert.Equal(t, "2019-06-01T12:00:00Z", document["@timestamp"])
+	assert.Equal(t, devID1, document["device"])
+}
+
+func TestElasticsearchSendBatched(t *testing.T) {
+	server := newElasticsearchTestServer(t, `{"errors":false}`)
+	defer server.Close()
+
+	sender, _ := NewElasticsearchSender(ElasticsearchConfig{URL: server.URL, BatchSize: 2, BatchTimeout: time.Minute})
+
+	continuePipeline, result := sender.ElasticsearchSend(context, models.Event{Device: devID1})
+	assert.False(t, continuePipeline, "Pipeline should stop while batching")
+	assert.Nil(t, result)
+	assert.Equal(t, 0, len(server.requests))
+
+	continuePipeline, _ = sender.ElasticsearchSend(context, &models.Event{Device: devID2})
+	assert.True(t, continuePipeline, "Pipeline should continue once batch is sent")
+	require.Equal(t, 1, len(server.requests))
+	assert.Equal(t, 4, len(server.requests[0]), "Batch should be sent in a single bulk request")
+}
+
+func TestElasticsearchSendItemErrors(t *testing.T) {
+	server := newElasticsearchTestServer(t,
+		`{"errors":true,"items":[{"index":{"status":400,"error":{"type":"mapper_parsing_exception","reason":"failed to parse"}}}]}`)
+	defer server.Close()
+
+	sender, _ := NewElasticsearchSender(ElasticsearchConfig{URL: server.URL})
+	continuePipeline, result := sender.ElasticsearchSend(context, models.Event{Device: devID1})
+	assert.False(t, continuePipeline)
+	require.Error(t, result.(error))
+	assert.Contains(t, result.(error).Error(), "mapper_parsing_exception")
+}
+
+func TestElasticsearchSendBatchTimeoutFailure(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	sender, _ := NewElasticsearchSender(ElasticsearchConfig{URL: server.URL, BatchSize: 10, BatchTimeout: 50 * time.Millisecond})
+	failed := make(chan interface{}, 1)
+	sender.SetBatchFailureHandler(func(edgexcontext *appcontext.Context, data interface{}, err error) bool {
+		failed <- data
+		return true
+	})
+
+	event := models.Event{Device: devID1}
+	continuePipeline, _ := sender.ElasticsearchSend(context, event)
+	assert.False(t, continuePipeline, "Pipeline should stop while batching")
+
+	select {
+	case data := <-failed:
+		assert.Equal(t, event, data, "The event of the failed batch should be given to the handler")
+	case <-time.After(time.Second):
+		t.Fatal("The event of the failed batch was dropped")
+	}
+}
+
+func TestElasticsearchSendRefreshesRejectedCredentials(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if username, password, _ := r.BasicAuth(); username != "elastic" || password != "rotated" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`{"errors":false,"items":[{"index":{"status":201}}]}`))
+	}))
+	defer server.Close()
+
+	reads := 0
+	password := "expired"
+	credentials := CredentialsProviderFunc(func() (string, string, error) {
+		reads++
+		return "elastic", password, nil
+	})
+	sender, err := NewElasticsearchSender(ElasticsearchConfig{URL: server.URL, Credentials: credentials})
+	require.NoError(t, err)
+
+	continuePipeline, _ := sender.ElasticsearchSend(context, models.Event{Device: devID1})
+	assert.False(t, continuePipeline, "The rejected credentials should fail the request")
+	password = "rotated"
+	continuePipeline, _ = sender.ElasticsearchSend(context, models.Event{Device: devID1})
+	assert.True(t, continuePipeline, "The credentials should be read again once rejected")
+	sender.ElasticsearchSend(context, models.Event{Device: devID1})
+	assert.Equal(t, 2, reads, "The credentials should only be read again once rejected")
+
+	_, err = NewElasticsearchSender(ElasticsearchConfig{URL: server.URL, Credentials: CredentialsProviderFunc(func() (string, string, error) {
+		return "", "", errors.New("no password secret")
+	})})
+	assert.Error(t, err)
+}
+
+func TestElasticsearchSendWrongType(t *testing.T) {
+	sender, _ := NewElasticsearchSender(ElasticsearchConfig{URL: "http://elasticsearch:9200"})
+	continuePipeline, result := sender.ElasticsearchSend(context, "not an event")
+	assert.False(t, continuePipeline)
+	assert.Error(t, result.(error))
+
+	continuePipeline, result = sender.ElasticsearchSend(context)
+	assert.False(t, continuePipeline)
+	assert.Equal(t, "No Event Received", result.(error).Error())
+}
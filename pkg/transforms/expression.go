@@ -0,0 +1,149 @@
+//
+// Copyright (c) 2019 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/antoniomtz/app-functions-sdk-go/appcontext"
+	"github.com/antoniomtz/app-functions-sdk-go/pkg/expression"
+	"github.com/edgexfoundry/go-mod-core-contracts/models"
+)
+
+// ExpressionFilter keeps the readings for which an expression is true, i.e.
+// `Float64(value) > 40 && deviceName =~ "Random-.*"`. See ReadingParameters for the parameters of the expression.
+type ExpressionFilter struct {
+	expression *expression.Expression
+}
+
+// ReadingComputer computes the value of the readings of each value descriptor with an expression, i.e.
+// `Round(Float64(value) * 1.8 + 32, 1)`. See ReadingParameters for the parameters of the expressions.
+type ReadingComputer struct {
+	expressions map[string]*expression.Expression
+}
+
+// ReadingParameters returns the parameters expressions are evaluated with for a reading of the event: the deviceName,
+// readingName and value, a string, of the reading, and its origin, defaulting to the origin of the event
+func ReadingParameters(event models.Event, reading models.Reading) map[string]interface{} {
+	device := reading.Device
+	if device == "" {
+		device = event.Device
+	}
+	origin := reading.Origin
+	if origin == 0 {
+		origin = event.Origin
+	}
+	return map[string]interface{}{
+		"deviceName":  device,
+		"readingName": reading.Name,
+		"value":       reading.Value,
+		"origin":      origin,
+	}
+}
+
+// NewExpressionFilter creates an ExpressionFilter for the text of an expression
+func NewExpressionFilter(text string) (*ExpressionFilter, error) {
+	parsed, err := expression.Parse(text)
+	if err != nil {
+		return nil, err
+	}
+	return &ExpressionFilter{expression: parsed}, nil
+}
+
+// FilterByExpression removes the readings of the Event from the previous function for which the expression is false,
+// or can't be evaluated, i.e. when a reading doesn't have a numeric value. If no readings remain the pipeline execution
+// will stop, otherwise the filtered Event is returned.
+func (f *ExpressionFilter) FilterByExpression(edgexcontext *appcontext.Context, params ...interface{}) (bool, interface{}) {
+	if len(params) < 1 {
+		return false, errors.New("No Event Received")
+	}
+
+	edgexcontext.LoggingClient.Debug("Filter by Expression")
+
+	event, ok := params[0].(models.Event)
+	if !ok {
+		return false, errors.New("Unexpected type received, expecting models.Event")
+	}
+
+	readings := []models.Reading{}
+	for _, reading := range event.Readings {
+		matches, err := f.expression.EvaluateBool(ReadingParameters(event, reading))
+		if err != nil {
+			edgexcontext.LoggingClient.Debug(fmt.Sprintf("Reading '%s' removed, unable to evaluate expression: %v", reading.Name, err))
+			continue
+		}
+		if matches {
+			readings = append(readings, reading)
+		}
+	}
+
+	if len(readings) == 0 {
+		return false, nil
+	}
+	event.Readings = readings
+
+	return true, event
+}
+
+// NewReadingComputer creates a ReadingComputer for the text of the expression of each value descriptor
+func NewReadingComputer(readings map[string]string) (*ReadingComputer, error) {
+	if len(readings) == 0 {
+		return nil, errors.New("an expression must be specified for at least one value descriptor")
+	}
+	computer := &ReadingComputer{expressions: map[string]*expression.Expression{}}
+	for valueDescriptor, text := range readings {
+		parsed, err := expression.Parse(text)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", valueDescriptor, err)
+		}
+		computer.expressions[valueDescriptor] = parsed
+	}
+	return computer, nil
+}
+
+// ComputeReadings replaces the value of each reading of the Event from the previous function with the result of the
+// expression of its value descriptor. Numbers are formatted with the fewest digits needed. Readings of other value
+// descriptors are passed through unchanged. This function returns an Event, or an error when an expression can't be
+// evaluated.
+func (c *ReadingComputer) ComputeReadings(edgexcontext *appcontext.Context, params ...interface{}) (bool, interface{}) {
+	if len(params) < 1 {
+		return false, errors.New("No Event Received")
+	}
+
+	edgexcontext.LoggingClient.Debug("Computing readings")
+
+	event, ok := params[0].(models.Event)
+	if !ok {
+		return false, errors.New("Unexpected type received, expecting models.Event")
+	}
+
+	readings := make([]models.Reading, len(event.Readings))
+	for index, reading := range event.Readings {
+		if computation, ok := c.expressions[reading.Name]; ok {
+			value, err := computation.Evaluate(ReadingParameters(event, reading))
+			if err != nil {
+				return false, fmt.Errorf("unable to compute reading '%s': %v", reading.Name, err)
+			}
+			reading.Value = expression.FormatValue(value)
+		}
+		readings[index] = reading
+	}
+	event.Readings = readings
+
+	return true, event
+}
@@ -0,0 +1,114 @@
+//
+// Copyright (c) 2019 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	"testing"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFilterByExpression(t *testing.T) {
+	filter, err := NewExpressionFilter(`Float64(value) > 40 && deviceName =~ "^Random-"`)
+	require.NoError(t, err)
+
+	event := models.Event{
+		Device: "Random-Float-Device",
+		Readings: []models.Reading{
+			{Name: "Temperature", Value: "42"},
+			{Name: "Humidity", Value: "35"},
+			{Name: "Status", Value: "ok"},
+			{Name: "Pressure", Value: "1013", Device: "Weather-Station"},
+		},
+	}
+	continuePipeline, result := filter.FilterByExpression(context, event)
+	require.True(t, continuePipeline)
+	assert.Equal(t, []models.Reading{{Name: "Temperature", Value: "42"}}, result.(models.Event).Readings)
+
+	event.Readings = event.Readings[1:]
+	continuePipeline, result = filter.FilterByExpression(context, event)
+	assert.False(t, continuePipeline, "Pipeline should stop when no readings remain")
+	assert.Nil(t, result)
+}
+
+func TestFilterByExpressionOrigin(t *testing.T) {
+	filter, err := NewExpressionFilter(`origin >= 2000 && readingName != "Humidity"`)
+	require.NoError(t, err)
+
+	continuePipeline, result := filter.FilterByExpression(context, models.Event{
+		Origin: 2000,
+		Readings: []models.Reading{
+			{Name: "Temperature", Value: "21"},
+			{Name: "Pressure", Value: "1013", Origin: 1000},
+			{Name: "Humidity", Value: "40"},
+		},
+	})
+	require.True(t, continuePipeline)
+	assert.Equal(t, []models.Reading{{Name: "Temperature", Value: "21"}}, result.(models.Event).Readings)
+}
+
+func TestComputeReadings(t *testing.T) {
+	computer, err := NewReadingComputer(map[string]string{
+		"Temperature": "Round(Float64(value) * 1.8 + 32, 1)",
+		"Status":      `value == "1" ? "on" : "off"`,
+	})
+	require.NoError(t, err)
+
+	continuePipeline, result := computer.ComputeReadings(context, models.Event{
+		Device: devID1,
+		Readings: []models.Reading{
+			{Name: "Temperature", Value: "21.46"},
+			{Name: "Status", Value: "1"},
+			{Name: "Humidity", Value: "40"},
+		},
+	})
+	require.True(t, continuePipeline)
+	assert.Equal(t, []models.Reading{
+		{Name: "Temperature", Value: "70.6"},
+		{Name: "Status", Value: "on"},
+		{Name: "Humidity", Value: "40"},
+	}, result.(models.Event).Readings)
+
+	continuePipeline, result = computer.ComputeReadings(context, models.Event{
+		Readings: []models.Reading{{Name: "Temperature", Value: "hot"}},
+	})
+	assert.False(t, continuePipeline)
+	assert.EqualError(t, result.(error), "unable to compute reading 'Temperature': Float64: 'hot' is not a number")
+}
+
+func TestExpressionErrors(t *testing.T) {
+	_, err := NewExpressionFilter("value >")
+	assert.Error(t, err)
+	_, err = NewReadingComputer(map[string]string{"Temperature": "value *"})
+	assert.EqualError(t, err, "Temperature: invalid expression 'value *': unexpected end of expression")
+	_, err = NewReadingComputer(nil)
+	assert.Error(t, err)
+
+	filter, err := NewExpressionFilter("true")
+	require.NoError(t, err)
+	continuePipeline, result := filter.FilterByExpression(context, "event")
+	assert.False(t, continuePipeline)
+	assert.EqualError(t, result.(error), "Unexpected type received, expecting models.Event")
+
+	computer, err := NewReadingComputer(map[string]string{"Temperature": "value"})
+	require.NoError(t, err)
+	continuePipeline, result = computer.ComputeReadings(context)
+	assert.False(t, continuePipeline)
+	assert.EqualError(t, result.(error), "No Event Received")
+}
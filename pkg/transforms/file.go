@@ -0,0 +1,222 @@
+//
+// Copyright (c) 2019 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/antoniomtz/app-functions-sdk-go/appcontext"
+	"github.com/edgexfoundry/go-mod-core-contracts/clients"
+)
+
+const rotatedFileTimeFormat = "20060102T150405.000"
+
+// FileWriter appends data to a local file, rotating the file when it reaches a maximum size or age
+type FileWriter struct {
+	file *rotatingFile
+}
+
+// NewFileWriter creates a FileWriter for the file at path. The file is rotated once it exceeds maxSize bytes or
+// has been open for longer than maxAge. Zero values disable the respective rotation. Rotated files are renamed with
+// a timestamp suffix and gzipped when compress is true.
+func NewFileWriter(path string, maxSize int64, maxAge time.Duration, compress bool) *FileWriter {
+	return &FileWriter{
+		file: &rotatingFile{
+			path:     path,
+			maxSize:  maxSize,
+			maxAge:   maxAge,
+			compress: compress,
+		},
+	}
+}
+
+// FileExport appends data from the previous function to the file as a new line.
+func (writer *FileWriter) FileExport(edgexcontext *appcontext.Context, params ...interface{}) (bool, interface{}) {
+	if len(params) < 1 {
+		// We didn't receive a result
+		return false, errors.New("No Data Received")
+	}
+
+	data, err := coerceToBytes(params[0])
+	if err != nil {
+		return false, err
+	}
+
+	if err := writer.file.WriteLine(data); err != nil {
+		return false, fmt.Errorf("unable to write to file %s: %v", writer.file.path, err)
+	}
+
+	edgexcontext.LoggingClient.Debug("Wrote data to file", "file", writer.file.path)
+	edgexcontext.LoggingClient.Trace("Data exported", "Transport", "File", clients.CorrelationHeader, edgexcontext.CorrelationID)
+	if err := edgexcontext.MarkAsPushed(); err != nil {
+		edgexcontext.LoggingClient.Error(err.Error())
+	}
+
+	return true, nil
+}
+
+// rotatingFile is an append only file which is rotated based on size and age
+type rotatingFile struct {
+	path     string
+	maxSize  int64
+	maxAge   time.Duration
+	compress bool
+	mutex    sync.Mutex
+	file     *os.File
+	size     int64
+	opened   time.Time
+}
+
+// WriteLine appends data to the file, terminated with a newline if not already
+func (rf *rotatingFile) WriteLine(data []byte) error {
+	if len(data) == 0 || data[len(data)-1] != '\n' {
+		data = append(data[:len(data):len(data)], '\n')
+	}
+
+	rf.mutex.Lock()
+	defer rf.mutex.Unlock()
+
+	if rf.file != nil && rf.needsRotation(int64(len(data))) {
+		if err := rf.rotate(); err != nil {
+			return err
+		}
+	}
+
+	if rf.file == nil {
+		if err := rf.open(); err != nil {
+			return err
+		}
+	}
+
+	written, err := rf.file.Write(data)
+	rf.size += int64(written)
+	return err
+}
+
+// Close closes the file, i.e. before the service stops. The file is reopened by the next export.
+func (writer *FileWriter) Close() error {
+	return writer.file.Close()
+}
+
+// Close closes the current file without rotating it
+func (rf *rotatingFile) Close() error {
+	rf.mutex.Lock()
+	defer rf.mutex.Unlock()
+
+	if rf.file == nil {
+		return nil
+	}
+
+	err := rf.file.Close()
+	rf.file = nil
+	return err
+}
+
+func (rf *rotatingFile) needsRotation(pending int64) bool {
+	if rf.maxSize > 0 && rf.size > 0 && rf.size+pending > rf.maxSize {
+		return true
+	}
+	return rf.maxAge > 0 && time.Since(rf.opened) >= rf.maxAge
+}
+
+func (rf *rotatingFile) open() error {
+	if err := os.MkdirAll(filepath.Dir(rf.path), 0755); err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(rf.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+
+	rf.file = file
+	rf.size = info.Size()
+	rf.opened = time.Now()
+	return nil
+}
+
+func (rf *rotatingFile) rotate() error {
+	if err := rf.file.Close(); err != nil {
+		return err
+	}
+	rf.file = nil
+
+	rotatedPath := rf.path + "." + time.Now().Format(rotatedFileTimeFormat)
+	for suffix := 1; fileExists(rotatedPath) || fileExists(rotatedPath+".gz"); suffix++ {
+		rotatedPath = fmt.Sprintf("%s.%s-%d", rf.path, time.Now().Format(rotatedFileTimeFormat), suffix)
+	}
+	if err := os.Rename(rf.path, rotatedPath); err != nil {
+		return err
+	}
+
+	if rf.compress {
+		return gzipFile(rotatedPath)
+	}
+
+	return nil
+}
+
+// gzipFile compresses the file at path to path.gz and removes the original
+func gzipFile(path string) error {
+	if err := gzipCopy(path, path+".gz"); err != nil {
+		return err
+	}
+
+	return os.Remove(path)
+}
+
+func gzipCopy(sourcePath string, destinationPath string) error {
+	source, err := os.Open(sourcePath)
+	if err != nil {
+		return err
+	}
+	defer source.Close()
+
+	destination, err := os.OpenFile(destinationPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	defer destination.Close()
+
+	writer := gzip.NewWriter(destination)
+	if _, err := io.Copy(writer, source); err != nil {
+		return err
+	}
+	if err := writer.Close(); err != nil {
+		return err
+	}
+
+	return destination.Close()
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
@@ -0,0 +1,92 @@
+//
+// Copyright (c) 2019 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	"compress/gzip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileExport(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "fileexport")
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "export", "data.log")
+
+	writer := NewFileWriter(path, 0, 0, false)
+	continuePipeline, result := writer.FileExport(context, "first")
+	assert.True(t, continuePipeline, "Pipeline should continue")
+	assert.Nil(t, result)
+	writer.FileExport(context, []byte("second\n"))
+	writer.file.Close()
+
+	contents, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "first\nsecond\n", string(contents))
+}
+
+func TestFileExportRotateBySize(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "fileexport")
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "data.log")
+
+	writer := NewFileWriter(path, 10, 0, false)
+	writer.FileExport(context, "123456")
+	writer.FileExport(context, "abcdef")
+	writer.FileExport(context, "ghijkl")
+	writer.file.Close()
+
+	rotated, _ := filepath.Glob(path + ".*")
+	assert.Equal(t, 2, len(rotated), "Expected two rotated files")
+	contents, _ := ioutil.ReadFile(path)
+	assert.Equal(t, "ghijkl\n", string(contents))
+}
+
+func TestFileExportRotateByAgeCompressed(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "fileexport")
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "data.log")
+
+	writer := NewFileWriter(path, 0, 10*time.Millisecond, true)
+	writer.FileExport(context, "old")
+	time.Sleep(20 * time.Millisecond)
+	writer.FileExport(context, "new")
+	writer.file.Close()
+
+	rotated, _ := filepath.Glob(path + ".*.gz")
+	require.Equal(t, 1, len(rotated), "Expected one compressed rotated file")
+
+	file, _ := os.Open(rotated[0])
+	defer file.Close()
+	reader, err := gzip.NewReader(file)
+	require.NoError(t, err)
+	contents, _ := ioutil.ReadAll(reader)
+	assert.Equal(t, "old\n", string(contents))
+}
+
+func TestFileExportNoData(t *testing.T) {
+	writer := NewFileWriter("unused", 0, 0, false)
+	continuePipeline, result := writer.FileExport(context)
+	assert.False(t, continuePipeline)
+	assert.Equal(t, "No Data Received", result.(error).Error())
+}
@@ -44,7 +44,17 @@ func (sender HTTPSender) HTTPPost(edgexcontext *appcontext.Context, params ...in
 	}
 	if result, ok := params[0].(string); ok {
 		edgexcontext.LoggingClient.Info("POSTing data")
-		response, err := http.Post(sender.URL, sender.MimeType, bytes.NewReader(([]byte)(result)))
+		request, err := http.NewRequest(http.MethodPost, sender.URL, bytes.NewReader(([]byte)(result)))
+		if err != nil {
+			return false, err
+		}
+		// abort the request when the service stops
+		request = request.WithContext(edgexcontext.RequestContext())
+		request.Header.Set("Content-Type", sender.MimeType)
+		if edgexcontext.TraceParent != "" {
+			request.Header.Set("traceparent", edgexcontext.TraceParent)
+		}
+		response, err := http.DefaultClient.Do(request)
 		if err != nil {
 			//LoggingClient.Error(err.Error())
 			return false, err
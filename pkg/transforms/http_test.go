@@ -16,12 +16,16 @@
 package transforms
 
 import (
+	syscontext "context"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
 	"strings"
 	"testing"
+	"time"
+
+	"github.com/antoniomtz/app-functions-sdk-go/appcontext"
 )
 
 func TestHTTPPost(t *testing.T) {
@@ -65,6 +69,55 @@ func TestHTTPPost(t *testing.T) {
 	sender.HTTPPost(context, msgStr)
 }
 
+func TestHTTPPostTraceParent(t *testing.T) {
+	const traceParent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+	var received string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r.Header.Get("traceparent")
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ts.Close()
+
+	context.TraceParent = traceParent
+	defer func() { context.TraceParent = "" }()
+
+	sender := HTTPSender{URL: ts.URL}
+	sender.HTTPPost(context, "test message")
+	if received != traceParent {
+		t.Errorf("Invalid traceparent received %s, expected %s", received, traceParent)
+	}
+}
+
+func TestHTTPPostCancelled(t *testing.T) {
+	stuck := make(chan struct{})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-stuck
+	}))
+	defer ts.Close()
+	defer close(stuck)
+
+	ctx, cancel := syscontext.WithCancel(syscontext.Background())
+	edgexcontext := &appcontext.Context{LoggingClient: context.LoggingClient, Ctx: ctx}
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		cancel()
+	}()
+
+	started := time.Now()
+	sender := HTTPSender{URL: ts.URL}
+	continuePipeline, result := sender.HTTPPost(edgexcontext, "test message")
+	if continuePipeline {
+		t.Fatal("Pipeline should stop")
+	}
+	if _, ok := result.(error); !ok {
+		t.Fatal("Should return an error when the service is stopping")
+	}
+	if time.Since(started) > 5*time.Second {
+		t.Fatal("The request should be aborted when the service is stopping")
+	}
+}
+
 func TestHTTPPostNoParameterPassed(t *testing.T) {
 
 	sender := HTTPSender{}
@@ -0,0 +1,441 @@
+//
+// Copyright (c) 2019 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/antoniomtz/app-functions-sdk-go/appcontext"
+	"github.com/edgexfoundry/go-mod-core-contracts/clients"
+	"github.com/edgexfoundry/go-mod-core-contracts/models"
+)
+
+// The protocols of the model servers
+const (
+	// InferenceTFServing is the REST API of TensorFlow Serving, also served by OpenVINO Model Server
+	InferenceTFServing = "tfserving"
+	// InferenceV2 is the KServe v2 inference protocol, served by Triton, OpenVINO Model Server and ONNX Runtime
+	// through Triton
+	InferenceV2 = "v2"
+)
+
+// InferenceConfig contains the parameters for calling a model server
+type InferenceConfig struct {
+	// URL is the inference endpoint of the model, i.e. http://tfserving:8501/v1/models/anomaly:predict or
+	// http://triton:8000/v2/models/anomaly/infer
+	URL string
+	// Protocol is the protocol of the model server, tfserving, the default, or v2
+	Protocol string
+	// InputName is the name of the input tensor with the v2 protocol, input by default
+	InputName string
+	// Readings are the names of the readings sent as the features of the model, in order. All the readings with a
+	// numeric value are sent when empty.
+	Readings []string
+	// Binary sends the binary value of the first of the readings which has one, i.e. a camera image, base64 encoded,
+	// instead of numeric features
+	Binary bool
+	// ResultName is the name of the reading the inference result is attached as, inference by default
+	ResultName string
+	// BatchSize is the maximum number of events sent in each request. Events processed concurrently are batched
+	// together. Values less than 2 disable batching.
+	BatchSize int
+	// BatchTimeout is the maximum time an event waits for an incomplete batch to be sent, 100ms by default
+	BatchTimeout time.Duration
+	// Timeout is the timeout of the requests, 30s by default
+	Timeout time.Duration
+}
+
+// InferenceMetrics contains the requests sent by an InferenceClient and their latency
+type InferenceMetrics struct {
+	Name string
+	// Requests is the number of requests sent to the model server
+	Requests uint64
+	// Failed is the number of requests which failed
+	Failed uint64
+	// Instances is the number of events sent to the model server
+	Instances uint64
+	// LatencyNanos is the total time spent in requests, and MaxLatencyNanos and AverageLatencyNanos the longest and
+	// average time of a request
+	LatencyNanos        int64
+	MaxLatencyNanos     int64
+	AverageLatencyNanos int64
+}
+
+// InferenceClient sends the readings of events to a model server and attaches the inference result to the events
+type InferenceClient struct {
+	// Name is the name the metrics of the client are reported under
+	Name       string
+	config     InferenceConfig
+	httpClient *http.Client
+	mutex      sync.Mutex
+	pending    []*inferenceCall
+	timer      *time.Timer
+	metrics    InferenceMetrics
+}
+
+// inferenceCall is an instance waiting in a batch for its prediction
+type inferenceCall struct {
+	instance interface{}
+	done     chan inferenceResult
+}
+
+type inferenceResult struct {
+	prediction interface{}
+	err        error
+}
+
+// NewInferenceClient creates an InferenceClient reporting its metrics under name, setting the defaults of the config
+func NewInferenceClient(name string, config InferenceConfig) (*InferenceClient, error) {
+	if config.URL == "" {
+		return nil, errors.New("model server URL must be specified")
+	}
+	if config.Protocol == "" {
+		config.Protocol = InferenceTFServing
+	}
+	if config.Protocol != InferenceTFServing && config.Protocol != InferenceV2 {
+		return nil, fmt.Errorf("inference protocol must be tfserving or v2, got '%s'", config.Protocol)
+	}
+	if config.BatchSize < 0 || config.BatchTimeout < 0 || config.Timeout < 0 {
+		return nil, errors.New("inference batch size and timeouts must not be negative")
+	}
+	if config.InputName == "" {
+		config.InputName = "input"
+	}
+	if config.ResultName == "" {
+		config.ResultName = "inference"
+	}
+	if config.BatchTimeout == 0 {
+		config.BatchTimeout = 100 * time.Millisecond
+	}
+	if config.Timeout == 0 {
+		config.Timeout = 30 * time.Second
+	}
+
+	return &InferenceClient{
+		Name:       name,
+		config:     config,
+		httpClient: &http.Client{Timeout: config.Timeout},
+		metrics:    InferenceMetrics{Name: name},
+	}, nil
+}
+
+// Infer sends the readings of the Event from the previous function to the model server and adds a reading with the
+// inference result, named ResultName, to the event. A result which isn't a number or a string is attached as JSON.
+// This function returns the Event, or an error when the readings to send are missing or the request fails.
+func (client *InferenceClient) Infer(edgexcontext *appcontext.Context, params ...interface{}) (bool, interface{}) {
+	if len(params) < 1 {
+		return false, errors.New("No Event Received")
+	}
+
+	event, ok := params[0].(models.Event)
+	if !ok {
+		return false, errors.New("Unexpected type received, expecting models.Event")
+	}
+
+	instance, err := client.instance(event)
+	if err != nil {
+		return false, err
+	}
+
+	prediction, err := client.infer(instance)
+	if err != nil {
+		return false, fmt.Errorf("inference failed: %v", err)
+	}
+
+	value, err := formatPrediction(prediction)
+	if err != nil {
+		return false, err
+	}
+	event.Readings = append(append([]models.Reading(nil), event.Readings...), models.Reading{
+		Device: event.Device,
+		Name:   client.config.ResultName,
+		Value:  value,
+		Origin: time.Now().UnixNano() / int64(time.Millisecond),
+	})
+
+	return true, event
+}
+
+// Metrics returns a snapshot of the requests sent by the client
+func (client *InferenceClient) Metrics() InferenceMetrics {
+	client.mutex.Lock()
+	defer client.mutex.Unlock()
+
+	metrics := client.metrics
+	if metrics.Requests > 0 {
+		metrics.AverageLatencyNanos = metrics.LatencyNanos / int64(metrics.Requests)
+	}
+	return metrics
+}
+
+// instance returns the input of the model for the event, the numeric features or the base64 encoded binary value
+func (client *InferenceClient) instance(event models.Event) (interface{}, error) {
+	selected := event.Readings
+	if len(client.config.Readings) > 0 {
+		byName := map[string]models.Reading{}
+		for _, reading := range event.Readings {
+			byName[reading.Name] = reading
+		}
+		selected = nil
+		for _, name := range client.config.Readings {
+			reading, ok := byName[name]
+			if !ok {
+				if client.config.Binary {
+					continue
+				}
+				return nil, fmt.Errorf("reading '%s' to infer is missing", name)
+			}
+			selected = append(selected, reading)
+		}
+	}
+
+	if client.config.Binary {
+		for _, reading := range selected {
+			if len(reading.BinaryValue) > 0 {
+				return base64.StdEncoding.EncodeToString(reading.BinaryValue), nil
+			}
+		}
+		return nil, errors.New("no reading to infer has a binary value")
+	}
+
+	features := []float64{}
+	for _, reading := range selected {
+		value, err := strconv.ParseFloat(strings.TrimSpace(reading.Value), 64)
+		if err != nil {
+			if len(client.config.Readings) == 0 {
+				continue
+			}
+			return nil, fmt.Errorf("reading '%s' to infer doesn't have a numeric value, got '%s'", reading.Name, reading.Value)
+		}
+		features = append(features, value)
+	}
+	if len(features) == 0 {
+		return nil, errors.New("no reading to infer has a numeric value")
+	}
+	return features, nil
+}
+
+// infer returns the prediction for the instance, batching it with the instances of concurrent calls when enabled
+func (client *InferenceClient) infer(instance interface{}) (interface{}, error) {
+	if client.config.BatchSize < 2 {
+		predictions, err := client.send([]interface{}{instance})
+		if err != nil {
+			return nil, err
+		}
+		return predictions[0], nil
+	}
+
+	call := &inferenceCall{instance: instance, done: make(chan inferenceResult, 1)}
+	client.mutex.Lock()
+	client.pending = append(client.pending, call)
+	if len(client.pending) >= client.config.BatchSize {
+		batch := client.takePending()
+		client.mutex.Unlock()
+		client.sendBatch(batch)
+	} else {
+		if len(client.pending) == 1 {
+			client.timer = time.AfterFunc(client.config.BatchTimeout, client.sendPending)
+		}
+		client.mutex.Unlock()
+	}
+
+	result := <-call.done
+	return result.prediction, result.err
+}
+
+// takePending returns the calls of the incomplete batch, which must be called with the mutex locked
+func (client *InferenceClient) takePending() []*inferenceCall {
+	batch := client.pending
+	client.pending = nil
+	if client.timer != nil {
+		client.timer.Stop()
+		client.timer = nil
+	}
+	return batch
+}
+
+// sendPending sends the incomplete batch once its timeout expired
+func (client *InferenceClient) sendPending() {
+	client.mutex.Lock()
+	batch := client.takePending()
+	client.mutex.Unlock()
+	if len(batch) > 0 {
+		client.sendBatch(batch)
+	}
+}
+
+func (client *InferenceClient) sendBatch(batch []*inferenceCall) {
+	instances := make([]interface{}, len(batch))
+	for i, call := range batch {
+		instances[i] = call.instance
+	}
+	predictions, err := client.send(instances)
+	for i, call := range batch {
+		if err != nil {
+			call.done <- inferenceResult{err: err}
+		} else {
+			call.done <- inferenceResult{prediction: predictions[i]}
+		}
+	}
+}
+
+// send requests the predictions of the instances, returning one prediction per instance
+func (client *InferenceClient) send(instances []interface{}) ([]interface{}, error) {
+	start := time.Now()
+	predictions, err := client.request(instances)
+	latency := time.Since(start).Nanoseconds()
+
+	client.mutex.Lock()
+	client.metrics.Requests++
+	client.metrics.Instances += uint64(len(instances))
+	client.metrics.LatencyNanos += latency
+	if latency > client.metrics.MaxLatencyNanos {
+		client.metrics.MaxLatencyNanos = latency
+	}
+	if err != nil {
+		client.metrics.Failed++
+	}
+	client.mutex.Unlock()
+
+	return predictions, err
+}
+
+func (client *InferenceClient) request(instances []interface{}) ([]interface{}, error) {
+	var body interface{}
+	if client.config.Protocol == InferenceV2 {
+		body = client.v2Request(instances)
+	} else {
+		tfInstances := make([]interface{}, len(instances))
+		for i, instance := range instances {
+			if encoded, ok := instance.(string); ok {
+				tfInstances[i] = map[string]string{"b64": encoded}
+			} else {
+				tfInstances[i] = instance
+			}
+		}
+		body = map[string]interface{}{"instances": tfInstances}
+	}
+
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	response, err := client.httpClient.Post(client.config.URL, clients.ContentTypeJSON, bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+	contents, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return nil, err
+	}
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		return nil, fmt.Errorf("model server returned %s: %s", response.Status, strings.TrimSpace(string(contents)))
+	}
+
+	var predictions []interface{}
+	if client.config.Protocol == InferenceV2 {
+		predictions, err = v2Predictions(contents, len(instances))
+	} else {
+		var tfResponse struct {
+			Predictions []interface{} `json:"predictions"`
+		}
+		err = json.Unmarshal(contents, &tfResponse)
+		predictions = tfResponse.Predictions
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid model server response: %v", err)
+	}
+	if len(predictions) != len(instances) {
+		return nil, fmt.Errorf("model server returned %d predictions for %d instances", len(predictions), len(instances))
+	}
+	return predictions, nil
+}
+
+// v2Request returns the request of the v2 protocol, with a tensor of the features, or of the binary values
+func (client *InferenceClient) v2Request(instances []interface{}) interface{} {
+	input := map[string]interface{}{"name": client.config.InputName}
+	if client.config.Binary {
+		input["datatype"] = "BYTES"
+		input["shape"] = []int{len(instances)}
+		input["data"] = instances
+	} else {
+		var data []float64
+		for _, instance := range instances {
+			data = append(data, instance.([]float64)...)
+		}
+		input["datatype"] = "FP64"
+		input["shape"] = []int{len(instances), len(data) / len(instances)}
+		input["data"] = data
+	}
+	return map[string]interface{}{"inputs": []interface{}{input}}
+}
+
+// v2Predictions splits the data of the first output of a v2 response in a prediction per instance, a single value or
+// the values of the instance
+func v2Predictions(contents []byte, count int) ([]interface{}, error) {
+	var response struct {
+		Outputs []struct {
+			Data []interface{} `json:"data"`
+		} `json:"outputs"`
+	}
+	if err := json.Unmarshal(contents, &response); err != nil {
+		return nil, err
+	}
+	if len(response.Outputs) == 0 {
+		return nil, errors.New("no outputs")
+	}
+	data := response.Outputs[0].Data
+	if len(data) == 0 || len(data)%count != 0 {
+		return nil, fmt.Errorf("%d output values for %d instances", len(data), count)
+	}
+	size := len(data) / count
+	predictions := make([]interface{}, count)
+	for i := range predictions {
+		if size == 1 {
+			predictions[i] = data[i]
+		} else {
+			predictions[i] = data[i*size : (i+1)*size]
+		}
+	}
+	return predictions, nil
+}
+
+// formatPrediction formats a prediction as the value of a reading
+func formatPrediction(prediction interface{}) (string, error) {
+	switch value := prediction.(type) {
+	case string:
+		return value, nil
+	case float64:
+		return strconv.FormatFloat(value, 'f', -1, 64), nil
+	}
+	encoded, err := json.Marshal(prediction)
+	if err != nil {
+		return "", fmt.Errorf("unable to encode inference result: %v", err)
+	}
+	return string(encoded), nil
+}
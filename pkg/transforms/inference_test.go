@@ -0,0 +1,203 @@
+//
+// Copyright (c) 2019 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// inferenceServer returns a model server recording the requests received and answering with the response
+func inferenceServer(t *testing.T, response func(request map[string]interface{}) interface{}) (*httptest.Server, *[]map[string]interface{}) {
+	var mutex sync.Mutex
+	requests := []map[string]interface{}{}
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		body, err := ioutil.ReadAll(request.Body)
+		require.NoError(t, err)
+		decoded := map[string]interface{}{}
+		require.NoError(t, json.Unmarshal(body, &decoded))
+
+		mutex.Lock()
+		requests = append(requests, decoded)
+		mutex.Unlock()
+
+		json.NewEncoder(writer).Encode(response(decoded))
+	}))
+	return server, &requests
+}
+
+func TestInferTFServing(t *testing.T) {
+	server, requests := inferenceServer(t, func(request map[string]interface{}) interface{} {
+		return map[string]interface{}{"predictions": []interface{}{0.97}}
+	})
+	defer server.Close()
+
+	client, err := NewInferenceClient("anomaly", InferenceConfig{URL: server.URL, Readings: []string{"Temperature", "Humidity"}, ResultName: "anomaly"})
+	require.NoError(t, err)
+
+	continuePipeline, result := client.Infer(context, models.Event{
+		Device: devID1,
+		Readings: []models.Reading{
+			{Name: "Humidity", Value: "40"},
+			{Name: "Temperature", Value: "21.5"},
+			{Name: "Status", Value: "ok"},
+		},
+	})
+	require.True(t, continuePipeline, "%v", result)
+	readings := result.(models.Event).Readings
+	require.Len(t, readings, 4)
+	assert.Equal(t, "anomaly", readings[3].Name)
+	assert.Equal(t, "0.97", readings[3].Value)
+	assert.Equal(t, devID1, readings[3].Device)
+
+	require.Len(t, *requests, 1)
+	assert.Equal(t, map[string]interface{}{"instances": []interface{}{[]interface{}{21.5, 40.0}}}, (*requests)[0])
+
+	metrics := client.Metrics()
+	assert.Equal(t, "anomaly", metrics.Name)
+	assert.Equal(t, uint64(1), metrics.Requests)
+	assert.Equal(t, uint64(1), metrics.Instances)
+	assert.Equal(t, uint64(0), metrics.Failed)
+	assert.True(t, metrics.LatencyNanos > 0)
+	assert.Equal(t, metrics.LatencyNanos, metrics.AverageLatencyNanos)
+}
+
+func TestInferTFServingBinary(t *testing.T) {
+	server, requests := inferenceServer(t, func(request map[string]interface{}) interface{} {
+		return map[string]interface{}{"predictions": []interface{}{map[string]interface{}{"label": "person", "score": 0.9}}}
+	})
+	defer server.Close()
+
+	client, err := NewInferenceClient("camera", InferenceConfig{URL: server.URL, Binary: true})
+	require.NoError(t, err)
+
+	continuePipeline, result := client.Infer(context, models.Event{
+		Device:   devID1,
+		Readings: []models.Reading{{Name: "Image", BinaryValue: []byte("image")}},
+	})
+	require.True(t, continuePipeline, "%v", result)
+	readings := result.(models.Event).Readings
+	assert.Equal(t, "inference", readings[1].Name)
+	assert.JSONEq(t, `{"label": "person", "score": 0.9}`, readings[1].Value)
+	assert.Equal(t, map[string]interface{}{"instances": []interface{}{map[string]interface{}{"b64": "aW1hZ2U="}}}, (*requests)[0])
+
+	continuePipeline, result = client.Infer(context, models.Event{Readings: []models.Reading{{Name: "Temperature", Value: "21"}}})
+	assert.False(t, continuePipeline)
+	assert.EqualError(t, result.(error), "no reading to infer has a binary value")
+}
+
+func TestInferV2Batch(t *testing.T) {
+	server, requests := inferenceServer(t, func(request map[string]interface{}) interface{} {
+		input := request["inputs"].([]interface{})[0].(map[string]interface{})
+		data := input["data"].([]interface{})
+		// predicts the sum of the features of each instance
+		features := int(input["shape"].([]interface{})[1].(float64))
+		sums := []interface{}{}
+		for i := 0; i < len(data); i += features {
+			sum := 0.0
+			for _, value := range data[i : i+features] {
+				sum += value.(float64)
+			}
+			sums = append(sums, sum)
+		}
+		return map[string]interface{}{"outputs": []interface{}{map[string]interface{}{"name": "output", "data": sums}}}
+	})
+	defer server.Close()
+
+	client, err := NewInferenceClient("sum", InferenceConfig{URL: server.URL, Protocol: InferenceV2, InputName: "features", BatchSize: 3, BatchTimeout: time.Minute})
+	require.NoError(t, err)
+
+	var wait sync.WaitGroup
+	results := make([]string, 3)
+	for i := 0; i < 3; i++ {
+		wait.Add(1)
+		go func(i int) {
+			defer wait.Done()
+			continuePipeline, result := client.Infer(context, models.Event{
+				Readings: []models.Reading{{Name: "a", Value: "1"}, {Name: "b", Value: []string{"1", "2", "3"}[i]}},
+			})
+			if assert.True(t, continuePipeline, "%v", result) {
+				results[i] = result.(models.Event).Readings[2].Value
+			}
+		}(i)
+	}
+	wait.Wait()
+
+	assert.Equal(t, []string{"2", "3", "4"}, results)
+	require.Len(t, *requests, 1, "Concurrent events should be sent in a single request")
+	input := (*requests)[0]["inputs"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, "features", input["name"])
+	assert.Equal(t, "FP64", input["datatype"])
+	assert.Equal(t, []interface{}{3.0, 2.0}, input["shape"])
+	assert.Equal(t, uint64(3), client.Metrics().Instances)
+}
+
+func TestInferBatchTimeout(t *testing.T) {
+	server, requests := inferenceServer(t, func(request map[string]interface{}) interface{} {
+		return map[string]interface{}{"predictions": []interface{}{1}}
+	})
+	defer server.Close()
+
+	client, err := NewInferenceClient("timeout", InferenceConfig{URL: server.URL, BatchSize: 10, BatchTimeout: 10 * time.Millisecond})
+	require.NoError(t, err)
+
+	continuePipeline, result := client.Infer(context, models.Event{Readings: []models.Reading{{Name: "a", Value: "1"}}})
+	require.True(t, continuePipeline, "%v", result)
+	assert.Equal(t, "1", result.(models.Event).Readings[1].Value)
+	assert.Len(t, *requests, 1)
+}
+
+func TestInferErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		http.Error(writer, `{"error": "model not found"}`, http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	client, err := NewInferenceClient("missing", InferenceConfig{URL: server.URL, Readings: []string{"Temperature"}})
+	require.NoError(t, err)
+
+	continuePipeline, result := client.Infer(context, models.Event{Readings: []models.Reading{{Name: "Temperature", Value: "21"}}})
+	assert.False(t, continuePipeline)
+	assert.EqualError(t, result.(error), `inference failed: model server returned 404 Not Found: {"error": "model not found"}`)
+	assert.Equal(t, uint64(1), client.Metrics().Failed)
+
+	continuePipeline, result = client.Infer(context, models.Event{Readings: []models.Reading{{Name: "Humidity", Value: "40"}}})
+	assert.False(t, continuePipeline)
+	assert.EqualError(t, result.(error), "reading 'Temperature' to infer is missing")
+
+	continuePipeline, result = client.Infer(context, models.Event{Readings: []models.Reading{{Name: "Temperature", Value: "hot"}}})
+	assert.False(t, continuePipeline)
+	assert.EqualError(t, result.(error), "reading 'Temperature' to infer doesn't have a numeric value, got 'hot'")
+
+	continuePipeline, result = client.Infer(context, "event")
+	assert.False(t, continuePipeline)
+	assert.EqualError(t, result.(error), "Unexpected type received, expecting models.Event")
+
+	_, err = NewInferenceClient("invalid", InferenceConfig{})
+	assert.EqualError(t, err, "model server URL must be specified")
+	_, err = NewInferenceClient("invalid", InferenceConfig{URL: server.URL, Protocol: "grpc"})
+	assert.EqualError(t, err, "inference protocol must be tfserving or v2, got 'grpc'")
+}
@@ -0,0 +1,306 @@
+//
+// Copyright (c) 2019 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/antoniomtz/app-functions-sdk-go/appcontext"
+	"github.com/edgexfoundry/go-mod-core-contracts/clients"
+	"github.com/edgexfoundry/go-mod-core-contracts/models"
+	influxmodels "github.com/influxdata/influxdb1-client/models"
+	influxdb "github.com/influxdata/influxdb1-client/v2"
+)
+
+const influxDBPrecision = "ms"
+
+// influxDBRejected are the errors returned by InfluxDB for writes whose points it rejects, which fail again when retried
+var influxDBRejected = []string{"partial write", "unable to parse", "field type conflict", "authorization failed", "unauthorized", "database not found"}
+
+// influxDBUnauthorized are the errors returned by InfluxDB 1.x and 2.x for writes whose credentials it rejects
+var influxDBUnauthorized = []string{"authorization failed", "unauthorized"}
+
+// InfluxDBConfig contains the parameters for writing to InfluxDB. Setting Bucket writes to InfluxDB 2.x through its
+// 1.x compatibility API, otherwise Database and RetentionPolicy are written to with InfluxDB 1.x.
+type InfluxDBConfig struct {
+	// URL is the base URL of the InfluxDB server, i.e. http://influxdb:8086
+	URL string
+	// Database and RetentionPolicy are used with InfluxDB 1.x
+	Database        string
+	RetentionPolicy string
+	// Bucket is used with InfluxDB 2.x, which must map the bucket to a database and retention policy
+	Bucket string
+	// Credentials provides the username and password used with InfluxDB 1.x, or the token used as the password with
+	// InfluxDB 2.x. They are read when the sender is created and again once InfluxDB rejects them. Writes are not
+	// authenticated without a provider.
+	Credentials CredentialsProvider
+	// BatchSize is the number of events written in each request. Values less than 2 disable batching.
+	BatchSize int
+	// BatchTimeout is the maximum time an event waits in an incomplete batch before the batch is written.
+	BatchTimeout time.Duration
+	// MaxRetries is the number of times a failed write is retried. Writes whose points are rejected are not retried.
+	MaxRetries int
+	// RetryInterval is the wait before the first retry, doubled for each subsequent retry. Defaults to one second.
+	RetryInterval time.Duration
+}
+
+// InfluxDBSender writes readings to InfluxDB with the InfluxDB client
+type InfluxDBSender struct {
+	config InfluxDBConfig
+	batch  exportBatch
+
+	mutex sync.Mutex
+	// client is nil once InfluxDB rejected its credentials, so the next write reads them again
+	client influxdb.Client
+}
+
+// NewInfluxDBSender creates a sender for the specified InfluxDB configuration
+func NewInfluxDBSender(config InfluxDBConfig) (*InfluxDBSender, error) {
+	if config.URL == "" {
+		return nil, errors.New("InfluxDB URL must be specified")
+	}
+	if config.Bucket == "" && config.Database == "" {
+		return nil, errors.New("InfluxDB bucket or database must be specified")
+	}
+	if config.RetryInterval <= 0 {
+		config.RetryInterval = time.Second
+	}
+
+	if config.Bucket != "" {
+		config.Database = config.Bucket
+	}
+
+	sender := &InfluxDBSender{config: config}
+	if _, err := sender.currentClient(); err != nil {
+		return nil, err
+	}
+	sender.batch = exportBatch{name: "InfluxDB", size: config.BatchSize, timeout: config.BatchTimeout, export: sender.writeBatch}
+	return sender, nil
+}
+
+// currentClient returns the client, creating it with credentials read from the provider when there is none
+func (sender *InfluxDBSender) currentClient() (influxdb.Client, error) {
+	sender.mutex.Lock()
+	defer sender.mutex.Unlock()
+	if sender.client != nil {
+		return sender.client, nil
+	}
+
+	httpConfig := influxdb.HTTPConfig{Addr: sender.config.URL, Timeout: 30 * time.Second}
+	if sender.config.Credentials != nil {
+		username, password, err := sender.config.Credentials.Credentials()
+		if err != nil {
+			return nil, fmt.Errorf("unable to get InfluxDB credentials: %v", err)
+		}
+		httpConfig.Username, httpConfig.Password = username, password
+		if sender.config.Bucket != "" && httpConfig.Username == "" {
+			// InfluxDB 2.x accepts the token as the password of the 1.x compatibility API, along with any username
+			httpConfig.Username = "edgex"
+		}
+	}
+	client, err := influxdb.NewHTTPClient(httpConfig)
+	if err != nil {
+		return nil, err
+	}
+	sender.client = client
+	return client, nil
+}
+
+// rejectCredentials drops the client whose credentials InfluxDB rejected, so the next write reads them again
+func (sender *InfluxDBSender) rejectCredentials(client influxdb.Client) {
+	sender.mutex.Lock()
+	defer sender.mutex.Unlock()
+	if sender.client == client {
+		sender.client = nil
+		client.Close()
+	}
+}
+
+// SetBatchFailureHandler sets the handler given the data of the events held in a batch which failed to be written
+// after the calls holding them returned, i.e. to store it for store and forward. Without a handler the data is kept
+// and written with the next batch.
+func (sender *InfluxDBSender) SetBatchFailureHandler(handler BatchFailureHandler) {
+	sender.batch.failed = handler
+}
+
+// InfluxDBSend writes the data from the previous function to InfluxDB. An event is converted to a point per reading,
+// using the reading name as measurement, the device as tag and the reading value as field. Data of type string or
+// []byte must already be line protocol, timestamped in milliseconds. When batching is enabled the pipeline stops for
+// the events held in an incomplete batch.
+func (sender *InfluxDBSender) InfluxDBSend(edgexcontext *appcontext.Context, params ...interface{}) (bool, interface{}) {
+	if len(params) < 1 {
+		// We didn't receive a result
+		return false, errors.New("No Data Received")
+	}
+
+	var points []*influxdb.Point
+	var err error
+	switch data := params[0].(type) {
+	case models.Event:
+		points, err = toPoints(data)
+	case *models.Event:
+		points, err = toPoints(*data)
+	case string:
+		points, err = parsePoints([]byte(data))
+	case []byte:
+		points, err = parsePoints(data)
+	default:
+		return false, errors.New("Unexpected type received - passed in data must be of type models.Event, []byte or string")
+	}
+	if err != nil {
+		return false, err
+	}
+	if len(points) == 0 {
+		return false, errors.New("No readings to write to InfluxDB")
+	}
+
+	exported, err := sender.batch.add(edgexcontext, params[0], points)
+	if err != nil {
+		return false, err
+	}
+	if !exported {
+		return false, nil
+	}
+
+	edgexcontext.LoggingClient.Info("Sent data to InfluxDB")
+	edgexcontext.LoggingClient.Trace("Data exported", "Transport", "InfluxDB", clients.CorrelationHeader, edgexcontext.CorrelationID)
+
+	return true, nil
+}
+
+// PendingEvents returns the number of events held in an incomplete batch, which have not been exported yet
+func (sender *InfluxDBSender) PendingEvents() int {
+	return sender.batch.pending()
+}
+
+// Flush writes the points held in an incomplete batch to InfluxDB, i.e. before the service stops
+func (sender *InfluxDBSender) Flush() error {
+	return sender.batch.flush()
+}
+
+// writeBatch writes the points of the entries in a single request, retrying failures which may be transient
+func (sender *InfluxDBSender) writeBatch(entries []batchEntry) (int, error) {
+	batchPoints, err := influxdb.NewBatchPoints(influxdb.BatchPointsConfig{
+		Precision:       influxDBPrecision,
+		Database:        sender.config.Database,
+		RetentionPolicy: sender.config.RetentionPolicy,
+	})
+	if err != nil {
+		return 0, err
+	}
+	for _, entry := range entries {
+		batchPoints.AddPoints(entry.payload.([]*influxdb.Point))
+	}
+
+	interval := sender.config.RetryInterval
+	for attempt := 0; ; attempt++ {
+		client, err := sender.currentClient()
+		if err != nil {
+			return 0, err
+		}
+		err = client.Write(batchPoints)
+		if err == nil {
+			return len(entries), nil
+		}
+		if sender.config.Credentials != nil && influxDBError(err, influxDBUnauthorized) {
+			sender.rejectCredentials(client)
+		}
+		if !retryInfluxDBWrite(err) || attempt >= sender.config.MaxRetries {
+			return 0, fmt.Errorf("InfluxDB write failed: %v", err)
+		}
+		time.Sleep(interval)
+		interval *= 2
+	}
+}
+
+// retryInfluxDBWrite returns whether the failed write may succeed when retried, i.e. it failed to connect or the
+// server failed, rather than rejecting the points
+func retryInfluxDBWrite(err error) bool {
+	if _, ok := err.(*url.Error); ok {
+		return true
+	}
+	return !influxDBError(err, influxDBRejected)
+}
+
+// influxDBError returns whether the error returned by InfluxDB contains one of the messages
+func influxDBError(err error, messages []string) bool {
+	for _, message := range messages {
+		if strings.Contains(err.Error(), message) {
+			return true
+		}
+	}
+	return false
+}
+
+// parsePoints parses the line protocol, whose timestamps are in milliseconds
+func parsePoints(lines []byte) ([]*influxdb.Point, error) {
+	parsed, err := influxmodels.ParsePointsWithPrecision(lines, time.Now().UTC(), influxDBPrecision)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse InfluxDB line protocol: %v", err)
+	}
+
+	points := make([]*influxdb.Point, len(parsed))
+	for i, point := range parsed {
+		points[i] = influxdb.NewPointFrom(point)
+	}
+	return points, nil
+}
+
+// toPoints converts the readings of the event to points. Numeric and boolean values are written as such, all other
+// values as strings. Points are timestamped with the reading or event creation time.
+func toPoints(event models.Event) ([]*influxdb.Point, error) {
+	points := make([]*influxdb.Point, 0, len(event.Readings))
+	for _, reading := range event.Readings {
+		device := reading.Device
+		if device == "" {
+			device = event.Device
+		}
+		timestamp := reading.Created
+		if timestamp == 0 {
+			timestamp = event.Created
+		}
+
+		var created []time.Time
+		if timestamp > 0 {
+			created = append(created, time.Unix(0, timestamp*int64(time.Millisecond)).UTC())
+		}
+		point, err := influxdb.NewPoint(reading.Name, map[string]string{"device": device},
+			map[string]interface{}{"value": pointValue(reading.Value)}, created...)
+		if err != nil {
+			return nil, fmt.Errorf("unable to convert reading %s to an InfluxDB point: %v", reading.Name, err)
+		}
+		points = append(points, point)
+	}
+	return points, nil
+}
+
+func pointValue(value string) interface{} {
+	if floatValue, err := strconv.ParseFloat(value, 64); err == nil && !math.IsNaN(floatValue) && !math.IsInf(floatValue, 0) {
+		return floatValue
+	}
+	if boolValue, err := strconv.ParseBool(value); err == nil {
+		return boolValue
+	}
+	return value
+}
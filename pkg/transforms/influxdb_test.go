@@ -0,0 +1,210 @@
+//
+// Copyright (c) 2019 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/antoniomtz/app-functions-sdk-go/appcontext"
+	"github.com/edgexfoundry/go-mod-core-contracts/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestToPoints(t *testing.T) {
+	event := models.Event{
+		Device:  "Device 1",
+		Created: 1559390400000,
+		Readings: []models.Reading{
+			{Name: readingName1, Value: readingValue1},
+			{Name: "switch", Value: "true", Created: 1559390400001},
+			{Name: "label", Value: `say "hi"`},
+		},
+	}
+
+	points, err := toPoints(event)
+	require.NoError(t, err)
+	require.Equal(t, 3, len(points))
+	assert.Equal(t, `sensor1,device=Device\ 1 value=123.45 1559390400000`, points[0].PrecisionString(influxDBPrecision))
+	assert.Equal(t, `switch,device=Device\ 1 value=true 1559390400001`, points[1].PrecisionString(influxDBPrecision))
+	assert.Equal(t, `label,device=Device\ 1 value="say \"hi\"" 1559390400000`, points[2].PrecisionString(influxDBPrecision))
+}
+
+func TestInfluxDBSendV2(t *testing.T) {
+	var body string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/write", r.URL.Path)
+		assert.Equal(t, "edgex", r.URL.Query().Get("db"))
+		_, password, _ := r.BasicAuth()
+		assert.Equal(t, "secret", password, "The token should be the password of the compatibility API")
+		data, _ := ioutil.ReadAll(r.Body)
+		body = string(data)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	credentials := CredentialsProviderFunc(func() (string, string, error) {
+		return "", "secret", nil
+	})
+	sender, err := NewInfluxDBSender(InfluxDBConfig{URL: server.URL, Bucket: "edgex", Credentials: credentials})
+	require.NoError(t, err)
+
+	event := models.Event{Device: devID1, Readings: []models.Reading{{Name: readingName1, Value: readingValue1}}}
+	continuePipeline, result := sender.InfluxDBSend(context, event)
+	assert.True(t, continuePipeline, "Pipeline should continue")
+	assert.Nil(t, result)
+	assert.Equal(t, "sensor1,device=id1 value=123.45\n", body)
+}
+
+func TestInfluxDBSendBatchedV1(t *testing.T) {
+	requests := 0
+	var body string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/write", r.URL.Path)
+		assert.Equal(t, "edgex", r.URL.Query().Get("db"))
+		assert.Equal(t, "one_week", r.URL.Query().Get("rp"))
+		assert.Equal(t, "ms", r.URL.Query().Get("precision"))
+		requests++
+		data, _ := ioutil.ReadAll(r.Body)
+		body = string(data)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	sender, _ := NewInfluxDBSender(InfluxDBConfig{URL: server.URL, Database: "edgex", RetentionPolicy: "one_week", BatchSize: 2})
+
+	continuePipeline, _ := sender.InfluxDBSend(context, "cpu value=1 1559390400000")
+	assert.False(t, continuePipeline, "Pipeline should stop while batching")
+	assert.Equal(t, 0, requests)
+
+	continuePipeline, _ = sender.InfluxDBSend(context, []byte("cpu value=2 1559390400001\n"))
+	assert.True(t, continuePipeline, "Pipeline should continue once batch is written")
+	assert.Equal(t, 1, requests)
+	assert.Equal(t, "cpu value=1 1559390400000\ncpu value=2 1559390400001\n", body)
+}
+
+func TestInfluxDBSendInvalidLineProtocol(t *testing.T) {
+	sender, _ := NewInfluxDBSender(InfluxDBConfig{URL: "http://influxdb:8086", Database: "edgex"})
+	continuePipeline, result := sender.InfluxDBSend(context, "invalid")
+	assert.False(t, continuePipeline)
+	assert.Error(t, result.(error))
+}
+
+func TestInfluxDBSendRetry(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			w.Write([]byte(`{"error":"timeout"}`))
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	sender, _ := NewInfluxDBSender(InfluxDBConfig{URL: server.URL, Database: "edgex", MaxRetries: 2, RetryInterval: time.Millisecond})
+	continuePipeline, result := sender.InfluxDBSend(context, "cpu value=1")
+	assert.True(t, continuePipeline, "Write should succeed after retrying")
+	assert.Nil(t, result)
+	assert.Equal(t, 3, requests)
+}
+
+func TestInfluxDBSendNoRetryOnBadRequest(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"error":"partial write: field type conflict"}`))
+	}))
+	defer server.Close()
+
+	sender, _ := NewInfluxDBSender(InfluxDBConfig{URL: server.URL, Database: "edgex", MaxRetries: 3, RetryInterval: time.Millisecond})
+	continuePipeline, result := sender.InfluxDBSend(context, "cpu value=\"text\"")
+	assert.False(t, continuePipeline)
+	assert.Error(t, result.(error))
+	assert.Equal(t, 1, requests, "Bad requests should not be retried")
+}
+
+func TestInfluxDBSendRefreshesRejectedCredentials(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if username, password, _ := r.BasicAuth(); username != "edgex" || password != "rotated" {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"error":"authorization failed"}`))
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	reads := 0
+	password := "expired"
+	credentials := CredentialsProviderFunc(func() (string, string, error) {
+		reads++
+		return "edgex", password, nil
+	})
+	sender, err := NewInfluxDBSender(InfluxDBConfig{URL: server.URL, Database: "edgex", Credentials: credentials})
+	require.NoError(t, err)
+
+	continuePipeline, _ := sender.InfluxDBSend(context, "cpu value=1")
+	assert.False(t, continuePipeline, "The rejected credentials should fail the write")
+	password = "rotated"
+	continuePipeline, _ = sender.InfluxDBSend(context, "cpu value=1")
+	assert.True(t, continuePipeline, "The credentials should be read again once rejected")
+	sender.InfluxDBSend(context, "cpu value=1")
+	assert.Equal(t, 2, reads, "The credentials should only be read again once rejected")
+}
+
+func TestNewInfluxDBSenderCredentialsError(t *testing.T) {
+	credentials := CredentialsProviderFunc(func() (string, string, error) {
+		return "", "", errors.New("no password secret")
+	})
+	_, err := NewInfluxDBSender(InfluxDBConfig{URL: "http://influxdb:8086", Database: "edgex", Credentials: credentials})
+	assert.Error(t, err)
+}
+
+func TestInfluxDBSendRetryReleasesBatch(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	sender, _ := NewInfluxDBSender(InfluxDBConfig{URL: server.URL, Database: "edgex"})
+	done := make(chan bool)
+	go func() {
+		continuePipeline, _ := sender.InfluxDBSend(&appcontext.Context{LoggingClient: context.LoggingClient}, "cpu value=1")
+		done <- continuePipeline
+	}()
+
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, 1, sender.PendingEvents(), "The batch should not be locked while it is written")
+	close(release)
+	assert.True(t, <-done)
+	assert.Equal(t, 0, sender.PendingEvents())
+}
+
+func TestNewInfluxDBSenderMissingDatabase(t *testing.T) {
+	_, err := NewInfluxDBSender(InfluxDBConfig{URL: "http://influxdb:8086"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,183 @@
+//
+// Copyright (c) 2019 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/antoniomtz/app-functions-sdk-go/appcontext"
+	"github.com/edgexfoundry/go-mod-core-contracts/models"
+	"github.com/robertkrimen/otto"
+)
+
+// javaScriptRunner calls the transform function of the script with the event as JSON, returning null to stop the
+// pipeline, or the kind of the result, an event or a string, along with the event as JSON or the string. The values
+// of the readings are converted to strings, so scripts can assign numbers.
+const javaScriptRunner = `(function(input) {
+	var result = transform(JSON.parse(input));
+	if (result === undefined || result === null) {
+		return null;
+	}
+	if (typeof result === "string") {
+		return ["string", result];
+	}
+	if (result.readings) {
+		result.readings.forEach(function(reading) {
+			if (reading.value !== undefined && reading.value !== null && typeof reading.value !== "string") {
+				reading.value = String(reading.value);
+			}
+		});
+	}
+	return ["event", JSON.stringify(result)];
+})`
+
+var errJavaScriptTimeout = errors.New("script timed out")
+
+// JavaScriptTransform executes a JavaScript function against the events of the pipeline, so payloads can be adjusted
+// by editing configuration rather than recompiling the service. Events are processed one at a time.
+type JavaScriptTransform struct {
+	timeout time.Duration
+	mutex   sync.Mutex
+	vm      *otto.Otto
+	run     otto.Value
+	// calls identifies the interrupts of the current call, as the timer of a previous call may fire once it completed
+	calls uint64
+}
+
+// NewJavaScriptTransform creates a JavaScriptTransform for a script which defines a function named transform.
+// The function is called with the event, i.e. {"device": "...", "readings": [{"name": "...", "value": "..."}]}, and
+// returns the event passed on, a string passed on as is, or null to stop the pipeline. Scripts can call log(message)
+// to log a message. The execution of the function is interrupted after timeout, unless it's zero, between two
+// statements, so an empty loop can't be interrupted.
+func NewJavaScriptTransform(script string, timeout time.Duration) (*JavaScriptTransform, error) {
+	if strings.TrimSpace(script) == "" {
+		return nil, errors.New("script must be specified")
+	}
+	if timeout < 0 {
+		return nil, errors.New("script timeout must not be negative")
+	}
+
+	vm := otto.New()
+	vm.Interrupt = make(chan func(), 1)
+	if _, err := vm.Run(script); err != nil {
+		return nil, fmt.Errorf("invalid script: %v", err)
+	}
+	if function, err := vm.Get("transform"); err != nil || !function.IsFunction() {
+		return nil, errors.New("script must define a function named transform")
+	}
+	run, err := vm.Eval(javaScriptRunner)
+	if err != nil {
+		return nil, err
+	}
+
+	return &JavaScriptTransform{timeout: timeout, vm: vm, run: run}, nil
+}
+
+// NewJavaScriptTransformFromFile creates a JavaScriptTransform for the script read from file
+func NewJavaScriptTransformFromFile(file string, timeout time.Duration) (*JavaScriptTransform, error) {
+	if file == "" {
+		return nil, errors.New("script file must be specified")
+	}
+	contents, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read script file: %v", err)
+	}
+	return NewJavaScriptTransform(string(contents), timeout)
+}
+
+// TransformWithJavaScript calls the transform function of the script with the Event from the previous function.
+// This function returns the Event or the string returned by the script, stops the pipeline when it returns null,
+// and returns an error when the script fails or times out.
+func (js *JavaScriptTransform) TransformWithJavaScript(edgexcontext *appcontext.Context, params ...interface{}) (bool, interface{}) {
+	if len(params) < 1 {
+		return false, errors.New("No Event Received")
+	}
+
+	event, ok := params[0].(models.Event)
+	if !ok {
+		return false, errors.New("Unexpected type received, expecting models.Event")
+	}
+
+	input, err := json.Marshal(event)
+	if err != nil {
+		return false, fmt.Errorf("unable to marshal event for script: %v", err)
+	}
+
+	result, err := js.call(edgexcontext, string(input))
+	if err != nil {
+		return false, fmt.Errorf("JavaScript transform failed: %v", err)
+	}
+	if result.IsNull() {
+		return false, nil
+	}
+
+	kind, _ := result.Object().Get("0")
+	data, _ := result.Object().Get("1")
+	if kind.String() == "string" {
+		return true, data.String()
+	}
+	transformed := models.Event{}
+	if err := json.Unmarshal([]byte(data.String()), &transformed); err != nil {
+		return false, fmt.Errorf("JavaScript transform returned an invalid event: %v", err)
+	}
+	return true, transformed
+}
+
+// call runs the transform function with the event, interrupting it after the timeout
+func (js *JavaScriptTransform) call(edgexcontext *appcontext.Context, input string) (result otto.Value, err error) {
+	js.mutex.Lock()
+	defer js.mutex.Unlock()
+
+	js.calls++
+	current := js.calls
+	select {
+	case <-js.vm.Interrupt:
+	default:
+	}
+
+	js.vm.Set("log", func(call otto.FunctionCall) otto.Value {
+		edgexcontext.LoggingClient.Info(call.Argument(0).String())
+		return otto.UndefinedValue()
+	})
+
+	if js.timeout > 0 {
+		timer := time.AfterFunc(js.timeout, func() {
+			js.vm.Interrupt <- func() {
+				if js.calls == current {
+					panic(errJavaScriptTimeout)
+				}
+			}
+		})
+		defer timer.Stop()
+		defer func() {
+			if caught := recover(); caught != nil {
+				if caught != errJavaScriptTimeout {
+					panic(caught)
+				}
+				err = errJavaScriptTimeout
+			}
+		}()
+	}
+
+	return js.run.Call(otto.NullValue(), input)
+}
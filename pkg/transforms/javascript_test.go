@@ -0,0 +1,141 @@
+//
+// Copyright (c) 2019 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransformWithJavaScript(t *testing.T) {
+	js, err := NewJavaScriptTransform(`
+		function transform(event) {
+			log("transforming " + event.device);
+			event.device = event.device.toUpperCase();
+			event.readings = event.readings.filter(function(reading) {
+				return reading.name !== "Humidity";
+			});
+			event.readings.forEach(function(reading) {
+				reading.value = parseFloat(reading.value) * 2;
+			});
+			return event;
+		}`, time.Second)
+	require.NoError(t, err)
+
+	continuePipeline, result := js.TransformWithJavaScript(context, models.Event{
+		Device: devID1,
+		Origin: 1000,
+		Readings: []models.Reading{
+			{Name: "Temperature", Value: "21.5"},
+			{Name: "Humidity", Value: "40"},
+		},
+	})
+	require.True(t, continuePipeline, "%v", result)
+	event := result.(models.Event)
+	assert.Equal(t, "ID1", event.Device)
+	assert.Equal(t, int64(1000), event.Origin)
+	require.Len(t, event.Readings, 1)
+	assert.Equal(t, "Temperature", event.Readings[0].Name)
+	assert.Equal(t, "43", event.Readings[0].Value)
+}
+
+func TestTransformWithJavaScriptResults(t *testing.T) {
+	js, err := NewJavaScriptTransform(`
+		function transform(event) {
+			if (event.device === "skip") {
+				return null;
+			}
+			if (event.device === "fail") {
+				throw new Error("unsupported device");
+			}
+			return event.device + ":" + event.readings.length;
+		}`, 0)
+	require.NoError(t, err)
+
+	continuePipeline, result := js.TransformWithJavaScript(context, models.Event{Device: devID1, Readings: []models.Reading{{Name: "Temperature"}}})
+	require.True(t, continuePipeline)
+	assert.Equal(t, "id1:1", result)
+
+	continuePipeline, result = js.TransformWithJavaScript(context, models.Event{Device: "skip"})
+	assert.False(t, continuePipeline)
+	assert.Nil(t, result)
+
+	continuePipeline, result = js.TransformWithJavaScript(context, models.Event{Device: "fail"})
+	assert.False(t, continuePipeline)
+	require.Error(t, result.(error))
+	assert.Contains(t, result.(error).Error(), "unsupported device")
+
+	continuePipeline, result = js.TransformWithJavaScript(context, "event")
+	assert.False(t, continuePipeline)
+	assert.EqualError(t, result.(error), "Unexpected type received, expecting models.Event")
+}
+
+func TestTransformWithJavaScriptTimeout(t *testing.T) {
+	js, err := NewJavaScriptTransform(`
+		function transform(event) {
+			var i = 0;
+			while (event.device === "loop") {
+				i++;
+			}
+			return event;
+		}`, 50*time.Millisecond)
+	require.NoError(t, err)
+
+	continuePipeline, result := js.TransformWithJavaScript(context, models.Event{Device: "loop"})
+	assert.False(t, continuePipeline)
+	assert.EqualError(t, result.(error), "JavaScript transform failed: script timed out")
+
+	continuePipeline, result = js.TransformWithJavaScript(context, models.Event{Device: devID1})
+	assert.True(t, continuePipeline, "The script should run again after a timeout")
+	assert.Equal(t, devID1, result.(models.Event).Device)
+}
+
+func TestNewJavaScriptTransformErrors(t *testing.T) {
+	_, err := NewJavaScriptTransform("", 0)
+	assert.EqualError(t, err, "script must be specified")
+	_, err = NewJavaScriptTransform("function transform(event) {", 0)
+	assert.Error(t, err)
+	_, err = NewJavaScriptTransform("var transform = 1;", 0)
+	assert.EqualError(t, err, "script must define a function named transform")
+	_, err = NewJavaScriptTransform("function transform(event) { return event; }", -time.Second)
+	assert.EqualError(t, err, "script timeout must not be negative")
+}
+
+func TestNewJavaScriptTransformFromFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "javascript")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "transform.js")
+	require.NoError(t, ioutil.WriteFile(file, []byte("function transform(event) { return event.device; }"), 0644))
+
+	js, err := NewJavaScriptTransformFromFile(file, 0)
+	require.NoError(t, err)
+	continuePipeline, result := js.TransformWithJavaScript(context, models.Event{Device: devID1})
+	require.True(t, continuePipeline)
+	assert.Equal(t, devID1, result)
+
+	_, err = NewJavaScriptTransformFromFile(filepath.Join(dir, "missing.js"), 0)
+	assert.Error(t, err)
+}
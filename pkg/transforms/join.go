@@ -0,0 +1,208 @@
+//
+// Copyright (c) 2019 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/antoniomtz/app-functions-sdk-go/appcontext"
+	"github.com/edgexfoundry/go-mod-core-contracts/models"
+)
+
+// JoinSource identifies the readings of a device which are joined
+type JoinSource struct {
+	Device string
+	// Reading is the name of the reading joined. Any reading of the device matches when it's empty.
+	Reading string
+}
+
+// JoinConfig defines the sources whose readings are combined into a single event
+type JoinConfig struct {
+	// Sources are the devices and readings which are joined, at least two
+	Sources []JoinSource
+	// Window is the time a reading waits for the readings of the other sources
+	Window time.Duration
+	// Device is the device name of the combined events. Defaults to the device names of the sources joined by '+'.
+	Device string
+	// Partial emits the readings received when the window expires before a reading of every source is received,
+	// otherwise they are dropped
+	Partial bool
+}
+
+// Joiner correlates readings from several devices received within a time window into a single event
+type Joiner struct {
+	config   JoinConfig
+	mutex    sync.Mutex
+	readings []*models.Reading
+	start    time.Time
+	now      func() time.Time
+}
+
+// ParseJoinSource parses a source in the form <device>/<reading>, or <device> to match any reading of the device.
+// The reading name follows the last '/'.
+func ParseJoinSource(value string) (JoinSource, error) {
+	value = strings.TrimSpace(value)
+	source := JoinSource{Device: value}
+	if index := strings.LastIndex(value, "/"); index >= 0 {
+		source = JoinSource{Device: strings.TrimSpace(value[:index]), Reading: strings.TrimSpace(value[index+1:])}
+		if source.Reading == "" {
+			return JoinSource{}, fmt.Errorf("invalid join source '%s', expected <device>/<reading>", value)
+		}
+	}
+	if source.Device == "" {
+		return JoinSource{}, fmt.Errorf("invalid join source '%s', a device name is required", value)
+	}
+	return source, nil
+}
+
+// Validate checks the sources and window of the config
+func (config JoinConfig) Validate() error {
+	if len(config.Sources) < 2 {
+		return errors.New("at least two sources must be joined")
+	}
+	seen := map[JoinSource]bool{}
+	for _, source := range config.Sources {
+		if source.Device == "" {
+			return errors.New("the device of a join source must be specified")
+		}
+		if seen[source] {
+			return fmt.Errorf("duplicate join source '%s'", source)
+		}
+		seen[source] = true
+	}
+	if config.Window <= 0 {
+		return errors.New("join window must be positive")
+	}
+	return nil
+}
+
+func (source JoinSource) String() string {
+	if source.Reading == "" {
+		return source.Device
+	}
+	return source.Device + "/" + source.Reading
+}
+
+func (source JoinSource) matches(device string, reading models.Reading) bool {
+	return source.Device == device && (source.Reading == "" || source.Reading == reading.Name)
+}
+
+// NewJoiner creates a Joiner for the config
+func NewJoiner(config JoinConfig) (*Joiner, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	if config.Device == "" {
+		var devices []string
+		seen := map[string]bool{}
+		for _, source := range config.Sources {
+			if !seen[source.Device] {
+				seen[source.Device] = true
+				devices = append(devices, source.Device)
+			}
+		}
+		config.Device = strings.Join(devices, "+")
+	}
+
+	return &Joiner{
+		config:   config,
+		readings: make([]*models.Reading, len(config.Sources)),
+		now:      time.Now,
+	}, nil
+}
+
+// Join collects the readings of the sources from the events of the previous function. Once a reading of every source
+// is received within the window, which starts with the first reading collected, an event holding them in the order of
+// the sources is returned, with the device name of the config. The readings keep the name of their device. A newer
+// reading of a source replaces the one collected. When the window expires before every source is received, the
+// readings collected are returned by the next event if Partial is set, and dropped otherwise. A complete event takes
+// precedence over an expired partial one. Otherwise the pipeline execution stops.
+func (joiner *Joiner) Join(edgexcontext *appcontext.Context, params ...interface{}) (bool, interface{}) {
+	if len(params) < 1 {
+		return false, errors.New("No Event Received")
+	}
+
+	event, ok := params[0].(models.Event)
+	if !ok {
+		return false, errors.New("Unexpected type received, expecting models.Event")
+	}
+
+	now := joiner.now()
+
+	joiner.mutex.Lock()
+	defer joiner.mutex.Unlock()
+
+	var partial []models.Reading
+	if !joiner.start.IsZero() && now.Sub(joiner.start) > joiner.config.Window {
+		partial = joiner.collected()
+		joiner.reset()
+	}
+
+	for _, reading := range event.Readings {
+		if reading.Device == "" {
+			reading.Device = event.Device
+		}
+		for index, source := range joiner.config.Sources {
+			if !source.matches(event.Device, reading) {
+				continue
+			}
+			if joiner.start.IsZero() {
+				joiner.start = now
+			}
+			collected := reading
+			joiner.readings[index] = &collected
+		}
+	}
+
+	readings := joiner.collected()
+	if len(readings) == len(joiner.config.Sources) {
+		joiner.reset()
+		return true, joiner.event(readings, now)
+	}
+	if joiner.config.Partial && len(partial) > 0 {
+		return true, joiner.event(partial, now)
+	}
+	return false, nil
+}
+
+// collected returns the readings collected in the order of the sources
+func (joiner *Joiner) collected() []models.Reading {
+	var readings []models.Reading
+	for _, reading := range joiner.readings {
+		if reading != nil {
+			readings = append(readings, *reading)
+		}
+	}
+	return readings
+}
+
+func (joiner *Joiner) reset() {
+	joiner.readings = make([]*models.Reading, len(joiner.config.Sources))
+	joiner.start = time.Time{}
+}
+
+func (joiner *Joiner) event(readings []models.Reading, now time.Time) models.Event {
+	return models.Event{
+		Device:   joiner.config.Device,
+		Origin:   now.UnixNano() / int64(time.Millisecond),
+		Readings: readings,
+	}
+}
@@ -0,0 +1,177 @@
+//
+// Copyright (c) 2019 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	"testing"
+	"time"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestJoiner(t *testing.T, config JoinConfig) (*Joiner, *time.Time) {
+	joiner, err := NewJoiner(config)
+	require.NoError(t, err)
+	now := time.Date(2019, 6, 1, 12, 0, 0, 0, time.UTC)
+	joiner.now = func() time.Time { return now }
+	return joiner, &now
+}
+
+func joinEvent(device string, name string, value string) models.Event {
+	return models.Event{Device: device, Readings: []models.Reading{{Name: name, Value: value}}}
+}
+
+func TestJoin(t *testing.T) {
+	joiner, now := newTestJoiner(t, JoinConfig{
+		Sources: []JoinSource{{Device: devID1, Reading: "Temperature"}, {Device: devID2, Reading: "Humidity"}},
+		Window:  10 * time.Second,
+	})
+
+	continuePipeline, result := joiner.Join(context, joinEvent(devID2, "Humidity", "40"))
+	assert.False(t, continuePipeline)
+	assert.Nil(t, result)
+
+	*now = now.Add(2 * time.Second)
+	continuePipeline, result = joiner.Join(context, joinEvent(devID1, "Pressure", "1013"))
+	assert.False(t, continuePipeline, "Readings of no source should not be joined")
+	assert.Nil(t, result)
+
+	continuePipeline, result = joiner.Join(context, joinEvent(devID1, "Temperature", "21"))
+	require.True(t, continuePipeline)
+	assert.Equal(t, models.Event{
+		Device: devID1 + "+" + devID2,
+		Origin: now.UnixNano() / int64(time.Millisecond),
+		Readings: []models.Reading{
+			{Device: devID1, Name: "Temperature", Value: "21"},
+			{Device: devID2, Name: "Humidity", Value: "40"},
+		},
+	}, result)
+
+	continuePipeline, result = joiner.Join(context, joinEvent(devID1, "Temperature", "22"))
+	assert.False(t, continuePipeline, "Readings should only be joined once")
+	assert.Nil(t, result)
+}
+
+func TestJoinNewerReadingReplaces(t *testing.T) {
+	joiner, _ := newTestJoiner(t, JoinConfig{
+		Sources: []JoinSource{{Device: devID1}, {Device: devID2}},
+		Window:  time.Minute,
+		Device:  "room",
+	})
+
+	joiner.Join(context, joinEvent(devID1, "Temperature", "21"))
+	joiner.Join(context, joinEvent(devID1, "Temperature", "23"))
+	continuePipeline, result := joiner.Join(context, joinEvent(devID2, "Humidity", "40"))
+	require.True(t, continuePipeline)
+	event := result.(models.Event)
+	assert.Equal(t, "room", event.Device)
+	require.Len(t, event.Readings, 2)
+	assert.Equal(t, "23", event.Readings[0].Value)
+}
+
+func TestJoinTimeout(t *testing.T) {
+	tests := []struct {
+		Name    string
+		Partial bool
+	}{
+		{"Dropped", false},
+		{"Partial", true},
+	}
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			joiner, now := newTestJoiner(t, JoinConfig{
+				Sources: []JoinSource{{Device: devID1, Reading: "Temperature"}, {Device: devID2, Reading: "Humidity"}},
+				Window:  10 * time.Second,
+				Partial: test.Partial,
+			})
+
+			joiner.Join(context, joinEvent(devID1, "Temperature", "21"))
+			*now = now.Add(11 * time.Second)
+			continuePipeline, result := joiner.Join(context, joinEvent(devID2, "Humidity", "40"))
+			if test.Partial {
+				require.True(t, continuePipeline)
+				assert.Equal(t, []models.Reading{{Device: devID1, Name: "Temperature", Value: "21"}}, result.(models.Event).Readings)
+			} else {
+				assert.False(t, continuePipeline)
+				assert.Nil(t, result)
+			}
+
+			// the humidity reading starts a new window
+			*now = now.Add(5 * time.Second)
+			continuePipeline, result = joiner.Join(context, joinEvent(devID1, "Temperature", "22"))
+			require.True(t, continuePipeline)
+			assert.Len(t, result.(models.Event).Readings, 2)
+		})
+	}
+}
+
+func TestJoinNoEvent(t *testing.T) {
+	joiner, _ := newTestJoiner(t, JoinConfig{Sources: []JoinSource{{Device: devID1}, {Device: devID2}}, Window: time.Second})
+
+	continuePipeline, result := joiner.Join(context)
+	assert.False(t, continuePipeline)
+	assert.EqualError(t, result.(error), "No Event Received")
+
+	continuePipeline, result = joiner.Join(context, "event")
+	assert.False(t, continuePipeline)
+	assert.EqualError(t, result.(error), "Unexpected type received, expecting models.Event")
+}
+
+func TestJoinConfigValidate(t *testing.T) {
+	tests := []struct {
+		Name   string
+		Config JoinConfig
+		Error  string
+	}{
+		{"Valid", JoinConfig{Sources: []JoinSource{{Device: devID1}, {Device: devID2}}, Window: time.Second}, ""},
+		{"One source", JoinConfig{Sources: []JoinSource{{Device: devID1}}, Window: time.Second}, "at least two sources must be joined"},
+		{"No device", JoinConfig{Sources: []JoinSource{{Device: devID1}, {Reading: "Humidity"}}, Window: time.Second}, "the device of a join source must be specified"},
+		{"Duplicate", JoinConfig{Sources: []JoinSource{{Device: devID1}, {Device: devID1}}, Window: time.Second}, "duplicate join source 'id1'"},
+		{"No window", JoinConfig{Sources: []JoinSource{{Device: devID1}, {Device: devID2}}}, "join window must be positive"},
+	}
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			err := test.Config.Validate()
+			if test.Error == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, test.Error)
+			}
+		})
+	}
+}
+
+func TestParseJoinSource(t *testing.T) {
+	source, err := ParseJoinSource(" thermostat/Temperature ")
+	require.NoError(t, err)
+	assert.Equal(t, JoinSource{Device: "thermostat", Reading: "Temperature"}, source)
+
+	source, err = ParseJoinSource("floor/1/hygrometer")
+	require.NoError(t, err)
+	assert.Equal(t, JoinSource{Device: "floor/1", Reading: "hygrometer"}, source)
+
+	source, err = ParseJoinSource("thermostat")
+	require.NoError(t, err)
+	assert.Equal(t, JoinSource{Device: "thermostat"}, source)
+
+	_, err = ParseJoinSource("thermostat/")
+	assert.Error(t, err)
+	_, err = ParseJoinSource("/Temperature")
+	assert.Error(t, err)
+}
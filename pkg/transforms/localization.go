@@ -0,0 +1,84 @@
+//
+// Copyright (c) 2019 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/antoniomtz/app-functions-sdk-go/appcontext"
+	"github.com/edgexfoundry/go-mod-core-contracts/models"
+)
+
+// LocalizationLabels maps a reading name to the label to use for each of its raw values
+type LocalizationLabels map[string]map[string]string
+
+// Localization houses the per locale lookup tables used to replace enumerated reading values with human-readable labels
+type Localization struct {
+	// Locale is the locale to translate to, i.e. "en" or "fr-CA". When a locale with a region has no table,
+	// the table for its base language is used.
+	Locale string
+	// Labels contains the lookup table for each supported locale
+	Labels map[string]LocalizationLabels
+}
+
+// LocalizeReadings replaces the value of each reading found in the lookup table for the configured locale
+// with its label. Readings or values not found in the lookup table are passed through unchanged.
+// This function returns an Event
+func (l Localization) LocalizeReadings(edgexcontext *appcontext.Context, params ...interface{}) (continuePipeline bool, result interface{}) {
+	if len(params) < 1 {
+		return false, errors.New("No Event Received")
+	}
+
+	edgexcontext.LoggingClient.Debug("Localizing readings", "locale", l.Locale)
+
+	event, ok := params[0].(models.Event)
+	if !ok {
+		return false, errors.New("Unexpected type received, expecting models.Event")
+	}
+
+	labels := l.labelsForLocale()
+	if labels == nil {
+		return false, errors.New("No localization labels found for locale '" + l.Locale + "'")
+	}
+
+	readings := make([]models.Reading, len(event.Readings))
+	for index, reading := range event.Readings {
+		if values, ok := labels[reading.Name]; ok {
+			if label, ok := values[reading.Value]; ok {
+				reading.Value = label
+			}
+		}
+		readings[index] = reading
+	}
+	event.Readings = readings
+
+	return true, event
+}
+
+func (l Localization) labelsForLocale() LocalizationLabels {
+	if labels, ok := l.Labels[l.Locale]; ok {
+		return labels
+	}
+
+	// fall back to the base language, i.e. "fr" for "fr-CA"
+	if index := strings.IndexAny(l.Locale, "-_"); index > 0 {
+		return l.Labels[l.Locale[:index]]
+	}
+
+	return nil
+}
@@ -0,0 +1,85 @@
+//
+// Copyright (c) 2019 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	"testing"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/models"
+	"github.com/stretchr/testify/assert"
+)
+
+var localizationLabels = map[string]LocalizationLabels{
+	"en": {
+		"ValveState": {"0": "Closed", "1": "Open"},
+	},
+	"fr": {
+		"ValveState": {"0": "Fermée", "1": "Ouverte"},
+	},
+}
+
+func TestLocalizeReadings(t *testing.T) {
+	eventIn := models.Event{
+		Device: devID1,
+		Readings: []models.Reading{
+			{Name: "ValveState", Value: "1"},
+			{Name: "ValveState", Value: "7"},
+			{Name: readingName1, Value: readingValue1},
+		},
+	}
+
+	localization := Localization{Locale: "en", Labels: localizationLabels}
+	continuePipeline, result := localization.LocalizeReadings(context, eventIn)
+
+	assert.True(t, continuePipeline, "Pipeline should continue")
+	eventOut, ok := result.(models.Event)
+	if !assert.True(t, ok, "Result should be models.Event") {
+		t.Fatal()
+	}
+	assert.Equal(t, "Open", eventOut.Readings[0].Value, "Known value should be localized")
+	assert.Equal(t, "7", eventOut.Readings[1].Value, "Unknown value should be unchanged")
+	assert.Equal(t, readingValue1, eventOut.Readings[2].Value, "Unknown reading should be unchanged")
+	assert.Equal(t, "1", eventIn.Readings[0].Value, "Original event should not be modified")
+}
+
+func TestLocalizeReadingsBaseLanguageFallback(t *testing.T) {
+	eventIn := models.Event{
+		Readings: []models.Reading{{Name: "ValveState", Value: "0"}},
+	}
+
+	localization := Localization{Locale: "fr-CA", Labels: localizationLabels}
+	continuePipeline, result := localization.LocalizeReadings(context, eventIn)
+
+	assert.True(t, continuePipeline, "Pipeline should continue")
+	assert.Equal(t, "Fermée", result.(models.Event).Readings[0].Value)
+}
+
+func TestLocalizeReadingsUnknownLocale(t *testing.T) {
+	localization := Localization{Locale: "de", Labels: localizationLabels}
+	continuePipeline, result := localization.LocalizeReadings(context, models.Event{})
+
+	assert.False(t, continuePipeline, "Pipeline should stop")
+	assert.Error(t, result.(error))
+}
+
+func TestLocalizeReadingsNoParameters(t *testing.T) {
+	localization := Localization{Locale: "en", Labels: localizationLabels}
+	continuePipeline, result := localization.LocalizeReadings(context)
+
+	assert.False(t, continuePipeline, "Pipeline should stop")
+	assert.Equal(t, "No Event Received", result.(error).Error())
+}
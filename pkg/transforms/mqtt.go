@@ -17,30 +17,148 @@
 package transforms
 
 import (
+	syscontext "context"
 	"crypto/tls"
+	"encoding/json"
+	"encoding/xml"
 	"errors"
 	"fmt"
+	"math/rand"
+	"net/url"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	MQTT "github.com/eclipse/paho.mqtt.golang"
 	"github.com/antoniomtz/app-functions-sdk-go/appcontext"
+	"github.com/antoniomtz/app-functions-sdk-go/pkg/certs"
 	"github.com/edgexfoundry/go-mod-core-contracts/clients"
 	"github.com/edgexfoundry/go-mod-core-contracts/clients/logger"
 	"github.com/edgexfoundry/go-mod-core-contracts/models"
 )
 
+const (
+	// mqttCancelPollInterval is how often a wait for the broker checks whether the service is stopping
+	mqttCancelPollInterval = 100 * time.Millisecond
+	// defaultMqttRetryInterval is the wait before the first retry of a failed publish
+	defaultMqttRetryInterval = time.Second
+	// defaultMqttMaxRetryInterval caps the wait between retries of a failed publish
+	defaultMqttMaxRetryInterval = 30 * time.Second
+)
+
+const (
+	// MqttQosKey is the key of the context value, set with SetValue, which overrides the QoS (0, 1 or 2) of the
+	// message MQTTSend publishes for the event, i.e. to publish alarms at QoS 2 while telemetry stays at QoS 0
+	MqttQosKey = "mqtt.qos"
+	// MqttRetainKey is the key of the context value, set with SetValue, which overrides whether the broker retains
+	// the message MQTTSend publishes for the event
+	MqttRetainKey = "mqtt.retain"
+)
+
+const (
+	// MqttFormatJSON publishes the events MQTTSend receives as JSON, which is the default
+	MqttFormatJSON = "json"
+	// MqttFormatXML publishes the events MQTTSend receives as XML
+	MqttFormatXML = "xml"
+)
+
 // MqttConfig contains mqtt client parameters
 type MqttConfig struct {
 	qos           byte
 	retain        bool
 	autoreconnect bool
+	credentials   CredentialsProvider
+	// unordered is the inverse of paho's order matters option, so that the zero value keeps messages in order
+	unordered            bool
+	maxReconnectInterval time.Duration
+	messageChannelDepth  uint
+	maxRetries           int
+	retryInterval        time.Duration
+	maxRetryInterval     time.Duration
+	persistent           bool
+	willTopic            string
+	willPayload          []byte
+	willQos              byte
+	willRetain           bool
+	format               string
+	failoverBrokers      []string
 }
 
 type MQTTSender struct {
 	client MQTT.Client
 	topic  string
 	opts   MqttConfig
+	state  *mqttConnectionState
+	// brokers are the primary broker followed by the failover brokers, empty when there are no failover brokers
+	brokers []mqttBroker
+}
+
+// mqttBroker is a broker the sender can publish to, with its own client
+type mqttBroker struct {
+	url    string
+	client MQTT.Client
+}
+
+// MqttConnectionState reports the health of the connection of an MQTTSender to the broker
+type MqttConnectionState struct {
+	Connected bool
+	// Published is the number of messages published
+	Published uint64
+	// Errors is the number of failed connections and lost connections
+	Errors        uint64
+	LastError     string
+	LastErrorTime time.Time
+	// Broker is the URL of the broker in use, which is a failover broker after failing over
+	Broker string
+	// Failovers is the number of times the sender switched to another broker
+	Failovers uint64
+}
+
+// mqttConnectionState tracks the connection of a sender, and is shared by the copies of the sender
+type mqttConnectionState struct {
+	mutex sync.Mutex
+	state MqttConnectionState
+	// index is the index of the broker in use among the brokers of the sender
+	index int
+	// connecting serializes the connections to the brokers of a sender with failover brokers
+	connecting sync.Mutex
+}
+
+func (state *mqttConnectionState) published() {
+	if state == nil {
+		return
+	}
+	state.mutex.Lock()
+	defer state.mutex.Unlock()
+	state.state.Published++
+}
+
+// active returns the index of the broker in use
+func (state *mqttConnectionState) active() int {
+	state.mutex.Lock()
+	defer state.mutex.Unlock()
+	return state.index
+}
+
+// switchTo records that the sender switched to the broker at the index
+func (state *mqttConnectionState) switchTo(index int, broker string) {
+	state.mutex.Lock()
+	defer state.mutex.Unlock()
+	state.index = index
+	state.state.Broker = broker
+	state.state.Failovers++
+}
+
+func (state *mqttConnectionState) failed(err error) {
+	if state == nil {
+		return
+	}
+	state.mutex.Lock()
+	defer state.mutex.Unlock()
+	state.state.Errors++
+	state.state.LastError = err.Error()
+	state.state.LastErrorTime = time.Now()
 }
 
 // NewMqttConfig returns a new MqttConfig with default values
@@ -53,88 +171,544 @@ func NewMqttConfig() *MqttConfig {
 	return mqttConfig
 }
 
+// MqttOption sets an option of the MqttConfig created by NewMqttConfigWithOptions
+type MqttOption func(*MqttConfig)
+
+// NewMqttConfigWithOptions returns a new MqttConfig with the options applied to the default values, or an error when
+// the resulting config is invalid, i.e. NewMqttConfigWithOptions(WithQos(1), WithRetain(true))
+func NewMqttConfigWithOptions(options ...MqttOption) (*MqttConfig, error) {
+	mqttConfig := NewMqttConfig()
+	for _, option := range options {
+		option(mqttConfig)
+	}
+	if err := mqttConfig.Validate(); err != nil {
+		return nil, err
+	}
+	return mqttConfig, nil
+}
+
+// WithQos sets the QoS (0, 1 or 2) of all messages, like SetQos
+func WithQos(qos byte) MqttOption {
+	return func(mqttConfig *MqttConfig) { mqttConfig.SetQos(qos) }
+}
+
+// WithRetain sets whether the broker retains the messages, like SetRetain
+func WithRetain(retain bool) MqttOption {
+	return func(mqttConfig *MqttConfig) { mqttConfig.SetRetain(retain) }
+}
+
+// WithAutoreconnect sets whether the client reconnects to the broker automatically, like SetAutoreconnect
+func WithAutoreconnect(reconnect bool) MqttOption {
+	return func(mqttConfig *MqttConfig) { mqttConfig.SetAutoreconnect(reconnect) }
+}
+
+// WithCredentialsProvider sets the provider of the credentials used on each connection, like SetCredentialsProvider
+func WithCredentialsProvider(provider CredentialsProvider) MqttOption {
+	return func(mqttConfig *MqttConfig) { mqttConfig.SetCredentialsProvider(provider) }
+}
+
+// WithOrderMatters sets whether messages are delivered in order, like SetOrderMatters
+func WithOrderMatters(order bool) MqttOption {
+	return func(mqttConfig *MqttConfig) { mqttConfig.SetOrderMatters(order) }
+}
+
+// WithMaxReconnectInterval sets the maximum time waited between attempts to reconnect, like SetMaxReconnectInterval
+func WithMaxReconnectInterval(interval time.Duration) MqttOption {
+	return func(mqttConfig *MqttConfig) { mqttConfig.SetMaxReconnectInterval(interval) }
+}
+
+// WithMessageChannelDepth sets the number of messages queued while reconnecting, like SetMessageChannelDepth
+func WithMessageChannelDepth(depth uint) MqttOption {
+	return func(mqttConfig *MqttConfig) { mqttConfig.SetMessageChannelDepth(depth) }
+}
+
+// WithRetries sets how failed publishes are retried, like SetRetries
+func WithRetries(maxRetries int, interval time.Duration, maxInterval time.Duration) MqttOption {
+	return func(mqttConfig *MqttConfig) { mqttConfig.SetRetries(maxRetries, interval, maxInterval) }
+}
+
+// WithPersistent sets whether the connection is made when the sender is created, like SetPersistent
+func WithPersistent(persistent bool) MqttOption {
+	return func(mqttConfig *MqttConfig) { mqttConfig.SetPersistent(persistent) }
+}
+
+// WithFormat sets the format events are published in, like SetFormat
+func WithFormat(format string) MqttOption {
+	return func(mqttConfig *MqttConfig) { mqttConfig.SetFormat(format) }
+}
+
+// WithFailoverBrokers sets the brokers published to when the primary broker is unreachable, like SetFailoverBrokers
+func WithFailoverBrokers(brokers ...string) MqttOption {
+	return func(mqttConfig *MqttConfig) { mqttConfig.SetFailoverBrokers(brokers...) }
+}
+
+// WithWill sets the Last Will and Testament of the connection, like SetWill
+func WithWill(topic string, payload []byte, qos byte, retain bool) MqttOption {
+	return func(mqttConfig *MqttConfig) { mqttConfig.SetWill(topic, payload, qos, retain) }
+}
+
+// Validate returns an error describing the first invalid setting of the config
+func (mqttConfig *MqttConfig) Validate() error {
+	switch {
+	case mqttConfig.qos > 2:
+		return fmt.Errorf("MQTT QoS must be 0, 1 or 2, got %d", mqttConfig.qos)
+	case mqttConfig.willTopic != "" && mqttConfig.willQos > 2:
+		return fmt.Errorf("MQTT will QoS must be 0, 1 or 2, got %d", mqttConfig.willQos)
+	case mqttConfig.willTopic == "" && len(mqttConfig.willPayload) > 0:
+		return errors.New("MQTT will topic must be set along with the will payload")
+	case mqttConfig.maxReconnectInterval < 0:
+		return fmt.Errorf("MQTT max reconnect interval must not be negative, got %s", mqttConfig.maxReconnectInterval)
+	case mqttConfig.format != "" && mqttConfig.format != MqttFormatJSON && mqttConfig.format != MqttFormatXML:
+		return fmt.Errorf("MQTT format must be %s or %s, got '%s'", MqttFormatJSON, MqttFormatXML, mqttConfig.format)
+	case mqttConfig.maxRetries < 0:
+		return fmt.Errorf("MQTT max retries must not be negative, got %d", mqttConfig.maxRetries)
+	case mqttConfig.retryInterval < 0 || mqttConfig.maxRetryInterval < 0:
+		return errors.New("MQTT retry intervals must not be negative")
+	}
+	for _, broker := range mqttConfig.failoverBrokers {
+		if parsed, err := url.Parse(broker); err != nil || parsed.Scheme == "" || parsed.Host == "" {
+			return fmt.Errorf("MQTT failover broker must be a URL such as 'tcp://localhost:1883', got '%s'", broker)
+		}
+	}
+	return nil
+}
+
 // SetRetain enables or disables mqtt retain option
-func (mqttConfig MqttConfig) SetRetain(retain bool) {
+func (mqttConfig *MqttConfig) SetRetain(retain bool) {
 	mqttConfig.retain = retain
 }
 
 // SetQos changes mqtt qos(0,1,2) for all messages
-func (mqttConfig MqttConfig) SetQos(qos byte) {
+func (mqttConfig *MqttConfig) SetQos(qos byte) {
 	mqttConfig.qos = qos
 }
 
 // SetAutoreconnect enables or disables the automatic client reconnection to broker
-func (mqttConfig MqttConfig) SetAutoreconnect(reconnect bool) {
+func (mqttConfig *MqttConfig) SetAutoreconnect(reconnect bool) {
 	mqttConfig.autoreconnect = reconnect
 }
 
+// SetCredentialsProvider sets the provider of the credentials used on each connection to the broker, instead of
+// the User and Password of the addressable
+func (mqttConfig *MqttConfig) SetCredentialsProvider(provider CredentialsProvider) {
+	mqttConfig.credentials = provider
+}
+
+// SetOrderMatters sets whether messages are delivered in order within each QoS level. Disabling ordering allows the
+// client to handle messages asynchronously, which raises the throughput of high rate exports. Messages are in order
+// by default.
+func (mqttConfig *MqttConfig) SetOrderMatters(order bool) {
+	mqttConfig.unordered = !order
+}
+
+// SetMaxReconnectInterval sets the maximum time waited between attempts to reconnect to the broker when the
+// connection is lost. Zero uses the client's default of 10 minutes.
+func (mqttConfig *MqttConfig) SetMaxReconnectInterval(interval time.Duration) {
+	mqttConfig.maxReconnectInterval = interval
+}
+
+// SetMessageChannelDepth sets the number of messages the client queues while it is reconnecting to the broker.
+// It only applies with automatic reconnection. Zero uses the client's default of 100.
+func (mqttConfig *MqttConfig) SetMessageChannelDepth(depth uint) {
+	mqttConfig.messageChannelDepth = depth
+}
+
+// SetRetries sets the number of times a publish which failed, i.e. on a transient broker hiccup, is retried before
+// the pipeline stops with the error. The wait before the first retry is interval, doubled for each subsequent retry up
+// to maxInterval, and randomized by up to half so that senders don't retry in step. Zero intervals default to one
+// second and 30 seconds respectively. Retries stop early when the pipeline deadline would pass while waiting, or when
+// the service stops. No failed publish is retried by default.
+func (mqttConfig *MqttConfig) SetRetries(maxRetries int, interval time.Duration, maxInterval time.Duration) {
+	mqttConfig.maxRetries = maxRetries
+	mqttConfig.retryInterval = interval
+	mqttConfig.maxRetryInterval = maxInterval
+}
+
+// SetPersistent sets whether the sender connects to the broker when it is created, so that a misconfigured broker
+// address, TLS certificate or credentials is reported as the service starts rather than once data flows, and
+// reconnects automatically when the connection is lost. The state of the connection is reported by ConnectionState.
+// Senders connect on the first send by default.
+func (mqttConfig *MqttConfig) SetPersistent(persistent bool) {
+	mqttConfig.persistent = persistent
+}
+
+// SetWill sets the Last Will and Testament the broker publishes to topic when the connection of the sender is lost
+// without disconnecting, i.e. so that subscribers know the service went offline. An empty topic sets no will.
+func (mqttConfig *MqttConfig) SetWill(topic string, payload []byte, qos byte, retain bool) {
+	mqttConfig.willTopic = topic
+	mqttConfig.willPayload = payload
+	mqttConfig.willQos = qos
+	mqttConfig.willRetain = retain
+}
+
+// SetFailoverBrokers sets the URLs of the brokers, such as "tcp://backup:1883", which are tried in order when the
+// primary broker of the addressable is unreachable. The sender keeps publishing to the broker it failed over to until
+// its connection is lost, and then tries the brokers in order again starting with the primary. The client options,
+// credentials and certificate apply to all brokers.
+func (mqttConfig *MqttConfig) SetFailoverBrokers(brokers ...string) {
+	mqttConfig.failoverBrokers = brokers
+}
+
+// SetFormat sets the format, MqttFormatJSON or MqttFormatXML, in which MQTTSend publishes the events it receives.
+// Strings and []byte are published as they are.
+func (mqttConfig *MqttConfig) SetFormat(format string) {
+	mqttConfig.format = format
+}
+
 // MQTTSend ...
 func (sender MQTTSender) MQTTSend(edgexcontext *appcontext.Context, params ...interface{}) (bool, interface{}) {
 	if len(params) < 1 {
 		// We didn't receive a result
 		return false, errors.New("No Data Received")
 	}
-	if !sender.client.IsConnected() {
+	data, err := sender.payload(params[0])
+	if err != nil {
+		return false, err
+	}
+	if err := sender.publishWithRetries(edgexcontext, data); err != nil {
+		return false, err
+	}
+	edgexcontext.LoggingClient.Info("Sent data to MQTT Broker")
+	edgexcontext.LoggingClient.Trace("Data exported", "Transport", "MQTT", clients.CorrelationHeader, edgexcontext.CorrelationID)
+	err = edgexcontext.MarkAsPushed()
+	if err != nil {
+		edgexcontext.LoggingClient.Error(err.Error())
+	}
+	return true, nil
+}
+
+// payload returns the message to publish for the data of the previous function. Strings and []byte are published
+// as they are, while events are marshaled in the configured format.
+func (sender MQTTSender) payload(data interface{}) ([]byte, error) {
+	switch data := data.(type) {
+	case string:
+		return []byte(data), nil
+	case []byte:
+		return data, nil
+	case models.Event:
+		if sender.opts.format == MqttFormatXML {
+			marshaled, err := xml.Marshal(data)
+			if err != nil {
+				return nil, fmt.Errorf("Marshaling event to XML failed: %s", err.Error())
+			}
+			return marshaled, nil
+		}
+		marshaled, err := json.Marshal(data)
+		if err != nil {
+			return nil, fmt.Errorf("Marshaling event to JSON failed: %s", err.Error())
+		}
+		return marshaled, nil
+	default:
+		return nil, errors.New("Unexpected type received")
+	}
+}
+
+// publishWithRetries publishes the data, retrying failures with an exponential backoff up to the configured number
+// of times
+func (sender MQTTSender) publishWithRetries(edgexcontext *appcontext.Context, data []byte) error {
+	qos, retain, err := sender.messageOptions(edgexcontext)
+	if err != nil {
+		return err
+	}
+	ctx := edgexcontext.RequestContext()
+	interval := sender.opts.retryInterval
+	if interval <= 0 {
+		interval = defaultMqttRetryInterval
+	}
+	maxInterval := sender.opts.maxRetryInterval
+	if maxInterval <= 0 {
+		maxInterval = defaultMqttMaxRetryInterval
+	}
+
+	for attempt := 0; ; attempt++ {
+		err := sender.publish(edgexcontext, ctx, data, qos, retain)
+		if err == nil || attempt >= sender.opts.maxRetries || ctx.Err() != nil {
+			return err
+		}
+
+		if interval > maxInterval {
+			interval = maxInterval
+		}
+		wait := jitter(interval)
+		if !edgexcontext.Deadline.IsZero() && time.Now().Add(wait).After(edgexcontext.Deadline) {
+			return fmt.Errorf("%s (giving up after %d retries as the pipeline deadline would pass)", err.Error(), attempt)
+		}
+		edgexcontext.LoggingClient.Warn(fmt.Sprintf("Failed to send data to MQTT Broker, retrying in %s: %s", wait, err.Error()),
+			clients.CorrelationHeader, edgexcontext.CorrelationID)
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		}
+		interval *= 2
+	}
+}
+
+// messageOptions returns the QoS and retain flag of the message published for the event, which the MqttQosKey and
+// MqttRetainKey context values override
+func (sender MQTTSender) messageOptions(edgexcontext *appcontext.Context) (byte, bool, error) {
+	qos := sender.opts.qos
+	retain := sender.opts.retain
+
+	if value, ok := edgexcontext.GetValue(MqttQosKey); ok {
+		var override int
+		switch typed := value.(type) {
+		case byte:
+			override = int(typed)
+		case int:
+			override = typed
+		default:
+			return 0, false, fmt.Errorf("%s context value must be a byte or an int, got %T", MqttQosKey, value)
+		}
+		if override < 0 || override > 2 {
+			return 0, false, fmt.Errorf("%s context value must be 0, 1 or 2, got %d", MqttQosKey, override)
+		}
+		qos = byte(override)
+	}
+	if value, ok := edgexcontext.GetValue(MqttRetainKey); ok {
+		override, isBool := value.(bool)
+		if !isBool {
+			return 0, false, fmt.Errorf("%s context value must be a bool, got %T", MqttRetainKey, value)
+		}
+		retain = override
+	}
+	return qos, retain, nil
+}
+
+// publish connects to the broker when the client isn't connected, and publishes the data
+func (sender MQTTSender) publish(edgexcontext *appcontext.Context, ctx syscontext.Context, data []byte, qos byte, retain bool) error {
+	client := sender.current()
+	if !client.IsConnected() {
 		edgexcontext.LoggingClient.Info("Connecting to mqtt server")
-		if token := sender.client.Connect(); token.Wait() && token.Error() != nil {
-			return false, fmt.Errorf("Could not connect to mqtt server, drop event. Error: %s", token.Error().Error())
+		var err error
+		if client, err = sender.connect(ctx, edgexcontext.LoggingClient); err != nil {
+			return fmt.Errorf("Could not connect to mqtt server, drop event. Error: %s", err.Error())
 		}
 		edgexcontext.LoggingClient.Info("Connected to mqtt server")
 	}
-	if data, ok := params[0].(string); ok {
-		token := sender.client.Publish(sender.topic, sender.opts.qos, sender.opts.retain, ([]byte)(data))
-		// FIXME: could be removed? set of tokens?
-		token.Wait()
-		if token.Error() != nil {
-			return false, token.Error()
+	if err := waitForToken(ctx, client.Publish(sender.topic, qos, retain, data)); err != nil {
+		return err
+	}
+	sender.state.published()
+	return nil
+}
+
+// current returns the client of the broker in use
+func (sender MQTTSender) current() MQTT.Client {
+	if len(sender.brokers) == 0 {
+		return sender.client
+	}
+	return sender.brokers[sender.state.active()].client
+}
+
+// connect connects to the broker and returns its client. With failover brokers, the brokers are tried in order,
+// starting with the primary broker, and the client of the first one reachable is returned.
+func (sender MQTTSender) connect(ctx syscontext.Context, logging logger.LoggingClient) (MQTT.Client, error) {
+	if len(sender.brokers) == 0 {
+		if err := waitForToken(ctx, sender.client.Connect()); err != nil {
+			sender.state.failed(err)
+			return nil, err
 		}
-		edgexcontext.LoggingClient.Info("Sent data to MQTT Broker")
-		edgexcontext.LoggingClient.Trace("Data exported", "Transport", "MQTT", clients.CorrelationHeader, edgexcontext.CorrelationID)
-		err := edgexcontext.MarkAsPushed()
-		if err != nil {
-			edgexcontext.LoggingClient.Error(err.Error())
+		return sender.client, nil
+	}
+
+	// the connection lost handler connects in the background, while a send may connect at the same time
+	sender.state.connecting.Lock()
+	defer sender.state.connecting.Unlock()
+	if client := sender.current(); client.IsConnected() {
+		return client, nil
+	}
+
+	var err error
+	for index, broker := range sender.brokers {
+		if err = waitForToken(ctx, broker.client.Connect()); err != nil {
+			sender.state.failed(err)
+			logging.Warn(fmt.Sprintf("Could not connect to mqtt server %s: %s", broker.url, err.Error()))
+			if ctx.Err() != nil {
+				return nil, err
+			}
+			continue
+		}
+		if active := sender.state.active(); index != active {
+			sender.state.switchTo(index, broker.url)
+			logging.Warn(fmt.Sprintf("Switched from mqtt server %s to %s", sender.brokers[active].url, broker.url))
+		}
+		return broker.client, nil
+	}
+	return nil, err
+}
+
+// Persistent returns whether the sender keeps its connection to the broker open from the time it is created
+func (sender *MQTTSender) Persistent() bool {
+	return sender.opts.persistent
+}
+
+// ConnectionState returns the state of the connection to the broker
+func (sender *MQTTSender) ConnectionState() MqttConnectionState {
+	state := MqttConnectionState{}
+	if sender.state != nil {
+		sender.state.mutex.Lock()
+		state = sender.state.state
+		sender.state.mutex.Unlock()
+	}
+	state.Connected = sender.current().IsConnected()
+	return state
+}
+
+// jitter returns a random duration between half the interval and the interval
+func jitter(interval time.Duration) time.Duration {
+	half := int64(interval / 2)
+	return time.Duration(half + rand.Int63n(half+1))
+}
+
+// Close disconnects the client from the broker, waiting up to a quarter of a second for messages in flight to be
+// delivered, i.e. before the service stops
+func (sender *MQTTSender) Close() error {
+	if sender.client.IsConnected() {
+		sender.client.Disconnect(250)
+	}
+	// the first broker is the primary broker, whose client is the client of the sender
+	for index, broker := range sender.brokers {
+		if index > 0 && broker.client.IsConnected() {
+			broker.client.Disconnect(250)
 		}
-		return true, nil
+	}
+	return nil
+}
 
+// waitForToken waits for the token to complete and returns its error, or returns an error when the context is
+// cancelled first, i.e. as the service stops while the broker is unreachable
+func waitForToken(ctx syscontext.Context, token MQTT.Token) error {
+	for !token.WaitTimeout(mqttCancelPollInterval) {
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("abandoned waiting for mqtt server: %v", ctx.Err())
+		default:
+		}
 	}
-	return false, errors.New("Unexpected type received")
+	return token.Error()
 }
 
 // NewMQTTSender - create new mqtt sender
 func NewMQTTSender(logging logger.LoggingClient, addr models.Addressable, certFile string, key string, config *MqttConfig) *MQTTSender {
+	if err := config.Validate(); err != nil {
+		logging.Error("Invalid MQTT config: " + err.Error())
+		return nil
+	}
 	protocol := strings.ToLower(addr.Protocol)
-
-	opts := MQTT.NewClientOptions()
 	broker := protocol + "://" + addr.Address + ":" + strconv.Itoa(addr.Port) + addr.Path
-	opts.AddBroker(broker)
-	opts.SetClientID(addr.Publisher)
-	opts.SetUsername(addr.User)
-	opts.SetPassword(addr.Password)
-	opts.SetAutoReconnect(config.autoreconnect)
+	failover := len(config.failoverBrokers) > 0
+
+	useTLS := mqttTLSProtocol(protocol)
+	for _, failoverBroker := range config.failoverBrokers {
+		parsed, _ := url.Parse(failoverBroker)
+		useTLS = useTLS || mqttTLSProtocol(strings.ToLower(parsed.Scheme))
+	}
 
-	if protocol == "tcps" || protocol == "ssl" || protocol == "tls" {
-		cert, err := tls.LoadX509KeyPair(certFile, key)
+	var tlsConfig *tls.Config
+	if useTLS {
+		// the certificate is reloaded when the files change, and used from the next connection to the broker
+		watcher, err := certs.NewWatcher(certs.FileSource(certFile, key), certs.DefaultReloadInterval, logging)
 
 		if err != nil {
 			logging.Error("Failed loading x509 data")
 			return nil
 		}
 
-		tlsConfig := &tls.Config{
+		tlsConfig = watcher.ClientTLSConfig(&tls.Config{
 			ClientCAs:          nil,
 			InsecureSkipVerify: true,
-			Certificates:       []tls.Certificate{cert},
-		}
+		})
+	}
 
-		opts.SetTLSConfig(tlsConfig)
+	state := &mqttConnectionState{state: MqttConnectionState{Broker: broker}}
+	sender := &MQTTSender{
+		topic: addr.Topic,
+		opts:  *config,
+		state: state,
+	}
 
+	newClient := func(broker string) MQTT.Client {
+		opts := MQTT.NewClientOptions()
+		opts.AddBroker(broker)
+		opts.SetClientID(addr.Publisher)
+		opts.SetUsername(addr.User)
+		opts.SetPassword(addr.Password)
+		// with failover brokers, the sender connects to the first reachable broker instead of paho reconnecting
+		opts.SetAutoReconnect((config.autoreconnect || config.persistent) && !failover)
+		opts.SetOrderMatters(!config.unordered)
+		if config.maxReconnectInterval > 0 {
+			opts.SetMaxReconnectInterval(config.maxReconnectInterval)
+		}
+		if config.messageChannelDepth > 0 {
+			opts.SetMessageChannelDepth(config.messageChannelDepth)
+		}
+		if config.willTopic != "" {
+			opts.SetBinaryWill(config.willTopic, config.willPayload, config.willQos, config.willRetain)
+		}
+		if config.credentials != nil {
+			opts.SetCredentialsProvider(mqttCredentialsProvider(logging, addr, config.credentials))
+		}
+		if tlsConfig != nil {
+			opts.SetTLSConfig(tlsConfig)
+		}
+		if config.persistent {
+			opts.SetConnectionLostHandler(func(client MQTT.Client, err error) {
+				state.failed(err)
+				if !failover {
+					logging.Warn("Lost connection to mqtt server " + broker + ", reconnecting: " + err.Error())
+					return
+				}
+				logging.Warn("Lost connection to mqtt server " + broker + ", connecting to the first reachable server: " + err.Error())
+				go func() {
+					if _, err := sender.connect(syscontext.Background(), logging); err != nil {
+						logging.Error("Could not connect to any mqtt server, connecting again on the next send: " + err.Error())
+					}
+				}()
+			})
+		}
+		return MQTT.NewClient(opts)
 	}
 
-	sender := &MQTTSender{
-		client: MQTT.NewClient(opts),
-		topic:  addr.Topic,
-		opts:   *config,
+	sender.client = newClient(broker)
+	if failover {
+		sender.brokers = append(sender.brokers, mqttBroker{url: broker, client: sender.client})
+		for _, failoverBroker := range config.failoverBrokers {
+			sender.brokers = append(sender.brokers, mqttBroker{url: failoverBroker, client: newClient(failoverBroker)})
+		}
+	}
+
+	if config.persistent {
+		logging.Info("Connecting to mqtt server " + broker)
+		if _, err := sender.connect(syscontext.Background(), logging); err != nil && failover {
+			logging.Error("Could not connect to any mqtt server, connecting again on the first send: " + err.Error())
+		} else if err != nil {
+			logging.Error(fmt.Sprintf("Could not connect to mqtt server %s, connecting again on the first send: %s", broker, err.Error()))
+		} else {
+			logging.Info("Connected to mqtt server " + sender.ConnectionState().Broker)
+		}
 	}
 
 	return sender
 }
+
+// mqttTLSProtocol returns whether the protocol of a broker connects over TLS
+func mqttTLSProtocol(protocol string) bool {
+	return protocol == "tcps" || protocol == "ssl" || protocol == "tls"
+}
+
+// mqttCredentialsProvider adapts the provider for the MQTT client, which can't handle errors. When the provider
+// fails, the User and Password of the addressable are used, so that the broker rejects the connection.
+func mqttCredentialsProvider(logging logger.LoggingClient, addr models.Addressable, provider CredentialsProvider) MQTT.CredentialsProvider {
+	return func() (string, string) {
+		username, password, err := provider.Credentials()
+		if err != nil {
+			logging.Error("Failed to get MQTT credentials: " + err.Error())
+			return addr.User, addr.Password
+		}
+		return username, password
+	}
+}
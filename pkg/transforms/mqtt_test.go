@@ -1,13 +1,20 @@
 package transforms
 
 import (
+	syscontext "context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
 	"strconv"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/antoniomtz/app-functions-sdk-go/appcontext"
 	MQTT "github.com/eclipse/paho.mqtt.golang"
 	"github.com/edgexfoundry/go-mod-core-contracts/models"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 var addr models.Addressable
@@ -68,8 +75,7 @@ func TestMQTTSendInvalidData(t *testing.T) {
 		client: MQTT.NewClient(opts),
 		topic:  "",
 	}
-	dataToSend := "SOME DATA TO SEND"
-	continuePipeline, result := sender.MQTTSend(context, ([]byte)(dataToSend))
+	continuePipeline, result := sender.MQTTSend(context, 42)
 	assert.False(t, continuePipeline, "Should Not Continue Pipeline")
 	assert.Equal(t, "Unexpected type received", result.(error).Error(), "Error should be: Unexpected type received")
 
@@ -100,3 +106,474 @@ func TestNewMQTTSender(t *testing.T) {
 	assert.Equal(t, "password", opts.Password(), "Password should be password")
 	assert.False(t, opts.AutoReconnect(), "Autoreconnect should be false")
 }
+
+func TestNewMQTTSenderTuning(t *testing.T) {
+	sender := NewMQTTSender(lc, addr, "", "", NewMqttConfig())
+	opts := sender.client.OptionsReader()
+	assert.True(t, opts.Order(), "Messages should be in order by default")
+	assert.Equal(t, 10*time.Minute, opts.MaxReconnectInterval(), "Client default should be kept")
+
+	// the message channel only queues messages while reconnecting automatically
+	config := NewMqttConfig()
+	config.autoreconnect = true
+	sender = NewMQTTSender(lc, addr, "", "", config)
+	opts = sender.client.OptionsReader()
+	assert.Equal(t, uint(100), opts.MessageChannelDepth(), "Client default should be kept")
+
+	config.SetOrderMatters(false)
+	config.SetMaxReconnectInterval(30 * time.Second)
+	config.SetMessageChannelDepth(5000)
+	sender = NewMQTTSender(lc, addr, "", "", config)
+	opts = sender.client.OptionsReader()
+	assert.False(t, opts.Order())
+	assert.Equal(t, 30*time.Second, opts.MaxReconnectInterval())
+	assert.Equal(t, uint(5000), opts.MessageChannelDepth())
+}
+
+func TestMQTTCredentialsProvider(t *testing.T) {
+	addr1 := models.Addressable{User: "user", Password: "password"}
+	calls := 0
+	provider := CredentialsProviderFunc(func() (string, string, error) {
+		calls++
+		if calls > 1 {
+			return "", "", errors.New("token request failed")
+		}
+		return "unused", "jwt-" + strconv.Itoa(calls), nil
+	})
+
+	credentials := mqttCredentialsProvider(context.LoggingClient, addr1, provider)
+
+	username, password := credentials()
+	assert.Equal(t, "unused", username)
+	assert.Equal(t, "jwt-1", password)
+
+	username, password = credentials()
+	assert.Equal(t, 2, calls, "Provider should be called on each connection")
+	assert.Equal(t, "user", username, "Addressable should be used when the provider fails")
+	assert.Equal(t, "password", password)
+}
+
+type pendingToken struct{}
+
+func (token pendingToken) Wait() bool {
+	select {}
+}
+
+func (token pendingToken) WaitTimeout(time.Duration) bool {
+	time.Sleep(time.Millisecond)
+	return false
+}
+
+func (token pendingToken) Error() error {
+	return nil
+}
+
+func TestWaitForTokenCancelled(t *testing.T) {
+	ctx, cancel := syscontext.WithCancel(syscontext.Background())
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		cancel()
+	}()
+
+	err := waitForToken(ctx, pendingToken{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "abandoned waiting for mqtt server")
+}
+
+type doneToken struct {
+	err error
+}
+
+func (token doneToken) Wait() bool {
+	return true
+}
+
+func (token doneToken) WaitTimeout(time.Duration) bool {
+	return true
+}
+
+func (token doneToken) Error() error {
+	return token.err
+}
+
+// flakyClient fails to publish until failures have been returned
+type flakyClient struct {
+	MQTT.Client
+	failures  int
+	published int
+	qos       byte
+	retained  bool
+	payload   interface{}
+}
+
+func (client *flakyClient) IsConnected() bool {
+	return true
+}
+
+func (client *flakyClient) Publish(topic string, qos byte, retained bool, payload interface{}) MQTT.Token {
+	client.published++
+	client.qos = qos
+	client.retained = retained
+	client.payload = payload
+	if client.published <= client.failures {
+		return doneToken{err: errors.New("broker unavailable")}
+	}
+	return doneToken{}
+}
+
+func TestMQTTSendRetries(t *testing.T) {
+	client := &flakyClient{failures: 2}
+	config := NewMqttConfig()
+	config.SetRetries(3, time.Millisecond, 2*time.Millisecond)
+	sender := MQTTSender{client: client, topic: addr.Topic, opts: *config}
+
+	continuePipeline, result := sender.MQTTSend(context, "SOME DATA TO SEND")
+	assert.True(t, continuePipeline, "transient failures should be retried")
+	assert.Nil(t, result)
+	assert.Equal(t, 3, client.published)
+
+	client = &flakyClient{failures: 5}
+	sender.client = client
+	continuePipeline, result = sender.MQTTSend(context, "SOME DATA TO SEND")
+	assert.False(t, continuePipeline)
+	require.Error(t, result.(error))
+	assert.Equal(t, "broker unavailable", result.(error).Error())
+	assert.Equal(t, 4, client.published, "the publish should be attempted once, then retried 3 times")
+}
+
+func TestMQTTSendNoRetries(t *testing.T) {
+	client := &flakyClient{failures: 1}
+	sender := MQTTSender{client: client, topic: addr.Topic, opts: *NewMqttConfig()}
+
+	continuePipeline, result := sender.MQTTSend(context, "SOME DATA TO SEND")
+	assert.False(t, continuePipeline)
+	assert.Error(t, result.(error))
+	assert.Equal(t, 1, client.published, "failures should not be retried by default")
+}
+
+func TestMQTTSendRetriesDeadline(t *testing.T) {
+	client := &flakyClient{failures: 5}
+	config := NewMqttConfig()
+	config.SetRetries(3, time.Minute, 0)
+	sender := MQTTSender{client: client, topic: addr.Topic, opts: *config}
+
+	edgexcontext := &appcontext.Context{LoggingClient: context.LoggingClient, Deadline: time.Now().Add(time.Second)}
+	continuePipeline, result := sender.MQTTSend(edgexcontext, "SOME DATA TO SEND")
+	assert.False(t, continuePipeline)
+	require.Error(t, result.(error))
+	assert.Contains(t, result.(error).Error(), "giving up after 0 retries as the pipeline deadline would pass")
+	assert.Equal(t, 1, client.published)
+}
+
+func TestNewMQTTSenderPersistent(t *testing.T) {
+	unreachable := models.Addressable{Address: "localhost", Port: 1, Protocol: "tcp", Topic: "testMQTTTopic"}
+	sender := NewMQTTSender(context.LoggingClient, unreachable, "", "", NewMqttConfig())
+	assert.False(t, sender.Persistent())
+	assert.Equal(t, MqttConnectionState{Broker: "tcp://localhost:1"}, sender.ConnectionState(), "senders should connect on the first send by default")
+
+	config := NewMqttConfig()
+	config.SetPersistent(true)
+	sender = NewMQTTSender(context.LoggingClient, unreachable, "", "", config)
+	assert.True(t, sender.Persistent())
+	opts := sender.client.OptionsReader()
+	assert.True(t, opts.AutoReconnect(), "persistent senders should reconnect automatically")
+	state := sender.ConnectionState()
+	assert.False(t, state.Connected)
+	assert.Equal(t, uint64(1), state.Errors, "the connection should be made when the sender is created")
+	assert.NotEmpty(t, state.LastError)
+	assert.False(t, state.LastErrorTime.IsZero())
+}
+
+func TestMQTTSendPublishedState(t *testing.T) {
+	sender := MQTTSender{client: &flakyClient{}, topic: addr.Topic, opts: *NewMqttConfig(), state: &mqttConnectionState{}}
+	continuePipeline, _ := sender.MQTTSend(context, "SOME DATA TO SEND")
+	assert.True(t, continuePipeline)
+	state := sender.ConnectionState()
+	assert.True(t, state.Connected)
+	assert.Equal(t, uint64(1), state.Published)
+}
+
+func TestJitter(t *testing.T) {
+	for i := 0; i < 100; i++ {
+		wait := jitter(time.Second)
+		assert.True(t, wait >= 500*time.Millisecond && wait <= time.Second, "%s should be between half and the interval", wait)
+	}
+}
+
+func TestMQTTSendMessageOverrides(t *testing.T) {
+	client := &flakyClient{}
+	config := NewMqttConfig()
+	config.qos = 0
+	sender := MQTTSender{client: client, topic: addr.Topic, opts: *config}
+
+	telemetry := &appcontext.Context{LoggingClient: context.LoggingClient}
+	continuePipeline, _ := sender.MQTTSend(telemetry, "telemetry")
+	assert.True(t, continuePipeline)
+	assert.Equal(t, byte(0), client.qos)
+	assert.False(t, client.retained)
+
+	alarm := &appcontext.Context{LoggingClient: context.LoggingClient}
+	alarm.SetValue(MqttQosKey, byte(2))
+	alarm.SetValue(MqttRetainKey, true)
+	continuePipeline, _ = sender.MQTTSend(alarm, "alarm")
+	assert.True(t, continuePipeline)
+	assert.Equal(t, byte(2), client.qos, "the QoS should be overridden for the event")
+	assert.True(t, client.retained, "the retain flag should be overridden for the event")
+
+	alarm.SetValue(MqttQosKey, 1)
+	continuePipeline, _ = sender.MQTTSend(alarm, "alarm")
+	assert.True(t, continuePipeline)
+	assert.Equal(t, byte(1), client.qos, "the QoS can be set as an int")
+
+	tests := []struct {
+		name     string
+		key      string
+		value    interface{}
+		expected string
+	}{
+		{"QoS out of range", MqttQosKey, 3, "mqtt.qos context value must be 0, 1 or 2, got 3"},
+		{"negative QoS", MqttQosKey, -1, "mqtt.qos context value must be 0, 1 or 2, got -1"},
+		{"QoS of wrong type", MqttQosKey, "2", "mqtt.qos context value must be a byte or an int, got string"},
+		{"retain of wrong type", MqttRetainKey, "yes", "mqtt.retain context value must be a bool, got string"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			invalid := &appcontext.Context{LoggingClient: context.LoggingClient}
+			invalid.SetValue(test.key, test.value)
+			published := client.published
+			continuePipeline, result := sender.MQTTSend(invalid, "data")
+			assert.False(t, continuePipeline)
+			require.Error(t, result.(error))
+			assert.Equal(t, test.expected, result.(error).Error())
+			assert.Equal(t, published, client.published, "nothing should be published")
+		})
+	}
+}
+
+func TestNewMQTTSenderWill(t *testing.T) {
+	sender := NewMQTTSender(lc, addr, "", "", NewMqttConfig())
+	opts := sender.client.OptionsReader()
+	assert.False(t, opts.WillEnabled(), "no will should be set by default")
+
+	config := NewMqttConfig()
+	config.SetWill("status/app-export", []byte("offline"), 1, true)
+	sender = NewMQTTSender(lc, addr, "", "", config)
+	opts = sender.client.OptionsReader()
+	assert.True(t, opts.WillEnabled())
+	assert.Equal(t, "status/app-export", opts.WillTopic())
+	assert.Equal(t, []byte("offline"), opts.WillPayload())
+	assert.Equal(t, byte(1), opts.WillQos())
+	assert.True(t, opts.WillRetained())
+}
+
+func TestMqttConfigSetters(t *testing.T) {
+	config := NewMqttConfig()
+	config.SetQos(2)
+	config.SetRetain(true)
+	config.SetAutoreconnect(true)
+	assert.Equal(t, byte(2), config.qos)
+	assert.True(t, config.retain)
+	assert.True(t, config.autoreconnect)
+
+	client := &flakyClient{}
+	sender := MQTTSender{client: client, topic: addr.Topic, opts: *config}
+	continuePipeline, _ := sender.MQTTSend(&appcontext.Context{LoggingClient: context.LoggingClient}, "data")
+	assert.True(t, continuePipeline)
+	assert.Equal(t, byte(2), client.qos, "the QoS set on the config should be published with")
+	assert.True(t, client.retained, "the retain flag set on the config should be published with")
+
+	opts := NewMQTTSender(lc, addr, "", "", config).client.OptionsReader()
+	assert.True(t, opts.AutoReconnect(), "Autoreconnect set on the config should be applied")
+}
+
+func TestNewMqttConfigWithOptions(t *testing.T) {
+	config, err := NewMqttConfigWithOptions()
+	require.NoError(t, err)
+	assert.Equal(t, *NewMqttConfig(), *config, "no options should keep the defaults")
+
+	config, err = NewMqttConfigWithOptions(
+		WithQos(1),
+		WithRetain(true),
+		WithAutoreconnect(true),
+		WithOrderMatters(false),
+		WithMaxReconnectInterval(30*time.Second),
+		WithMessageChannelDepth(5000),
+		WithRetries(3, time.Second, 10*time.Second),
+		WithPersistent(true),
+		WithWill("status/app-export", []byte("offline"), 1, true),
+	)
+	require.NoError(t, err)
+	assert.Equal(t, byte(1), config.qos)
+	assert.True(t, config.retain)
+	assert.True(t, config.autoreconnect)
+	assert.True(t, config.unordered)
+	assert.Equal(t, 30*time.Second, config.maxReconnectInterval)
+	assert.Equal(t, uint(5000), config.messageChannelDepth)
+	assert.Equal(t, 3, config.maxRetries)
+	assert.Equal(t, time.Second, config.retryInterval)
+	assert.Equal(t, 10*time.Second, config.maxRetryInterval)
+	assert.True(t, config.persistent)
+	assert.Equal(t, "status/app-export", config.willTopic)
+}
+
+func TestMqttConfigValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		option   MqttOption
+		expected string
+	}{
+		{"QoS out of range", WithQos(3), "MQTT QoS must be 0, 1 or 2, got 3"},
+		{"will QoS out of range", WithWill("status", nil, 3, false), "MQTT will QoS must be 0, 1 or 2, got 3"},
+		{"will payload without topic", WithWill("", []byte("offline"), 0, false), "MQTT will topic must be set along with the will payload"},
+		{"negative reconnect interval", WithMaxReconnectInterval(-time.Second), "MQTT max reconnect interval must not be negative, got -1s"},
+		{"negative retries", WithRetries(-1, time.Second, time.Second), "MQTT max retries must not be negative, got -1"},
+		{"negative retry interval", WithRetries(1, -time.Second, time.Second), "MQTT retry intervals must not be negative"},
+		{"failover broker without scheme", WithFailoverBrokers("tcp://backup:1883", "backup:1883"), "MQTT failover broker must be a URL such as 'tcp://localhost:1883', got 'backup:1883'"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			config, err := NewMqttConfigWithOptions(test.option)
+			assert.Nil(t, config)
+			require.Error(t, err)
+			assert.Equal(t, test.expected, err.Error())
+		})
+	}
+}
+
+func TestNewMQTTSenderInvalidConfig(t *testing.T) {
+	config := NewMqttConfig()
+	config.SetQos(3)
+	assert.Nil(t, NewMQTTSender(context.LoggingClient, addr, "", "", config), "no sender should be created for an invalid config")
+}
+
+func TestMQTTSendPayloadTypes(t *testing.T) {
+	event := models.Event{Device: "Random-Integer-Device", Readings: []models.Reading{{Name: "Int8", Value: "42"}}}
+	jsonEvent, err := json.Marshal(event)
+	require.NoError(t, err)
+	xmlEvent, err := xml.Marshal(event)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		format   string
+		data     interface{}
+		expected []byte
+	}{
+		{"string", "", "data", []byte("data")},
+		{"bytes", "", []byte{0x01, 0x02}, []byte{0x01, 0x02}},
+		{"event as JSON by default", "", event, jsonEvent},
+		{"event as JSON", MqttFormatJSON, event, jsonEvent},
+		{"event as XML", MqttFormatXML, event, xmlEvent},
+		{"bytes with XML format", MqttFormatXML, []byte("data"), []byte("data")},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			client := &flakyClient{}
+			config, err := NewMqttConfigWithOptions(WithFormat(test.format))
+			require.NoError(t, err)
+			sender := MQTTSender{client: client, topic: addr.Topic, opts: *config}
+			continuePipeline, result := sender.MQTTSend(&appcontext.Context{LoggingClient: context.LoggingClient}, test.data)
+			require.True(t, continuePipeline, "%v", result)
+			assert.Equal(t, test.expected, client.payload)
+		})
+	}
+
+	sender := MQTTSender{client: &flakyClient{}, topic: addr.Topic, opts: *NewMqttConfig()}
+	continuePipeline, result := sender.MQTTSend(&appcontext.Context{LoggingClient: context.LoggingClient}, 42)
+	assert.False(t, continuePipeline)
+	assert.Equal(t, "Unexpected type received", result.(error).Error())
+
+	_, err = NewMqttConfigWithOptions(WithFormat("cbor"))
+	assert.EqualError(t, err, "MQTT format must be json or xml, got 'cbor'")
+}
+
+// brokerClient is a client of a broker which is reachable or not
+type brokerClient struct {
+	MQTT.Client
+	reachable bool
+	connected bool
+	published int
+}
+
+func (client *brokerClient) IsConnected() bool {
+	return client.connected
+}
+
+func (client *brokerClient) Connect() MQTT.Token {
+	if !client.reachable {
+		return doneToken{err: errors.New("connection refused")}
+	}
+	client.connected = true
+	return doneToken{}
+}
+
+func (client *brokerClient) Publish(topic string, qos byte, retained bool, payload interface{}) MQTT.Token {
+	client.published++
+	return doneToken{}
+}
+
+func TestMQTTSendFailover(t *testing.T) {
+	primary := &brokerClient{}
+	backup := &brokerClient{reachable: true}
+	sender := MQTTSender{
+		client:  primary,
+		topic:   addr.Topic,
+		opts:    *NewMqttConfig(),
+		state:   &mqttConnectionState{state: MqttConnectionState{Broker: "tcp://primary:1883"}},
+		brokers: []mqttBroker{{url: "tcp://primary:1883", client: primary}, {url: "tcp://backup:1883", client: backup}},
+	}
+
+	continuePipeline, result := sender.MQTTSend(context, "SOME DATA TO SEND")
+	require.True(t, continuePipeline, "%v", result)
+	assert.Equal(t, 1, backup.published, "the backup broker should be published to while the primary is unreachable")
+	state := sender.ConnectionState()
+	assert.True(t, state.Connected)
+	assert.Equal(t, "tcp://backup:1883", state.Broker)
+	assert.Equal(t, uint64(1), state.Failovers)
+	assert.Equal(t, uint64(1), state.Errors)
+
+	primary.reachable = true
+	continuePipeline, _ = sender.MQTTSend(context, "SOME DATA TO SEND")
+	require.True(t, continuePipeline)
+	assert.Equal(t, 2, backup.published, "the backup broker should be kept while connected")
+	assert.Equal(t, 0, primary.published)
+
+	backup.connected = false
+	continuePipeline, _ = sender.MQTTSend(context, "SOME DATA TO SEND")
+	require.True(t, continuePipeline)
+	assert.Equal(t, 1, primary.published, "the primary broker should be tried first again once the connection is lost")
+	state = sender.ConnectionState()
+	assert.Equal(t, "tcp://primary:1883", state.Broker)
+	assert.Equal(t, uint64(2), state.Failovers)
+
+	primary.connected, primary.reachable, backup.reachable = false, false, false
+	continuePipeline, result = sender.MQTTSend(context, "SOME DATA TO SEND")
+	assert.False(t, continuePipeline)
+	assert.Contains(t, result.(error).Error(), "Could not connect to mqtt server")
+	assert.Equal(t, uint64(3), sender.ConnectionState().Errors)
+}
+
+func TestNewMQTTSenderFailover(t *testing.T) {
+	unreachable := models.Addressable{Address: "localhost", Port: 1, Protocol: "tcp", Topic: "testMQTTTopic"}
+	config := NewMqttConfig()
+	config.SetAutoreconnect(true)
+	config.SetPersistent(true)
+	config.SetFailoverBrokers("tcp://localhost:2")
+	sender := NewMQTTSender(context.LoggingClient, unreachable, "", "", config)
+	require.Len(t, sender.brokers, 2)
+	assert.Equal(t, sender.client, sender.brokers[0].client, "the primary broker should be tried first")
+	for _, broker := range sender.brokers {
+		opts := broker.client.OptionsReader()
+		servers := opts.Servers()
+		require.Len(t, servers, 1)
+		assert.Equal(t, broker.url, servers[0].String())
+		assert.False(t, opts.AutoReconnect(), "the sender should fail over instead of paho reconnecting to the same broker")
+	}
+	assert.Equal(t, "tcp://localhost:2", sender.brokers[1].url)
+
+	state := sender.ConnectionState()
+	assert.False(t, state.Connected)
+	assert.Equal(t, uint64(2), state.Errors, "each broker should be tried when the sender is created")
+	assert.Equal(t, "tcp://localhost:1", state.Broker)
+	assert.Nil(t, sender.Close())
+}
@@ -0,0 +1,124 @@
+//
+// Copyright (c) 2019 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	"bytes"
+	syscontext "context"
+	"errors"
+	"fmt"
+	"strconv"
+	"text/template"
+	"time"
+
+	"github.com/antoniomtz/app-functions-sdk-go/appcontext"
+	"github.com/edgexfoundry/go-mod-core-contracts/clients/notifications"
+	"github.com/edgexfoundry/go-mod-core-contracts/models"
+)
+
+// NotificationConfig contains the parameters of the notifications sent to Support Notifications
+type NotificationConfig struct {
+	// SlugPrefix is the prefix of the notification slug, which is made unique by appending a timestamp
+	SlugPrefix string
+	Sender     string
+	Category   notifications.CategoryEnum
+	Severity   notifications.SeverityEnum
+	// ContentTemplate is a text/template for the content of the notification. It is executed with the Event, the
+	// reading values keyed by reading name as Readings, and the CorrelationID, i.e.
+	// "Temperature of {{.Event.Device}} is {{.Readings.Temperature}}"
+	ContentTemplate string
+	Description     string
+	Labels          []string
+}
+
+// Notifier sends notifications about the events it receives
+type Notifier struct {
+	config  NotificationConfig
+	content *template.Template
+}
+
+type notificationTemplateData struct {
+	Event         models.Event
+	Readings      map[string]string
+	CorrelationID string
+}
+
+// NewNotifier creates a notifier for the specified configuration
+func NewNotifier(config NotificationConfig) (*Notifier, error) {
+	if config.SlugPrefix == "" {
+		return nil, errors.New("notification slug prefix must be specified")
+	}
+
+	content, err := template.New("content").Option("missingkey=zero").Parse(config.ContentTemplate)
+	if err != nil {
+		return nil, fmt.Errorf("invalid notification content template: %v", err)
+	}
+
+	return &Notifier{config: config, content: content}, nil
+}
+
+// NotifySupport sends a notification to Support Notifications with content built from the event from the previous
+// function, which is passed unchanged to the next function.
+func (notifier *Notifier) NotifySupport(edgexcontext *appcontext.Context, params ...interface{}) (bool, interface{}) {
+	if len(params) < 1 {
+		// We didn't receive a result
+		return false, errors.New("No Event Received")
+	}
+
+	event, ok := params[0].(models.Event)
+	if !ok {
+		return false, errors.New("Unexpected type received, expecting models.Event")
+	}
+
+	if edgexcontext.NotificationsClient == nil {
+		return false, errors.New("Notifications client is not configured")
+	}
+
+	data := notificationTemplateData{
+		Event:         event,
+		Readings:      map[string]string{},
+		CorrelationID: edgexcontext.CorrelationID,
+	}
+	for _, reading := range event.Readings {
+		data.Readings[reading.Name] = reading.Value
+	}
+
+	var content bytes.Buffer
+	if err := notifier.content.Execute(&content, data); err != nil {
+		return false, fmt.Errorf("unable to build notification content: %v", err)
+	}
+
+	notification := notifications.Notification{
+		Slug:        notifier.config.SlugPrefix + "-" + strconv.FormatInt(time.Now().UnixNano(), 10),
+		Sender:      notifier.config.Sender,
+		Category:    notifier.config.Category,
+		Severity:    notifier.config.Severity,
+		Content:     content.String(),
+		Description: notifier.config.Description,
+		Labels:      notifier.config.Labels,
+	}
+
+	err := edgexcontext.CallWithDeadline(func(ctx syscontext.Context) error {
+		return edgexcontext.NotificationsClient.SendNotification(notification, ctx)
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to send notification: %v", err)
+	}
+
+	edgexcontext.LoggingClient.Info("Sent notification " + notification.Slug)
+	return true, event
+}
@@ -0,0 +1,89 @@
+//
+// Copyright (c) 2019 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/antoniomtz/app-functions-sdk-go/appcontext"
+	"github.com/edgexfoundry/go-mod-core-contracts/clients"
+	"github.com/edgexfoundry/go-mod-core-contracts/clients/notifications"
+	"github.com/edgexfoundry/go-mod-core-contracts/clients/types"
+	"github.com/edgexfoundry/go-mod-core-contracts/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNotifySupport(t *testing.T) {
+	var received notifications.Notification
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, clients.ApiNotificationRoute, r.URL.Path)
+		assert.Equal(t, "123", r.Header.Get(clients.CorrelationHeader))
+		json.NewDecoder(r.Body).Decode(&received)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	notifier, err := NewNotifier(NotificationConfig{
+		SlugPrefix:      "high-temperature",
+		Sender:          "alerts",
+		Category:        notifications.HW_HEALTH,
+		Severity:        notifications.CRITICAL,
+		ContentTemplate: "Temperature of {{.Event.Device}} is {{.Readings.Temperature}}",
+		Labels:          []string{"temperature"},
+	})
+	require.NoError(t, err)
+
+	edgexcontext := &appcontext.Context{
+		LoggingClient:       context.LoggingClient,
+		CorrelationID:       "123",
+		NotificationsClient: notifications.NewNotificationsClient(types.EndpointParams{Url: server.URL + clients.ApiNotificationRoute}, nil),
+	}
+	event := models.Event{Device: devID1, Readings: []models.Reading{{Name: "Temperature", Value: "85"}}}
+
+	continuePipeline, result := notifier.NotifySupport(edgexcontext, event)
+	require.True(t, continuePipeline, "Unexpected error: %v", result)
+	assert.Equal(t, event, result, "Event should be passed to the next function unchanged")
+
+	assert.True(t, strings.HasPrefix(received.Slug, "high-temperature-"))
+	assert.Equal(t, "alerts", received.Sender)
+	assert.Equal(t, notifications.HW_HEALTH, received.Category)
+	assert.Equal(t, notifications.CRITICAL, received.Severity)
+	assert.Equal(t, "Temperature of id1 is 85", received.Content)
+	assert.Equal(t, []string{"temperature"}, received.Labels)
+}
+
+func TestNotifySupportNotConfigured(t *testing.T) {
+	notifier, err := NewNotifier(NotificationConfig{SlugPrefix: "alert", ContentTemplate: "{{.Event.Device}}"})
+	require.NoError(t, err)
+
+	continuePipeline, result := notifier.NotifySupport(context, models.Event{Device: devID1})
+	assert.False(t, continuePipeline)
+	assert.EqualError(t, result.(error), "Notifications client is not configured")
+}
+
+func TestNewNotifierInvalidTemplate(t *testing.T) {
+	_, err := NewNotifier(NotificationConfig{SlugPrefix: "alert", ContentTemplate: "{{.Event.Device"})
+	assert.Error(t, err)
+	_, err = NewNotifier(NotificationConfig{ContentTemplate: "{{.Event.Device}}"})
+	assert.Error(t, err, "Slug prefix should be required")
+}
@@ -0,0 +1,242 @@
+//
+// Copyright (c) 2019 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/antoniomtz/app-functions-sdk-go/appcontext"
+	"github.com/edgexfoundry/go-mod-core-contracts/models"
+)
+
+// The statistics outliers can be detected with
+const (
+	// OutlierZScore rejects the values more than Threshold standard deviations from the mean of the baseline
+	OutlierZScore = "zscore"
+	// OutlierIQR rejects the values more than Threshold interquartile ranges below the first quartile or above the
+	// third quartile of the baseline
+	OutlierIQR = "iqr"
+)
+
+// Defaults of the OutlierConfig
+const (
+	defaultZScoreThreshold   = 3
+	defaultIQRThreshold      = 1.5
+	defaultOutlierWindow     = 30
+	defaultOutlierMinSamples = 5
+)
+
+// OutlierConfig defines how outliers are detected
+type OutlierConfig struct {
+	// Method is either OutlierZScore, the default, or OutlierIQR
+	Method string
+	// Threshold is the number of standard deviations, 3 by default, or of interquartile ranges, 1.5 by default,
+	// beyond which values are rejected
+	Threshold float64
+	// Window is the number of values of the rolling baseline, 30 by default
+	Window int
+	// MinSamples is the number of values the baseline must hold before values are rejected, 5 or the Window if
+	// smaller by default
+	MinSamples int
+	// Readings are the names of the readings filtered. All numeric readings are filtered when empty.
+	Readings []string
+}
+
+// OutlierMetrics contains the readings evaluated and rejected by an OutlierFilter
+type OutlierMetrics struct {
+	Name      string
+	Evaluated uint64
+	Rejected  uint64
+	// RejectedByDevice is the number of readings rejected for each device
+	RejectedByDevice map[string]uint64 `json:",omitempty"`
+}
+
+// OutlierFilter drops the readings which deviate from the rolling baseline of the device's previous readings
+type OutlierFilter struct {
+	// Name is the name the metrics of the filter are reported under
+	Name      string
+	config    OutlierConfig
+	readings  map[string]bool
+	mutex     sync.Mutex
+	baselines map[outlierKey][]float64
+	metrics   OutlierMetrics
+}
+
+// outlierKey identifies the readings of a device sharing a baseline
+type outlierKey struct {
+	device string
+	name   string
+}
+
+// NewOutlierFilter creates an OutlierFilter reporting its metrics under name, setting the defaults of the config
+func NewOutlierFilter(name string, config OutlierConfig) (*OutlierFilter, error) {
+	if config.Method == "" {
+		config.Method = OutlierZScore
+	}
+	if config.Threshold == 0 {
+		config.Threshold = defaultZScoreThreshold
+		if config.Method == OutlierIQR {
+			config.Threshold = defaultIQRThreshold
+		}
+	}
+	if config.Window == 0 {
+		config.Window = defaultOutlierWindow
+	}
+	if config.MinSamples == 0 {
+		config.MinSamples = defaultOutlierMinSamples
+		if config.Window < defaultOutlierMinSamples {
+			config.MinSamples = config.Window
+		}
+	}
+
+	if config.Method != OutlierZScore && config.Method != OutlierIQR {
+		return nil, fmt.Errorf("outlier method must be %s or %s, got '%s'", OutlierZScore, OutlierIQR, config.Method)
+	}
+	if config.Threshold < 0 {
+		return nil, errors.New("outlier threshold must not be negative")
+	}
+	if config.Window < 2 {
+		return nil, errors.New("outlier window must hold at least 2 values")
+	}
+	if config.MinSamples < 2 || config.MinSamples > config.Window {
+		return nil, fmt.Errorf("outlier minimum samples must be from 2 to the window of %d", config.Window)
+	}
+
+	filter := &OutlierFilter{
+		Name:      name,
+		config:    config,
+		readings:  map[string]bool{},
+		baselines: map[outlierKey][]float64{},
+		metrics:   OutlierMetrics{Name: name, RejectedByDevice: map[string]uint64{}},
+	}
+	for _, reading := range config.Readings {
+		filter.readings[reading] = true
+	}
+	return filter, nil
+}
+
+// RejectOutliers removes the readings of the event from the previous function which deviate from the baseline of
+// the last Window values of the same device and reading. Rejected values aren't added to the baseline, and nothing is
+// rejected until the baseline holds MinSamples values or while it doesn't vary. Readings which aren't filtered, or
+// don't have a numeric value, are passed through unchanged. If no readings remain the pipeline execution will stop,
+// otherwise the filtered Event is returned.
+func (filter *OutlierFilter) RejectOutliers(edgexcontext *appcontext.Context, params ...interface{}) (bool, interface{}) {
+	if len(params) < 1 {
+		return false, errors.New("No Event Received")
+	}
+
+	event, ok := params[0].(models.Event)
+	if !ok {
+		return false, errors.New("Unexpected type received, expecting models.Event")
+	}
+
+	filter.mutex.Lock()
+	defer filter.mutex.Unlock()
+
+	readings := []models.Reading{}
+	for _, reading := range event.Readings {
+		if len(filter.readings) > 0 && !filter.readings[reading.Name] {
+			readings = append(readings, reading)
+			continue
+		}
+		value, err := strconv.ParseFloat(strings.TrimSpace(reading.Value), 64)
+		if err != nil {
+			readings = append(readings, reading)
+			continue
+		}
+
+		filter.metrics.Evaluated++
+		key := outlierKey{device: event.Device, name: reading.Name}
+		baseline := filter.baselines[key]
+		if len(baseline) >= filter.config.MinSamples && filter.isOutlier(baseline, value) {
+			filter.metrics.Rejected++
+			filter.metrics.RejectedByDevice[event.Device]++
+			edgexcontext.LoggingClient.Debug(fmt.Sprintf("Reading '%s' of device '%s' rejected as an outlier, value '%s'", reading.Name, event.Device, reading.Value))
+			continue
+		}
+
+		baseline = append(baseline, value)
+		if len(baseline) > filter.config.Window {
+			baseline = baseline[len(baseline)-filter.config.Window:]
+		}
+		filter.baselines[key] = baseline
+		readings = append(readings, reading)
+	}
+
+	if len(readings) == 0 {
+		return false, nil
+	}
+	event.Readings = readings
+
+	return true, event
+}
+
+// Metrics returns a snapshot of the readings evaluated and rejected by the filter
+func (filter *OutlierFilter) Metrics() OutlierMetrics {
+	filter.mutex.Lock()
+	defer filter.mutex.Unlock()
+
+	metrics := filter.metrics
+	metrics.RejectedByDevice = make(map[string]uint64, len(filter.metrics.RejectedByDevice))
+	for device, rejected := range filter.metrics.RejectedByDevice {
+		metrics.RejectedByDevice[device] = rejected
+	}
+	return metrics
+}
+
+// isOutlier reports whether the value deviates from the baseline by more than the threshold
+func (filter *OutlierFilter) isOutlier(baseline []float64, value float64) bool {
+	if filter.config.Method == OutlierIQR {
+		sorted := append([]float64(nil), baseline...)
+		sort.Float64s(sorted)
+		first, third := quantile(sorted, 0.25), quantile(sorted, 0.75)
+		spread := third - first
+		if spread == 0 {
+			return false
+		}
+		return value < first-filter.config.Threshold*spread || value > third+filter.config.Threshold*spread
+	}
+
+	mean := 0.0
+	for _, sample := range baseline {
+		mean += sample
+	}
+	mean /= float64(len(baseline))
+	variance := 0.0
+	for _, sample := range baseline {
+		variance += (sample - mean) * (sample - mean)
+	}
+	deviation := math.Sqrt(variance / float64(len(baseline)))
+	if deviation == 0 {
+		return false
+	}
+	return math.Abs(value-mean)/deviation > filter.config.Threshold
+}
+
+// quantile returns the q quantile of the sorted values, interpolating between the closest values
+func quantile(sorted []float64, q float64) float64 {
+	position := q * float64(len(sorted)-1)
+	lower := int(math.Floor(position))
+	upper := int(math.Ceil(position))
+	return sorted[lower] + (position-float64(lower))*(sorted[upper]-sorted[lower])
+}
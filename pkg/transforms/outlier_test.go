@@ -0,0 +1,122 @@
+//
+// Copyright (c) 2019 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	"testing"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// filterOutlier passes a Temperature reading of the device to the filter, reporting whether it was forwarded
+func filterOutlier(t *testing.T, filter *OutlierFilter, device string, value string) bool {
+	continuePipeline, result := filter.RejectOutliers(context, models.Event{
+		Device:   device,
+		Readings: []models.Reading{{Name: "Temperature", Value: value}},
+	})
+	if !continuePipeline {
+		assert.Nil(t, result)
+		return false
+	}
+	require.Len(t, result.(models.Event).Readings, 1)
+	return true
+}
+
+func TestRejectOutliersZScore(t *testing.T) {
+	filter, err := NewOutlierFilter("temperature", OutlierConfig{Window: 10, MinSamples: 4})
+	require.NoError(t, err)
+
+	for _, value := range []string{"20", "21", "19", "20"} {
+		assert.True(t, filterOutlier(t, filter, devID1, value), "Values should be forwarded until the baseline holds MinSamples")
+	}
+	assert.True(t, filterOutlier(t, filter, devID1, "21.5"), "Value within the threshold should be forwarded")
+	assert.False(t, filterOutlier(t, filter, devID1, "80"), "Outlier should be rejected")
+	assert.True(t, filterOutlier(t, filter, devID1, "20.5"), "Rejected value should not skew the baseline")
+	assert.True(t, filterOutlier(t, filter, devID2, "80"), "Devices should have their own baseline")
+
+	metrics := filter.Metrics()
+	assert.Equal(t, "temperature", metrics.Name)
+	assert.Equal(t, uint64(8), metrics.Evaluated)
+	assert.Equal(t, uint64(1), metrics.Rejected)
+	assert.Equal(t, map[string]uint64{devID1: 1}, metrics.RejectedByDevice)
+}
+
+func TestRejectOutliersIQR(t *testing.T) {
+	filter, err := NewOutlierFilter("temperature", OutlierConfig{Method: OutlierIQR})
+	require.NoError(t, err)
+
+	for _, value := range []string{"10", "12", "14", "16", "18"} {
+		assert.True(t, filterOutlier(t, filter, devID1, value))
+	}
+	// the quartiles are 12 and 16, so values from 6 to 22 are within 1.5 interquartile ranges
+	assert.True(t, filterOutlier(t, filter, devID1, "22"))
+	assert.False(t, filterOutlier(t, filter, devID1, "4"))
+}
+
+func TestRejectOutliersUnfiltered(t *testing.T) {
+	filter, err := NewOutlierFilter("temperature", OutlierConfig{Window: 3, Readings: []string{"Temperature"}})
+	require.NoError(t, err)
+
+	for _, value := range []string{"1", "1", "1", "100"} {
+		assert.True(t, filterOutlier(t, filter, devID1, value), "Nothing should be rejected while the baseline doesn't vary")
+	}
+	for _, value := range []string{"1", "2", "3"} {
+		filterOutlier(t, filter, devID1, value)
+	}
+
+	continuePipeline, result := filter.RejectOutliers(context, models.Event{
+		Device: devID1,
+		Readings: []models.Reading{
+			{Name: "Temperature", Value: "1000"},
+			{Name: "Temperature", Value: "hot"},
+			{Name: "Humidity", Value: "1000"},
+		},
+	})
+	require.True(t, continuePipeline)
+	assert.Equal(t, []models.Reading{{Name: "Temperature", Value: "hot"}, {Name: "Humidity", Value: "1000"}}, result.(models.Event).Readings)
+}
+
+func TestNewOutlierFilterErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   OutlierConfig
+		expected string
+	}{
+		{"method", OutlierConfig{Method: "mad"}, "outlier method must be zscore or iqr, got 'mad'"},
+		{"threshold", OutlierConfig{Threshold: -1}, "outlier threshold must not be negative"},
+		{"window", OutlierConfig{Window: 1}, "outlier window must hold at least 2 values"},
+		{"min samples", OutlierConfig{Window: 10, MinSamples: 11}, "outlier minimum samples must be from 2 to the window of 10"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := NewOutlierFilter("temperature", test.config)
+			assert.EqualError(t, err, test.expected)
+		})
+	}
+}
+
+func TestRejectOutliersNoParameters(t *testing.T) {
+	filter, err := NewOutlierFilter("temperature", OutlierConfig{})
+	require.NoError(t, err)
+	continuePipeline, result := filter.RejectOutliers(context)
+
+	assert.False(t, continuePipeline, "Pipeline should stop")
+	assert.EqualError(t, result.(error), "No Event Received")
+}
@@ -0,0 +1,48 @@
+//
+// Copyright (c) 2019 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	"errors"
+
+	"github.com/antoniomtz/app-functions-sdk-go/appcontext"
+)
+
+// ResponseData holds the content type of the data returned to the trigger
+type ResponseData struct {
+	ContentType string
+}
+
+// SetResponseData returns the data from the previous function to the trigger, i.e. as the response of the HTTP
+// trigger or the message published by the message bus trigger. The data is passed on to the next function.
+func (responseData ResponseData) SetResponseData(edgexcontext *appcontext.Context, params ...interface{}) (bool, interface{}) {
+	if len(params) < 1 {
+		// We didn't receive a result
+		return false, errors.New("No Data Received")
+	}
+
+	data, err := coerceToBytes(params[0])
+	if err != nil {
+		return false, err
+	}
+
+	edgexcontext.SetResponseData(data)
+	if responseData.ContentType != "" {
+		edgexcontext.SetResponseContentType(responseData.ContentType)
+	}
+	return true, params[0]
+}
@@ -0,0 +1,45 @@
+//
+// Copyright (c) 2019 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	"testing"
+
+	"github.com/antoniomtz/app-functions-sdk-go/appcontext"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetResponseData(t *testing.T) {
+	edgexcontext := &appcontext.Context{LoggingClient: context.LoggingClient}
+	responseData := ResponseData{ContentType: "application/xml"}
+
+	continuePipeline, result := responseData.SetResponseData(edgexcontext, "<Event/>")
+
+	assert.True(t, continuePipeline)
+	assert.Equal(t, "<Event/>", result, "Data should be passed to the next function")
+	assert.Equal(t, []byte("<Event/>"), edgexcontext.OutputData)
+	assert.Equal(t, "application/xml", edgexcontext.ResponseContentType)
+}
+
+func TestSetResponseDataNoData(t *testing.T) {
+	responseData := ResponseData{}
+
+	continuePipeline, result := responseData.SetResponseData(context)
+
+	assert.False(t, continuePipeline)
+	assert.EqualError(t, result.(error), "No Data Received")
+}
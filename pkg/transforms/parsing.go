@@ -0,0 +1,335 @@
+//
+// Copyright (c) 2019 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/antoniomtz/app-functions-sdk-go/appcontext"
+	"github.com/edgexfoundry/go-mod-core-contracts/models"
+)
+
+// Register types supported by RegisterField
+const (
+	RegisterTypeUint16  = "UINT16"
+	RegisterTypeInt16   = "INT16"
+	RegisterTypeUint32  = "UINT32"
+	RegisterTypeInt32   = "INT32"
+	RegisterTypeFloat32 = "FLOAT32"
+)
+
+// KeyValueParser houses the parameters for decoding readings with values such as "temp=21.5,humidity=40"
+type KeyValueParser struct {
+	// ReadingNames are the readings to decode. When empty, all readings are decoded.
+	ReadingNames []string
+	// PairSeparator separates the pairs and defaults to ","
+	PairSeparator string
+	// KeyValueSeparator separates each key from its value and defaults to "="
+	KeyValueSeparator string
+}
+
+// NMEAParser houses the parameters for decoding readings containing NMEA 0183 GGA or RMC sentences
+type NMEAParser struct {
+	// ReadingNames are the readings to decode. When empty, all readings are decoded.
+	ReadingNames []string
+}
+
+// RegisterField describes a value within a block of 16 bit registers
+type RegisterField struct {
+	Name string
+	// Register is the index of the first register holding the value
+	Register int
+	// Type is one of the RegisterType constants. 32 bit types span two registers.
+	Type string
+	// Scale multiplies the raw value when not zero
+	Scale float64
+	// WordSwap indicates the low word of a 32 bit value is held in the first register
+	WordSwap bool
+}
+
+// RegisterParser houses the parameters for decoding readings containing a hex-encoded block of big-endian
+// registers, such as a raw Modbus holding register read
+type RegisterParser struct {
+	// ReadingNames are the readings to decode. When empty, all readings are decoded.
+	ReadingNames []string
+	// Layout describes the values held in the register block
+	Layout []RegisterField
+}
+
+// ParseKeyValues replaces each selected reading with a reading for each of the key/value pairs in its value.
+// This function returns an Event
+func (p KeyValueParser) ParseKeyValues(edgexcontext *appcontext.Context, params ...interface{}) (continuePipeline bool, result interface{}) {
+	pairSeparator := p.PairSeparator
+	if pairSeparator == "" {
+		pairSeparator = ","
+	}
+	keyValueSeparator := p.KeyValueSeparator
+	if keyValueSeparator == "" {
+		keyValueSeparator = "="
+	}
+
+	return parseStringReadings(params, p.ReadingNames, func(value string) ([]models.Reading, error) {
+		var readings []models.Reading
+		for _, pair := range strings.Split(value, pairSeparator) {
+			if strings.TrimSpace(pair) == "" {
+				continue
+			}
+			parts := strings.SplitN(pair, keyValueSeparator, 2)
+			if len(parts) != 2 || strings.TrimSpace(parts[0]) == "" {
+				return nil, fmt.Errorf("malformed key/value pair '%s'", pair)
+			}
+			readings = append(readings, models.Reading{Name: strings.TrimSpace(parts[0]), Value: strings.TrimSpace(parts[1])})
+		}
+		return readings, nil
+	})
+}
+
+// ParseNMEA replaces each selected reading with readings for the position and fix details in its NMEA sentence.
+// GGA sentences produce latitude, longitude, altitude, fixQuality, satellites and hdop readings and RMC sentences
+// produce latitude, longitude, speed (in knots), course and status readings. Fields empty in the sentence are omitted.
+// This function returns an Event
+func (p NMEAParser) ParseNMEA(edgexcontext *appcontext.Context, params ...interface{}) (continuePipeline bool, result interface{}) {
+	return parseStringReadings(params, p.ReadingNames, parseNMEASentence)
+}
+
+// ParseRegisters replaces each selected reading with a reading for each field of the layout, decoded from the
+// register block in its value.
+// This function returns an Event
+func (p RegisterParser) ParseRegisters(edgexcontext *appcontext.Context, params ...interface{}) (continuePipeline bool, result interface{}) {
+	return parseStringReadings(params, p.ReadingNames, func(value string) ([]models.Reading, error) {
+		block, err := hex.DecodeString(strings.Replace(value, " ", "", -1))
+		if err != nil {
+			return nil, fmt.Errorf("invalid hex register block: %v", err)
+		}
+
+		readings := make([]models.Reading, 0, len(p.Layout))
+		for _, field := range p.Layout {
+			fieldValue, err := decodeRegisterField(block, field)
+			if err != nil {
+				return nil, err
+			}
+			readings = append(readings, models.Reading{Name: field.Name, Value: fieldValue})
+		}
+		return readings, nil
+	})
+}
+
+// parseStringReadings replaces each reading selected by readingNames with the readings decoded from its value.
+// Decoded readings inherit the device and timestamps of the reading they are decoded from.
+func parseStringReadings(params []interface{}, readingNames []string, decode func(value string) ([]models.Reading, error)) (bool, interface{}) {
+	if len(params) < 1 {
+		return false, errors.New("No Event Received")
+	}
+
+	event, ok := params[0].(models.Event)
+	if !ok {
+		return false, errors.New("Unexpected type received, expecting models.Event")
+	}
+
+	readings := make([]models.Reading, 0, len(event.Readings))
+	for _, reading := range event.Readings {
+		if !readingSelected(reading.Name, readingNames) {
+			readings = append(readings, reading)
+			continue
+		}
+
+		decoded, err := decode(reading.Value)
+		if err != nil {
+			return false, fmt.Errorf("unable to parse reading '%s': %v", reading.Name, err)
+		}
+		for _, decodedReading := range decoded {
+			decodedReading.Device = reading.Device
+			decodedReading.Origin = reading.Origin
+			decodedReading.Created = reading.Created
+			readings = append(readings, decodedReading)
+		}
+	}
+	event.Readings = readings
+
+	return true, event
+}
+
+func readingSelected(name string, readingNames []string) bool {
+	if len(readingNames) == 0 {
+		return true
+	}
+	for _, readingName := range readingNames {
+		if name == readingName {
+			return true
+		}
+	}
+	return false
+}
+
+func parseNMEASentence(sentence string) ([]models.Reading, error) {
+	sentence = strings.TrimSpace(sentence)
+	if !strings.HasPrefix(sentence, "$") {
+		return nil, errors.New("NMEA sentence must start with '$'")
+	}
+
+	body := sentence[1:]
+	if index := strings.LastIndex(body, "*"); index >= 0 {
+		expected, err := strconv.ParseUint(body[index+1:], 16, 8)
+		if err != nil {
+			return nil, fmt.Errorf("invalid NMEA checksum '%s'", body[index+1:])
+		}
+		body = body[:index]
+		var checksum byte
+		for i := 0; i < len(body); i++ {
+			checksum ^= body[i]
+		}
+		if checksum != byte(expected) {
+			return nil, fmt.Errorf("NMEA checksum mismatch, expected %02X but calculated %02X", expected, checksum)
+		}
+	}
+
+	fields := strings.Split(body, ",")
+	if len(fields[0]) != 5 {
+		return nil, fmt.Errorf("invalid NMEA sentence address '%s'", fields[0])
+	}
+
+	var names []string
+	var values []string
+	add := func(name string, value string) {
+		if value != "" {
+			names = append(names, name)
+			values = append(values, value)
+		}
+	}
+
+	// the address is a two character talker ID, i.e. GP or GN, followed by the sentence type
+	switch sentenceType := fields[0][2:]; sentenceType {
+	case "GGA":
+		if len(fields) < 10 {
+			return nil, errors.New("GGA sentence has too few fields")
+		}
+		latitude, longitude, err := nmeaPosition(fields[2], fields[3], fields[4], fields[5])
+		if err != nil {
+			return nil, err
+		}
+		add("latitude", latitude)
+		add("longitude", longitude)
+		add("fixQuality", fields[6])
+		add("satellites", fields[7])
+		add("hdop", fields[8])
+		add("altitude", fields[9])
+
+	case "RMC":
+		if len(fields) < 9 {
+			return nil, errors.New("RMC sentence has too few fields")
+		}
+		latitude, longitude, err := nmeaPosition(fields[3], fields[4], fields[5], fields[6])
+		if err != nil {
+			return nil, err
+		}
+		add("status", fields[2])
+		add("latitude", latitude)
+		add("longitude", longitude)
+		add("speed", fields[7])
+		add("course", fields[8])
+
+	default:
+		return nil, fmt.Errorf("unsupported NMEA sentence type '%s'", sentenceType)
+	}
+
+	readings := make([]models.Reading, len(names))
+	for index := range names {
+		readings[index] = models.Reading{Name: names[index], Value: values[index]}
+	}
+	return readings, nil
+}
+
+// nmeaPosition converts NMEA ddmm.mmmm/dddmm.mmmm coordinates to signed decimal degrees
+func nmeaPosition(latitude string, north string, longitude string, east string) (string, string, error) {
+	if latitude == "" || longitude == "" {
+		return "", "", nil
+	}
+
+	lat, err := nmeaDegrees(latitude, north == "S")
+	if err != nil {
+		return "", "", err
+	}
+	lon, err := nmeaDegrees(longitude, east == "W")
+	if err != nil {
+		return "", "", err
+	}
+	return lat, lon, nil
+}
+
+func nmeaDegrees(value string, negative bool) (string, error) {
+	raw, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return "", fmt.Errorf("invalid NMEA coordinate '%s'", value)
+	}
+
+	degrees := math.Floor(raw / 100)
+	degrees += (raw - degrees*100) / 60
+	if negative {
+		degrees = -degrees
+	}
+	return strconv.FormatFloat(degrees, 'f', 6, 64), nil
+}
+
+func decodeRegisterField(block []byte, field RegisterField) (string, error) {
+	size := 2
+	if field.Type != RegisterTypeUint16 && field.Type != RegisterTypeInt16 {
+		size = 4
+	}
+	offset := field.Register * 2
+	if field.Register < 0 || offset+size > len(block) {
+		return "", fmt.Errorf("register %d of field '%s' is outside the %d register block", field.Register, field.Name, len(block)/2)
+	}
+
+	data := make([]byte, size)
+	copy(data, block[offset:offset+size])
+	if size == 4 && field.WordSwap {
+		data[0], data[1], data[2], data[3] = data[2], data[3], data[0], data[1]
+	}
+
+	var value float64
+	switch field.Type {
+	case RegisterTypeUint16:
+		value = float64(binary.BigEndian.Uint16(data))
+	case RegisterTypeInt16:
+		value = float64(int16(binary.BigEndian.Uint16(data)))
+	case RegisterTypeUint32:
+		value = float64(binary.BigEndian.Uint32(data))
+	case RegisterTypeInt32:
+		value = float64(int32(binary.BigEndian.Uint32(data)))
+	case RegisterTypeFloat32:
+		value = float64(math.Float32frombits(binary.BigEndian.Uint32(data)))
+	default:
+		return "", fmt.Errorf("unsupported register type '%s' for field '%s'", field.Type, field.Name)
+	}
+
+	if field.Scale == 0 || field.Scale == 1 {
+		if field.Type == RegisterTypeFloat32 {
+			return strconv.FormatFloat(value, 'f', -1, 32), nil
+		}
+		return strconv.FormatFloat(value, 'f', -1, 64), nil
+	}
+
+	// round to 12 significant digits so scaling doesn't introduce artifacts such as 21.500000000000004
+	scaled, _ := strconv.ParseFloat(strconv.FormatFloat(value*field.Scale, 'g', 12, 64), 64)
+	return strconv.FormatFloat(scaled, 'f', -1, 64), nil
+}
@@ -0,0 +1,137 @@
+//
+// Copyright (c) 2019 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	"testing"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func readingValues(event models.Event) map[string]string {
+	values := map[string]string{}
+	for _, reading := range event.Readings {
+		values[reading.Name] = reading.Value
+	}
+	return values
+}
+
+func TestParseKeyValues(t *testing.T) {
+	eventIn := models.Event{
+		Device: devID1,
+		Readings: []models.Reading{
+			{Name: "status", Device: devID1, Value: "temp=21.5; humidity = 40;", Created: 10},
+			{Name: readingName1, Device: devID1, Value: readingValue1},
+		},
+	}
+
+	parser := KeyValueParser{ReadingNames: []string{"status"}, PairSeparator: ";"}
+	continuePipeline, result := parser.ParseKeyValues(context, eventIn)
+	require.True(t, continuePipeline)
+
+	event := result.(models.Event)
+	require.Equal(t, 3, len(event.Readings))
+	assert.Equal(t, map[string]string{"temp": "21.5", "humidity": "40", readingName1: readingValue1}, readingValues(event))
+	assert.Equal(t, int64(10), event.Readings[0].Created, "Decoded readings should inherit the timestamps")
+	assert.Equal(t, devID1, event.Readings[0].Device, "Decoded readings should inherit the device")
+}
+
+func TestParseKeyValuesMalformed(t *testing.T) {
+	eventIn := models.Event{Readings: []models.Reading{{Name: "status", Value: "temp:21.5"}}}
+	continuePipeline, result := KeyValueParser{}.ParseKeyValues(context, eventIn)
+	assert.False(t, continuePipeline)
+	assert.Error(t, result.(error))
+}
+
+func TestParseNMEAGGA(t *testing.T) {
+	eventIn := models.Event{Readings: []models.Reading{
+		{Name: "gps", Value: "$GPGGA,123519,4807.038,N,01131.000,E,1,08,0.9,545.4,M,46.9,M,,*47"},
+	}}
+
+	continuePipeline, result := NMEAParser{}.ParseNMEA(context, eventIn)
+	require.True(t, continuePipeline, "Unexpected error: %v", result)
+	assert.Equal(t, map[string]string{
+		"latitude":   "48.117300",
+		"longitude":  "11.516667",
+		"fixQuality": "1",
+		"satellites": "08",
+		"hdop":       "0.9",
+		"altitude":   "545.4",
+	}, readingValues(result.(models.Event)))
+}
+
+func TestParseNMEARMC(t *testing.T) {
+	eventIn := models.Event{Readings: []models.Reading{
+		{Name: "gps", Value: "$GPRMC,123519,A,4807.038,N,01131.000,W,022.4,084.4,230394,003.1,W*78"},
+	}}
+
+	continuePipeline, result := NMEAParser{ReadingNames: []string{"gps"}}.ParseNMEA(context, eventIn)
+	require.True(t, continuePipeline, "Unexpected error: %v", result)
+	values := readingValues(result.(models.Event))
+	assert.Equal(t, "-11.516667", values["longitude"])
+	assert.Equal(t, "022.4", values["speed"])
+	assert.Equal(t, "A", values["status"])
+}
+
+func TestParseNMEAChecksumMismatch(t *testing.T) {
+	eventIn := models.Event{Readings: []models.Reading{
+		{Name: "gps", Value: "$GPGGA,123519,4807.038,N,01131.000,E,1,08,0.9,545.4,M,46.9,M,,*48"},
+	}}
+
+	continuePipeline, result := NMEAParser{}.ParseNMEA(context, eventIn)
+	assert.False(t, continuePipeline)
+	assert.Contains(t, result.(error).Error(), "checksum mismatch")
+}
+
+func TestParseRegisters(t *testing.T) {
+	eventIn := models.Event{Readings: []models.Reading{
+		{Name: "holding", Value: "00D7 FFFE 41AC 0000 0000 0001"},
+	}}
+
+	parser := RegisterParser{Layout: []RegisterField{
+		{Name: "temperature", Register: 0, Type: RegisterTypeUint16, Scale: 0.1},
+		{Name: "offset", Register: 1, Type: RegisterTypeInt16},
+		{Name: "pressure", Register: 2, Type: RegisterTypeFloat32},
+		{Name: "counter", Register: 4, Type: RegisterTypeUint32, WordSwap: true},
+	}}
+
+	continuePipeline, result := parser.ParseRegisters(context, eventIn)
+	require.True(t, continuePipeline, "Unexpected error: %v", result)
+	assert.Equal(t, map[string]string{
+		"temperature": "21.5",
+		"offset":      "-2",
+		"pressure":    "21.5",
+		"counter":     "65536",
+	}, readingValues(result.(models.Event)))
+}
+
+func TestParseRegistersOutOfRange(t *testing.T) {
+	eventIn := models.Event{Readings: []models.Reading{{Name: "holding", Value: "00D7"}}}
+	parser := RegisterParser{Layout: []RegisterField{{Name: "counter", Register: 0, Type: RegisterTypeUint32}}}
+
+	continuePipeline, result := parser.ParseRegisters(context, eventIn)
+	assert.False(t, continuePipeline)
+	assert.Error(t, result.(error))
+}
+
+func TestParseNoEvent(t *testing.T) {
+	continuePipeline, result := NMEAParser{}.ParseNMEA(context)
+	assert.False(t, continuePipeline)
+	assert.Equal(t, "No Event Received", result.(error).Error())
+}
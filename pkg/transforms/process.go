@@ -0,0 +1,163 @@
+//
+// Copyright (c) 2019 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	"bytes"
+	syscontext "context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/antoniomtz/app-functions-sdk-go/appcontext"
+)
+
+const (
+	// maxProcessOutput is the size of the standard output of a command beyond which it fails, so a runaway command
+	// can't use up the memory
+	maxProcessOutput = 32 * 1024 * 1024
+	// maxProcessErrorOutput is the size of the standard error of a command kept for the error returned when it fails
+	maxProcessErrorOutput = 64 * 1024
+)
+
+// ProcessTransform pipes the data of the pipeline to an external executable and reads the transformed data from its
+// standard output, so existing analytics, i.e. Python or C programs, can be reused. A process is started per call.
+type ProcessTransform struct {
+	command string
+	args    []string
+	timeout time.Duration
+}
+
+// NewProcessTransform creates a ProcessTransform running the command with the args, which is killed after timeout
+// unless it's zero. The command is looked up in the PATH when it doesn't contain a path separator.
+func NewProcessTransform(command string, args []string, timeout time.Duration) (*ProcessTransform, error) {
+	if command == "" {
+		return nil, errors.New("command must be specified")
+	}
+	if timeout < 0 {
+		return nil, errors.New("command timeout must not be negative")
+	}
+	path, err := exec.LookPath(command)
+	if err != nil {
+		return nil, fmt.Errorf("command '%s' not found: %v", command, err)
+	}
+	return &ProcessTransform{command: path, args: args, timeout: timeout}, nil
+}
+
+// TransformWithProcess writes the data from the previous function, an Event or a TaggedEvent serialized as JSON, or a
+// string or []byte written as is, to the standard input of the command. The process is killed when the service stops.
+// This function returns the standard output of the command as a []byte, and stops the pipeline when it's empty.
+// It returns an error when the command exits with a non-zero status, including its standard error, times out, or writes
+// more than 32 MiB. The processes started by the command are killed along with it, except on Windows.
+func (p *ProcessTransform) TransformWithProcess(edgexcontext *appcontext.Context, params ...interface{}) (bool, interface{}) {
+	if len(params) < 1 {
+		return false, errors.New("No Data Received")
+	}
+
+	var input []byte
+	switch data := params[0].(type) {
+	case []byte:
+		input = data
+	case string:
+		input = []byte(data)
+	default:
+		event, ok := eventOrTaggedEvent(data)
+		if !ok {
+			return false, errors.New("Unexpected type received, expecting models.Event, string or []byte")
+		}
+		var err error
+		if input, err = json.Marshal(event); err != nil {
+			return false, fmt.Errorf("unable to marshal event for command: %v", err)
+		}
+	}
+
+	ctx, cancel := syscontext.WithCancel(edgexcontext.RequestContext())
+	defer cancel()
+	if p.timeout > 0 {
+		ctx, cancel = syscontext.WithTimeout(ctx, p.timeout)
+		defer cancel()
+	}
+
+	// the command is stopped as soon as its output exceeds the limit
+	stdout := &limitedBuffer{limit: maxProcessOutput, exceeded: cancel}
+	stderr := &limitedBuffer{limit: maxProcessErrorOutput}
+	cmd := exec.Command(p.command, p.args...)
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	startProcessGroup(cmd)
+	if err := cmd.Start(); err != nil {
+		return false, fmt.Errorf("command '%s' failed to start: %v", p.command, err)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- cmd.Wait()
+	}()
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		// the whole process group is killed, as processes started by the command would otherwise keep running and
+		// hold its output open, leaving Wait blocked
+		killProcessGroup(cmd)
+		err = <-done
+	}
+
+	if stdout.truncated {
+		return false, fmt.Errorf("command '%s' output exceeds %d bytes", p.command, maxProcessOutput)
+	}
+	if err != nil {
+		if ctx.Err() == syscontext.DeadlineExceeded {
+			return false, fmt.Errorf("command '%s' timed out after %v", p.command, p.timeout)
+		}
+		if message := strings.TrimSpace(stderr.buffer.String()); message != "" {
+			return false, fmt.Errorf("command '%s' failed: %v: %s", p.command, err, message)
+		}
+		return false, fmt.Errorf("command '%s' failed: %v", p.command, err)
+	}
+
+	if stdout.buffer.Len() == 0 {
+		return false, nil
+	}
+	return true, stdout.buffer.Bytes()
+}
+
+// limitedBuffer holds up to limit bytes of the output of a command, discarding the rest so the command isn't blocked
+// writing it. The buffer isn't embedded, as its ReadFrom would bypass the limit when the output is copied.
+type limitedBuffer struct {
+	buffer    bytes.Buffer
+	limit     int
+	truncated bool
+	// exceeded, when set, is called once the output exceeds the limit
+	exceeded func()
+}
+
+func (output *limitedBuffer) Write(data []byte) (int, error) {
+	if remaining := output.limit - output.buffer.Len(); len(data) > remaining {
+		output.buffer.Write(data[:remaining])
+		if !output.truncated && output.exceeded != nil {
+			output.exceeded()
+		}
+		output.truncated = true
+		return len(data), nil
+	}
+	return output.buffer.Write(data)
+}
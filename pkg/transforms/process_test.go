@@ -0,0 +1,115 @@
+//
+// Copyright (c) 2019 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransformWithProcess(t *testing.T) {
+	p, err := NewProcessTransform("sh", []string{"-c", "tr a-z A-Z"}, time.Second)
+	require.NoError(t, err)
+
+	continuePipeline, result := p.TransformWithProcess(context, "payload")
+	require.True(t, continuePipeline, "%v", result)
+	assert.Equal(t, []byte("PAYLOAD"), result)
+
+	p, err = NewProcessTransform("cat", nil, 0)
+	require.NoError(t, err)
+
+	event := models.Event{Device: devID1, Readings: []models.Reading{{Name: readingName1, Value: readingValue1}}}
+	expected, err := json.Marshal(event)
+	require.NoError(t, err)
+	continuePipeline, result = p.TransformWithProcess(context, event)
+	require.True(t, continuePipeline, "%v", result)
+	assert.Equal(t, expected, result)
+
+	continuePipeline, result = p.TransformWithProcess(context, 42)
+	assert.False(t, continuePipeline)
+	assert.EqualError(t, result.(error), "Unexpected type received, expecting models.Event, string or []byte")
+}
+
+func TestTransformWithProcessEmptyOutput(t *testing.T) {
+	p, err := NewProcessTransform("sh", []string{"-c", "cat > /dev/null"}, time.Second)
+	require.NoError(t, err)
+
+	continuePipeline, result := p.TransformWithProcess(context, []byte("payload"))
+	assert.False(t, continuePipeline)
+	assert.Nil(t, result)
+}
+
+func TestTransformWithProcessFailure(t *testing.T) {
+	p, err := NewProcessTransform("sh", []string{"-c", "echo 'invalid payload' >&2; exit 3"}, time.Second)
+	require.NoError(t, err)
+
+	continuePipeline, result := p.TransformWithProcess(context, "payload")
+	assert.False(t, continuePipeline)
+	require.Error(t, result.(error))
+	assert.Contains(t, result.(error).Error(), "exit status 3: invalid payload")
+}
+
+func TestTransformWithProcessTimeout(t *testing.T) {
+	p, err := NewProcessTransform("sleep", []string{"5"}, 50*time.Millisecond)
+	require.NoError(t, err)
+
+	start := time.Now()
+	continuePipeline, result := p.TransformWithProcess(context, "payload")
+	assert.False(t, continuePipeline)
+	require.Error(t, result.(error))
+	assert.Contains(t, result.(error).Error(), "timed out after 50ms")
+	assert.True(t, time.Since(start) < 5*time.Second, "The process should be killed")
+}
+
+func TestTransformWithProcessTimeoutKillsChildren(t *testing.T) {
+	// the child sleep holds the output of the command open after the shell is killed
+	p, err := NewProcessTransform("sh", []string{"-c", "sleep 5 & sleep 5"}, 50*time.Millisecond)
+	require.NoError(t, err)
+
+	start := time.Now()
+	continuePipeline, result := p.TransformWithProcess(context, "payload")
+	assert.False(t, continuePipeline)
+	require.Error(t, result.(error))
+	assert.Contains(t, result.(error).Error(), "timed out after 50ms")
+	assert.True(t, time.Since(start) < 4*time.Second, "The processes started by the command should be killed")
+}
+
+func TestTransformWithProcessOutputLimit(t *testing.T) {
+	p, err := NewProcessTransform("yes", nil, 10*time.Second)
+	require.NoError(t, err)
+
+	start := time.Now()
+	continuePipeline, result := p.TransformWithProcess(context, "payload")
+	assert.False(t, continuePipeline)
+	require.Error(t, result.(error))
+	assert.Contains(t, result.(error).Error(), "output exceeds 33554432 bytes")
+	assert.True(t, time.Since(start) < 10*time.Second, "The command should be killed once its output exceeds the limit")
+}
+
+func TestNewProcessTransformErrors(t *testing.T) {
+	_, err := NewProcessTransform("", nil, 0)
+	assert.EqualError(t, err, "command must be specified")
+	_, err = NewProcessTransform("does-not-exist-command", nil, 0)
+	assert.Error(t, err)
+	_, err = NewProcessTransform("cat", nil, -time.Second)
+	assert.EqualError(t, err, "command timeout must not be negative")
+}
@@ -0,0 +1,166 @@
+//
+// Copyright (c) 2019 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/antoniomtz/app-functions-sdk-go/appcontext"
+	"github.com/edgexfoundry/go-mod-core-contracts/models"
+)
+
+// Pseudonymizer replaces device names with opaque random identifiers. The mapping from device names to
+// pseudonyms is persisted to a file so a device always maps to the same pseudonym, and the data can be
+// re-identified locally.
+type Pseudonymizer struct {
+	mappingFile string
+	mutex       sync.Mutex
+	pseudonyms  map[string]string
+	devices     map[string]string
+}
+
+// NewPseudonymizer creates a pseudonymizer persisting its mapping to mappingFile, loading any existing mapping
+func NewPseudonymizer(mappingFile string) (*Pseudonymizer, error) {
+	if mappingFile == "" {
+		return nil, errors.New("pseudonym mapping file must be specified")
+	}
+
+	p := &Pseudonymizer{
+		mappingFile: mappingFile,
+		pseudonyms:  map[string]string{},
+		devices:     map[string]string{},
+	}
+
+	contents, err := ioutil.ReadFile(mappingFile)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("unable to read pseudonym mapping file: %v", err)
+	}
+	if len(contents) > 0 {
+		if err := json.Unmarshal(contents, &p.pseudonyms); err != nil {
+			return nil, fmt.Errorf("unable to parse pseudonym mapping file '%s': %v", mappingFile, err)
+		}
+	}
+	for device, pseudonym := range p.pseudonyms {
+		p.devices[pseudonym] = device
+	}
+
+	return p, nil
+}
+
+// Pseudonymize replaces the device name of the event from the previous function, and of its readings, with the
+// device's pseudonym. A pseudonym is created and persisted the first time a device is seen.
+// This function returns an Event
+func (p *Pseudonymizer) Pseudonymize(edgexcontext *appcontext.Context, params ...interface{}) (bool, interface{}) {
+	if len(params) < 1 {
+		return false, errors.New("No Event Received")
+	}
+
+	event, ok := params[0].(models.Event)
+	if !ok {
+		return false, errors.New("Unexpected type received, expecting models.Event")
+	}
+
+	pseudonym, err := p.pseudonym(event.Device)
+	if err != nil {
+		return false, err
+	}
+
+	event.Device = pseudonym
+	readings := make([]models.Reading, len(event.Readings))
+	for index, reading := range event.Readings {
+		reading.Device = pseudonym
+		readings[index] = reading
+	}
+	event.Readings = readings
+
+	return true, event
+}
+
+// Reidentify returns the device name for a pseudonym, and whether the pseudonym is known
+func (p *Pseudonymizer) Reidentify(pseudonym string) (string, bool) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	device, ok := p.devices[pseudonym]
+	return device, ok
+}
+
+func (p *Pseudonymizer) pseudonym(device string) (string, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if pseudonym, ok := p.pseudonyms[device]; ok {
+		return pseudonym, nil
+	}
+
+	pseudonym, err := newUUID()
+	if err != nil {
+		return "", err
+	}
+
+	p.pseudonyms[device] = pseudonym
+	if err := p.save(); err != nil {
+		// the pseudonym can't be used unless it's persisted, otherwise the device would map to a new one later
+		delete(p.pseudonyms, device)
+		return "", fmt.Errorf("unable to persist pseudonym mapping: %v", err)
+	}
+	p.devices[pseudonym] = device
+
+	return pseudonym, nil
+}
+
+// save writes the mapping to a temporary file which replaces the mapping file, so an interrupted write doesn't
+// lose the existing mapping. The caller must hold the mutex.
+func (p *Pseudonymizer) save() error {
+	contents, err := json.MarshalIndent(p.pseudonyms, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	temp, err := ioutil.TempFile(filepath.Dir(p.mappingFile), filepath.Base(p.mappingFile)+".tmp")
+	if err != nil {
+		return err
+	}
+	if _, err := temp.Write(contents); err != nil {
+		temp.Close()
+		os.Remove(temp.Name())
+		return err
+	}
+	if err := temp.Close(); err != nil {
+		os.Remove(temp.Name())
+		return err
+	}
+	return os.Rename(temp.Name(), p.mappingFile)
+}
+
+// newUUID returns a random (version 4) UUID
+func newUUID() (string, error) {
+	uuid := make([]byte, 16)
+	if _, err := rand.Read(uuid); err != nil {
+		return "", err
+	}
+	uuid[6] = uuid[6]&0x0f | 0x40
+	uuid[8] = uuid[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", uuid[0:4], uuid[4:6], uuid[6:8], uuid[8:10], uuid[10:]), nil
+}
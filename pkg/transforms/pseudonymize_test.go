@@ -0,0 +1,69 @@
+//
+// Copyright (c) 2019 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPseudonymize(t *testing.T) {
+	dir, err := ioutil.TempDir("", "pseudonyms")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	mappingFile := filepath.Join(dir, "pseudonyms.json")
+
+	pseudonymizer, err := NewPseudonymizer(mappingFile)
+	require.NoError(t, err)
+
+	event := models.Event{Device: devID1, Readings: []models.Reading{{Name: readingName1, Value: readingValue1, Device: devID1}}}
+	continuePipeline, result := pseudonymizer.Pseudonymize(context, event)
+	require.True(t, continuePipeline, "Unexpected error: %v", result)
+
+	pseudonymized := result.(models.Event)
+	assert.Regexp(t, regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`), pseudonymized.Device)
+	assert.Equal(t, pseudonymized.Device, pseudonymized.Readings[0].Device)
+	assert.Equal(t, devID1, event.Readings[0].Device, "Readings of the received event should not be modified")
+
+	_, other := pseudonymizer.Pseudonymize(context, models.Event{Device: devID2})
+	assert.NotEqual(t, pseudonymized.Device, other.(models.Event).Device, "Devices should have distinct pseudonyms")
+
+	device, ok := pseudonymizer.Reidentify(pseudonymized.Device)
+	assert.True(t, ok)
+	assert.Equal(t, devID1, device)
+
+	reloaded, err := NewPseudonymizer(mappingFile)
+	require.NoError(t, err)
+	_, result = reloaded.Pseudonymize(context, event)
+	assert.Equal(t, pseudonymized.Device, result.(models.Event).Device, "Pseudonyms should persist across restarts")
+}
+
+func TestPseudonymizeNoEvent(t *testing.T) {
+	pseudonymizer, err := NewPseudonymizer(filepath.Join(os.TempDir(), "unused-pseudonyms.json"))
+	require.NoError(t, err)
+
+	continuePipeline, result := pseudonymizer.Pseudonymize(context)
+	assert.False(t, continuePipeline)
+	assert.EqualError(t, result.(error), "No Event Received")
+}
@@ -0,0 +1,305 @@
+//
+// Copyright (c) 2019 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/antoniomtz/app-functions-sdk-go/appcontext"
+	"github.com/edgexfoundry/go-mod-core-contracts/clients"
+)
+
+const (
+	pubSubEndpoint    = "https://pubsub.googleapis.com"
+	pubSubScope       = "https://www.googleapis.com/auth/pubsub"
+	googleTokenURL    = "https://oauth2.googleapis.com/token"
+	jwtBearerGrant    = "urn:ietf:params:oauth:grant-type:jwt-bearer"
+	pubSubMaxMessages = 1000
+)
+
+// gcpServiceAccount holds the fields of a GCP service account JSON key required to obtain access tokens
+type gcpServiceAccount struct {
+	ProjectID   string `json:"project_id"`
+	PrivateKey  string `json:"private_key"`
+	ClientEmail string `json:"client_email"`
+	TokenURI    string `json:"token_uri"`
+}
+
+type pubSubMessage struct {
+	Data       string            `json:"data"`
+	Attributes map[string]string `json:"attributes,omitempty"`
+}
+
+// GCPPubSubSender publishes pipeline data to a Google Cloud Pub/Sub topic using the Pub/Sub REST API
+type GCPPubSubSender struct {
+	ProjectID string
+	Topic     string
+
+	endpoint   string
+	account    gcpServiceAccount
+	signingKey *rsa.PrivateKey
+	httpClient *http.Client
+	batch      exportBatch
+	// mutex guards the cached access token
+	mutex       sync.Mutex
+	token       string
+	tokenExpiry time.Time
+}
+
+// NewGCPPubSubSender creates a sender for the specified topic authenticated with the service account JSON key
+// provided in credentials. The project is taken from the service account.
+func NewGCPPubSubSender(credentials []byte, topic string, batchSize int, batchTimeout time.Duration) (*GCPPubSubSender, error) {
+	account := gcpServiceAccount{}
+	if err := json.Unmarshal(credentials, &account); err != nil {
+		return nil, fmt.Errorf("unable to parse GCP service account credentials: %v", err)
+	}
+	if account.ClientEmail == "" || account.PrivateKey == "" {
+		return nil, errors.New("GCP service account credentials must contain client_email and private_key")
+	}
+	if account.TokenURI == "" {
+		account.TokenURI = googleTokenURL
+	}
+
+	signingKey, err := parseRSAPrivateKey(account.PrivateKey)
+	if err != nil {
+		return nil, err
+	}
+
+	sender := &GCPPubSubSender{
+		ProjectID:  account.ProjectID,
+		Topic:      topic,
+		endpoint:   pubSubEndpoint,
+		account:    account,
+		signingKey: signingKey,
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+	}
+	sender.batch = exportBatch{name: "GCP Pub/Sub", size: batchSize, timeout: batchTimeout, export: sender.publishBatch}
+	return sender, nil
+}
+
+// SetBatchFailureHandler sets the handler given the data of the events held in a batch which failed to be published
+// after the calls holding them returned, i.e. to store it for store and forward. Without a handler the data is kept
+// and published with the next batch.
+func (sender *GCPPubSubSender) SetBatchFailureHandler(handler BatchFailureHandler) {
+	sender.batch.failed = handler
+}
+
+// PubSubSend publishes data from the previous function to the configured Pub/Sub topic. When batching is enabled
+// the data is held until the batch is full or the batch timeout expires, and the pipeline stops for the events
+// whose data is held.
+func (sender *GCPPubSubSender) PubSubSend(edgexcontext *appcontext.Context, params ...interface{}) (bool, interface{}) {
+	if len(params) < 1 {
+		// We didn't receive a result
+		return false, errors.New("No Data Received")
+	}
+
+	data, err := coerceToBytes(params[0])
+	if err != nil {
+		return false, err
+	}
+
+	message := pubSubMessage{Data: base64.StdEncoding.EncodeToString(data)}
+	if edgexcontext.CorrelationID != "" {
+		message.Attributes = map[string]string{clients.CorrelationHeader: edgexcontext.CorrelationID}
+	}
+
+	exported, err := sender.batch.add(edgexcontext, params[0], message)
+	if err != nil {
+		return false, err
+	}
+	if !exported {
+		return false, nil
+	}
+
+	edgexcontext.LoggingClient.Info("Sent data to GCP Pub/Sub")
+	edgexcontext.LoggingClient.Trace("Data exported", "Transport", "GCP Pub/Sub", clients.CorrelationHeader, edgexcontext.CorrelationID)
+
+	return true, nil
+}
+
+// PendingEvents returns the number of events held in an incomplete batch, which have not been exported yet
+func (sender *GCPPubSubSender) PendingEvents() int {
+	return sender.batch.pending()
+}
+
+// Flush publishes the messages held in an incomplete batch to GCP Pub/Sub, i.e. before the service stops
+func (sender *GCPPubSubSender) Flush() error {
+	return sender.batch.flush()
+}
+
+// publishBatch publishes the messages of the entries in requests of up to pubSubMaxMessages, returning the number of
+// messages published
+func (sender *GCPPubSubSender) publishBatch(entries []batchEntry) (int, error) {
+	messages := make([]pubSubMessage, len(entries))
+	for i, entry := range entries {
+		messages[i] = entry.payload.(pubSubMessage)
+	}
+
+	published := 0
+	for published < len(messages) {
+		count := len(messages) - published
+		if count > pubSubMaxMessages {
+			count = pubSubMaxMessages
+		}
+		if err := sender.publish(messages[published : published+count]); err != nil {
+			return published, err
+		}
+		published += count
+	}
+
+	return published, nil
+}
+
+func (sender *GCPPubSubSender) publish(messages []pubSubMessage) error {
+	token, err := sender.accessToken()
+	if err != nil {
+		return err
+	}
+
+	body, err := json.Marshal(map[string][]pubSubMessage{"messages": messages})
+	if err != nil {
+		return err
+	}
+
+	publishURL := fmt.Sprintf("%s/v1/projects/%s/topics/%s:publish", sender.endpoint, sender.ProjectID, sender.Topic)
+	request, err := http.NewRequest(http.MethodPost, publishURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	request.Header.Set(clients.ContentType, clients.ContentTypeJSON)
+	request.Header.Set("Authorization", "Bearer "+token)
+
+	response, err := sender.httpClient.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		responseBody, _ := ioutil.ReadAll(response.Body)
+		return fmt.Errorf("GCP Pub/Sub publish failed with status %s: %s", response.Status, string(responseBody))
+	}
+
+	return nil
+}
+
+// accessToken returns a cached OAuth2 access token, requesting a new one with a signed JWT assertion when expired
+func (sender *GCPPubSubSender) accessToken() (string, error) {
+	sender.mutex.Lock()
+	defer sender.mutex.Unlock()
+
+	if sender.token != "" && time.Now().Before(sender.tokenExpiry) {
+		return sender.token, nil
+	}
+
+	now := time.Now()
+	assertion, err := signJWT(sender.signingKey, map[string]interface{}{
+		"iss":   sender.account.ClientEmail,
+		"scope": pubSubScope,
+		"aud":   sender.account.TokenURI,
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Hour).Unix(),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	form := url.Values{"grant_type": {jwtBearerGrant}, "assertion": {assertion}}
+	response, err := sender.httpClient.PostForm(sender.account.TokenURI, form)
+	if err != nil {
+		return "", fmt.Errorf("unable to obtain GCP access token: %v", err)
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		responseBody, _ := ioutil.ReadAll(response.Body)
+		return "", fmt.Errorf("unable to obtain GCP access token, status %s: %s", response.Status, string(responseBody))
+	}
+
+	tokenResponse := struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int64  `json:"expires_in"`
+	}{}
+	if err := json.NewDecoder(response.Body).Decode(&tokenResponse); err != nil {
+		return "", fmt.Errorf("unable to parse GCP access token response: %v", err)
+	}
+
+	sender.token = tokenResponse.AccessToken
+	// refresh a minute early so the token doesn't expire in flight
+	sender.tokenExpiry = now.Add(time.Duration(tokenResponse.ExpiresIn)*time.Second - time.Minute)
+
+	return sender.token, nil
+}
+
+func signJWT(key *rsa.PrivateKey, claims map[string]interface{}) (string, error) {
+	header, err := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT"})
+	if err != nil {
+		return "", err
+	}
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+
+	signingInput := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	hash := sha256.Sum256([]byte(signingInput))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, hash[:])
+	if err != nil {
+		return "", err
+	}
+
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+func parseRSAPrivateKey(pemKey string) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode([]byte(strings.TrimSpace(pemKey)))
+	if block == nil {
+		return nil, errors.New("unable to decode PEM private key")
+	}
+
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse private key: %v", err)
+	}
+
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("private key is not an RSA key")
+	}
+
+	return key, nil
+}
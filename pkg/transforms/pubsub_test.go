@@ -0,0 +1,256 @@
+//
+// Copyright (c) 2019 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/antoniomtz/app-functions-sdk-go/appcontext"
+	"github.com/edgexfoundry/go-mod-core-contracts/clients"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type pubSubTestServer struct {
+	*httptest.Server
+	mutex         sync.Mutex
+	tokenRequests int
+	publishes     [][]pubSubMessage
+	// failures is the number of publish requests to fail
+	failures int
+}
+
+func newPubSubTestServer(t *testing.T) *pubSubTestServer {
+	server := &pubSubTestServer{}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		server.mutex.Lock()
+		server.tokenRequests++
+		server.mutex.Unlock()
+		assert.Equal(t, jwtBearerGrant, r.FormValue("grant_type"))
+		assert.NotEmpty(t, r.FormValue("assertion"))
+		w.Write([]byte(`{"access_token":"test-token","expires_in":3600}`))
+	})
+	mux.HandleFunc("/v1/projects/test-project/topics/test-topic:publish", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer test-token", r.Header.Get("Authorization"))
+		body := map[string][]pubSubMessage{}
+		json.NewDecoder(r.Body).Decode(&body)
+		server.mutex.Lock()
+		if server.failures > 0 {
+			server.failures--
+			server.mutex.Unlock()
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		server.publishes = append(server.publishes, body["messages"])
+		server.mutex.Unlock()
+		w.Write([]byte(`{"messageIds":["1"]}`))
+	})
+	server.Server = httptest.NewServer(mux)
+	return server
+}
+
+func newTestPubSubSender(t *testing.T, server *pubSubTestServer, batchSize int, batchTimeout time.Duration) *GCPPubSubSender {
+	key, err := rsa.GenerateKey(rand.Reader, 1024)
+	require.NoError(t, err)
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+
+	credentials, _ := json.Marshal(gcpServiceAccount{
+		ProjectID:   "test-project",
+		PrivateKey:  string(keyPEM),
+		ClientEmail: "sender@test-project.iam.gserviceaccount.com",
+		TokenURI:    server.URL + "/token",
+	})
+
+	sender, err := NewGCPPubSubSender(credentials, "test-topic", batchSize, batchTimeout)
+	require.NoError(t, err)
+	sender.endpoint = server.URL
+	return sender
+}
+
+func TestPubSubSend(t *testing.T) {
+	server := newPubSubTestServer(t)
+	defer server.Close()
+	sender := newTestPubSubSender(t, server, 0, 0)
+
+	context.CorrelationID = "123"
+	continuePipeline, result := sender.PubSubSend(context, "first")
+	assert.True(t, continuePipeline, "Pipeline should continue")
+	assert.Nil(t, result)
+	continuePipeline, _ = sender.PubSubSend(context, []byte("second"))
+	assert.True(t, continuePipeline, "Pipeline should continue")
+
+	require.Equal(t, 2, len(server.publishes), "Each message should be published when batching is disabled")
+	assert.Equal(t, 1, server.tokenRequests, "Access token should be cached")
+	data, _ := base64.StdEncoding.DecodeString(server.publishes[0][0].Data)
+	assert.Equal(t, "first", string(data))
+	assert.Equal(t, "123", server.publishes[0][0].Attributes[clients.CorrelationHeader])
+}
+
+func TestPubSubSendBatched(t *testing.T) {
+	server := newPubSubTestServer(t)
+	defer server.Close()
+	sender := newTestPubSubSender(t, server, 3, 0)
+
+	continuePipeline, result := sender.PubSubSend(context, "1")
+	assert.False(t, continuePipeline, "Pipeline should stop while batching")
+	assert.Nil(t, result)
+	sender.PubSubSend(context, "2")
+	assert.Equal(t, 0, len(server.publishes), "Nothing should be published before the batch is full")
+
+	continuePipeline, _ = sender.PubSubSend(context, "3")
+	assert.True(t, continuePipeline, "Pipeline should continue once batch is published")
+	require.Equal(t, 1, len(server.publishes))
+	assert.Equal(t, 3, len(server.publishes[0]), "Batch should be published in a single request")
+}
+
+func TestPubSubSendBatchTimeout(t *testing.T) {
+	server := newPubSubTestServer(t)
+	defer server.Close()
+	sender := newTestPubSubSender(t, server, 10, 50*time.Millisecond)
+
+	sender.PubSubSend(context, "1")
+	time.Sleep(200 * time.Millisecond)
+
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+	require.Equal(t, 1, len(server.publishes), "Incomplete batch should be published after the timeout")
+	assert.Equal(t, 1, len(server.publishes[0]))
+}
+
+func TestPubSubSendBatchMarksAllEventsAsPushed(t *testing.T) {
+	server := newPubSubTestServer(t)
+	defer server.Close()
+	sender := newTestPubSubSender(t, server, 3, 0)
+
+	var mutex sync.Mutex
+	var marked []string
+	coreData := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPut, r.Method)
+		mutex.Lock()
+		marked = append(marked, r.URL.Path)
+		mutex.Unlock()
+	}))
+	defer coreData.Close()
+
+	for _, id := range []string{"event1", "event2", "event3"} {
+		edgexcontext := newCoreDataContext(coreData.URL)
+		edgexcontext.EventID = id
+		sender.PubSubSend(edgexcontext, id)
+	}
+
+	require.Equal(t, 1, len(server.publishes))
+	mutex.Lock()
+	defer mutex.Unlock()
+	assert.Equal(t, []string{
+		clients.ApiEventRoute + "/id/event3",
+		clients.ApiEventRoute + "/id/event1",
+		clients.ApiEventRoute + "/id/event2",
+	}, marked, "Every event in the batch should be marked as pushed")
+}
+
+func TestPubSubSendBatchTimeoutMarksAsPushedAfterDeadline(t *testing.T) {
+	server := newPubSubTestServer(t)
+	defer server.Close()
+	sender := newTestPubSubSender(t, server, 10, 50*time.Millisecond)
+
+	var mutex sync.Mutex
+	var marked []string
+	coreData := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mutex.Lock()
+		marked = append(marked, r.URL.Path)
+		mutex.Unlock()
+	}))
+	defer coreData.Close()
+
+	for _, id := range []string{"event1", "event2"} {
+		edgexcontext := newCoreDataContext(coreData.URL)
+		edgexcontext.EventID = id
+		// the pipelines of the events have returned by the time the batch is published
+		edgexcontext.Deadline = time.Now().Add(10 * time.Millisecond)
+		sender.PubSubSend(edgexcontext, id)
+	}
+	time.Sleep(300 * time.Millisecond)
+
+	mutex.Lock()
+	defer mutex.Unlock()
+	assert.Equal(t, []string{
+		clients.ApiEventRoute + "/id/event1",
+		clients.ApiEventRoute + "/id/event2",
+	}, marked, "Events of a batch published after their deadline should be marked as pushed")
+}
+
+func TestPubSubSendBatchFailure(t *testing.T) {
+	server := newPubSubTestServer(t)
+	defer server.Close()
+	server.failures = 1
+	sender := newTestPubSubSender(t, server, 3, 0)
+	var failed []interface{}
+	sender.SetBatchFailureHandler(func(edgexcontext *appcontext.Context, data interface{}, err error) bool {
+		failed = append(failed, data)
+		return true
+	})
+
+	for _, data := range []string{"1", "2"} {
+		sender.PubSubSend(&appcontext.Context{LoggingClient: context.LoggingClient}, data)
+	}
+	continuePipeline, result := sender.PubSubSend(&appcontext.Context{LoggingClient: context.LoggingClient}, "3")
+
+	assert.False(t, continuePipeline)
+	assert.Error(t, result.(error), "The event completing the batch should report the failure")
+	assert.Equal(t, []interface{}{"1", "2"}, failed, "The other events of the batch should be given to the handler")
+	assert.Equal(t, 0, sender.PendingEvents())
+}
+
+func TestPubSubSendBatchTimeoutFailureKept(t *testing.T) {
+	server := newPubSubTestServer(t)
+	defer server.Close()
+	server.failures = 1
+	sender := newTestPubSubSender(t, server, 10, 50*time.Millisecond)
+
+	sender.PubSubSend(context, "1")
+	time.Sleep(300 * time.Millisecond)
+
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+	require.Equal(t, 1, len(server.publishes), "A failed batch should be kept and published again without a handler")
+	data, _ := base64.StdEncoding.DecodeString(server.publishes[0][0].Data)
+	assert.Equal(t, "1", string(data))
+	assert.Equal(t, 0, sender.PendingEvents())
+}
+
+func TestPubSubSendNoData(t *testing.T) {
+	sender := &GCPPubSubSender{}
+	continuePipeline, result := sender.PubSubSend(context)
+	assert.False(t, continuePipeline)
+	assert.Equal(t, "No Data Received", result.(error).Error())
+}
+
+func TestNewGCPPubSubSenderInvalidCredentials(t *testing.T) {
+	_, err := NewGCPPubSubSender([]byte(`{"client_email":"a@b.c"}`), "topic", 0, 0)
+	assert.Error(t, err, "Credentials without a private key should be rejected")
+}
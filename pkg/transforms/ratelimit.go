@@ -0,0 +1,114 @@
+//
+// Copyright (c) 2019 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/antoniomtz/app-functions-sdk-go/appcontext"
+	"github.com/edgexfoundry/go-mod-core-contracts/models"
+)
+
+// RateLimiter limits the events forwarded for each device, counting the events of each device exactly, so high
+// frequency devices don't overwhelm cloud quotas
+type RateLimiter struct {
+	every     int
+	maxEvents int
+	window    time.Duration
+	mutex     sync.Mutex
+	devices   map[string]*deviceRate
+}
+
+// deviceRate is the count of the events of a device
+type deviceRate struct {
+	received    int
+	windowStart time.Time
+	forwarded   int
+}
+
+// NewRateLimiter creates a RateLimiter forwarding 1 of every events of each device, and at most maxEvents events of
+// each device per window. Zero disables the respective limit, but at least one must be set.
+func NewRateLimiter(every int, maxEvents int, window time.Duration) (*RateLimiter, error) {
+	if every < 0 || maxEvents < 0 {
+		return nil, errors.New("rate limits must not be negative")
+	}
+	if maxEvents > 0 && window <= 0 {
+		return nil, errors.New("rate limit window must be greater than zero")
+	}
+	if every == 0 && maxEvents == 0 {
+		return nil, errors.New("either 1 of every N events or a maximum number of events per window must be set")
+	}
+
+	return &RateLimiter{
+		every:     every,
+		maxEvents: maxEvents,
+		window:    window,
+		devices:   map[string]*deviceRate{},
+	}, nil
+}
+
+// RateLimit forwards the event from the previous function when its device is within the limits, starting with the
+// first event of each device, and stops the pipeline execution otherwise. The window of a device starts with the
+// first event forwarded after the previous window ended.
+// This function returns an Event
+func (limiter *RateLimiter) RateLimit(edgexcontext *appcontext.Context, params ...interface{}) (bool, interface{}) {
+	if len(params) < 1 {
+		return false, errors.New("No Event Received")
+	}
+
+	event, ok := params[0].(models.Event)
+	if !ok {
+		return false, errors.New("Unexpected type received, expecting models.Event")
+	}
+
+	if !limiter.allows(event.Device, time.Now()) {
+		edgexcontext.LoggingClient.Debug("Event dropped, device rate limit reached", "device", event.Device)
+		return false, nil
+	}
+
+	return true, event
+}
+
+func (limiter *RateLimiter) allows(device string, now time.Time) bool {
+	limiter.mutex.Lock()
+	defer limiter.mutex.Unlock()
+
+	rate, ok := limiter.devices[device]
+	if !ok {
+		rate = &deviceRate{}
+		limiter.devices[device] = rate
+	}
+
+	rate.received++
+	if limiter.every > 0 && (rate.received-1)%limiter.every != 0 {
+		return false
+	}
+
+	if limiter.maxEvents > 0 {
+		if rate.windowStart.IsZero() || now.Sub(rate.windowStart) >= limiter.window {
+			rate.windowStart = now
+			rate.forwarded = 0
+		}
+		if rate.forwarded >= limiter.maxEvents {
+			return false
+		}
+		rate.forwarded++
+	}
+	return true
+}
@@ -0,0 +1,100 @@
+//
+// Copyright (c) 2019 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	"testing"
+	"time"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRateLimitEvery(t *testing.T) {
+	limiter, err := NewRateLimiter(3, 0, 0)
+	require.NoError(t, err)
+
+	var forwarded []int
+	for i := 0; i < 7; i++ {
+		event := models.Event{Device: devID1, Origin: int64(i)}
+		continuePipeline, result := limiter.RateLimit(context, event)
+		if continuePipeline {
+			assert.Equal(t, event, result)
+			forwarded = append(forwarded, i)
+		} else {
+			assert.Nil(t, result)
+		}
+	}
+	assert.Equal(t, []int{0, 3, 6}, forwarded)
+
+	continuePipeline, _ := limiter.RateLimit(context, models.Event{Device: devID2})
+	assert.True(t, continuePipeline, "Devices should be counted separately")
+}
+
+func TestRateLimitWindow(t *testing.T) {
+	limiter, err := NewRateLimiter(0, 2, 50*time.Millisecond)
+	require.NoError(t, err)
+
+	event := models.Event{Device: devID1}
+	for i := 0; i < 2; i++ {
+		continuePipeline, _ := limiter.RateLimit(context, event)
+		assert.True(t, continuePipeline, "Events within the limit should be forwarded")
+	}
+	continuePipeline, _ := limiter.RateLimit(context, event)
+	assert.False(t, continuePipeline, "Events over the limit should be dropped")
+	continuePipeline, _ = limiter.RateLimit(context, models.Event{Device: devID2})
+	assert.True(t, continuePipeline, "Devices should be limited separately")
+
+	time.Sleep(60 * time.Millisecond)
+	continuePipeline, _ = limiter.RateLimit(context, event)
+	assert.True(t, continuePipeline, "Events should be forwarded again in the next window")
+}
+
+func TestRateLimitEveryAndWindow(t *testing.T) {
+	limiter, err := NewRateLimiter(2, 2, time.Minute)
+	require.NoError(t, err)
+
+	forwarded := 0
+	for i := 0; i < 10; i++ {
+		if continuePipeline, _ := limiter.RateLimit(context, models.Event{Device: devID1}); continuePipeline {
+			forwarded++
+		}
+	}
+	assert.Equal(t, 2, forwarded)
+}
+
+func TestNewRateLimiterErrors(t *testing.T) {
+	tests := []struct {
+		name      string
+		every     int
+		maxEvents int
+		window    time.Duration
+		expected  string
+	}{
+		{"no limit", 0, 0, 0, "either 1 of every N events or a maximum number of events per window must be set"},
+		{"negative", -1, 0, 0, "rate limits must not be negative"},
+		{"no window", 0, 10, 0, "rate limit window must be greater than zero"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := NewRateLimiter(test.every, test.maxEvents, test.window)
+			assert.EqualError(t, err, test.expected)
+		})
+	}
+}
@@ -0,0 +1,148 @@
+//
+// Copyright (c) 2019 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/antoniomtz/app-functions-sdk-go/appcontext"
+	"github.com/edgexfoundry/go-mod-core-contracts/clients"
+	"github.com/gomodule/redigo/redis"
+)
+
+// RedisConfig contains the parameters for publishing to Redis
+type RedisConfig struct {
+	// Address of the Redis server, i.e. redis:6379
+	Address  string
+	Database int
+	UseTLS   bool
+	// Credentials provides the password used to AUTH each new connection, while the username is ignored. Connections
+	// are not authenticated without a provider.
+	Credentials CredentialsProvider
+	// Stream is the Redis Stream the data is added to with XADD
+	Stream string
+	// MaxLen caps the length of the stream, trimming old entries approximately. Zero leaves the stream untrimmed.
+	MaxLen int64
+	// Channel is the channel the data is published to when Stream is not set
+	Channel string
+	// MaxIdle and MaxActive are the maximum number of idle and total pooled connections. Zero MaxActive is unlimited.
+	MaxIdle   int
+	MaxActive int
+}
+
+// RedisSender publishes pipeline data to a Redis Stream or channel
+type RedisSender struct {
+	config RedisConfig
+	pool   *redis.Pool
+}
+
+// NewRedisSender creates a sender for the specified Redis configuration. Connections are made as needed and pooled.
+func NewRedisSender(config RedisConfig) (*RedisSender, error) {
+	if config.Address == "" {
+		return nil, errors.New("Redis address must be specified")
+	}
+	if config.Stream == "" && config.Channel == "" {
+		return nil, errors.New("Redis stream or channel must be specified")
+	}
+	if config.MaxIdle <= 0 {
+		config.MaxIdle = 2
+	}
+	if config.Credentials != nil {
+		if _, _, err := config.Credentials.Credentials(); err != nil {
+			return nil, fmt.Errorf("unable to get Redis credentials: %v", err)
+		}
+	}
+
+	pool := &redis.Pool{
+		MaxIdle:     config.MaxIdle,
+		MaxActive:   config.MaxActive,
+		IdleTimeout: 5 * time.Minute,
+		Wait:        true,
+		Dial: func() (redis.Conn, error) {
+			options := []redis.DialOption{
+				redis.DialDatabase(config.Database),
+				redis.DialUseTLS(config.UseTLS),
+				redis.DialConnectTimeout(10 * time.Second),
+			}
+			if config.Credentials != nil {
+				// read on each connection so a rotated password is used once Redis closes the old connections
+				_, password, err := config.Credentials.Credentials()
+				if err != nil {
+					return nil, fmt.Errorf("unable to get Redis credentials: %v", err)
+				}
+				options = append(options, redis.DialPassword(password))
+			}
+			return redis.Dial("tcp", config.Address, options...)
+		},
+		TestOnBorrow: func(conn redis.Conn, idleSince time.Time) error {
+			if time.Since(idleSince) < time.Minute {
+				return nil
+			}
+			_, err := conn.Do("PING")
+			return err
+		},
+	}
+
+	return &RedisSender{config: config, pool: pool}, nil
+}
+
+// Close closes the pooled connections to Redis, i.e. before the service stops
+func (sender *RedisSender) Close() error {
+	return sender.pool.Close()
+}
+
+// RedisSend adds the data from the previous function to the configured Redis Stream, or publishes it to the
+// configured channel. Stream entries contain the data along with the correlation ID and device name.
+func (sender *RedisSender) RedisSend(edgexcontext *appcontext.Context, params ...interface{}) (bool, interface{}) {
+	if len(params) < 1 {
+		// We didn't receive a result
+		return false, errors.New("No Data Received")
+	}
+
+	data, err := coerceToBytes(params[0])
+	if err != nil {
+		return false, err
+	}
+
+	conn := sender.pool.Get()
+	defer conn.Close()
+
+	if sender.config.Stream != "" {
+		args := redis.Args{}.Add(sender.config.Stream)
+		if sender.config.MaxLen > 0 {
+			args = args.Add("MAXLEN", "~", sender.config.MaxLen)
+		}
+		args = args.Add("*", "data", data, clients.CorrelationHeader, edgexcontext.CorrelationID, "device", edgexcontext.DeviceName)
+		if _, err := conn.Do("XADD", args...); err != nil {
+			return false, fmt.Errorf("unable to add data to Redis stream %s: %v", sender.config.Stream, err)
+		}
+	} else {
+		if _, err := conn.Do("PUBLISH", sender.config.Channel, data); err != nil {
+			return false, fmt.Errorf("unable to publish data to Redis channel %s: %v", sender.config.Channel, err)
+		}
+	}
+
+	edgexcontext.LoggingClient.Info("Sent data to Redis")
+	edgexcontext.LoggingClient.Trace("Data exported", "Transport", "Redis", clients.CorrelationHeader, edgexcontext.CorrelationID)
+	if err := edgexcontext.MarkAsPushed(); err != nil {
+		edgexcontext.LoggingClient.Error(err.Error())
+	}
+
+	return true, nil
+}
@@ -0,0 +1,176 @@
+//
+// Copyright (c) 2019 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/clients"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// redisTestServer is a minimal RESP server which records the commands it receives
+type redisTestServer struct {
+	listener net.Listener
+	mutex    sync.Mutex
+	commands [][]string
+}
+
+func newRedisTestServer(t *testing.T) *redisTestServer {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	server := &redisTestServer{listener: listener}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go server.serve(conn)
+		}
+	}()
+	return server
+}
+
+func (server *redisTestServer) serve(conn net.Conn) {
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	for {
+		command, err := readRESPArray(reader)
+		if err != nil {
+			return
+		}
+
+		server.mutex.Lock()
+		server.commands = append(server.commands, command)
+		server.mutex.Unlock()
+
+		switch strings.ToUpper(command[0]) {
+		case "XADD":
+			fmt.Fprint(conn, "$3\r\n1-0\r\n")
+		case "PUBLISH":
+			fmt.Fprint(conn, ":1\r\n")
+		default:
+			fmt.Fprint(conn, "+OK\r\n")
+		}
+	}
+}
+
+func (server *redisTestServer) received(name string) []string {
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+	for _, command := range server.commands {
+		if strings.EqualFold(command[0], name) {
+			return command
+		}
+	}
+	return nil
+}
+
+func readRESPArray(reader *bufio.Reader) ([]string, error) {
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	count, _ := strconv.Atoi(strings.TrimSpace(line[1:]))
+
+	values := make([]string, count)
+	for i := range values {
+		line, err = reader.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		length, _ := strconv.Atoi(strings.TrimSpace(line[1:]))
+		value := make([]byte, length+2)
+		if _, err := io.ReadFull(reader, value); err != nil {
+			return nil, err
+		}
+		values[i] = string(value[:length])
+	}
+	return values, nil
+}
+
+func TestRedisSendStream(t *testing.T) {
+	server := newRedisTestServer(t)
+	defer server.listener.Close()
+
+	sender, err := NewRedisSender(RedisConfig{
+		Address: server.listener.Addr().String(),
+		Stream:  "edgex",
+		MaxLen:  1000,
+		Credentials: CredentialsProviderFunc(func() (string, string, error) {
+			return "", "secret", nil
+		}),
+	})
+	require.NoError(t, err)
+
+	context.CorrelationID = "123"
+	context.DeviceName = devID1
+	continuePipeline, result := sender.RedisSend(context, "payload")
+	assert.True(t, continuePipeline, "Pipeline should continue")
+	assert.Nil(t, result)
+
+	assert.Equal(t, []string{"AUTH", "secret"}, server.received("AUTH"))
+	assert.Equal(t, []string{"XADD", "edgex", "MAXLEN", "~", "1000", "*", "data", "payload", clients.CorrelationHeader, "123", "device", devID1},
+		server.received("XADD"))
+}
+
+func TestRedisSendChannel(t *testing.T) {
+	server := newRedisTestServer(t)
+	defer server.listener.Close()
+
+	sender, _ := NewRedisSender(RedisConfig{Address: server.listener.Addr().String(), Channel: "events"})
+	continuePipeline, result := sender.RedisSend(context, []byte("payload"))
+	assert.True(t, continuePipeline, "Pipeline should continue")
+	assert.Nil(t, result)
+
+	assert.Equal(t, []string{"PUBLISH", "events", "payload"}, server.received("PUBLISH"))
+	assert.Nil(t, server.received("AUTH"), "AUTH should not be sent without a password")
+}
+
+func TestRedisSendConnectionFailure(t *testing.T) {
+	listener, _ := net.Listen("tcp", "127.0.0.1:0")
+	address := listener.Addr().String()
+	listener.Close()
+
+	sender, _ := NewRedisSender(RedisConfig{Address: address, Stream: "edgex"})
+	continuePipeline, result := sender.RedisSend(context, "payload")
+	assert.False(t, continuePipeline)
+	assert.Error(t, result.(error))
+}
+
+func TestNewRedisSenderMissingTarget(t *testing.T) {
+	_, err := NewRedisSender(RedisConfig{Address: "redis:6379"})
+	assert.Error(t, err)
+}
+
+func TestNewRedisSenderCredentialsError(t *testing.T) {
+	_, err := NewRedisSender(RedisConfig{Address: "redis:6379", Stream: "edgex", Credentials: CredentialsProviderFunc(func() (string, string, error) {
+		return "", "", errors.New("no password secret")
+	})})
+	assert.Error(t, err)
+}
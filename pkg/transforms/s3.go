@@ -0,0 +1,289 @@
+//
+// Copyright (c) 2019 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/antoniomtz/app-functions-sdk-go/appcontext"
+	"github.com/edgexfoundry/go-mod-core-contracts/clients"
+)
+
+const (
+	awsDateFormat      = "20060102T150405Z"
+	awsShortDateFormat = "20060102"
+	awsSigningAlgo     = "AWS4-HMAC-SHA256"
+)
+
+// S3Config contains the parameters for uploading to S3 compatible object storage
+type S3Config struct {
+	// Endpoint is the base URL of the object storage, i.e. https://s3.us-east-1.amazonaws.com or http://minio:9000
+	Endpoint string
+	Region   string
+	Bucket   string
+	// KeyTemplate is the template for object keys. The placeholders {device}, {year}, {month}, {day}, {hour},
+	// {timestamp} and {correlation-id} are replaced, and a unique sequence number is appended to each key.
+	KeyTemplate     string
+	AccessKeyID     string
+	SecretAccessKey string
+	// BatchSize is the number of payloads combined, one per line, into each uploaded object. Values less than 2 disable batching.
+	BatchSize int
+	// BatchTimeout is the maximum time a payload waits in an incomplete batch before the batch is uploaded.
+	BatchTimeout time.Duration
+	// Compress gzips each object before upload and appends .gz to the key.
+	Compress bool
+}
+
+// S3Sender uploads pipeline data to S3 compatible object storage
+type S3Sender struct {
+	config     S3Config
+	httpClient *http.Client
+	sequence   uint64
+	batch      exportBatch
+}
+
+// s3Payload is data held in a batch along with when it was received, which the key of the batch is built from
+type s3Payload struct {
+	data     []byte
+	received time.Time
+}
+
+// NewS3Sender creates a sender for the specified object storage configuration
+func NewS3Sender(config S3Config) (*S3Sender, error) {
+	if config.Endpoint == "" || config.Bucket == "" {
+		return nil, errors.New("S3 endpoint and bucket must be specified")
+	}
+	if config.Region == "" {
+		config.Region = "us-east-1"
+	}
+	if config.KeyTemplate == "" {
+		config.KeyTemplate = "{device}/{year}/{month}/{day}/{timestamp}"
+	}
+
+	sender := &S3Sender{
+		config:     config,
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+	}
+	sender.batch = exportBatch{name: "S3", size: config.BatchSize, timeout: config.BatchTimeout, export: sender.uploadBatch}
+	return sender, nil
+}
+
+// SetBatchFailureHandler sets the handler given the data of the events held in a batch which failed to be uploaded
+// after the calls holding them returned, i.e. to store it for store and forward. Without a handler the data is kept
+// and uploaded with the next batch.
+func (sender *S3Sender) SetBatchFailureHandler(handler BatchFailureHandler) {
+	sender.batch.failed = handler
+}
+
+// S3Upload uploads data from the previous function as an object to the configured bucket. When batching is enabled
+// the data is held until the batch is full or the batch timeout expires, and the pipeline stops for the events whose
+// data is held. The key of a batch is determined by the first event in it.
+func (sender *S3Sender) S3Upload(edgexcontext *appcontext.Context, params ...interface{}) (bool, interface{}) {
+	if len(params) < 1 {
+		// We didn't receive a result
+		return false, errors.New("No Data Received")
+	}
+
+	data, err := coerceToBytes(params[0])
+	if err != nil {
+		return false, err
+	}
+
+	exported, err := sender.batch.add(edgexcontext, params[0], s3Payload{data: data, received: time.Now().UTC()})
+	if err != nil {
+		return false, err
+	}
+	if !exported {
+		return false, nil
+	}
+
+	edgexcontext.LoggingClient.Info("Sent data to S3")
+	edgexcontext.LoggingClient.Trace("Data exported", "Transport", "S3", clients.CorrelationHeader, edgexcontext.CorrelationID)
+
+	return true, nil
+}
+
+// PendingEvents returns the number of events held in an incomplete batch, which have not been exported yet
+func (sender *S3Sender) PendingEvents() int {
+	return sender.batch.pending()
+}
+
+// Flush uploads the data held in an incomplete batch to S3, i.e. before the service stops
+func (sender *S3Sender) Flush() error {
+	return sender.batch.flush()
+}
+
+// uploadBatch uploads the data of the entries as a single object, keyed by the first entry
+func (sender *S3Sender) uploadBatch(entries []batchEntry) (int, error) {
+	payloads := make([][]byte, len(entries))
+	for i, entry := range entries {
+		payloads[i] = entry.payload.(s3Payload).data
+	}
+	body := bytes.Join(payloads, []byte("\n"))
+	key := sender.objectKey(entries[0].context, entries[0].payload.(s3Payload).received)
+
+	if sender.config.Compress {
+		var buf bytes.Buffer
+		writer := gzip.NewWriter(&buf)
+		writer.Write(body)
+		writer.Close()
+		body = buf.Bytes()
+		key += ".gz"
+	}
+
+	if err := sender.putObject(key, body); err != nil {
+		return 0, err
+	}
+	return len(entries), nil
+}
+
+func (sender *S3Sender) objectKey(edgexcontext *appcontext.Context, now time.Time) string {
+	replacer := strings.NewReplacer(
+		"{device}", edgexcontext.DeviceName,
+		"{year}", now.Format("2006"),
+		"{month}", now.Format("01"),
+		"{day}", now.Format("02"),
+		"{hour}", now.Format("15"),
+		"{timestamp}", now.Format(awsDateFormat),
+		"{correlation-id}", edgexcontext.CorrelationID,
+	)
+	key := strings.TrimLeft(replacer.Replace(sender.config.KeyTemplate), "/")
+	return fmt.Sprintf("%s-%d", key, atomic.AddUint64(&sender.sequence, 1))
+}
+
+func (sender *S3Sender) putObject(key string, body []byte) error {
+	objectURL := strings.TrimRight(sender.config.Endpoint, "/") + "/" + sender.config.Bucket + "/" + key
+	request, err := http.NewRequest(http.MethodPut, objectURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	// send the key exactly as it is encoded in the signature
+	request.URL.RawPath = awsURIEncode(request.URL.Path)
+	if sender.config.Compress {
+		request.Header.Set(clients.ContentType, "application/gzip")
+	}
+
+	signAWSRequest(request, body, sender.config.AccessKeyID, sender.config.SecretAccessKey, sender.config.Region, "s3", time.Now().UTC())
+
+	response, err := sender.httpClient.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		responseBody, _ := ioutil.ReadAll(response.Body)
+		return fmt.Errorf("S3 upload of %s failed with status %s: %s", key, response.Status, string(responseBody))
+	}
+
+	return nil
+}
+
+// signAWSRequest adds an AWS Signature Version 4 Authorization header to the request, signing the
+// host and every header already set on the request.
+func signAWSRequest(request *http.Request, body []byte, accessKeyID string, secretAccessKey string, region string, service string, now time.Time) {
+	payloadHash := sha256.Sum256(body)
+	request.Header.Set("X-Amz-Date", now.Format(awsDateFormat))
+	request.Header.Set("X-Amz-Content-Sha256", hex.EncodeToString(payloadHash[:]))
+
+	headers := map[string]string{"host": request.URL.Host}
+	for name, values := range request.Header {
+		headers[strings.ToLower(name)] = strings.TrimSpace(strings.Join(values, ","))
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		request.Method,
+		awsURIEncode(request.URL.Path),
+		canonicalQuery(request.URL.Query()),
+		canonicalHeaders.String(),
+		signedHeaders,
+		hex.EncodeToString(payloadHash[:]),
+	}, "\n")
+	canonicalHash := sha256.Sum256([]byte(canonicalRequest))
+
+	scope := now.Format(awsShortDateFormat) + "/" + region + "/" + service + "/aws4_request"
+	stringToSign := awsSigningAlgo + "\n" + now.Format(awsDateFormat) + "\n" + scope + "\n" + hex.EncodeToString(canonicalHash[:])
+
+	signingKey := hmacSHA256([]byte("AWS4"+secretAccessKey), now.Format(awsShortDateFormat))
+	signingKey = hmacSHA256(signingKey, region)
+	signingKey = hmacSHA256(signingKey, service)
+	signingKey = hmacSHA256(signingKey, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(signingKey, stringToSign))
+
+	request.Header.Set("Authorization", fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		awsSigningAlgo, accessKeyID, scope, signedHeaders, signature))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// awsURIEncode percent encodes everything except unreserved characters and '/'
+func awsURIEncode(path string) string {
+	var encoded strings.Builder
+	for _, b := range []byte(path) {
+		if (b >= 'A' && b <= 'Z') || (b >= 'a' && b <= 'z') || (b >= '0' && b <= '9') ||
+			b == '-' || b == '_' || b == '.' || b == '~' || b == '/' {
+			encoded.WriteByte(b)
+		} else {
+			encoded.WriteString(fmt.Sprintf("%%%02X", b))
+		}
+	}
+	return encoded.String()
+}
+
+func canonicalQuery(values url.Values) string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var parts []string
+	for _, key := range keys {
+		for _, value := range values[key] {
+			parts = append(parts, awsURIEncode(key)+"="+strings.Replace(awsURIEncode(value), "/", "%2F", -1))
+		}
+	}
+	return strings.Join(parts, "&")
+}
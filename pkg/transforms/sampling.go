@@ -0,0 +1,83 @@
+//
+// Copyright (c) 2019 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	"errors"
+	"hash/fnv"
+	"strconv"
+	"time"
+
+	"github.com/antoniomtz/app-functions-sdk-go/appcontext"
+	"github.com/edgexfoundry/go-mod-core-contracts/models"
+)
+
+// sampleResolution is the number of slots the sampling hash is divided into
+const sampleResolution = 1000000
+
+// Sampler houses the parameters for forwarding a representative sample of events
+type Sampler struct {
+	// OneIn forwards 1 in OneIn events. Used when Percentage is not set.
+	OneIn int
+	// Percentage of events to forward, from 0 to 100
+	Percentage float64
+	// BucketSize groups the events of a device created within the same interval, so they are forwarded or
+	// dropped together. Zero samples each event on its own.
+	BucketSize time.Duration
+}
+
+// Sample forwards a share of the events from the previous function, dropping the others. Which events are
+// forwarded is decided by hashing the device name and the time bucket of the event's creation time, so the
+// decision is the same for every instance of the service and when events are reprocessed.
+// This function returns an Event
+func (s Sampler) Sample(edgexcontext *appcontext.Context, params ...interface{}) (bool, interface{}) {
+	if len(params) < 1 {
+		return false, errors.New("No Event Received")
+	}
+
+	event, ok := params[0].(models.Event)
+	if !ok {
+		return false, errors.New("Unexpected type received, expecting models.Event")
+	}
+
+	if !s.selects(event) {
+		edgexcontext.LoggingClient.Debug("Event not sampled")
+		return false, nil
+	}
+
+	return true, event
+}
+
+func (s Sampler) selects(event models.Event) bool {
+	share := s.Percentage / 100
+	if s.Percentage == 0 && s.OneIn > 0 {
+		share = 1 / float64(s.OneIn)
+	}
+
+	created := event.Created
+	if created == 0 {
+		created = time.Now().UnixNano() / int64(time.Millisecond)
+	}
+	bucket := created
+	if bucketMillis := int64(s.BucketSize / time.Millisecond); bucketMillis > 0 {
+		bucket = created / bucketMillis
+	}
+
+	hash := fnv.New64a()
+	hash.Write([]byte(event.Device + ":" + strconv.FormatInt(bucket, 10)))
+	return float64(hash.Sum64()%sampleResolution) < share*sampleResolution
+}
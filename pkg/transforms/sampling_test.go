@@ -0,0 +1,86 @@
+//
+// Copyright (c) 2019 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSampleOneIn(t *testing.T) {
+	sampler := Sampler{OneIn: 10}
+
+	forwarded := 0
+	for i := 0; i < 1000; i++ {
+		event := models.Event{Device: fmt.Sprintf("device%d", i), Created: 1565000000000}
+		continuePipeline, result := sampler.Sample(context, event)
+		again, _ := sampler.Sample(context, event)
+		assert.Equal(t, continuePipeline, again, "Sampling should be deterministic")
+		if continuePipeline {
+			assert.Equal(t, event, result)
+			forwarded++
+		} else {
+			assert.Nil(t, result)
+		}
+	}
+
+	assert.InDelta(t, 100, forwarded, 40, "Expected about 1 in 10 events to be forwarded")
+}
+
+func TestSamplePercentage(t *testing.T) {
+	event := models.Event{Device: devID1, Created: 1565000000000}
+
+	continuePipeline, _ := Sampler{Percentage: 100}.Sample(context, event)
+	assert.True(t, continuePipeline, "All events should be forwarded at 100 percent")
+	continuePipeline, _ = Sampler{Percentage: 0}.Sample(context, event)
+	assert.False(t, continuePipeline, "No events should be forwarded at 0 percent")
+
+	sampler := Sampler{Percentage: 25}
+	forwarded := 0
+	for i := 0; i < 1000; i++ {
+		if continuePipeline, _ := sampler.Sample(context, models.Event{Device: devID1, Created: int64(i)}); continuePipeline {
+			forwarded++
+		}
+	}
+	assert.InDelta(t, 250, forwarded, 60, "Expected about 25 percent of events to be forwarded")
+}
+
+func TestSampleBucketSize(t *testing.T) {
+	sampler := Sampler{OneIn: 2, BucketSize: time.Minute}
+	bucketStart := int64(1565000040000)
+
+	for i := 0; i < 20; i++ {
+		device := fmt.Sprintf("device%d", i)
+		first, _ := sampler.Sample(context, models.Event{Device: device, Created: bucketStart})
+		last, _ := sampler.Sample(context, models.Event{Device: device, Created: bucketStart + 59999})
+		assert.Equal(t, first, last, "Events in the same bucket should be sampled together")
+	}
+}
+
+func TestSampleNoEvent(t *testing.T) {
+	continuePipeline, result := Sampler{OneIn: 2}.Sample(context)
+	assert.False(t, continuePipeline)
+	assert.EqualError(t, result.(error), "No Event Received")
+
+	continuePipeline, result = Sampler{OneIn: 2}.Sample(context, "not an event")
+	assert.False(t, continuePipeline)
+	assert.EqualError(t, result.(error), "Unexpected type received, expecting models.Event")
+}
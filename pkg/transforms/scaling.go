@@ -0,0 +1,170 @@
+//
+// Copyright (c) 2019 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/antoniomtz/app-functions-sdk-go/appcontext"
+	"github.com/edgexfoundry/go-mod-core-contracts/models"
+)
+
+// ScaleConfig is the calibration applied to the readings of a value descriptor, y = Scale * x + Offset, and the name
+// they are renamed to
+type ScaleConfig struct {
+	// Scale is the multiplier applied to the reading value. Zero is treated as one, so only an offset can be set.
+	Scale  float64
+	Offset float64
+	// Min and Max clamp the scaled value when set
+	Min *float64
+	Max *float64
+	// Precision is the number of decimal places the scaled value is rounded to when set
+	Precision *int
+	// Name is the name the readings are renamed to when set, i.e. to convert raw register values to named
+	// engineering units. The value is left unchanged when only the Name is set.
+	Name string
+}
+
+// ScaleAndOffset houses the calibration of each value descriptor, keyed by value descriptor name
+type ScaleAndOffset struct {
+	Readings map[string]ScaleConfig
+}
+
+// ParseScaleConfig parses a calibration of the form "Scale=1.8, Offset=32, Min=-40, Max=120, Precision=1,
+// Name=TemperatureF", where each setting is optional
+func ParseScaleConfig(value string) (ScaleConfig, error) {
+	config := ScaleConfig{}
+	for _, setting := range strings.Split(value, ",") {
+		setting = strings.TrimSpace(setting)
+		if setting == "" {
+			continue
+		}
+		parts := strings.SplitN(setting, "=", 2)
+		if len(parts) != 2 {
+			return ScaleConfig{}, fmt.Errorf("invalid setting '%s', expected <name>=<value>", setting)
+		}
+		name, settingValue := strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1])
+
+		if name == "Name" {
+			if settingValue == "" {
+				return ScaleConfig{}, errors.New("Name must not be empty")
+			}
+			config.Name = settingValue
+			continue
+		}
+		if name == "Precision" {
+			precision, err := strconv.Atoi(settingValue)
+			if err != nil || precision < 0 {
+				return ScaleConfig{}, fmt.Errorf("Precision must be a non-negative integer, got '%s'", settingValue)
+			}
+			config.Precision = &precision
+			continue
+		}
+
+		number, err := strconv.ParseFloat(settingValue, 64)
+		if err != nil {
+			return ScaleConfig{}, fmt.Errorf("%s must be a number, got '%s'", name, settingValue)
+		}
+		switch name {
+		case "Scale":
+			config.Scale = number
+		case "Offset":
+			config.Offset = number
+		case "Min":
+			config.Min = &number
+		case "Max":
+			config.Max = &number
+		default:
+			return ScaleConfig{}, fmt.Errorf("unknown setting '%s', expected Scale, Offset, Min, Max, Precision or Name", name)
+		}
+	}
+
+	if config.Min != nil && config.Max != nil && *config.Min > *config.Max {
+		return ScaleConfig{}, fmt.Errorf("Min %g is greater than Max %g", *config.Min, *config.Max)
+	}
+	return config, nil
+}
+
+// scales reports whether the config changes the value, rather than only renaming the readings
+func (config ScaleConfig) scales() bool {
+	return config.Scale != 0 || config.Offset != 0 || config.Min != nil || config.Max != nil || config.Precision != nil
+}
+
+// Apply scales, offsets, clamps and rounds the value
+func (config ScaleConfig) Apply(value float64) float64 {
+	scale := config.Scale
+	if scale == 0 {
+		scale = 1
+	}
+	value = scale*value + config.Offset
+
+	if config.Min != nil && value < *config.Min {
+		value = *config.Min
+	}
+	if config.Max != nil && value > *config.Max {
+		value = *config.Max
+	}
+	if config.Precision != nil {
+		factor := math.Pow(10, float64(*config.Precision))
+		value = math.Round(value*factor) / factor
+	}
+	return value
+}
+
+// Scale applies the calibration of each reading's value descriptor to its value, and renames the reading when the
+// calibration has a Name. Readings of other value descriptors are passed through unchanged. This function returns an
+// Event, or an error when a reading to be scaled doesn't have a numeric value.
+func (s ScaleAndOffset) Scale(edgexcontext *appcontext.Context, params ...interface{}) (continuePipeline bool, result interface{}) {
+	if len(params) < 1 {
+		return false, errors.New("No Event Received")
+	}
+
+	edgexcontext.LoggingClient.Debug("Scaling readings")
+
+	event, ok := params[0].(models.Event)
+	if !ok {
+		return false, errors.New("Unexpected type received, expecting models.Event")
+	}
+
+	readings := make([]models.Reading, len(event.Readings))
+	for index, reading := range event.Readings {
+		config, ok := s.Readings[reading.Name]
+		if ok && config.scales() {
+			value, err := strconv.ParseFloat(strings.TrimSpace(reading.Value), 64)
+			if err != nil {
+				return false, fmt.Errorf("unable to scale reading '%s', value '%s' is not a number", reading.Name, reading.Value)
+			}
+
+			precision := -1
+			if config.Precision != nil {
+				precision = *config.Precision
+			}
+			reading.Value = strconv.FormatFloat(config.Apply(value), 'f', precision, 64)
+		}
+		if ok && config.Name != "" {
+			reading.Name = config.Name
+		}
+		readings[index] = reading
+	}
+	event.Readings = readings
+
+	return true, event
+}
@@ -0,0 +1,141 @@
+//
+// Copyright (c) 2019 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	"testing"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScaleAndOffset(t *testing.T) {
+	max := 100.0
+	precision := 1
+	eventIn := models.Event{
+		Device: devID1,
+		Readings: []models.Reading{
+			{Name: "Temperature", Value: "21.5"},
+			{Name: "Temperature", Value: "40"},
+			{Name: "Pressure", Value: " 1013 "},
+			{Name: readingName1, Value: readingValue1},
+		},
+	}
+
+	scaling := ScaleAndOffset{Readings: map[string]ScaleConfig{
+		"Temperature": {Scale: 1.8, Offset: 32, Max: &max, Precision: &precision},
+		"Pressure":    {Offset: -13},
+	}}
+	continuePipeline, result := scaling.Scale(context, eventIn)
+
+	assert.True(t, continuePipeline, "Pipeline should continue")
+	eventOut, ok := result.(models.Event)
+	require.True(t, ok, "Result should be models.Event")
+	assert.Equal(t, "70.7", eventOut.Readings[0].Value)
+	assert.Equal(t, "100.0", eventOut.Readings[1].Value, "Value should be clamped to Max")
+	assert.Equal(t, "1000", eventOut.Readings[2].Value, "Zero Scale should be treated as one")
+	assert.Equal(t, readingValue1, eventOut.Readings[3].Value, "Unknown reading should be unchanged")
+	assert.Equal(t, "21.5", eventIn.Readings[0].Value, "Original event should not be modified")
+}
+
+func TestScaleAndOffsetRename(t *testing.T) {
+	eventIn := models.Event{
+		Device: devID1,
+		Readings: []models.Reading{
+			{Name: "Register40001", Value: "2150"},
+			{Name: "Register40002", Value: "on"},
+		},
+	}
+
+	scaling := ScaleAndOffset{Readings: map[string]ScaleConfig{
+		"Register40001": {Scale: 0.01, Name: "Temperature"},
+		"Register40002": {Name: "Pump"},
+	}}
+	continuePipeline, result := scaling.Scale(context, eventIn)
+
+	assert.True(t, continuePipeline, "Pipeline should continue")
+	eventOut, ok := result.(models.Event)
+	require.True(t, ok, "Result should be models.Event")
+	assert.Equal(t, []models.Reading{
+		{Name: "Temperature", Value: "21.5"},
+		{Name: "Pump", Value: "on"},
+	}, eventOut.Readings, "Value should be unchanged when only the Name is set")
+	assert.Equal(t, "Register40001", eventIn.Readings[0].Name, "Original event should not be modified")
+}
+
+func TestScaleAndOffsetNotNumeric(t *testing.T) {
+	scaling := ScaleAndOffset{Readings: map[string]ScaleConfig{"Temperature": {Scale: 2}}}
+	continuePipeline, result := scaling.Scale(context, models.Event{
+		Readings: []models.Reading{{Name: "Temperature", Value: "warm"}},
+	})
+
+	assert.False(t, continuePipeline, "Pipeline should stop")
+	assert.EqualError(t, result.(error), "unable to scale reading 'Temperature', value 'warm' is not a number")
+}
+
+func TestScaleAndOffsetNoParameters(t *testing.T) {
+	scaling := ScaleAndOffset{}
+	continuePipeline, result := scaling.Scale(context)
+
+	assert.False(t, continuePipeline, "Pipeline should stop")
+	assert.EqualError(t, result.(error), "No Event Received")
+}
+
+func TestParseScaleConfig(t *testing.T) {
+	config, err := ParseScaleConfig("Scale=1.8, Offset=32, Min=-40, Max=120, Precision=2")
+	require.NoError(t, err)
+	assert.Equal(t, 1.8, config.Scale)
+	assert.Equal(t, 32.0, config.Offset)
+	assert.Equal(t, -40.0, *config.Min)
+	assert.Equal(t, 120.0, *config.Max)
+	assert.Equal(t, 2, *config.Precision)
+	assert.Equal(t, -40.0, config.Apply(-100))
+	assert.Equal(t, 33.86, config.Apply(1.0333))
+
+	config, err = ParseScaleConfig("Name=TemperatureF")
+	require.NoError(t, err)
+	assert.Equal(t, "TemperatureF", config.Name)
+	assert.False(t, config.scales())
+
+	config, err = ParseScaleConfig("Offset=-0.5")
+	require.NoError(t, err)
+	assert.Nil(t, config.Min)
+	assert.Nil(t, config.Precision)
+	assert.Equal(t, 9.5, config.Apply(10))
+}
+
+func TestParseScaleConfigErrors(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected string
+	}{
+		{"Scale", "invalid setting 'Scale', expected <name>=<value>"},
+		{"Scale=high", "Scale must be a number, got 'high'"},
+		{"Precision=-1", "Precision must be a non-negative integer, got '-1'"},
+		{"Gain=2", "unknown setting 'Gain', expected Scale, Offset, Min, Max, Precision or Name"},
+		{"Name=", "Name must not be empty"},
+		{"Min=10, Max=0", "Min 10 is greater than Max 0"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.value, func(t *testing.T) {
+			_, err := ParseScaleConfig(test.value)
+			assert.EqualError(t, err, test.expected)
+		})
+	}
+}
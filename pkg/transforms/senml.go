@@ -0,0 +1,208 @@
+//
+// Copyright (c) 2019 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/antoniomtz/app-functions-sdk-go/appcontext"
+	"github.com/edgexfoundry/go-mod-core-contracts/models"
+	"github.com/ugorji/go/codec"
+)
+
+// The encodings of SenML packs
+const (
+	// SenMLJSON is the JSON representation of SenML, application/senml+json
+	SenMLJSON = "json"
+	// SenMLCBOR is the CBOR representation of SenML, application/senml+cbor
+	SenMLCBOR = "cbor"
+)
+
+// senMLDevicePlaceholder is replaced with the device name of the event in the base name
+const senMLDevicePlaceholder = "{device}"
+
+// SenMLConfig contains the parameters for converting events to SenML
+type SenMLConfig struct {
+	// Format is the encoding of the SenML pack, json, the default, or cbor
+	Format string
+	// BaseName is the base name of the pack, which the names of the readings are appended to. The {device}
+	// placeholder is replaced with the device name of the event, i.e. urn:dev:edgex:{device}: or /3303/0/.
+	// {device}: by default.
+	BaseName string
+	// Units are the SenML units of the readings by name, i.e. Cel for a Temperature reading
+	Units map[string]string
+}
+
+// senMLRecord is a SenML record, as defined by RFC 8428
+type senMLRecord struct {
+	BaseName    string   `json:"bn,omitempty"`
+	BaseTime    float64  `json:"bt,omitempty"`
+	Name        string   `json:"n,omitempty"`
+	Unit        string   `json:"u,omitempty"`
+	Value       *float64 `json:"v,omitempty"`
+	StringValue *string  `json:"vs,omitempty"`
+	BoolValue   *bool    `json:"vb,omitempty"`
+	DataValue   []byte   `json:"-"`
+	Time        float64  `json:"t,omitempty"`
+}
+
+// MarshalJSON encodes the record, with the data value base64url encoded without padding as RFC 8428 requires
+func (record senMLRecord) MarshalJSON() ([]byte, error) {
+	type jsonRecord senMLRecord
+	encoded := struct {
+		jsonRecord
+		DataValue string `json:"vd,omitempty"`
+	}{jsonRecord: jsonRecord(record)}
+	if record.DataValue != nil {
+		encoded.DataValue = base64.RawURLEncoding.EncodeToString(record.DataValue)
+	}
+	return json.Marshal(encoded)
+}
+
+// cborMap returns the record with the integer labels of the CBOR representation
+func (record senMLRecord) cborMap() map[int]interface{} {
+	labels := map[int]interface{}{}
+	if record.BaseName != "" {
+		labels[-2] = record.BaseName
+	}
+	if record.BaseTime != 0 {
+		labels[-3] = record.BaseTime
+	}
+	if record.Name != "" {
+		labels[0] = record.Name
+	}
+	if record.Unit != "" {
+		labels[1] = record.Unit
+	}
+	switch {
+	case record.Value != nil:
+		labels[2] = *record.Value
+	case record.StringValue != nil:
+		labels[3] = *record.StringValue
+	case record.BoolValue != nil:
+		labels[4] = *record.BoolValue
+	case record.DataValue != nil:
+		labels[8] = record.DataValue
+	}
+	if record.Time != 0 {
+		labels[6] = record.Time
+	}
+	return labels
+}
+
+// SenMLConverter converts events to SenML packs
+type SenMLConverter struct {
+	config SenMLConfig
+}
+
+// NewSenMLConverter creates a SenMLConverter, setting the defaults of the config
+func NewSenMLConverter(config SenMLConfig) (*SenMLConverter, error) {
+	switch config.Format {
+	case "":
+		config.Format = SenMLJSON
+	case SenMLJSON, SenMLCBOR:
+	default:
+		return nil, fmt.Errorf("SenML format must be json or cbor, got '%s'", config.Format)
+	}
+	if config.BaseName == "" {
+		config.BaseName = senMLDevicePlaceholder + ":"
+	}
+	return &SenMLConverter{config: config}, nil
+}
+
+// TransformToSenML converts the Event from the previous function to a SenML pack with a record per reading. The base
+// time of the pack is the origin of the event, and the time of a record the offset of the origin of its reading, in
+// seconds. Numeric values are converted to numbers, true and false to booleans, and binary values to data values.
+// This function returns a string for JSON and a []byte for CBOR, or stops the pipeline when the event has no readings.
+func (converter *SenMLConverter) TransformToSenML(edgexcontext *appcontext.Context, params ...interface{}) (bool, interface{}) {
+	if len(params) < 1 {
+		return false, errors.New("No Event Received")
+	}
+
+	var event models.Event
+	switch data := params[0].(type) {
+	case models.Event:
+		event = data
+	case TaggedEvent:
+		event = data.Event
+	default:
+		return false, errors.New("Unexpected type received, expecting models.Event")
+	}
+
+	if len(event.Readings) == 0 {
+		edgexcontext.LoggingClient.Debug("Event has no readings to convert to SenML")
+		return false, nil
+	}
+
+	edgexcontext.LoggingClient.Debug("Transforming to SenML")
+	records := converter.records(event)
+
+	if converter.config.Format == SenMLCBOR {
+		labels := make([]map[int]interface{}, len(records))
+		for i, record := range records {
+			labels[i] = record.cborMap()
+		}
+		var encoded []byte
+		if err := codec.NewEncoderBytes(&encoded, &codec.CborHandle{}).Encode(labels); err != nil {
+			return false, fmt.Errorf("unable to encode SenML: %v", err)
+		}
+		return true, encoded
+	}
+
+	encoded, err := json.Marshal(records)
+	if err != nil {
+		return false, fmt.Errorf("unable to encode SenML: %v", err)
+	}
+	return true, string(encoded)
+}
+
+// records returns the SenML records of the readings of the event, the first one holding the base name and time
+func (converter *SenMLConverter) records(event models.Event) []senMLRecord {
+	records := make([]senMLRecord, 0, len(event.Readings))
+	for _, reading := range event.Readings {
+		record := senMLRecord{
+			Name: reading.Name,
+			Unit: converter.config.Units[reading.Name],
+		}
+		if reading.Origin != 0 {
+			record.Time = float64(reading.Origin-event.Origin) / 1000
+		}
+
+		if reading.BinaryValue != nil {
+			record.DataValue = reading.BinaryValue
+		} else if value, err := strconv.ParseFloat(reading.Value, 64); err == nil && !math.IsInf(value, 0) && !math.IsNaN(value) {
+			record.Value = &value
+		} else if reading.Value == "true" || reading.Value == "false" {
+			value := reading.Value == "true"
+			record.BoolValue = &value
+		} else {
+			value := reading.Value
+			record.StringValue = &value
+		}
+		records = append(records, record)
+	}
+
+	records[0].BaseName = strings.Replace(converter.config.BaseName, senMLDevicePlaceholder, event.Device, -1)
+	records[0].BaseTime = float64(event.Origin) / 1000
+	return records
+}
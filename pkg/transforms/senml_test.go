@@ -0,0 +1,96 @@
+//
+// Copyright (c) 2019 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	"testing"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ugorji/go/codec"
+)
+
+var senMLEvent = models.Event{
+	Device: devID1,
+	Origin: 1559390400000,
+	Readings: []models.Reading{
+		{Name: "Temperature", Value: "21.5"},
+		{Name: "Open", Value: "true", Origin: 1559390400500},
+		{Name: "State", Value: "idle"},
+		{Name: "Image", BinaryValue: []byte{0xfb, 0xff}},
+	},
+}
+
+func TestTransformToSenMLJSON(t *testing.T) {
+	converter, err := NewSenMLConverter(SenMLConfig{Units: map[string]string{"Temperature": "Cel"}})
+	require.NoError(t, err)
+
+	continuePipeline, result := converter.TransformToSenML(context, senMLEvent)
+	require.True(t, continuePipeline, "%v", result)
+	assert.JSONEq(t, `[
+		{"bn":"id1:","bt":1559390400,"n":"Temperature","u":"Cel","v":21.5},
+		{"n":"Open","vb":true,"t":0.5},
+		{"n":"State","vs":"idle"},
+		{"n":"Image","vd":"-_8"}
+	]`, result.(string))
+}
+
+func TestTransformToSenMLCBOR(t *testing.T) {
+	converter, err := NewSenMLConverter(SenMLConfig{Format: SenMLCBOR, BaseName: "urn:dev:edgex:{device}:"})
+	require.NoError(t, err)
+
+	continuePipeline, result := converter.TransformToSenML(context, TaggedEvent{Event: senMLEvent})
+	require.True(t, continuePipeline, "%v", result)
+
+	var records []map[int]interface{}
+	require.NoError(t, codec.NewDecoderBytes(result.([]byte), &codec.CborHandle{}).Decode(&records))
+	require.Equal(t, 4, len(records))
+	assert.Equal(t, "urn:dev:edgex:id1:", records[0][-2])
+	assert.Equal(t, float64(1559390400), records[0][-3])
+	assert.Equal(t, "Temperature", records[0][0])
+	assert.Equal(t, 21.5, records[0][2])
+	assert.Equal(t, true, records[1][4])
+	assert.Equal(t, 0.5, records[1][6])
+	assert.Equal(t, "idle", records[2][3])
+	assert.Equal(t, []byte{0xfb, 0xff}, records[3][8])
+}
+
+func TestTransformToSenMLNoReadings(t *testing.T) {
+	converter, err := NewSenMLConverter(SenMLConfig{})
+	require.NoError(t, err)
+
+	continuePipeline, result := converter.TransformToSenML(context, models.Event{Device: devID1})
+	assert.False(t, continuePipeline)
+	assert.Nil(t, result)
+}
+
+func TestTransformToSenMLErrors(t *testing.T) {
+	converter, err := NewSenMLConverter(SenMLConfig{})
+	require.NoError(t, err)
+
+	continuePipeline, result := converter.TransformToSenML(context)
+	assert.False(t, continuePipeline)
+	assert.EqualError(t, result.(error), "No Event Received")
+
+	continuePipeline, result = converter.TransformToSenML(context, "not an event")
+	assert.False(t, continuePipeline)
+	assert.EqualError(t, result.(error), "Unexpected type received, expecting models.Event")
+
+	_, err = NewSenMLConverter(SenMLConfig{Format: "xml"})
+	assert.EqualError(t, err, "SenML format must be json or cbor, got 'xml'")
+}
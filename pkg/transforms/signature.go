@@ -0,0 +1,140 @@
+//
+// Copyright (c) 2019 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/antoniomtz/app-functions-sdk-go/appcontext"
+	"github.com/edgexfoundry/go-mod-core-contracts/models"
+)
+
+// SignatureVerifier verifies the signatures of inbound events against a set of trusted public keys
+type SignatureVerifier struct {
+	publicKeys []crypto.PublicKey
+	// signatureReading is the name of the reading holding the signature. When empty the signature is taken
+	// from the context.
+	signatureReading string
+}
+
+// NewSignatureVerifier creates a verifier trusting the RSA and ECDSA public keys in the PEM encoded
+// publicKeys. When signatureReading is set, the signature is read from the reading of that name rather than
+// from the X-Signature header received by the HTTP trigger.
+func NewSignatureVerifier(publicKeys [][]byte, signatureReading string) (*SignatureVerifier, error) {
+	verifier := &SignatureVerifier{signatureReading: signatureReading}
+	for _, publicKeyPEM := range publicKeys {
+		block, _ := pem.Decode(publicKeyPEM)
+		if block == nil {
+			return nil, errors.New("no PEM encoded public key found")
+		}
+		publicKey, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse public key: %v", err)
+		}
+		switch publicKey.(type) {
+		case *rsa.PublicKey, *ecdsa.PublicKey:
+		default:
+			return nil, fmt.Errorf("unsupported public key type %T, expecting RSA or ECDSA", publicKey)
+		}
+		verifier.publicKeys = append(verifier.publicKeys, publicKey)
+	}
+
+	if len(verifier.publicKeys) == 0 {
+		return nil, errors.New("at least one public key must be specified")
+	}
+	return verifier, nil
+}
+
+// VerifySignature checks the base64 encoded SHA-256 signature of the event from the previous function, stopping the
+// pipeline with an error when it is missing or isn't valid for any of the trusted keys. A signature from the
+// X-Signature header is verified against the payload exactly as received. A signature held in a reading is
+// verified against the JSON encoding of the event without that reading, which is also removed from the event
+// passed to the next function.
+// This function returns an Event
+func (verifier *SignatureVerifier) VerifySignature(edgexcontext *appcontext.Context, params ...interface{}) (bool, interface{}) {
+	if len(params) < 1 {
+		return false, errors.New("No Event Received")
+	}
+
+	event, ok := params[0].(models.Event)
+	if !ok {
+		return false, errors.New("Unexpected type received, expecting models.Event")
+	}
+
+	signature := edgexcontext.Signature
+	signed := edgexcontext.RawPayload
+	if verifier.signatureReading != "" {
+		signature = ""
+		readings := make([]models.Reading, 0, len(event.Readings))
+		for _, reading := range event.Readings {
+			if reading.Name == verifier.signatureReading {
+				signature = reading.Value
+				continue
+			}
+			readings = append(readings, reading)
+		}
+		event.Readings = readings
+
+		var err error
+		if signed, err = json.Marshal(event); err != nil {
+			return false, fmt.Errorf("unable to marshal event to JSON: %v", err)
+		}
+	}
+
+	if signature == "" {
+		return false, errors.New("event signature missing")
+	}
+	decoded, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil {
+		return false, fmt.Errorf("event signature is not valid base64: %v", err)
+	}
+
+	digest := sha256.Sum256(signed)
+	for _, publicKey := range verifier.publicKeys {
+		if verifySignature(publicKey, digest[:], decoded) {
+			edgexcontext.LoggingClient.Debug("Event signature verified")
+			return true, event
+		}
+	}
+
+	return false, errors.New("event signature verification failed")
+}
+
+func verifySignature(publicKey crypto.PublicKey, digest []byte, signature []byte) bool {
+	switch key := publicKey.(type) {
+	case *rsa.PublicKey:
+		return rsa.VerifyPKCS1v15(key, crypto.SHA256, digest, signature) == nil
+	case *ecdsa.PublicKey:
+		var sig struct{ R, S *big.Int }
+		if _, err := asn1.Unmarshal(signature, &sig); err != nil {
+			return false
+		}
+		return ecdsa.Verify(key, digest, sig.R, sig.S)
+	}
+	return false
+}
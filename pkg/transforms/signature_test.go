@@ -0,0 +1,112 @@
+//
+// Copyright (c) 2019 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"testing"
+
+	"github.com/antoniomtz/app-functions-sdk-go/appcontext"
+	"github.com/edgexfoundry/go-mod-core-contracts/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func publicKeyPEM(t *testing.T, publicKey crypto.PublicKey) []byte {
+	der, err := x509.MarshalPKIXPublicKey(publicKey)
+	require.NoError(t, err)
+	return pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})
+}
+
+func TestVerifySignatureHeader(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 1024)
+	require.NoError(t, err)
+	ecdsaKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	verifier, err := NewSignatureVerifier([][]byte{publicKeyPEM(t, &rsaKey.PublicKey), publicKeyPEM(t, &ecdsaKey.PublicKey)}, "")
+	require.NoError(t, err)
+
+	event := models.Event{Device: devID1}
+	payload, _ := json.Marshal(event)
+	digest := sha256.Sum256(payload)
+	r, s, err := ecdsa.Sign(rand.Reader, ecdsaKey, digest[:])
+	require.NoError(t, err)
+	signature, _ := asn1.Marshal(struct{ R, S *big.Int }{r, s})
+
+	edgexcontext := &appcontext.Context{
+		LoggingClient: context.LoggingClient,
+		RawPayload:    payload,
+		Signature:     base64.StdEncoding.EncodeToString(signature),
+	}
+	continuePipeline, result := verifier.VerifySignature(edgexcontext, event)
+	require.True(t, continuePipeline, "Unexpected error: %v", result)
+	assert.Equal(t, event, result)
+
+	edgexcontext.RawPayload = []byte(`{"device":"tampered"}`)
+	continuePipeline, result = verifier.VerifySignature(edgexcontext, event)
+	assert.False(t, continuePipeline)
+	assert.EqualError(t, result.(error), "event signature verification failed")
+
+	edgexcontext.Signature = ""
+	continuePipeline, result = verifier.VerifySignature(edgexcontext, event)
+	assert.False(t, continuePipeline)
+	assert.EqualError(t, result.(error), "event signature missing")
+}
+
+func TestVerifySignatureReading(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 1024)
+	require.NoError(t, err)
+	verifier, err := NewSignatureVerifier([][]byte{publicKeyPEM(t, &rsaKey.PublicKey)}, "signature")
+	require.NoError(t, err)
+
+	event := models.Event{Device: devID1, Readings: []models.Reading{{Name: readingName1, Value: readingValue1}}}
+	signed, _ := json.Marshal(event)
+	digest := sha256.Sum256(signed)
+	signature, err := rsa.SignPKCS1v15(rand.Reader, rsaKey, crypto.SHA256, digest[:])
+	require.NoError(t, err)
+
+	signedEvent := event
+	signedEvent.Readings = append([]models.Reading{}, event.Readings...)
+	signedEvent.Readings = append(signedEvent.Readings, models.Reading{Name: "signature", Value: base64.StdEncoding.EncodeToString(signature)})
+
+	continuePipeline, result := verifier.VerifySignature(context, signedEvent)
+	require.True(t, continuePipeline, "Unexpected error: %v", result)
+	assert.Equal(t, event, result, "Signature reading should be removed")
+
+	signedEvent.Readings[0].Value = "tampered"
+	continuePipeline, _ = verifier.VerifySignature(context, signedEvent)
+	assert.False(t, continuePipeline)
+}
+
+func TestNewSignatureVerifierInvalidKeys(t *testing.T) {
+	_, err := NewSignatureVerifier(nil, "")
+	assert.Error(t, err, "A public key should be required")
+	_, err = NewSignatureVerifier([][]byte{[]byte("not a key")}, "")
+	assert.Error(t, err)
+}
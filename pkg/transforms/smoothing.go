@@ -0,0 +1,229 @@
+//
+// Copyright (c) 2019 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/antoniomtz/app-functions-sdk-go/appcontext"
+	"github.com/edgexfoundry/go-mod-core-contracts/models"
+)
+
+// The moving averages which can smooth the readings of a value descriptor
+const (
+	// SmoothingSMA is the simple moving average of the last Window values
+	SmoothingSMA = "sma"
+	// SmoothingEMA is the exponential moving average, weighting each new value by Alpha
+	SmoothingEMA = "ema"
+)
+
+// SmoothingConfig is the moving average applied to the readings of a value descriptor
+type SmoothingConfig struct {
+	// Method is either SmoothingSMA or SmoothingEMA
+	Method string
+	// Window is the number of values averaged by SmoothingSMA
+	Window int
+	// Alpha is the weight of each new value for SmoothingEMA, greater than 0 and at most 1
+	Alpha float64
+	// Name is the name of the smoothed reading, <value descriptor>_<method> when empty
+	Name string
+	// Precision is the number of decimal places the smoothed value is rounded to when set
+	Precision *int
+}
+
+// Smoother adds a reading with the moving average of each reading of the configured value descriptors, tracking the
+// average of each device separately
+type Smoother struct {
+	readings map[string]SmoothingConfig
+	mutex    sync.Mutex
+	averages map[smoothingKey]*movingAverage
+}
+
+// smoothingKey identifies the readings of a device averaged together
+type smoothingKey struct {
+	device string
+	name   string
+}
+
+// movingAverage is the state of the average of a device's readings
+type movingAverage struct {
+	// values are the last values of an sma
+	values  []float64
+	average float64
+	started bool
+}
+
+// ParseSmoothingConfig parses a moving average of the form "Method=sma, Window=5" or "Method=ema, Alpha=0.2",
+// optionally with the Name of the smoothed reading and its Precision
+func ParseSmoothingConfig(value string) (SmoothingConfig, error) {
+	config := SmoothingConfig{}
+	for _, setting := range strings.Split(value, ",") {
+		setting = strings.TrimSpace(setting)
+		if setting == "" {
+			continue
+		}
+		parts := strings.SplitN(setting, "=", 2)
+		if len(parts) != 2 {
+			return SmoothingConfig{}, fmt.Errorf("invalid setting '%s', expected <name>=<value>", setting)
+		}
+		name, settingValue := strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1])
+
+		switch name {
+		case "Method":
+			config.Method = strings.ToLower(settingValue)
+		case "Name":
+			config.Name = settingValue
+		case "Window":
+			window, err := strconv.Atoi(settingValue)
+			if err != nil {
+				return SmoothingConfig{}, fmt.Errorf("Window must be an integer, got '%s'", settingValue)
+			}
+			config.Window = window
+		case "Alpha":
+			alpha, err := strconv.ParseFloat(settingValue, 64)
+			if err != nil {
+				return SmoothingConfig{}, fmt.Errorf("Alpha must be a number, got '%s'", settingValue)
+			}
+			config.Alpha = alpha
+		case "Precision":
+			precision, err := strconv.Atoi(settingValue)
+			if err != nil || precision < 0 {
+				return SmoothingConfig{}, fmt.Errorf("Precision must be a non-negative integer, got '%s'", settingValue)
+			}
+			config.Precision = &precision
+		default:
+			return SmoothingConfig{}, fmt.Errorf("unknown setting '%s', expected Method, Window, Alpha, Name or Precision", name)
+		}
+	}
+
+	return config, config.Validate()
+}
+
+// Validate checks the method of the config has its parameters
+func (config SmoothingConfig) Validate() error {
+	switch config.Method {
+	case SmoothingSMA:
+		if config.Window < 1 {
+			return errors.New("sma Window must be at least 1")
+		}
+	case SmoothingEMA:
+		if config.Alpha <= 0 || config.Alpha > 1 {
+			return errors.New("ema Alpha must be greater than 0 and at most 1")
+		}
+	default:
+		return fmt.Errorf("Method must be %s or %s, got '%s'", SmoothingSMA, SmoothingEMA, config.Method)
+	}
+	return nil
+}
+
+// NewSmoother creates a Smoother for the moving averages of readings, keyed by value descriptor name
+func NewSmoother(readings map[string]SmoothingConfig) (*Smoother, error) {
+	for valueDescriptor, config := range readings {
+		if err := config.Validate(); err != nil {
+			return nil, fmt.Errorf("%s: %v", valueDescriptor, err)
+		}
+	}
+
+	return &Smoother{
+		readings: readings,
+		averages: map[smoothingKey]*movingAverage{},
+	}, nil
+}
+
+// Smooth adds a reading with the moving average of each reading of the configured value descriptors in the event from
+// the previous function, right after the reading. Until an sma Window is full, the values received so far are
+// averaged. Readings which don't have a numeric value are passed through without a smoothed reading.
+// This function returns an Event
+func (s *Smoother) Smooth(edgexcontext *appcontext.Context, params ...interface{}) (bool, interface{}) {
+	if len(params) < 1 {
+		return false, errors.New("No Event Received")
+	}
+
+	event, ok := params[0].(models.Event)
+	if !ok {
+		return false, errors.New("Unexpected type received, expecting models.Event")
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	readings := make([]models.Reading, 0, len(event.Readings))
+	for _, reading := range event.Readings {
+		readings = append(readings, reading)
+
+		config, ok := s.readings[reading.Name]
+		if !ok {
+			continue
+		}
+		value, err := strconv.ParseFloat(strings.TrimSpace(reading.Value), 64)
+		if err != nil {
+			edgexcontext.LoggingClient.Debug(fmt.Sprintf("Reading '%s' not smoothed, value '%s' is not a number", reading.Name, reading.Value))
+			continue
+		}
+
+		key := smoothingKey{device: event.Device, name: reading.Name}
+		average, ok := s.averages[key]
+		if !ok {
+			average = &movingAverage{}
+			s.averages[key] = average
+		}
+
+		smoothed := reading
+		smoothed.Id = ""
+		smoothed.Name = config.Name
+		if smoothed.Name == "" {
+			smoothed.Name = reading.Name + "_" + config.Method
+		}
+		precision := -1
+		if config.Precision != nil {
+			precision = *config.Precision
+		}
+		smoothed.Value = strconv.FormatFloat(average.add(config, value), 'f', precision, 64)
+		readings = append(readings, smoothed)
+	}
+	event.Readings = readings
+
+	return true, event
+}
+
+// add adds the value to the average, returning the new average
+func (average *movingAverage) add(config SmoothingConfig, value float64) float64 {
+	if config.Method == SmoothingEMA {
+		if !average.started {
+			average.average = value
+			average.started = true
+		} else {
+			average.average += config.Alpha * (value - average.average)
+		}
+		return average.average
+	}
+
+	average.values = append(average.values, value)
+	if len(average.values) > config.Window {
+		average.values = average.values[len(average.values)-config.Window:]
+	}
+	sum := 0.0
+	for _, value := range average.values {
+		sum += value
+	}
+	average.average = sum / float64(len(average.values))
+	return average.average
+}
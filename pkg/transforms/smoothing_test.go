@@ -0,0 +1,123 @@
+//
+// Copyright (c) 2019 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	"testing"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// smooth passes a Temperature reading of the device to the smoother, returning the readings of the result
+func smooth(t *testing.T, smoother *Smoother, device string, value string) []models.Reading {
+	continuePipeline, result := smoother.Smooth(context, models.Event{
+		Device:   device,
+		Readings: []models.Reading{{Name: "Temperature", Value: value}, {Name: readingName1, Value: readingValue1}},
+	})
+	require.True(t, continuePipeline, "Pipeline should continue")
+	eventOut, ok := result.(models.Event)
+	require.True(t, ok, "Result should be models.Event")
+	return eventOut.Readings
+}
+
+func TestSmoothSMA(t *testing.T) {
+	smoother, err := NewSmoother(map[string]SmoothingConfig{"Temperature": {Method: SmoothingSMA, Window: 3}})
+	require.NoError(t, err)
+
+	var averages []string
+	for _, value := range []string{"10", "20", "30", "40"} {
+		readings := smooth(t, smoother, devID1, value)
+		require.Len(t, readings, 3)
+		assert.Equal(t, "Temperature", readings[0].Name)
+		assert.Equal(t, value, readings[0].Value, "Original reading should be kept")
+		assert.Equal(t, "Temperature_sma", readings[1].Name)
+		assert.Equal(t, readingName1, readings[2].Name, "Other readings should be passed through")
+		averages = append(averages, readings[1].Value)
+	}
+	assert.Equal(t, []string{"10", "15", "20", "30"}, averages)
+
+	readings := smooth(t, smoother, devID2, "100")
+	assert.Equal(t, "100", readings[1].Value, "Devices should be averaged separately")
+}
+
+func TestSmoothEMA(t *testing.T) {
+	precision := 2
+	smoother, err := NewSmoother(map[string]SmoothingConfig{
+		"Temperature": {Method: SmoothingEMA, Alpha: 0.3, Name: "SmoothTemperature", Precision: &precision},
+	})
+	require.NoError(t, err)
+
+	var averages []string
+	for _, value := range []string{"10", "20", "warm", "20"} {
+		readings := smooth(t, smoother, devID1, value)
+		if value == "warm" {
+			assert.Len(t, readings, 2, "Non-numeric reading should not be smoothed")
+			continue
+		}
+		assert.Equal(t, "SmoothTemperature", readings[1].Name)
+		averages = append(averages, readings[1].Value)
+	}
+	assert.Equal(t, []string{"10.00", "13.00", "15.10"}, averages)
+}
+
+func TestSmoothNoParameters(t *testing.T) {
+	smoother, err := NewSmoother(nil)
+	require.NoError(t, err)
+	continuePipeline, result := smoother.Smooth(context)
+
+	assert.False(t, continuePipeline, "Pipeline should stop")
+	assert.EqualError(t, result.(error), "No Event Received")
+}
+
+func TestParseSmoothingConfig(t *testing.T) {
+	config, err := ParseSmoothingConfig("Method=EMA, Alpha=0.2, Name=Smoothed, Precision=1")
+	require.NoError(t, err)
+	assert.Equal(t, SmoothingEMA, config.Method)
+	assert.Equal(t, 0.2, config.Alpha)
+	assert.Equal(t, "Smoothed", config.Name)
+	assert.Equal(t, 1, *config.Precision)
+
+	config, err = ParseSmoothingConfig("Method=sma, Window=5")
+	require.NoError(t, err)
+	assert.Equal(t, 5, config.Window)
+	assert.Nil(t, config.Precision)
+}
+
+func TestParseSmoothingConfigErrors(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected string
+	}{
+		{"Method", "invalid setting 'Method', expected <name>=<value>"},
+		{"Method=median", "Method must be sma or ema, got 'median'"},
+		{"Method=sma", "sma Window must be at least 1"},
+		{"Method=sma, Window=five", "Window must be an integer, got 'five'"},
+		{"Method=ema, Alpha=2", "ema Alpha must be greater than 0 and at most 1"},
+		{"Method=ema, Alpha=high", "Alpha must be a number, got 'high'"},
+		{"Method=ema, Alpha=0.5, Precision=-1", "Precision must be a non-negative integer, got '-1'"},
+		{"Method=ema, Span=3", "unknown setting 'Span', expected Method, Window, Alpha, Name or Precision"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.value, func(t *testing.T) {
+			_, err := ParseSmoothingConfig(test.value)
+			assert.EqualError(t, err, test.expected)
+		})
+	}
+}
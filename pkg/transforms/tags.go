@@ -0,0 +1,162 @@
+//
+// Copyright (c) 2019 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/antoniomtz/app-functions-sdk-go/appcontext"
+	"github.com/edgexfoundry/go-mod-core-contracts/models"
+)
+
+// TaggedEvent is an event along with the tags attached to it, i.e. the site and gateway it was processed by. It's
+// encoded as the event with an additional "tags" object in JSON, and Tags element in XML.
+type TaggedEvent struct {
+	models.Event
+	Tags map[string]string
+}
+
+// Tagger attaches static tags, i.e. the site, gateway ID or GPS coordinates, and dynamic tags to events
+type Tagger struct {
+	tags         map[string]string
+	hostnameTag  string
+	processedTag string
+	hostname     string
+}
+
+// NewTagger creates a Tagger attaching the static tags, along with the hostname as the hostnameTag and the time the
+// event is processed as the processedTag when they are set
+func NewTagger(tags map[string]string, hostnameTag string, processedTag string) (*Tagger, error) {
+	tagger := &Tagger{
+		tags:         tags,
+		hostnameTag:  hostnameTag,
+		processedTag: processedTag,
+	}
+	if hostnameTag != "" {
+		hostname, err := os.Hostname()
+		if err != nil {
+			return nil, fmt.Errorf("unable to get the hostname: %v", err)
+		}
+		tagger.hostname = hostname
+	}
+	return tagger, nil
+}
+
+// AddTags attaches the tags to the event from the previous function, which is either an Event or a TaggedEvent
+// whose tags are kept. The processing time is formatted as RFC 3339 with nanoseconds. Dynamic tags replace static
+// tags of the same name.
+// This function returns a TaggedEvent
+func (tagger *Tagger) AddTags(edgexcontext *appcontext.Context, params ...interface{}) (bool, interface{}) {
+	if len(params) < 1 {
+		return false, errors.New("No Event Received")
+	}
+
+	var tagged TaggedEvent
+	switch event := params[0].(type) {
+	case models.Event:
+		tagged.Event = event
+	case TaggedEvent:
+		tagged.Event = event.Event
+		tagged.Tags = event.Tags
+	default:
+		return false, errors.New("Unexpected type received, expecting models.Event")
+	}
+
+	tags := make(map[string]string, len(tagged.Tags)+len(tagger.tags)+2)
+	for name, value := range tagged.Tags {
+		tags[name] = value
+	}
+	for name, value := range tagger.tags {
+		tags[name] = value
+	}
+	if tagger.hostnameTag != "" {
+		tags[tagger.hostnameTag] = tagger.hostname
+	}
+	if tagger.processedTag != "" {
+		tags[tagger.processedTag] = time.Now().UTC().Format(time.RFC3339Nano)
+	}
+	tagged.Tags = tags
+
+	edgexcontext.LoggingClient.Debug("Tags added to event", "device", tagged.Device)
+	return true, tagged
+}
+
+// MarshalJSON encodes the event with an additional "tags" object
+func (tagged TaggedEvent) MarshalJSON() ([]byte, error) {
+	event, err := json.Marshal(tagged.Event)
+	if err != nil {
+		return nil, err
+	}
+	tags, err := json.Marshal(tagged.Tags)
+	if err != nil {
+		return nil, err
+	}
+	if len(event) < 2 || event[0] != '{' || event[len(event)-1] != '}' {
+		return nil, errors.New("unexpected encoding of the event")
+	}
+
+	encoded := append([]byte{}, event[:len(event)-1]...)
+	if len(event) > 2 {
+		encoded = append(encoded, ',')
+	}
+	encoded = append(encoded, `"tags":`...)
+	encoded = append(encoded, tags...)
+	return append(encoded, '}'), nil
+}
+
+// UnmarshalJSON decodes the event and its "tags" object
+func (tagged *TaggedEvent) UnmarshalJSON(data []byte) error {
+	var tags struct {
+		Tags map[string]string `json:"tags"`
+	}
+	if err := json.Unmarshal(data, &tags); err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, &tagged.Event); err != nil {
+		return err
+	}
+	tagged.Tags = tags.Tags
+	return nil
+}
+
+// taggedEventXML is the XML encoding of a TaggedEvent, the Event element with an additional Tags element
+type taggedEventXML struct {
+	XMLName xml.Name `xml:"Event"`
+	models.Event
+	Tags []tagXML `xml:"Tags>Tag"`
+}
+
+type tagXML struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:",chardata"`
+}
+
+// MarshalXML encodes the event with an additional Tags element holding a Tag element per tag, ordered by name
+func (tagged TaggedEvent) MarshalXML(encoder *xml.Encoder, start xml.StartElement) error {
+	encoded := taggedEventXML{Event: tagged.Event}
+	for name, value := range tagged.Tags {
+		encoded.Tags = append(encoded.Tags, tagXML{Name: name, Value: value})
+	}
+	sort.Slice(encoded.Tags, func(i, j int) bool { return encoded.Tags[i].Name < encoded.Tags[j].Name })
+	return encoder.Encode(encoded)
+}
@@ -0,0 +1,97 @@
+//
+// Copyright (c) 2019 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAddTags(t *testing.T) {
+	tagger, err := NewTagger(map[string]string{"site": "plant-1", "gps": "45.50,-73.56"}, "gateway", "processed")
+	require.NoError(t, err)
+
+	event := models.Event{Device: devID1, Readings: []models.Reading{{Name: readingName1, Value: readingValue1}}}
+	continuePipeline, result := tagger.AddTags(context, event)
+
+	require.True(t, continuePipeline, "Pipeline should continue")
+	tagged, ok := result.(TaggedEvent)
+	require.True(t, ok, "Result should be TaggedEvent")
+	assert.Equal(t, event, tagged.Event)
+	assert.Equal(t, "plant-1", tagged.Tags["site"])
+	assert.Equal(t, "45.50,-73.56", tagged.Tags["gps"])
+	hostname, _ := os.Hostname()
+	assert.Equal(t, hostname, tagged.Tags["gateway"])
+	processed, err := time.Parse(time.RFC3339Nano, tagged.Tags["processed"])
+	require.NoError(t, err)
+	assert.WithinDuration(t, time.Now(), processed, time.Minute)
+
+	retagger, err := NewTagger(map[string]string{"site": "plant-2", "line": "3"}, "", "")
+	require.NoError(t, err)
+	_, result = retagger.AddTags(context, tagged)
+	assert.Equal(t, "plant-2", result.(TaggedEvent).Tags["site"], "Tags should be replaced")
+	assert.Equal(t, "3", result.(TaggedEvent).Tags["line"])
+	assert.Equal(t, hostname, result.(TaggedEvent).Tags["gateway"], "Existing tags should be kept")
+	assert.Equal(t, "plant-1", tagged.Tags["site"], "Original tags should not be modified")
+}
+
+func TestAddTagsErrors(t *testing.T) {
+	tagger, err := NewTagger(nil, "", "")
+	require.NoError(t, err)
+
+	continuePipeline, result := tagger.AddTags(context)
+	assert.False(t, continuePipeline)
+	assert.EqualError(t, result.(error), "No Event Received")
+
+	continuePipeline, result = tagger.AddTags(context, "event")
+	assert.False(t, continuePipeline)
+	assert.EqualError(t, result.(error), "Unexpected type received, expecting models.Event")
+}
+
+func TestTaggedEventEncoding(t *testing.T) {
+	tagged := TaggedEvent{
+		Event: models.Event{Device: devID1, Readings: []models.Reading{{Name: readingName1, Value: readingValue1}}},
+		Tags:  map[string]string{"site": "plant-1", "gateway": "gw-7"},
+	}
+
+	encoded, err := json.Marshal(tagged)
+	require.NoError(t, err)
+	var decoded TaggedEvent
+	require.NoError(t, json.Unmarshal(encoded, &decoded))
+	assert.Equal(t, tagged.Tags, decoded.Tags)
+	assert.Equal(t, devID1, decoded.Device)
+	assert.Equal(t, readingValue1, decoded.Readings[0].Value)
+
+	encoded, err = json.Marshal(TaggedEvent{Tags: map[string]string{"site": "plant-1"}})
+	require.NoError(t, err)
+	assert.Equal(t, `{"tags":{"site":"plant-1"}}`, string(encoded))
+
+	continuePipeline, result := Conversion{}.TransformToJSON(context, tagged)
+	require.True(t, continuePipeline)
+	assert.Contains(t, result.(string), `"tags":{"gateway":"gw-7","site":"plant-1"}`)
+
+	continuePipeline, result = Conversion{}.TransformToXML(context, tagged)
+	require.True(t, continuePipeline)
+	assert.Contains(t, result.(string), "<Device>id1</Device>")
+	assert.Contains(t, result.(string), `<Tags><Tag name="gateway">gw-7</Tag><Tag name="site">plant-1</Tag></Tags></Event>`)
+}
@@ -0,0 +1,96 @@
+//
+// Copyright (c) 2019 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/antoniomtz/app-functions-sdk-go/appcontext"
+)
+
+// templateFunctions are available to payload templates in addition to the text/template builtins
+var templateFunctions = template.FuncMap{
+	"json": func(value interface{}) (string, error) {
+		b, err := json.Marshal(value)
+		return string(b), err
+	},
+	"join":  strings.Join,
+	"upper": strings.ToUpper,
+	"lower": strings.ToLower,
+	"formatTime": func(milliseconds int64, layout string) string {
+		return time.Unix(0, milliseconds*int64(time.Millisecond)).UTC().Format(layout)
+	},
+}
+
+// TemplateTransform renders the data of the pipeline through a text/template
+type TemplateTransform struct {
+	template *template.Template
+}
+
+// NewTemplateTransform creates a TemplateTransform for the text of a template. Besides the builtins, templates can
+// use json to encode a value as JSON, i.e. {{json .Device}} for a quoted and escaped string, join to join a list of
+// strings with a separator, upper and lower to change the case of a string, and formatTime to format a timestamp in
+// milliseconds, such as an Origin, with a Go time layout in UTC, i.e. {{formatTime .Origin "150405"}}.
+func NewTemplateTransform(text string) (*TemplateTransform, error) {
+	if strings.TrimSpace(text) == "" {
+		return nil, errors.New("template must be specified")
+	}
+	parsed, err := template.New("payload").Funcs(templateFunctions).Option("missingkey=zero").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid payload template: %v", err)
+	}
+	return &TemplateTransform{template: parsed}, nil
+}
+
+// NewTemplateTransformFromFile creates a TemplateTransform for the template read from file
+func NewTemplateTransformFromFile(file string) (*TemplateTransform, error) {
+	if file == "" {
+		return nil, errors.New("template file must be specified")
+	}
+	contents, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read template file: %v", err)
+	}
+	return NewTemplateTransform(string(contents))
+}
+
+// TransformWithTemplate executes the template with the data from the previous function, i.e. an Event, whose fields
+// are accessed as {{.Device}} and {{range .Readings}}...{{end}}. A []byte is passed to the template as a string.
+// This function returns a string
+func (t *TemplateTransform) TransformWithTemplate(edgexcontext *appcontext.Context, params ...interface{}) (bool, interface{}) {
+	if len(params) < 1 {
+		return false, errors.New("No Data Received")
+	}
+
+	data := params[0]
+	if b, ok := data.([]byte); ok {
+		data = string(b)
+	}
+
+	var buffer bytes.Buffer
+	if err := t.template.Execute(&buffer, data); err != nil {
+		return false, fmt.Errorf("unable to render payload template: %v", err)
+	}
+	return true, buffer.String()
+}
@@ -0,0 +1,105 @@
+//
+// Copyright (c) 2019 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransformWithTemplate(t *testing.T) {
+	event := models.Event{
+		Device: devID1,
+		Origin: 1559390400000,
+		Readings: []models.Reading{
+			{Name: "Temperature", Value: "21.5"},
+			{Name: "Humidity", Value: "40"},
+		},
+	}
+
+	tests := []struct {
+		Name     string
+		Template string
+		Expected string
+	}{
+		{"CSV", `{{range .Readings}}{{$.Device}},{{.Name}},{{.Value}}{{"\n"}}{{end}}`, "id1,Temperature,21.5\nid1,Humidity,40\n"},
+		{"JSON", `{"sensor":{{json .Device}},"values":{ {{- range $i, $r := .Readings}}{{if $i}},{{end}}{{json $r.Name}}:{{$r.Value}}{{end -}} }}`, `{"sensor":"id1","values":{"Temperature":21.5,"Humidity":40}}`},
+		{"Functions", `{{upper .Device}} {{lower (index .Readings 0).Name}} {{formatTime .Origin "2006-01-02T15:04"}}`, "ID1 temperature 2019-06-01T12:00"},
+	}
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			transform, err := NewTemplateTransform(test.Template)
+			require.NoError(t, err)
+
+			continuePipeline, result := transform.TransformWithTemplate(context, event)
+			require.True(t, continuePipeline, "%v", result)
+			assert.Equal(t, test.Expected, result)
+		})
+	}
+}
+
+func TestTransformWithTemplateBytes(t *testing.T) {
+	transform, err := NewTemplateTransform(`$GPTXT,{{.}}`)
+	require.NoError(t, err)
+
+	continuePipeline, result := transform.TransformWithTemplate(context, []byte("hello"))
+	require.True(t, continuePipeline)
+	assert.Equal(t, "$GPTXT,hello", result)
+}
+
+func TestTransformWithTemplateErrors(t *testing.T) {
+	_, err := NewTemplateTransform(" ")
+	assert.EqualError(t, err, "template must be specified")
+	_, err = NewTemplateTransform("{{.Device")
+	assert.Error(t, err)
+
+	transform, err := NewTemplateTransform("{{.Device.Name}}")
+	require.NoError(t, err)
+	continuePipeline, result := transform.TransformWithTemplate(context, models.Event{Device: devID1})
+	assert.False(t, continuePipeline)
+	assert.Error(t, result.(error))
+
+	continuePipeline, result = transform.TransformWithTemplate(context)
+	assert.False(t, continuePipeline)
+	assert.EqualError(t, result.(error), "No Data Received")
+}
+
+func TestNewTemplateTransformFromFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "template")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "payload.tmpl")
+	require.NoError(t, ioutil.WriteFile(file, []byte("{{.Device}}"), 0644))
+
+	transform, err := NewTemplateTransformFromFile(file)
+	require.NoError(t, err)
+	continuePipeline, result := transform.TransformWithTemplate(context, models.Event{Device: devID1})
+	require.True(t, continuePipeline)
+	assert.Equal(t, devID1, result)
+
+	_, err = NewTemplateTransformFromFile(filepath.Join(dir, "missing.tmpl"))
+	assert.Error(t, err)
+	_, err = NewTemplateTransformFromFile("")
+	assert.EqualError(t, err, "template file must be specified")
+}
@@ -0,0 +1,245 @@
+//
+// Copyright (c) 2019 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/antoniomtz/app-functions-sdk-go/appcontext"
+)
+
+// coerceToBytes converts data received from the previous function into a []byte. The data
+// must be of type string, []byte or implement json.Marshaler.
+func coerceToBytes(data interface{}) ([]byte, error) {
+	switch data.(type) {
+	case string:
+		return []byte(data.(string)), nil
+
+	case []byte:
+		return data.([]byte), nil
+
+	case json.Marshaler:
+		marshaled, err := data.(json.Marshaler).MarshalJSON()
+		if err != nil {
+			return nil, errors.New("Marshaling input data to JSON failed")
+		}
+		return marshaled, nil
+
+	default:
+		return nil, errors.New("Unexpected type received - passed in data must be of type []byte, string or implement json.Marshaler")
+	}
+}
+
+// CredentialsProvider provides the username and password each time an export function connects or authenticates to
+// its destination, i.e. when the MQTT client connects or reconnects to the broker, so that credentials which expire,
+// such as the JWTs used by Google Cloud IoT Core, or which are rotated in the secret store can be refreshed
+type CredentialsProvider interface {
+	Credentials() (username string, password string, err error)
+}
+
+// CredentialsProviderFunc is a function which implements CredentialsProvider
+type CredentialsProviderFunc func() (username string, password string, err error)
+
+// Credentials calls the function
+func (f CredentialsProviderFunc) Credentials() (string, string, error) {
+	return f()
+}
+
+// BatchFailureHandler is given the data of an event held in a batch when exporting the batch failed after the call
+// holding the data returned, along with the context of the event. It returns whether the data was stored to be
+// exported later, otherwise the data is kept to be exported with the next batch while no more than 10
+// batches of data are held.
+type BatchFailureHandler func(edgexcontext *appcontext.Context, data interface{}, err error) bool
+
+// maxHeldBatches is the number of batches worth of data held while exports fail without a failure handler storing
+// it, beyond which the data of the oldest events is dropped so an unreachable destination doesn't exhaust the memory
+const maxHeldBatches = 10
+
+// batchEntry is the data of an event held in a batch
+type batchEntry struct {
+	context *appcontext.Context
+	// data is the data the export function received, given to the failure handler when the batch fails
+	data interface{}
+	// payload is the data as prepared by the export function to be sent
+	payload interface{}
+}
+
+// exportBatch holds the data of events until size events are held, or timeout has elapsed since the first one was
+// held, and exports them with export. export is called without holding the mutex, and returns the number of leading
+// entries which were exported along with its error. The entries which failed are given to failed, or kept to be
+// exported with the next batch, up to maxHeldBatches batches of them.
+type exportBatch struct {
+	// name is the destination of the batch used in log messages
+	name    string
+	size    int
+	timeout time.Duration
+	export  func(entries []batchEntry) (int, error)
+	failed  BatchFailureHandler
+
+	mutex   sync.Mutex
+	entries []batchEntry
+	// exporting is the number of entries being exported
+	exporting int
+	timer     *time.Timer
+}
+
+// add holds the data of the event, exporting the batch once it is complete. It returns whether the batch holding the
+// data was exported, and the error of the export, in which case the data of the event is reported by the caller
+// through the error while the data of the other events is given to the failure handler.
+func (batch *exportBatch) add(edgexcontext *appcontext.Context, data interface{}, payload interface{}) (bool, error) {
+	batch.mutex.Lock()
+	batch.entries = append(batch.entries, batchEntry{context: edgexcontext, data: data, payload: payload})
+	if batch.size > 1 && len(batch.entries) < batch.size {
+		batch.armTimer()
+		edgexcontext.LoggingClient.Debug(fmt.Sprintf("Batched data for %s (%d/%d)", batch.name, len(batch.entries), batch.size))
+		batch.mutex.Unlock()
+		return false, nil
+	}
+	entries := batch.take()
+	batch.mutex.Unlock()
+
+	return true, batch.exportEntries(entries, edgexcontext)
+}
+
+// flush exports the incomplete batch, i.e. before the service stops
+func (batch *exportBatch) flush() error {
+	batch.mutex.Lock()
+	entries := batch.take()
+	batch.mutex.Unlock()
+
+	if len(entries) == 0 {
+		return nil
+	}
+	return batch.exportEntries(entries, nil)
+}
+
+// pending returns the number of events held or being exported, which have not been exported yet
+func (batch *exportBatch) pending() int {
+	batch.mutex.Lock()
+	defer batch.mutex.Unlock()
+	return len(batch.entries) + batch.exporting
+}
+
+// armTimer exports the incomplete batch once the timeout has elapsed. The caller must hold the mutex.
+func (batch *exportBatch) armTimer() {
+	if batch.timer != nil || batch.timeout <= 0 {
+		return
+	}
+	batch.timer = time.AfterFunc(batch.timeout, func() {
+		batch.mutex.Lock()
+		batch.timer = nil
+		entries := batch.take()
+		batch.mutex.Unlock()
+
+		if len(entries) == 0 {
+			return
+		}
+		if err := batch.exportEntries(entries, nil); err != nil {
+			entries[0].context.LoggingClient.Error(fmt.Sprintf("Failed to export batch to %s: %s", batch.name, err.Error()))
+		}
+	})
+}
+
+// take returns the held entries, which are then being exported. The caller must hold the mutex.
+func (batch *exportBatch) take() []batchEntry {
+	if batch.timer != nil {
+		batch.timer.Stop()
+		batch.timer = nil
+	}
+	entries := batch.entries
+	batch.entries = nil
+	batch.exporting += len(entries)
+	return entries
+}
+
+// exportEntries exports the entries and marks the events exported as pushed. current is the context of the event
+// whose call is exporting the batch, which is the last entry, and is nil when the batch is exported after the calls
+// holding its entries returned.
+func (batch *exportBatch) exportEntries(entries []batchEntry, current *appcontext.Context) error {
+	exported, err := batch.export(entries)
+	if exported > len(entries) {
+		exported = len(entries)
+	}
+
+	if exported > 0 {
+		// the pipelines of the other events have returned, so their deadline has passed or their context is cancelled
+		edgexcontext := current
+		if edgexcontext == nil || err != nil {
+			edgexcontext = entries[0].context.Detached()
+		}
+		events := make([]appcontext.EventReference, 0, exported)
+		for _, entry := range entries[:exported] {
+			events = append(events, entry.context.EventReference())
+		}
+		markBatchAsPushed(edgexcontext, events)
+	}
+
+	var kept []batchEntry
+	if err != nil {
+		for _, entry := range entries[exported:] {
+			if current != nil && entry.context == current {
+				continue
+			}
+			if batch.failed == nil || !batch.failed(entry.context, entry.data, err) {
+				kept = append(kept, entry)
+			}
+		}
+	}
+
+	dropped := 0
+	batch.mutex.Lock()
+	batch.exporting -= len(entries)
+	if len(kept) > 0 {
+		batch.entries = append(kept, batch.entries...)
+		if dropped = len(batch.entries) - batch.maxHeld(); dropped > 0 {
+			batch.entries = batch.entries[dropped:]
+		}
+		batch.armTimer()
+	}
+	batch.mutex.Unlock()
+
+	if dropped > 0 {
+		kept[0].context.LoggingClient.Error(fmt.Sprintf("Dropped the data of %d events which failed to be exported to %s, at most %d events are held: %s", dropped, batch.name, batch.maxHeld(), err.Error()))
+	}
+	return err
+}
+
+// maxHeld returns the number of events held at most, including those kept after failing to be exported
+func (batch *exportBatch) maxHeld() int {
+	if batch.size > 1 {
+		return batch.size * maxHeldBatches
+	}
+	return maxHeldBatches
+}
+
+// markBatchAsPushed marks the events of an exported batch as pushed using the context of one of the events
+func markBatchAsPushed(edgexcontext *appcontext.Context, events []appcontext.EventReference) {
+	current := edgexcontext.EventReference()
+	for _, event := range events {
+		if event != current {
+			edgexcontext.AddBatchedEvents(event)
+		}
+	}
+
+	if err := edgexcontext.MarkAsPushed(); err != nil {
+		edgexcontext.LoggingClient.Error(err.Error())
+	}
+}
@@ -0,0 +1,43 @@
+//
+// Copyright (c) 2019 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExportBatchDropsOldestDataBeyondLimit(t *testing.T) {
+	batch := exportBatch{name: "test", size: 2, export: func(entries []batchEntry) (int, error) {
+		return 0, errors.New("destination unreachable")
+	}}
+
+	for i := 0; i < 50; i++ {
+		batch.mutex.Lock()
+		batch.entries = append(batch.entries, batchEntry{context: context, data: i})
+		entries := batch.take()
+		batch.mutex.Unlock()
+		// exported by the timer, so every entry is kept
+		assert.Error(t, batch.exportEntries(entries, nil))
+	}
+
+	assert.Equal(t, 2*maxHeldBatches, batch.pending(), "the data held should be capped")
+	assert.Equal(t, 50-2*maxHeldBatches, batch.entries[0].data, "the data of the oldest events should be dropped")
+	assert.Equal(t, 49, batch.entries[len(batch.entries)-1].data)
+}
@@ -0,0 +1,188 @@
+//
+// Copyright (c) 2019 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/antoniomtz/app-functions-sdk-go/appcontext"
+	"github.com/edgexfoundry/go-mod-core-contracts/models"
+)
+
+// comparisonOperators are the supported operators, longest first so ">=" isn't parsed as ">"
+var comparisonOperators = []string{">=", "<=", "==", "!=", ">", "<"}
+
+// ValueComparison compares a reading value against a number, i.e. "> 80"
+type ValueComparison struct {
+	// Operator is one of >, >=, <, <=, == or !=
+	Operator string
+	Value    float64
+}
+
+// ValueCondition is the condition the readings of a value descriptor must satisfy to be passed on
+type ValueCondition struct {
+	// Min and Max bound the value, inclusively, when set
+	Min *float64
+	Max *float64
+	// Comparisons must all be satisfied by the value
+	Comparisons []ValueComparison
+}
+
+// ValueFilter houses the condition of each value descriptor, keyed by value descriptor name
+type ValueFilter struct {
+	Readings map[string]ValueCondition
+}
+
+// ParseValueCondition parses a condition made of comma separated settings, each either a bound, "Min=-40" or
+// "Max=120", or a comparison, i.e. "> 80" or "!= 0". All of the settings must be satisfied.
+func ParseValueCondition(value string) (ValueCondition, error) {
+	condition := ValueCondition{}
+	for _, setting := range strings.Split(value, ",") {
+		setting = strings.TrimSpace(setting)
+		if setting == "" {
+			continue
+		}
+
+		if comparison, ok, err := parseValueComparison(setting); ok {
+			if err != nil {
+				return ValueCondition{}, err
+			}
+			condition.Comparisons = append(condition.Comparisons, comparison)
+			continue
+		}
+
+		parts := strings.SplitN(setting, "=", 2)
+		if len(parts) != 2 {
+			return ValueCondition{}, fmt.Errorf("invalid setting '%s', expected <name>=<value> or <operator> <value>", setting)
+		}
+		name, settingValue := strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1])
+
+		number, err := strconv.ParseFloat(settingValue, 64)
+		if err != nil {
+			return ValueCondition{}, fmt.Errorf("%s must be a number, got '%s'", name, settingValue)
+		}
+		switch name {
+		case "Min":
+			condition.Min = &number
+		case "Max":
+			condition.Max = &number
+		default:
+			return ValueCondition{}, fmt.Errorf("unknown setting '%s', expected Min, Max or a comparison", name)
+		}
+	}
+
+	if condition.Min == nil && condition.Max == nil && len(condition.Comparisons) == 0 {
+		return ValueCondition{}, errors.New("a Min, Max or comparison must be specified")
+	}
+	if condition.Min != nil && condition.Max != nil && *condition.Min > *condition.Max {
+		return ValueCondition{}, fmt.Errorf("Min %g is greater than Max %g", *condition.Min, *condition.Max)
+	}
+	return condition, nil
+}
+
+// parseValueComparison parses a setting starting with a comparison operator, reporting whether it does
+func parseValueComparison(setting string) (ValueComparison, bool, error) {
+	for _, operator := range comparisonOperators {
+		if !strings.HasPrefix(setting, operator) {
+			continue
+		}
+		operand := strings.TrimSpace(strings.TrimPrefix(setting, operator))
+		number, err := strconv.ParseFloat(operand, 64)
+		if err != nil {
+			return ValueComparison{}, true, fmt.Errorf("%s must be followed by a number, got '%s'", operator, operand)
+		}
+		return ValueComparison{Operator: operator, Value: number}, true, nil
+	}
+	return ValueComparison{}, false, nil
+}
+
+// Matches reports whether the value satisfies the comparison
+func (comparison ValueComparison) Matches(value float64) bool {
+	switch comparison.Operator {
+	case ">":
+		return value > comparison.Value
+	case ">=":
+		return value >= comparison.Value
+	case "<":
+		return value < comparison.Value
+	case "<=":
+		return value <= comparison.Value
+	case "==":
+		return value == comparison.Value
+	case "!=":
+		return value != comparison.Value
+	}
+	return false
+}
+
+// Matches reports whether the value is within the bounds and satisfies every comparison
+func (condition ValueCondition) Matches(value float64) bool {
+	if condition.Min != nil && value < *condition.Min {
+		return false
+	}
+	if condition.Max != nil && value > *condition.Max {
+		return false
+	}
+	for _, comparison := range condition.Comparisons {
+		if !comparison.Matches(value) {
+			return false
+		}
+	}
+	return true
+}
+
+// FilterByValue removes the readings whose value doesn't satisfy the condition of their value descriptor. A reading
+// to be filtered which doesn't have a numeric value is removed as well. Readings of other value descriptors are
+// passed through unchanged. If no readings remain the pipeline execution will stop, otherwise the filtered Event is
+// returned.
+func (f ValueFilter) FilterByValue(edgexcontext *appcontext.Context, params ...interface{}) (continuePipeline bool, result interface{}) {
+	if len(params) < 1 {
+		return false, errors.New("No Event Received")
+	}
+
+	edgexcontext.LoggingClient.Debug("Filter by Value")
+
+	event, ok := params[0].(models.Event)
+	if !ok {
+		return false, errors.New("Unexpected type received, expecting models.Event")
+	}
+
+	readings := []models.Reading{}
+	for _, reading := range event.Readings {
+		if condition, ok := f.Readings[reading.Name]; ok {
+			value, err := strconv.ParseFloat(strings.TrimSpace(reading.Value), 64)
+			if err != nil {
+				edgexcontext.LoggingClient.Debug(fmt.Sprintf("Reading '%s' removed, value '%s' is not a number", reading.Name, reading.Value))
+				continue
+			}
+			if !condition.Matches(value) {
+				continue
+			}
+		}
+		readings = append(readings, reading)
+	}
+
+	if len(readings) == 0 {
+		return false, nil
+	}
+	event.Readings = readings
+
+	return true, event
+}
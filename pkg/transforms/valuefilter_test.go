@@ -0,0 +1,130 @@
+//
+// Copyright (c) 2019 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	"testing"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFilterByValue(t *testing.T) {
+	min := 0.0
+	max := 100.0
+	eventIn := models.Event{
+		Device: devID1,
+		Readings: []models.Reading{
+			{Name: "Temperature", Value: "85.5"},
+			{Name: "Temperature", Value: "72"},
+			{Name: "Temperature", Value: "warm"},
+			{Name: "Humidity", Value: " 45 "},
+			{Name: "Humidity", Value: "120"},
+			{Name: readingName1, Value: readingValue1},
+		},
+	}
+
+	filter := ValueFilter{Readings: map[string]ValueCondition{
+		"Temperature": {Comparisons: []ValueComparison{{Operator: ">", Value: 80}}},
+		"Humidity":    {Min: &min, Max: &max},
+	}}
+	continuePipeline, result := filter.FilterByValue(context, eventIn)
+
+	assert.True(t, continuePipeline, "Pipeline should continue")
+	eventOut, ok := result.(models.Event)
+	require.True(t, ok, "Result should be models.Event")
+	require.Len(t, eventOut.Readings, 3)
+	assert.Equal(t, "85.5", eventOut.Readings[0].Value)
+	assert.Equal(t, " 45 ", eventOut.Readings[1].Value)
+	assert.Equal(t, readingValue1, eventOut.Readings[2].Value, "Unknown reading should be passed through")
+	assert.Len(t, eventIn.Readings, 6, "Original event should not be modified")
+}
+
+func TestFilterByValueNoMatch(t *testing.T) {
+	filter := ValueFilter{Readings: map[string]ValueCondition{
+		"Temperature": {Comparisons: []ValueComparison{{Operator: ">", Value: 80}}},
+	}}
+	continuePipeline, result := filter.FilterByValue(context, models.Event{
+		Readings: []models.Reading{{Name: "Temperature", Value: "21.5"}},
+	})
+
+	assert.False(t, continuePipeline, "Pipeline should stop")
+	assert.Nil(t, result)
+}
+
+func TestFilterByValueNoParameters(t *testing.T) {
+	filter := ValueFilter{}
+	continuePipeline, result := filter.FilterByValue(context)
+
+	assert.False(t, continuePipeline, "Pipeline should stop")
+	assert.EqualError(t, result.(error), "No Event Received")
+}
+
+func TestParseValueCondition(t *testing.T) {
+	condition, err := ParseValueCondition("Min=-40, Max=120, != 0")
+	require.NoError(t, err)
+	assert.Equal(t, -40.0, *condition.Min)
+	assert.Equal(t, 120.0, *condition.Max)
+	assert.Equal(t, []ValueComparison{{Operator: "!=", Value: 0}}, condition.Comparisons)
+	assert.True(t, condition.Matches(-40))
+	assert.False(t, condition.Matches(0))
+	assert.False(t, condition.Matches(120.5))
+
+	tests := []struct {
+		operator string
+		matching float64
+		other    float64
+	}{
+		{">", 81, 80},
+		{">=", 80, 79.9},
+		{"<", 79, 80},
+		{"<=", 80, 80.1},
+		{"==", 80, 81},
+		{"!=", 81, 80},
+	}
+	for _, test := range tests {
+		t.Run(test.operator, func(t *testing.T) {
+			condition, err := ParseValueCondition(test.operator + "80")
+			require.NoError(t, err)
+			assert.Equal(t, test.operator, condition.Comparisons[0].Operator)
+			assert.True(t, condition.Matches(test.matching))
+			assert.False(t, condition.Matches(test.other))
+		})
+	}
+}
+
+func TestParseValueConditionErrors(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected string
+	}{
+		{"", "a Min, Max or comparison must be specified"},
+		{"Min", "invalid setting 'Min', expected <name>=<value> or <operator> <value>"},
+		{"Max=hot", "Max must be a number, got 'hot'"},
+		{"> hot", "> must be followed by a number, got 'hot'"},
+		{"Above=80", "unknown setting 'Above', expected Min, Max or a comparison"},
+		{"Min=10, Max=0", "Min 10 is greater than Max 0"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.value, func(t *testing.T) {
+			_, err := ParseValueCondition(test.value)
+			assert.EqualError(t, err, test.expected)
+		})
+	}
+}
@@ -0,0 +1,184 @@
+//
+// Copyright (c) 2019 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"sync"
+
+	"github.com/antoniomtz/app-functions-sdk-go/appcontext"
+	"github.com/go-interpreter/wagon/exec"
+	"github.com/go-interpreter/wagon/wasm"
+)
+
+// WASMTransform calls a function exported by a WebAssembly module with the serialized data of the pipeline, so custom
+// logic can be shipped as a sandboxed plugin. The module can't import any function, so it has no access to the host.
+// The module must export its memory, named memory, an alloc function, (i32 size) -> i32 pointer, which returns
+// where a buffer of size bytes can be written in the memory, and the function called, (i32 pointer, i32 size) -> i64,
+// which returns the pointer to the transformed bytes in the upper 32 bits and their size in the lower 32 bits, or
+// zero to stop the pipeline. The globals of the module are reset before each call. Data is processed one at a time.
+type WASMTransform struct {
+	mutex    sync.Mutex
+	vm       *exec.VM
+	alloc    int64
+	function int64
+}
+
+// NewWASMTransform creates a WASMTransform calling the function of the WebAssembly module
+func NewWASMTransform(module []byte, function string) (*WASMTransform, error) {
+	if function == "" {
+		return nil, errors.New("WebAssembly function must be specified")
+	}
+
+	parsed, err := wasm.ReadModule(bytes.NewReader(module), func(name string) (*wasm.Module, error) {
+		return nil, fmt.Errorf("module imports '%s', WebAssembly modules can't import other modules", name)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("invalid WebAssembly module: %v", err)
+	}
+	if parsed.Import != nil && len(parsed.Import.Entries) > 0 {
+		return nil, errors.New("invalid WebAssembly module: modules can't import functions")
+	}
+
+	exports := map[string]wasm.ExportEntry{}
+	if parsed.Export != nil {
+		exports = parsed.Export.Entries
+	}
+	if export, ok := exports["memory"]; !ok || export.Kind != wasm.ExternalMemory {
+		return nil, errors.New("WebAssembly module must export its memory")
+	}
+	alloc, err := exportedFunction(parsed, exports, "alloc", []wasm.ValueType{wasm.ValueTypeI32}, wasm.ValueTypeI32)
+	if err != nil {
+		return nil, err
+	}
+	called, err := exportedFunction(parsed, exports, function, []wasm.ValueType{wasm.ValueTypeI32, wasm.ValueTypeI32}, wasm.ValueTypeI64)
+	if err != nil {
+		return nil, err
+	}
+
+	vm, err := exec.NewVM(parsed)
+	if err != nil {
+		return nil, fmt.Errorf("unable to instantiate WebAssembly module: %v", err)
+	}
+	vm.RecoverPanic = true
+
+	return &WASMTransform{vm: vm, alloc: alloc, function: called}, nil
+}
+
+// NewWASMTransformFromFile creates a WASMTransform calling the function of the WebAssembly module read from file
+func NewWASMTransformFromFile(file string, function string) (*WASMTransform, error) {
+	if file == "" {
+		return nil, errors.New("WebAssembly module file must be specified")
+	}
+	contents, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read WebAssembly module file: %v", err)
+	}
+	return NewWASMTransform(contents, function)
+}
+
+// exportedFunction returns the index of the function exported as name, checking its signature
+func exportedFunction(module *wasm.Module, exports map[string]wasm.ExportEntry, name string, params []wasm.ValueType, result wasm.ValueType) (int64, error) {
+	export, ok := exports[name]
+	if !ok || export.Kind != wasm.ExternalFunction {
+		return 0, fmt.Errorf("WebAssembly module must export a function named %s", name)
+	}
+	signature := module.GetFunction(int(export.Index)).Sig
+	matches := len(signature.ReturnTypes) == 1 && signature.ReturnTypes[0] == result && len(signature.ParamTypes) == len(params)
+	for i := 0; matches && i < len(params); i++ {
+		matches = signature.ParamTypes[i] == params[i]
+	}
+	if !matches {
+		return 0, fmt.Errorf("WebAssembly function %s must have the signature %v -> %s", name, params, result)
+	}
+	return int64(export.Index), nil
+}
+
+// TransformWithWASM calls the function of the module with the data from the previous function, an Event or a
+// TaggedEvent serialized as JSON, or a string or []byte passed as is.
+// This function returns a []byte, stops the pipeline when the function returns zero, and returns an error when the
+// function fails.
+func (w *WASMTransform) TransformWithWASM(edgexcontext *appcontext.Context, params ...interface{}) (bool, interface{}) {
+	if len(params) < 1 {
+		return false, errors.New("No Data Received")
+	}
+
+	var input []byte
+	switch data := params[0].(type) {
+	case []byte:
+		input = data
+	case string:
+		input = []byte(data)
+	default:
+		event, ok := eventOrTaggedEvent(data)
+		if !ok {
+			return false, errors.New("Unexpected type received, expecting models.Event, string or []byte")
+		}
+		var err error
+		if input, err = json.Marshal(event); err != nil {
+			return false, fmt.Errorf("unable to marshal event for WebAssembly module: %v", err)
+		}
+	}
+
+	output, err := w.call(input)
+	if err != nil {
+		return false, fmt.Errorf("WebAssembly transform failed: %v", err)
+	}
+	if output == nil {
+		return false, nil
+	}
+	return true, output
+}
+
+// call copies the input to the memory of the module and calls the function, returning a copy of its result
+func (w *WASMTransform) call(input []byte) ([]byte, error) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	w.vm.Restart()
+
+	result, err := w.vm.ExecCode(w.alloc, uint64(len(input)))
+	if err != nil {
+		return nil, err
+	}
+	pointer := uint64(result.(uint32))
+	memory := w.vm.Memory()
+	if pointer+uint64(len(input)) > uint64(len(memory)) {
+		return nil, fmt.Errorf("alloc returned %d, out of the bounds of memory", pointer)
+	}
+	copy(memory[pointer:], input)
+
+	if result, err = w.vm.ExecCode(w.function, pointer, uint64(len(input))); err != nil {
+		return nil, err
+	}
+	packed := result.(uint64)
+	if packed == 0 {
+		return nil, nil
+	}
+	pointer, size := packed>>32, packed&0xffffffff
+	memory = w.vm.Memory()
+	if pointer+size > uint64(len(memory)) {
+		return nil, fmt.Errorf("result of %d bytes at %d is out of the bounds of memory", size, pointer)
+	}
+	output := make([]byte, size)
+	copy(output, memory[pointer:])
+	return output, nil
+}
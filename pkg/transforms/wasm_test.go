@@ -0,0 +1,129 @@
+//
+// Copyright (c) 2019 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// echoModule is a WebAssembly module exporting its memory, a bump allocator starting at 1024 named alloc, a
+// function named transform returning its input, and a function named stop returning zero
+var echoModule = []byte{
+	0x00, 0x61, 0x73, 0x6d, 0x01, 0x00, 0x00, 0x00,
+	// types: (i32) -> i32, (i32, i32) -> i64
+	0x01, 0x0c, 0x02, 0x60, 0x01, 0x7f, 0x01, 0x7f, 0x60, 0x02, 0x7f, 0x7f, 0x01, 0x7e,
+	// functions: alloc, transform, stop
+	0x03, 0x04, 0x03, 0x00, 0x01, 0x01,
+	// memory of one page
+	0x05, 0x03, 0x01, 0x00, 0x01,
+	// mutable global heap pointer = 1024
+	0x06, 0x07, 0x01, 0x7f, 0x01, 0x41, 0x80, 0x08, 0x0b,
+	// exports
+	0x07, 0x25, 0x04,
+	0x06, 'm', 'e', 'm', 'o', 'r', 'y', 0x02, 0x00,
+	0x05, 'a', 'l', 'l', 'o', 'c', 0x00, 0x00,
+	0x09, 't', 'r', 'a', 'n', 's', 'f', 'o', 'r', 'm', 0x00, 0x01,
+	0x04, 's', 't', 'o', 'p', 0x00, 0x02,
+	// code
+	0x0a, 0x1f, 0x03,
+	// alloc: heap; heap += size
+	0x0b, 0x00, 0x23, 0x00, 0x23, 0x00, 0x20, 0x00, 0x6a, 0x24, 0x00, 0x0b,
+	// transform: pointer << 32 | size
+	0x0c, 0x00, 0x20, 0x00, 0xad, 0x42, 0x20, 0x86, 0x20, 0x01, 0xad, 0x84, 0x0b,
+	// stop: 0
+	0x04, 0x00, 0x42, 0x00, 0x0b,
+}
+
+func TestTransformWithWASM(t *testing.T) {
+	w, err := NewWASMTransform(echoModule, "transform")
+	require.NoError(t, err)
+
+	event := models.Event{Device: devID1, Readings: []models.Reading{{Name: readingName1, Value: readingValue1}}}
+	expected, err := json.Marshal(event)
+	require.NoError(t, err)
+
+	continuePipeline, result := w.TransformWithWASM(context, event)
+	require.True(t, continuePipeline, "%v", result)
+	assert.Equal(t, expected, result)
+
+	for i := 0; i < 3; i++ {
+		continuePipeline, result = w.TransformWithWASM(context, "payload")
+		require.True(t, continuePipeline, "%v", result)
+		assert.Equal(t, []byte("payload"), result)
+	}
+
+	continuePipeline, result = w.TransformWithWASM(context, 42)
+	assert.False(t, continuePipeline)
+	assert.EqualError(t, result.(error), "Unexpected type received, expecting models.Event, string or []byte")
+}
+
+func TestTransformWithWASMStop(t *testing.T) {
+	w, err := NewWASMTransform(echoModule, "stop")
+	require.NoError(t, err)
+
+	continuePipeline, result := w.TransformWithWASM(context, []byte("payload"))
+	assert.False(t, continuePipeline)
+	assert.Nil(t, result)
+}
+
+func TestTransformWithWASMOutOfBounds(t *testing.T) {
+	w, err := NewWASMTransform(echoModule, "transform")
+	require.NoError(t, err)
+
+	continuePipeline, result := w.TransformWithWASM(context, make([]byte, 65536))
+	assert.False(t, continuePipeline)
+	assert.EqualError(t, result.(error), "WebAssembly transform failed: alloc returned 1024, out of the bounds of memory")
+}
+
+func TestNewWASMTransformErrors(t *testing.T) {
+	_, err := NewWASMTransform(echoModule, "")
+	assert.EqualError(t, err, "WebAssembly function must be specified")
+	_, err = NewWASMTransform([]byte("not wasm"), "transform")
+	assert.Error(t, err)
+	_, err = NewWASMTransform(echoModule, "missing")
+	assert.EqualError(t, err, "WebAssembly module must export a function named missing")
+	_, err = NewWASMTransform(echoModule, "alloc")
+	assert.EqualError(t, err, "WebAssembly function alloc must have the signature [i32 i32] -> i64")
+}
+
+func TestNewWASMTransformFromFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "wasm")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "echo.wasm")
+	require.NoError(t, ioutil.WriteFile(file, echoModule, 0644))
+
+	w, err := NewWASMTransformFromFile(file, "transform")
+	require.NoError(t, err)
+	continuePipeline, result := w.TransformWithWASM(context, "payload")
+	require.True(t, continuePipeline)
+	assert.Equal(t, []byte("payload"), result)
+
+	_, err = NewWASMTransformFromFile(filepath.Join(dir, "missing.wasm"), "transform")
+	assert.Error(t, err)
+	_, err = NewWASMTransformFromFile("", "transform")
+	assert.EqualError(t, err, "WebAssembly module file must be specified")
+}